@@ -592,11 +592,72 @@ func (hs *HTTPServer) registerRoutes() {
 			// POST influx line protocol.
 			liveRoute.Post("/push/:streamId", hs.LivePushGateway.Handle)
 
+			// POST data frames in JSON frame format directly to a pipeline channel rule.
+			liveRoute.Post("/pipeline/push-frames/*", reqOrgAdmin, hs.LivePushGateway.HandlePipelinePushFrames)
+
+			// Manage pipeline channel rules of the current organization.
+			liveRoute.Get("/pipeline/rules", reqOrgAdmin, routing.Wrap(hs.Live.HandleChannelRulesListHTTP))
+			liveRoute.Post("/pipeline/rules", reqOrgAdmin, routing.Wrap(hs.Live.HandleChannelRulesPostHTTP))
+			liveRoute.Put("/pipeline/rules", reqOrgAdmin, routing.Wrap(hs.Live.HandleChannelRulesPutHTTP))
+			liveRoute.Delete("/pipeline/rules", reqOrgAdmin, routing.Wrap(hs.Live.HandleChannelRulesDeleteHTTP))
+
+			// Reload live channel rules file, rules are also reloaded on file changes and SIGHUP.
+			liveRoute.Post("/pipeline/rules/reload", reqGrafanaAdmin, routing.Wrap(hs.Live.HandlePipelineRulesReloadHTTP))
+
+			// Manage remote write backends of the current organization, the catalog lists
+			// backends rules can reference by name, including ones shared by other organizations.
+			liveRoute.Get("/pipeline/write-configs", reqOrgAdmin, routing.Wrap(hs.Live.HandleWriteConfigsListHTTP))
+			liveRoute.Post("/pipeline/write-configs", reqOrgAdmin, routing.Wrap(hs.Live.HandleWriteConfigsPostHTTP))
+			liveRoute.Put("/pipeline/write-configs", reqOrgAdmin, routing.Wrap(hs.Live.HandleWriteConfigsPutHTTP))
+			liveRoute.Delete("/pipeline/write-configs", reqOrgAdmin, routing.Wrap(hs.Live.HandleWriteConfigsDeleteHTTP))
+			liveRoute.Get("/pipeline/write-configs/catalog", routing.Wrap(hs.Live.HandleWriteConfigsCatalogHTTP))
+
+			// Preview rules a channel resolves to.
+			liveRoute.Get("/pipeline/match", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineRuleMatchHTTP))
+
+			// Run a payload through a rule definition without executing outputs.
+			liveRoute.Post("/pipeline/rules/dry-run", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineRuleDryRunHTTP))
+
+			// Run sample payloads attached to pipeline rules through the rules.
+			liveRoute.Post("/pipeline/samples/test", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineSamplesTestHTTP))
+
+			// Check pipeline rules for unreachable rules, unknown backends and never true conditions.
+			liveRoute.Post("/pipeline/lint", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineLintHTTP))
+
+			// Share pipeline rules as YAML bundles with referenced write configs.
+			liveRoute.Get("/pipeline/export", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineExportHTTP))
+			liveRoute.Post("/pipeline/import", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineImportHTTP))
+
+			// Inspect, purge and replay pipeline failures kept in the dead-letter queue.
+			liveRoute.Get("/pipeline/dead-letters", reqOrgAdmin, routing.Wrap(hs.Live.HandleDeadLettersListHTTP))
+			liveRoute.Delete("/pipeline/dead-letters", reqOrgAdmin, routing.Wrap(hs.Live.HandleDeadLettersDeleteHTTP))
+			liveRoute.Post("/pipeline/dead-letters/replay", reqOrgAdmin, routing.Wrap(hs.Live.HandleDeadLettersReplayHTTP))
+			liveRoute.Get("/pipeline/debug-frames", reqOrgAdmin, routing.Wrap(hs.Live.HandleDebugFramesHTTP))
+
+			// Follow a pipeline message by the ID returned in X-Grafana-Live-Message-Id headers.
+			liveRoute.Get("/pipeline/messages/:messageId", reqOrgAdmin, routing.Wrap(hs.Live.HandlePipelineMessageTraceHTTP))
+
+			// Health of remote write backends, to tell failures of one target from another.
+			liveRoute.Get("/pipeline/remote-write/health", reqOrgAdmin, routing.Wrap(hs.Live.HandleRemoteWriteHealthHTTP))
+
+			// CPU profile of a pipeline rule with pprof labels per stage.
+			liveRoute.Get("/pipeline/profile", reqGrafanaAdmin, routing.Wrap(hs.Live.HandlePipelineProfileHTTP))
+
 			// List available streams and fields
 			liveRoute.Get("/list", routing.Wrap(hs.Live.HandleListHTTP))
 
+			// Replay frames of a managed stream across history tiers.
+			liveRoute.Get("/history", routing.Wrap(hs.Live.HandleHistoryHTTP))
+
+			// Managed stream channel activity, most active and idle channels
+			liveRoute.Get("/usage", reqOrgAdmin, routing.Wrap(hs.Live.HandleChannelUsageHTTP))
+
 			// Some channels may have info
 			liveRoute.Get("/info/*", routing.Wrap(hs.Live.HandleInfoHTTP))
+
+			// Active channel subscribers and force-disconnect
+			liveRoute.Get("/subscriptions", reqOrgAdmin, routing.Wrap(hs.Live.HandleSubscriptionsListHTTP))
+			liveRoute.Post("/subscriptions/disconnect", reqOrgAdmin, routing.Wrap(hs.Live.HandleSubscriptionsDisconnectHTTP))
 		})
 
 		// short urls
@@ -225,6 +225,20 @@ grafana-cli user-manager conflicts ingest-file <filepath>
 	},
 }
 
+var liveCommands = []*cli.Command{
+	{
+		Name:   "validate-rules",
+		Usage:  "validate-rules <rules file or directory>",
+		Action: runPluginCommand(validateLiveRulesCommand),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "write-configs",
+				Usage: "Path to write-configs.json file to check references to write configs",
+			},
+		},
+	},
+}
+
 var Commands = []*cli.Command{
 	{
 		Name:        "plugins",
@@ -236,4 +250,9 @@ var Commands = []*cli.Command{
 		Usage:       "Grafana admin commands",
 		Subcommands: adminCommands,
 	},
+	{
+		Name:        "live",
+		Usage:       "Grafana Live commands",
+		Subcommands: liveCommands,
+	},
 }
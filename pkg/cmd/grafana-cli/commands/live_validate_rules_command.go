@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+)
+
+var errMissingRulesPath = errors.New("missing path of live channel rules file or directory")
+
+func validateLiveRulesCommand(c utils.CommandLine) error {
+	rulesPath := c.Args().First()
+	if rulesPath == "" {
+		return errMissingRulesPath
+	}
+
+	validationErrors, err := pipeline.ValidateRulesFile(rulesPath, c.String("write-configs"))
+	if err != nil {
+		return err
+	}
+	if len(validationErrors) == 0 {
+		logger.Info(color.GreenString("Live channel rules are valid.\n"))
+		return nil
+	}
+	for _, validationError := range validationErrors {
+		logger.Infof("%s: %s\n", color.RedString(validationError.Path), validationError.Message)
+	}
+	return fmt.Errorf("live channel rules have %d errors", len(validationErrors))
+}
@@ -8,11 +8,14 @@ import (
 
 // ParseStr attempts to parse a string into a GRN. It returns an error if the
 // given string does not match the GRN format, but does not validate the values.
+// Both grn:tenantID:kind/resourceIdentifier and
+// grn:tenantID:group/version:kind/resourceIdentifier formats are supported, the
+// version is optional in the latter.
 func ParseStr(str string) (*GRN, error) {
 	ret := &GRN{}
 	parts := strings.Split(str, ":")
 
-	if len(parts) != 3 {
+	if len(parts) != 3 && len(parts) != 4 {
 		return ret, ErrInvalidGRN.Errorf("%q is not a complete GRN", str)
 	}
 
@@ -20,9 +23,18 @@ func ParseStr(str string) (*GRN, error) {
 		return ret, ErrInvalidGRN.Errorf("%q does not look like a GRN", str)
 	}
 
+	if len(parts) == 4 {
+		group, version, _ := strings.Cut(parts[2], "/")
+		if group == "" || strings.Contains(version, "/") {
+			return ret, ErrInvalidGRN.Errorf("invalid group/version in GRN %q", str)
+		}
+		ret.ResourceGroup = group
+		ret.ResourceVersion = version
+	}
+
 	// split the final segment into Kind and ID. This only splits after the
 	// first occurrence of "/"; a ResourceIdentifier may contain "/"
-	kind, id, found := strings.Cut(parts[2], "/")
+	kind, id, found := strings.Cut(parts[len(parts)-1], "/")
 	if !found { // missing "/"
 		return ret, ErrInvalidGRN.Errorf("invalid resource identifier in GRN %q", str)
 	}
@@ -52,18 +64,38 @@ func MustParseStr(str string) *GRN {
 }
 
 // ToGRNString returns a string representation of a grn in the format
-// grn:tenantID:kind/resourceIdentifier
+// grn:tenantID:kind/resourceIdentifier, or
+// grn:tenantID:group/version:kind/resourceIdentifier when the group is set
 func (g *GRN) ToGRNString() string {
+	if g.ResourceGroup != "" {
+		groupVersion := g.ResourceGroup
+		if g.ResourceVersion != "" {
+			groupVersion += "/" + g.ResourceVersion
+		}
+		return fmt.Sprintf("grn:%d:%s:%s/%s", g.TenantID, groupVersion, g.ResourceKind, g.ResourceIdentifier)
+	}
 	return fmt.Sprintf("grn:%d:%s/%s", g.TenantID, g.ResourceKind, g.ResourceIdentifier)
 }
 
+// Unversioned returns a copy of the GRN without group and version, it identifies
+// the same object regardless of the shape it is requested in.
+func (g *GRN) Unversioned() *GRN {
+	return &GRN{
+		TenantID:           g.TenantID,
+		ResourceKind:       g.ResourceKind,
+		ResourceIdentifier: g.ResourceIdentifier,
+	}
+}
+
 // Check if the two GRNs reference to the same object
-// we can not use simple `*x == *b` because of the internal settings
+// we can not use simple `*x == *b` because of the internal settings.
+// Versions are ignored as they select a shape of the same object
 func (g *GRN) Equal(b *GRN) bool {
 	if b == nil {
 		return false
 	}
 	return g == b || (g.TenantID == b.TenantID &&
+		g.ResourceGroup == b.ResourceGroup &&
 		g.ResourceKind == b.ResourceKind &&
 		g.ResourceIdentifier == b.ResourceIdentifier)
 }
@@ -58,6 +58,26 @@ func TestParseGRNStr(t *testing.T) {
 			&GRN{TenantID: 0, ResourceKind: "roles", ResourceIdentifier: "//Admin/with/leading/slashes"},
 			false,
 		},
+		{ // good! group and version
+			"grn:1:dashboard.grafana.app/v1:dashboard/abc",
+			&GRN{TenantID: 1, ResourceGroup: "dashboard.grafana.app", ResourceVersion: "v1", ResourceKind: "dashboard", ResourceIdentifier: "abc"},
+			false,
+		},
+		{ // good! group without version
+			"grn:1:dashboard.grafana.app:dashboard/abc",
+			&GRN{TenantID: 1, ResourceGroup: "dashboard.grafana.app", ResourceKind: "dashboard", ResourceIdentifier: "abc"},
+			false,
+		},
+		{ // Missing group
+			"grn:1:/v1:dashboard/abc",
+			&GRN{TenantID: 0},
+			true,
+		},
+		{ // Too many group/version parts
+			"grn:1:dashboard.grafana.app/v1/beta:dashboard/abc",
+			&GRN{TenantID: 0},
+			true,
+		},
 	}
 
 	for _, test := range tests {
@@ -77,3 +97,28 @@ func TestParseGRNStr(t *testing.T) {
 		})
 	}
 }
+
+func TestGRN_ToGRNString(t *testing.T) {
+	for _, str := range []string{
+		"grn:1:dashboard/abc",
+		"grn:1:dashboard.grafana.app:dashboard/abc",
+		"grn:1:dashboard.grafana.app/v1:dashboard/abc",
+	} {
+		g, err := ParseStr(str)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %s", str, err)
+		}
+		if g.ToGRNString() != str {
+			t.Fatalf("wrong result. Wanted %s, got %s", str, g.ToGRNString())
+		}
+	}
+
+	v1 := MustParseStr("grn:1:dashboard.grafana.app/v1:dashboard/abc")
+	v2 := MustParseStr("grn:1:dashboard.grafana.app/v2:dashboard/abc")
+	if !v1.Equal(v2) {
+		t.Fatal("versions of the same object must be equal")
+	}
+	if v1.Unversioned().ToGRNString() != "grn:1:dashboard/abc" {
+		t.Fatalf("wrong result. Got %s", v1.Unversioned().ToGRNString())
+	}
+}
@@ -123,8 +123,14 @@ import (
 	"github.com/grafana/grafana/pkg/services/star/starimpl"
 	"github.com/grafana/grafana/pkg/services/stats/statsimpl"
 	"github.com/grafana/grafana/pkg/services/store"
+	entityaccess "github.com/grafana/grafana/pkg/services/store/entity/access"
+	entitycomments "github.com/grafana/grafana/pkg/services/store/entity/comments"
+	entityfavorites "github.com/grafana/grafana/pkg/services/store/entity/favorites"
 	"github.com/grafana/grafana/pkg/services/store/entity/httpentitystore"
+	entityownership "github.com/grafana/grafana/pkg/services/store/entity/ownership"
+	entityretention "github.com/grafana/grafana/pkg/services/store/entity/retention"
 	"github.com/grafana/grafana/pkg/services/store/entity/sqlstash"
+	entityusage "github.com/grafana/grafana/pkg/services/store/entity/usage"
 	"github.com/grafana/grafana/pkg/services/store/kind"
 	"github.com/grafana/grafana/pkg/services/store/resolver"
 	"github.com/grafana/grafana/pkg/services/store/sanitizer"
@@ -332,6 +338,12 @@ var wireBasicSet = wire.NewSet(
 	sqlstash.ProvideSQLEntityServer,
 	resolver.ProvideEntityReferenceResolver,
 	httpentitystore.ProvideHTTPEntityStore,
+	entityaccess.ProvideService,
+	entitycomments.ProvideService,
+	entityfavorites.ProvideService,
+	entityusage.ProvideService,
+	entityownership.ProvideService,
+	entityretention.ProvideService,
 	teamimpl.ProvideService,
 	tempuserimpl.ProvideService,
 	loginattemptimpl.ProvideService,
@@ -0,0 +1,45 @@
+package live
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// entityGeoJSONWriter saves rolling feature collections of geojson outputs as versions
+// of geojson entities.
+type entityGeoJSONWriter struct {
+	store entity.EntityStoreServer
+}
+
+var _ pipeline.GeoJSONWriter = &entityGeoJSONWriter{}
+
+func (w *entityGeoJSONWriter) WriteGeoJSON(ctx context.Context, orgID int64, uid string, channel string, body []byte) error {
+	// Outputs write outside of user requests, write entities as an org admin.
+	ctx = appcontext.WithUser(ctx, &user.SignedInUser{
+		OrgID:   orgID,
+		OrgRole: org.RoleAdmin,
+	})
+	rsp, err := w.store.Write(ctx, &entity.WriteEntityRequest{
+		GRN: &grn.GRN{
+			TenantID:           orgID,
+			ResourceKind:       entity.StandardKindGeoJSON,
+			ResourceIdentifier: uid,
+		},
+		Body:    body,
+		Comment: "Recent points of " + channel,
+	})
+	if err != nil {
+		return err
+	}
+	if rsp.Error != nil {
+		return errors.New(rsp.Error.Message)
+	}
+	return nil
+}
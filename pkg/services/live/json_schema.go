@@ -0,0 +1,48 @@
+package live
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// entityJSONSchemaGetter reads JSON Schemas of pipeline converters from jsonobj entities.
+type entityJSONSchemaGetter struct {
+	store entity.EntityStoreServer
+}
+
+var _ pipeline.JSONSchemaGetter = &entityJSONSchemaGetter{}
+
+func (g *entityJSONSchemaGetter) GetJSONSchema(ctx context.Context, orgID int64, uid string) ([]byte, string, error) {
+	rsp, err := readJSONObjEntity(ctx, g.store, orgID, uid)
+	if err != nil {
+		return nil, "", err
+	}
+	if rsp.GRN == nil {
+		return nil, "", fmt.Errorf("json schema entity not found: %s", uid)
+	}
+	return rsp.Body, rsp.Version, nil
+}
+
+// readJSONObjEntity reads a jsonobj entity with a body for pipeline rules of an org.
+func readJSONObjEntity(ctx context.Context, store entity.EntityStoreServer, orgID int64, uid string) (*entity.Entity, error) {
+	// Pipeline rules run outside of user requests, read entities as an org admin.
+	ctx = appcontext.WithUser(ctx, &user.SignedInUser{
+		OrgID:   orgID,
+		OrgRole: org.RoleAdmin,
+	})
+	return store.Read(ctx, &entity.ReadEntityRequest{
+		GRN: &grn.GRN{
+			TenantID:           orgID,
+			ResourceKind:       entity.StandardKindJSONObj,
+			ResourceIdentifier: uid,
+		},
+		WithBody: true,
+	})
+}
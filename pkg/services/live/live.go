@@ -1,6 +1,7 @@
 package live
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,15 +9,20 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/centrifugal/centrifuge"
 	"github.com/go-redis/redis/v8"
 	"github.com/gobwas/glob"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/live"
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/sync/errgroup"
@@ -48,10 +54,12 @@ import (
 	"github.com/grafana/grafana/pkg/services/live/pushws"
 	"github.com/grafana/grafana/pkg/services/live/runstream"
 	"github.com/grafana/grafana/pkg/services/live/survey"
+	"github.com/grafana/grafana/pkg/services/ngalert"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
 	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/store/entity"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
@@ -77,7 +85,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	dataSourceCache datasources.CacheService, sqlStore db.DB, secretsService secrets.Service,
 	usageStatsService usagestats.Service, queryDataService query.Service, toggles featuremgmt.FeatureToggles,
 	accessControl accesscontrol.AccessControl, dashboardService dashboards.DashboardService, annotationsRepo annotations.Repository,
-	orgService org.Service) (*GrafanaLive, error) {
+	orgService org.Service, alertNG *ngalert.AlertNG, entityStore entity.EntityStoreServer) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -96,6 +104,18 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		},
 		usageStatsService: usageStatsService,
 		orgService:        orgService,
+		AnnotationsRepo:   annotationsRepo,
+		DashboardService:  dashboardService,
+		connections:       newConnectionRegistry(),
+	}
+	if alertNG != nil && alertNG.MultiOrgAlertmanager != nil {
+		g.ContactPoints = alertNG.MultiOrgAlertmanager
+	}
+	if entityStore != nil {
+		g.JSONSchemas = &entityJSONSchemaGetter{store: entityStore}
+		g.LookupTables = &entityLookupTableGetter{store: entityStore}
+		g.GeoJSON = &entityGeoJSONWriter{store: entityStore}
+		g.Samples = &entitySampleGetter{store: entityStore}
 	}
 
 	logger.Debug("GrafanaLive initialization", "ha", g.IsHA())
@@ -157,8 +177,9 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	channelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, nil)
 
 	var managedStreamRunner *managedstream.Runner
+	var redisClient *redis.Client
 	if g.IsHA() {
-		redisClient := redis.NewClient(&redis.Options{
+		redisClient = redis.NewClient(&redis.Options{
 			Addr: g.Cfg.LiveHAEngineAddress,
 		})
 		cmd := redisClient.Ping(context.Background())
@@ -180,6 +201,106 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 
 	g.ManagedStreamRunner = managedStreamRunner
 
+	if g.Cfg.LiveManagedStreamHistoryEnabled {
+		// Recent frames are kept in memory, older frames in Redis in HA mode and the
+		// long tail in the entity store when it's available.
+		tiers := []managedstream.HistoryTier{
+			managedstream.NewMemoryHistoryTier(g.Cfg.LiveManagedStreamHistoryMemoryFrames),
+		}
+		if redisClient != nil {
+			tiers = append(tiers, managedstream.NewRedisHistoryTier(redisClient, g.Cfg.LiveManagedStreamHistoryRedisMaxAge))
+		}
+		if entityStore != nil {
+			tiers = append(tiers, managedstream.NewChunkHistoryTier(&entityHistoryChunkStore{store: entityStore}, g.Cfg.LiveManagedStreamHistoryChunkFrames))
+		}
+		g.managedStreamHistory = managedstream.NewTieredHistory(tiers...)
+		managedStreamRunner.SetHistory(g.managedStreamHistory)
+	}
+
+	g.DebugFrames = pipeline.NewDebugFrameBuffer(g.Cfg.LivePipelineDebugBufferSize)
+	if rulesFile := g.Cfg.LiveChannelRulesFile; rulesFile != "" {
+		fileStorage := &pipeline.FileStorage{DataPath: g.Cfg.DataPath, RulesFile: rulesFile, SecretsService: secretsService}
+		if err := fileStorage.Reload(); err != nil {
+			return nil, fmt.Errorf("error loading live channel rules file: %w", err)
+		}
+		g.pipelineRulesFile = fileStorage
+		g.pipelineStorage = fileStorage
+	} else if sqlStore != nil {
+		g.pipelineStorage = &pipeline.SQLStorage{SQLStore: sqlStore, SecretsService: secretsService}
+	}
+	if g.pipelineStorage != nil && g.Cfg.LivePipelineEnabled {
+		g.pipelineRules = pipeline.NewCacheSegmentedTree(g.pipelineRuleBuilder(g.pipelineStorage))
+		g.Pipeline, err = pipeline.New(g.pipelineRules)
+		if err != nil {
+			return nil, fmt.Errorf("error creating pipeline: %w", err)
+		}
+		g.Pipeline.RuleMatch, err = pipeline.ParseRuleMatchMode(g.Cfg.LivePipelineRuleMatch)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing live pipeline_rule_match: %w", err)
+		}
+		if redisClient != nil {
+			// Rule rate limits are global for all instances in HA mode.
+			g.Pipeline.RateLimiter = pipeline.NewRedisRateLimiter(redisClient)
+		}
+	}
+
+	g.DeadLetters = pipeline.NewDeadLetterQueue(pipeline.DeadLetterQueueConfig{
+		MaxEntries: g.Cfg.LivePipelineDeadLetterMaxEntries,
+		MaxBytes:   g.Cfg.LivePipelineDeadLetterMaxBytes,
+		MaxAge:     g.Cfg.LivePipelineDeadLetterMaxAge,
+	})
+	if g.Pipeline != nil {
+		g.Pipeline.DeadLetters = g.DeadLetters
+	}
+	if g.Cfg.LivePipelineMessageTraceEnabled {
+		g.MessageTraces = pipeline.NewMessageTracker(pipeline.MessageTrackerConfig{
+			Retention:   g.Cfg.LivePipelineMessageTraceRetention,
+			MaxMessages: g.Cfg.LivePipelineMessageTraceMaxMessages,
+		})
+		if g.Pipeline != nil {
+			g.Pipeline.Messages = g.MessageTraces
+		}
+	}
+	if g.Cfg.LivePipelineMetricsStreamEnabled {
+		g.PipelineStats = pipeline.NewRuleStats()
+		g.pipelineStatsInterval = g.Cfg.LivePipelineMetricsStreamInterval
+		if g.Pipeline != nil {
+			g.Pipeline.Stats = g.PipelineStats
+		}
+	}
+
+	if maxInFlight := g.Cfg.LivePipelineMaxInFlight; maxInFlight > 0 {
+		g.PipelineShedder = pipeline.NewLoadShedder(maxInFlight)
+		if g.Pipeline != nil {
+			g.Pipeline.Shedder = g.PipelineShedder
+		}
+	}
+
+	g.PipelineProfiler = pipeline.NewRuleProfiler()
+	if g.Pipeline != nil {
+		g.Pipeline.MaxDecompressedSize = g.Cfg.LivePipelineMaxDecompressedSize
+		g.Pipeline.Profiler = g.PipelineProfiler
+		g.heartbeats = pipeline.NewHeartbeatMonitor(g.Pipeline)
+		g.Pipeline.Heartbeats = g.heartbeats
+	}
+
+	if g.Cfg.LivePipelineGeneratorsEnabled && g.Pipeline != nil && g.pipelineStorage != nil {
+		g.generators = pipeline.NewGeneratorRunner(g.Pipeline, g.pipelineStorage, g.listOrgIDs)
+	}
+
+	if g.Cfg.LivePipelineSnapshotsEnabled && entityStore != nil && g.pipelineStorage != nil {
+		g.snapshots = pipeline.NewSnapshotRunner(g.pipelineStorage, g.ManagedStreamRunner, &entitySnapshotWriter{store: entityStore}, g.listOrgIDs)
+	}
+
+	g.pipelineShutdownTimeout = g.Cfg.LivePipelineShutdownTimeout
+	g.idleChannelCleanupAfter = g.Cfg.LiveManagedStreamIdleCleanupAfter
+	g.idleChannelCleanupInterval = g.Cfg.LiveManagedStreamIdleCleanupInterval
+
+	// Warn about pipeline rules which are valid but most probably do not work as intended.
+	if g.Pipeline != nil {
+		g.lintPipelineRules(context.Background())
+	}
+
 	g.contextGetter = liveplugin.NewContextGetter(g.PluginContextProvider, g.DataSourceCache)
 	pipelinedChannelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, g.Pipeline)
 	numLocalSubscribersGetter := liveplugin.NewNumLocalSubscribersGetter(node)
@@ -223,6 +344,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		}
 		logger.Debug("Client connected", "user", client.UserID(), "client", client.ID())
 		connectedAt := time.Now()
+		g.connections.add(client.ID(), connectedAt)
 
 		// Called when client issues RPC (async request over Live connection).
 		client.OnRPC(func(e centrifuge.RPCEvent, cb centrifuge.RPCCallback) {
@@ -257,6 +379,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		})
 
 		client.OnDisconnect(func(e centrifuge.DisconnectEvent) {
+			g.connections.remove(client.ID())
 			reason := e.Disconnect.Reason
 			if e.Disconnect.Code == 3001 { // Shutdown
 				return
@@ -367,6 +490,7 @@ type GrafanaLive struct {
 
 	node         *centrifuge.Node
 	surveyCaller *survey.Caller
+	connections  *connectionRegistry
 
 	// Websocket handlers
 	websocketHandler             interface{}
@@ -381,8 +505,53 @@ type GrafanaLive struct {
 	GrafanaScope CoreGrafanaScope
 
 	ManagedStreamRunner *managedstream.Runner
-	Pipeline            *pipeline.Pipeline
-	pipelineStorage     pipeline.Storage
+	// managedStreamHistory keeps frames pushed to managed streams, nil when disabled.
+	managedStreamHistory *managedstream.TieredHistory
+	Pipeline             *pipeline.Pipeline
+	pipelineStorage      pipeline.Storage
+	// pipelineRules caches rules of the pipeline, nil when pipeline is disabled.
+	pipelineRules *pipeline.CacheSegmentedTree
+	// pipelineRulesFile is set when rules are loaded from channel_rules_file.
+	pipelineRulesFile *pipeline.FileStorage
+	// DeadLetters keeps pipeline failures for inspection and replay.
+	DeadLetters *pipeline.DeadLetterQueue
+	// MessageTraces keeps recent events of pipeline messages by message ID when enabled.
+	MessageTraces *pipeline.MessageTracker
+	// DebugFrames keeps last frames passed to debug outputs.
+	DebugFrames *pipeline.DebugFrameBuffer
+	// PipelineStats counts pipeline activity per rule published to grafana/pipeline/metrics
+	// managed stream, nil when disabled.
+	PipelineStats         *pipeline.RuleStats
+	pipelineStatsInterval time.Duration
+	// generators feed synthetic frames of channel rules to the pipeline, nil when disabled.
+	generators *pipeline.GeneratorRunner
+	// snapshots save last frames of channel rules to the entity store, nil when disabled.
+	snapshots *pipeline.SnapshotRunner
+	// PipelineShedder drops pipeline inputs by rule priority under load, nil when disabled.
+	PipelineShedder *pipeline.LoadShedder
+	// PipelineProfiler captures CPU profiles of pipeline rules labeled per stage.
+	PipelineProfiler *pipeline.RuleProfiler
+	// heartbeats report channels of rules with a heartbeat which stopped receiving inputs.
+	heartbeats *pipeline.HeartbeatMonitor
+	// idleChannelCleanupAfter is a period without activity after which managed stream
+	// channel state is removed, cleanup is disabled when zero.
+	idleChannelCleanupAfter    time.Duration
+	idleChannelCleanupInterval time.Duration
+	// pipelineShutdownTimeout limits time in-flight inputs and buffered outputs are drained for.
+	pipelineShutdownTimeout time.Duration
+
+	AnnotationsRepo  annotations.Repository
+	DashboardService dashboards.DashboardService
+	// ContactPoints sends pipeline alert notifications, nil when alerting is disabled.
+	ContactPoints pipeline.ContactPointNotifier
+	// JSONSchemas reads JSON Schemas of pipeline converters from the entity store.
+	JSONSchemas pipeline.JSONSchemaGetter
+	// LookupTables reads lookup tables of pipeline value map processors from the entity store.
+	LookupTables pipeline.LookupTableGetter
+	// GeoJSON writes rolling feature collections of pipeline geojson outputs to the entity store.
+	GeoJSON pipeline.GeoJSONWriter
+	// Samples reads sample payloads of pipeline rules from the entity store.
+	Samples pipeline.SampleGetter
 
 	contextGetter    *liveplugin.ContextGetter
 	runStreamManager *runstream.Manager
@@ -436,6 +605,88 @@ func (g *GrafanaLive) Run(ctx context.Context) error {
 		}
 	})
 
+	if g.DeadLetters != nil {
+		eGroup.Go(func() error {
+			return g.DeadLetters.Run(eCtx)
+		})
+	}
+
+	if g.MessageTraces != nil {
+		eGroup.Go(func() error {
+			return g.MessageTraces.Run(eCtx)
+		})
+	}
+
+	if g.PipelineStats != nil && g.ManagedStreamRunner != nil {
+		eGroup.Go(func() error {
+			return g.PipelineStats.Run(eCtx, g.pipelineStatsInterval, g.publishPipelineStats)
+		})
+	}
+
+	if g.generators != nil {
+		eGroup.Go(func() error {
+			return g.generators.Run(eCtx, time.Minute)
+		})
+	}
+
+	if g.snapshots != nil {
+		eGroup.Go(func() error {
+			return g.snapshots.Run(eCtx, time.Minute)
+		})
+	}
+
+	if g.managedStreamHistory != nil {
+		eGroup.Go(func() error {
+			<-eCtx.Done()
+			// Write buffered history chunks on shutdown.
+			if err := g.managedStreamHistory.Flush(context.Background()); err != nil {
+				logger.Error("Error flushing managed stream history", "error", err)
+			}
+			return eCtx.Err()
+		})
+	}
+
+	if g.heartbeats != nil {
+		eGroup.Go(func() error {
+			return g.heartbeats.Run(eCtx, time.Second)
+		})
+	}
+
+	if g.Pipeline != nil {
+		eGroup.Go(func() error {
+			<-eCtx.Done()
+			g.shutdownPipeline()
+			return eCtx.Err()
+		})
+	}
+
+	if g.pipelineRulesFile != nil {
+		eGroup.Go(func() error {
+			return g.pipelineRulesFile.Watch(eCtx, 5*time.Second, g.refreshPipelineRules)
+		})
+		eGroup.Go(func() error {
+			sighup := make(chan os.Signal, 1)
+			signal.Notify(sighup, syscall.SIGHUP)
+			defer signal.Stop(sighup)
+			for {
+				select {
+				case <-sighup:
+					if err := g.ReloadPipelineRules(); err != nil {
+						logger.Error("Error reloading live pipeline rules", "error", err)
+					}
+				case <-eCtx.Done():
+					return eCtx.Err()
+				}
+			}
+		})
+	}
+
+	if g.idleChannelCleanupAfter > 0 && g.ManagedStreamRunner != nil {
+		eGroup.Go(func() error {
+			return g.ManagedStreamRunner.RunIdleCleanup(eCtx, g.idleChannelCleanupInterval, g.idleChannelCleanupAfter)
+		})
+	}
+
 	if g.runStreamManager != nil {
 		// Only run stream manager if GrafanaLive properly initialized.
 		eGroup.Go(func() error {
@@ -503,6 +754,53 @@ func (g *GrafanaLive) IsHA() bool {
 	return g.Cfg != nil && g.Cfg.LiveHAEngine != ""
 }
 
+// publishPipelineStats pushes a frame of pipeline rule stats to grafana/pipeline/metrics
+// managed stream of an organization.
+func (g *GrafanaLive) publishPipelineStats(ctx context.Context, orgID int64, frame *data.Frame) error {
+	stream, err := g.ManagedStreamRunner.GetOrCreateStream(orgID, live.ScopeGrafana, pipeline.RuleStatsNamespace)
+	if err != nil {
+		return err
+	}
+	return stream.Push(ctx, pipeline.RuleStatsPath, frame)
+}
+
+// lintPipelineRules logs lint warnings of channel rules of all organizations.
+func (g *GrafanaLive) lintPipelineRules(ctx context.Context) {
+	orgIDs, err := g.listOrgIDs(ctx)
+	if err != nil {
+		logger.Warn("Error linting live pipeline rules", "error", err)
+		return
+	}
+	lintWarnings, err := pipeline.LintStorage(ctx, g.pipelineStorage, orgIDs)
+	if err != nil {
+		logger.Warn("Error linting live pipeline rules", "error", err)
+		return
+	}
+	for orgID, warnings := range lintWarnings {
+		for _, w := range warnings {
+			logger.Warn("Live pipeline rule lint warning", "orgId", orgID, "ruleIndex", w.RuleIndex, "pattern", w.Pattern, "code", w.Code, "message", w.Message)
+		}
+	}
+}
+
+// listOrgIDs returns IDs of all organizations.
+func (g *GrafanaLive) listOrgIDs(ctx context.Context) ([]int64, error) {
+	orgs, err := g.orgService.Search(ctx, &org.SearchOrgsQuery{})
+	if err != nil {
+		return nil, err
+	}
+	orgIDs := make([]int64, 0, len(orgs))
+	for _, o := range orgs {
+		orgIDs = append(orgIDs, o.ID)
+	}
+	return orgIDs, nil
+}
+
+// pipelineFileOutputDir is a directory pipeline file outputs write into.
+func (g *GrafanaLive) pipelineFileOutputDir() string {
+	return g.Cfg.LivePipelineFileOutputDir
+}
+
 func runConcurrentlyIfNeeded(ctx context.Context, semaphore chan struct{}, fn func()) error {
 	if cap(semaphore) > 1 {
 		select {
@@ -731,6 +1029,9 @@ func (g *GrafanaLive) handleOnPublish(ctx context.Context, client *centrifuge.Cl
 				}
 			}
 			_, err := g.Pipeline.ProcessInput(client.Context(), user.GetOrgID(), channel, e.Data)
+			if errors.Is(err, pipeline.ErrRateLimited) {
+				return centrifuge.PublishReply{}, centrifuge.ErrorLimitExceeded
+			}
 			if err != nil {
 				logger.Error("Error processing input", "user", client.UserID(), "client", client.ID(), "channel", e.Channel, "error", err)
 				return centrifuge.PublishReply{}, centrifuge.ErrorInternal
@@ -867,7 +1168,14 @@ func (g *GrafanaLive) GetChannelHandlerFactory(ctx context.Context, user identit
 	}
 }
 
-func (g *GrafanaLive) handleGrafanaScope(_ identity.Requester, namespace string) (model.ChannelHandlerFactory, error) {
+func (g *GrafanaLive) handleGrafanaScope(u identity.Requester, namespace string) (model.ChannelHandlerFactory, error) {
+	if namespace == pipeline.RuleStatsNamespace && g.PipelineStats != nil {
+		// Pipeline rules are managed by organization admins, so are their stats.
+		if !u.HasRole(org.RoleAdmin) {
+			return nil, fmt.Errorf("unknown feature: %q", namespace)
+		}
+		return g.ManagedStreamRunner.GetOrCreateStream(u.GetOrgID(), live.ScopeGrafana, pipeline.RuleStatsNamespace)
+	}
 	if p, ok := g.GrafanaScope.Features[namespace]; ok {
 		return p, nil
 	}
@@ -961,12 +1269,17 @@ func (g *GrafanaLive) HandleHTTPPublish(ctx *contextmodel.ReqContext) response.R
 					return response.Error(http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
 				}
 			}
-			_, err := g.Pipeline.ProcessInput(ctx.Req.Context(), user.GetOrgID(), channel, cmd.Data)
+			messageID := pipeline.NewMessageID()
+			pipelineCtx := pipeline.WithMessageID(livecontext.SetContextSignedUser(ctx.Req.Context(), user), messageID)
+			_, err := g.Pipeline.ProcessInput(pipelineCtx, user.GetOrgID(), channel, cmd.Data)
+			if errors.Is(err, pipeline.ErrRateLimited) {
+				return response.Error(http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests), err)
+			}
 			if err != nil {
-				logger.Error("Error processing input", "user", user, "channel", channel, "error", err)
+				logger.Error("Error processing input", "user", user, "channel", channel, "messageId", messageID, "error", err)
 				return response.Error(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), nil)
 			}
-			return response.JSON(http.StatusOK, dtos.LivePublishResponse{})
+			return response.JSON(http.StatusOK, dtos.LivePublishResponse{}).SetHeader(pipeline.MessageIDHeader, messageID)
 		}
 	}
 
@@ -1019,6 +1332,28 @@ func (g *GrafanaLive) HandleListHTTP(c *contextmodel.ReqContext) response.Respon
 	return response.JSONStreaming(http.StatusOK, info)
 }
 
+// HandleChannelUsageHTTP returns usage of managed stream channels of the current organization
+// on this instance, the most active channels first. Channels without activity for idleDays
+// query parameter days are listed separately.
+func (g *GrafanaLive) HandleChannelUsageHTTP(c *contextmodel.ReqContext) response.Response {
+	orgID := c.SignedInUser.GetOrgID()
+	channels := g.ManagedStreamRunner.GetChannelUsage(orgID)
+	if limit := c.QueryInt("limit"); limit > 0 && limit < len(channels) {
+		channels = channels[:limit]
+	}
+	result := util.DynMap{
+		"channels": channels,
+	}
+	if idleDays := c.QueryInt("idleDays"); idleDays > 0 {
+		idle := g.ManagedStreamRunner.GetIdleChannels(orgID, time.Duration(idleDays)*24*time.Hour, time.Now())
+		if idle == nil {
+			idle = []managedstream.ChannelUsage{}
+		}
+		result["idle"] = idle
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 // HandleInfoHTTP special http response for
 func (g *GrafanaLive) HandleInfoHTTP(ctx *contextmodel.ReqContext) response.Response {
 	path := web.Params(ctx.Req)["*"]
@@ -1055,6 +1390,7 @@ type ConvertDryRunResponse struct {
 
 type DryRunRuleStorage struct {
 	ChannelRules []pipeline.ChannelRule
+	WriteConfigs []pipeline.WriteConfig
 }
 
 func (s *DryRunRuleStorage) GetWriteConfig(_ context.Context, _ int64, _ pipeline.WriteConfigGetCmd) (pipeline.WriteConfig, bool, error) {
@@ -1086,13 +1422,119 @@ func (s *DryRunRuleStorage) DeleteChannelRule(_ context.Context, _ int64, _ pipe
 }
 
 func (s *DryRunRuleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]pipeline.WriteConfig, error) {
-	return nil, nil
+	return s.WriteConfigs, nil
 }
 
 func (s *DryRunRuleStorage) ListChannelRules(_ context.Context, _ int64) ([]pipeline.ChannelRule, error) {
 	return s.ChannelRules, nil
 }
 
+// shutdownPipeline stops accepting pipeline inputs and drains buffered outputs for
+// pipelineShutdownTimeout, work which was not finished in time is reported.
+func (g *GrafanaLive) shutdownPipeline() {
+	ctx, cancel := context.WithTimeout(context.Background(), g.pipelineShutdownTimeout)
+	defer cancel()
+	report := g.Pipeline.Shutdown(ctx)
+	if report.Lost() {
+		logger.Warn("Live pipeline was shut down before all work was done", "inFlightInputs", report.InFlight, "droppedByOutput", report.Dropped)
+		return
+	}
+	logger.Info("Live pipeline was shut down")
+}
+
+var errPipelineRulesFileNotSet = errors.New("live channel rules file is not configured")
+
+// ReloadPipelineRules reloads channel rules file and rebuilds rules of the pipeline.
+func (g *GrafanaLive) ReloadPipelineRules() error {
+	if g.pipelineRulesFile == nil {
+		return errPipelineRulesFileNotSet
+	}
+	if err := g.pipelineRulesFile.Reload(); err != nil {
+		return err
+	}
+	g.refreshPipelineRules()
+	logger.Info("Live pipeline rules reloaded")
+	return nil
+}
+
+func (g *GrafanaLive) refreshPipelineRules() {
+	if g.pipelineRules == nil {
+		return
+	}
+	if err := g.pipelineRules.Refresh(); err != nil {
+		logger.Error("Error rebuilding live pipeline rules", "error", err)
+	}
+}
+
+// HandlePipelineRulesReloadHTTP reloads channel rules file.
+func (g *GrafanaLive) HandlePipelineRulesReloadHTTP(_ *contextmodel.ReqContext) response.Response {
+	err := g.ReloadPipelineRules()
+	if errors.Is(err, errPipelineRulesFileNotSet) {
+		return response.Error(http.StatusBadRequest, "Live channel rules file is not configured", err)
+	}
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Failed to reload live channel rules", err)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{"message": "Live channel rules reloaded"})
+}
+
+type PipelineRuleMatchResponse struct {
+	Mode  pipeline.RuleMatchMode `json:"mode"`
+	Rules []PipelineRuleMatch    `json:"rules"`
+}
+
+type PipelineRuleMatch struct {
+	Pattern string `json:"pattern"`
+	Order   int    `json:"order,omitempty"`
+	// Executed is true for rules which process inputs of the channel.
+	Executed bool `json:"executed"`
+}
+
+// HandlePipelineRuleMatchHTTP previews rules a channel resolves to, in execution order.
+func (g *GrafanaLive) HandlePipelineRuleMatchHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.Pipeline == nil || g.pipelineRules == nil {
+		return response.Error(http.StatusNotFound, "Pipeline is not enabled", nil)
+	}
+	channel := c.Query("channel")
+	if ch, err := live.ParseChannel(channel); err != nil || !ch.IsValid() {
+		return response.Error(http.StatusBadRequest, "Invalid channel", err)
+	}
+	rules, err := g.pipelineRules.Match(c.SignedInUser.GetOrgID(), channel)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error getting channel rules", err)
+	}
+	resp := PipelineRuleMatchResponse{Mode: g.Pipeline.RuleMatch, Rules: make([]PipelineRuleMatch, 0, len(rules))}
+	for i, rule := range rules {
+		resp.Rules = append(resp.Rules, PipelineRuleMatch{
+			Pattern:  rule.Pattern,
+			Order:    rule.Order,
+			Executed: i == 0 || g.Pipeline.RuleMatch == pipeline.RuleMatchAll,
+		})
+	}
+	return response.JSON(http.StatusOK, resp)
+}
+
+// pipelineRuleBuilder returns a builder of pipeline rules kept in storage.
+func (g *GrafanaLive) pipelineRuleBuilder(storage pipeline.Storage) *pipeline.StorageRuleBuilder {
+	return &pipeline.StorageRuleBuilder{
+		Node:                 g.node,
+		ManagedStream:        g.ManagedStreamRunner,
+		FrameStorage:         pipeline.NewFrameStorage(),
+		Storage:              storage,
+		ChannelHandlerGetter: g,
+		SecretsService:       g.SecretsService,
+		FileOutputDir:        g.pipelineFileOutputDir(),
+		RemoteWriteWALDir:    filepath.Join(g.Cfg.DataPath, "live", "remote-write-wal"),
+		DebugFrames:          g.DebugFrames,
+		AnnotationsRepo:      g.AnnotationsRepo,
+		DashboardService:     g.DashboardService,
+		ContactPoints:        g.ContactPoints,
+		JSONSchemas:          g.JSONSchemas,
+		LookupTables:         g.LookupTables,
+		GeoJSON:              g.GeoJSON,
+	}
+}
+
 // HandlePipelineConvertTestHTTP ...
 func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *contextmodel.ReqContext) response.Response {
 	body, err := io.ReadAll(c.Req.Body)
@@ -1107,14 +1549,7 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *contextmodel.ReqContext)
 	storage := &DryRunRuleStorage{
 		ChannelRules: req.ChannelRules,
 	}
-	builder := &pipeline.StorageRuleBuilder{
-		Node:                 g.node,
-		ManagedStream:        g.ManagedStreamRunner,
-		FrameStorage:         pipeline.NewFrameStorage(),
-		Storage:              storage,
-		ChannelHandlerGetter: g,
-	}
-	channelRuleGetter := pipeline.NewCacheSegmentedTree(builder)
+	channelRuleGetter := pipeline.NewCacheSegmentedTree(g.pipelineRuleBuilder(storage))
 	pipe, err := pipeline.New(channelRuleGetter)
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Error creating pipeline", err)
@@ -1138,6 +1573,123 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *contextmodel.ReqContext)
 	})
 }
 
+type PipelineRuleDryRunRequest struct {
+	// Rule is a channel rule definition to test, it does not have to be saved.
+	Rule pipeline.ChannelRule `json:"rule"`
+	// Channel the payload is sent to, defaults to the rule pattern.
+	Channel string `json:"channel,omitempty"`
+	Data    string `json:"data"`
+}
+
+// HandlePipelineRuleDryRunHTTP runs a payload through a rule definition and responds
+// with converted frames, processed frames and outputs which would be executed, outputs
+// are not executed.
+func (g *GrafanaLive) HandlePipelineRuleDryRunHTTP(c *contextmodel.ReqContext) response.Response {
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error reading body", err)
+	}
+	var req PipelineRuleDryRunRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return response.Error(http.StatusBadRequest, "Error decoding request", err)
+	}
+	orgID := c.SignedInUser.GetOrgID()
+	req.Rule.OrgId = orgID
+	if ok, reason := req.Rule.Valid(); !ok {
+		return response.Error(http.StatusBadRequest, fmt.Sprintf("Invalid channel rule: %s", reason), nil)
+	}
+	if req.Channel == "" {
+		req.Channel = req.Rule.Pattern
+	}
+	storage := &DryRunRuleStorage{ChannelRules: []pipeline.ChannelRule{req.Rule}}
+	if g.pipelineStorage != nil {
+		storage.WriteConfigs, err = g.pipelineStorage.ListWriteConfigs(c.Req.Context(), orgID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get write configs", err)
+		}
+	}
+	// The rule is built once for the request, its outputs are closed after the dry run.
+	channelRuleGetter := pipeline.NewStaticSegmentedTree(g.pipelineRuleBuilder(storage))
+	defer func() { _ = channelRuleGetter.Close() }()
+	pipe, err := pipeline.New(channelRuleGetter)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error creating pipeline", err)
+	}
+	rule, ok, err := channelRuleGetter.Get(orgID, req.Channel)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Error building channel rule", err)
+	}
+	if !ok {
+		return response.Error(http.StatusBadRequest, "Channel does not match rule pattern", nil)
+	}
+	result, err := pipe.DryRun(c.Req.Context(), rule, orgID, req.Channel, []byte(req.Data))
+	if err != nil {
+		return response.Error(http.StatusUnprocessableEntity, fmt.Sprintf("Dry run failed: %s", err), err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+type PipelineSamplesTestRequest struct {
+	// ChannelRules are tested instead of rules kept in storage when set, so rules can
+	// be checked before they are deployed.
+	ChannelRules []pipeline.ChannelRule `json:"channelRules,omitempty"`
+}
+
+type PipelineSamplesTestResponse struct {
+	Results []pipeline.SampleResult `json:"results"`
+	Failed  int                     `json:"failed"`
+}
+
+// HandlePipelineSamplesTestHTTP runs sample payloads of channel rules through their rules.
+// Responds with 422 when any sample fails, so it can be used as a CI check.
+func (g *GrafanaLive) HandlePipelineSamplesTestHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.Samples == nil {
+		return response.Error(http.StatusBadRequest, "Rule samples require the entity store", nil)
+	}
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error reading body", err)
+	}
+	var req PipelineSamplesTestRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return response.Error(http.StatusBadRequest, "Error decoding request", err)
+		}
+	}
+	orgID := c.SignedInUser.GetOrgID()
+	storage := &DryRunRuleStorage{ChannelRules: req.ChannelRules}
+	if g.pipelineStorage != nil {
+		if storage.ChannelRules == nil {
+			storage.ChannelRules, err = g.pipelineStorage.ListChannelRules(c.Req.Context(), orgID)
+			if err != nil {
+				return response.Error(http.StatusInternalServerError, "Failed to get channel rules", err)
+			}
+		}
+		storage.WriteConfigs, err = g.pipelineStorage.ListWriteConfigs(c.Req.Context(), orgID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get write configs", err)
+		}
+	}
+	// Rules are built once for the request, their outputs are closed when samples are run.
+	rules := pipeline.NewStaticSegmentedTree(g.pipelineRuleBuilder(storage))
+	defer func() { _ = rules.Close() }()
+	pipe, err := pipeline.New(rules)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error creating pipeline", err)
+	}
+	results := pipeline.RunRuleSamples(c.Req.Context(), pipe, orgID, storage.ChannelRules, g.Samples)
+	rsp := PipelineSamplesTestResponse{Results: results}
+	for _, r := range results {
+		if !r.Passed {
+			rsp.Failed++
+		}
+	}
+	if rsp.Failed > 0 {
+		return response.JSON(http.StatusUnprocessableEntity, rsp)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
 // HandleChannelRulesPostHTTP ...
 func (g *GrafanaLive) HandleChannelRulesPostHTTP(c *contextmodel.ReqContext) response.Response {
 	body, err := io.ReadAll(c.Req.Body)
@@ -1202,6 +1754,262 @@ func (g *GrafanaLive) HandleChannelRulesDeleteHTTP(c *contextmodel.ReqContext) r
 	return response.JSON(http.StatusOK, util.DynMap{})
 }
 
+type LintRequest struct {
+	Rules []pipeline.ChannelRule `json:"rules"`
+	// WriteConfigs to check outputs against, configured write configs are used when not set.
+	WriteConfigs []pipeline.WriteConfig `json:"writeConfigs,omitempty"`
+}
+
+// HandlePipelineLintHTTP checks a rules document for rules which are valid but most probably
+// do not work as intended.
+func (g *GrafanaLive) HandlePipelineLintHTTP(c *contextmodel.ReqContext) response.Response {
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error reading body", err)
+	}
+	var req LintRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Error decoding request", err)
+	}
+	writeConfigs := req.WriteConfigs
+	if writeConfigs == nil && g.pipelineStorage != nil {
+		writeConfigs, err = g.pipelineStorage.ListWriteConfigs(c.Req.Context(), c.SignedInUser.GetOrgID())
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get write configs", err)
+		}
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"warnings": pipeline.LintRules(req.Rules, writeConfigs),
+	})
+}
+
+// HandlePipelineExportHTTP exports rules passed in pattern query parameters, or rules with
+// a pattern prefix, as a YAML bundle with write configs the rules reference.
+func (g *GrafanaLive) HandlePipelineExportHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.pipelineStorage == nil {
+		return response.Error(http.StatusNotFound, "Pipeline is not enabled", nil)
+	}
+	patterns := c.QueryStrings("pattern")
+	prefix := c.Query("prefix")
+	if len(patterns) == 0 && prefix == "" {
+		return response.Error(http.StatusBadRequest, "Rule pattern or prefix required", nil)
+	}
+	bundle, err := pipeline.ExportRuleBundle(c.Req.Context(), g.pipelineStorage, c.SignedInUser.GetOrgID(), patterns, prefix)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to export channel rules", err)
+	}
+	if len(bundle.Rules) == 0 {
+		return response.Error(http.StatusNotFound, "No rules found", nil)
+	}
+	body, err := pipeline.MarshalRuleBundleYAML(bundle)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error encoding rule bundle", err)
+	}
+	return response.Respond(http.StatusOK, body).
+		SetHeader("Content-Type", "application/yaml").
+		SetHeader("Content-Disposition", `attachment;filename="live-pipeline-rules.yaml"`)
+}
+
+// HandlePipelineImportHTTP imports a YAML rule bundle. Rules and write configs which exist
+// with a different content are conflicts, nothing is imported when there are conflicts
+// unless overwrite query parameter is true.
+func (g *GrafanaLive) HandlePipelineImportHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.pipelineStorage == nil {
+		return response.Error(http.StatusNotFound, "Pipeline is not enabled", nil)
+	}
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error reading body", err)
+	}
+	bundle, err := pipeline.UnmarshalRuleBundleYAML(body)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Error decoding rule bundle", err)
+	}
+	result, err := pipeline.ImportRuleBundle(c.Req.Context(), g.pipelineStorage, c.SignedInUser.GetOrgID(), bundle, c.QueryBool("overwrite"))
+	if err != nil {
+		if errors.Is(err, pipeline.ErrRuleBundleChecksum) {
+			return response.Error(http.StatusBadRequest, "Rule bundle was modified after export", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to import rule bundle", err)
+	}
+	if len(result.Conflicts) > 0 {
+		return response.JSON(http.StatusConflict, result)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// HandleDeadLettersListHTTP returns pipeline dead letters of the current organization.
+func (g *GrafanaLive) HandleDeadLettersListHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.DeadLetters == nil {
+		return response.Error(http.StatusNotFound, "Dead-letter queue is not enabled", nil)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"deadLetters": g.DeadLetters.List(c.SignedInUser.GetOrgID()),
+	})
+}
+
+// HandlePipelineMessageTraceHTTP returns events of a pipeline message of the current
+// organization by message ID, messages are kept for the configured retention only.
+func (g *GrafanaLive) HandlePipelineMessageTraceHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.MessageTraces == nil {
+		return response.Error(http.StatusNotFound, "Message tracing is not enabled", nil)
+	}
+	trace, ok := g.MessageTraces.Get(c.SignedInUser.GetOrgID(), web.Params(c.Req)[":messageId"])
+	if !ok {
+		return response.Error(http.StatusNotFound, "Message not found, it may be older than the retention", nil)
+	}
+	return response.JSON(http.StatusOK, trace)
+}
+
+// HandleRemoteWriteHealthHTTP returns traffic and health of remote write backends used
+// by pipeline outputs of the current organization.
+func (g *GrafanaLive) HandleRemoteWriteHealthHTTP(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, util.DynMap{
+		"backends": pipeline.RemoteWriteBackends.List(c.SignedInUser.GetOrgID()),
+	})
+}
+
+// HandlePipelineProfileHTTP captures a CPU profile for seconds query parameter while
+// stages of a rule passed in pattern query parameter are labeled, and returns it in
+// pprof format.
+func (g *GrafanaLive) HandlePipelineProfileHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.Pipeline == nil || g.PipelineProfiler == nil {
+		return response.Error(http.StatusNotFound, "Pipeline is not enabled", nil)
+	}
+	pattern := c.Query("pattern")
+	if pattern == "" {
+		return response.Error(http.StatusBadRequest, "Rule pattern required", nil)
+	}
+	seconds := c.QueryInt64WithDefault("seconds", 10)
+	duration := time.Duration(seconds) * time.Second
+	if seconds <= 0 || duration > pipeline.MaxProfileDuration {
+		return response.Error(http.StatusBadRequest, fmt.Sprintf("Profile duration must be between 1 and %d seconds", int(pipeline.MaxProfileDuration.Seconds())), nil)
+	}
+	if g.pipelineStorage != nil {
+		rules, err := g.pipelineStorage.ListChannelRules(c.Req.Context(), c.SignedInUser.GetOrgID())
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get channel rules", err)
+		}
+		found := false
+		for _, rule := range rules {
+			if rule.Pattern == pattern {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return response.Error(http.StatusNotFound, "Channel rule not found", nil)
+		}
+	}
+	var buf bytes.Buffer
+	err := g.PipelineProfiler.Profile(c.Req.Context(), c.SignedInUser.GetOrgID(), pattern, duration, &buf)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrProfilingInProgress) {
+			return response.Error(http.StatusConflict, "Profiling is already in progress", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to capture profile", err)
+	}
+	return response.Respond(http.StatusOK, buf.Bytes()).
+		SetHeader("Content-Type", "application/octet-stream").
+		SetHeader("Content-Disposition", `attachment; filename="pipeline-profile.pb.gz"`)
+}
+
+// HandleHistoryHTTP replays history of a managed stream channel passed in channel query
+// parameter across history tiers. Optional from and to query parameters are epoch
+// milliseconds, last hour by default, limit keeps only the latest frames.
+func (g *GrafanaLive) HandleHistoryHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.managedStreamHistory == nil {
+		return response.Error(http.StatusNotFound, "Managed stream history is not enabled", nil)
+	}
+	channel := c.Query("channel")
+	if channel == "" {
+		return response.Error(http.StatusBadRequest, "Channel required", nil)
+	}
+	to := time.Now()
+	if ms := c.QueryInt64("to"); ms > 0 {
+		to = time.UnixMilli(ms)
+	}
+	from := to.Add(-time.Hour)
+	if ms := c.QueryInt64("from"); ms > 0 {
+		from = time.UnixMilli(ms)
+	}
+	entries, err := g.ManagedStreamRunner.History(c.Req.Context(), c.SignedInUser.GetOrgID(), channel, from, to, c.QueryInt("limit"))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get channel history", err)
+	}
+	if entries == nil {
+		entries = []managedstream.HistoryEntry{}
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"frames": entries,
+	})
+}
+
+// HandleDebugFramesHTTP returns last frames captured by pipeline debug outputs in
+// the current organization, optionally filtered by channel query parameter.
+func (g *GrafanaLive) HandleDebugFramesHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.DebugFrames == nil {
+		return response.Error(http.StatusNotFound, "Debug frame buffer is not enabled", nil)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"frames": g.DebugFrames.List(c.SignedInUser.GetOrgID(), c.Query("channel")),
+	})
+}
+
+// HandleDeadLettersDeleteHTTP removes pipeline dead letters of the current organization
+// passed in id query parameters, or all of them when no ids passed.
+func (g *GrafanaLive) HandleDeadLettersDeleteHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.DeadLetters == nil {
+		return response.Error(http.StatusNotFound, "Dead-letter queue is not enabled", nil)
+	}
+	g.DeadLetters.Remove(c.SignedInUser.GetOrgID(), c.QueryStrings("id")...)
+	return response.JSON(http.StatusOK, util.DynMap{})
+}
+
+type DeadLettersReplayRequest struct {
+	// IDs of dead letters to replay, all dead letters of an organization are replayed when empty.
+	IDs []string `json:"ids,omitempty"`
+	// ChannelRules to replay dead letters with instead of configured rules, allows
+	// fixing a rule which caused failures before replaying.
+	ChannelRules []pipeline.ChannelRule `json:"channelRules,omitempty"`
+}
+
+// HandleDeadLettersReplayHTTP passes pipeline dead letters through the pipeline again,
+// replayed dead letters are removed from the queue.
+func (g *GrafanaLive) HandleDeadLettersReplayHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.DeadLetters == nil {
+		return response.Error(http.StatusNotFound, "Dead-letter queue is not enabled", nil)
+	}
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error reading body", err)
+	}
+	var req DeadLettersReplayRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Error decoding request", err)
+	}
+	pipe := g.Pipeline
+	if req.ChannelRules != nil {
+		// Rules are built once for the request, their outputs are closed when replay is done.
+		rules := pipeline.NewStaticSegmentedTree(g.pipelineRuleBuilder(&DryRunRuleStorage{ChannelRules: req.ChannelRules}))
+		defer func() { _ = rules.Close() }()
+		pipe, err = pipeline.New(rules)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Error creating pipeline", err)
+		}
+	}
+	if pipe == nil {
+		return response.Error(http.StatusBadRequest, "Pipeline is not enabled, channel rules required", nil)
+	}
+	result, err := pipe.ReplayDeadLetters(c.Req.Context(), g.DeadLetters, c.SignedInUser.GetOrgID(), req.IDs)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Error replaying dead letters", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 // HandlePipelineEntitiesListHTTP ...
 func (g *GrafanaLive) HandlePipelineEntitiesListHTTP(_ *contextmodel.ReqContext) response.Response {
 	return response.JSON(http.StatusOK, util.DynMap{
@@ -1313,6 +2121,40 @@ func (g *GrafanaLive) HandleWriteConfigsDeleteHTTP(c *contextmodel.ReqContext) r
 	return response.JSON(http.StatusOK, util.DynMap{})
 }
 
+// HandleWriteConfigsCatalogHTTP returns write configs rules of the organization can
+// reference by name. Organization admins get all configs of the organization and
+// configs shared by other organizations, other users get configs shared with their teams.
+func (g *GrafanaLive) HandleWriteConfigsCatalogHTTP(c *contextmodel.ReqContext) response.Response {
+	if g.pipelineStorage == nil {
+		return response.Error(http.StatusNotFound, "Pipeline is not enabled", nil)
+	}
+	orgID := c.SignedInUser.GetOrgID()
+	writeConfigs, err := g.pipelineStorage.ListWriteConfigs(c.Req.Context(), orgID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get write configs", err)
+	}
+	isAdmin := c.SignedInUser.HasRole(org.RoleAdmin)
+	result := make([]pipeline.WriteConfigCatalogEntry, 0, len(writeConfigs))
+	for _, wc := range writeConfigs {
+		if !isAdmin && !wc.SharedWithTeams(c.SignedInUser.GetTeams()) {
+			continue
+		}
+		result = append(result, pipeline.WriteConfigCatalogEntry{OrgId: wc.OrgId, UID: wc.UID, Name: wc.Name})
+	}
+	if lister, ok := g.pipelineStorage.(pipeline.SharedWriteConfigLister); ok && isAdmin {
+		shared, err := lister.ListSharedWriteConfigs(c.Req.Context(), orgID)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get shared write configs", err)
+		}
+		for _, wc := range shared {
+			result = append(result, pipeline.WriteConfigCatalogEntry{OrgId: wc.OrgId, UID: wc.UID, Name: wc.Name, Shared: true})
+		}
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"writeConfigs": result,
+	})
+}
+
 // Write to the standard log15 logger
 func handleLog(msg centrifuge.LogEntry) {
 	arr := make([]interface{}, 0)
@@ -199,3 +199,27 @@ func Test_getHistogramMetric(t *testing.T) {
 		})
 	}
 }
+
+func Test_channelFilter(t *testing.T) {
+	f, err := newChannelFilter("", "")
+	require.NoError(t, err)
+	require.True(t, f.match("stream/test/a"))
+
+	f, err = newChannelFilter("stream/test/a", "")
+	require.NoError(t, err)
+	require.True(t, f.match("stream/test/a"))
+	require.False(t, f.match("stream/test/b"))
+
+	f, err = newChannelFilter("", "stream/test/*")
+	require.NoError(t, err)
+	require.True(t, f.match("stream/test/a"))
+	require.False(t, f.match("stream/test/a/b"))
+	require.False(t, f.match("plugin/test/a"))
+
+	f, err = newChannelFilter("", "stream/**")
+	require.NoError(t, err)
+	require.True(t, f.match("stream/test/a/b"))
+
+	_, err = newChannelFilter("", "stream/[")
+	require.Error(t, err)
+}
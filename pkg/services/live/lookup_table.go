@@ -0,0 +1,44 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+// entityLookupTableGetter reads lookup tables of pipeline value map processors from
+// jsonobj entities with an object of mappings like {"0": "Stopped", "1": "Running"}.
+type entityLookupTableGetter struct {
+	store entity.EntityStoreServer
+}
+
+var _ pipeline.LookupTableGetter = &entityLookupTableGetter{}
+
+func (g *entityLookupTableGetter) GetLookupTable(ctx context.Context, orgID int64, uid string) (map[string]string, string, error) {
+	rsp, err := readJSONObjEntity(ctx, g.store, orgID, uid)
+	if err != nil {
+		return nil, "", err
+	}
+	if rsp.GRN == nil {
+		return nil, "", fmt.Errorf("lookup table entity not found: %s", uid)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(rsp.Body, &values); err != nil {
+		return nil, "", fmt.Errorf("lookup table %s must be a JSON object: %w", uid, err)
+	}
+	table := make(map[string]string, len(values))
+	for k, v := range values {
+		switch value := v.(type) {
+		case string:
+			table[k] = value
+		case float64, bool:
+			table[k] = fmt.Sprint(value)
+		default:
+			return nil, "", fmt.Errorf("lookup table %s: value of %s must be a string", uid, k)
+		}
+	}
+	return table, rsp.Version, nil
+}
@@ -15,4 +15,6 @@ type FrameCache interface {
 	GetFrame(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error)
 	// Update updates frame cache and returns true if schema changed.
 	Update(ctx context.Context, orgID int64, channel string, frameJson data.FrameJSONCache) (bool, error)
+	// Delete removes a channel frame from cache.
+	Delete(ctx context.Context, orgID int64, channel string) error
 }
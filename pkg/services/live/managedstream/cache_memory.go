@@ -53,3 +53,13 @@ func (c *MemoryFrameCache) Update(ctx context.Context, orgID int64, channel stri
 	c.frames[orgID][channel] = jsonFrame
 	return schemaUpdated, nil
 }
+
+func (c *MemoryFrameCache) Delete(_ context.Context, orgID int64, channel string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.frames[orgID], channel)
+	if len(c.frames[orgID]) == 0 {
+		delete(c.frames, orgID)
+	}
+	return nil
+}
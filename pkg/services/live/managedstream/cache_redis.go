@@ -107,6 +107,18 @@ func (c *RedisFrameCache) Update(ctx context.Context, orgID int64, channel strin
 	return true, nil
 }
 
+// Delete removes a channel frame from local state only. The channel can be active on
+// other nodes, so frames shared over Redis expire by frameCacheTTL.
+func (c *RedisFrameCache) Delete(_ context.Context, orgID int64, channel string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.frames[orgID], channel)
+	if len(c.frames[orgID]) == 0 {
+		delete(c.frames, orgID)
+	}
+	return nil
+}
+
 func getCacheKey(channelID string) string {
 	return "gf_live.managed_stream." + channelID
 }
@@ -0,0 +1,203 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// HistoryEntry is a frame pushed to a managed stream channel at Time.
+type HistoryEntry struct {
+	Time  time.Time       `json:"time"`
+	Frame json.RawMessage `json:"frame"`
+}
+
+// HistoryTier keeps a part of channel history.
+type HistoryTier interface {
+	// Append adds entries ordered by time and returns entries evicted from the tier,
+	// which are passed to the next tier.
+	Append(ctx context.Context, orgID int64, channel string, entries []HistoryEntry) ([]HistoryEntry, error)
+	// Range returns entries with time in [from, to].
+	Range(ctx context.Context, orgID int64, channel string, from, to time.Time) ([]HistoryEntry, error)
+}
+
+// TieredHistory keeps history of managed stream channels in tiers, like recent frames
+// in memory, older frames in Redis and long-tail history in the entity store. Frames
+// are appended to the first tier, frames evicted from a tier move to the next one and
+// frames evicted from the last tier are dropped.
+type TieredHistory struct {
+	tiers []HistoryTier
+}
+
+func NewTieredHistory(tiers ...HistoryTier) *TieredHistory {
+	return &TieredHistory{tiers: tiers}
+}
+
+// Append adds a frame to channel history.
+func (h *TieredHistory) Append(ctx context.Context, orgID int64, channel string, entry HistoryEntry) error {
+	entries := []HistoryEntry{entry}
+	for _, tier := range h.tiers {
+		if len(entries) == 0 {
+			return nil
+		}
+		var err error
+		entries, err = tier.Append(ctx, orgID, channel, entries)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range returns frames of channel history with time in [from, to] from all tiers
+// ordered by time. When limit is positive only the latest limit frames are returned.
+func (h *TieredHistory) Range(ctx context.Context, orgID int64, channel string, from, to time.Time, limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	for _, tier := range h.tiers {
+		tierEntries, err := tier.Range(ctx, orgID, channel, from, to)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, tierEntries...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// Flush writes entries buffered by tiers, it's called on shutdown.
+func (h *TieredHistory) Flush(ctx context.Context) error {
+	for _, tier := range h.tiers {
+		if f, ok := tier.(interface{ Flush(context.Context) error }); ok {
+			if err := f.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func entriesInRange(entries []HistoryEntry, from, to time.Time) []HistoryEntry {
+	var result []HistoryEntry
+	for _, e := range entries {
+		if !e.Time.Before(from) && !e.Time.After(to) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// MemoryHistoryTier keeps the latest MaxFrames frames of each channel in memory.
+type MemoryHistoryTier struct {
+	maxFrames int
+
+	mu       sync.RWMutex
+	channels map[string][]HistoryEntry
+}
+
+func NewMemoryHistoryTier(maxFrames int) *MemoryHistoryTier {
+	return &MemoryHistoryTier{maxFrames: maxFrames, channels: map[string][]HistoryEntry{}}
+}
+
+func (t *MemoryHistoryTier) Append(_ context.Context, orgID int64, channel string, entries []HistoryEntry) ([]HistoryEntry, error) {
+	key := orgchannel.PrependOrgID(orgID, channel)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	channelEntries := append(t.channels[key], entries...)
+	var evicted []HistoryEntry
+	if len(channelEntries) > t.maxFrames {
+		n := len(channelEntries) - t.maxFrames
+		evicted = append(evicted, channelEntries[:n]...)
+		channelEntries = append([]HistoryEntry(nil), channelEntries[n:]...)
+	}
+	t.channels[key] = channelEntries
+	return evicted, nil
+}
+
+func (t *MemoryHistoryTier) Range(_ context.Context, orgID int64, channel string, from, to time.Time) ([]HistoryEntry, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return entriesInRange(t.channels[orgchannel.PrependOrgID(orgID, channel)], from, to), nil
+}
+
+// HistoryChunkStore saves chunks of channel history, like to the entity store.
+type HistoryChunkStore interface {
+	WriteChunk(ctx context.Context, orgID int64, channel string, entries []HistoryEntry) error
+	// ReadChunks returns entries of chunks which may have entries in [from, to].
+	ReadChunks(ctx context.Context, orgID int64, channel string, from, to time.Time) ([]HistoryEntry, error)
+}
+
+// ChunkHistoryTier buffers frames of each channel and writes them to a chunk store in
+// chunks of ChunkFrames, it never evicts frames.
+type ChunkHistoryTier struct {
+	store       HistoryChunkStore
+	chunkFrames int
+
+	mu      sync.Mutex
+	buffers map[string]*chunkBuffer
+}
+
+type chunkBuffer struct {
+	orgID   int64
+	channel string
+	entries []HistoryEntry
+}
+
+func NewChunkHistoryTier(store HistoryChunkStore, chunkFrames int) *ChunkHistoryTier {
+	return &ChunkHistoryTier{store: store, chunkFrames: chunkFrames, buffers: map[string]*chunkBuffer{}}
+}
+
+func (t *ChunkHistoryTier) Append(ctx context.Context, orgID int64, channel string, entries []HistoryEntry) ([]HistoryEntry, error) {
+	key := orgchannel.PrependOrgID(orgID, channel)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.buffers[key]
+	if !ok {
+		b = &chunkBuffer{orgID: orgID, channel: channel}
+		t.buffers[key] = b
+	}
+	b.entries = append(b.entries, entries...)
+	if len(b.entries) < t.chunkFrames {
+		return nil, nil
+	}
+	if err := t.store.WriteChunk(ctx, orgID, channel, b.entries); err != nil {
+		return nil, err
+	}
+	delete(t.buffers, key)
+	return nil, nil
+}
+
+func (t *ChunkHistoryTier) Range(ctx context.Context, orgID int64, channel string, from, to time.Time) ([]HistoryEntry, error) {
+	entries, err := t.store.ReadChunks(ctx, orgID, channel, from, to)
+	if err != nil {
+		return nil, err
+	}
+	entries = entriesInRange(entries, from, to)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b, ok := t.buffers[orgchannel.PrependOrgID(orgID, channel)]; ok {
+		entries = append(entries, entriesInRange(b.entries, from, to)...)
+	}
+	return entries, nil
+}
+
+// Flush writes buffered entries as partial chunks.
+func (t *ChunkHistoryTier) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, b := range t.buffers {
+		if err := t.store.WriteChunk(ctx, b.orgID, b.channel, b.entries); err != nil {
+			return err
+		}
+		delete(t.buffers, key)
+	}
+	return nil
+}
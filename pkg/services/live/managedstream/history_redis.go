@@ -0,0 +1,83 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// RedisHistoryTier keeps frames of each channel for MaxAge in Redis sorted sets scored
+// by frame time, so history is shared by all nodes.
+type RedisHistoryTier struct {
+	redisClient *redis.Client
+	maxAge      time.Duration
+	now         func() time.Time
+}
+
+func NewRedisHistoryTier(redisClient *redis.Client, maxAge time.Duration) *RedisHistoryTier {
+	return &RedisHistoryTier{redisClient: redisClient, maxAge: maxAge, now: time.Now}
+}
+
+func getHistoryKey(channelID string) string {
+	return "gf_live.managed_stream_history." + channelID
+}
+
+func historyScore(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func (t *RedisHistoryTier) Append(ctx context.Context, orgID int64, channel string, entries []HistoryEntry) ([]HistoryEntry, error) {
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+	members := make([]*redis.Z, 0, len(entries))
+	for _, e := range entries {
+		member, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, &redis.Z{Score: float64(e.Time.UnixMilli()), Member: member})
+	}
+	maxScore := "(" + historyScore(t.now().Add(-t.maxAge))
+
+	// Expired entries are read and removed in a transaction, so only one node
+	// passes them to the next tier.
+	pipe := t.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+	pipe.ZAdd(ctx, key, members...)
+	expired := pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: maxScore})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", maxScore)
+	pipe.Expire(ctx, key, frameCacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return decodeHistoryEntries(expired.Val())
+}
+
+func (t *RedisHistoryTier) Range(ctx context.Context, orgID int64, channel string, from, to time.Time) ([]HistoryEntry, error) {
+	key := getHistoryKey(orgchannel.PrependOrgID(orgID, channel))
+	members, err := t.redisClient.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: historyScore(from), Max: historyScore(to)}).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := decodeHistoryEntries(members)
+	if err != nil {
+		return nil, err
+	}
+	return entriesInRange(entries, from, to), nil
+}
+
+func decodeHistoryEntries(members []string) ([]HistoryEntry, error) {
+	entries := make([]HistoryEntry, 0, len(members))
+	for _, m := range members {
+		var e HistoryEntry
+		if err := json.Unmarshal([]byte(m), &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
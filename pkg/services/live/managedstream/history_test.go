@@ -0,0 +1,65 @@
+package managedstream
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testChunkStore struct {
+	mu     sync.Mutex
+	chunks [][]HistoryEntry
+}
+
+func (s *testChunkStore) WriteChunk(_ context.Context, _ int64, _ string, entries []HistoryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = append(s.chunks, append([]HistoryEntry(nil), entries...))
+	return nil
+}
+
+func (s *testChunkStore) ReadChunks(_ context.Context, _ int64, _ string, _, _ time.Time) ([]HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []HistoryEntry
+	for _, chunk := range s.chunks {
+		entries = append(entries, chunk...)
+	}
+	return entries, nil
+}
+
+func TestTieredHistory(t *testing.T) {
+	chunks := &testChunkStore{}
+	history := NewTieredHistory(NewMemoryHistoryTier(2), NewChunkHistoryTier(chunks, 2))
+
+	start := time.Unix(1000, 0)
+	for i := 0; i < 7; i++ {
+		err := history.Append(context.Background(), 1, "stream/test/history", HistoryEntry{
+			Time:  start.Add(time.Duration(i) * time.Second),
+			Frame: json.RawMessage(`{}`),
+		})
+		require.NoError(t, err)
+	}
+	// 2 latest entries are in memory, 4 evicted are written in chunks of 2, one is buffered.
+	require.Len(t, chunks.chunks, 2)
+
+	entries, err := history.Range(context.Background(), 1, "stream/test/history", start, start.Add(time.Minute), 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 7)
+	for i, e := range entries {
+		require.Equal(t, start.Add(time.Duration(i)*time.Second), e.Time)
+	}
+
+	entries, err = history.Range(context.Background(), 1, "stream/test/history", start.Add(2*time.Second), start.Add(5*time.Second), 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, start.Add(4*time.Second), entries[0].Time)
+	require.Equal(t, start.Add(5*time.Second), entries[1].Time)
+
+	require.NoError(t, history.Flush(context.Background()))
+	require.Len(t, chunks.chunks, 3)
+}
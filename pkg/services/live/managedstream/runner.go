@@ -42,6 +42,8 @@ type Runner struct {
 	publisher      model.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	usage          *usageTracker
+	history        *TieredHistory
 }
 
 type LocalPublisher interface {
@@ -55,6 +57,7 @@ func NewRunner(publisher model.ChannelPublisher, localPublisher LocalPublisher,
 		localPublisher: localPublisher,
 		streams:        map[int64]map[string]*NamespaceStream{},
 		frameCache:     frameCache,
+		usage:          newUsageTracker(),
 	}
 }
 
@@ -113,6 +116,48 @@ func (r *Runner) GetManagedChannels(orgID int64) ([]*ManagedChannel, error) {
 	return channels, nil
 }
 
+// SetHistory enables keeping history of channel frames, it must be called before
+// streams are created.
+func (r *Runner) SetHistory(history *TieredHistory) {
+	r.history = history
+}
+
+// History returns frames of a managed channel with time in [from, to], nil when
+// history is not enabled.
+func (r *Runner) History(ctx context.Context, orgID int64, channel string, from, to time.Time, limit int) ([]HistoryEntry, error) {
+	if r.history == nil {
+		return nil, nil
+	}
+	return r.history.Range(ctx, orgID, channel, from, to, limit)
+}
+
+// GetFrame returns the last frame of a managed channel as JSON.
+func (r *Runner) GetFrame(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error) {
+	return r.frameCache.GetFrame(ctx, orgID, channel)
+}
+
+// GetSchema returns a frame without rows with fields of the last frame published to a
+// managed channel. It's used as a schema frames published to the channel are expected
+// to follow.
+func (r *Runner) GetSchema(ctx context.Context, orgID int64, channel string) (*data.Frame, bool, error) {
+	frameJSON, ok, err := r.frameCache.GetFrame(ctx, orgID, channel)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var frame data.Frame
+	if err := json.Unmarshal(frameJSON, &frame); err != nil {
+		return nil, false, fmt.Errorf("can't unmarshal channel frame: %w", err)
+	}
+	schema := data.NewFrame(frame.Name)
+	for _, f := range frame.Fields {
+		field := data.NewFieldFromFieldType(f.Type(), 0)
+		field.Name = f.Name
+		field.Labels = f.Labels
+		schema.Fields = append(schema.Fields, field)
+	}
+	return schema, true, nil
+}
+
 // GetOrCreateStream -- for now this will create new manager for each key.
 // Eventually, the stream behavior will need to be configured explicitly
 func (r *Runner) GetOrCreateStream(orgID int64, scope string, namespace string) (*NamespaceStream, error) {
@@ -126,6 +171,8 @@ func (r *Runner) GetOrCreateStream(orgID int64, scope string, namespace string)
 	s, ok := r.streams[orgID][prefix]
 	if !ok {
 		s = NewNamespaceStream(orgID, scope, namespace, r.publisher, r.localPublisher, r.frameCache)
+		s.usage = r.usage
+		s.history = r.history
 		r.streams[orgID][prefix] = s
 	}
 	return s, nil
@@ -141,6 +188,10 @@ type NamespaceStream struct {
 	frameCache     FrameCache
 	rateMu         sync.RWMutex
 	rates          map[string][60]rateEntry
+	// usage is shared by streams of a Runner, nil for streams created outside of it.
+	usage *usageTracker
+	// history keeps pushed frames when enabled.
+	history *TieredHistory
 }
 
 type rateEntry struct {
@@ -195,7 +246,17 @@ func (s *NamespaceStream) Push(ctx context.Context, path string, frame *data.Fra
 	frameJSON := jsonFrameCache.Bytes(include)
 
 	logger.Debug("Publish data to channel", "channel", channel, "dataLength", len(frameJSON))
-	s.incRate(path, time.Now().Unix())
+	now := time.Now()
+	s.incRate(path, now.Unix())
+	if s.usage != nil {
+		s.usage.publish(s.orgID, channel, now)
+	}
+	if s.history != nil {
+		// History is best effort, failures don't affect publishing.
+		if err := s.history.Append(ctx, s.orgID, channel, HistoryEntry{Time: now, Frame: jsonFrameCache.Bytes(data.IncludeAll)}); err != nil {
+			logger.Error("Error appending managed stream history", "channel", channel, "error", err)
+		}
+	}
 	if s.scope == live.ScopeDatasource || s.scope == live.ScopePlugin {
 		return s.localPublisher.PublishLocal(orgchannel.PrependOrgID(s.orgID, channel), frameJSON)
 	}
@@ -235,6 +296,14 @@ func (s *NamespaceStream) minuteRate(path string) int64 {
 	return total
 }
 
+// removePath drops rates of a path and returns a number of remaining paths.
+func (s *NamespaceStream) removePath(path string) int {
+	s.rateMu.Lock()
+	defer s.rateMu.Unlock()
+	delete(s.rates, path)
+	return len(s.rates)
+}
+
 func (s *NamespaceStream) GetHandlerForPath(_ string) (model.ChannelHandler, error) {
 	return s, nil
 }
@@ -248,6 +317,9 @@ func (s *NamespaceStream) OnSubscribe(ctx context.Context, u identity.Requester,
 	if ok {
 		reply.Data = frameJSON
 	}
+	if s.usage != nil {
+		s.usage.subscribe(u.GetOrgID(), e.Channel, time.Now())
+	}
 	return reply, backend.SubscribeStreamStatusOK, nil
 }
 
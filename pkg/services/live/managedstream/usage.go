@@ -0,0 +1,193 @@
+package managedstream
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+)
+
+// ChannelUsage describes activity of a managed stream channel since the channel was first
+// used on this instance. Times are in epoch milliseconds, zero when the event never happened.
+type ChannelUsage struct {
+	Channel       string `json:"channel"`
+	Publishes     int64  `json:"publishes"`
+	Subscriptions int64  `json:"subscriptions"`
+	MinuteRate    int64  `json:"minuteRate"`
+	FirstSeen     int64  `json:"firstSeen"`
+	LastPublish   int64  `json:"lastPublish,omitempty"`
+	LastSubscribe int64  `json:"lastSubscribe,omitempty"`
+	// LastActivity is the latest of LastPublish and LastSubscribe.
+	LastActivity int64 `json:"lastActivity"`
+}
+
+// usageTracker keeps ChannelUsage of managed stream channels by organization.
+type usageTracker struct {
+	mu       sync.RWMutex
+	channels map[int64]map[string]*ChannelUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{channels: map[int64]map[string]*ChannelUsage{}}
+}
+
+func (t *usageTracker) record(orgID int64, channel string, now time.Time, fn func(u *ChannelUsage, nowMs int64)) {
+	nowMs := now.UnixMilli()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	channels, ok := t.channels[orgID]
+	if !ok {
+		channels = map[string]*ChannelUsage{}
+		t.channels[orgID] = channels
+	}
+	u, ok := channels[channel]
+	if !ok {
+		u = &ChannelUsage{Channel: channel, FirstSeen: nowMs}
+		channels[channel] = u
+	}
+	fn(u, nowMs)
+	u.LastActivity = nowMs
+}
+
+func (t *usageTracker) publish(orgID int64, channel string, now time.Time) {
+	t.record(orgID, channel, now, func(u *ChannelUsage, nowMs int64) {
+		u.Publishes++
+		u.LastPublish = nowMs
+	})
+}
+
+func (t *usageTracker) subscribe(orgID int64, channel string, now time.Time) {
+	t.record(orgID, channel, now, func(u *ChannelUsage, nowMs int64) {
+		u.Subscriptions++
+		u.LastSubscribe = nowMs
+	})
+}
+
+func (t *usageTracker) list(orgID int64) []ChannelUsage {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := make([]ChannelUsage, 0, len(t.channels[orgID]))
+	for _, u := range t.channels[orgID] {
+		result = append(result, *u)
+	}
+	return result
+}
+
+// idle returns channels of all organizations without activity since a time.
+func (t *usageTracker) idle(since time.Time) map[int64][]string {
+	sinceMs := since.UnixMilli()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := map[int64][]string{}
+	for orgID, channels := range t.channels {
+		for ch, u := range channels {
+			if u.LastActivity < sinceMs {
+				result[orgID] = append(result[orgID], ch)
+			}
+		}
+	}
+	return result
+}
+
+func (t *usageTracker) remove(orgID int64, channel string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.channels[orgID], channel)
+	if len(t.channels[orgID]) == 0 {
+		delete(t.channels, orgID)
+	}
+}
+
+// GetChannelUsage returns usage of managed stream channels of an organization, the most
+// active channels (by publishes) first.
+func (r *Runner) GetChannelUsage(orgID int64) []ChannelUsage {
+	usage := r.usage.list(orgID)
+	r.mu.RLock()
+	for i, u := range usage {
+		channel, err := live.ParseChannel(u.Channel)
+		if err != nil {
+			continue
+		}
+		if s, ok := r.streams[orgID][channel.Scope+"/"+channel.Namespace]; ok {
+			usage[i].MinuteRate = s.minuteRate(channel.Path)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Publishes != usage[j].Publishes {
+			return usage[i].Publishes > usage[j].Publishes
+		}
+		return usage[i].Channel < usage[j].Channel
+	})
+	return usage
+}
+
+// GetIdleChannels returns usage of managed stream channels of an organization without
+// publishes and subscriptions for a period, the longest idle channels first.
+func (r *Runner) GetIdleChannels(orgID int64, idleFor time.Duration, now time.Time) []ChannelUsage {
+	sinceMs := now.Add(-idleFor).UnixMilli()
+	var idle []ChannelUsage
+	for _, u := range r.usage.list(orgID) {
+		if u.LastActivity < sinceMs {
+			idle = append(idle, u)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		if idle[i].LastActivity != idle[j].LastActivity {
+			return idle[i].LastActivity < idle[j].LastActivity
+		}
+		return idle[i].Channel < idle[j].Channel
+	})
+	return idle
+}
+
+// CleanupIdleChannels tears down state of managed stream channels without activity for
+// a period: cached frames, rates and usage. Namespace streams without remaining channels
+// are removed. Returns a number of removed channels.
+func (r *Runner) CleanupIdleChannels(ctx context.Context, idleFor time.Duration, now time.Time) (int, error) {
+	removed := 0
+	for orgID, channels := range r.usage.idle(now.Add(-idleFor)) {
+		for _, ch := range channels {
+			if err := r.frameCache.Delete(ctx, orgID, ch); err != nil {
+				return removed, err
+			}
+			r.usage.remove(orgID, ch)
+			removed++
+
+			channel, err := live.ParseChannel(ch)
+			if err != nil {
+				continue
+			}
+			prefix := channel.Scope + "/" + channel.Namespace
+			r.mu.Lock()
+			if s, ok := r.streams[orgID][prefix]; ok && s.removePath(channel.Path) == 0 {
+				delete(r.streams[orgID], prefix)
+			}
+			r.mu.Unlock()
+		}
+	}
+	return removed, nil
+}
+
+// RunIdleCleanup periodically removes channels idle for a period until context is done.
+func (r *Runner) RunIdleCleanup(ctx context.Context, interval time.Duration, idleFor time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			removed, err := r.CleanupIdleChannels(ctx, idleFor, now)
+			if err != nil {
+				logger.Error("Error cleaning up idle managed stream channels", "error", err)
+				continue
+			}
+			if removed > 0 {
+				logger.Info("Cleaned up idle managed stream channels", "removed", removed)
+			}
+		}
+	}
+}
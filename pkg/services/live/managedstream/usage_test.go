@@ -0,0 +1,64 @@
+package managedstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestRunner_ChannelUsage(t *testing.T) {
+	publisher := &testPublisher{t: t}
+	frameCache := NewMemoryFrameCache()
+	runner := NewRunner(publisher.publish, nil, frameCache)
+	s, err := runner.GetOrCreateStream(1, "stream", "test")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Push(context.Background(), "cpu", data.NewFrame("cpu")))
+	require.NoError(t, s.Push(context.Background(), "cpu", data.NewFrame("cpu")))
+	require.NoError(t, s.Push(context.Background(), "mem", data.NewFrame("mem")))
+	_, _, err = s.OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{Channel: "stream/test/mem"})
+	require.NoError(t, err)
+
+	usage := runner.GetChannelUsage(1)
+	require.Len(t, usage, 2)
+	require.Equal(t, "stream/test/cpu", usage[0].Channel)
+	require.Equal(t, int64(2), usage[0].Publishes)
+	require.Equal(t, int64(2), usage[0].MinuteRate)
+	require.Equal(t, "stream/test/mem", usage[1].Channel)
+	require.Equal(t, int64(1), usage[1].Subscriptions)
+	require.NotZero(t, usage[1].LastSubscribe)
+	require.Empty(t, runner.GetChannelUsage(2))
+
+	now := time.Now()
+	require.Empty(t, runner.GetIdleChannels(1, time.Hour, now))
+	require.Len(t, runner.GetIdleChannels(1, time.Hour, now.Add(2*time.Hour)), 2)
+}
+
+func TestRunner_CleanupIdleChannels(t *testing.T) {
+	publisher := &testPublisher{t: t}
+	frameCache := NewMemoryFrameCache()
+	runner := NewRunner(publisher.publish, nil, frameCache)
+	s, err := runner.GetOrCreateStream(1, "stream", "test")
+	require.NoError(t, err)
+	require.NoError(t, s.Push(context.Background(), "cpu", data.NewFrame("cpu")))
+
+	removed, err := runner.CleanupIdleChannels(context.Background(), time.Hour, time.Now())
+	require.NoError(t, err)
+	require.Zero(t, removed)
+
+	removed, err = runner.CleanupIdleChannels(context.Background(), time.Hour, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	require.Empty(t, runner.GetChannelUsage(1))
+	_, ok, err := frameCache.GetFrame(context.Background(), 1, "stream/test/cpu")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, runner.streams[1])
+}
@@ -0,0 +1,351 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleBundleVersion is a version of rule bundle format.
+const RuleBundleVersion = 1
+
+// RuleBundle is a self-contained export of channel rules together with write configs
+// the rules reference, so rules can be shared between organizations and instances.
+// Secrets are never exported, bundle only references names of secure settings which
+// have to be set on write configs after import.
+type RuleBundle struct {
+	APIVersion   int                     `json:"apiVersion"`
+	Rules        []RuleBundleRule        `json:"rules"`
+	WriteConfigs []RuleBundleWriteConfig `json:"writeConfigs,omitempty"`
+	// Checksum of rules and write configs, import rejects bundles modified after export.
+	Checksum string `json:"checksum"`
+}
+
+type RuleBundleRule struct {
+	Pattern  string              `json:"pattern"`
+	Settings ChannelRuleSettings `json:"settings"`
+	// Checksum of rule settings, used to detect conflicts with existing rules on import.
+	Checksum string `json:"checksum"`
+}
+
+type RuleBundleWriteConfig struct {
+	UID      string        `json:"uid"`
+	Settings WriteSettings `json:"settings"`
+	// SecureSettings are names of secure settings of the exported write config.
+	SecureSettings []string `json:"secureSettings,omitempty"`
+	Checksum       string   `json:"checksum"`
+}
+
+// RuleBundleImportResult describes changes made by an import, when there are conflicts
+// nothing is imported.
+type RuleBundleImportResult struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	Conflicts []string `json:"conflicts"`
+	// MissingSecrets are secure settings of created or updated write configs which have
+	// to be set after import, "{uid}.{name}".
+	MissingSecrets []string `json:"missingSecrets"`
+}
+
+// ErrRuleBundleChecksum is returned when bundle content does not match its checksum.
+var ErrRuleBundleChecksum = errors.New("rule bundle checksum mismatch")
+
+// ExportRuleBundle exports rules of an organization matching one of patterns exactly, or
+// all rules which pattern starts with prefix when it's set, and write configs they reference.
+func ExportRuleBundle(ctx context.Context, storage Storage, orgID int64, patterns []string, prefix string) (RuleBundle, error) {
+	rules, err := storage.ListChannelRules(ctx, orgID)
+	if err != nil {
+		return RuleBundle{}, err
+	}
+	writeConfigs, err := storage.ListWriteConfigs(ctx, orgID)
+	if err != nil {
+		return RuleBundle{}, err
+	}
+	wanted := make(map[string]struct{}, len(patterns))
+	for _, p := range patterns {
+		wanted[p] = struct{}{}
+	}
+
+	bundle := RuleBundle{APIVersion: RuleBundleVersion, Rules: []RuleBundleRule{}}
+	uids := map[string]struct{}{}
+	for _, rule := range rules {
+		_, ok := wanted[rule.Pattern]
+		if !ok && (prefix == "" || !strings.HasPrefix(rule.Pattern, prefix)) {
+			continue
+		}
+		checksum, err := bundleChecksum(rule.Settings)
+		if err != nil {
+			return RuleBundle{}, err
+		}
+		bundle.Rules = append(bundle.Rules, RuleBundleRule{Pattern: rule.Pattern, Settings: rule.Settings, Checksum: checksum})
+		for _, uid := range ruleBackendUIDs(rule.Settings) {
+			uids[uid] = struct{}{}
+		}
+	}
+	for _, wc := range writeConfigs {
+		if _, ok := uids[wc.UID]; !ok {
+			continue
+		}
+		exported, err := bundleWriteConfig(wc)
+		if err != nil {
+			return RuleBundle{}, err
+		}
+		bundle.WriteConfigs = append(bundle.WriteConfigs, exported)
+	}
+	bundle.Checksum, err = bundle.contentChecksum()
+	if err != nil {
+		return RuleBundle{}, err
+	}
+	return bundle, nil
+}
+
+func bundleWriteConfig(wc WriteConfig) (RuleBundleWriteConfig, error) {
+	settings := wc.Settings
+	secureSettings := make([]string, 0, len(wc.SecureSettings))
+	for name := range wc.SecureSettings {
+		secureSettings = append(secureSettings, name)
+	}
+	if settings.BasicAuth != nil && settings.BasicAuth.Password != "" {
+		// Plain text password is a secret too, keep only a reference to it.
+		settings.BasicAuth = &BasicAuth{User: settings.BasicAuth.User}
+		secureSettings = append(secureSettings, SecureSettingBasicAuthPassword)
+	}
+	sort.Strings(secureSettings)
+	checksum, err := bundleChecksum(settings)
+	if err != nil {
+		return RuleBundleWriteConfig{}, err
+	}
+	return RuleBundleWriteConfig{
+		UID:            wc.UID,
+		Settings:       settings,
+		SecureSettings: secureSettings,
+		Checksum:       checksum,
+	}, nil
+}
+
+// ImportRuleBundle creates rules and write configs of a bundle in an organization. Rules
+// and write configs which already exist with different content are conflicts, they are
+// updated only with overwrite, otherwise nothing is imported and conflicts are returned.
+func ImportRuleBundle(ctx context.Context, storage Storage, orgID int64, bundle RuleBundle, overwrite bool) (RuleBundleImportResult, error) {
+	result := RuleBundleImportResult{
+		Created:        []string{},
+		Updated:        []string{},
+		Unchanged:      []string{},
+		Conflicts:      []string{},
+		MissingSecrets: []string{},
+	}
+	if err := bundle.verify(); err != nil {
+		return result, err
+	}
+	rules, err := storage.ListChannelRules(ctx, orgID)
+	if err != nil {
+		return result, err
+	}
+	writeConfigs, err := storage.ListWriteConfigs(ctx, orgID)
+	if err != nil {
+		return result, err
+	}
+
+	existingRules := make(map[string]ChannelRule, len(rules))
+	for _, rule := range rules {
+		existingRules[rule.Pattern] = rule
+	}
+	existingWriteConfigs := make(map[string]WriteConfig, len(writeConfigs))
+	for _, wc := range writeConfigs {
+		existingWriteConfigs[wc.UID] = wc
+	}
+
+	var createWriteConfigs, updateWriteConfigs []RuleBundleWriteConfig
+	for _, wc := range bundle.WriteConfigs {
+		existing, ok := existingWriteConfigs[wc.UID]
+		if !ok {
+			createWriteConfigs = append(createWriteConfigs, wc)
+			continue
+		}
+		current, err := bundleWriteConfig(existing)
+		if err != nil {
+			return result, err
+		}
+		if current.Checksum == wc.Checksum {
+			result.Unchanged = append(result.Unchanged, "writeConfig:"+wc.UID)
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, "writeConfig:"+wc.UID)
+		updateWriteConfigs = append(updateWriteConfigs, wc)
+	}
+	var createRules, updateRules []RuleBundleRule
+	for _, rule := range bundle.Rules {
+		existing, ok := existingRules[rule.Pattern]
+		if !ok {
+			createRules = append(createRules, rule)
+			continue
+		}
+		checksum, err := bundleChecksum(existing.Settings)
+		if err != nil {
+			return result, err
+		}
+		if checksum == rule.Checksum {
+			result.Unchanged = append(result.Unchanged, "rule:"+rule.Pattern)
+			continue
+		}
+		result.Conflicts = append(result.Conflicts, "rule:"+rule.Pattern)
+		updateRules = append(updateRules, rule)
+	}
+	if len(result.Conflicts) > 0 && !overwrite {
+		return result, nil
+	}
+
+	// Write configs go first so that created rules never reference missing backends.
+	for _, wc := range createWriteConfigs {
+		if _, err := storage.CreateWriteConfig(ctx, orgID, WriteConfigCreateCmd{UID: wc.UID, Settings: wc.Settings}); err != nil {
+			return result, fmt.Errorf("error creating write config %s: %w", wc.UID, err)
+		}
+		result.Created = append(result.Created, "writeConfig:"+wc.UID)
+		for _, name := range wc.SecureSettings {
+			result.MissingSecrets = append(result.MissingSecrets, wc.UID+"."+name)
+		}
+	}
+	for _, wc := range updateWriteConfigs {
+		existing := existingWriteConfigs[wc.UID]
+		settings := wc.Settings
+		if settings.BasicAuth != nil && existing.Settings.BasicAuth != nil && settings.BasicAuth.Password == "" {
+			settings.BasicAuth = &BasicAuth{User: settings.BasicAuth.User, Password: existing.Settings.BasicAuth.Password}
+		}
+		// Update replaces secure settings of a write config and a bundle has none, so
+		// they have to be set again.
+		if _, err := storage.UpdateWriteConfig(ctx, orgID, WriteConfigUpdateCmd{UID: wc.UID, Settings: settings}); err != nil {
+			return result, fmt.Errorf("error updating write config %s: %w", wc.UID, err)
+		}
+		result.Updated = append(result.Updated, "writeConfig:"+wc.UID)
+		for _, name := range wc.SecureSettings {
+			if name == SecureSettingBasicAuthPassword && settings.BasicAuth != nil && settings.BasicAuth.Password != "" {
+				continue
+			}
+			result.MissingSecrets = append(result.MissingSecrets, wc.UID+"."+name)
+		}
+	}
+	for _, rule := range createRules {
+		if _, err := storage.CreateChannelRule(ctx, orgID, ChannelRuleCreateCmd{Pattern: rule.Pattern, Settings: rule.Settings}); err != nil {
+			return result, fmt.Errorf("error creating rule %s: %w", rule.Pattern, err)
+		}
+		result.Created = append(result.Created, "rule:"+rule.Pattern)
+	}
+	for _, rule := range updateRules {
+		if _, err := storage.UpdateChannelRule(ctx, orgID, ChannelRuleUpdateCmd{Pattern: rule.Pattern, Settings: rule.Settings}); err != nil {
+			return result, fmt.Errorf("error updating rule %s: %w", rule.Pattern, err)
+		}
+		result.Updated = append(result.Updated, "rule:"+rule.Pattern)
+	}
+	result.Conflicts = []string{}
+	return result, nil
+}
+
+// verify checks bundle version and that rule, write config and bundle checksums match content.
+func (b RuleBundle) verify() error {
+	if b.APIVersion != RuleBundleVersion {
+		return fmt.Errorf("unsupported rule bundle version: %d", b.APIVersion)
+	}
+	checksum, err := b.contentChecksum()
+	if err != nil {
+		return err
+	}
+	if checksum != b.Checksum {
+		return ErrRuleBundleChecksum
+	}
+	for _, rule := range b.Rules {
+		checksum, err := bundleChecksum(rule.Settings)
+		if err != nil {
+			return err
+		}
+		if checksum != rule.Checksum {
+			return fmt.Errorf("%w: rule %s", ErrRuleBundleChecksum, rule.Pattern)
+		}
+	}
+	for _, wc := range b.WriteConfigs {
+		checksum, err := bundleChecksum(wc.Settings)
+		if err != nil {
+			return err
+		}
+		if checksum != wc.Checksum {
+			return fmt.Errorf("%w: write config %s", ErrRuleBundleChecksum, wc.UID)
+		}
+	}
+	return nil
+}
+
+func (b RuleBundle) contentChecksum() (string, error) {
+	return bundleChecksum(struct {
+		APIVersion   int                     `json:"apiVersion"`
+		Rules        []RuleBundleRule        `json:"rules"`
+		WriteConfigs []RuleBundleWriteConfig `json:"writeConfigs,omitempty"`
+	}{b.APIVersion, b.Rules, b.WriteConfigs})
+}
+
+// bundleChecksum is a SHA-256 of a JSON encoding of v, JSON encoding of struct fields
+// and map keys is stable so equal values always have equal checksums.
+func bundleChecksum(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// MarshalRuleBundleYAML encodes bundle to YAML with the same field names as JSON API uses.
+func MarshalRuleBundleYAML(bundle RuleBundle) ([]byte, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML, decoding to a node keeps field order of JSON encoding.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&node)
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resetYAMLStyle switches flow style of decoded JSON to a block style.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
+
+// UnmarshalRuleBundleYAML decodes a bundle from YAML, JSON documents are accepted too.
+func UnmarshalRuleBundleYAML(data []byte) (RuleBundle, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return RuleBundle{}, err
+	}
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return RuleBundle{}, err
+	}
+	var bundle RuleBundle
+	if err := json.Unmarshal(jsonData, &bundle); err != nil {
+		return RuleBundle{}, err
+	}
+	return bundle, nil
+}
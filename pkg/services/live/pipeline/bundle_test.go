@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testBundleStorage struct {
+	Storage
+	rules        []ChannelRule
+	writeConfigs []WriteConfig
+}
+
+func (s *testBundleStorage) ListChannelRules(_ context.Context, _ int64) ([]ChannelRule, error) {
+	return s.rules, nil
+}
+
+func (s *testBundleStorage) ListWriteConfigs(_ context.Context, _ int64) ([]WriteConfig, error) {
+	return s.writeConfigs, nil
+}
+
+func (s *testBundleStorage) CreateChannelRule(_ context.Context, orgID int64, cmd ChannelRuleCreateCmd) (ChannelRule, error) {
+	rule := ChannelRule{OrgId: orgID, Pattern: cmd.Pattern, Settings: cmd.Settings}
+	s.rules = append(s.rules, rule)
+	return rule, nil
+}
+
+func (s *testBundleStorage) UpdateChannelRule(_ context.Context, orgID int64, cmd ChannelRuleUpdateCmd) (ChannelRule, error) {
+	for i, rule := range s.rules {
+		if rule.Pattern == cmd.Pattern {
+			s.rules[i].Settings = cmd.Settings
+			return s.rules[i], nil
+		}
+	}
+	return s.CreateChannelRule(context.Background(), orgID, ChannelRuleCreateCmd(cmd))
+}
+
+func (s *testBundleStorage) CreateWriteConfig(_ context.Context, orgID int64, cmd WriteConfigCreateCmd) (WriteConfig, error) {
+	wc := WriteConfig{OrgId: orgID, UID: cmd.UID, Settings: cmd.Settings}
+	s.writeConfigs = append(s.writeConfigs, wc)
+	return wc, nil
+}
+
+func (s *testBundleStorage) UpdateWriteConfig(_ context.Context, orgID int64, cmd WriteConfigUpdateCmd) (WriteConfig, error) {
+	for i, wc := range s.writeConfigs {
+		if wc.UID == cmd.UID {
+			s.writeConfigs[i] = WriteConfig{OrgId: orgID, UID: cmd.UID, Settings: cmd.Settings}
+			return s.writeConfigs[i], nil
+		}
+	}
+	return s.CreateWriteConfig(context.Background(), orgID, WriteConfigCreateCmd(cmd))
+}
+
+func testBundleSourceStorage() *testBundleStorage {
+	return &testBundleStorage{
+		rules: []ChannelRule{
+			{
+				Pattern: "stream/telemetry/cpu",
+				Settings: ChannelRuleSettings{
+					Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+					FrameOutputters: []*FrameOutputterConfig{{
+						Type:                    FrameOutputTypeRemoteWrite,
+						RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: "prom"},
+					}},
+				},
+			},
+			{
+				Pattern: "stream/telemetry/mem",
+				Settings: ChannelRuleSettings{
+					Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+				},
+			},
+			{
+				Pattern: "stream/other/cpu",
+				Settings: ChannelRuleSettings{
+					Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+				},
+			},
+		},
+		writeConfigs: []WriteConfig{
+			{
+				UID: "prom",
+				Settings: WriteSettings{
+					Endpoint:  "http://localhost:9090/api/v1/write",
+					BasicAuth: &BasicAuth{User: "admin", Password: "secret"},
+				},
+				SecureSettings: map[string][]byte{"token": []byte("encrypted")},
+			},
+			{
+				UID:      "unused",
+				Settings: WriteSettings{Endpoint: "http://localhost:3100"},
+			},
+		},
+	}
+}
+
+func TestRuleBundle_ExportImport(t *testing.T) {
+	source := testBundleSourceStorage()
+	bundle, err := ExportRuleBundle(context.Background(), source, 1, nil, "stream/telemetry/")
+	require.NoError(t, err)
+	require.Len(t, bundle.Rules, 2)
+	require.Len(t, bundle.WriteConfigs, 1)
+	require.Equal(t, "prom", bundle.WriteConfigs[0].UID)
+	require.Equal(t, []string{"basicAuthPassword", "token"}, bundle.WriteConfigs[0].SecureSettings)
+
+	data, err := MarshalRuleBundleYAML(bundle)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+	require.Contains(t, string(data), "pattern: stream/telemetry/cpu")
+
+	decoded, err := UnmarshalRuleBundleYAML(data)
+	require.NoError(t, err)
+	require.Equal(t, bundle, decoded)
+
+	target := &testBundleStorage{}
+	result, err := ImportRuleBundle(context.Background(), target, 2, decoded, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"writeConfig:prom", "rule:stream/telemetry/cpu", "rule:stream/telemetry/mem"}, result.Created)
+	require.Equal(t, []string{"prom.basicAuthPassword", "prom.token"}, result.MissingSecrets)
+	require.Len(t, target.rules, 2)
+	require.Equal(t, "admin", target.writeConfigs[0].Settings.BasicAuth.User)
+
+	// Importing the same bundle again changes nothing.
+	result, err = ImportRuleBundle(context.Background(), target, 2, decoded, false)
+	require.NoError(t, err)
+	require.Empty(t, result.Created)
+	require.Len(t, result.Unchanged, 3)
+}
+
+func TestRuleBundle_Conflicts(t *testing.T) {
+	bundle, err := ExportRuleBundle(context.Background(), testBundleSourceStorage(), 1, []string{"stream/telemetry/mem"}, "")
+	require.NoError(t, err)
+	require.Len(t, bundle.Rules, 1)
+	require.Empty(t, bundle.WriteConfigs)
+
+	target := &testBundleStorage{rules: []ChannelRule{{
+		Pattern:  "stream/telemetry/mem",
+		Settings: ChannelRuleSettings{Converter: &ConverterConfig{Type: ConverterTypeInfluxAuto}},
+	}}}
+	result, err := ImportRuleBundle(context.Background(), target, 1, bundle, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"rule:stream/telemetry/mem"}, result.Conflicts)
+	require.Equal(t, ConverterTypeInfluxAuto, target.rules[0].Settings.Converter.Type)
+
+	result, err = ImportRuleBundle(context.Background(), target, 1, bundle, true)
+	require.NoError(t, err)
+	require.Empty(t, result.Conflicts)
+	require.Equal(t, []string{"rule:stream/telemetry/mem"}, result.Updated)
+	require.Equal(t, ConverterTypeJsonAuto, target.rules[0].Settings.Converter.Type)
+}
+
+func TestRuleBundle_Tampered(t *testing.T) {
+	bundle, err := ExportRuleBundle(context.Background(), testBundleSourceStorage(), 1, []string{"stream/telemetry/cpu"}, "")
+	require.NoError(t, err)
+	data, err := MarshalRuleBundleYAML(bundle)
+	require.NoError(t, err)
+
+	tampered := strings.Replace(string(data), "http://localhost:9090", "http://attacker:9090", 1)
+	decoded, err := UnmarshalRuleBundleYAML([]byte(tampered))
+	require.NoError(t, err)
+	_, err = ImportRuleBundle(context.Background(), &testBundleStorage{}, 1, decoded, false)
+	require.ErrorIs(t, err, ErrRuleBundleChecksum)
+
+	bundle.APIVersion = 2
+	_, err = ImportRuleBundle(context.Background(), &testBundleStorage{}, 1, bundle, false)
+	require.ErrorContains(t, err, "unsupported rule bundle version")
+}
@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBOR (RFC 8949) decoding into values similar to ones produced by JSON decoding:
+// maps are decoded into cborMap, arrays into []any, all numbers into float64, byte
+// strings into base64 encoded strings. Standard date/time tags (0 and 1) are decoded into time.Time,
+// other tags are ignored and tag content is used.
+
+const cborMaxDepth = 64
+
+var cborDecMode = func() cbor.DecMode {
+	dm, err := cbor.DecOptions{
+		MaxNestedLevels: cborMaxDepth,
+		IndefLength:     cbor.IndefLengthAllowed,
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return dm
+}()
+
+// cborMap keeps map keys in document order, so converted frames have stable field order.
+type cborMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func decodeCBOR(body []byte) (any, error) {
+	// Unmarshal checks the whole document is well-formed, so nested items are not
+	// validated again.
+	var raw cbor.RawMessage
+	if err := cborDecMode.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	return decodeCBORItem(raw)
+}
+
+func decodeCBORItem(raw cbor.RawMessage) (any, error) {
+	switch raw[0] >> 5 {
+	case 4:
+		var items []cbor.RawMessage
+		if err := cborDecMode.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		arr := make([]any, 0, len(items))
+		for _, item := range items {
+			v, err := decodeCBORItem(item)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case 5:
+		return decodeCBORMap(raw)
+	case 6:
+		var tag cbor.RawTag
+		if err := cborDecMode.Unmarshal(raw, &tag); err != nil {
+			return nil, err
+		}
+		v, err := decodeCBORItem(tag.Content)
+		if err != nil {
+			return nil, err
+		}
+		switch tag.Number {
+		case 0:
+			s, ok := v.(string)
+			if !ok {
+				return nil, errors.New("invalid CBOR date/time string")
+			}
+			return time.Parse(time.RFC3339Nano, s)
+		case 1:
+			f, ok := v.(float64)
+			if !ok {
+				return nil, errors.New("invalid CBOR epoch date/time")
+			}
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+		return v, nil
+	}
+
+	var v any
+	if err := cborDecMode.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	switch val := v.(type) {
+	case uint64:
+		return float64(val), nil
+	case int64:
+		return float64(val), nil
+	case big.Int:
+		f, _ := new(big.Float).SetInt(&val).Float64()
+		return f, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(val), nil
+	case float64, string, bool, nil:
+		return val, nil
+	}
+	// Unassigned simple values.
+	return nil, nil
+}
+
+// decodeCBORMap decodes map entries one by one, as the decoder does not keep order
+// of map keys.
+func decodeCBORMap(raw cbor.RawMessage) (*cborMap, error) {
+	count, rest, err := cborMapHead(raw)
+	if err != nil {
+		return nil, err
+	}
+	m := &cborMap{values: map[string]any{}}
+	for i := 0; count < 0 || i < count; i++ {
+		if count < 0 && len(rest) > 0 && rest[0] == 0xff {
+			break
+		}
+		var k, v cbor.RawMessage
+		if rest, err = cborDecMode.UnmarshalFirst(rest, &k); err != nil {
+			return nil, err
+		}
+		if rest, err = cborDecMode.UnmarshalFirst(rest, &v); err != nil {
+			return nil, err
+		}
+		decodedKey, err := decodeCBORItem(k)
+		if err != nil {
+			return nil, err
+		}
+		key, err := cborMapKey(decodedKey)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeCBORItem(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m.values[key]; !ok {
+			m.keys = append(m.keys, key)
+		}
+		m.values[key] = value
+	}
+	return m, nil
+}
+
+// cborMapHead returns a number of map entries, -1 for indefinite length maps, and
+// bytes of entries.
+func cborMapHead(raw cbor.RawMessage) (int, []byte, error) {
+	info := raw[0] & 0x1f
+	switch {
+	case info < 24:
+		return int(info), raw[1:], nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(raw) < 1+size {
+			return 0, nil, errors.New("unexpected end of CBOR data")
+		}
+		var count uint64
+		for _, b := range raw[1 : 1+size] {
+			count = count<<8 | uint64(b)
+		}
+		if count > uint64(len(raw)) {
+			return 0, nil, errors.New("invalid CBOR map length")
+		}
+		return int(count), raw[1+size:], nil
+	case info == 31:
+		return -1, raw[1:], nil
+	}
+	return 0, nil, fmt.Errorf("invalid CBOR additional information: %d", info)
+}
+
+func cborMapKey(k any) (string, error) {
+	switch key := k.(type) {
+	case string:
+		return key, nil
+	case float64:
+		return strconv.FormatFloat(key, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(key), nil
+	default:
+		return "", fmt.Errorf("unsupported CBOR map key type: %T", k)
+	}
+}
@@ -1,5 +1,7 @@
 package pipeline
 
+import "fmt"
+
 // NumberCompareOp is an comparison operator.
 type NumberCompareOp string
 
@@ -12,3 +14,33 @@ const (
 	NumberCompareOpEq  NumberCompareOp = "eq"
 	NumberCompareOpNe  NumberCompareOp = "ne"
 )
+
+func (op NumberCompareOp) compare(a, b float64) (bool, error) {
+	switch op {
+	case NumberCompareOpGt:
+		return a > b, nil
+	case NumberCompareOpGte:
+		return a >= b, nil
+	case NumberCompareOpLte:
+		return a <= b, nil
+	case NumberCompareOpLt:
+		return a < b, nil
+	case NumberCompareOpEq:
+		return a == b, nil
+	case NumberCompareOpNe:
+		return a != b, nil
+	default:
+		return false, fmt.Errorf("unknown comparison operator: %s", op)
+	}
+}
+
+// LabelCompareOp is a label matching operator.
+type LabelCompareOp string
+
+// Known LabelCompareOp types.
+const (
+	LabelCompareOpEq       LabelCompareOp = "eq"
+	LabelCompareOpNe       LabelCompareOp = "ne"
+	LabelCompareOpRegex    LabelCompareOp = "regex"
+	LabelCompareOpNotRegex LabelCompareOp = "notRegex"
+)
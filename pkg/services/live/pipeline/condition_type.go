@@ -7,4 +7,6 @@ type ConditionType string
 const (
 	ConditionAll ConditionType = "all"
 	ConditionAny ConditionType = "any"
+	// ConditionNone negates conditions, a single condition is a NOT.
+	ConditionNone ConditionType = "none"
 )
@@ -26,10 +26,115 @@ type ChannelRuleSettings struct {
 	Converter       *ConverterConfig        `json:"converter,omitempty"`
 	FrameProcessors []*FrameProcessorConfig `json:"frameProcessors,omitempty"`
 	FrameOutputters []*FrameOutputterConfig `json:"frameOutputs,omitempty"`
+	// DeadLetterOutputter receives payloads and frames which failed conversion, processing
+	// or output of the rule.
+	DeadLetterOutputter *FrameOutputterConfig `json:"deadLetterOutput,omitempty"`
+	// Locale used by converters to parse numbers and timestamps from strings.
+	Locale *LocaleConfig `json:"locale,omitempty"`
+	// Priority class of rule inputs: low, normal (default), high or critical. Lower
+	// priority inputs are shed first when the pipeline is overloaded.
+	Priority string `json:"priority,omitempty"`
+	// Order of the rule among rules matching the same channel, lower first. The most
+	// specific pattern goes first among rules of the same order.
+	Order int `json:"order,omitempty"`
+	// RateLimit drops rule inputs over a limit, the limit is shared by all Grafana
+	// instances in HA mode.
+	RateLimit *RateLimitConfig `json:"rateLimit,omitempty"`
+	// Generator publishes synthetic frames to the rule channel, pattern must not
+	// contain wildcards.
+	Generator *GeneratorConfig `json:"generator,omitempty"`
+	// Snapshot saves the last frame of the rule channel to an entity on schedule,
+	// pattern must not contain wildcards.
+	Snapshot *SnapshotConfig `json:"snapshot,omitempty"`
+	// Heartbeat sends status frames to outputs when a channel of the rule receives no
+	// input for a timeout.
+	Heartbeat *HeartbeatConfig `json:"heartbeat,omitempty"`
+	// Samples are payloads the rule is tested with, see RunRuleSamples.
+	Samples []*RuleSampleConfig `json:"samples,omitempty"`
+	// Rollout enables the rule for a percentage of matching channels only, other
+	// channels are handled as if the rule did not exist.
+	Rollout *RolloutConfig `json:"rollout,omitempty"`
+}
+
+// RateLimitConfig allows up to Limit inputs of a rule per interval.
+type RateLimitConfig struct {
+	Limit int `json:"limit"`
+	// IntervalMilliseconds is 1 second by default.
+	IntervalMilliseconds int64 `json:"intervalMilliseconds,omitempty"`
+	// PerChannel applies the limit to each channel matching the rule separately
+	// instead of all of them together.
+	PerChannel bool `json:"perChannel,omitempty"`
+}
+
+// RolloutConfig selects a stable percentage of channels by a hash of organization,
+// channel and rollout name, so a rule or an output can be rolled out gradually.
+type RolloutConfig struct {
+	// Name of the rollout in metrics, rule pattern is used for rule rollouts when empty
+	// and it is required for rollout outputs. Rollouts with different names select
+	// different channels for the same percentage.
+	Name string `json:"name,omitempty"`
+	// Percentage of channels in the rollout, from 0 to 100.
+	Percentage float64 `json:"percentage"`
+}
+
+// RuleSampleConfig attaches a named sample payload kept in a jsonobj entity to a rule.
+type RuleSampleConfig struct {
+	Name string `json:"name"`
+	// UID of a jsonobj entity with the payload. A JSON string body is used as a raw
+	// payload, like Influx line protocol, other bodies are used as is.
+	UID string `json:"uid"`
+	// Channel the payload is published to, rule pattern by default. Must be set for
+	// patterns with parameters.
+	Channel string `json:"channel,omitempty"`
+	// ExpectError marks samples the rule must fail on.
+	ExpectError bool `json:"expectError,omitempty"`
+	// ExpectFrames is a number of frames expected after processing, not checked when nil.
+	ExpectFrames *int `json:"expectFrames,omitempty"`
+}
+
+type HeartbeatConfig struct {
+	// TimeoutMilliseconds without inputs after which a channel is stale.
+	TimeoutMilliseconds int64 `json:"timeoutMilliseconds"`
+	// Outputs receive heartbeat status frames.
+	Outputs []*FrameOutputterConfig `json:"outputs"`
+}
+
+type SnapshotConfig struct {
+	// Schedule is a cron expression like "0 * * * *" or a descriptor like "@hourly".
+	Schedule string `json:"schedule"`
+	// UID of a data frame entity snapshots are written to as new versions, by default
+	// made of the channel with slashes replaced by dashes.
+	UID string `json:"uid,omitempty"`
+}
+
+type GeneratorConfig struct {
+	// IntervalMilliseconds between generated frames, 1000 by default.
+	IntervalMilliseconds int64 `json:"intervalMilliseconds,omitempty"`
+	// Frame name, "generator" by default.
+	Frame  string                 `json:"frame,omitempty"`
+	Fields []GeneratorFieldConfig `json:"fields"`
+}
+
+type GeneratorFieldConfig struct {
+	Name string `json:"name"`
+	// Type is one of sine, square, triangle, sawtooth, random, randomWalk, counter
+	// or categorical.
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Min and Max are bounds of numeric values, 0 and 1 by default.
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	// PeriodMilliseconds of waveforms, 60000 by default.
+	PeriodMilliseconds int64 `json:"periodMilliseconds,omitempty"`
+	// Step of random walk and counter values, 1% of range by default.
+	Step float64 `json:"step,omitempty"`
+	// Values of categorical field, a random one is taken for each frame.
+	Values []string `json:"values,omitempty"`
 }
 
 type ChannelRule struct {
-	OrgId    int64               `json:"-"`
+	// OrgId of the rule, rules without organization belong to the main organization.
+	OrgId    int64               `json:"orgId,omitempty"`
 	Pattern  string              `json:"pattern"`
 	Settings ChannelRuleSettings `json:"settings"`
 }
@@ -40,6 +145,8 @@ type ConverterConfig struct {
 	ExactJsonConverterConfig  *ExactJsonConverterConfig  `json:"jsonExact,omitempty"`
 	AutoInfluxConverterConfig *AutoInfluxConverterConfig `json:"influxAuto,omitempty"`
 	JsonFrameConverterConfig  *JsonFrameConverterConfig  `json:"jsonFrame,omitempty"`
+	CBORConverterConfig       *CBORConverterConfig       `json:"cbor,omitempty"`
+	NMEAConverterConfig       *NMEAConverterConfig       `json:"nmea,omitempty"`
 }
 
 type DropFieldsFrameProcessorConfig struct {
@@ -51,10 +158,20 @@ type KeepFieldsFrameProcessorConfig struct {
 }
 
 type FrameProcessorConfig struct {
-	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
-	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
-	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
-	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	Type                      string                              `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
+	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig     `json:"dropFields,omitempty"`
+	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig     `json:"keepFields,omitempty"`
+	MultipleProcessorConfig   *MultipleFrameProcessorConfig       `json:"multiple,omitempty"`
+	FillNullProcessorConfig   *FillNullFrameProcessorConfig       `json:"fillNull,omitempty"`
+	JoinProcessorConfig       *JoinFrameProcessorConfig           `json:"join,omitempty"`
+	ExplodeProcessorConfig    *ExplodeFrameProcessorConfig        `json:"explode,omitempty"`
+	ScriptProcessorConfig     *ScriptFrameProcessorConfig         `json:"script,omitempty"`
+	HistogramProcessorConfig  *HistogramFrameProcessorConfig      `json:"histogram,omitempty"`
+	ReshapeProcessorConfig    *ReshapeFrameProcessorConfig        `json:"reshape,omitempty"`
+	IdentityLabelsConfig      *IdentityLabelsFrameProcessorConfig `json:"identityLabels,omitempty"`
+	SessionWindowConfig       *SessionWindowFrameProcessorConfig  `json:"sessionWindow,omitempty"`
+	EncryptFieldsConfig       *EncryptFieldsFrameProcessorConfig  `json:"encryptFields,omitempty"`
+	ValueMapConfig            *ValueMapFrameProcessorConfig       `json:"valueMap,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -63,6 +180,9 @@ type MultipleFrameProcessorConfig struct {
 
 type MultipleOutputterConfig struct {
 	Outputters []FrameOutputterConfig `json:"outputs"`
+	// ContinueOnError runs all outputs even if some of them fail, so a failing
+	// backend doesn't stop others from receiving frames.
+	ContinueOnError bool `json:"continueOnError,omitempty"`
 }
 
 type ConditionalOutputConfig struct {
@@ -70,15 +190,349 @@ type ConditionalOutputConfig struct {
 	Outputter *FrameOutputterConfig        `json:"output"`
 }
 
+// RolloutOutputConfig sends frames of channels in a rollout to a new output and frames
+// of other channels to a fallback output, usually the one being replaced.
+type RolloutOutputConfig struct {
+	RolloutConfig
+	Outputter *FrameOutputterConfig `json:"output"`
+	// Fallback is optional, frames of channels outside the rollout are not sent
+	// anywhere when not set.
+	Fallback *FrameOutputterConfig `json:"fallback,omitempty"`
+}
+
+// WriteConfigRef references a write config by name instead of UID.
+type WriteConfigRef struct {
+	Name string `json:"name"`
+	// OrgID of the write config, a config of the rule organization is used when not set.
+	// Write configs of other organizations must be shared with the rule one.
+	OrgID int64 `json:"orgId,omitempty"`
+}
+
 type RemoteWriteOutputConfig struct {
-	UID                string `json:"uid"`
-	SampleMilliseconds int64  `json:"sampleMilliseconds"`
+	UID string `json:"uid"`
+	// Backend references a write config from an organization catalog by name, it's
+	// used instead of UID when set.
+	Backend            *WriteConfigRef `json:"backend,omitempty"`
+	SampleMilliseconds int64           `json:"sampleMilliseconds"`
+	// Labels are added to all time series before relabeling, values support {orgId},
+	// {channel}, {scope}, {namespace}, {path}, {frame} and {:name} placeholders, e.g.
+	// {"site": "{:site}"} for stream/:site/:device rule.
+	Labels map[string]string `json:"labels,omitempty"`
+	// RelabelConfigs are applied to time series labels before sending, in order.
+	RelabelConfigs []RelabelConfig `json:"relabelConfigs,omitempty"`
+	// FlushIntervalMilliseconds is a max time samples of all frames are batched for
+	// before sending, 15000 by default.
+	FlushIntervalMilliseconds int64 `json:"flushIntervalMilliseconds,omitempty"`
+	// MaxBatchSamples sends a batch as soon as it has that many samples.
+	MaxBatchSamples int `json:"maxBatchSamples,omitempty"`
+	// MaxBufferSamples bounds samples kept while the endpoint is unavailable, oldest
+	// are dropped first. 1000000 by default.
+	MaxBufferSamples int `json:"maxBufferSamples,omitempty"`
+	// WAL keeps batches on disk until acknowledged by the endpoint, so samples are
+	// not lost across restarts. Backends rejecting duplicate and out of order samples
+	// (Prometheus, Cortex, Mimir) get each sample once.
+	WAL bool `json:"wal,omitempty"`
 }
 
 type LokiOutputConfig struct {
 	UID string `json:"uid"`
 }
 
+type ElasticsearchOutputConfig struct {
+	// UID of a write config with Elasticsearch URL. Basic auth is used from write config,
+	// or API key from "apiKey" secure setting.
+	UID string `json:"uid"`
+	// Index is an index name template, supports {orgId}, {channel}, {scope}, {namespace},
+	// {path}, {frame} placeholders and date math like {now/d{yyyy.MM.dd}}. By default
+	// "grafana-live-{channel}-{now/d}".
+	Index string `json:"index,omitempty"`
+}
+
+type InfluxOutputConfig struct {
+	// UID of a write config with InfluxDB URL. API token is used from "token" secure
+	// setting, basic auth from write config.
+	UID string `json:"uid"`
+	// Org and Bucket to write to with InfluxDB 2.x API.
+	Org    string `json:"org,omitempty"`
+	Bucket string `json:"bucket,omitempty"`
+	// Database and optional RetentionPolicy to write to with InfluxDB 1.x API, used
+	// when Bucket is not set.
+	Database        string `json:"database,omitempty"`
+	RetentionPolicy string `json:"retentionPolicy,omitempty"`
+	// Measurement name, frame name by default.
+	Measurement string `json:"measurement,omitempty"`
+	// TagFields are names of fields written as tags instead of fields.
+	TagFields []string `json:"tagFields,omitempty"`
+}
+
+type PostgresOutputConfig struct {
+	// UID of a write config with postgres:// URL, user and password are used from
+	// basic auth.
+	UID string `json:"uid"`
+	// Table name template with optional schema, supports {orgId}, {channel}, {scope},
+	// {namespace}, {path}, {frame} placeholders and date math like {now/M{yyyy_MM}}.
+	Table string `json:"table"`
+	// Columns maps frame fields to table columns, by default all fields are inserted
+	// into columns with the same names.
+	Columns []PostgresColumnConfig `json:"columns,omitempty"`
+	// LabelsColumn is an optional column to store field labels as JSON.
+	LabelsColumn string `json:"labelsColumn,omitempty"`
+	// BatchSize is a number of buffered rows which triggers insert before flush
+	// interval, 500 by default.
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+type PostgresColumnConfig struct {
+	Field string `json:"field"`
+	// Column name, field name by default.
+	Column string `json:"column,omitempty"`
+}
+
+type NATSOutputConfig struct {
+	// UID of a write config with nats:// or tls:// URL. User and password are used
+	// from basic auth, token from "token" secure setting.
+	UID string `json:"uid"`
+	// Subject template, supports {orgId}, {channel}, {scope}, {namespace}, {path},
+	// {frame} and rule pattern {:name} placeholders, slashes become subject token
+	// separators. By default "grafana.live.{channel}".
+	Subject string `json:"subject,omitempty"`
+	// JetStream waits for a stream acknowledgement of each published frame.
+	JetStream bool `json:"jetStream,omitempty"`
+	// Stream is an optional name of a stream expected to acknowledge publishes.
+	Stream string `json:"stream,omitempty"`
+}
+
+type WebhookOutputConfig struct {
+	// UID of a write config with webhook URL. Basic auth is used from write config, or
+	// bearer token from "token" secure setting.
+	UID string `json:"uid"`
+	// Method is an HTTP method, POST by default.
+	Method string `json:"method,omitempty"`
+	// Headers are additional request headers.
+	Headers map[string]string `json:"headers,omitempty"`
+	// TimeoutMilliseconds of a single request, 5000 by default.
+	TimeoutMilliseconds int64 `json:"timeoutMilliseconds,omitempty"`
+	// MaxRetries of failed requests (network errors, 429 and 5xx responses).
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// RetryBackoffMilliseconds is an initial delay between retries doubled after each
+	// attempt, 500 by default.
+	RetryBackoffMilliseconds int64 `json:"retryBackoffMilliseconds,omitempty"`
+}
+
+type S3OutputConfig struct {
+	// UID of a write config with S3 compatible storage URL, basic auth user and password
+	// of write config are used as access key ID and secret access key.
+	UID    string `json:"uid"`
+	Bucket string `json:"bucket"`
+	// Region is us-east-1 by default.
+	Region string `json:"region,omitempty"`
+	// ForcePathStyle uses path style bucket addressing required by some S3 compatible
+	// storages like MinIO.
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// Key is an object key prefix template, supports {orgId}, {channel}, {scope},
+	// {namespace}, {path}, {frame} placeholders and date math like {now{yyyy/MM/dd}}.
+	// By default "grafana-live/{orgId}/{channel}/{now{yyyy/MM/dd}}".
+	Key string `json:"key,omitempty"`
+	// Format of objects: ndjson (default) or parquet.
+	Format string `json:"format,omitempty"`
+	// FlushIntervalMilliseconds is a period of uploads, 60000 by default.
+	FlushIntervalMilliseconds int64 `json:"flushIntervalMilliseconds,omitempty"`
+	// MaxBufferRows triggers an upload before flush interval, 100000 by default.
+	MaxBufferRows int `json:"maxBufferRows,omitempty"`
+}
+
+type FileOutputConfig struct {
+	// Path is a directory template relative to the live file output directory, supports
+	// {orgId}, {channel}, {scope}, {namespace}, {path}, {frame} placeholders and date
+	// math like {now{yyyy/MM/dd}}. By default "{orgId}/{channel}".
+	Path string `json:"path,omitempty"`
+	// MaxSizeBytes of an active file before rotation, 64MB by default.
+	MaxSizeBytes int64 `json:"maxSizeBytes,omitempty"`
+	// RotationIntervalMilliseconds rotates an active file periodically, disabled by default.
+	RotationIntervalMilliseconds int64 `json:"rotationIntervalMilliseconds,omitempty"`
+	// MaxFiles is a number of rotated files to keep per directory, 10 by default.
+	MaxFiles int `json:"maxFiles,omitempty"`
+	// MaxAgeMilliseconds removes rotated files older than that, disabled by default.
+	MaxAgeMilliseconds int64 `json:"maxAgeMilliseconds,omitempty"`
+}
+
+type DebugOutputConfig struct {
+	// Level to log frames at: debug (default), info or warn.
+	Level string `json:"level,omitempty"`
+	// MaxRows of a frame to log and keep, 10 by default, negative value disables truncation.
+	MaxRows int `json:"maxRows,omitempty"`
+}
+
+type AnnotationOutputConfig struct {
+	// DashboardUID to create annotations on, organization annotations are created by default.
+	DashboardUID string `json:"dashboardUid,omitempty"`
+	// PanelID to create annotations on, requires DashboardUID.
+	PanelID int64 `json:"panelId,omitempty"`
+	// Text is a Go template executed with the last frame row, supports .OrgID, .Channel,
+	// .Scope, .Namespace, .Path, .Frame, .Fields (values by field name) and .Labels.
+	// By default "{{ .Channel }}".
+	Text string `json:"text,omitempty"`
+	// Tags are Go templates executed like Text, empty tags are skipped.
+	Tags []string `json:"tags,omitempty"`
+	// TimeField is a name of annotation time field, first time field by default.
+	TimeField string `json:"timeField,omitempty"`
+}
+
+type AlertNotificationOutputConfig struct {
+	// ContactPoint is a name of an alerting contact point of the organization.
+	ContactPoint string `json:"contactPoint"`
+	// AlertName is a Go template executed like annotation output text, used as
+	// alertname label. By default "{{ .Channel }}".
+	AlertName string `json:"alertName,omitempty"`
+	// Summary and Description are Go templates of notification annotations.
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Labels are additional notification labels, values are Go templates.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type ForwardOutputConfig struct {
+	// UID of a write config with a root URL of remote Grafana. Bearer token is used from
+	// "token" secure setting (a service account token), or basic auth from write config.
+	UID string `json:"uid"`
+	// Channel template on remote Grafana, supports {orgId}, {channel}, {scope}, {namespace},
+	// {path} and {frame} placeholders. By default "{channel}".
+	Channel string `json:"channel,omitempty"`
+	// TimeoutMilliseconds of a single request, 5000 by default.
+	TimeoutMilliseconds int64 `json:"timeoutMilliseconds,omitempty"`
+}
+
+type RetryOutputConfig struct {
+	Outputter *FrameOutputterConfig `json:"output"`
+	// MaxAttempts to output a frame including the first one, 5 by default.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// InitialBackoffMilliseconds before the first retry, doubled after each failed
+	// attempt up to MaxBackoffMilliseconds. 500 and 30000 by default.
+	InitialBackoffMilliseconds int64 `json:"initialBackoffMilliseconds,omitempty"`
+	MaxBackoffMilliseconds     int64 `json:"maxBackoffMilliseconds,omitempty"`
+	// QueueSize is a max number of frames waiting for retry, 1000 by default.
+	QueueSize int `json:"queueSize,omitempty"`
+}
+
+type AMQPOutputConfig struct {
+	// UID of a write config with amqp:// or amqps:// URL, URL path is a vhost. User
+	// and password are used from basic auth.
+	UID string `json:"uid"`
+	// Exchange to publish to, the default exchange routes by queue name.
+	Exchange string `json:"exchange,omitempty"`
+	// RoutingKey template, supports {orgId}, {channel}, {scope}, {namespace}, {path},
+	// {frame} and rule pattern {:name} placeholders, slashes become dots. By default
+	// "grafana.live.{channel}".
+	RoutingKey string `json:"routingKey,omitempty"`
+	// Confirm waits for a broker confirm of each published frame.
+	Confirm bool `json:"confirm,omitempty"`
+	// Mandatory fails publishes of frames which can't be routed to any queue, used
+	// with Confirm.
+	Mandatory bool `json:"mandatory,omitempty"`
+	// Persistent publishes frames with persistent delivery mode.
+	Persistent bool `json:"persistent,omitempty"`
+}
+
+type EventHubsOutputConfig struct {
+	// UID of a write config. An Event Hubs connection string is read from the
+	// "connectionString" secure setting, otherwise write config URL like
+	// https://{namespace}.servicebus.windows.net/{eventHub} is used with basic auth user
+	// and password as a shared access key name and a key, or an Azure AD client ID and
+	// a secret.
+	UID string `json:"uid"`
+	// EventHub name, overrides EntityPath of a connection string and URL path.
+	EventHub string `json:"eventHub,omitempty"`
+	// Auth is sas (default), clientSecret or managedIdentity. Managed identity uses
+	// basic auth user as a client ID of a user-assigned identity when it's set.
+	Auth string `json:"auth,omitempty"`
+	// TenantID of an Azure AD application, required for clientSecret auth.
+	TenantID string `json:"tenantId,omitempty"`
+	// PartitionKey template, supports {orgId}, {channel}, {scope}, {namespace}, {path}
+	// and {frame} placeholders. By default "{channel}", so frames of a channel keep order.
+	PartitionKey string `json:"partitionKey,omitempty"`
+}
+
+type PubSubOutputConfig struct {
+	// UID of a write config. A JSON key of a service account is read from the
+	// "serviceAccountKey" secure setting, application default credentials are used
+	// when it's not set. Write config URL overrides Pub/Sub API endpoint, with a custom
+	// URL and no key requests are sent without credentials, like to the Pub/Sub emulator.
+	UID string `json:"uid"`
+	// Project ID, by default a project of credentials.
+	Project string `json:"project,omitempty"`
+	// Topic name template, supports {orgId}, {channel}, {scope}, {namespace}, {path},
+	// {frame} and rule pattern {:name} placeholders. By default "grafana-live".
+	Topic string `json:"topic,omitempty"`
+	// OrderingKeyField is a frame field which values are used as message ordering keys.
+	// Rows are grouped by a value and every group is published as a separate message.
+	// Ordering must be enabled on subscriptions for Pub/Sub to deliver messages in order.
+	OrderingKeyField string `json:"orderingKeyField,omitempty"`
+}
+
+type PushgatewayOutputConfig struct {
+	// UID of a write config with Pushgateway URL and optional basic auth.
+	UID string `json:"uid"`
+	// Job template, supports {orgId}, {channel}, {scope}, {namespace}, {path} and
+	// {frame} placeholders. By default "grafana_live".
+	Job string `json:"job,omitempty"`
+	// Grouping labels added to a grouping key besides job, values are templates like Job.
+	Grouping map[string]string `json:"grouping,omitempty"`
+	// Replace all metrics of a group with pushed ones, by default only metrics with
+	// the same names are replaced.
+	Replace bool `json:"replace,omitempty"`
+}
+
+type FailureAlertOutputConfig struct {
+	Outputter *FrameOutputterConfig `json:"output"`
+	// FailureRate of output calls within a window to start alerting at, 0.5 by default.
+	FailureRate float64 `json:"failureRate,omitempty"`
+	// WindowMilliseconds is a length of a sliding window failure rate is calculated
+	// over, 60000 by default.
+	WindowMilliseconds int64 `json:"windowMilliseconds,omitempty"`
+	// MinSamples is a min number of output calls within a window to calculate failure
+	// rate, 10 by default.
+	MinSamples int `json:"minSamples,omitempty"`
+	// CircuitBreaker skips the output for a while after consecutive failures.
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
+	// WebhookUID is a UID of a write config with a URL alert events are POSTed to as
+	// JSON. Basic auth is used from write config, or bearer token from "token" secure
+	// setting.
+	WebhookUID string `json:"webhookUid,omitempty"`
+	// ContactPoint is a name of an alerting contact point alerts are sent to.
+	ContactPoint string `json:"contactPoint,omitempty"`
+}
+
+type CircuitBreakerConfig struct {
+	// ConsecutiveFailures to open a circuit breaker after, 5 by default.
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+	// OpenMilliseconds is a time frames are not passed to the output after breaker
+	// opens, then a single frame is passed to check if the output recovered. 30000
+	// by default.
+	OpenMilliseconds int64 `json:"openMilliseconds,omitempty"`
+}
+
+type ProcessedOutputConfig struct {
+	// Processors applied to a copy of a frame passed to Outputter.
+	Processors []*FrameProcessorConfig `json:"processors"`
+	Outputter  *FrameOutputterConfig   `json:"output"`
+}
+
+type GeoJSONOutputConfig struct {
+	// UID of a geojson entity to write, by default a channel with slashes replaced by dashes.
+	UID string `json:"uid,omitempty"`
+	// LatitudeField and LongitudeField names, "lat" and "lon" by default.
+	LatitudeField  string `json:"latitudeField,omitempty"`
+	LongitudeField string `json:"longitudeField,omitempty"`
+	// KeyField groups points, e.g. by vehicle id, so limits apply to each track.
+	KeyField string `json:"keyField,omitempty"`
+	// MaxPoints kept per key, 100 by default.
+	MaxPoints int `json:"maxPoints,omitempty"`
+	// MaxAgeMilliseconds drops older points, disabled by default.
+	MaxAgeMilliseconds int64 `json:"maxAgeMilliseconds,omitempty"`
+	// WriteIntervalMilliseconds is a min time between entity writes, 10s by default.
+	WriteIntervalMilliseconds int64 `json:"writeIntervalMilliseconds,omitempty"`
+}
+
 type MultipleSubscriberConfig struct {
 	Subscribers []SubscriberConfig `json:"subscribers"`
 }
@@ -100,15 +554,35 @@ type DataOutputterConfig struct {
 }
 
 type FrameOutputterConfig struct {
-	Type                    string                     `json:"type" ts_type:"Omit<keyof FrameOutputterConfig, 'type'>"`
-	ManagedStreamConfig     *ManagedStreamOutputConfig `json:"managedStream,omitempty"`
-	MultipleOutputterConfig *MultipleOutputterConfig   `json:"multiple,omitempty"`
-	RedirectOutputConfig    *RedirectOutputConfig      `json:"redirect,omitempty"`
-	ConditionalOutputConfig *ConditionalOutputConfig   `json:"conditional,omitempty"`
-	ThresholdOutputConfig   *ThresholdOutputConfig     `json:"threshold,omitempty"`
-	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
-	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
-	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	Type                     string                         `json:"type" ts_type:"Omit<keyof FrameOutputterConfig, 'type'>"`
+	ManagedStreamConfig      *ManagedStreamOutputConfig     `json:"managedStream,omitempty"`
+	MultipleOutputterConfig  *MultipleOutputterConfig       `json:"multiple,omitempty"`
+	RedirectOutputConfig     *RedirectOutputConfig          `json:"redirect,omitempty"`
+	ConditionalOutputConfig  *ConditionalOutputConfig       `json:"conditional,omitempty"`
+	ThresholdOutputConfig    *ThresholdOutputConfig         `json:"threshold,omitempty"`
+	RemoteWriteOutputConfig  *RemoteWriteOutputConfig       `json:"remoteWrite,omitempty"`
+	LokiOutputConfig         *LokiOutputConfig              `json:"loki,omitempty"`
+	ChangeLogOutputConfig    *ChangeLogOutputConfig         `json:"changeLog,omitempty"`
+	ElasticsearchConfig      *ElasticsearchOutputConfig     `json:"elasticsearch,omitempty"`
+	InfluxOutputConfig       *InfluxOutputConfig            `json:"influx,omitempty"`
+	PostgresOutputConfig     *PostgresOutputConfig          `json:"postgres,omitempty"`
+	NATSOutputConfig         *NATSOutputConfig              `json:"nats,omitempty"`
+	WebhookOutputConfig      *WebhookOutputConfig           `json:"webhook,omitempty"`
+	S3OutputConfig           *S3OutputConfig                `json:"s3,omitempty"`
+	FileOutputConfig         *FileOutputConfig              `json:"file,omitempty"`
+	DebugOutputConfig        *DebugOutputConfig             `json:"debug,omitempty"`
+	AnnotationOutputConfig   *AnnotationOutputConfig        `json:"annotation,omitempty"`
+	AlertNotificationConfig  *AlertNotificationOutputConfig `json:"alertNotification,omitempty"`
+	ForwardOutputConfig      *ForwardOutputConfig           `json:"forward,omitempty"`
+	RetryOutputConfig        *RetryOutputConfig             `json:"retry,omitempty"`
+	AMQPOutputConfig         *AMQPOutputConfig              `json:"amqp,omitempty"`
+	EventHubsOutputConfig    *EventHubsOutputConfig         `json:"eventHubs,omitempty"`
+	PubSubOutputConfig       *PubSubOutputConfig            `json:"pubSub,omitempty"`
+	PushgatewayOutputConfig  *PushgatewayOutputConfig       `json:"pushgateway,omitempty"`
+	FailureAlertOutputConfig *FailureAlertOutputConfig      `json:"failureAlert,omitempty"`
+	ProcessedOutputConfig    *ProcessedOutputConfig         `json:"processed,omitempty"`
+	GeoJSONOutputConfig      *GeoJSONOutputConfig           `json:"geojson,omitempty"`
+	RolloutOutputConfig      *RolloutOutputConfig           `json:"rollout,omitempty"`
 }
 
 type MultipleFrameConditionCheckerConfig struct {
@@ -122,14 +596,92 @@ type NumberCompareFrameConditionConfig struct {
 	Value     float64         `json:"value"`
 }
 
+type RegexMatchFrameConditionConfig struct {
+	FieldName string `json:"fieldName"`
+	// Pattern is a regular expression in RE2 syntax matched against a string field value.
+	Pattern string `json:"pattern"`
+}
+
+type LabelCompareFrameConditionConfig struct {
+	// FieldName to check labels of, labels of all fields are checked when empty.
+	FieldName string         `json:"fieldName,omitempty"`
+	Label     string         `json:"label"`
+	Op        LabelCompareOp `json:"op"`
+	// Value to compare with, a regular expression matching a whole label value for
+	// regex and notRegex ops.
+	Value string `json:"value"`
+}
+
+type ValueChangedFrameConditionConfig struct {
+	FieldName string `json:"fieldName"`
+}
+
+type ExpressionFrameConditionConfig struct {
+	// Language of an expression, only "cel" is supported at the moment.
+	Language string `json:"language,omitempty"`
+	// Expression returning bool, like `value("temp") > 80 && label("site") == "berlin"`.
+	Expression string `json:"expression"`
+	// CostLimit limits cost of an evaluation, 1000000 by default.
+	CostLimit uint64 `json:"costLimit,omitempty"`
+}
+
+type FrameSizeFrameConditionConfig struct {
+	// Measure is rows or bytes, rows by default. Bytes is a length of frame JSON.
+	Measure FrameSizeMeasure `json:"measure,omitempty"`
+	Op      NumberCompareOp  `json:"op"`
+	Value   float64          `json:"value"`
+}
+
+type FieldPresentFrameConditionConfig struct {
+	FieldName string `json:"fieldName"`
+}
+
+type IsNullFrameConditionConfig struct {
+	FieldName string `json:"fieldName"`
+	// NotNull inverts the condition, so it's true for a field with a value.
+	NotNull bool `json:"notNull,omitempty"`
+}
+
+type ScheduleFrameConditionConfig struct {
+	// Timezone is an IANA time zone name windows and cron expression are evaluated
+	// in, UTC by default.
+	Timezone string `json:"timezone,omitempty"`
+	// Windows are weekday and time of day ranges the condition passes in.
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+	// Cron expression like "* 9-17 * * 1-5", the condition passes during every
+	// minute matching it.
+	Cron string `json:"cron,omitempty"`
+}
+
+type ScheduleWindow struct {
+	// Weekdays like "mon" or ranges like "mon-fri", all days by default.
+	Weekdays []string `json:"weekdays,omitempty"`
+	// From and To are times of day in "15:04" format, To is exclusive. A window
+	// wraps around midnight when To is before From. Whole day by default.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
 type FrameConditionCheckerConfig struct {
 	Type                           string                               `json:"type" ts_type:"Omit<keyof FrameConditionCheckerConfig, 'type'>"`
 	MultipleConditionCheckerConfig *MultipleFrameConditionCheckerConfig `json:"multiple,omitempty"`
 	NumberCompareConditionConfig   *NumberCompareFrameConditionConfig   `json:"numberCompare,omitempty"`
+	RegexMatchConditionConfig      *RegexMatchFrameConditionConfig      `json:"regexMatch,omitempty"`
+	FieldPresentConditionConfig    *FieldPresentFrameConditionConfig    `json:"fieldPresent,omitempty"`
+	IsNullConditionConfig          *IsNullFrameConditionConfig          `json:"isNull,omitempty"`
+	ScheduleConditionConfig        *ScheduleFrameConditionConfig        `json:"schedule,omitempty"`
+	LabelCompareConditionConfig    *LabelCompareFrameConditionConfig    `json:"labelCompare,omitempty"`
+	ValueChangedConditionConfig    *ValueChangedFrameConditionConfig    `json:"valueChanged,omitempty"`
+	ExpressionConditionConfig      *ExpressionFrameConditionConfig      `json:"expression,omitempty"`
+	FrameSizeConditionConfig       *FrameSizeFrameConditionConfig       `json:"frameSize,omitempty"`
 }
 
 type AutoJsonConverterConfig struct {
 	FieldTips map[string]Field `json:"fieldTips,omitempty"`
+	// Schema is a UID of a jsonobj entity with a JSON Schema of documents. Field types,
+	// required properties and defaults are taken from the schema instead of inference,
+	// field tips override schema types. Schema is reloaded when the entity changes.
+	Schema string `json:"schema,omitempty"`
 }
 
 // Field description.
@@ -152,4 +704,9 @@ type AutoInfluxConverterConfig struct {
 
 type JsonFrameConverterConfig struct{}
 
-type ManagedStreamOutputConfig struct{}
+type ManagedStreamOutputConfig struct {
+	// SchemaValidation checks frames against the schema of the last frame published to
+	// a channel: "adapt" converts frames to the schema, "reject" sends mismatching frames
+	// to the dead-letter queue instead of publishing. Frames are not checked when empty.
+	SchemaValidation string `json:"schemaValidation,omitempty"`
+}
@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// CBORConverterMode defines how CBOR documents are converted to frames.
+type CBORConverterMode string
+
+// Known CBORConverterMode types.
+const (
+	// CBORConverterModeAuto converts all values of a document like jsonAuto converter.
+	CBORConverterModeAuto CBORConverterMode = "auto"
+	// CBORConverterModeExact converts only configured fields.
+	CBORConverterModeExact CBORConverterMode = "exact"
+)
+
+type CBORConverterConfig struct {
+	// Mode is auto by default.
+	Mode CBORConverterMode `json:"mode,omitempty"`
+	// FieldTips are used in auto mode, same as in jsonAuto converter.
+	FieldTips map[string]Field `json:"fieldTips,omitempty"`
+	// Fields are used in exact mode. Field value is a path to a value in a document in
+	// the same form as field names produced by auto mode, for example "sensor.values[0]".
+	// Time fields with empty value get the current time. Label values are paths too,
+	// label value is used as is when a document has no such path.
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// CBORConverter converts CBOR documents sent by constrained devices (for example
+// over CoAP) to frames. Standard date/time tags are converted to time values, byte
+// strings to base64 encoded strings.
+type CBORConverter struct {
+	config      CBORConverterConfig
+	parser      *valueParser
+	nowTimeFunc func() time.Time
+}
+
+func NewCBORConverter(c CBORConverterConfig, locale *LocaleConfig) (*CBORConverter, error) {
+	switch c.Mode {
+	case "":
+		c.Mode = CBORConverterModeAuto
+	case CBORConverterModeAuto:
+	case CBORConverterModeExact:
+		if len(c.Fields) == 0 {
+			return nil, fmt.Errorf("no fields configured for exact mode")
+		}
+	default:
+		return nil, fmt.Errorf("unknown CBOR converter mode: %s", c.Mode)
+	}
+	converter := &CBORConverter{config: c}
+	if locale != nil {
+		parser, err := newValueParser(*locale)
+		if err != nil {
+			return nil, err
+		}
+		converter.parser = parser
+	}
+	return converter, nil
+}
+
+const ConverterTypeCBOR = "cbor"
+
+func (c *CBORConverter) Type() string {
+	return ConverterTypeCBOR
+}
+
+func (c *CBORConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	nowTimeFunc := c.nowTimeFunc
+	if nowTimeFunc == nil {
+		nowTimeFunc = time.Now
+	}
+	v, err := decodeCBOR(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var frame *data.Frame
+	if c.config.Mode == CBORConverterModeExact {
+		frame, err = c.exactFrame(vars.Path, v, nowTimeFunc)
+	} else {
+		d := newDoc(c.config.FieldTips, c.parser, nowTimeFunc)
+		d.walk(v)
+		frame, err = d.frame(vars.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*ChannelFrame{
+		{Channel: "", Frame: frame},
+	}, nil
+}
+
+// walk adds fields for decoded CBOR values the same way next does for JSON.
+func (d *doc) walk(v any) {
+	switch val := v.(type) {
+	case string:
+		d.addString(val)
+	case float64:
+		d.addNumber(val)
+	case bool:
+		d.addBool(val)
+	case time.Time:
+		d.addTime(val)
+	case nil:
+		d.addNil()
+	case []any:
+		size := len(d.path)
+		for i, item := range val {
+			d.path = append(d.path, fmt.Sprintf("[%d]", i))
+			d.walk(item)
+			d.path = d.path[:size]
+		}
+	case *cborMap:
+		size := len(d.path)
+		for _, key := range val.keys {
+			if size > 0 {
+				d.path = append(d.path, ".")
+			}
+			d.path = append(d.path, key)
+			d.walk(val.values[key])
+			d.path = d.path[:size]
+		}
+	}
+}
+
+func (c *CBORConverter) exactFrame(name string, doc any, nowTimeFunc func() time.Time) (*data.Frame, error) {
+	fields := make([]*data.Field, 0, len(c.config.Fields))
+	for _, fieldConfig := range c.config.Fields {
+		f := data.NewFieldFromFieldType(fieldConfig.Type, 1)
+		f.Name = fieldConfig.Name
+		f.Config = fieldConfig.Config
+		if len(fieldConfig.Labels) > 0 {
+			f.Labels = make(data.Labels, len(fieldConfig.Labels))
+			for _, label := range fieldConfig.Labels {
+				value := label.Value
+				if v, ok := lookupCBORPath(doc, label.Value); ok && v != nil {
+					value = fmt.Sprintf("%v", v)
+				}
+				f.Labels[label.Name] = value
+			}
+		}
+
+		var (
+			v  any
+			ok bool
+		)
+		if fieldConfig.Value == "" && fieldConfig.Type.Time() {
+			v, ok = nowTimeFunc(), true
+		} else {
+			v, ok = lookupCBORPath(doc, fieldConfig.Value)
+		}
+		if !ok || v == nil {
+			if !fieldConfig.Type.Nullable() {
+				return nil, fmt.Errorf("value for field %s not found", fieldConfig.Name)
+			}
+			fields = append(fields, f)
+			continue
+		}
+		if s, isString := v.(string); isString && c.parser != nil {
+			switch {
+			case fieldConfig.Type.Numeric():
+				if n, err := c.parser.parseNumber(s); err == nil {
+					v = n
+				}
+			case fieldConfig.Type.Time():
+				if t, err := c.parser.parseTime(s); err == nil {
+					v = t
+				}
+			}
+		}
+		converted, err := convertToFieldType(v, fieldConfig.Type)
+		if err != nil {
+			return nil, fmt.Errorf("error converting value for field %s: %w", fieldConfig.Name, err)
+		}
+		f.SetConcrete(0, converted)
+		fields = append(fields, f)
+	}
+	return data.NewFrame(name, fields...), nil
+}
+
+// lookupCBORPath returns a value by path like "a.b[1].c".
+func lookupCBORPath(v any, path string) (any, bool) {
+	for path != "" {
+		if strings.HasPrefix(path, "[") {
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, false
+			}
+			index, err := strconv.Atoi(path[1:end])
+			arr, ok := v.([]any)
+			if err != nil || !ok || index < 0 || index >= len(arr) {
+				return nil, false
+			}
+			v = arr[index]
+			path = strings.TrimPrefix(path[end+1:], ".")
+			continue
+		}
+		m, ok := v.(*cborMap)
+		if !ok {
+			return nil, false
+		}
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			end = len(path)
+		}
+		v, ok = m.values[path[:end]]
+		if !ok {
+			return nil, false
+		}
+		path = strings.TrimPrefix(path[end:], ".")
+	}
+	return v, true
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func cborText(s string) []byte {
+	return append([]byte{0x60 | byte(len(s))}, s...)
+}
+
+func cborJoin(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// {"sensor": {"temp": 21.5, "ok": true}, "ts": 1(1363896240), "values": [1, -2], "name": (_ "ab", "c")}
+var testCBORDocument = cborJoin(
+	[]byte{0xa4},
+	cborText("sensor"), []byte{0xa2},
+	cborText("temp"), []byte{0xf9, 0x4d, 0x60},
+	cborText("ok"), []byte{0xf5},
+	cborText("ts"), []byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0},
+	cborText("values"), []byte{0x82, 0x01, 0x21},
+	cborText("name"), []byte{0x7f}, cborText("ab"), cborText("c"), []byte{0xff},
+)
+
+func TestCBORConverter_Convert_auto(t *testing.T) {
+	now := time.Unix(100, 0)
+	c, err := NewCBORConverter(CBORConverterConfig{}, nil)
+	require.NoError(t, err)
+	c.nowTimeFunc = func() time.Time { return now }
+
+	frames, err := c.Convert(context.Background(), Vars{Path: "test"}, testCBORDocument)
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	frame := frames[0].Frame
+
+	names := make([]string, 0, len(frame.Fields))
+	for _, f := range frame.Fields {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"Time", "sensor.temp", "sensor.ok", "ts", "values[0]", "values[1]", "name"}, names)
+	require.Equal(t, now, frame.Fields[0].At(0))
+	require.Equal(t, 21.5, *frame.Fields[1].At(0).(*float64))
+	require.True(t, *frame.Fields[2].At(0).(*bool))
+	require.Equal(t, time.Unix(1363896240, 0).UTC(), *frame.Fields[3].At(0).(*time.Time))
+	require.Equal(t, -2.0, *frame.Fields[5].At(0).(*float64))
+	require.Equal(t, "abc", *frame.Fields[6].At(0).(*string))
+}
+
+func TestCBORConverter_Convert_exact(t *testing.T) {
+	now := time.Unix(100, 0)
+	c, err := NewCBORConverter(CBORConverterConfig{
+		Mode: CBORConverterModeExact,
+		Fields: []Field{
+			{Name: "time", Type: data.FieldTypeTime, Value: "ts"},
+			{Name: "received", Type: data.FieldTypeTime},
+			{
+				Name:   "temperature",
+				Type:   data.FieldTypeNullableFloat64,
+				Value:  "sensor.temp",
+				Labels: []Label{{Name: "device", Value: "name"}, {Name: "unit", Value: "celsius"}},
+			},
+			{Name: "second", Type: data.FieldTypeFloat64, Value: "values[1]"},
+			{Name: "missing", Type: data.FieldTypeNullableString, Value: "sensor.missing"},
+		},
+	}, nil)
+	require.NoError(t, err)
+	c.nowTimeFunc = func() time.Time { return now }
+
+	frames, err := c.Convert(context.Background(), Vars{Path: "test"}, testCBORDocument)
+	require.NoError(t, err)
+	frame := frames[0].Frame
+	require.Len(t, frame.Fields, 5)
+	require.Equal(t, time.Unix(1363896240, 0).UTC(), frame.Fields[0].At(0))
+	require.Equal(t, now, frame.Fields[1].At(0))
+	require.Equal(t, 21.5, *frame.Fields[2].At(0).(*float64))
+	require.Equal(t, data.Labels{"device": "abc", "unit": "celsius"}, frame.Fields[2].Labels)
+	require.Equal(t, -2.0, frame.Fields[3].At(0))
+	require.Nil(t, frame.Fields[4].At(0))
+}
+
+func TestDecodeCBOR_longMap(t *testing.T) {
+	// Map of 24 entries has a length in the byte following the initial byte.
+	body := []byte{0xb8, 24}
+	for i := 0; i < 24; i++ {
+		body = cborJoin(body, cborText(string(rune('a'+i))), []byte{byte(i)})
+	}
+	v, err := decodeCBOR(body)
+	require.NoError(t, err)
+	m := v.(*cborMap)
+	require.Len(t, m.keys, 24)
+	require.Equal(t, "x", m.keys[23])
+	require.Equal(t, 23.0, m.values["x"])
+}
+
+func TestCBORConverter_Convert_invalid(t *testing.T) {
+	c, err := NewCBORConverter(CBORConverterConfig{}, nil)
+	require.NoError(t, err)
+	for _, body := range [][]byte{
+		{},
+		{0xa1, 0x61},
+		{0x82, 0x01},
+		{0x01, 0x02},
+		{0xff},
+	} {
+		_, err := c.Convert(context.Background(), Vars{}, body)
+		require.Error(t, err, "%x", body)
+	}
+
+	_, err = NewCBORConverter(CBORConverterConfig{Mode: CBORConverterModeExact}, nil)
+	require.Error(t, err)
+}
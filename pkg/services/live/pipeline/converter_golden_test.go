@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental"
+	"github.com/stretchr/testify/require"
+)
+
+// converterFixturesDir has a directory per converter test case with:
+//   - converter.json: converter settings as in a channel rule,
+//   - input.*: the raw message body, any extension,
+//   - output.golden.jsonc: expected frames, the output channel of each frame is its refId,
+//     or error.golden.txt when conversion is expected to fail.
+//
+// Golden files are (re)generated with go test -run TestConverterFixtures -update.
+const converterFixturesDir = "testdata/converters"
+
+// converterFixtureNow is the current time seen by converters in fixtures
+var converterFixtureNow = time.Date(2021, 01, 01, 12, 12, 12, 0, time.UTC)
+
+func TestConverterFixtures(t *testing.T) {
+	dirs, err := os.ReadDir(converterFixturesDir)
+	require.NoError(t, err)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		name := dir.Name()
+		t.Run(name, func(t *testing.T) {
+			checkConverterFixture(t, filepath.Join(converterFixturesDir, name))
+		})
+	}
+}
+
+func checkConverterFixture(t *testing.T, dir string) {
+	t.Helper()
+	// Safe to disable, this is a test.
+	// nolint:gosec
+	configJSON, err := os.ReadFile(filepath.Join(dir, "converter.json"))
+	require.NoError(t, err)
+	var config ConverterConfig
+	require.NoError(t, json.Unmarshal(configJSON, &config))
+
+	builder := &StorageRuleBuilder{}
+	converter, err := builder.extractConverter(&config, nil)
+	require.NoError(t, err)
+	require.NotNil(t, converter, "converter type is required")
+	setConverterNow(converter, func() time.Time { return converterFixtureNow })
+
+	inputs, err := filepath.Glob(filepath.Join(dir, "input.*"))
+	require.NoError(t, err)
+	require.Len(t, inputs, 1, "expected a single input file")
+	// nolint:gosec
+	body, err := os.ReadFile(inputs[0])
+	require.NoError(t, err)
+
+	name := filepath.Base(dir)
+	channelFrames, err := converter.Convert(context.Background(), Vars{
+		OrgID:     1,
+		Channel:   "stream/fixtures/" + name,
+		Scope:     "stream",
+		Namespace: "fixtures",
+		Path:      name,
+	}, body)
+	if err != nil {
+		checkGoldenError(t, filepath.Join(dir, "error.golden.txt"), err)
+		return
+	}
+
+	dr := &backend.DataResponse{}
+	for _, cf := range channelFrames {
+		cf.Frame.RefID = cf.Channel
+		dr.Frames = append(dr.Frames, cf.Frame)
+	}
+	experimental.CheckGoldenJSONResponse(t, dir, "output.golden", dr, *update)
+}
+
+func checkGoldenError(t *testing.T, path string, err error) {
+	t.Helper()
+	// nolint:gosec
+	expected, readErr := os.ReadFile(path)
+	if readErr != nil && *update {
+		require.NoError(t, os.WriteFile(path, []byte(err.Error()+"\n"), 0600))
+		return
+	}
+	require.NoError(t, readErr, "unexpected conversion error: %v", err)
+	require.Equal(t, strings.TrimSpace(string(expected)), err.Error())
+}
+
+// setConverterNow fixes the current time of converters adding it to frames, new
+// converters depending on the current time should be added here.
+func setConverterNow(converter Converter, now func() time.Time) {
+	switch c := converter.(type) {
+	case *AutoJsonConverter:
+		c.nowTimeFunc = now
+	case *CBORConverter:
+		c.nowTimeFunc = now
+	case *NMEAConverter:
+		c.nowTimeFunc = now
+	}
+}
@@ -2,18 +2,43 @@ package pipeline
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
 type AutoJsonConverter struct {
 	config      AutoJsonConverterConfig
+	parser      *valueParser
 	nowTimeFunc func() time.Time
+	// schemas is set when config references a JSON Schema entity.
+	schemas *jsonSchemaCache
 }
 
 func NewAutoJsonConverter(c AutoJsonConverterConfig) *AutoJsonConverter {
 	return &AutoJsonConverter{config: c}
 }
 
+// NewAutoJsonConverterWithLocale creates a converter which parses string values of fields
+// with numeric or time type in field tips according to a locale.
+func NewAutoJsonConverterWithLocale(c AutoJsonConverterConfig, locale LocaleConfig) (*AutoJsonConverter, error) {
+	parser, err := newValueParser(locale)
+	if err != nil {
+		return nil, err
+	}
+	return &AutoJsonConverter{config: c, parser: parser}, nil
+}
+
+// useJSONSchema makes converter take field types, required fields and defaults from
+// a JSON Schema entity instead of inferring them from documents.
+func (c *AutoJsonConverter) useJSONSchema(getter JSONSchemaGetter) error {
+	if getter == nil {
+		return errors.New("json schema store is not available")
+	}
+	c.schemas = newJSONSchemaCache(getter, c.config.Schema)
+	return nil
+}
+
 const ConverterTypeJsonAuto = "jsonAuto"
 
 func (c *AutoJsonConverter) Type() string {
@@ -26,15 +51,34 @@ func (c *AutoJsonConverter) Type() string {
 // To preserve nulls we need FieldTips from a user.
 // Custom time can be injected on FrameProcessor stage theoretically.
 // Custom labels can be injected on FrameProcessor stage theoretically.
-func (c *AutoJsonConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+func (c *AutoJsonConverter) Convert(ctx context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
 	nowTimeFunc := c.nowTimeFunc
 	if nowTimeFunc == nil {
 		nowTimeFunc = time.Now
 	}
-	frame, err := jsonDocToFrame(vars.Path, body, c.config.FieldTips, nowTimeFunc)
+	fieldTips := c.config.FieldTips
+	var schema *jsonSchema
+	if c.schemas != nil {
+		var err error
+		schema, err = c.schemas.get(ctx, vars.OrgID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting json schema: %w", err)
+		}
+		body, err = schema.prepare(body)
+		if err != nil {
+			return nil, err
+		}
+		fieldTips = schemaFieldTips(schema, fieldTips)
+	}
+	frame, err := jsonDocToFrame(vars.Path, body, fieldTips, c.parser, nowTimeFunc)
 	if err != nil {
 		return nil, err
 	}
+	if schema != nil {
+		if err := schema.applyTypes(frame, c.config.FieldTips); err != nil {
+			return nil, err
+		}
+	}
 	return []*ChannelFrame{
 		{Channel: "", Frame: frame},
 	}, nil
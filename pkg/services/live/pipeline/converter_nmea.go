@@ -0,0 +1,319 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// NMEASpeedUnit is a unit of speed field produced by NMEA converter.
+type NMEASpeedUnit string
+
+// Known NMEASpeedUnit types.
+const (
+	NMEASpeedUnitKnots NMEASpeedUnit = "knots"
+	NMEASpeedUnitKmh   NMEASpeedUnit = "kmh"
+	NMEASpeedUnitMs    NMEASpeedUnit = "ms"
+)
+
+type NMEAConverterConfig struct {
+	// SpeedUnit of speed field, knots by default.
+	SpeedUnit NMEASpeedUnit `json:"speedUnit,omitempty"`
+}
+
+// NMEAConverter converts NMEA 0183 GGA and RMC sentences (one per line) into a frame
+// with a row per position fix. Sentences with invalid checksum, without fix and of
+// other types are skipped.
+type NMEAConverter struct {
+	config      NMEAConverterConfig
+	nowTimeFunc func() time.Time
+}
+
+func NewNMEAConverter(c NMEAConverterConfig) (*NMEAConverter, error) {
+	switch c.SpeedUnit {
+	case "":
+		c.SpeedUnit = NMEASpeedUnitKnots
+	case NMEASpeedUnitKnots, NMEASpeedUnitKmh, NMEASpeedUnitMs:
+	default:
+		return nil, fmt.Errorf("unknown speed unit: %s", c.SpeedUnit)
+	}
+	return &NMEAConverter{config: c}, nil
+}
+
+const ConverterTypeNMEA = "nmea"
+
+func (c *NMEAConverter) Type() string {
+	return ConverterTypeNMEA
+}
+
+type nmeaPosition struct {
+	time       time.Time
+	latitude   float64
+	longitude  float64
+	altitude   *float64
+	speed      *float64
+	heading    *float64
+	satellites *float64
+}
+
+func (c *NMEAConverter) Convert(_ context.Context, vars Vars, body []byte) ([]*ChannelFrame, error) {
+	nowTimeFunc := c.nowTimeFunc
+	if nowTimeFunc == nil {
+		nowTimeFunc = time.Now
+	}
+	now := nowTimeFunc().UTC()
+
+	var positions []nmeaPosition
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := parseNMEASentence(line)
+		if err != nil {
+			logger.Debug("Skip NMEA sentence", "sentence", line, "error", err)
+			continue
+		}
+		var pos *nmeaPosition
+		switch {
+		case strings.HasSuffix(fields[0], "GGA"):
+			pos, err = parseNMEAGGA(fields, now)
+		case strings.HasSuffix(fields[0], "RMC"):
+			pos, err = parseNMEARMC(fields)
+		default:
+			continue
+		}
+		if err != nil {
+			logger.Debug("Skip NMEA sentence", "sentence", line, "error", err)
+			continue
+		}
+		if pos == nil {
+			continue
+		}
+		if pos.speed != nil {
+			speed := c.convertSpeed(*pos.speed)
+			pos.speed = &speed
+		}
+		positions = append(positions, *pos)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(positions) == 0 {
+		return nil, errors.New("no position fixes found in NMEA sentences")
+	}
+
+	timeField := data.NewField("time", nil, make([]time.Time, len(positions)))
+	latField := data.NewField("latitude", nil, make([]float64, len(positions)))
+	lonField := data.NewField("longitude", nil, make([]float64, len(positions)))
+	altField := data.NewField("altitude", nil, make([]*float64, len(positions)))
+	speedField := data.NewField("speed", nil, make([]*float64, len(positions)))
+	headingField := data.NewField("heading", nil, make([]*float64, len(positions)))
+	satellitesField := data.NewField("satellites", nil, make([]*float64, len(positions)))
+	altField.Config = &data.FieldConfig{Unit: "lengthm"}
+	speedField.Config = &data.FieldConfig{Unit: c.speedFieldUnit()}
+	headingField.Config = &data.FieldConfig{Unit: "degree"}
+	for i, pos := range positions {
+		timeField.Set(i, pos.time)
+		latField.Set(i, pos.latitude)
+		lonField.Set(i, pos.longitude)
+		altField.Set(i, pos.altitude)
+		speedField.Set(i, pos.speed)
+		headingField.Set(i, pos.heading)
+		satellitesField.Set(i, pos.satellites)
+	}
+	frame := data.NewFrame(vars.Path, timeField, latField, lonField, altField, speedField, headingField, satellitesField)
+	return []*ChannelFrame{
+		{Channel: "", Frame: frame},
+	}, nil
+}
+
+func (c *NMEAConverter) convertSpeed(knots float64) float64 {
+	switch c.config.SpeedUnit {
+	case NMEASpeedUnitKmh:
+		return knots * 1.852
+	case NMEASpeedUnitMs:
+		return knots * 1852 / 3600
+	default:
+		return knots
+	}
+}
+
+func (c *NMEAConverter) speedFieldUnit() string {
+	switch c.config.SpeedUnit {
+	case NMEASpeedUnitKmh:
+		return "velocitykmh"
+	case NMEASpeedUnitMs:
+		return "velocityms"
+	default:
+		return "velocityknot"
+	}
+}
+
+// parseNMEASentence validates a checksum and returns comma separated sentence fields
+// starting with an address like GPGGA.
+func parseNMEASentence(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "$") && !strings.HasPrefix(s, "!") {
+		return nil, errors.New("sentence must start with $")
+	}
+	s = s[1:]
+	if i := strings.LastIndexByte(s, '*'); i >= 0 {
+		expected, err := strconv.ParseUint(s[i+1:], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum: %w", err)
+		}
+		var checksum byte
+		for j := 0; j < i; j++ {
+			checksum ^= s[j]
+		}
+		if checksum != byte(expected) {
+			return nil, fmt.Errorf("checksum mismatch: %02X != %02X", checksum, expected)
+		}
+		s = s[:i]
+	}
+	fields := strings.Split(s, ",")
+	if len(fields[0]) < 5 {
+		return nil, fmt.Errorf("invalid address: %s", fields[0])
+	}
+	return fields, nil
+}
+
+// parseNMEAGGA parses $--GGA,hhmmss.ss,llll.ll,a,yyyyy.yy,a,q,nn,h.h,a.a,M,... sentence.
+// GGA has no date, so date of now is used.
+func parseNMEAGGA(fields []string, now time.Time) (*nmeaPosition, error) {
+	if len(fields) < 10 {
+		return nil, errors.New("not enough GGA fields")
+	}
+	if fields[6] == "" || fields[6] == "0" {
+		// No fix.
+		return nil, nil
+	}
+	clock, err := parseNMEAClock(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(clock)
+	// Fix taken just before midnight received after midnight.
+	if t.Sub(now) > 12*time.Hour {
+		t = t.AddDate(0, 0, -1)
+	}
+	lat, lon, err := parseNMEACoordinates(fields[2:6])
+	if err != nil {
+		return nil, err
+	}
+	return &nmeaPosition{
+		time:       t,
+		latitude:   lat,
+		longitude:  lon,
+		satellites: parseNMEAOptionalFloat(fields[7]),
+		altitude:   parseNMEAOptionalFloat(fields[9]),
+	}, nil
+}
+
+// parseNMEARMC parses $--RMC,hhmmss.ss,A,llll.ll,a,yyyyy.yy,a,x.x,x.x,ddmmyy,... sentence.
+func parseNMEARMC(fields []string) (*nmeaPosition, error) {
+	if len(fields) < 10 {
+		return nil, errors.New("not enough RMC fields")
+	}
+	if fields[2] != "A" {
+		// Navigation receiver warning.
+		return nil, nil
+	}
+	clock, err := parseNMEAClock(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse("020106", fields[9])
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %w", err)
+	}
+	lat, lon, err := parseNMEACoordinates(fields[3:7])
+	if err != nil {
+		return nil, err
+	}
+	return &nmeaPosition{
+		time:      date.Add(clock),
+		latitude:  lat,
+		longitude: lon,
+		speed:     parseNMEAOptionalFloat(fields[7]),
+		heading:   parseNMEAOptionalFloat(fields[8]),
+	}, nil
+}
+
+func parseNMEAClock(s string) (time.Duration, error) {
+	if len(s) < 6 {
+		return 0, fmt.Errorf("invalid time: %s", s)
+	}
+	h, err1 := strconv.Atoi(s[0:2])
+	m, err2 := strconv.Atoi(s[2:4])
+	sec, err3 := strconv.ParseFloat(s[4:], 64)
+	if err1 != nil || err2 != nil || err3 != nil || h > 23 || m > 59 || sec >= 61 {
+		return 0, fmt.Errorf("invalid time: %s", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec*float64(time.Second)), nil
+}
+
+// parseNMEACoordinates parses latitude (ddmm.mm), N/S, longitude (dddmm.mm), E/W
+// fields into decimal degrees.
+func parseNMEACoordinates(fields []string) (float64, float64, error) {
+	lat, err := parseNMEADegrees(fields[0], 2)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude: %w", err)
+	}
+	lon, err := parseNMEADegrees(fields[2], 3)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude: %w", err)
+	}
+	switch fields[1] {
+	case "N":
+	case "S":
+		lat = -lat
+	default:
+		return 0, 0, fmt.Errorf("invalid latitude hemisphere: %q", fields[1])
+	}
+	switch fields[3] {
+	case "E":
+	case "W":
+		lon = -lon
+	default:
+		return 0, 0, fmt.Errorf("invalid longitude hemisphere: %q", fields[3])
+	}
+	return lat, lon, nil
+}
+
+func parseNMEADegrees(s string, degreeDigits int) (float64, error) {
+	if len(s) < degreeDigits+2 {
+		return 0, fmt.Errorf("too short: %q", s)
+	}
+	degrees, err := strconv.Atoi(s[:degreeDigits])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.ParseFloat(s[degreeDigits:], 64)
+	if err != nil {
+		return 0, err
+	}
+	if minutes >= 60 {
+		return 0, fmt.Errorf("invalid minutes: %q", s)
+	}
+	return float64(degrees) + minutes/60, nil
+}
+
+func parseNMEAOptionalFloat(s string) *float64 {
+	if s == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNMEAConverter_Convert(t *testing.T) {
+	c, err := NewNMEAConverter(NMEAConverterConfig{SpeedUnit: NMEASpeedUnitKmh})
+	require.NoError(t, err)
+	c.nowTimeFunc = func() time.Time { return time.Date(1994, 3, 23, 13, 0, 0, 0, time.UTC) }
+
+	body := []byte(`$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47
+$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A
+$GPGSV,2,1,08,01,40,083,46,02,17,308,41,12,07,344,39,14,22,228,45*75
+$GPGGA,123520,4807.038,N,01131.000,E,0,00,,,M,,M,,*4D
+$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*00
+`)
+	frames, err := c.Convert(context.Background(), Vars{Path: "tracker"}, body)
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	frame := frames[0].Frame
+	require.Equal(t, "tracker", frame.Name)
+	require.Equal(t, 2, frame.Rows())
+
+	expectedTime := time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC)
+	require.Equal(t, expectedTime, frame.Fields[0].At(0))
+	require.Equal(t, expectedTime, frame.Fields[0].At(1))
+	for row := 0; row < 2; row++ {
+		require.InDelta(t, 48.1173, frame.Fields[1].At(row), 0.0001)
+		require.InDelta(t, 11.5167, frame.Fields[2].At(row), 0.0001)
+	}
+
+	// GGA row has altitude and satellites.
+	require.Equal(t, 545.4, *frame.Fields[3].At(0).(*float64))
+	require.Nil(t, frame.Fields[4].At(0))
+	require.Equal(t, 8.0, *frame.Fields[6].At(0).(*float64))
+
+	// RMC row has speed and heading.
+	require.Nil(t, frame.Fields[3].At(1))
+	require.InDelta(t, 41.4848, *frame.Fields[4].At(1).(*float64), 0.0001)
+	require.Equal(t, 84.4, *frame.Fields[5].At(1).(*float64))
+}
+
+func TestNMEAConverter_Convert_noFix(t *testing.T) {
+	c, err := NewNMEAConverter(NMEAConverterConfig{})
+	require.NoError(t, err)
+	_, err = c.Convert(context.Background(), Vars{}, []byte("$GPRMC,123519,V,,,,,,,230394,,*00\nnot a sentence"))
+	require.Error(t, err)
+
+	_, err = NewNMEAConverter(NMEAConverterConfig{SpeedUnit: "mph"})
+	require.Error(t, err)
+}
+
+func TestParseNMEACoordinates(t *testing.T) {
+	lat, lon, err := parseNMEACoordinates([]string{"3351.000", "S", "15112.600", "W"})
+	require.NoError(t, err)
+	require.InDelta(t, -33.85, lat, 1e-9)
+	require.InDelta(t, -151.21, lon, 1e-9)
+
+	_, _, err = parseNMEACoordinates([]string{"3361.000", "S", "15112.600", "W"})
+	require.Error(t, err)
+	_, _, err = parseNMEACoordinates([]string{"3351.000", "X", "15112.600", "W"})
+	require.Error(t, err)
+}
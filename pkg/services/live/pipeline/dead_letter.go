@@ -0,0 +1,397 @@
+package pipeline
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// Pipeline stages where processing of a dead letter failed.
+const (
+	DeadLetterStageConvert = "convert"
+	DeadLetterStageProcess = "process"
+	DeadLetterStageOutput  = "output"
+)
+
+const (
+	defaultDeadLetterMaxEntries = 10000
+	defaultDeadLetterMaxBytes   = 64 << 20
+	defaultDeadLetterMaxAge     = 24 * time.Hour
+	deadLetterCompactInterval   = time.Minute
+)
+
+var (
+	deadLetterDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "dead_letter_queue_depth",
+		Help:      "Number of entries in the live pipeline dead-letter queue",
+	})
+	deadLetterBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "dead_letter_queue_bytes",
+		Help:      "Size of payloads kept in the live pipeline dead-letter queue",
+	})
+	deadLetterAdded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "dead_letters_total",
+		Help:      "A counter for failures put into the live pipeline dead-letter queue",
+	}, []string{"stage"})
+	deadLetterEvicted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "dead_letters_evicted_total",
+		Help:      "A counter for entries removed from the live pipeline dead-letter queue by retention",
+	}, []string{"reason"})
+)
+
+func init() {
+	prometheus.MustRegister(deadLetterDepth, deadLetterBytes, deadLetterAdded, deadLetterEvicted)
+}
+
+// DeadLetterQueueConfig sets retention of DeadLetterQueue, zero values mean defaults.
+type DeadLetterQueueConfig struct {
+	// MaxEntries limits a number of kept entries, the oldest are evicted first.
+	MaxEntries int
+	// MaxBytes limits a total size of kept payloads.
+	MaxBytes int
+	// MaxAge is a period after the last failure an entry is kept for.
+	MaxAge time.Duration
+}
+
+// DeadLetter is a payload or a frame which failed pipeline processing.
+type DeadLetter struct {
+	ID      string `json:"id"`
+	OrgID   int64  `json:"orgId"`
+	Channel string `json:"channel"`
+	// Stage is one of DeadLetterStageConvert, DeadLetterStageProcess or DeadLetterStageOutput.
+	Stage string `json:"stage"`
+	Error string `json:"error"`
+	// Data is a raw payload, set for failures before conversion to frames.
+	Data []byte `json:"data,omitempty"`
+	// Frame in JSON format, set for failures after conversion.
+	Frame     json.RawMessage `json:"frame,omitempty"`
+	FirstSeen time.Time       `json:"firstSeen"`
+	LastSeen  time.Time       `json:"lastSeen"`
+	// Count is a number of identical failures compacted into this entry.
+	Count int64 `json:"count"`
+	// MessageID of the last failure, replays keep it.
+	MessageID string `json:"messageId,omitempty"`
+
+	key string
+}
+
+func (d *DeadLetter) size() int {
+	return len(d.Data) + len(d.Frame)
+}
+
+// DeadLetterQueue keeps failed payloads and frames in memory so they could be
+// inspected and replayed later. Identical failures of the same payload are compacted
+// into one entry with a counter, entries are evicted by age, count and total size
+// so the queue stays bounded during long backend outages. The queue is not shared
+// in HA setup.
+type DeadLetterQueue struct {
+	config DeadLetterQueueConfig
+
+	mu     sync.Mutex
+	nextID uint64
+	bytes  int
+	// entries are ordered by last failure time.
+	entries *list.List
+	byKey   map[string]*list.Element
+	byID    map[string]*list.Element
+}
+
+func NewDeadLetterQueue(config DeadLetterQueueConfig) *DeadLetterQueue {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultDeadLetterMaxEntries
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultDeadLetterMaxBytes
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = defaultDeadLetterMaxAge
+	}
+	return &DeadLetterQueue{
+		config:  config,
+		entries: list.New(),
+		byKey:   map[string]*list.Element{},
+		byID:    map[string]*list.Element{},
+	}
+}
+
+// Run periodically removes expired entries until context is done.
+func (q *DeadLetterQueue) Run(ctx context.Context) error {
+	ticker := time.NewTicker(deadLetterCompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.Compact(time.Now())
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// AddData puts a raw payload which failed processing into the queue.
+func (q *DeadLetterQueue) AddData(orgID int64, channel string, messageID string, stage string, err error, payload []byte) {
+	q.add(&DeadLetter{
+		OrgID:     orgID,
+		Channel:   channel,
+		Stage:     stage,
+		Error:     err.Error(),
+		Data:      payload,
+		MessageID: messageID,
+	}, time.Now())
+}
+
+// AddFrame puts a frame which failed processing into the queue.
+func (q *DeadLetterQueue) AddFrame(orgID int64, channel string, messageID string, stage string, err error, frame *data.Frame) {
+	frameJSON, jsonErr := data.FrameToJSON(frame, data.IncludeAll)
+	if jsonErr != nil {
+		logger.Error("Error encoding dead letter frame", "channel", channel, "error", jsonErr)
+		return
+	}
+	q.add(&DeadLetter{
+		OrgID:     orgID,
+		Channel:   channel,
+		Stage:     stage,
+		Error:     err.Error(),
+		Frame:     frameJSON,
+		MessageID: messageID,
+	}, time.Now())
+}
+
+func (q *DeadLetterQueue) add(d *DeadLetter, now time.Time) {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00", d.OrgID, d.Channel, d.Stage, d.Error)
+	_, _ = h.Write(d.Data)
+	_, _ = h.Write(d.Frame)
+	d.key = hex.EncodeToString(h.Sum(nil))
+
+	deadLetterAdded.WithLabelValues(d.Stage).Inc()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if el, ok := q.byKey[d.key]; ok {
+		existing := el.Value.(*DeadLetter)
+		existing.Count++
+		existing.LastSeen = now
+		existing.MessageID = d.MessageID
+		q.entries.MoveToBack(el)
+		return
+	}
+	q.nextID++
+	d.ID = strconv.FormatUint(q.nextID, 10)
+	d.FirstSeen = now
+	d.LastSeen = now
+	d.Count = 1
+	el := q.entries.PushBack(d)
+	q.byKey[d.key] = el
+	q.byID[d.ID] = el
+	q.bytes += d.size()
+	q.evictLocked(now)
+}
+
+// Compact removes entries older than configured max age.
+func (q *DeadLetterQueue) Compact(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.evictLocked(now)
+}
+
+func (q *DeadLetterQueue) evictLocked(now time.Time) {
+	for el := q.entries.Front(); el != nil; el = q.entries.Front() {
+		d := el.Value.(*DeadLetter)
+		var reason string
+		switch {
+		case now.Sub(d.LastSeen) > q.config.MaxAge:
+			reason = "age"
+		case q.entries.Len() > q.config.MaxEntries:
+			reason = "entries"
+		case q.bytes > q.config.MaxBytes:
+			reason = "bytes"
+		}
+		if reason == "" {
+			break
+		}
+		q.removeLocked(el)
+		deadLetterEvicted.WithLabelValues(reason).Inc()
+	}
+	q.updateMetricsLocked()
+}
+
+func (q *DeadLetterQueue) removeLocked(el *list.Element) {
+	d := q.entries.Remove(el).(*DeadLetter)
+	delete(q.byKey, d.key)
+	delete(q.byID, d.ID)
+	q.bytes -= d.size()
+}
+
+func (q *DeadLetterQueue) updateMetricsLocked() {
+	deadLetterDepth.Set(float64(q.entries.Len()))
+	deadLetterBytes.Set(float64(q.bytes))
+}
+
+// List returns entries of an organization, the most recent failures first.
+func (q *DeadLetterQueue) List(orgID int64) []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var result []DeadLetter
+	for el := q.entries.Back(); el != nil; el = el.Prev() {
+		d := el.Value.(*DeadLetter)
+		if d.OrgID == orgID {
+			result = append(result, *d)
+		}
+	}
+	return result
+}
+
+// Remove deletes entries of an organization by ids, all organization entries are
+// removed when ids are empty.
+func (q *DeadLetterQueue) Remove(orgID int64, ids ...string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(ids) == 0 {
+		for el := q.entries.Front(); el != nil; {
+			next := el.Next()
+			if el.Value.(*DeadLetter).OrgID == orgID {
+				q.removeLocked(el)
+			}
+			el = next
+		}
+	}
+	for _, id := range ids {
+		if el, ok := q.byID[id]; ok && el.Value.(*DeadLetter).OrgID == orgID {
+			q.removeLocked(el)
+		}
+	}
+	q.updateMetricsLocked()
+}
+
+// deadLetterData passes a payload which failed conversion to the dead-letter queue and
+// the dead letter output of a rule.
+func (p *Pipeline) deadLetterData(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, err error, body []byte) {
+	vars := Vars{OrgID: orgID, Channel: channelID, MessageID: MessageIDFromContext(ctx)}
+	p.recordMessage(ctx, rule, vars, MessageEventDeadLetter, DeadLetterStageConvert, err)
+	if p.DeadLetters != nil {
+		p.DeadLetters.AddData(orgID, channelID, vars.MessageID, DeadLetterStageConvert, err, body)
+	}
+	if rule.DeadLetterOutputter == nil {
+		return
+	}
+	if ch, parseErr := live.ParseChannel(channelID); parseErr == nil {
+		vars.Scope, vars.Namespace, vars.Path = ch.Scope, ch.Namespace, ch.Path
+	}
+	frame := data.NewFrame("deadLetter",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("data", nil, []string{string(body)}),
+	)
+	p.outputDeadLetter(ctx, rule, vars, DeadLetterStageConvert, err, frame)
+}
+
+// deadLetterFrame passes a frame which failed processing or output to the dead-letter
+// queue and the dead letter output of a rule.
+func (p *Pipeline) deadLetterFrame(ctx context.Context, rule *LiveChannelRule, vars Vars, stage string, err error, frame *data.Frame) {
+	p.recordMessage(ctx, rule, vars, MessageEventDeadLetter, stage, err)
+	if p.DeadLetters != nil {
+		p.DeadLetters.AddFrame(vars.OrgID, vars.Channel, vars.MessageID, stage, err, frame)
+	}
+	if rule.DeadLetterOutputter == nil {
+		return
+	}
+	// Frame is copied as it's shared with other outputs.
+	p.outputDeadLetter(ctx, rule, vars, stage, err, truncateFrame(frame, frame.Rows()))
+}
+
+func (p *Pipeline) outputDeadLetter(ctx context.Context, rule *LiveChannelRule, vars Vars, stage string, err error, frame *data.Frame) {
+	for _, f := range frame.Fields {
+		if f.Labels == nil {
+			f.Labels = data.Labels{}
+		}
+		f.Labels["stage"] = stage
+		f.Labels["error"] = err.Error()
+	}
+	if _, outErr := p.processFrameOutput(ctx, rule.DeadLetterOutputter, vars, frame); outErr != nil {
+		logger.Error("Error outputting dead letter", "channel", vars.Channel, "stage", stage, "error", outErr)
+	}
+}
+
+// DeadLetterReplayResult contains ids of replayed entries and errors of entries
+// which failed again.
+type DeadLetterReplayResult struct {
+	Replayed []string          `json:"replayed"`
+	Failed   map[string]string `json:"failed,omitempty"`
+}
+
+// ReplayDeadLetters passes entries of an organization through the pipeline again,
+// all organization entries are replayed when ids are empty. The pipeline can be built
+// from modified rules to fix a failure cause. Successfully replayed entries are removed
+// from the queue.
+func (p *Pipeline) ReplayDeadLetters(ctx context.Context, q *DeadLetterQueue, orgID int64, ids []string) (DeadLetterReplayResult, error) {
+	entries := q.List(orgID)
+	if len(ids) > 0 {
+		wanted := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			wanted[id] = struct{}{}
+		}
+		filtered := entries[:0]
+		for _, d := range entries {
+			if _, ok := wanted[d.ID]; ok {
+				filtered = append(filtered, d)
+			}
+		}
+		entries = filtered
+	}
+
+	result := DeadLetterReplayResult{Failed: map[string]string{}}
+	// Replay in the original order.
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		d := entries[i]
+		ok, err := p.replayDeadLetter(ctx, d)
+		if err == nil && !ok {
+			err = fmt.Errorf("no rule for channel %s", d.Channel)
+		}
+		if err != nil {
+			result.Failed[d.ID] = err.Error()
+			continue
+		}
+		result.Replayed = append(result.Replayed, d.ID)
+	}
+	if len(result.Replayed) > 0 {
+		q.Remove(orgID, result.Replayed...)
+	}
+	return result, nil
+}
+
+func (p *Pipeline) replayDeadLetter(ctx context.Context, d DeadLetter) (bool, error) {
+	if d.MessageID != "" {
+		ctx = WithMessageID(ctx, d.MessageID)
+	}
+	if d.Frame == nil {
+		return p.ProcessInput(ctx, d.OrgID, d.Channel, d.Data)
+	}
+	var frame data.Frame
+	if err := json.Unmarshal(d.Frame, &frame); err != nil {
+		return false, fmt.Errorf("invalid dead letter frame: %w", err)
+	}
+	return p.ProcessFrames(ctx, d.OrgID, d.Channel, []*data.Frame{&frame})
+}
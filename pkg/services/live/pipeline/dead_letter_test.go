@@ -0,0 +1,156 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterQueue_compaction(t *testing.T) {
+	q := NewDeadLetterQueue(DeadLetterQueueConfig{})
+	now := time.Now()
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Stage: DeadLetterStageConvert, Error: "boom", Data: []byte("1")}, now)
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Stage: DeadLetterStageConvert, Error: "boom", Data: []byte("1")}, now.Add(time.Second))
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Stage: DeadLetterStageConvert, Error: "boom", Data: []byte("2")}, now.Add(2*time.Second))
+	q.add(&DeadLetter{OrgID: 2, Channel: "stream/test/cpu", Stage: DeadLetterStageConvert, Error: "boom", Data: []byte("1")}, now)
+
+	entries := q.List(1)
+	require.Len(t, entries, 2)
+	require.Equal(t, []byte("2"), entries[0].Data)
+	require.Equal(t, int64(1), entries[0].Count)
+	require.Equal(t, []byte("1"), entries[1].Data)
+	require.Equal(t, int64(2), entries[1].Count)
+	require.Equal(t, now, entries[1].FirstSeen)
+	require.Equal(t, now.Add(time.Second), entries[1].LastSeen)
+	require.Len(t, q.List(2), 1)
+}
+
+func TestDeadLetterQueue_retention(t *testing.T) {
+	now := time.Now()
+
+	q := NewDeadLetterQueue(DeadLetterQueueConfig{MaxEntries: 2})
+	for _, payload := range []string{"1", "2", "3"} {
+		q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Error: "boom", Data: []byte(payload)}, now)
+	}
+	entries := q.List(1)
+	require.Len(t, entries, 2)
+	require.Equal(t, []byte("3"), entries[0].Data)
+	require.Equal(t, []byte("2"), entries[1].Data)
+
+	q = NewDeadLetterQueue(DeadLetterQueueConfig{MaxBytes: 5})
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Error: "boom", Data: []byte("1234")}, now)
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Error: "boom", Data: []byte("56")}, now)
+	entries = q.List(1)
+	require.Len(t, entries, 1)
+	require.Equal(t, []byte("56"), entries[0].Data)
+
+	q = NewDeadLetterQueue(DeadLetterQueueConfig{MaxAge: time.Minute})
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Error: "boom", Data: []byte("1")}, now)
+	q.add(&DeadLetter{OrgID: 1, Channel: "stream/test/cpu", Error: "boom", Data: []byte("2")}, now.Add(30*time.Second))
+	q.Compact(now.Add(75 * time.Second))
+	entries = q.List(1)
+	require.Len(t, entries, 1)
+	require.Equal(t, []byte("2"), entries[0].Data)
+}
+
+func TestPipeline_ReplayDeadLetters(t *testing.T) {
+	q := NewDeadLetterQueue(DeadLetterQueueConfig{})
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	q.AddData(1, "stream/test/cpu", "", DeadLetterStageConvert, errors.New("boom"), []byte(`{}`))
+	q.AddFrame(1, "stream/test/mem", "", DeadLetterStageOutput, errors.New("boom"), frame)
+	q.AddFrame(1, "stream/test/unknown", "", DeadLetterStageOutput, errors.New("boom"), frame)
+
+	outputter := &testOutputter{}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/cpu": {
+				Converter:       &testConverter{frame: frame},
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+			"stream/test/mem": {
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	result, err := p.ReplayDeadLetters(context.Background(), q, 1, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "2"}, result.Replayed)
+	require.Contains(t, result.Failed, "3")
+
+	entries := q.List(1)
+	require.Len(t, entries, 1)
+	require.Equal(t, "3", entries[0].ID)
+}
+
+type testErrorConverter struct{}
+
+func (t *testErrorConverter) Type() string {
+	return "test"
+}
+
+func (t *testErrorConverter) Convert(_ context.Context, _ Vars, _ []byte) ([]*ChannelFrame, error) {
+	return nil, errors.New("invalid payload")
+}
+
+type testErrorProcessor struct{}
+
+func (t *testErrorProcessor) Type() string {
+	return "test"
+}
+
+func (t *testErrorProcessor) ProcessFrame(_ context.Context, _ Vars, _ *data.Frame) (*data.Frame, error) {
+	return nil, errors.New("processing failed")
+}
+
+func TestPipeline_deadLetterOutput(t *testing.T) {
+	frame := data.NewFrame("test", data.NewField("value", data.Labels{"host": "a"}, []float64{1}))
+	deadLetters := &testOutputter{}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/convert": {
+				Converter:           &testErrorConverter{},
+				DeadLetterOutputter: deadLetters,
+			},
+			"stream/test/process": {
+				Converter:           &testConverter{frame: frame},
+				FrameProcessors:     []FrameProcessor{&testErrorProcessor{}},
+				DeadLetterOutputter: deadLetters,
+			},
+			"stream/test/output": {
+				Converter:           &testConverter{frame: frame},
+				FrameOutputters:     []FrameOutputter{&testOutputter{err: errors.New("backend down")}},
+				DeadLetterOutputter: deadLetters,
+			},
+		},
+	})
+	require.NoError(t, err)
+	p.DeadLetters = NewDeadLetterQueue(DeadLetterQueueConfig{})
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/convert", []byte(`{"value":`))
+	require.Error(t, err)
+	require.Equal(t, data.Labels{"stage": DeadLetterStageConvert, "error": "invalid payload"}, deadLetters.frame.Fields[1].Labels)
+	require.Equal(t, `{"value":`, deadLetters.frame.Fields[1].At(0))
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/process", []byte(`{}`))
+	require.Error(t, err)
+	require.Equal(t, data.Labels{"host": "a", "stage": DeadLetterStageProcess, "error": "processing failed"}, deadLetters.frame.Fields[0].Labels)
+	require.Equal(t, 1.0, deadLetters.frame.Fields[0].At(0))
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/output", []byte(`{}`))
+	require.Error(t, err)
+	require.Equal(t, data.Labels{"host": "a", "stage": DeadLetterStageOutput, "error": "backend down"}, deadLetters.frame.Fields[0].Labels)
+	// Original frame is not modified.
+	require.Equal(t, data.Labels{"host": "a"}, frame.Fields[0].Labels)
+
+	entries := p.DeadLetters.List(1)
+	require.Len(t, entries, 3)
+	require.Equal(t, DeadLetterStageOutput, entries[0].Stage)
+	require.Equal(t, DeadLetterStageConvert, entries[2].Stage)
+	require.Equal(t, []byte(`{"value":`), entries[2].Data)
+}
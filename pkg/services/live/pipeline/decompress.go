@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultMaxDecompressedSize limits a size of a decompressed payload when
+// Pipeline.MaxDecompressedSize is not set.
+const defaultMaxDecompressedSize = 16 << 20
+
+// ErrDecompressedPayloadTooLarge is returned for compressed payloads which expand
+// over the size limit, protects from decompression bombs.
+var ErrDecompressedPayloadTooLarge = errors.New("decompressed payload is too large")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decompressPayload detects gzip, zlib (HTTP deflate) and zstd compressed payloads
+// by their header and decompresses them, other payloads are returned as is. Payloads
+// which only look like zlib but can't be inflated are returned as is too.
+func decompressPayload(body []byte, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxDecompressedSize
+	}
+	var (
+		r        io.Reader
+		encoding string
+	)
+	switch {
+	case bytes.HasPrefix(body, gzipMagic):
+		encoding = "gzip"
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", encoding, err)
+		}
+		defer func() { _ = gr.Close() }()
+		r = gr
+	case bytes.HasPrefix(body, zstdMagic):
+		encoding = "zstd"
+		zr, err := zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", encoding, err)
+		}
+		defer zr.Close()
+		r = zr
+	case isZlibHeader(body):
+		encoding = "deflate"
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return body, nil
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	default:
+		return body, nil
+	}
+	// Read one byte over the limit to tell a payload of exactly max size from a larger one.
+	decompressed, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+			return nil, ErrDecompressedPayloadTooLarge
+		}
+		if encoding == "deflate" {
+			// A short zlib header has no magic, so a text payload can look like one.
+			return body, nil
+		}
+		return nil, fmt.Errorf("invalid %s payload: %w", encoding, err)
+	}
+	if int64(len(decompressed)) > maxSize {
+		return nil, ErrDecompressedPayloadTooLarge
+	}
+	return decompressed, nil
+}
+
+// isZlibHeader reports whether a payload starts with a zlib header: deflate method
+// with a valid window size, no preset dictionary and a valid header checksum.
+func isZlibHeader(body []byte) bool {
+	if len(body) < 2 {
+		return false
+	}
+	cmf, flg := body[0], body[1]
+	return cmf&0x0f == 8 && cmf>>4 <= 7 && flg&0x20 == 0 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
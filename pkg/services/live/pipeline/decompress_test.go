@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompressPayload(t *testing.T) {
+	payload := []byte(`{"value":1}`)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(payload)
+	require.NoError(t, gw.Close())
+
+	var zl bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	_, _ = zw.Write(payload)
+	require.NoError(t, zw.Close())
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	zst := enc.EncodeAll(payload, nil)
+
+	for name, body := range map[string][]byte{"gzip": gz.Bytes(), "deflate": zl.Bytes(), "zstd": zst, "plain": payload} {
+		t.Run(name, func(t *testing.T) {
+			decompressed, err := decompressPayload(body, 0)
+			require.NoError(t, err)
+			require.Equal(t, payload, decompressed)
+		})
+	}
+
+	// Text which looks like a zlib header is passed as is.
+	text := []byte("x^ some text")
+	require.True(t, isZlibHeader(text))
+	decompressed, err := decompressPayload(text, 0)
+	require.NoError(t, err)
+	require.Equal(t, text, decompressed)
+
+	_, err = decompressPayload(gz.Bytes()[:len(gz.Bytes())-4], 0)
+	require.ErrorContains(t, err, "invalid gzip payload")
+}
+
+func TestDecompressPayload_SizeLimit(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 1<<20)
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(payload)
+	require.NoError(t, gw.Close())
+
+	_, err := decompressPayload(gz.Bytes(), 1024)
+	require.ErrorIs(t, err, ErrDecompressedPayloadTooLarge)
+	decompressed, err := decompressPayload(gz.Bytes(), 1<<20)
+	require.NoError(t, err)
+	require.Len(t, decompressed, 1<<20)
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	_, err = decompressPayload(enc.EncodeAll(payload, nil), 1024)
+	require.ErrorIs(t, err, ErrDecompressedPayloadTooLarge)
+}
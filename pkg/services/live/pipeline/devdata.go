@@ -97,6 +97,12 @@ type DevRuleBuilder struct {
 }
 
 func (f *DevRuleBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	spikesRedirect, err := NewRedirectFrameOutput(RedirectOutputConfig{
+		Channel: "stream/influx/input/cpu/spikes",
+	})
+	if err != nil {
+		return nil, err
+	}
 	return []*LiveChannelRule{
 		{
 			Pattern: "plugin/testdata/random-20Hz-stream:rest",
@@ -167,9 +173,7 @@ func (f *DevRuleBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelR
 				NewManagedStreamFrameOutput(f.ManagedStream),
 				NewConditionalOutput(
 					NewFrameNumberCompareCondition("usage_user", "gte", 50),
-					NewRedirectFrameOutput(RedirectOutputConfig{
-						Channel: "stream/influx/input/cpu/spikes",
-					}),
+					spikesRedirect,
 				),
 			},
 		},
@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+)
+
+// DryRunResult shows how a rule handles a payload: frames produced by the rule
+// converter, frames after rule processors and outputs which would be executed.
+type DryRunResult struct {
+	Pattern string `json:"pattern"`
+	// ConvertedFrames are frames produced by the converter, including frames
+	// the converter sends to other channels.
+	ConvertedFrames []*ChannelFrame `json:"convertedFrames"`
+	// ProcessedFrames are frames of the rule channel after frame processors.
+	ProcessedFrames []*DryRunFrame `json:"processedFrames"`
+	// Dropped is a number of frames dropped by frame processors.
+	Dropped int `json:"dropped"`
+}
+
+// DryRunFrame is a processed frame with outputs which would be executed for it.
+type DryRunFrame struct {
+	Channel string         `json:"channel"`
+	Frame   *data.Frame    `json:"frame"`
+	Outputs []DryRunOutput `json:"outputs"`
+}
+
+// DryRunOutput tells whether an output would be executed for a frame. Outputs nested in
+// multiple, conditional, processed, retry, failure alert and rollout outputs are listed after their
+// parent, Path is a dot separated list of output indexes.
+type DryRunOutput struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Fire   bool   `json:"fire"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DryRun converts a payload with a rule converter, runs frames for the rule channel
+// through rule frame processors and evaluates output conditions. Outputs are never
+// executed.
+func (p *Pipeline) DryRun(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, body []byte) (*DryRunResult, error) {
+	if rule.Converter == nil {
+		return nil, errors.New("rule has no converter")
+	}
+	channelFrames, err := p.DataToChannelFrames(ctx, *rule, orgID, channelID, body)
+	if err != nil {
+		return nil, fmt.Errorf("converter %s: %w", rule.Converter.Type(), err)
+	}
+	ch, err := live.ParseChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	vars := Vars{
+		OrgID:     orgID,
+		Channel:   channelID,
+		Scope:     ch.Scope,
+		Namespace: ch.Namespace,
+		Path:      ch.Path,
+		Params:    rule.ChannelParams(channelID),
+	}
+
+	result := &DryRunResult{
+		Pattern:         rule.Pattern,
+		ConvertedFrames: make([]*ChannelFrame, 0, len(channelFrames)),
+		ProcessedFrames: []*DryRunFrame{},
+	}
+	for _, channelFrame := range channelFrames {
+		// Frames are processed in place, converted frames are kept as copies.
+		result.ConvertedFrames = append(result.ConvertedFrames, &ChannelFrame{
+			Channel: channelFrame.Channel,
+			Frame:   copyFrame(channelFrame.Frame),
+		})
+		if channelFrame.Channel != "" && channelFrame.Channel != channelID {
+			continue
+		}
+		frame, err := p.runRuleProcessors(ctx, rule, vars, channelFrame.Frame)
+		if err != nil {
+			return nil, err
+		}
+		if frame == nil {
+			result.Dropped++
+			continue
+		}
+		result.ProcessedFrames = append(result.ProcessedFrames, &DryRunFrame{
+			Channel: channelID,
+			Frame:   frame,
+			Outputs: p.dryRunOutputs(ctx, vars, frame, rule.FrameOutputters, "", true, ""),
+		})
+	}
+	return result, nil
+}
+
+// runRuleProcessors runs a frame through rule frame processors, nil frame is returned
+// when a processor drops the frame.
+func (p *Pipeline) runRuleProcessors(ctx context.Context, rule *LiveChannelRule, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	var err error
+	for _, proc := range rule.FrameProcessors {
+		frame, err = p.execProcessor(ctx, proc, vars, frame)
+		if err != nil {
+			return nil, fmt.Errorf("processor %s: %w", proc.Type(), err)
+		}
+		if frame == nil {
+			return nil, nil
+		}
+	}
+	return frame, nil
+}
+
+// dryRunOutputs walks outputs evaluating conditions and processors of wrapping outputs.
+// Outputs under a parent which would not fire are listed with the parent reason.
+func (p *Pipeline) dryRunOutputs(ctx context.Context, vars Vars, frame *data.Frame, outputs []FrameOutputter, prefix string, fire bool, reason string) []DryRunOutput {
+	var result []DryRunOutput
+	for i, out := range outputs {
+		if out == nil {
+			continue
+		}
+		path := prefix + strconv.Itoa(i)
+		entry := DryRunOutput{Path: path, Type: out.Type(), Fire: fire, Reason: reason}
+		nestedFire, nestedReason := fire, reason
+		nestedFrame := frame
+		var nested []FrameOutputter
+
+		switch o := out.(type) {
+		case *MultipleFrameOutput:
+			nested = o.Outputters
+		case *ConditionalOutput:
+			nested = []FrameOutputter{o.Outputter}
+			if fire {
+				ok, err := o.Condition.CheckFrameCondition(withConditionVars(ctx, vars), frame)
+				switch {
+				case err != nil:
+					entry.Fire, entry.Reason = false, "condition error: "+err.Error()
+				case !ok:
+					entry.Fire, entry.Reason = false, "condition "+o.Condition.Type()+" not met"
+				default:
+					entry.Reason = "condition " + o.Condition.Type() + " met"
+				}
+				nestedFire, nestedReason = entry.Fire, entry.Reason
+			}
+		case *ProcessedOutput:
+			nested = []FrameOutputter{o.Outputter}
+			if fire {
+				processed := copyFrame(frame)
+				for _, proc := range o.Processors {
+					var err error
+					processed, err = proc.ProcessFrame(ctx, vars, processed)
+					if err != nil {
+						entry.Fire, entry.Reason = false, "processor "+proc.Type()+" error: "+err.Error()
+						break
+					}
+					if processed == nil {
+						entry.Fire, entry.Reason = false, "frame dropped by processor "+proc.Type()
+						break
+					}
+				}
+				nestedFrame = processed
+				nestedFire, nestedReason = entry.Fire, entry.Reason
+			}
+		case *RetryOutput:
+			nested = []FrameOutputter{o.Outputter}
+		case *FailureAlertOutput:
+			nested = []FrameOutputter{o.Outputter}
+		case *RolloutOutput:
+			// Output is listed at index 0 and fallback at index 1, only one of them fires.
+			included := o.Rollout.Includes(vars.OrgID, vars.Channel)
+			newFire, newReason, oldFire, oldReason := fire, reason, fire, reason
+			if fire {
+				entry.Reason = "channel not in rollout " + o.Rollout.Name
+				if included {
+					entry.Reason = "channel in rollout " + o.Rollout.Name
+				}
+				newFire, newReason = included, entry.Reason
+				oldFire, oldReason = !included, entry.Reason
+			}
+			result = append(result, entry)
+			result = append(result, p.dryRunOutputs(ctx, vars, frame, []FrameOutputter{o.Outputter}, path+".", newFire, newReason)...)
+			if o.Fallback != nil {
+				result = append(result, p.dryRunOutputs(ctx, vars, frame, []FrameOutputter{nil, o.Fallback}, path+".", oldFire, oldReason)...)
+			}
+			continue
+		case *RedirectFrameOutput:
+			if fire {
+				entry.Reason = "redirects to " + o.targetChannel(vars, frame)
+			}
+		}
+
+		result = append(result, entry)
+		result = append(result, p.dryRunOutputs(ctx, vars, nestedFrame, nested, path+".", nestedFire, nestedReason)...)
+	}
+	return result
+}
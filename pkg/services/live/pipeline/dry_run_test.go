@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_DryRun(t *testing.T) {
+	fired := &testOutputter{}
+	notFired := &testOutputter{}
+	rule := &LiveChannelRule{
+		Pattern:         "stream/test/json",
+		Converter:       NewAutoJsonConverter(AutoJsonConverterConfig{}),
+		FrameProcessors: []FrameProcessor{NewDropFieldsFrameProcessor(DropFieldsFrameProcessorConfig{FieldNames: []string{"debug"}})},
+		FrameOutputters: []FrameOutputter{
+			NewMultipleFrameOutput(
+				NewConditionalOutput(NewFrameNumberCompareCondition("value", NumberCompareOpGt, 10), notFired),
+				NewConditionalOutput(NewFrameNumberCompareCondition("value", NumberCompareOpGt, 0), fired),
+			),
+			newTestRedirectFrameOutput(t, "stream/test/other"),
+		},
+	}
+	p, err := New(&testRuleGetter{rules: map[string]*LiveChannelRule{"stream/test/json": rule}})
+	require.NoError(t, err)
+
+	result, err := p.DryRun(context.Background(), rule, 1, "stream/test/json", []byte(`{"value": 1, "debug": "x"}`))
+	require.NoError(t, err)
+	require.Equal(t, "stream/test/json", result.Pattern)
+	require.Len(t, result.ConvertedFrames, 1)
+	require.Len(t, result.ConvertedFrames[0].Frame.Fields, 3)
+	require.Len(t, result.ProcessedFrames, 1)
+	require.Len(t, result.ProcessedFrames[0].Frame.Fields, 2)
+	require.Equal(t, []DryRunOutput{
+		{Path: "0", Type: FrameOutputTypeMultiple, Fire: true},
+		{Path: "0.0", Type: FrameOutputTypeConditional, Fire: false, Reason: "condition numberCompare not met"},
+		{Path: "0.0.0", Type: "test", Fire: false, Reason: "condition numberCompare not met"},
+		{Path: "0.1", Type: FrameOutputTypeConditional, Fire: true, Reason: "condition numberCompare met"},
+		{Path: "0.1.0", Type: "test", Fire: true, Reason: "condition numberCompare met"},
+		{Path: "1", Type: FrameOutputTypeRedirect, Fire: true, Reason: "redirects to stream/test/other"},
+	}, result.ProcessedFrames[0].Outputs)
+
+	// Outputs are never executed.
+	require.Nil(t, fired.frame)
+	require.Nil(t, notFired.frame)
+
+	_, err = p.DryRun(context.Background(), rule, 1, "stream/test/json", []byte(`not json`))
+	require.Error(t, err)
+}
@@ -11,3 +11,16 @@ type FrameConditionChecker interface {
 	Type() string
 	CheckFrameCondition(ctx context.Context, frame *data.Frame) (bool, error)
 }
+
+type conditionVarsKey struct{}
+
+// withConditionVars passes vars of a processed frame to condition checkers which keep
+// per channel state.
+func withConditionVars(ctx context.Context, vars Vars) context.Context {
+	return context.WithValue(ctx, conditionVarsKey{}, vars)
+}
+
+func conditionVarsFromContext(ctx context.Context) (Vars, bool) {
+	vars, ok := ctx.Value(conditionVarsKey{}).(Vars)
+	return vars, ok
+}
@@ -7,6 +7,7 @@ import (
 )
 
 // MultipleFrameConditionChecker can check multiple conditions according to ConditionType.
+// Conditions can be multiple checkers too, so any, all and none groups can be nested.
 type MultipleFrameConditionChecker struct {
 	ConditionType ConditionType
 	Conditions    []FrameConditionChecker
@@ -27,6 +28,9 @@ func (c *MultipleFrameConditionChecker) CheckFrameCondition(ctx context.Context,
 		if ok && c.ConditionType == ConditionAny {
 			return true, nil
 		}
+		if ok && c.ConditionType == ConditionNone {
+			return false, nil
+		}
 		if !ok && c.ConditionType == ConditionAll {
 			return false, nil
 		}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipleFrameConditionChecker(t *testing.T) {
+	value := 5.0
+	frame := data.NewFrame("test",
+		data.NewField("value", nil, []*float64{&value}),
+		data.NewField("status", nil, []string{"maintenance"}),
+	)
+	gt3 := NewFrameNumberCompareCondition("value", NumberCompareOpGt, 3)
+	gt10 := NewFrameNumberCompareCondition("value", NumberCompareOpGt, 10)
+	maintenance, err := NewFrameRegexMatchCondition("status", "^maintenance$")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		condition FrameConditionChecker
+		expected  bool
+	}{
+		{name: "all", condition: NewMultipleFrameConditionChecker(ConditionAll, gt3, gt10), expected: false},
+		{name: "any", condition: NewMultipleFrameConditionChecker(ConditionAny, gt3, gt10), expected: true},
+		{name: "not", condition: NewMultipleFrameConditionChecker(ConditionNone, gt10), expected: true},
+		{name: "none", condition: NewMultipleFrameConditionChecker(ConditionNone, gt10, gt3), expected: false},
+		{
+			// value > 3 AND NOT (value > 10 OR status is maintenance)
+			name: "nested",
+			condition: NewMultipleFrameConditionChecker(ConditionAll,
+				gt3,
+				NewMultipleFrameConditionChecker(ConditionNone,
+					NewMultipleFrameConditionChecker(ConditionAny, gt10, maintenance),
+				),
+			),
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := tc.condition.CheckFrameCondition(context.Background(), frame)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ok)
+		})
+	}
+}
+
+func TestStorageRuleBuilder_nestedConditions(t *testing.T) {
+	f := &StorageRuleBuilder{}
+	c, err := f.extractFrameConditionChecker(&FrameConditionCheckerConfig{
+		Type: FrameConditionCheckerTypeMultiple,
+		MultipleConditionCheckerConfig: &MultipleFrameConditionCheckerConfig{
+			ConditionType: ConditionNone,
+			Conditions: []FrameConditionCheckerConfig{{
+				Type: FrameConditionCheckerTypeMultiple,
+				MultipleConditionCheckerConfig: &MultipleFrameConditionCheckerConfig{
+					ConditionType: ConditionAny,
+					Conditions: []FrameConditionCheckerConfig{{
+						Type:                         FrameConditionCheckerTypeNumberCompare,
+						NumberCompareConditionConfig: &NumberCompareFrameConditionConfig{FieldName: "value", Op: NumberCompareOpGt, Value: 10},
+					}},
+				},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	value := 5.0
+	ok, err := c.CheckFrameCondition(context.Background(), data.NewFrame("test", data.NewField("value", nil, []*float64{&value})))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = f.extractFrameConditionChecker(&FrameConditionCheckerConfig{
+		Type:                           FrameConditionCheckerTypeMultiple,
+		MultipleConditionCheckerConfig: &MultipleFrameConditionCheckerConfig{ConditionType: "xor"},
+	})
+	require.ErrorContains(t, err, "unknown condition type")
+}
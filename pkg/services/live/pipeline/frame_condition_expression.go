@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// FrameExpressionCondition evaluates a boolean CEL expression against the first row of
+// a frame. Besides `row`, `labels` and `vars` variables expressions can use `value(name)`
+// returning a field value or null when a frame has no such field, and `label(name)`
+// returning a label value of frame fields or an empty string.
+type FrameExpressionCondition struct {
+	Expression string
+	program    cel.Program
+	timeout    time.Duration
+}
+
+const FrameConditionCheckerTypeExpression = "expression"
+
+func NewFrameExpressionCondition(config ExpressionFrameConditionConfig) (*FrameExpressionCondition, error) {
+	if config.Language != "" && config.Language != ScriptLanguageCEL {
+		return nil, fmt.Errorf("unsupported expression language: %s", config.Language)
+	}
+	if config.Expression == "" {
+		return nil, errors.New("expression required")
+	}
+	env, err := cel.NewEnv(
+		cel.Variable("row", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("vars", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Macros(lookupMacro("value", "row"), lookupMacro("label", "labels")),
+		cel.Function("value",
+			cel.Overload("value_map_string", []*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType}, cel.DynType,
+				cel.BinaryBinding(func(m, key ref.Val) ref.Val { return mapLookup(m, key, types.NullValue) }))),
+		cel.Function("label",
+			cel.Overload("label_map_string", []*cel.Type{cel.MapType(cel.StringType, cel.StringType), cel.StringType}, cel.StringType,
+				cel.BinaryBinding(func(m, key ref.Val) ref.Val { return mapLookup(m, key, types.String("")) }))),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(config.Expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("error compiling expression: %w", iss.Err())
+	}
+	if t := ast.OutputType(); !t.IsAssignableType(cel.BoolType) && !t.IsAssignableType(cel.DynType) {
+		return nil, fmt.Errorf("expression must return bool, got %s", t)
+	}
+	costLimit := config.CostLimit
+	if costLimit == 0 {
+		costLimit = defaultScriptCostLimit
+	}
+	program, err := env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(100))
+	if err != nil {
+		return nil, err
+	}
+	return &FrameExpressionCondition{Expression: config.Expression, program: program, timeout: defaultScriptTimeout}, nil
+}
+
+// lookupMacro rewrites function(name) calls to function(variable, name), so expressions
+// don't pass row or labels explicitly.
+func lookupMacro(function string, variable string) cel.Macro {
+	return cel.NewGlobalMacro(function, 1, func(eh cel.MacroExprHelper, _ *exprpb.Expr, args []*exprpb.Expr) (*exprpb.Expr, *common.Error) {
+		return eh.GlobalCall(function, eh.Ident(variable), args[0]), nil
+	})
+}
+
+func mapLookup(m ref.Val, key ref.Val, missing ref.Val) ref.Val {
+	mapper, ok := m.(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(m)
+	}
+	if v, found := mapper.Find(key); found {
+		return v
+	}
+	return missing
+}
+
+func (c *FrameExpressionCondition) Type() string {
+	return FrameConditionCheckerTypeExpression
+}
+
+func (c *FrameExpressionCondition) CheckFrameCondition(ctx context.Context, frame *data.Frame) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	row := make(map[string]any, len(frame.Fields))
+	labels := map[string]string{}
+	for _, f := range frame.Fields {
+		if f.Len() > 0 {
+			v, _ := f.ConcreteAt(0)
+			row[f.Name] = v
+		}
+		for k, v := range f.Labels {
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+			}
+		}
+	}
+	scriptVars := map[string]any{}
+	if vars, ok := conditionVarsFromContext(ctx); ok {
+		scriptVars = map[string]any{
+			"orgId":     vars.OrgID,
+			"channel":   vars.Channel,
+			"scope":     vars.Scope,
+			"namespace": vars.Namespace,
+			"path":      vars.Path,
+		}
+	}
+	out, err := evalScript(ctx, c.program, map[string]any{"row": row, "labels": labels, "vars": scriptVars})
+	if err != nil {
+		return false, fmt.Errorf("error evaluating expression: %w", err)
+	}
+	ok, isBool := out.(bool)
+	if !isBool {
+		return false, fmt.Errorf("expression must return bool, got %T", out)
+	}
+	return ok, nil
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameExpressionCondition(t *testing.T) {
+	c, err := NewFrameExpressionCondition(ExpressionFrameConditionConfig{
+		Expression: `value("temp") > 80.0 && label("site") == "berlin"`,
+	})
+	require.NoError(t, err)
+
+	frame := func(temp float64, site string) *data.Frame {
+		return data.NewFrame("test",
+			data.NewField("time", nil, []float64{1}),
+			data.NewField("temp", data.Labels{"site": site}, []float64{temp}),
+		)
+	}
+	ok, err := c.CheckFrameCondition(context.Background(), frame(85, "berlin"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = c.CheckFrameCondition(context.Background(), frame(85, "paris"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = c.CheckFrameCondition(context.Background(), frame(70, "berlin"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	c, err = NewFrameExpressionCondition(ExpressionFrameConditionConfig{
+		Expression: `value("missing") == null && label("missing") == "" && vars.channel.startsWith("stream/")`,
+	})
+	require.NoError(t, err)
+	ok, err = c.CheckFrameCondition(withConditionVars(context.Background(), Vars{Channel: "stream/test/1"}), frame(1, "berlin"))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestFrameExpressionCondition_validation(t *testing.T) {
+	_, err := NewFrameExpressionCondition(ExpressionFrameConditionConfig{Expression: `value("temp") >`})
+	require.ErrorContains(t, err, "error compiling expression")
+
+	_, err = NewFrameExpressionCondition(ExpressionFrameConditionConfig{Expression: `label("site")`})
+	require.ErrorContains(t, err, "expression must return bool")
+
+	_, err = NewFrameExpressionCondition(ExpressionFrameConditionConfig{Expression: `unknown("x")`})
+	require.Error(t, err)
+
+	_, err = NewFrameExpressionCondition(ExpressionFrameConditionConfig{Language: "lua", Expression: `true`})
+	require.Error(t, err)
+}
@@ -0,0 +1,26 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameFieldPresentCondition checks a frame has a field with a name.
+type FrameFieldPresentCondition struct {
+	FieldName string
+}
+
+const FrameConditionCheckerTypeFieldPresent = "fieldPresent"
+
+func (c *FrameFieldPresentCondition) Type() string {
+	return FrameConditionCheckerTypeFieldPresent
+}
+
+func (c *FrameFieldPresentCondition) CheckFrameCondition(_ context.Context, frame *data.Frame) (bool, error) {
+	return fieldIndex(frame, c.FieldName) >= 0, nil
+}
+
+func NewFrameFieldPresentCondition(fieldName string) *FrameFieldPresentCondition {
+	return &FrameFieldPresentCondition{FieldName: fieldName}
+}
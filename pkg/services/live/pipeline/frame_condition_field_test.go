@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameFieldPresentCondition(t *testing.T) {
+	frame := data.NewFrame("test", data.NewField("value", nil, []*float64{nil}))
+	ok, err := NewFrameFieldPresentCondition("value").CheckFrameCondition(context.Background(), frame)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = NewFrameFieldPresentCondition("missing").CheckFrameCondition(context.Background(), frame)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestFrameIsNullCondition(t *testing.T) {
+	v := 1.0
+	frame := data.NewFrame("test",
+		data.NewField("null", nil, []*float64{nil}),
+		data.NewField("nullable", nil, []*float64{&v}),
+		data.NewField("value", nil, []string{""}),
+	)
+	testCases := []struct {
+		field   string
+		notNull bool
+		want    bool
+	}{
+		{field: "null", want: true},
+		{field: "nullable", want: false},
+		{field: "value", want: false},
+		{field: "missing", want: true},
+		{field: "null", notNull: true, want: false},
+		{field: "nullable", notNull: true, want: true},
+		{field: "missing", notNull: true, want: false},
+	}
+	for _, tc := range testCases {
+		ok, err := NewFrameIsNullCondition(tc.field, tc.notNull).CheckFrameCondition(context.Background(), frame)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, ok, "%s notNull=%v", tc.field, tc.notNull)
+	}
+}
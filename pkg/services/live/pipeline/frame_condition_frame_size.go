@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameSizeMeasure is what FrameSizeCondition measures.
+type FrameSizeMeasure string
+
+// Known FrameSizeMeasure types.
+const (
+	FrameSizeMeasureRows  FrameSizeMeasure = "rows"
+	FrameSizeMeasureBytes FrameSizeMeasure = "bytes"
+)
+
+// FrameSizeCondition compares a number of frame rows or a frame size in bytes, so
+// oversized frames can be diverted away from browser subscribers. Size in bytes is a
+// length of frame JSON as published to subscribers.
+type FrameSizeCondition struct {
+	Measure FrameSizeMeasure
+	Op      NumberCompareOp
+	Value   float64
+}
+
+const FrameConditionCheckerTypeFrameSize = "frameSize"
+
+func (c *FrameSizeCondition) Type() string {
+	return FrameConditionCheckerTypeFrameSize
+}
+
+func (c *FrameSizeCondition) CheckFrameCondition(_ context.Context, frame *data.Frame) (bool, error) {
+	var size int
+	switch c.Measure {
+	case FrameSizeMeasureRows:
+		size = frame.Rows()
+	case FrameSizeMeasureBytes:
+		frameJSON, err := json.Marshal(frame)
+		if err != nil {
+			return false, err
+		}
+		size = len(frameJSON)
+	default:
+		return false, fmt.Errorf("unknown frame size measure: %s", c.Measure)
+	}
+	return c.Op.compare(float64(size), c.Value)
+}
+
+func NewFrameSizeCondition(measure FrameSizeMeasure, op NumberCompareOp, value float64) (*FrameSizeCondition, error) {
+	if measure == "" {
+		measure = FrameSizeMeasureRows
+	}
+	if measure != FrameSizeMeasureRows && measure != FrameSizeMeasureBytes {
+		return nil, fmt.Errorf("unknown frame size measure: %s", measure)
+	}
+	if _, err := op.compare(0, value); err != nil {
+		return nil, err
+	}
+	return &FrameSizeCondition{Measure: measure, Op: op, Value: value}, nil
+}
@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameSizeCondition(t *testing.T) {
+	frame := data.NewFrame("test",
+		data.NewField("value", nil, []float64{1, 2, 3}),
+	)
+
+	testCases := []struct {
+		name     string
+		measure  FrameSizeMeasure
+		op       NumberCompareOp
+		value    float64
+		expected bool
+	}{
+		{name: "rows by default", op: NumberCompareOpEq, value: 3, expected: true},
+		{name: "rows gt", measure: FrameSizeMeasureRows, op: NumberCompareOpGt, value: 3, expected: false},
+		{name: "bytes gt", measure: FrameSizeMeasureBytes, op: NumberCompareOpGt, value: 10, expected: true},
+		{name: "bytes lt", measure: FrameSizeMeasureBytes, op: NumberCompareOpLt, value: 10, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewFrameSizeCondition(tc.measure, tc.op, tc.value)
+			require.NoError(t, err)
+			ok, err := c.CheckFrameCondition(context.Background(), frame)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ok)
+		})
+	}
+}
+
+func TestNewFrameSizeCondition_invalid(t *testing.T) {
+	_, err := NewFrameSizeCondition("cells", NumberCompareOpGt, 1)
+	require.Error(t, err)
+	_, err = NewFrameSizeCondition(FrameSizeMeasureRows, "between", 1)
+	require.Error(t, err)
+}
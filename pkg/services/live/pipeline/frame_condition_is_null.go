@@ -0,0 +1,34 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameIsNullCondition checks a field value is null. A missing field or a field
+// without values is considered null.
+type FrameIsNullCondition struct {
+	FieldName string
+	// NotNull inverts the condition.
+	NotNull bool
+}
+
+const FrameConditionCheckerTypeIsNull = "isNull"
+
+func (c *FrameIsNullCondition) Type() string {
+	return FrameConditionCheckerTypeIsNull
+}
+
+func (c *FrameIsNullCondition) CheckFrameCondition(_ context.Context, frame *data.Frame) (bool, error) {
+	isNull := true
+	if i := fieldIndex(frame, c.FieldName); i >= 0 && frame.Fields[i].Len() > 0 {
+		_, ok := frame.Fields[i].ConcreteAt(0)
+		isNull = !ok
+	}
+	return isNull != c.NotNull, nil
+}
+
+func NewFrameIsNullCondition(fieldName string, notNull bool) *FrameIsNullCondition {
+	return &FrameIsNullCondition{FieldName: fieldName, NotNull: notNull}
+}
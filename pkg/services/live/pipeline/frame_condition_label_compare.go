@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameLabelCompareCondition checks a label value of frame fields. Like Prometheus
+// matchers a missing label has an empty value. When FieldName is empty the condition
+// passes if any field matches.
+type FrameLabelCompareCondition struct {
+	FieldName string
+	Label     string
+	Op        LabelCompareOp
+	Value     string
+
+	pattern *regexp.Regexp
+}
+
+const FrameConditionCheckerTypeLabelCompare = "labelCompare"
+
+func (c *FrameLabelCompareCondition) Type() string {
+	return FrameConditionCheckerTypeLabelCompare
+}
+
+func (c *FrameLabelCompareCondition) CheckFrameCondition(_ context.Context, frame *data.Frame) (bool, error) {
+	for _, field := range frame.Fields {
+		if c.FieldName != "" && field.Name != c.FieldName {
+			continue
+		}
+		if c.matches(field.Labels[c.Label]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *FrameLabelCompareCondition) matches(value string) bool {
+	switch c.Op {
+	case LabelCompareOpEq:
+		return value == c.Value
+	case LabelCompareOpNe:
+		return value != c.Value
+	case LabelCompareOpRegex:
+		return c.pattern.MatchString(value)
+	case LabelCompareOpNotRegex:
+		return !c.pattern.MatchString(value)
+	default:
+		return false
+	}
+}
+
+func NewFrameLabelCompareCondition(fieldName string, label string, op LabelCompareOp, value string) (*FrameLabelCompareCondition, error) {
+	if label == "" {
+		return nil, errors.New("label name is required")
+	}
+	c := &FrameLabelCompareCondition{FieldName: fieldName, Label: label, Op: op, Value: value}
+	switch op {
+	case LabelCompareOpEq, LabelCompareOpNe:
+	case LabelCompareOpRegex, LabelCompareOpNotRegex:
+		// Anchored like Prometheus label matchers.
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		c.pattern = re
+	default:
+		return nil, fmt.Errorf("unknown label compare op: %s", op)
+	}
+	return c, nil
+}
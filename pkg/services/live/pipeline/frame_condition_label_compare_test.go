@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameLabelCompareCondition(t *testing.T) {
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []float64{1}),
+		data.NewField("value", data.Labels{"host": "web-1", "env": "prod"}, []float64{1}),
+	)
+
+	testCases := []struct {
+		name      string
+		fieldName string
+		label     string
+		op        LabelCompareOp
+		value     string
+		expected  bool
+	}{
+		{name: "eq", label: "env", op: LabelCompareOpEq, value: "prod", expected: true},
+		{name: "eq other field", fieldName: "time", label: "env", op: LabelCompareOpEq, value: "prod", expected: false},
+		{name: "eq missing label", fieldName: "value", label: "region", op: LabelCompareOpEq, value: "", expected: true},
+		{name: "ne", fieldName: "value", label: "env", op: LabelCompareOpNe, value: "prod", expected: false},
+		{name: "regex", fieldName: "value", label: "host", op: LabelCompareOpRegex, value: "web-.*", expected: true},
+		{name: "regex anchored", fieldName: "value", label: "host", op: LabelCompareOpRegex, value: "web", expected: false},
+		{name: "not regex", fieldName: "value", label: "host", op: LabelCompareOpNotRegex, value: "db-.*", expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewFrameLabelCompareCondition(tc.fieldName, tc.label, tc.op, tc.value)
+			require.NoError(t, err)
+			ok, err := c.CheckFrameCondition(context.Background(), frame)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ok)
+		})
+	}
+
+	_, err := NewFrameLabelCompareCondition("", "host", LabelCompareOpRegex, "(")
+	require.ErrorContains(t, err, "invalid regex pattern")
+	_, err = NewFrameLabelCompareCondition("", "host", "gt", "a")
+	require.ErrorContains(t, err, "unknown label compare op")
+	_, err = NewFrameLabelCompareCondition("", "", LabelCompareOpEq, "a")
+	require.Error(t, err)
+}
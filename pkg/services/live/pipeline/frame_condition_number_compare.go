@@ -31,22 +31,7 @@ func (c *FrameNumberCompareCondition) CheckFrameCondition(_ context.Context, fra
 			if value == nil {
 				return false, nil
 			}
-			switch c.Op {
-			case NumberCompareOpGt:
-				return *value > c.Value, nil
-			case NumberCompareOpGte:
-				return *value >= c.Value, nil
-			case NumberCompareOpLte:
-				return *value <= c.Value, nil
-			case NumberCompareOpLt:
-				return *value < c.Value, nil
-			case NumberCompareOpEq:
-				return *value == c.Value, nil
-			case NumberCompareOpNe:
-				return *value != c.Value, nil
-			default:
-				return false, fmt.Errorf("unknown comparison operator: %s", c.Op)
-			}
+			return c.Op.compare(*value, c.Value)
 		}
 	}
 	return false, nil
@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FrameRegexMatchCondition checks a string field value matches a regular expression.
+type FrameRegexMatchCondition struct {
+	FieldName string
+	Pattern   *regexp.Regexp
+}
+
+const FrameConditionCheckerTypeRegexMatch = "regexMatch"
+
+func (c *FrameRegexMatchCondition) Type() string {
+	return FrameConditionCheckerTypeRegexMatch
+}
+
+func (c *FrameRegexMatchCondition) CheckFrameCondition(_ context.Context, frame *data.Frame) (bool, error) {
+	for _, field := range frame.Fields {
+		if field.Name != c.FieldName || field.Len() == 0 {
+			continue
+		}
+		switch field.Type() {
+		case data.FieldTypeString:
+			return c.Pattern.MatchString(field.At(0).(string)), nil
+		case data.FieldTypeNullableString:
+			value := field.At(0).(*string)
+			if value == nil {
+				return false, nil
+			}
+			return c.Pattern.MatchString(*value), nil
+		default:
+			return false, fmt.Errorf("field %s is not a string field: %s", c.FieldName, field.Type())
+		}
+	}
+	return false, nil
+}
+
+func NewFrameRegexMatchCondition(fieldName string, pattern string) (*FrameRegexMatchCondition, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	return &FrameRegexMatchCondition{FieldName: fieldName, Pattern: re}, nil
+}
@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameRegexMatchCondition(t *testing.T) {
+	c, err := NewFrameRegexMatchCondition("message", `^(ERROR|FATAL)\b`)
+	require.NoError(t, err)
+
+	ok, err := c.CheckFrameCondition(context.Background(), data.NewFrame("log", data.NewField("message", nil, []string{"ERROR disk full"})))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = c.CheckFrameCondition(context.Background(), data.NewFrame("log", data.NewField("message", nil, []*string{stringPtr("INFO started")})))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = c.CheckFrameCondition(context.Background(), data.NewFrame("log", data.NewField("message", nil, []*string{nil})))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = c.CheckFrameCondition(context.Background(), data.NewFrame("log", data.NewField("message", nil, []float64{1})))
+	require.Error(t, err)
+
+	_, err = NewFrameRegexMatchCondition("message", "(")
+	require.ErrorContains(t, err, "invalid regex pattern")
+}
@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/robfig/cron/v3"
+)
+
+var scheduleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// FrameScheduleCondition passes frames processed inside configured time windows or
+// during minutes matching a cron expression. Frame content is not checked.
+type FrameScheduleCondition struct {
+	location *time.Location
+	windows  []scheduleWindow
+	cron     cron.Schedule
+	now      func() time.Time
+}
+
+type scheduleWindow struct {
+	// weekdays is a bit set of allowed days, bit N is time.Weekday N.
+	weekdays uint8
+	// from and to are minutes since midnight.
+	from, to int
+}
+
+const FrameConditionCheckerTypeSchedule = "schedule"
+
+func (c *FrameScheduleCondition) Type() string {
+	return FrameConditionCheckerTypeSchedule
+}
+
+func (c *FrameScheduleCondition) CheckFrameCondition(_ context.Context, _ *data.Frame) (bool, error) {
+	return c.matches(c.now().In(c.location)), nil
+}
+
+func (c *FrameScheduleCondition) matches(t time.Time) bool {
+	for _, w := range c.windows {
+		if w.matches(t) {
+			return true
+		}
+	}
+	if c.cron != nil {
+		minute := t.Truncate(time.Minute)
+		return c.cron.Next(minute.Add(-time.Second)).Equal(minute)
+	}
+	return false
+}
+
+func (w scheduleWindow) matches(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.from > w.to && minute < w.to {
+		// Early morning part of a window which started the day before.
+		day = (day + 6) % 7
+		return w.weekdays&(1<<day) != 0
+	}
+	if w.weekdays&(1<<day) == 0 {
+		return false
+	}
+	if w.from <= w.to {
+		return minute >= w.from && minute < w.to
+	}
+	return minute >= w.from
+}
+
+func NewFrameScheduleCondition(config ScheduleFrameConditionConfig) (*FrameScheduleCondition, error) {
+	if len(config.Windows) == 0 && config.Cron == "" {
+		return nil, errors.New("schedule condition requires windows or cron expression")
+	}
+	c := &FrameScheduleCondition{location: time.UTC, now: time.Now}
+	if config.Timezone != "" {
+		loc, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+		c.location = loc
+	}
+	for _, wc := range config.Windows {
+		w, err := parseScheduleWindow(wc)
+		if err != nil {
+			return nil, err
+		}
+		c.windows = append(c.windows, w)
+	}
+	if config.Cron != "" {
+		schedule, err := cron.ParseStandard(config.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression: %w", err)
+		}
+		c.cron = schedule
+	}
+	return c, nil
+}
+
+func parseScheduleWindow(config ScheduleWindow) (scheduleWindow, error) {
+	w := scheduleWindow{to: 24 * 60}
+	if len(config.Weekdays) == 0 {
+		w.weekdays = 0x7f
+	}
+	for _, spec := range config.Weekdays {
+		first, last, isRange := strings.Cut(strings.ToLower(spec), "-")
+		from, ok := scheduleWeekdays[first]
+		if !ok {
+			return w, fmt.Errorf("invalid weekday: %s", spec)
+		}
+		to := from
+		if isRange {
+			if to, ok = scheduleWeekdays[last]; !ok {
+				return w, fmt.Errorf("invalid weekday: %s", spec)
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			w.weekdays |= 1 << d
+			if d == to {
+				break
+			}
+		}
+	}
+	var err error
+	if config.From != "" {
+		if w.from, err = parseTimeOfDay(config.From); err != nil {
+			return w, err
+		}
+	}
+	if config.To != "" {
+		if w.to, err = parseTimeOfDay(config.To); err != nil {
+			return w, err
+		}
+	}
+	if w.from == w.to {
+		return w, fmt.Errorf("empty time window %s-%s", config.From, config.To)
+	}
+	return w, nil
+}
+
+// parseTimeOfDay returns minutes since midnight of a "15:04" time, "24:00" is allowed
+// as the end of a day.
+func parseTimeOfDay(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameScheduleCondition_Windows(t *testing.T) {
+	c, err := NewFrameScheduleCondition(ScheduleFrameConditionConfig{
+		Timezone: "Europe/Paris",
+		Windows: []ScheduleWindow{
+			{Weekdays: []string{"mon-fri"}, From: "09:00", To: "17:30"},
+			{Weekdays: []string{"sat"}, From: "22:00", To: "02:00"},
+		},
+	})
+	require.NoError(t, err)
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		time string
+		want bool
+	}{
+		{time: "2023-06-05 09:00", want: true},  // Monday.
+		{time: "2023-06-05 08:59", want: false}, // Monday.
+		{time: "2023-06-09 17:29", want: true},  // Friday.
+		{time: "2023-06-09 17:30", want: false}, // Friday.
+		{time: "2023-06-10 12:00", want: false}, // Saturday.
+		{time: "2023-06-10 23:00", want: true},  // Saturday.
+		{time: "2023-06-11 01:59", want: true},  // Sunday, window started on Saturday.
+		{time: "2023-06-11 02:00", want: false}, // Sunday.
+		{time: "2023-06-12 01:00", want: false}, // Monday, no window on Sunday night.
+	}
+	for _, tc := range testCases {
+		now, err := time.ParseInLocation("2006-01-02 15:04", tc.time, paris)
+		require.NoError(t, err)
+		// Evaluated in the configured timezone regardless of time location.
+		c.now = func() time.Time { return now.UTC() }
+		ok, err := c.CheckFrameCondition(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, ok, tc.time)
+	}
+}
+
+func TestFrameScheduleCondition_Cron(t *testing.T) {
+	c, err := NewFrameScheduleCondition(ScheduleFrameConditionConfig{Cron: "*/15 9-17 * * 1-5"})
+	require.NoError(t, err)
+	now := time.Date(2023, 6, 5, 9, 15, 30, 0, time.UTC)
+	require.True(t, c.matches(now))
+	require.False(t, c.matches(now.Add(time.Minute)))
+	require.False(t, c.matches(now.AddDate(0, 0, 5)))
+}
+
+func TestNewFrameScheduleCondition_Invalid(t *testing.T) {
+	_, err := NewFrameScheduleCondition(ScheduleFrameConditionConfig{})
+	require.Error(t, err)
+	_, err = NewFrameScheduleCondition(ScheduleFrameConditionConfig{Timezone: "Mars/Olympus", Cron: "@hourly"})
+	require.ErrorContains(t, err, "invalid timezone")
+	_, err = NewFrameScheduleCondition(ScheduleFrameConditionConfig{Windows: []ScheduleWindow{{Weekdays: []string{"funday"}}}})
+	require.ErrorContains(t, err, "invalid weekday")
+	_, err = NewFrameScheduleCondition(ScheduleFrameConditionConfig{Windows: []ScheduleWindow{{From: "9am"}}})
+	require.ErrorContains(t, err, "invalid time of day")
+	_, err = NewFrameScheduleCondition(ScheduleFrameConditionConfig{Cron: "* *"})
+	require.ErrorContains(t, err, "invalid cron expression")
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// valueChangedStateKey is appended to a channel to keep a last frame checked by a
+// value changed condition in frame storage.
+const valueChangedStateKey = "#valueChanged:"
+
+// FrameValueChangedCondition passes frames with a field value different from the last
+// value in a previous frame of the same channel or from a value in a previous row, so
+// outputs can be called on change only. The first frame of a channel is a change.
+// Previous frames are kept in FrameStorage.
+type FrameValueChangedCondition struct {
+	frameStorage FrameGetSetter
+	FieldName    string
+}
+
+func NewFrameValueChangedCondition(frameStorage FrameGetSetter, fieldName string) *FrameValueChangedCondition {
+	return &FrameValueChangedCondition{frameStorage: frameStorage, FieldName: fieldName}
+}
+
+const FrameConditionCheckerTypeValueChanged = "valueChanged"
+
+func (c *FrameValueChangedCondition) Type() string {
+	return FrameConditionCheckerTypeValueChanged
+}
+
+func (c *FrameValueChangedCondition) CheckFrameCondition(ctx context.Context, frame *data.Frame) (bool, error) {
+	vars, ok := conditionVarsFromContext(ctx)
+	if !ok {
+		return false, errors.New("valueChanged condition can only be used in conditional output")
+	}
+	idx := fieldIndex(frame, c.FieldName)
+	if idx < 0 || frame.Fields[idx].Len() == 0 {
+		return false, nil
+	}
+	key := vars.Channel + valueChangedStateKey + c.FieldName
+	previous, ok, err := c.frameStorage.Get(vars.OrgID, key)
+	if err != nil {
+		return false, err
+	}
+	if err := c.frameStorage.Set(vars.OrgID, key, frame); err != nil {
+		return false, err
+	}
+	if !ok {
+		return true, nil
+	}
+	previousIdx := fieldIndex(previous, c.FieldName)
+	if previousIdx < 0 || previous.Fields[previousIdx].Len() == 0 {
+		return true, nil
+	}
+	previousField := previous.Fields[previousIdx]
+	last := previousField.At(previousField.Len() - 1)
+	field := frame.Fields[idx]
+	for i := 0; i < field.Len(); i++ {
+		value := field.At(i)
+		if !reflect.DeepEqual(last, value) {
+			return true, nil
+		}
+		last = value
+	}
+	return false, nil
+}
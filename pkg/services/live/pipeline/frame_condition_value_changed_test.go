@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameValueChangedCondition(t *testing.T) {
+	c := NewFrameValueChangedCondition(NewFrameStorage(), "status")
+	ctx := withConditionVars(context.Background(), Vars{OrgID: 1, Channel: "stream/test/1"})
+	otherCtx := withConditionVars(context.Background(), Vars{OrgID: 1, Channel: "stream/test/2"})
+
+	check := func(ctx context.Context, status string) bool {
+		ok, err := c.CheckFrameCondition(ctx, data.NewFrame("test", data.NewField("status", nil, []*string{stringPtr(status)})))
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, check(ctx, "ok"))
+	require.False(t, check(ctx, "ok"))
+	require.True(t, check(ctx, "failed"))
+	require.True(t, check(otherCtx, "failed"))
+	require.False(t, check(otherCtx, "failed"))
+
+	// Frames without the field are skipped.
+	ok, err := c.CheckFrameCondition(ctx, data.NewFrame("test", data.NewField("value", nil, []float64{1})))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = c.CheckFrameCondition(context.Background(), data.NewFrame("test", data.NewField("status", nil, []string{"ok"})))
+	require.Error(t, err)
+}
+
+func TestFrameValueChangedCondition_multipleRows(t *testing.T) {
+	c := NewFrameValueChangedCondition(NewFrameStorage(), "status")
+	ctx := withConditionVars(context.Background(), Vars{OrgID: 1, Channel: "stream/test/1"})
+
+	check := func(statuses ...string) bool {
+		ok, err := c.CheckFrameCondition(ctx, data.NewFrame("test", data.NewField("status", nil, statuses)))
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, check("ok", "failed"))
+	// Compared with the last row of the previous frame.
+	require.False(t, check("failed", "failed"))
+	require.True(t, check("ok"))
+	// Changes between rows of a frame.
+	require.True(t, check("ok", "failed", "ok"))
+	require.False(t, check("ok"))
+}
+
+func TestConditionalOutput_valueChanged(t *testing.T) {
+	outputter := &generatorTestOutputter{frames: make(chan *data.Frame, 3)}
+	out := NewConditionalOutput(NewFrameValueChangedCondition(NewFrameStorage(), "value"), outputter)
+	for _, v := range []float64{1, 1, 2} {
+		_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/1"}, data.NewFrame("test", data.NewField("value", nil, []float64{v})))
+		require.NoError(t, err)
+	}
+	require.Len(t, outputter.frames, 2)
+}
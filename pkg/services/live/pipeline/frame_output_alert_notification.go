@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// defaultAlertNotificationName is used when alert name template is not set in config.
+	defaultAlertNotificationName = "{{ .Channel }}"
+	// defaultAlertNotificationSummary is used when summary template is not set in config.
+	defaultAlertNotificationSummary = "Live channel {{ .Channel }} notification"
+)
+
+// ContactPointNotifier sends alerts to alerting contact points.
+type ContactPointNotifier interface {
+	NotifyContactPoint(ctx context.Context, orgID int64, contactPoint string, labels map[string]string, annotations map[string]string) error
+}
+
+// AlertNotificationFrameOutput sends a notification through an alerting contact point
+// for each frame passed to it. Alert name, summary, description and label values are
+// Go templates executed with the last frame row. Usually it's wrapped into threshold,
+// conditional or changeLog outputs to notify about state changes only.
+type AlertNotificationFrameOutput struct {
+	config      AlertNotificationOutputConfig
+	notifier    ContactPointNotifier
+	name        *template.Template
+	summary     *template.Template
+	description *template.Template
+	labels      map[string]*template.Template
+}
+
+func NewAlertNotificationFrameOutput(notifier ContactPointNotifier, config AlertNotificationOutputConfig) (*AlertNotificationFrameOutput, error) {
+	if notifier == nil {
+		return nil, errors.New("alerting is not available")
+	}
+	if config.ContactPoint == "" {
+		return nil, errors.New("contact point is required")
+	}
+	if config.AlertName == "" {
+		config.AlertName = defaultAlertNotificationName
+	}
+	if config.Summary == "" {
+		config.Summary = defaultAlertNotificationSummary
+	}
+	name, err := parseFrameTemplate("alertName", config.AlertName)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := parseFrameTemplate("summary", config.Summary)
+	if err != nil {
+		return nil, err
+	}
+	description, err := parseFrameTemplate("description", config.Description)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]*template.Template, len(config.Labels))
+	for k, v := range config.Labels {
+		t, err := parseFrameTemplate("label "+k, v)
+		if err != nil {
+			return nil, err
+		}
+		labels[k] = t
+	}
+	return &AlertNotificationFrameOutput{
+		config:      config,
+		notifier:    notifier,
+		name:        name,
+		summary:     summary,
+		description: description,
+		labels:      labels,
+	}, nil
+}
+
+const FrameOutputTypeAlertNotification = "alertNotification"
+
+func (out *AlertNotificationFrameOutput) Type() string {
+	return FrameOutputTypeAlertNotification
+}
+
+func (out *AlertNotificationFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	numRows := frame.Rows()
+	if numRows == 0 {
+		return nil, nil
+	}
+	templateData := newFrameTemplateData(vars, frame, numRows-1)
+
+	// Frame field labels are passed to notification, configured labels take precedence.
+	labels := make(map[string]string, len(templateData.Labels)+len(out.labels)+2)
+	for k, v := range templateData.Labels {
+		labels[k] = v
+	}
+	keys := make([]string, 0, len(out.labels))
+	for k := range out.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := executeTemplate(out.labels[k], templateData)
+		if err != nil {
+			return nil, err
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			labels[k] = v
+		}
+	}
+	name, err := executeTemplate(out.name, templateData)
+	if err != nil {
+		return nil, err
+	}
+	labels["alertname"] = name
+	labels["channel"] = vars.Channel
+
+	annotations := map[string]string{}
+	summary, err := executeTemplate(out.summary, templateData)
+	if err != nil {
+		return nil, err
+	}
+	annotations["summary"] = summary
+	description, err := executeTemplate(out.description, templateData)
+	if err != nil {
+		return nil, err
+	}
+	if description != "" {
+		annotations["description"] = description
+	}
+
+	if err := out.notifier.NotifyContactPoint(ctx, vars.OrgID, out.config.ContactPoint, labels, annotations); err != nil {
+		return nil, fmt.Errorf("error sending notification to %s: %w", out.config.ContactPoint, err)
+	}
+	return nil, nil
+}
+
+// parseFrameTemplate parses a template executed with frameTemplateData.
+func parseFrameTemplate(name string, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return t, nil
+}
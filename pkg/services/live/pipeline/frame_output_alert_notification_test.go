@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testContactPointNotifier struct {
+	err           error
+	orgID         int64
+	contactPoints []string
+	labels        []map[string]string
+	annotations   []map[string]string
+}
+
+func (n *testContactPointNotifier) NotifyContactPoint(_ context.Context, orgID int64, contactPoint string, labels map[string]string, annotations map[string]string) error {
+	n.orgID = orgID
+	n.contactPoints = append(n.contactPoints, contactPoint)
+	n.labels = append(n.labels, labels)
+	n.annotations = append(n.annotations, annotations)
+	return n.err
+}
+
+func TestAlertNotificationFrameOutput_OutputFrame(t *testing.T) {
+	notifier := &testContactPointNotifier{}
+	out, err := NewAlertNotificationFrameOutput(notifier, AlertNotificationOutputConfig{
+		ContactPoint: "ops",
+		AlertName:    "{{ .Path }} state",
+		Summary:      "{{ .Labels.host }} is {{ .Fields.state }}",
+		Description:  "value {{ .Fields.value }}{{ .Fields.missing }}",
+		Labels:       map[string]string{"severity": "{{ .Fields.state }}", "host": "override", "empty": "{{ .Fields.missing }}"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{now, now}),
+		data.NewField("state", data.Labels{"host": "a", "dc": "eu"}, []string{"normal", "critical"}),
+		data.NewField("value", nil, []float64{1, 42}),
+	)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 2, Channel: "stream/test/cpu", Path: "cpu"}, frame)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(2), notifier.orgID)
+	require.Equal(t, []string{"ops"}, notifier.contactPoints)
+	require.Equal(t, map[string]string{
+		"alertname": "cpu state",
+		"channel":   "stream/test/cpu",
+		"host":      "override",
+		"dc":        "eu",
+		"severity":  "critical",
+	}, notifier.labels[0])
+	require.Equal(t, map[string]string{
+		"summary":     "a is critical",
+		"description": "value 42",
+	}, notifier.annotations[0])
+
+	// Empty frames are skipped.
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 2, Channel: "stream/test/cpu"}, data.NewFrame("test"))
+	require.NoError(t, err)
+	require.Len(t, notifier.contactPoints, 1)
+}
+
+func TestAlertNotificationFrameOutput_defaults(t *testing.T) {
+	notifier := &testContactPointNotifier{}
+	out, err := NewAlertNotificationFrameOutput(notifier, AlertNotificationOutputConfig{ContactPoint: "ops"})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, "stream/test/cpu", notifier.labels[0]["alertname"])
+	require.Equal(t, map[string]string{"summary": "Live channel stream/test/cpu notification"}, notifier.annotations[0])
+}
+
+func TestAlertNotificationFrameOutput_errors(t *testing.T) {
+	_, err := NewAlertNotificationFrameOutput(nil, AlertNotificationOutputConfig{ContactPoint: "ops"})
+	require.Error(t, err)
+	_, err = NewAlertNotificationFrameOutput(&testContactPointNotifier{}, AlertNotificationOutputConfig{})
+	require.Error(t, err)
+	_, err = NewAlertNotificationFrameOutput(&testContactPointNotifier{}, AlertNotificationOutputConfig{ContactPoint: "ops", Summary: "{{ .Fields"})
+	require.Error(t, err)
+
+	out, err := NewAlertNotificationFrameOutput(&testContactPointNotifier{err: errors.New("boom")}, AlertNotificationOutputConfig{ContactPoint: "ops"})
+	require.NoError(t, err)
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+	require.ErrorContains(t, err, "boom")
+}
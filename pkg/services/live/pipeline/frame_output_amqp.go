@@ -0,0 +1,262 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// defaultAMQPRoutingKey is used when routing key template is not set in config.
+	defaultAMQPRoutingKey = "grafana.live.{channel}"
+	amqpConfirmTimeout    = 5 * time.Second
+	amqpDialTimeout       = 5 * time.Second
+)
+
+// errAMQPRejected is returned for messages nacked or returned by a broker.
+var errAMQPRejected = errors.New("amqp message rejected")
+
+// amqpConns are connections shared by outputs of the same broker and credentials,
+// each output publishes on its own channel.
+var amqpConns sharedClients[*amqpConn]
+
+// amqpConn is a broker connection, it's dialed on first use and redialed when the
+// broker closes it.
+type amqpConn struct {
+	url    string
+	config amqp.Config
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+}
+
+func (c *amqpConn) channel() (*amqp.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		conn, err := amqp.DialConfig(c.url, c.config)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	return c.conn.Channel()
+}
+
+// Close closes the connection in background, as closing waits for the broker.
+func (c *amqpConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	conn := c.conn
+	c.conn = nil
+	go func() {
+		if err := conn.CloseDeadline(time.Now().Add(amqpDialTimeout)); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			logger.Error("Error closing AMQP connection", "error", err)
+		}
+	}()
+	return nil
+}
+
+// AMQPFrameOutput publishes frames encoded to JSON to an AMQP exchange. In confirm
+// mode each publish waits for a broker confirm.
+type AMQPFrameOutput struct {
+	// Endpoint is an AMQP server URL like amqp://localhost:5672/vhost.
+	Endpoint string
+
+	config     AMQPOutputConfig
+	routingKey *nameTemplate
+	connKey    string
+	conn       *amqpConn
+
+	// mu guards the channel, publishes are serialized so a returned message is
+	// matched with its confirm.
+	mu      sync.Mutex
+	ch      *amqp.Channel
+	returns chan amqp.Return
+	closed  bool
+}
+
+func NewAMQPFrameOutput(endpoint string, basicAuth *BasicAuth, config AMQPOutputConfig) (*AMQPFrameOutput, error) {
+	if config.RoutingKey == "" {
+		config.RoutingKey = defaultAMQPRoutingKey
+	}
+	routingKey, err := parseNameTemplate(config.RoutingKey)
+	if err != nil {
+		return nil, err
+	}
+	out := &AMQPFrameOutput{
+		Endpoint:   endpoint,
+		config:     config,
+		routingKey: routingKey,
+	}
+	if endpoint == "" {
+		return out, nil
+	}
+	if _, err := amqp.ParseURI(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid AMQP URL: %w", err)
+	}
+	properties := amqp.NewConnectionProperties()
+	properties.SetClientConnectionName("grafana-live")
+	amqpConfig := amqp.Config{
+		Properties: properties,
+		Dial:       amqp.DefaultDial(amqpDialTimeout),
+	}
+	var user, password string
+	if basicAuth != nil {
+		user, password = basicAuth.User, basicAuth.Password
+		amqpConfig.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: user, Password: password}}
+	}
+	// The connection is acquired with the output, so it stays open when rules are
+	// rebuilt, as new outputs acquire it before replaced ones release it.
+	out.connKey = strings.Join([]string{endpoint, user, password}, "\x00")
+	out.conn, err = amqpConns.acquire(out.connKey, func() (*amqpConn, error) {
+		return &amqpConn{url: endpoint, config: amqpConfig}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const FrameOutputTypeAMQP = "amqp"
+
+func (out *AMQPFrameOutput) Type() string {
+	return FrameOutputTypeAMQP
+}
+
+// Close closes the output channel and releases the connection, it's closed when no
+// other output uses it.
+func (out *AMQPFrameOutput) Close() error {
+	if out.conn == nil {
+		return nil
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if out.closed {
+		return nil
+	}
+	out.closed = true
+	ch := out.ch
+	out.ch = nil
+	// Closing a channel waits for the broker, and the connection must be released
+	// after its channel is closed.
+	go func() {
+		if ch != nil {
+			_ = ch.Close()
+		}
+		amqpConns.release(out.connKey)
+	}()
+	return nil
+}
+
+func (out *AMQPFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip publishing to AMQP: no url")
+		return nil, nil
+	}
+	routingKey, err := out.routingKeyName(vars, frame.Name)
+	if err != nil {
+		return nil, err
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	ch, err := out.channel()
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to AMQP: %w", err)
+	}
+
+	msg := amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Headers: amqp.Table{
+			"orgId":   vars.OrgID,
+			"channel": vars.Channel,
+		},
+		Body: frameJSON,
+	}
+	if vars.MessageID != "" {
+		msg.Headers["messageId"] = vars.MessageID
+	}
+	if out.config.Persistent {
+		msg.DeliveryMode = amqp.Persistent
+	}
+	if !out.config.Confirm {
+		if err := ch.PublishWithContext(ctx, out.config.Exchange, routingKey, out.config.Mandatory, false, msg); err != nil {
+			return nil, fmt.Errorf("error publishing to AMQP: %w", err)
+		}
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, amqpConfirmTimeout)
+	defer cancel()
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, out.config.Exchange, routingKey, out.config.Mandatory, false, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error publishing to AMQP: %w", err)
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for AMQP confirm: %w", err)
+	}
+	if !acked {
+		return nil, fmt.Errorf("%w: nacked by broker", errAMQPRejected)
+	}
+	// A broker returns an unroutable mandatory message before confirming it.
+	select {
+	case r := <-out.returns:
+		return nil, fmt.Errorf("%w: returned by broker: %s", errAMQPRejected, r.ReplyText)
+	default:
+	}
+	return nil, nil
+}
+
+// channel returns an open channel of the output, channels closed by the broker on
+// errors are reopened. Must be called with mu held.
+func (out *AMQPFrameOutput) channel() (*amqp.Channel, error) {
+	if out.closed {
+		return nil, errors.New("output is closed")
+	}
+	if out.ch != nil && !out.ch.IsClosed() {
+		return out.ch, nil
+	}
+	ch, err := out.conn.channel()
+	if err != nil {
+		return nil, err
+	}
+	if out.config.Confirm {
+		if err := ch.Confirm(false); err != nil {
+			_ = ch.Close()
+			return nil, err
+		}
+		// Returns are only read in confirm mode, an unread returns channel blocks
+		// the connection.
+		if out.config.Mandatory {
+			out.returns = ch.NotifyReturn(make(chan amqp.Return, 1))
+		}
+	}
+	out.ch = ch
+	return ch, nil
+}
+
+// routingKeyName renders a routing key, channel parts separated by slashes become
+// dot separated words like topic exchanges expect.
+func (out *AMQPFrameOutput) routingKeyName(vars Vars, frameName string) (string, error) {
+	routingKey := strings.ReplaceAll(out.routingKey.render(vars, frameName, time.Now()), "/", ".")
+	if len(routingKey) > 255 {
+		return "", fmt.Errorf("AMQP routing key is longer than 255 bytes: %q", routingKey)
+	}
+	return routingKey, nil
+}
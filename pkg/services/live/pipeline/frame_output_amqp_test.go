@@ -0,0 +1,333 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type amqpPublished struct {
+	exchange   string
+	routingKey string
+	body       []byte
+}
+
+type testAMQPServer struct {
+	nack      bool
+	returned  bool
+	published chan amqpPublished
+	logins    chan string
+	vhosts    chan string
+	conns     atomic.Int32
+}
+
+// testAMQPBuffer encodes AMQP method arguments.
+type testAMQPBuffer struct {
+	bytes.Buffer
+}
+
+func (b *testAMQPBuffer) octet(v byte) { _ = b.WriteByte(v) }
+
+func (b *testAMQPBuffer) short(v uint16) { _ = binary.Write(b, binary.BigEndian, v) }
+
+func (b *testAMQPBuffer) long(v uint32) { _ = binary.Write(b, binary.BigEndian, v) }
+
+func (b *testAMQPBuffer) longlong(v uint64) { _ = binary.Write(b, binary.BigEndian, v) }
+
+func (b *testAMQPBuffer) shortstr(s string) {
+	b.octet(byte(len(s)))
+	_, _ = b.WriteString(s)
+}
+
+func (b *testAMQPBuffer) longstr(s string) {
+	b.long(uint32(len(s)))
+	_, _ = b.WriteString(s)
+}
+
+// testAMQPReader decodes AMQP method arguments, field tables are read as long strings.
+type testAMQPReader struct {
+	b []byte
+}
+
+// next returns n bytes, missing bytes of truncated arguments are zero.
+func (r *testAMQPReader) next(n int) []byte {
+	v := make([]byte, n)
+	r.b = r.b[copy(v, r.b):]
+	return v
+}
+
+func (r *testAMQPReader) short() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *testAMQPReader) shortstr() string {
+	return string(r.next(int(r.next(1)[0])))
+}
+
+func (r *testAMQPReader) longstr() string {
+	return string(r.next(int(binary.BigEndian.Uint32(r.next(4)))))
+}
+
+func writeTestAMQPFrame(w io.Writer, typ byte, channel uint16, payload []byte) {
+	frame := testAMQPBuffer{}
+	frame.octet(typ)
+	frame.short(channel)
+	frame.long(uint32(len(payload)))
+	_, _ = frame.Write(payload)
+	frame.octet(0xCE)
+	_, _ = w.Write(frame.Bytes())
+}
+
+func writeTestAMQPMethod(w io.Writer, channel uint16, class, method uint16, args []byte) {
+	payload := testAMQPBuffer{}
+	payload.short(class)
+	payload.short(method)
+	_, _ = payload.Write(args)
+	writeTestAMQPFrame(w, 1, channel, payload.Bytes())
+}
+
+func readTestAMQPFrame(r *bufio.Reader) (byte, uint16, []byte, error) {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[3:7])+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, 0, nil, err
+	}
+	return header[0], binary.BigEndian.Uint16(header[1:3]), payload[:len(payload)-1], nil
+}
+
+// startTestAMQPServer accepts connections, performs AMQP handshake and sends published
+// messages to a channel, in confirm mode publishes are confirmed with ack or nack.
+func startTestAMQPServer(t *testing.T, s *testAMQPServer) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	s.published = make(chan amqpPublished, 10)
+	s.logins = make(chan string, 10)
+	s.vhosts = make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	return "amqp://" + ln.Addr().String()
+}
+
+func (s *testAMQPServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	if _, err := io.ReadFull(r, make([]byte, 8)); err != nil {
+		return
+	}
+
+	start := testAMQPBuffer{}
+	start.octet(0)
+	start.octet(9)
+	start.long(0)
+	start.longstr("PLAIN")
+	start.longstr("en_US")
+	writeTestAMQPMethod(conn, 0, 10, 10, start.Bytes())
+
+	// Tags of confirmed publishes by channel, channels in confirm mode only.
+	tags := map[uint16]uint64{}
+	for {
+		typ, channel, payload, err := readTestAMQPFrame(r)
+		if err != nil {
+			return
+		}
+		if typ != 1 {
+			continue
+		}
+		args := &testAMQPReader{b: payload}
+		class, method := args.short(), args.short()
+		switch {
+		case class == 10 && method == 11: // connection.start-ok
+			args.longstr()
+			args.shortstr()
+			s.logins <- args.longstr()
+			tune := testAMQPBuffer{}
+			tune.short(2047)
+			tune.long(4096)
+			tune.short(0)
+			writeTestAMQPMethod(conn, 0, 10, 30, tune.Bytes())
+		case class == 10 && method == 40: // connection.open
+			s.vhosts <- args.shortstr()
+			writeTestAMQPMethod(conn, 0, 10, 41, []byte{0})
+		case class == 10 && method == 50: // connection.close
+			writeTestAMQPMethod(conn, 0, 10, 51, nil)
+			return
+		case class == 20 && method == 10: // channel.open
+			writeTestAMQPMethod(conn, channel, 20, 11, []byte{0, 0, 0, 0})
+		case class == 20 && method == 40: // channel.close
+			writeTestAMQPMethod(conn, channel, 20, 41, nil)
+		case class == 85 && method == 10: // confirm.select
+			tags[channel] = 0
+			writeTestAMQPMethod(conn, channel, 85, 11, nil)
+		case class == 60 && method == 40: // basic.publish
+			args.short()
+			msg := amqpPublished{exchange: args.shortstr(), routingKey: args.shortstr()}
+			_, _, header, err := readTestAMQPFrame(r)
+			if err != nil {
+				return
+			}
+			size := binary.BigEndian.Uint64(header[4:12])
+			for uint64(len(msg.body)) < size {
+				_, _, body, err := readTestAMQPFrame(r)
+				if err != nil {
+					return
+				}
+				msg.body = append(msg.body, body...)
+			}
+			s.published <- msg
+			tag, confirm := tags[channel]
+			if !confirm {
+				continue
+			}
+			tag++
+			tags[channel] = tag
+			if s.returned {
+				ret := testAMQPBuffer{}
+				ret.short(312)
+				ret.shortstr("NO_ROUTE")
+				ret.shortstr(msg.exchange)
+				ret.shortstr(msg.routingKey)
+				writeTestAMQPMethod(conn, channel, 60, 50, ret.Bytes())
+				returnHeader := testAMQPBuffer{}
+				returnHeader.short(60)
+				returnHeader.short(0)
+				returnHeader.longlong(uint64(len(msg.body)))
+				returnHeader.short(0)
+				writeTestAMQPFrame(conn, 2, channel, returnHeader.Bytes())
+				writeTestAMQPFrame(conn, 3, channel, msg.body)
+			}
+			ack := testAMQPBuffer{}
+			ack.longlong(tag)
+			ack.octet(0)
+			if s.nack {
+				writeTestAMQPMethod(conn, channel, 60, 120, ack.Bytes())
+			} else {
+				writeTestAMQPMethod(conn, channel, 60, 80, ack.Bytes())
+			}
+		}
+	}
+}
+
+func TestAMQPFrameOutput_OutputFrame(t *testing.T) {
+	server := &testAMQPServer{}
+	endpoint := startTestAMQPServer(t, server)
+	out, err := NewAMQPFrameOutput(endpoint+"/telemetry", &BasicAuth{User: "live", Password: "secret"}, AMQPOutputConfig{
+		Exchange: "live",
+		Confirm:  true,
+	})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+
+	// Body is split into multiple frames.
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = float64(i) * 1.5
+	}
+	frame := data.NewFrame("test", data.NewField("value", nil, values))
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+
+	require.Equal(t, "\x00live\x00secret", <-server.logins)
+	require.Equal(t, "telemetry", <-server.vhosts)
+	select {
+	case msg := <-server.published:
+		require.Equal(t, "live", msg.exchange)
+		require.Equal(t, "grafana.live.stream.test.cpu", msg.routingKey)
+		frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+		require.NoError(t, err)
+		require.Greater(t, len(frameJSON), 4096)
+		require.JSONEq(t, string(frameJSON), string(msg.body))
+	case <-time.After(time.Second):
+		t.Fatal("message not published")
+	}
+}
+
+func TestAMQPFrameOutput_OutputFrame_rejected(t *testing.T) {
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+
+	endpoint := startTestAMQPServer(t, &testAMQPServer{nack: true})
+	out, err := NewAMQPFrameOutput(endpoint, nil, AMQPOutputConfig{Confirm: true})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.ErrorIs(t, err, errAMQPRejected)
+	require.ErrorContains(t, err, "nacked")
+
+	endpoint = startTestAMQPServer(t, &testAMQPServer{returned: true})
+	out, err = NewAMQPFrameOutput(endpoint, nil, AMQPOutputConfig{Confirm: true, Mandatory: true})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.ErrorIs(t, err, errAMQPRejected)
+	require.ErrorContains(t, err, "NO_ROUTE")
+
+	// Connection is kept after rejected publishes.
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.ErrorContains(t, err, "NO_ROUTE")
+}
+
+func TestAMQPFrameOutput_sharedConn(t *testing.T) {
+	server := &testAMQPServer{}
+	endpoint := startTestAMQPServer(t, server)
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+
+	first, err := NewAMQPFrameOutput(endpoint, nil, AMQPOutputConfig{})
+	require.NoError(t, err)
+	second, err := NewAMQPFrameOutput(endpoint, nil, AMQPOutputConfig{Confirm: true})
+	require.NoError(t, err)
+	require.Same(t, first.conn, second.conn)
+	for _, out := range []*AMQPFrameOutput{first, second} {
+		_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), server.conns.Load())
+
+	// The connection is kept while it's used by other outputs.
+	require.NoError(t, first.Close())
+	require.NoError(t, first.Close())
+	_, err = first.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.Error(t, err)
+	_, err = second.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), server.conns.Load())
+
+	require.NoError(t, second.Close())
+	require.Eventually(t, func() bool {
+		second.conn.mu.Lock()
+		defer second.conn.mu.Unlock()
+		return second.conn.conn == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAMQPFrameOutput_routingKeyName(t *testing.T) {
+	out, err := NewAMQPFrameOutput("", nil, AMQPOutputConfig{RoutingKey: "live.{namespace}.{path}"})
+	require.NoError(t, err)
+	routingKey, err := out.routingKeyName(Vars{Namespace: "test", Path: "cpu/total"}, "")
+	require.NoError(t, err)
+	require.Equal(t, "live.test.cpu.total", routingKey)
+
+	_, err = out.routingKeyName(Vars{Namespace: strings.Repeat("a", 256)}, "")
+	require.Error(t, err)
+}
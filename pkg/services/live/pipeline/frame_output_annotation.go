@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// defaultAnnotationText is used when text template is not set in config.
+const defaultAnnotationText = "{{ .Channel }}"
+
+// dashboardGetter is a part of dashboards.DashboardService used to resolve dashboard UIDs.
+type dashboardGetter interface {
+	GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error)
+}
+
+// AnnotationFrameOutput creates an annotation for each frame passed to it, text and
+// tags are Go templates executed with the last frame row. Usually it's wrapped into
+// conditional or changeLog outputs to annotate state changes only.
+type AnnotationFrameOutput struct {
+	config      AnnotationOutputConfig
+	repo        annotations.Repository
+	dashboards  dashboardGetter
+	text        *template.Template
+	tags        []*template.Template
+	now         func() time.Time
+	dashboardMu sync.Mutex
+	// dashboardIDs resolved by org ID.
+	dashboardIDs map[int64]int64
+}
+
+// frameTemplateData is passed to templates executed with a frame row.
+type frameTemplateData struct {
+	OrgID     int64
+	Channel   string
+	Scope     string
+	Namespace string
+	Path      string
+	Frame     string
+	// Fields are values of the last frame row by field name.
+	Fields map[string]any
+	// Labels of all frame fields.
+	Labels map[string]string
+}
+
+func NewAnnotationFrameOutput(repo annotations.Repository, dashboardService dashboardGetter, config AnnotationOutputConfig) (*AnnotationFrameOutput, error) {
+	if repo == nil {
+		return nil, errors.New("annotations repository is not available")
+	}
+	if config.Text == "" {
+		config.Text = defaultAnnotationText
+	}
+	text, err := template.New("text").Option("missingkey=zero").Parse(config.Text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text template: %w", err)
+	}
+	tags := make([]*template.Template, 0, len(config.Tags))
+	for i, tag := range config.Tags {
+		t, err := template.New(fmt.Sprintf("tag%d", i)).Option("missingkey=zero").Parse(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag template %q: %w", tag, err)
+		}
+		tags = append(tags, t)
+	}
+	if config.DashboardUID != "" && dashboardService == nil {
+		return nil, errors.New("dashboard service is not available")
+	}
+	return &AnnotationFrameOutput{
+		config:       config,
+		repo:         repo,
+		dashboards:   dashboardService,
+		text:         text,
+		tags:         tags,
+		now:          time.Now,
+		dashboardIDs: map[int64]int64{},
+	}, nil
+}
+
+const FrameOutputTypeAnnotation = "annotation"
+
+func (out *AnnotationFrameOutput) Type() string {
+	return FrameOutputTypeAnnotation
+}
+
+func (out *AnnotationFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	numRows := frame.Rows()
+	if numRows == 0 {
+		return nil, nil
+	}
+	row := numRows - 1
+
+	templateData := newFrameTemplateData(vars, frame, row)
+	text, err := executeTemplate(out.text, templateData)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(out.tags))
+	for _, t := range out.tags {
+		tag, err := executeTemplate(t, templateData)
+		if err != nil {
+			return nil, err
+		}
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	dashboardID, err := out.dashboardID(ctx, vars.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	epoch := out.annotationTime(frame, row).UnixMilli()
+	item := &annotations.Item{
+		OrgID:       vars.OrgID,
+		DashboardID: dashboardID,
+		PanelID:     out.config.PanelID,
+		Text:        text,
+		Tags:        tags,
+		Epoch:       epoch,
+		EpochEnd:    epoch,
+		Data: simplejson.NewFromAny(map[string]any{
+			"channel": vars.Channel,
+			"fields":  templateData.Fields,
+		}),
+	}
+	if err := out.repo.Save(ctx, item); err != nil {
+		return nil, fmt.Errorf("error saving annotation: %w", err)
+	}
+	return nil, nil
+}
+
+func newFrameTemplateData(vars Vars, frame *data.Frame, row int) frameTemplateData {
+	templateData := frameTemplateData{
+		OrgID:     vars.OrgID,
+		Channel:   vars.Channel,
+		Scope:     vars.Scope,
+		Namespace: vars.Namespace,
+		Path:      vars.Path,
+		Frame:     frame.Name,
+		Fields:    make(map[string]any, len(frame.Fields)),
+		Labels:    map[string]string{},
+	}
+	for _, f := range frame.Fields {
+		if v, ok := f.ConcreteAt(row); ok {
+			templateData.Fields[f.Name] = v
+		}
+		for k, v := range f.Labels {
+			templateData.Labels[k] = v
+		}
+	}
+	return templateData
+}
+
+// annotationTime is a value of configured time field, or first time field, in a row.
+// Current time is used for frames without time.
+func (out *AnnotationFrameOutput) annotationTime(frame *data.Frame, row int) time.Time {
+	for _, f := range frame.Fields {
+		if out.config.TimeField != "" && f.Name != out.config.TimeField {
+			continue
+		}
+		if v, ok := f.ConcreteAt(row); ok {
+			if t, ok := v.(time.Time); ok {
+				return t
+			}
+		}
+		if out.config.TimeField != "" {
+			break
+		}
+	}
+	return out.now()
+}
+
+func (out *AnnotationFrameOutput) dashboardID(ctx context.Context, orgID int64) (int64, error) {
+	if out.config.DashboardUID == "" {
+		return 0, nil
+	}
+	out.dashboardMu.Lock()
+	defer out.dashboardMu.Unlock()
+	if id, ok := out.dashboardIDs[orgID]; ok {
+		return id, nil
+	}
+	dash, err := out.dashboards.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: out.config.DashboardUID, OrgID: orgID})
+	if err != nil {
+		return 0, fmt.Errorf("error getting dashboard %s: %w", out.config.DashboardUID, err)
+	}
+	out.dashboardIDs[orgID] = dash.ID
+	return dash.ID, nil
+}
+
+// executeTemplate renders a template, missing fields and labels render as empty strings.
+func executeTemplate(t *template.Template, templateData any) (string, error) {
+	var sb strings.Builder
+	if err := t.Execute(&sb, templateData); err != nil {
+		return "", fmt.Errorf("error executing %s template: %w", t.Name(), err)
+	}
+	return strings.ReplaceAll(sb.String(), "<no value>", ""), nil
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+type testDashboardGetter struct {
+	calls int
+}
+
+func (g *testDashboardGetter) GetDashboard(_ context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+	g.calls++
+	if query.UID != "live" {
+		return nil, dashboards.ErrDashboardNotFound
+	}
+	return &dashboards.Dashboard{ID: 10 + query.OrgID, UID: query.UID}, nil
+}
+
+func TestAnnotationFrameOutput_OutputFrame(t *testing.T) {
+	repo := annotationstest.NewFakeAnnotationsRepo()
+	getter := &testDashboardGetter{}
+	out, err := NewAnnotationFrameOutput(repo, getter, AnnotationOutputConfig{
+		DashboardUID: "live",
+		PanelID:      2,
+		Text:         "{{ .Fields.state }} on {{ .Labels.host }}",
+		Tags:         []string{"live", "{{ .Path }}", "{{ .Fields.unknown }}"},
+	})
+	require.NoError(t, err)
+
+	ts := time.Date(2021, 9, 14, 10, 0, 0, 0, time.UTC)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("state", data.Labels{"host": "a"}, []string{"ok", "alerting"}),
+	)
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}
+	for i := 0; i < 2; i++ {
+		_, err = out.OutputFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, getter.calls)
+
+	items := repo.Items()
+	require.Len(t, items, 2)
+	item := items[1]
+	require.Equal(t, int64(1), item.OrgID)
+	require.Equal(t, int64(11), item.DashboardID)
+	require.Equal(t, int64(2), item.PanelID)
+	require.Equal(t, "alerting on a", item.Text)
+	require.Equal(t, []string{"live", "cpu"}, item.Tags)
+	require.Equal(t, ts.Add(time.Second).UnixMilli(), item.Epoch)
+	require.Equal(t, "stream/test/cpu", item.Data.Get("channel").MustString())
+}
+
+func TestAnnotationFrameOutput_unknownDashboard(t *testing.T) {
+	out, err := NewAnnotationFrameOutput(annotationstest.NewFakeAnnotationsRepo(), &testDashboardGetter{}, AnnotationOutputConfig{DashboardUID: "unknown"})
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1}, data.NewFrame("test", data.NewField("value", nil, []float64{1})))
+	require.ErrorIs(t, err, dashboards.ErrDashboardNotFound)
+}
+
+func TestNewAnnotationFrameOutput_invalid(t *testing.T) {
+	repo := annotationstest.NewFakeAnnotationsRepo()
+	_, err := NewAnnotationFrameOutput(nil, nil, AnnotationOutputConfig{})
+	require.Error(t, err)
+	_, err = NewAnnotationFrameOutput(repo, nil, AnnotationOutputConfig{Text: "{{ .Channel"})
+	require.Error(t, err)
+	_, err = NewAnnotationFrameOutput(repo, nil, AnnotationOutputConfig{DashboardUID: "live"})
+	require.Error(t, err)
+}
@@ -1,7 +1,12 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
 	"time"
 
@@ -9,8 +14,20 @@ import (
 )
 
 type ChangeLogOutputConfig struct {
-	FieldName string `json:"fieldName"`
+	FieldName string `json:"fieldName,omitempty"`
 	Channel   string `json:"channel"`
+	// Fields to watch instead of FieldName, changes are emitted as a diff frame with
+	// a row per changed field.
+	Fields []ChangeLogFieldConfig `json:"fields,omitempty"`
+}
+
+type ChangeLogFieldConfig struct {
+	Name string `json:"name"`
+	// MinDelta ignores changes of numeric fields smaller than it, compared to the last
+	// emitted value, so slow drift is still reported.
+	MinDelta float64 `json:"minDelta,omitempty"`
+	// IgnoreNull skips null values instead of reporting a change to null.
+	IgnoreNull bool `json:"ignoreNull,omitempty"`
 }
 
 // ChangeLogFrameOutput can monitor value changes of the specified field and output
@@ -18,10 +35,37 @@ type ChangeLogOutputConfig struct {
 type ChangeLogFrameOutput struct {
 	frameStorage FrameGetSetter
 	config       ChangeLogOutputConfig
+	now          func() time.Time
 }
 
 func NewChangeLogFrameOutput(frameStorage FrameGetSetter, config ChangeLogOutputConfig) *ChangeLogFrameOutput {
-	return &ChangeLogFrameOutput{frameStorage: frameStorage, config: config}
+	return &ChangeLogFrameOutput{frameStorage: frameStorage, config: config, now: time.Now}
+}
+
+func validateChangeLogConfig(config ChangeLogOutputConfig) error {
+	if len(config.Fields) == 0 {
+		if config.FieldName == "" {
+			return errors.New("fieldName or fields required")
+		}
+		return nil
+	}
+	if config.FieldName != "" {
+		return errors.New("fieldName and fields can't be used together")
+	}
+	seen := map[string]struct{}{}
+	for _, f := range config.Fields {
+		if f.Name == "" {
+			return errors.New("field name required")
+		}
+		if _, ok := seen[f.Name]; ok {
+			return fmt.Errorf("duplicate field: %s", f.Name)
+		}
+		seen[f.Name] = struct{}{}
+		if f.MinDelta < 0 {
+			return fmt.Errorf("minDelta of field %s can't be negative", f.Name)
+		}
+	}
+	return nil
 }
 
 const FrameOutputTypeChangeLog = "changeLog"
@@ -31,6 +75,9 @@ func (out *ChangeLogFrameOutput) Type() string {
 }
 
 func (out *ChangeLogFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if len(out.config.Fields) > 0 {
+		return out.outputDiff(vars, frame)
+	}
 	previousFrame, previousFrameOK, err := out.frameStorage.Get(vars.OrgID, out.config.Channel)
 	if err != nil {
 		return nil, err
@@ -96,3 +143,146 @@ func (out *ChangeLogFrameOutput) OutputFrame(_ context.Context, vars Vars, frame
 
 	return nil, out.frameStorage.Set(vars.OrgID, out.config.Channel, frame)
 }
+
+// changeLogStateKey is appended to the output channel to keep last emitted values of
+// watched fields in frame storage.
+const changeLogStateKey = "#changeLog"
+
+// loadValues returns JSON encoded last emitted values by field name.
+func (out *ChangeLogFrameOutput) loadValues(orgID int64) (map[string]*json.RawMessage, error) {
+	values := map[string]*json.RawMessage{}
+	frame, ok, err := out.frameStorage.Get(orgID, out.config.Channel+changeLogStateKey)
+	if err != nil || !ok || len(frame.Fields) != 2 {
+		return values, err
+	}
+	for i := 0; i < frame.Rows(); i++ {
+		name, _ := frame.Fields[0].At(i).(string)
+		value, _ := frame.Fields[1].At(i).(*json.RawMessage)
+		values[name] = value
+	}
+	return values, nil
+}
+
+func (out *ChangeLogFrameOutput) saveValues(orgID int64, values map[string]*json.RawMessage) error {
+	names := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	names.Name = "field"
+	jsonValues := data.NewFieldFromFieldType(data.FieldTypeNullableJSON, 0)
+	jsonValues.Name = "value"
+	for _, f := range out.config.Fields {
+		if value, ok := values[f.Name]; ok {
+			names.Append(f.Name)
+			jsonValues.Append(value)
+		}
+	}
+	return out.frameStorage.Set(orgID, out.config.Channel+changeLogStateKey, data.NewFrame("changeLog", names, jsonValues))
+}
+
+// outputDiff emits a row with old and new values, and a delta for numeric fields, for
+// each change of watched fields.
+func (out *ChangeLogFrameOutput) outputDiff(vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	values, err := out.loadValues(vars.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	fTime := data.NewFieldFromFieldType(data.FieldTypeTime, 0)
+	fTime.Name = "time"
+	fField := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	fField.Name = "field"
+	fOld := data.NewFieldFromFieldType(data.FieldTypeNullableJSON, 0)
+	fOld.Name = "old"
+	fNew := data.NewFieldFromFieldType(data.FieldTypeNullableJSON, 0)
+	fNew.Name = "new"
+	fDelta := data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, 0)
+	fDelta.Name = "delta"
+
+	for row := 0; row < frame.Rows(); row++ {
+		var rowTime time.Time
+		for _, fc := range out.config.Fields {
+			index := fieldIndex(frame, fc.Name)
+			if index < 0 {
+				continue
+			}
+			field := frame.Fields[index]
+			var current *json.RawMessage
+			var currentNumber *float64
+			if v, ok := field.ConcreteAt(row); ok {
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, fmt.Errorf("error encoding %s value: %w", fc.Name, err)
+				}
+				raw := json.RawMessage(b)
+				current = &raw
+				currentNumber = changeLogNumber(field, v)
+			} else if fc.IgnoreNull {
+				continue
+			}
+
+			previous, seen := values[fc.Name]
+			if seen && changeLogEqual(previous, current) {
+				continue
+			}
+			var delta *float64
+			if previousNumber := changeLogJSONNumber(previous); previousNumber != nil && currentNumber != nil {
+				d := *currentNumber - *previousNumber
+				if math.Abs(d) < fc.MinDelta {
+					continue
+				}
+				delta = &d
+			}
+
+			if rowTime.IsZero() {
+				rowTime = frameRowTime(frame, row, out.now)
+			}
+			fTime.Append(rowTime)
+			fField.Append(fc.Name)
+			fOld.Append(previous)
+			fNew.Append(current)
+			fDelta.Append(delta)
+			values[fc.Name] = current
+		}
+	}
+
+	if err := out.saveValues(vars.OrgID, values); err != nil {
+		return nil, err
+	}
+	if fTime.Len() == 0 {
+		return nil, nil
+	}
+	return []*ChannelFrame{{
+		Channel: out.config.Channel,
+		Frame:   data.NewFrame("change", fTime, fField, fOld, fNew, fDelta),
+	}}, nil
+}
+
+func changeLogEqual(a, b *json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(*a, *b)
+}
+
+// changeLogNumber returns a value of a numeric field as float64.
+func changeLogNumber(field *data.Field, v any) *float64 {
+	if !field.Type().Numeric() {
+		return nil
+	}
+	f, err := convertToFieldType(v, data.FieldTypeFloat64)
+	if err != nil {
+		return nil
+	}
+	number := f.(float64)
+	return &number
+}
+
+// changeLogJSONNumber decodes a JSON encoded number, nil is returned for other values.
+func changeLogJSONNumber(raw *json.RawMessage) *float64 {
+	if raw == nil {
+		return nil
+	}
+	var number float64
+	if err := json.Unmarshal(*raw, &number); err != nil {
+		return nil
+	}
+	return &number
+}
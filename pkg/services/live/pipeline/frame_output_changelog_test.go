@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -88,3 +89,80 @@ func TestChangeLogOutput_NoPreviousFrame_MultipleRows(t *testing.T) {
 	require.Equal(t, &z, changeFrame.Fields[1].At(1).(*float64))
 	require.Equal(t, &v, changeFrame.Fields[2].At(1))
 }
+
+func TestChangeLogOutput_Fields(t *testing.T) {
+	outputter := NewChangeLogFrameOutput(NewFrameStorage(), ChangeLogOutputConfig{
+		Channel: "stream/test/diff",
+		Fields: []ChangeLogFieldConfig{
+			{Name: "status"},
+			{Name: "value", MinDelta: 1, IgnoreNull: true},
+		},
+	})
+	start := time.Now()
+	newFrame := func(statuses []string, values []*float64) *data.Frame {
+		times := make([]time.Time, len(values))
+		for i := range times {
+			times[i] = start.Add(time.Duration(i) * time.Second)
+		}
+		return data.NewFrame("test",
+			data.NewField("time", nil, times),
+			data.NewField("status", nil, statuses),
+			data.NewField("value", nil, values),
+		)
+	}
+	float := func(f float64) *float64 { return &f }
+	jsonValue := func(v any) any {
+		if v == nil {
+			return (*json.RawMessage)(nil)
+		}
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		raw := json.RawMessage(b)
+		return &raw
+	}
+
+	channelFrames, err := outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, newFrame(
+		[]string{"ok", "ok", "ok", "failing"},
+		[]*float64{float(10), float(10.5), nil, float(10.8)},
+	))
+	require.NoError(t, err)
+	require.Len(t, channelFrames, 1)
+	changeFrame := channelFrames[0].Frame
+	require.Equal(t, 3, changeFrame.Rows())
+
+	// Initial values are reported without delta.
+	require.Equal(t, start, changeFrame.Fields[0].At(0))
+	require.Equal(t, "status", changeFrame.Fields[1].At(0))
+	require.Equal(t, jsonValue(nil), changeFrame.Fields[2].At(0))
+	require.Equal(t, jsonValue("ok"), changeFrame.Fields[3].At(0))
+	require.Equal(t, "value", changeFrame.Fields[1].At(1))
+	require.Nil(t, changeFrame.Fields[4].At(1))
+
+	// Small changes and nulls are skipped, delta is counted from the last emitted value.
+	require.Equal(t, start.Add(3*time.Second), changeFrame.Fields[0].At(2))
+	require.Equal(t, "status", changeFrame.Fields[1].At(2))
+	require.Equal(t, jsonValue("ok"), changeFrame.Fields[2].At(2))
+	require.Equal(t, jsonValue("failing"), changeFrame.Fields[3].At(2))
+
+	channelFrames, err = outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, newFrame(
+		[]string{"failing"},
+		[]*float64{float(11.5)},
+	))
+	require.NoError(t, err)
+	require.Len(t, channelFrames, 1)
+	changeFrame = channelFrames[0].Frame
+	require.Equal(t, 1, changeFrame.Rows())
+	require.Equal(t, "value", changeFrame.Fields[1].At(0))
+	require.Equal(t, jsonValue(10.0), changeFrame.Fields[2].At(0))
+	require.Equal(t, jsonValue(11.5), changeFrame.Fields[3].At(0))
+	require.InDelta(t, 1.5, *changeFrame.Fields[4].At(0).(*float64), 1e-9)
+}
+
+func TestValidateChangeLogConfig(t *testing.T) {
+	require.NoError(t, validateChangeLogConfig(ChangeLogOutputConfig{FieldName: "test"}))
+	require.NoError(t, validateChangeLogConfig(ChangeLogOutputConfig{Fields: []ChangeLogFieldConfig{{Name: "a"}, {Name: "b"}}}))
+	require.Error(t, validateChangeLogConfig(ChangeLogOutputConfig{}))
+	require.Error(t, validateChangeLogConfig(ChangeLogOutputConfig{FieldName: "test", Fields: []ChangeLogFieldConfig{{Name: "a"}}}))
+	require.Error(t, validateChangeLogConfig(ChangeLogOutputConfig{Fields: []ChangeLogFieldConfig{{Name: "a"}, {Name: "a"}}}))
+	require.Error(t, validateChangeLogConfig(ChangeLogOutputConfig{Fields: []ChangeLogFieldConfig{{Name: "a", MinDelta: -1}}}))
+}
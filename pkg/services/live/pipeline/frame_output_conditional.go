@@ -22,7 +22,7 @@ func (out *ConditionalOutput) Type() string {
 }
 
 func (out ConditionalOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
-	ok, err := out.Condition.CheckFrameCondition(ctx, frame)
+	ok, err := out.Condition.CheckFrameCondition(withConditionVars(ctx, vars), frame)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	debugLevelDebug = "debug"
+	debugLevelInfo  = "info"
+	debugLevelWarn  = "warn"
+
+	defaultDebugMaxRows    = 10
+	defaultDebugBufferSize = 1000
+)
+
+// DebugFrame is a frame captured by DebugFrameOutput.
+type DebugFrame struct {
+	Time    time.Time       `json:"time"`
+	OrgID   int64           `json:"orgId"`
+	Channel string          `json:"channel"`
+	Rows    int             `json:"rows"`
+	Frame   json.RawMessage `json:"frame"`
+	// Truncated is true when Frame contains only first rows of a frame.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// DebugFrameBuffer is a fixed size ring buffer keeping last frames captured by debug
+// outputs, so rule output can be inspected without external tooling.
+type DebugFrameBuffer struct {
+	mu      sync.RWMutex
+	entries []DebugFrame
+	next    int
+	full    bool
+}
+
+// NewDebugFrameBuffer creates DebugFrameBuffer keeping up to size frames.
+func NewDebugFrameBuffer(size int) *DebugFrameBuffer {
+	if size <= 0 {
+		size = defaultDebugBufferSize
+	}
+	return &DebugFrameBuffer{entries: make([]DebugFrame, size)}
+}
+
+func (b *DebugFrameBuffer) add(f DebugFrame) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = f
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// List returns captured frames of an organization newest first, optionally filtered
+// by channel.
+func (b *DebugFrameBuffer) List(orgID int64, channel string) []DebugFrame {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	n := b.next
+	if b.full {
+		n = len(b.entries)
+	}
+	result := make([]DebugFrame, 0)
+	for i := 1; i <= n; i++ {
+		e := b.entries[(b.next-i+len(b.entries))%len(b.entries)]
+		if e.OrgID != orgID || (channel != "" && e.Channel != channel) {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// DebugFrameOutput logs frames passed to it and keeps them in DebugFrameBuffer.
+type DebugFrameOutput struct {
+	config DebugOutputConfig
+	buffer *DebugFrameBuffer
+	now    func() time.Time
+}
+
+// NewDebugFrameOutput creates DebugFrameOutput, buffer is optional.
+func NewDebugFrameOutput(buffer *DebugFrameBuffer, config DebugOutputConfig) (*DebugFrameOutput, error) {
+	switch config.Level {
+	case "":
+		config.Level = debugLevelDebug
+	case debugLevelDebug, debugLevelInfo, debugLevelWarn:
+	default:
+		return nil, fmt.Errorf("unsupported log level: %s", config.Level)
+	}
+	if config.MaxRows == 0 {
+		config.MaxRows = defaultDebugMaxRows
+	}
+	return &DebugFrameOutput{config: config, buffer: buffer, now: time.Now}, nil
+}
+
+const FrameOutputTypeDebug = "debug"
+
+func (out *DebugFrameOutput) Type() string {
+	return FrameOutputTypeDebug
+}
+
+func (out *DebugFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	numRows := frame.Rows()
+	truncated := out.config.MaxRows > 0 && numRows > out.config.MaxRows
+	logged := frame
+	if truncated {
+		logged = truncateFrame(frame, out.config.MaxRows)
+	}
+	frameJSON, err := data.FrameToJSON(logged, data.IncludeAll)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding frame: %w", err)
+	}
+
+	args := []any{"orgId", vars.OrgID, "channel", vars.Channel, "frame", frame.Name, "rows", numRows, "truncated", truncated, "data", string(frameJSON)}
+	switch out.config.Level {
+	case debugLevelWarn:
+		logger.Warn("Pipeline debug output", args...)
+	case debugLevelInfo:
+		logger.Info("Pipeline debug output", args...)
+	default:
+		logger.Debug("Pipeline debug output", args...)
+	}
+
+	if out.buffer != nil {
+		out.buffer.add(DebugFrame{
+			Time:      out.now(),
+			OrgID:     vars.OrgID,
+			Channel:   vars.Channel,
+			Rows:      numRows,
+			Frame:     frameJSON,
+			Truncated: truncated,
+		})
+	}
+	return nil, nil
+}
+
+// truncateFrame returns a copy of frame with first maxRows rows.
+func truncateFrame(frame *data.Frame, maxRows int) *data.Frame {
+	truncated := frame.EmptyCopy()
+	for i := 0; i < maxRows && i < frame.Rows(); i++ {
+		truncated.AppendRow(frame.RowCopy(i)...)
+	}
+	return truncated
+}
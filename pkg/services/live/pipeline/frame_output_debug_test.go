@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugFrameOutput_OutputFrame(t *testing.T) {
+	buffer := NewDebugFrameBuffer(10)
+	out, err := NewDebugFrameOutput(buffer, DebugOutputConfig{MaxRows: 2})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1, 2, 3}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, 3, frame.Rows())
+
+	frames := buffer.List(1, "")
+	require.Len(t, frames, 1)
+	require.Equal(t, 3, frames[0].Rows)
+	require.True(t, frames[0].Truncated)
+	require.JSONEq(t, `{"schema":{"name":"test","fields":[{"name":"value","labels":{},"type":"number","typeInfo":{"frame":"float64"}}]},"data":{"values":[[1,2]]}}`, string(frames[0].Frame))
+}
+
+func TestDebugFrameBuffer_List(t *testing.T) {
+	buffer := NewDebugFrameBuffer(3)
+	for i, channel := range []string{"stream/test/a", "stream/test/b", "stream/test/a", "stream/test/b"} {
+		buffer.add(DebugFrame{OrgID: 1, Channel: channel, Rows: i})
+	}
+	buffer.add(DebugFrame{OrgID: 2, Channel: "stream/test/a", Rows: 4})
+
+	frames := buffer.List(1, "")
+	require.Len(t, frames, 2)
+	require.Equal(t, 3, frames[0].Rows)
+	require.Equal(t, 2, frames[1].Rows)
+
+	frames = buffer.List(1, "stream/test/a")
+	require.Len(t, frames, 1)
+	require.Equal(t, 2, frames[0].Rows)
+
+	require.Len(t, buffer.List(2, ""), 1)
+}
+
+func TestNewDebugFrameOutput_invalid(t *testing.T) {
+	_, err := NewDebugFrameOutput(nil, DebugOutputConfig{Level: "trace"})
+	require.Error(t, err)
+}
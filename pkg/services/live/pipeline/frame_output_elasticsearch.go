@@ -0,0 +1,322 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	elasticsearchFlushInterval = 5 * time.Second
+	// elasticsearchMaxBuffer limits a number of documents kept when endpoint is not
+	// available, oldest documents are dropped first.
+	elasticsearchMaxBuffer = 10000
+	// defaultElasticsearchIndex is used when index template is not set in config.
+	defaultElasticsearchIndex = "grafana-live-{channel}-{now/d}"
+)
+
+// ElasticsearchFrameOutput writes frame rows as documents to Elasticsearch using
+// bulk API.
+type ElasticsearchFrameOutput struct {
+	mu sync.Mutex
+
+	// Endpoint is an Elasticsearch URL, bulk requests are sent to Endpoint + "/_bulk".
+	Endpoint string
+	// BasicAuth is an optional basic auth params.
+	BasicAuth *BasicAuth
+	// APIKey is an optional encoded API key sent in Authorization header, takes
+	// precedence over BasicAuth.
+	APIKey string
+
+	index      *nameTemplate
+	httpClient *http.Client
+	buffer     []elasticsearchDocument
+	now        func() time.Time
+	closeOnce  sync.Once
+	done       chan struct{}
+}
+
+type elasticsearchDocument struct {
+	index  string
+	source json.RawMessage
+}
+
+func NewElasticsearchFrameOutput(endpoint string, basicAuth *BasicAuth, apiKey string, index string) (*ElasticsearchFrameOutput, error) {
+	if index == "" {
+		index = defaultElasticsearchIndex
+	}
+	template, err := parseNameTemplate(index)
+	if err != nil {
+		return nil, err
+	}
+	out := &ElasticsearchFrameOutput{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		BasicAuth:  basicAuth,
+		APIKey:     apiKey,
+		index:      template,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		now:        time.Now,
+		done:       make(chan struct{}),
+	}
+	if out.Endpoint != "" {
+		go out.flushPeriodically()
+	}
+	return out, nil
+}
+
+const FrameOutputTypeElasticsearch = "elasticsearch"
+
+func (out *ElasticsearchFrameOutput) Type() string {
+	return FrameOutputTypeElasticsearch
+}
+
+func (out *ElasticsearchFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip sending to Elasticsearch: no url")
+		return nil, nil
+	}
+	docs, err := out.frameToDocuments(vars, frame)
+	if err != nil {
+		return nil, err
+	}
+	out.mu.Lock()
+	out.buffer = append(out.buffer, docs...)
+	if len(out.buffer) > elasticsearchMaxBuffer {
+		logger.Warn("Elasticsearch buffer is full, dropping documents", "numDropped", len(out.buffer)-elasticsearchMaxBuffer)
+		out.buffer = out.buffer[len(out.buffer)-elasticsearchMaxBuffer:]
+	}
+	out.mu.Unlock()
+	return nil, nil
+}
+
+// frameToDocuments converts each frame row into a document. Documents contain
+// frame field values by field name, @timestamp from the first time field and
+// labels of fields.
+func (out *ElasticsearchFrameOutput) frameToDocuments(vars Vars, frame *data.Frame) ([]elasticsearchDocument, error) {
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	now := out.now()
+	index, err := out.indexName(vars, frame.Name, now)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for _, f := range frame.Fields {
+		for k, v := range f.Labels {
+			labels[k] = v
+		}
+	}
+
+	docs := make([]elasticsearchDocument, 0, numRows)
+	for row := 0; row < numRows; row++ {
+		doc := make(map[string]any, len(frame.Fields)+2)
+		var timestamp *time.Time
+		for _, f := range frame.Fields {
+			v, ok := f.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			switch val := v.(type) {
+			case time.Time:
+				if timestamp == nil {
+					timestamp = &val
+				}
+				v = val.Format(time.RFC3339Nano)
+			case float64:
+				if math.IsNaN(val) || math.IsInf(val, 0) {
+					continue
+				}
+			case float32:
+				if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+					continue
+				}
+			}
+			doc[f.Name] = v
+		}
+		if _, ok := doc["@timestamp"]; !ok {
+			if timestamp == nil {
+				timestamp = &now
+			}
+			doc["@timestamp"] = timestamp.Format(time.RFC3339Nano)
+		}
+		if _, ok := doc["labels"]; !ok && len(labels) > 0 {
+			doc["labels"] = labels
+		}
+		source, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding Elasticsearch document: %w", err)
+		}
+		docs = append(docs, elasticsearchDocument{index: index, source: source})
+	}
+	return docs, nil
+}
+
+// Close stops periodic flushing, buffered documents are sent once more in background.
+func (out *ElasticsearchFrameOutput) Close() error {
+	out.closeOnce.Do(func() { close(out.done) })
+	return nil
+}
+
+func (out *ElasticsearchFrameOutput) flushPeriodically() {
+	ticker := time.NewTicker(elasticsearchFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			out.flushBuffer()
+		case <-out.done:
+			out.flushBuffer()
+			return
+		}
+	}
+}
+
+func (out *ElasticsearchFrameOutput) flushBuffer() {
+	out.mu.Lock()
+	if len(out.buffer) == 0 {
+		out.mu.Unlock()
+		return
+	}
+	tmpBuffer := make([]elasticsearchDocument, len(out.buffer))
+	copy(tmpBuffer, out.buffer)
+	out.buffer = nil
+	out.mu.Unlock()
+
+	retry, err := out.flush(tmpBuffer)
+	if err != nil {
+		logger.Error("Error flush to Elasticsearch", "error", err)
+	}
+	if len(retry) > 0 {
+		out.mu.Lock()
+		out.buffer = append(retry, out.buffer...)
+		out.mu.Unlock()
+	}
+}
+
+type elasticsearchBulkResponse struct {
+	Errors bool                                     `json:"errors"`
+	Items  []map[string]elasticsearchBulkItemResult `json:"items"`
+}
+
+type elasticsearchBulkItemResult struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error,omitempty"`
+}
+
+// flush sends documents with a single bulk request. Returns documents which should
+// be retried: all of them when the request failed, or ones rejected with a
+// retryable status.
+func (out *ElasticsearchFrameOutput) flush(docs []elasticsearchDocument) ([]elasticsearchDocument, error) {
+	logger.Debug("Elasticsearch flush", "numDocuments", len(docs))
+	var body bytes.Buffer
+	for _, doc := range docs {
+		action, err := json.Marshal(map[string]any{"index": map[string]string{"_index": doc.index}})
+		if err != nil {
+			return nil, err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc.source)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, out.Endpoint+"/_bulk", &body)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing Elasticsearch bulk request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case out.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+out.APIKey)
+	case out.BasicAuth != nil:
+		req.SetBasicAuth(out.BasicAuth.User, out.BasicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return docs, fmt.Errorf("error sending to Elasticsearch: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("Unexpected response code from Elasticsearch endpoint", "code", resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return docs, fmt.Errorf("unexpected response code from Elasticsearch endpoint: %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("unexpected response code from Elasticsearch endpoint: %d", resp.StatusCode)
+	}
+
+	var result elasticsearchBulkResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding Elasticsearch bulk response: %w", err)
+	}
+	if !result.Errors {
+		logger.Debug("Successfully sent to Elasticsearch", "elapsed", time.Since(started))
+		return nil, nil
+	}
+
+	var (
+		retry     []elasticsearchDocument
+		numFailed int
+		firstErr  string
+	)
+	for i, item := range result.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, r := range item {
+			if r.Error == nil {
+				continue
+			}
+			if r.Status == http.StatusTooManyRequests || r.Status >= http.StatusInternalServerError {
+				retry = append(retry, docs[i])
+				continue
+			}
+			numFailed++
+			if firstErr == "" {
+				firstErr = r.Error.Type + ": " + r.Error.Reason
+			}
+		}
+	}
+	if numFailed > 0 {
+		return retry, fmt.Errorf("%d documents rejected by Elasticsearch, first error: %s", numFailed, firstErr)
+	}
+	return retry, nil
+}
+
+func (out *ElasticsearchFrameOutput) indexName(vars Vars, frameName string, now time.Time) (string, error) {
+	index := sanitizeIndexName(out.index.render(vars, frameName, now))
+	if index == "" || index == "." || index == ".." {
+		return "", fmt.Errorf("invalid Elasticsearch index name: %q", index)
+	}
+	return index, nil
+}
+
+// sanitizeIndexName lowercases index name and replaces characters not allowed by
+// Elasticsearch with underscores.
+func sanitizeIndexName(s string) string {
+	s = strings.ToLower(s)
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', '*', '?', '"', '<', '>', '|', ' ', ',', '#', ':':
+			return '_'
+		}
+		return r
+	}, s)
+	return strings.TrimLeft(s, "-_+")
+}
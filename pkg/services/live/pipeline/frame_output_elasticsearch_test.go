@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticsearchFrameOutput_indexName(t *testing.T) {
+	now := time.Date(2021, 3, 17, 13, 45, 10, 0, time.UTC) // Wednesday.
+	vars := Vars{OrgID: 2, Channel: "stream/Test/cpu", Scope: "stream", Namespace: "Test", Path: "cpu"}
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{template: "", expected: "grafana-live-stream_test_cpu-2021.03.17"},
+		{template: "live-{orgId}-{namespace}-{path}", expected: "live-2-test-cpu"},
+		{template: "live-{frame}", expected: "live-frame"},
+		{template: "live-{now/M{yyyy.MM}}", expected: "live-2021.03"},
+		{template: "live-{now-1d/d}", expected: "live-2021.03.16"},
+		{template: "live-{now/w}", expected: "live-2021.03.15"},
+		{template: "live-{now+12h/d{yyyy-MM-dd}}", expected: "live-2021-03-18"},
+		{template: "live-{now/h{yyyy.MM.dd.HH|Europe/Berlin}}", expected: "live-2021.03.17.14"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			out, err := NewElasticsearchFrameOutput("", nil, "", tc.template)
+			require.NoError(t, err)
+			index, err := out.indexName(vars, "frame", now)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, index)
+		})
+	}
+
+	for _, template := range []string{"live-{unknown}", "live-{now/q}", "live-{now/d{yyyy|Unknown/Zone}}"} {
+		_, err := NewElasticsearchFrameOutput("", nil, "", template)
+		require.Error(t, err, template)
+	}
+}
+
+func TestElasticsearchFrameOutput_flush(t *testing.T) {
+	var (
+		lines  []map[string]any
+		header http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/_bulk", r.URL.Path)
+		header = r.Header
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		_, _ = w.Write([]byte(`{"errors":true,"items":[
+			{"index":{"status":201}},
+			{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"busy"}}}
+		]}`))
+	}))
+	defer server.Close()
+
+	out := &ElasticsearchFrameOutput{
+		Endpoint:   server.URL,
+		APIKey:     "secret",
+		httpClient: server.Client(),
+		now:        time.Now,
+	}
+	out.index, _ = parseNameTemplate("live-{path}")
+
+	ts := time.Date(2021, 3, 17, 13, 45, 10, 0, time.UTC)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("value", data.Labels{"host": "a"}, []*float64{floatPtr(1), nil}),
+	)
+	docs, err := out.frameToDocuments(Vars{Path: "cpu"}, frame)
+	require.NoError(t, err)
+
+	retry, err := out.flush(docs)
+	require.NoError(t, err)
+	require.Len(t, retry, 1)
+	require.Equal(t, docs[1], retry[0])
+	require.Equal(t, "ApiKey secret", header.Get("Authorization"))
+	require.Equal(t, "application/x-ndjson", header.Get("Content-Type"))
+
+	require.Equal(t, []map[string]any{
+		{"index": map[string]any{"_index": "live-cpu"}},
+		{
+			"@timestamp": "2021-03-17T13:45:10Z",
+			"time":       "2021-03-17T13:45:10Z",
+			"value":      1.0,
+			"labels":     map[string]any{"host": "a"},
+		},
+		{"index": map[string]any{"_index": "live-cpu"}},
+		{
+			"@timestamp": "2021-03-17T13:45:11Z",
+			"time":       "2021-03-17T13:45:11Z",
+			"labels":     map[string]any{"host": "a"},
+		},
+	}, lines)
+}
+
+func TestElasticsearchFrameOutput_Close(t *testing.T) {
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}}]}`))
+		close(received)
+	}))
+	defer server.Close()
+
+	out, err := NewElasticsearchFrameOutput(server.URL, nil, "", "live")
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test", data.NewField("value", nil, []float64{1})))
+	require.NoError(t, err)
+
+	// Buffered documents are sent on close without waiting for the flush interval.
+	require.NoError(t, out.Close())
+	require.NoError(t, out.Close())
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("buffered documents were not sent on close")
+	}
+}
@@ -0,0 +1,281 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	eventHubsAuthSAS             = "sas"
+	eventHubsAuthClientSecret    = "clientSecret"
+	eventHubsAuthManagedIdentity = "managedIdentity"
+
+	// defaultEventHubsPartitionKey is used when partition key template is not set in config.
+	defaultEventHubsPartitionKey = "{channel}"
+	eventHubsTimeout             = 10 * time.Second
+	eventHubsAPIVersion          = "2014-01"
+	eventHubsScope               = "https://eventhubs.azure.net/.default"
+	// eventHubsSASTokenTTL is a lifetime of generated SAS tokens, tokens are renewed
+	// when less than a minute is left.
+	eventHubsSASTokenTTL = time.Hour
+)
+
+// eventHubsTokenSource returns a value of Authorization header.
+type eventHubsTokenSource interface {
+	token(ctx context.Context) (string, error)
+}
+
+// EventHubsFrameOutput sends frames encoded to JSON as events to an Azure Event Hub with
+// Event Hubs REST API, authenticating with a shared access signature or Azure AD.
+type EventHubsFrameOutput struct {
+	// Endpoint is an Event Hub URL like https://{namespace}.servicebus.windows.net/{eventHub}.
+	Endpoint string
+
+	config       EventHubsOutputConfig
+	partitionKey *nameTemplate
+	tokens       eventHubsTokenSource
+	httpClient   *http.Client
+}
+
+// NewEventHubsFrameOutput creates EventHubsFrameOutput. Namespace, event hub and shared
+// access key are read from a connection string when it's set, otherwise endpoint is used
+// with basic auth user and password as a key name and a key for SAS auth, or a client ID
+// and a secret for Azure AD auth.
+func NewEventHubsFrameOutput(endpoint string, connectionString string, basicAuth *BasicAuth, config EventHubsOutputConfig) (*EventHubsFrameOutput, error) {
+	if config.PartitionKey == "" {
+		config.PartitionKey = defaultEventHubsPartitionKey
+	}
+	partitionKey, err := parseNameTemplate(config.PartitionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyName, key, eventHub string
+	if connectionString != "" {
+		cs, err := parseEventHubsConnectionString(connectionString)
+		if err != nil {
+			return nil, err
+		}
+		endpoint, keyName, key, eventHub = cs.endpoint, cs.keyName, cs.key, cs.entityPath
+	} else if basicAuth != nil {
+		keyName, key = basicAuth.User, basicAuth.Password
+	}
+	if endpoint == "" {
+		return nil, errors.New("event hubs endpoint or connection string required")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event hubs endpoint: %w", err)
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		eventHub = path
+	}
+	if config.EventHub != "" {
+		eventHub = config.EventHub
+	}
+	if eventHub == "" || strings.Contains(eventHub, "/") {
+		return nil, fmt.Errorf("invalid event hub name: %q", eventHub)
+	}
+	u.Path = "/" + eventHub
+
+	out := &EventHubsFrameOutput{
+		Endpoint:     u.String(),
+		config:       config,
+		partitionKey: partitionKey,
+		httpClient:   &http.Client{Timeout: eventHubsTimeout},
+	}
+	switch config.Auth {
+	case "", eventHubsAuthSAS:
+		if keyName == "" || key == "" {
+			return nil, errors.New("shared access key name and key required")
+		}
+		out.tokens = &eventHubsSASTokenSource{resource: out.Endpoint, keyName: keyName, key: key, now: time.Now}
+	case eventHubsAuthClientSecret:
+		if config.TenantID == "" || basicAuth == nil || basicAuth.User == "" {
+			return nil, errors.New("tenant ID, client ID and client secret required")
+		}
+		credential, err := azidentity.NewClientSecretCredential(config.TenantID, basicAuth.User, basicAuth.Password, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating Azure AD credential: %w", err)
+		}
+		out.tokens = &eventHubsAADTokenSource{credential: credential}
+	case eventHubsAuthManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{}
+		if basicAuth != nil && basicAuth.User != "" {
+			options.ID = azidentity.ClientID(basicAuth.User)
+		}
+		credential, err := azidentity.NewManagedIdentityCredential(options)
+		if err != nil {
+			return nil, fmt.Errorf("error creating managed identity credential: %w", err)
+		}
+		out.tokens = &eventHubsAADTokenSource{credential: credential}
+	default:
+		return nil, fmt.Errorf("unsupported event hubs auth: %s", config.Auth)
+	}
+	return out, nil
+}
+
+const FrameOutputTypeEventHubs = "eventHubs"
+
+func (out *EventHubsFrameOutput) Type() string {
+	return FrameOutputTypeEventHubs
+}
+
+// eventHubsEvent is an event in a batch sent with Event Hubs REST API.
+type eventHubsEvent struct {
+	Body             string               `json:"Body"`
+	BrokerProperties eventHubsBrokerProps `json:"BrokerProperties"`
+	UserProperties   map[string]any       `json:"UserProperties,omitempty"`
+}
+
+type eventHubsBrokerProps struct {
+	PartitionKey string `json:"PartitionKey,omitempty"`
+}
+
+func (out *EventHubsFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	properties := map[string]any{
+		"orgId":   vars.OrgID,
+		"channel": vars.Channel,
+	}
+	if vars.MessageID != "" {
+		properties["messageId"] = vars.MessageID
+	}
+	body, err := json.Marshal([]eventHubsEvent{{
+		Body:             string(frameJSON),
+		BrokerProperties: eventHubsBrokerProps{PartitionKey: out.partitionKey.render(vars, frame.Name, time.Now())},
+		UserProperties:   properties,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	token, err := out.tokens.token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting event hubs token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, out.Endpoint+"/messages?api-version="+eventHubsAPIVersion, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.microsoft.servicebus.json")
+	req.Header.Set("Authorization", token)
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending to event hubs: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected event hubs response status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil, nil
+}
+
+// eventHubsConnectionString is a parsed connection string like
+// Endpoint=sb://{namespace}.servicebus.windows.net/;SharedAccessKeyName=...;SharedAccessKey=...;EntityPath=...
+type eventHubsConnectionString struct {
+	endpoint   string
+	keyName    string
+	key        string
+	entityPath string
+}
+
+func parseEventHubsConnectionString(s string) (eventHubsConnectionString, error) {
+	cs := eventHubsConnectionString{}
+	for _, part := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(k) {
+		case "endpoint":
+			u, err := url.Parse(v)
+			if err != nil {
+				return cs, fmt.Errorf("invalid connection string endpoint: %w", err)
+			}
+			if u.Scheme == "sb" {
+				u.Scheme = "https"
+			}
+			cs.endpoint = u.String()
+		case "sharedaccesskeyname":
+			cs.keyName = v
+		case "sharedaccesskey":
+			cs.key = v
+		case "entitypath":
+			cs.entityPath = v
+		}
+	}
+	if cs.endpoint == "" || cs.keyName == "" || cs.key == "" {
+		return cs, errors.New("connection string must contain Endpoint, SharedAccessKeyName and SharedAccessKey")
+	}
+	return cs, nil
+}
+
+// eventHubsSASTokenSource generates shared access signature tokens of a resource.
+type eventHubsSASTokenSource struct {
+	resource string
+	keyName  string
+	key      string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	current string
+	expiry  time.Time
+}
+
+func (s *eventHubsSASTokenSource) token(_ context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.current != "" && now.Add(time.Minute).Before(s.expiry) {
+		return s.current, nil
+	}
+	s.expiry = now.Add(eventHubsSASTokenTTL)
+	s.current = eventHubsSASToken(s.resource, s.keyName, s.key, s.expiry)
+	return s.current, nil
+}
+
+func eventHubsSASToken(resource string, keyName string, key string, expiry time.Time) string {
+	encodedResource := url.QueryEscape(strings.ToLower(resource))
+	se := strconv.FormatInt(expiry.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	_, _ = mac.Write([]byte(encodedResource + "\n" + se))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s",
+		encodedResource, url.QueryEscape(signature), se, url.QueryEscape(keyName))
+}
+
+// eventHubsAADTokenSource gets Azure AD tokens, credentials cache tokens until they expire.
+type eventHubsAADTokenSource struct {
+	credential azcore.TokenCredential
+}
+
+func (s *eventHubsAADTokenSource) token(ctx context.Context) (string, error) {
+	t, err := s.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{eventHubsScope}})
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + t.Token, nil
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHubsFrameOutput_OutputFrame(t *testing.T) {
+	type request struct {
+		path          string
+		authorization string
+		contentType   string
+		events        []eventHubsEvent
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var events []eventHubsEvent
+		require.NoError(t, json.Unmarshal(body, &events))
+		requests <- request{
+			path:          r.URL.Path + "?" + r.URL.RawQuery,
+			authorization: r.Header.Get("Authorization"),
+			contentType:   r.Header.Get("Content-Type"),
+			events:        events,
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	connectionString := "Endpoint=" + server.URL + "/;SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0;EntityPath=hub"
+	out, err := NewEventHubsFrameOutput("", connectionString, nil, EventHubsOutputConfig{
+		PartitionKey: "{orgId}/{channel}",
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{
+		OrgID:   1,
+		Channel: "stream/test/cpu",
+	}, frame)
+	require.NoError(t, err)
+
+	req := <-requests
+	require.Equal(t, "/hub/messages?api-version=2014-01", req.path)
+	require.Equal(t, "application/vnd.microsoft.servicebus.json", req.contentType)
+	require.True(t, strings.HasPrefix(req.authorization, "SharedAccessSignature sr="))
+	require.Contains(t, req.authorization, "skn=send")
+	require.Len(t, req.events, 1)
+	require.Equal(t, "1/stream/test/cpu", req.events[0].BrokerProperties.PartitionKey)
+	require.Equal(t, "stream/test/cpu", req.events[0].UserProperties["channel"])
+
+	decoded := &data.Frame{}
+	require.NoError(t, json.Unmarshal([]byte(req.events[0].Body), decoded))
+	require.Equal(t, "test", decoded.Name)
+}
+
+func TestEventHubsFrameOutput_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("invalid signature"))
+	}))
+	defer server.Close()
+
+	out, err := NewEventHubsFrameOutput(server.URL+"/hub", "", &BasicAuth{User: "send", Password: "secret"}, EventHubsOutputConfig{})
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, data.NewFrame("test"))
+	require.ErrorContains(t, err, "invalid signature")
+}
+
+func TestNewEventHubsFrameOutput_Validation(t *testing.T) {
+	_, err := NewEventHubsFrameOutput("https://ns.servicebus.windows.net", "", &BasicAuth{User: "send", Password: "secret"}, EventHubsOutputConfig{})
+	require.ErrorContains(t, err, "invalid event hub name")
+
+	_, err = NewEventHubsFrameOutput("https://ns.servicebus.windows.net/hub", "", nil, EventHubsOutputConfig{})
+	require.ErrorContains(t, err, "shared access key name and key required")
+
+	_, err = NewEventHubsFrameOutput("https://ns.servicebus.windows.net/hub", "", nil, EventHubsOutputConfig{Auth: eventHubsAuthClientSecret})
+	require.ErrorContains(t, err, "tenant ID")
+
+	_, err = NewEventHubsFrameOutput("https://ns.servicebus.windows.net/hub", "", nil, EventHubsOutputConfig{Auth: "unknown"})
+	require.ErrorContains(t, err, "unsupported event hubs auth")
+
+	out, err := NewEventHubsFrameOutput("https://ns.servicebus.windows.net/hub", "", &BasicAuth{User: "send", Password: "secret"}, EventHubsOutputConfig{EventHub: "other"})
+	require.NoError(t, err)
+	require.Equal(t, "https://ns.servicebus.windows.net/other", out.Endpoint)
+}
+
+func TestParseEventHubsConnectionString(t *testing.T) {
+	cs, err := parseEventHubsConnectionString("Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=abc=;EntityPath=hub")
+	require.NoError(t, err)
+	require.Equal(t, "https://ns.servicebus.windows.net/", cs.endpoint)
+	require.Equal(t, "send", cs.keyName)
+	require.Equal(t, "abc=", cs.key)
+	require.Equal(t, "hub", cs.entityPath)
+
+	_, err = parseEventHubsConnectionString("Endpoint=sb://ns.servicebus.windows.net/")
+	require.Error(t, err)
+}
+
+func TestEventHubsSASTokenSource(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := &eventHubsSASTokenSource{
+		resource: "https://ns.servicebus.windows.net/hub",
+		keyName:  "send",
+		key:      "secret",
+		now:      func() time.Time { return now },
+	}
+	first, err := s.token(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, first, "se=1700003600")
+
+	now = now.Add(30 * time.Minute)
+	second, err := s.token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	now = now.Add(30 * time.Minute)
+	third, err := s.token(context.Background())
+	require.NoError(t, err)
+	require.NotEqual(t, first, third)
+}
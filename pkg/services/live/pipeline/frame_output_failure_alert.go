@@ -0,0 +1,391 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultFailureAlertRate       = 0.5
+	defaultFailureAlertWindow     = time.Minute
+	defaultFailureAlertMinSamples = 10
+	defaultBreakerFailures        = 5
+	defaultBreakerOpen            = 30 * time.Second
+	failureAlertNotifyTimeout     = 10 * time.Second
+)
+
+const (
+	failureAlertStateFiring   = "firing"
+	failureAlertStateResolved = "resolved"
+
+	failureAlertReasonRate    = "failureRate"
+	failureAlertReasonBreaker = "circuitBreaker"
+)
+
+// ErrCircuitBreakerOpen is returned for frames not passed to an output while its
+// circuit breaker is open.
+var ErrCircuitBreakerOpen = errors.New("circuit breaker is open")
+
+// FailureAlertOutput passes frames to a child outputter and tracks its failures. An
+// alert is sent to a webhook and/or an alerting contact point when a failure rate
+// within a sliding window crosses a threshold or a circuit breaker opens, and resolved
+// once the output recovers. Failures are tracked per channel in frame storage, so
+// firing alerts and open breakers survive rule rebuilds.
+type FailureAlertOutput struct {
+	Outputter FrameOutputter
+
+	frameStorage  FrameGetSetter
+	config        FailureAlertOutputConfig
+	window        time.Duration
+	openDuration  time.Duration
+	webhook       *failureAlertWebhook
+	contactPoints ContactPointNotifier
+	now           func() time.Time
+	// notify sends events, notifications are sent in background by default.
+	notify func(events []failureAlertEvent)
+
+	mu sync.Mutex
+}
+
+// failureAlertState is kept in frame storage between output calls.
+type failureAlertState struct {
+	Buckets []failureBucket `json:"buckets,omitempty"`
+	// RateFiring is true while a failure rate alert is firing.
+	RateFiring          bool      `json:"rateFiring,omitempty"`
+	ConsecutiveFailures int       `json:"consecutiveFailures,omitempty"`
+	BreakerOpen         bool      `json:"breakerOpen,omitempty"`
+	OpenUntil           time.Time `json:"openUntil"`
+	// Probing is true while a single frame is passed to an output with open breaker.
+	Probing bool `json:"probing,omitempty"`
+}
+
+// failureBucket counts output calls within a second.
+type failureBucket struct {
+	Second int64 `json:"second"`
+	Total  int   `json:"total"`
+	Failed int   `json:"failed"`
+}
+
+// failureAlertEvent is a body sent to a webhook.
+type failureAlertEvent struct {
+	State       string    `json:"state"`
+	Reason      string    `json:"reason"`
+	OrgID       int64     `json:"orgId"`
+	Channel     string    `json:"channel"`
+	Output      string    `json:"output"`
+	FailureRate float64   `json:"failureRate"`
+	Failures    int       `json:"failures"`
+	Samples     int       `json:"samples"`
+	Error       string    `json:"error,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+type failureAlertWebhook struct {
+	endpoint   string
+	basicAuth  *BasicAuth
+	token      string
+	httpClient *http.Client
+}
+
+func NewFailureAlertOutput(frameStorage FrameGetSetter, outputter FrameOutputter, webhookEndpoint string, basicAuth *BasicAuth, token string, contactPoints ContactPointNotifier, config FailureAlertOutputConfig) (*FailureAlertOutput, error) {
+	if config.FailureRate < 0 || config.FailureRate > 1 {
+		return nil, fmt.Errorf("failure rate must be between 0 and 1, got %v", config.FailureRate)
+	}
+	if config.WindowMilliseconds < 0 || config.MinSamples < 0 {
+		return nil, errors.New("failure alert settings can't be negative")
+	}
+	if config.WebhookUID == "" && config.ContactPoint == "" {
+		return nil, errors.New("webhook or contact point is required")
+	}
+	if config.ContactPoint != "" && contactPoints == nil {
+		return nil, errors.New("alerting is not available")
+	}
+	if config.FailureRate == 0 {
+		config.FailureRate = defaultFailureAlertRate
+	}
+	if config.MinSamples == 0 {
+		config.MinSamples = defaultFailureAlertMinSamples
+	}
+	out := &FailureAlertOutput{
+		Outputter:     outputter,
+		frameStorage:  frameStorage,
+		config:        config,
+		window:        time.Duration(config.WindowMilliseconds) * time.Millisecond,
+		contactPoints: contactPoints,
+		now:           time.Now,
+	}
+	if out.window == 0 {
+		out.window = defaultFailureAlertWindow
+	}
+	if cb := config.CircuitBreaker; cb != nil {
+		if cb.ConsecutiveFailures < 0 || cb.OpenMilliseconds < 0 {
+			return nil, errors.New("circuit breaker settings can't be negative")
+		}
+		if cb.ConsecutiveFailures == 0 {
+			out.config.CircuitBreaker = &CircuitBreakerConfig{ConsecutiveFailures: defaultBreakerFailures, OpenMilliseconds: cb.OpenMilliseconds}
+		}
+		out.openDuration = time.Duration(cb.OpenMilliseconds) * time.Millisecond
+		if out.openDuration == 0 {
+			out.openDuration = defaultBreakerOpen
+		}
+	}
+	if webhookEndpoint != "" {
+		out.webhook = &failureAlertWebhook{
+			endpoint:   webhookEndpoint,
+			basicAuth:  basicAuth,
+			token:      token,
+			httpClient: &http.Client{Timeout: defaultWebhookTimeout},
+		}
+	}
+	out.notify = func(events []failureAlertEvent) {
+		go out.sendEvents(events)
+	}
+	return out, nil
+}
+
+const FrameOutputTypeFailureAlert = "failureAlert"
+
+func (out *FailureAlertOutput) Type() string {
+	return FrameOutputTypeFailureAlert
+}
+
+func (out *FailureAlertOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	allowed, err := out.allow(vars)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrCircuitBreakerOpen
+	}
+	frames, err := out.Outputter.OutputFrame(ctx, vars, frame)
+	events, recordErr := out.record(vars, err)
+	if recordErr != nil {
+		logger.Error("Error saving output failure alert state", "channel", vars.Channel, "error", recordErr)
+	}
+	if len(events) > 0 {
+		out.notify(events)
+	}
+	return frames, err
+}
+
+// failureAlertStateKey is appended to a channel to keep failure alert state in frame
+// storage, separately from frames published into the channel.
+const failureAlertStateKey = "#failureAlert"
+
+func (out *FailureAlertOutput) loadState(vars Vars) (*failureAlertState, error) {
+	st := &failureAlertState{}
+	frame, ok, err := out.frameStorage.Get(vars.OrgID, vars.Channel+failureAlertStateKey)
+	if err != nil || !ok || frame.Rows() == 0 || len(frame.Fields) != 1 {
+		return st, err
+	}
+	value, _ := frame.Fields[0].At(0).(json.RawMessage)
+	if err := json.Unmarshal(value, st); err != nil {
+		return &failureAlertState{}, err
+	}
+	return st, nil
+}
+
+func (out *FailureAlertOutput) saveState(vars Vars, st *failureAlertState) error {
+	value, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return out.frameStorage.Set(vars.OrgID, vars.Channel+failureAlertStateKey, data.NewFrame("failureAlert",
+		data.NewField("state", nil, []json.RawMessage{value}),
+	))
+}
+
+// allow reports whether a frame can be passed to an output. After an open period a
+// single frame is passed to check the output.
+func (out *FailureAlertOutput) allow(vars Vars) (bool, error) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	st, err := out.loadState(vars)
+	if err != nil {
+		return false, err
+	}
+	if !st.BreakerOpen {
+		return true, nil
+	}
+	if st.Probing || out.now().Before(st.OpenUntil) {
+		return false, nil
+	}
+	st.Probing = true
+	return true, out.saveState(vars, st)
+}
+
+// record counts an output call and returns alert events on state changes.
+func (out *FailureAlertOutput) record(vars Vars, outputErr error) ([]failureAlertEvent, error) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	st, err := out.loadState(vars)
+	if err != nil {
+		return nil, err
+	}
+	events := out.recordState(st, vars, outputErr)
+	return events, out.saveState(vars, st)
+}
+
+func (out *FailureAlertOutput) recordState(st *failureAlertState, vars Vars, outputErr error) []failureAlertEvent {
+	now := out.now()
+	st.addSample(now, outputErr != nil)
+	failed, total := st.windowCounts(now, out.window)
+	var rate float64
+	if total > 0 {
+		rate = float64(failed) / float64(total)
+	}
+	newEvent := func(state, reason string) failureAlertEvent {
+		e := failureAlertEvent{
+			State:       state,
+			Reason:      reason,
+			OrgID:       vars.OrgID,
+			Channel:     vars.Channel,
+			Output:      out.Outputter.Type(),
+			FailureRate: rate,
+			Failures:    failed,
+			Samples:     total,
+			Time:        now,
+		}
+		if outputErr != nil {
+			e.Error = outputErr.Error()
+		}
+		return e
+	}
+
+	var events []failureAlertEvent
+	if !st.RateFiring && total >= out.config.MinSamples && rate >= out.config.FailureRate {
+		st.RateFiring = true
+		events = append(events, newEvent(failureAlertStateFiring, failureAlertReasonRate))
+	} else if st.RateFiring && rate < out.config.FailureRate {
+		st.RateFiring = false
+		events = append(events, newEvent(failureAlertStateResolved, failureAlertReasonRate))
+	}
+
+	if out.config.CircuitBreaker == nil {
+		return events
+	}
+	st.Probing = false
+	if outputErr == nil {
+		st.ConsecutiveFailures = 0
+		if st.BreakerOpen {
+			st.BreakerOpen = false
+			events = append(events, newEvent(failureAlertStateResolved, failureAlertReasonBreaker))
+		}
+		return events
+	}
+	st.ConsecutiveFailures++
+	if st.BreakerOpen {
+		// Output is still failing after an open period.
+		st.OpenUntil = now.Add(out.openDuration)
+	} else if st.ConsecutiveFailures >= out.config.CircuitBreaker.ConsecutiveFailures {
+		st.BreakerOpen = true
+		st.OpenUntil = now.Add(out.openDuration)
+		events = append(events, newEvent(failureAlertStateFiring, failureAlertReasonBreaker))
+	}
+	return events
+}
+
+func (st *failureAlertState) addSample(now time.Time, failed bool) {
+	second := now.Unix()
+	if n := len(st.Buckets); n == 0 || st.Buckets[n-1].Second != second {
+		st.Buckets = append(st.Buckets, failureBucket{Second: second})
+	}
+	b := &st.Buckets[len(st.Buckets)-1]
+	b.Total++
+	if failed {
+		b.Failed++
+	}
+}
+
+// windowCounts drops buckets outside a window and returns counts of the rest.
+func (st *failureAlertState) windowCounts(now time.Time, window time.Duration) (failed int, total int) {
+	from := now.Add(-window).Unix()
+	i := 0
+	for i < len(st.Buckets) && st.Buckets[i].Second <= from {
+		i++
+	}
+	st.Buckets = st.Buckets[i:]
+	for _, b := range st.Buckets {
+		failed += b.Failed
+		total += b.Total
+	}
+	return failed, total
+}
+
+func (out *FailureAlertOutput) sendEvents(events []failureAlertEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), failureAlertNotifyTimeout)
+	defer cancel()
+	for _, e := range events {
+		if out.webhook != nil {
+			if err := out.webhook.send(ctx, e); err != nil {
+				logger.Error("Error sending output failure alert to webhook", "channel", e.Channel, "output", e.Output, "error", err)
+			}
+		}
+		if out.config.ContactPoint != "" {
+			if err := out.sendToContactPoint(ctx, e); err != nil {
+				logger.Error("Error sending output failure alert to contact point", "channel", e.Channel, "contactPoint", out.config.ContactPoint, "error", err)
+			}
+		}
+	}
+}
+
+func (out *FailureAlertOutput) sendToContactPoint(ctx context.Context, e failureAlertEvent) error {
+	labels := map[string]string{
+		"alertname": "LiveOutputFailure",
+		"channel":   e.Channel,
+		"output":    e.Output,
+		"reason":    e.Reason,
+		"state":     e.State,
+	}
+	var summary string
+	switch {
+	case e.State == failureAlertStateResolved:
+		summary = fmt.Sprintf("Live output %s of %s recovered", e.Output, e.Channel)
+	case e.Reason == failureAlertReasonBreaker:
+		summary = fmt.Sprintf("Circuit breaker of live output %s of %s is open", e.Output, e.Channel)
+	default:
+		summary = fmt.Sprintf("Live output %s of %s fails %d of %d frames", e.Output, e.Channel, e.Failures, e.Samples)
+	}
+	annotations := map[string]string{"summary": summary}
+	if e.Error != "" {
+		annotations["description"] = e.Error
+	}
+	return out.contactPoints.NotifyContactPoint(ctx, e.OrgID, out.config.ContactPoint, labels, annotations)
+}
+
+func (w *failureAlertWebhook) send(ctx context.Context, e failureAlertEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	} else if w.basicAuth != nil {
+		req.SetBasicAuth(w.basicAuth.User, w.basicAuth.Password)
+	}
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected webhook response status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
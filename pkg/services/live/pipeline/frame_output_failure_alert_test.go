@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testFailingOutput struct {
+	err   error
+	calls int
+}
+
+func (o *testFailingOutput) Type() string {
+	return "test"
+}
+
+func (o *testFailingOutput) OutputFrame(_ context.Context, _ Vars, _ *data.Frame) ([]*ChannelFrame, error) {
+	o.calls++
+	return nil, o.err
+}
+
+func TestFailureAlertOutput_FailureRate(t *testing.T) {
+	events := make(chan failureAlertEvent, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var e failureAlertEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		events <- e
+	}))
+	defer server.Close()
+
+	child := &testFailingOutput{}
+	out, err := NewFailureAlertOutput(NewFrameStorage(), child, server.URL, nil, "secret", nil, FailureAlertOutputConfig{
+		WebhookUID: "hook",
+		MinSamples: 4,
+	})
+	require.NoError(t, err)
+	now := time.Unix(100, 0)
+	out.now = func() time.Time { return now }
+
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu"}
+	frame := data.NewFrame("test")
+	for i := 0; i < 2; i++ {
+		_, err = out.OutputFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+	}
+	child.err = errors.New("connection refused")
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.Error(t, err)
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.Error(t, err)
+
+	e := <-events
+	require.Equal(t, failureAlertStateFiring, e.State)
+	require.Equal(t, failureAlertReasonRate, e.Reason)
+	require.Equal(t, "stream/test/cpu", e.Channel)
+	require.Equal(t, 2, e.Failures)
+	require.Equal(t, 4, e.Samples)
+	require.Equal(t, "connection refused", e.Error)
+
+	// Failures leave the window, the alert is resolved with the next success.
+	child.err = nil
+	now = now.Add(2 * time.Minute)
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	e = <-events
+	require.Equal(t, failureAlertStateResolved, e.State)
+	require.Equal(t, 1, e.Samples)
+}
+
+func TestFailureAlertOutput_CircuitBreaker(t *testing.T) {
+	child := &testFailingOutput{err: errors.New("unavailable")}
+	notifier := &testContactPointNotifier{}
+	out, err := NewFailureAlertOutput(NewFrameStorage(), child, "", nil, "", notifier, FailureAlertOutputConfig{
+		ContactPoint:   "ops",
+		MinSamples:     100,
+		CircuitBreaker: &CircuitBreakerConfig{ConsecutiveFailures: 2, OpenMilliseconds: 1000},
+	})
+	require.NoError(t, err)
+	now := time.Unix(100, 0)
+	out.now = func() time.Time { return now }
+	out.notify = out.sendEvents
+
+	frame := data.NewFrame("test")
+	for i := 0; i < 2; i++ {
+		_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+		require.ErrorContains(t, err, "unavailable")
+	}
+	require.Len(t, notifier.labels, 1)
+	require.Equal(t, failureAlertReasonBreaker, notifier.labels[0]["reason"])
+	require.Equal(t, failureAlertStateFiring, notifier.labels[0]["state"])
+
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.ErrorIs(t, err, ErrCircuitBreakerOpen)
+	require.Equal(t, 2, child.calls)
+
+	// A frame is passed to the output after an open period, breaker stays open on failure.
+	now = now.Add(time.Second)
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.ErrorContains(t, err, "unavailable")
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.ErrorIs(t, err, ErrCircuitBreakerOpen)
+	require.Equal(t, 3, child.calls)
+
+	now = now.Add(time.Second)
+	child.err = nil
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, notifier.labels, 2)
+	require.Equal(t, failureAlertStateResolved, notifier.labels[1]["state"])
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+}
+
+func TestFailureAlertOutput_rebuild(t *testing.T) {
+	frameStorage := NewFrameStorage()
+	child := &testFailingOutput{err: errors.New("unavailable")}
+	notifier := &testContactPointNotifier{}
+	now := time.Unix(100, 0)
+	newOutput := func() *FailureAlertOutput {
+		out, err := NewFailureAlertOutput(frameStorage, child, "", nil, "", notifier, FailureAlertOutputConfig{
+			ContactPoint:   "ops",
+			MinSamples:     2,
+			CircuitBreaker: &CircuitBreakerConfig{ConsecutiveFailures: 2, OpenMilliseconds: 1000},
+		})
+		require.NoError(t, err)
+		out.now = func() time.Time { return now }
+		out.notify = out.sendEvents
+		return out
+	}
+
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu"}
+	frame := data.NewFrame("test")
+	out := newOutput()
+	for i := 0; i < 2; i++ {
+		_, err := out.OutputFrame(context.Background(), vars, frame)
+		require.ErrorContains(t, err, "unavailable")
+	}
+	// Failure rate and circuit breaker alerts are firing.
+	require.Len(t, notifier.labels, 2)
+
+	// Rule is rebuilt, the breaker stays open and no duplicate alerts are sent.
+	out = newOutput()
+	_, err := out.OutputFrame(context.Background(), vars, frame)
+	require.ErrorIs(t, err, ErrCircuitBreakerOpen)
+	require.Equal(t, 2, child.calls)
+	require.Len(t, notifier.labels, 2)
+
+	// Alerts are resolved once the output recovers.
+	out = newOutput()
+	child.err = nil
+	now = now.Add(2 * time.Minute)
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Len(t, notifier.labels, 4)
+	require.Equal(t, failureAlertStateResolved, notifier.labels[2]["state"])
+	require.Equal(t, failureAlertStateResolved, notifier.labels[3]["state"])
+}
+
+func TestNewFailureAlertOutput_Invalid(t *testing.T) {
+	_, err := NewFailureAlertOutput(NewFrameStorage(), &testFailingOutput{}, "", nil, "", nil, FailureAlertOutputConfig{})
+	require.ErrorContains(t, err, "webhook or contact point is required")
+	_, err = NewFailureAlertOutput(NewFrameStorage(), &testFailingOutput{}, "", nil, "", nil, FailureAlertOutputConfig{ContactPoint: "ops"})
+	require.ErrorContains(t, err, "alerting is not available")
+	_, err = NewFailureAlertOutput(NewFrameStorage(), &testFailingOutput{}, "", nil, "", nil, FailureAlertOutputConfig{WebhookUID: "x", FailureRate: 2})
+	require.Error(t, err)
+}
@@ -0,0 +1,285 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// defaultFilePath is used when path template is not set in config.
+	defaultFilePath         = "{orgId}/{channel}"
+	defaultFileMaxSizeBytes = 64 * 1024 * 1024
+	defaultFileMaxFiles     = 10
+
+	fileActiveName   = "frames.ndjson"
+	fileRotatedName  = "frames-"
+	fileNameExt      = ".ndjson"
+	fileDirPerm      = 0750
+	fileWritePerm    = 0640
+	fileOpenFlags    = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	fileMaxPathDepth = 32
+)
+
+// FileFrameOutput appends frames as JSON lines to local files, one directory per
+// rendered path template. An active file is rotated when it exceeds max size or
+// rotation interval, and rotated files beyond retention are removed.
+type FileFrameOutput struct {
+	dir    string
+	config FileOutputConfig
+	path   *nameTemplate
+	now    func() time.Time
+
+	mu     sync.Mutex
+	files  map[string]*rotatingFile
+	closed bool
+}
+
+// activeFiles are files shared by outputs writing to the same directory, so active
+// files stay open and keep their rotation time when rules are rebuilt.
+var activeFiles sharedClients[*rotatingFile]
+
+// rotatingFile is an active file of a directory, file is opened on write and is nil
+// after rotation.
+type rotatingFile struct {
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// NewFileFrameOutput creates FileFrameOutput writing into files under dir.
+func NewFileFrameOutput(dir string, config FileOutputConfig) (*FileFrameOutput, error) {
+	if dir == "" {
+		return nil, errors.New("file output directory is not configured")
+	}
+	if config.Path == "" {
+		config.Path = defaultFilePath
+	}
+	path, err := parseNameTemplate(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxSizeBytes <= 0 {
+		config.MaxSizeBytes = defaultFileMaxSizeBytes
+	}
+	if config.MaxFiles <= 0 {
+		config.MaxFiles = defaultFileMaxFiles
+	}
+	return &FileFrameOutput{
+		dir:    dir,
+		config: config,
+		path:   path,
+		now:    time.Now,
+		files:  map[string]*rotatingFile{},
+	}, nil
+}
+
+const FrameOutputTypeFile = "file"
+
+func (out *FileFrameOutput) Type() string {
+	return FrameOutputTypeFile
+}
+
+// Close releases active files, they are closed when no other output writes to them.
+func (out *FileFrameOutput) Close() error {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	out.closed = true
+	for dir := range out.files {
+		activeFiles.release(dir)
+	}
+	out.files = map[string]*rotatingFile{}
+	return nil
+}
+
+func (out *FileFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if frame.Rows() == 0 {
+		return nil, nil
+	}
+	dir, err := out.resolveDir(out.path.render(vars, frame.Name, out.now()))
+	if err != nil {
+		return nil, err
+	}
+	body, err := framesToNDJSON([]*data.Frame{frame})
+	if err != nil {
+		return nil, err
+	}
+
+	f, release, err := out.sharedFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := out.activeFile(dir, f, int64(len(body))); err != nil {
+		return nil, err
+	}
+	n, err := f.file.Write(body)
+	f.size += int64(n)
+	if err != nil {
+		return nil, fmt.Errorf("error writing frame to file: %w", err)
+	}
+	return nil, nil
+}
+
+// resolveDir returns an absolute directory for a rendered path, paths escaping
+// output directory are rejected.
+func (out *FileFrameOutput) resolveDir(rendered string) (string, error) {
+	rendered = strings.Trim(rendered, "/")
+	if rendered == "" || strings.Count(rendered, "/") >= fileMaxPathDepth {
+		return "", fmt.Errorf("invalid file output path: %q", rendered)
+	}
+	dir := filepath.Join(out.dir, filepath.FromSlash(rendered))
+	rel, err := filepath.Rel(out.dir, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file output path: %q", rendered)
+	}
+	return dir, nil
+}
+
+// sharedFile returns a file of a directory shared with other outputs. Files are kept
+// until the output is closed, frames written after close release the file at once.
+func (out *FileFrameOutput) sharedFile(dir string) (*rotatingFile, func(), error) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if f, ok := out.files[dir]; ok {
+		return f, func() {}, nil
+	}
+	f, err := activeFiles.acquire(dir, func() (*rotatingFile, error) {
+		return &rotatingFile{}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if out.closed {
+		return f, func() { activeFiles.release(dir) }, nil
+	}
+	out.files[dir] = f
+	return f, func() {}, nil
+}
+
+// activeFile opens an active file in dir, rotating it if writing next bytes exceeds
+// max size or the file is older than rotation interval. Must be called with file
+// lock held.
+func (out *FileFrameOutput) activeFile(dir string, f *rotatingFile, next int64) error {
+	now := out.now()
+	if f.file != nil && out.needsRotation(f, next, now) {
+		if err := out.rotate(dir, f, now); err != nil {
+			return err
+		}
+	}
+	if f.file != nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, fileDirPerm); err != nil {
+		return fmt.Errorf("error creating file output directory: %w", err)
+	}
+	// Path is constructed from the configured output directory and checked in resolveDir.
+	// nolint:gosec
+	file, err := os.OpenFile(filepath.Join(dir, fileActiveName), fileOpenFlags, fileWritePerm)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	f.file, f.size, f.openedAt = file, info.Size(), now
+	if f.size > 0 {
+		// A file left from a previous run is rotated by its modification time, as
+		// the creation time is not available.
+		f.openedAt = info.ModTime()
+		if out.needsRotation(f, next, now) {
+			return out.activeFile(dir, f, next)
+		}
+	}
+	return nil
+}
+
+func (out *FileFrameOutput) needsRotation(f *rotatingFile, next int64, now time.Time) bool {
+	if f.size > 0 && f.size+next > out.config.MaxSizeBytes {
+		return true
+	}
+	interval := time.Duration(out.config.RotationIntervalMilliseconds) * time.Millisecond
+	return interval > 0 && now.Sub(f.openedAt) >= interval
+}
+
+// rotate renames the active file to a timestamped name and applies retention.
+func (out *FileFrameOutput) rotate(dir string, f *rotatingFile, now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		logger.Warn("Error closing rotated file", "dir", dir, "error", err)
+	}
+	f.file = nil
+	if f.size == 0 {
+		return nil
+	}
+	f.size = 0
+	rotated := filepath.Join(dir, fileRotatedName+strconv.FormatInt(now.UnixMilli(), 10)+fileNameExt)
+	if err := os.Rename(filepath.Join(dir, fileActiveName), rotated); err != nil {
+		return fmt.Errorf("error rotating file: %w", err)
+	}
+	out.applyRetention(dir, now)
+	return nil
+}
+
+// applyRetention removes rotated files beyond max files count or older than max age.
+func (out *FileFrameOutput) applyRetention(dir string, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logger.Warn("Error reading file output directory", "dir", dir, "error", err)
+		return
+	}
+	type rotatedFile struct {
+		name string
+		ts   int64
+	}
+	var rotated []rotatedFile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, fileRotatedName) || !strings.HasSuffix(name, fileNameExt) {
+			continue
+		}
+		ts, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(name, fileRotatedName), fileNameExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		rotated = append(rotated, rotatedFile{name: name, ts: ts})
+	}
+	// Newest first.
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotated[i].ts > rotated[j].ts
+	})
+	maxAge := time.Duration(out.config.MaxAgeMilliseconds) * time.Millisecond
+	for i, r := range rotated {
+		expired := maxAge > 0 && now.Sub(time.UnixMilli(r.ts)) > maxAge
+		if i < out.config.MaxFiles && !expired {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, r.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("Error removing rotated file", "dir", dir, "file", r.name, "error", err)
+		}
+	}
+}
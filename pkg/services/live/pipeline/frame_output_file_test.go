@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func readDirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFileFrameOutput_OutputFrame(t *testing.T) {
+	dir := t.TempDir()
+	out, err := NewFileFrameOutput(dir, FileOutputConfig{})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", data.Labels{"host": "a"}, []float64{1, 2}))
+	for i := 0; i < 2; i++ {
+		_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+		require.NoError(t, err)
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, "1", "stream", "test", "cpu", fileActiveName))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 4)
+	require.JSONEq(t, `{"value":1,"labels":{"host":"a"}}`, lines[0])
+}
+
+func TestFileFrameOutput_rotation(t *testing.T) {
+	dir := t.TempDir()
+	out, err := NewFileFrameOutput(dir, FileOutputConfig{
+		Path:                         "{path}",
+		MaxSizeBytes:                 20,
+		RotationIntervalMilliseconds: time.Minute.Milliseconds(),
+		MaxFiles:                     2,
+	})
+	require.NoError(t, err)
+	now := time.Date(2021, 9, 14, 10, 0, 0, 0, time.UTC)
+	out.now = func() time.Time { return now }
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}
+	output := func() {
+		_, err := out.OutputFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+
+	// Each line is 12 bytes, so the second write rotates by size.
+	output()
+	output()
+	require.Equal(t, []string{"frames-1631613601000.ndjson", fileActiveName}, readDirNames(t, filepath.Join(dir, "cpu")))
+
+	// Rotation by time.
+	now = now.Add(time.Minute)
+	output()
+	require.Equal(t, []string{"frames-1631613601000.ndjson", "frames-1631613662000.ndjson", fileActiveName}, readDirNames(t, filepath.Join(dir, "cpu")))
+
+	// Oldest rotated file removed by retention.
+	output()
+	require.Equal(t, []string{"frames-1631613662000.ndjson", "frames-1631613663000.ndjson", fileActiveName}, readDirNames(t, filepath.Join(dir, "cpu")))
+}
+
+func TestFileFrameOutput_maxAge(t *testing.T) {
+	dir := t.TempDir()
+	out, err := NewFileFrameOutput(dir, FileOutputConfig{Path: "{path}", MaxSizeBytes: 1, MaxAgeMilliseconds: time.Minute.Milliseconds()})
+	require.NoError(t, err)
+	now := time.Date(2021, 9, 14, 10, 0, 0, 0, time.UTC)
+	out.now = func() time.Time { return now }
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}
+	for _, d := range []time.Duration{0, time.Second, 2 * time.Minute} {
+		now = now.Add(d)
+		_, err := out.OutputFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []string{"frames-1631613721000.ndjson", fileActiveName}, readDirNames(t, filepath.Join(dir, "cpu")))
+}
+
+func TestFileFrameOutput_invalidPath(t *testing.T) {
+	_, err := NewFileFrameOutput("", FileOutputConfig{})
+	require.Error(t, err)
+
+	out, err := NewFileFrameOutput(t.TempDir(), FileOutputConfig{Path: "{path}"})
+	require.NoError(t, err)
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	for _, path := range []string{"", "..", "../other", "a/../../other"} {
+		_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu", Path: path}, frame)
+		require.Error(t, err, path)
+	}
+}
+
+func TestFileFrameOutput_sharedFile(t *testing.T) {
+	dir := t.TempDir()
+	config := FileOutputConfig{Path: "{path}", RotationIntervalMilliseconds: time.Minute.Milliseconds()}
+	now := time.Date(2021, 9, 14, 10, 0, 0, 0, time.UTC)
+	newOutput := func() *FileFrameOutput {
+		out, err := NewFileFrameOutput(dir, config)
+		require.NoError(t, err)
+		out.now = func() time.Time { return now }
+		return out
+	}
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}
+
+	first := newOutput()
+	_, err := first.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+
+	// Output of a rebuilt rule writes to the same open file, the rotation interval is
+	// counted from the time the file was opened by the replaced output.
+	second := newOutput()
+	_, err = second.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Same(t, first.files[filepath.Join(dir, "cpu")], second.files[filepath.Join(dir, "cpu")])
+	require.NoError(t, first.Close())
+	now = now.Add(2 * time.Minute)
+	_, err = second.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Equal(t, []string{"frames-1631613720000.ndjson", fileActiveName}, readDirNames(t, filepath.Join(dir, "cpu")))
+
+	f := second.files[filepath.Join(dir, "cpu")]
+	require.NoError(t, second.Close())
+	require.Nil(t, f.file)
+}
+
+func TestFileFrameOutput_rotationByModTime(t *testing.T) {
+	dir := t.TempDir()
+	out, err := NewFileFrameOutput(dir, FileOutputConfig{Path: "{path}", RotationIntervalMilliseconds: time.Minute.Milliseconds()})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+	now := time.Date(2021, 9, 14, 10, 0, 0, 0, time.UTC)
+	out.now = func() time.Time { return now }
+
+	// Active file left from a previous run.
+	active := filepath.Join(dir, "cpu", fileActiveName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(active), 0750))
+	require.NoError(t, os.WriteFile(active, []byte("{}\n"), 0640))
+	require.NoError(t, os.Chtimes(active, now.Add(-2*time.Minute), now.Add(-2*time.Minute)))
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{Path: "cpu"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, []string{"frames-1631613600000.ndjson", fileActiveName}, readDirNames(t, filepath.Join(dir, "cpu")))
+}
@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+)
+
+const (
+	// defaultForwardChannel is used when channel template is not set in config.
+	defaultForwardChannel = "{channel}"
+	defaultForwardTimeout = 5 * time.Second
+	// forwardPushFramesPath is a path of Live frames push endpoint relative to Grafana URL.
+	forwardPushFramesPath = "/api/live/pipeline/push-frames/"
+)
+
+// ForwardFrameOutput pushes frames to a Live channel of another Grafana instance, so
+// edge instances can stream processed data to a central one. Frames are sent to the
+// push-frames endpoint, so they skip the conversion stage on the remote instance and
+// are processed by a channel rule there.
+type ForwardFrameOutput struct {
+	// Endpoint is a root URL of remote Grafana.
+	Endpoint string
+	// BasicAuth is an optional basic auth params.
+	BasicAuth *BasicAuth
+	// Token is a service account token of remote Grafana, takes precedence over BasicAuth.
+	Token string
+
+	channel    *nameTemplate
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewForwardFrameOutput(endpoint string, basicAuth *BasicAuth, token string, config ForwardOutputConfig) (*ForwardFrameOutput, error) {
+	if config.Channel == "" {
+		config.Channel = defaultForwardChannel
+	}
+	channel, err := parseNameTemplate(config.Channel)
+	if err != nil {
+		return nil, err
+	}
+	timeout := defaultForwardTimeout
+	if config.TimeoutMilliseconds > 0 {
+		timeout = time.Duration(config.TimeoutMilliseconds) * time.Millisecond
+	}
+	return &ForwardFrameOutput{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		BasicAuth:  basicAuth,
+		Token:      token,
+		channel:    channel,
+		httpClient: &http.Client{Timeout: timeout},
+		now:        time.Now,
+	}, nil
+}
+
+const FrameOutputTypeForward = "forward"
+
+func (out *ForwardFrameOutput) Type() string {
+	return FrameOutputTypeForward
+}
+
+func (out *ForwardFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip forwarding frame: no url")
+		return nil, nil
+	}
+	channel := out.channel.render(vars, frame.Name, out.now())
+	if _, err := live.ParseChannel(channel); err != nil {
+		return nil, fmt.Errorf("invalid forward channel %q: %w", channel, err)
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, out.Endpoint+forwardPushFramesPath+escapeChannelPath(channel), bytes.NewReader(frameJSON))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing forward request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if vars.MessageID != "" {
+		// The receiving instance keeps tracing the message under the same ID.
+		req.Header.Set(MessageIDHeader, vars.MessageID)
+	}
+	switch {
+	case out.Token != "":
+		req.Header.Set("Authorization", "Bearer "+out.Token)
+	case out.BasicAuth != nil:
+		req.SetBasicAuth(out.BasicAuth.User, out.BasicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error forwarding frame: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("no rule for channel %s on remote Grafana", channel)
+		}
+		return nil, fmt.Errorf("unexpected response code from remote Grafana: %d", resp.StatusCode)
+	}
+	logger.Debug("Successfully forwarded frame", "channel", channel, "elapsed", time.Since(started))
+	return nil, nil
+}
+
+// escapeChannelPath escapes channel parts keeping slashes between them.
+func escapeChannelPath(channel string) string {
+	parts := strings.Split(channel, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
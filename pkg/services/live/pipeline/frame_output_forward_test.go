@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardFrameOutput_OutputFrame(t *testing.T) {
+	var path, auth string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	out, err := NewForwardFrameOutput(server.URL+"/", nil, "glsa_token", ForwardOutputConfig{Channel: "stream/edge-1/{path}"})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}, frame)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/live/pipeline/push-frames/stream/edge-1/cpu", path)
+	require.Equal(t, "Bearer glsa_token", auth)
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	require.NoError(t, err)
+	require.JSONEq(t, string(frameJSON), string(body))
+}
+
+func TestForwardFrameOutput_errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	out, err := NewForwardFrameOutput(server.URL, &BasicAuth{User: "admin", Password: "admin"}, "", ForwardOutputConfig{})
+	require.NoError(t, err)
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+	require.ErrorContains(t, err, "no rule for channel stream/test/cpu")
+
+	out, err = NewForwardFrameOutput(server.URL, nil, "", ForwardOutputConfig{Channel: "{path}"})
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}, frame)
+	require.ErrorContains(t, err, "invalid forward channel")
+}
@@ -0,0 +1,270 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+const (
+	defaultGeoJSONMaxPoints     = 100
+	defaultGeoJSONWriteInterval = 10 * time.Second
+)
+
+// GeoJSONWriter saves a GeoJSON document as a new version of a geojson entity.
+type GeoJSONWriter interface {
+	WriteGeoJSON(ctx context.Context, orgID int64, uid string, channel string, body []byte) error
+}
+
+// GeoJSONOutput keeps recent points of frames as a rolling GeoJSON FeatureCollection
+// in the entity store, so Geomap layers can show recent positions and tracks. Each row
+// of a frame becomes a Point feature with other field values as properties. Entity is
+// written at most once per write interval, points received in between are written
+// with a delay.
+type GeoJSONOutput struct {
+	writer GeoJSONWriter
+	config GeoJSONOutputConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	collections map[string]*geoJSONCollection
+}
+
+type geoJSONCollection struct {
+	orgID     int64
+	uid       string
+	channel   string
+	points    map[string][]geoJSONPoint
+	lastWrite time.Time
+	// pending is set when a delayed write is scheduled.
+	pending bool
+}
+
+type geoJSONPoint struct {
+	time       time.Time
+	lon, lat   float64
+	properties map[string]any
+}
+
+func NewGeoJSONOutput(writer GeoJSONWriter, config GeoJSONOutputConfig) *GeoJSONOutput {
+	return &GeoJSONOutput{
+		writer:      writer,
+		config:      config,
+		now:         time.Now,
+		collections: map[string]*geoJSONCollection{},
+	}
+}
+
+const FrameOutputTypeGeoJSON = "geojson"
+
+func (out *GeoJSONOutput) Type() string {
+	return FrameOutputTypeGeoJSON
+}
+
+func (out *GeoJSONOutput) latitudeField() string {
+	if out.config.LatitudeField != "" {
+		return out.config.LatitudeField
+	}
+	return "lat"
+}
+
+func (out *GeoJSONOutput) longitudeField() string {
+	if out.config.LongitudeField != "" {
+		return out.config.LongitudeField
+	}
+	return "lon"
+}
+
+func (out *GeoJSONOutput) writeInterval() time.Duration {
+	if out.config.WriteIntervalMilliseconds > 0 {
+		return time.Duration(out.config.WriteIntervalMilliseconds) * time.Millisecond
+	}
+	return defaultGeoJSONWriteInterval
+}
+
+func (out *GeoJSONOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	latIdx := fieldIndex(frame, out.latitudeField())
+	lonIdx := fieldIndex(frame, out.longitudeField())
+	if latIdx < 0 || lonIdx < 0 {
+		return nil, fmt.Errorf("frame has no %s and %s fields", out.latitudeField(), out.longitudeField())
+	}
+	keyIdx := -1
+	if out.config.KeyField != "" {
+		keyIdx = fieldIndex(frame, out.config.KeyField)
+	}
+	timeIdx := -1
+	for i, f := range frame.Fields {
+		if f.Type().Time() {
+			timeIdx = i
+			break
+		}
+	}
+
+	uid := out.config.UID
+	if uid == "" {
+		uid = strings.ReplaceAll(vars.Channel, "/", "-")
+	}
+	now := out.now()
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	collectionKey := orgchannel.PrependOrgID(vars.OrgID, uid)
+	c, ok := out.collections[collectionKey]
+	if !ok {
+		c = &geoJSONCollection{orgID: vars.OrgID, uid: uid, channel: vars.Channel, points: map[string][]geoJSONPoint{}}
+		out.collections[collectionKey] = c
+	}
+	for row := 0; row < frame.Rows(); row++ {
+		lat, latOK := geoJSONCoordinate(frame.Fields[latIdx], row)
+		lon, lonOK := geoJSONCoordinate(frame.Fields[lonIdx], row)
+		if !latOK || !lonOK {
+			continue
+		}
+		p := geoJSONPoint{time: now, lat: lat, lon: lon, properties: map[string]any{}}
+		for i, f := range frame.Fields {
+			if i == latIdx || i == lonIdx {
+				continue
+			}
+			v, ok := f.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			if i == timeIdx {
+				p.time = v.(time.Time)
+				p.properties[f.Name] = p.time.UnixMilli()
+				continue
+			}
+			p.properties[f.Name] = v
+		}
+		key := ""
+		if keyIdx >= 0 {
+			if v, ok := frame.Fields[keyIdx].ConcreteAt(row); ok {
+				key = fmt.Sprint(v)
+			}
+		}
+		c.points[key] = append(c.points[key], p)
+	}
+	out.trim(c, now)
+
+	if wait := out.writeInterval() - now.Sub(c.lastWrite); wait > 0 {
+		if !c.pending {
+			c.pending = true
+			time.AfterFunc(wait, func() {
+				out.mu.Lock()
+				defer out.mu.Unlock()
+				c.pending = false
+				// Writes are done in background, they are not a part of any request.
+				if err := out.write(context.Background(), c, out.now()); err != nil {
+					logger.Error("Error writing GeoJSON entity", "uid", c.uid, "error", err)
+				}
+			})
+		}
+		return nil, nil
+	}
+	return nil, out.write(ctx, c, now)
+}
+
+// trim drops points over MaxPoints per key and points older than MaxAge.
+func (out *GeoJSONOutput) trim(c *geoJSONCollection, now time.Time) {
+	maxPoints := out.config.MaxPoints
+	if maxPoints <= 0 {
+		maxPoints = defaultGeoJSONMaxPoints
+	}
+	for key, points := range c.points {
+		if len(points) > maxPoints {
+			points = points[len(points)-maxPoints:]
+		}
+		if out.config.MaxAgeMilliseconds > 0 {
+			minTime := now.Add(-time.Duration(out.config.MaxAgeMilliseconds) * time.Millisecond)
+			i := 0
+			for i < len(points) && points[i].time.Before(minTime) {
+				i++
+			}
+			points = points[i:]
+		}
+		if len(points) == 0 {
+			delete(c.points, key)
+			continue
+		}
+		c.points[key] = points
+	}
+}
+
+// write saves a collection, must be called with mu held.
+func (out *GeoJSONOutput) write(ctx context.Context, c *geoJSONCollection, now time.Time) error {
+	out.trim(c, now)
+	body, err := json.Marshal(c.featureCollection(out.config.KeyField))
+	if err != nil {
+		return err
+	}
+	c.lastWrite = now
+	return out.writer.WriteGeoJSON(ctx, c.orgID, c.uid, c.channel, body)
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// featureCollection returns points ordered by key and time.
+func (c *geoJSONCollection) featureCollection(keyField string) geoJSONFeatureCollection {
+	keys := make([]string, 0, len(c.points))
+	for key := range c.points {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for _, key := range keys {
+		for _, p := range c.points[key] {
+			properties := p.properties
+			if keyField != "" {
+				properties[keyField] = key
+			}
+			fc.Features = append(fc.Features, geoJSONFeature{
+				Type:       "Feature",
+				Geometry:   geoJSONGeometry{Type: "Point", Coordinates: []float64{p.lon, p.lat}},
+				Properties: properties,
+			})
+		}
+	}
+	return fc
+}
+
+func geoJSONCoordinate(f *data.Field, row int) (float64, bool) {
+	v, ok := f.ConcreteAt(row)
+	if !ok {
+		return 0, false
+	}
+	converted, err := convertToFieldType(v, data.FieldTypeFloat64)
+	if err != nil {
+		return 0, false
+	}
+	return converted.(float64), true
+}
+
+func validateGeoJSONOutputConfig(config GeoJSONOutputConfig) error {
+	if config.MaxPoints < 0 || config.MaxAgeMilliseconds < 0 || config.WriteIntervalMilliseconds < 0 {
+		return errors.New("geojson output limits can't be negative")
+	}
+	return nil
+}
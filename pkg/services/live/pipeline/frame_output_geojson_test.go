@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testGeoJSONWriter struct {
+	mu     sync.Mutex
+	bodies map[string][]byte
+	writes int
+}
+
+func (w *testGeoJSONWriter) WriteGeoJSON(_ context.Context, _ int64, uid string, _ string, body []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.bodies[uid] = body
+	w.writes++
+	return nil
+}
+
+func (w *testGeoJSONWriter) collection(t *testing.T, uid string) geoJSONFeatureCollection {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var fc geoJSONFeatureCollection
+	require.NoError(t, json.Unmarshal(w.bodies[uid], &fc))
+	return fc
+}
+
+func TestGeoJSONOutput(t *testing.T) {
+	writer := &testGeoJSONWriter{bodies: map[string][]byte{}}
+	out := NewGeoJSONOutput(writer, GeoJSONOutputConfig{KeyField: "vehicle", MaxPoints: 2, MaxAgeMilliseconds: 60000})
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	out.now = func() time.Time { return now }
+
+	frame := data.NewFrame("positions",
+		data.NewField("time", nil, []time.Time{now.Add(-2 * time.Minute), now.Add(-3 * time.Second), now.Add(-2 * time.Second), now.Add(-time.Second), now}),
+		data.NewField("vehicle", nil, []string{"a", "a", "a", "a", "b"}),
+		data.NewField("lat", nil, []float64{1, 2, 3, 4, 5}),
+		data.NewField("lon", nil, []*float64{nil, floatPtr(20), floatPtr(30), floatPtr(40), floatPtr(50)}),
+		data.NewField("speed", nil, []float64{10, 20, 30, 40, 50}),
+	)
+	_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/fleet/positions"}, frame)
+	require.NoError(t, err)
+
+	fc := writer.collection(t, "stream-fleet-positions")
+	require.Equal(t, "FeatureCollection", fc.Type)
+	require.Len(t, fc.Features, 3)
+	require.Equal(t, []float64{30, 3}, fc.Features[0].Geometry.Coordinates)
+	require.Equal(t, []float64{40, 4}, fc.Features[1].Geometry.Coordinates)
+	require.Equal(t, "a", fc.Features[1].Properties["vehicle"])
+	require.Equal(t, float64(40), fc.Features[1].Properties["speed"])
+	require.Equal(t, []float64{50, 5}, fc.Features[2].Geometry.Coordinates)
+
+	// Points within a write interval are written later.
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/fleet/positions"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, 1, writer.writes)
+
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/fleet/positions"}, data.NewFrame("test", data.NewField("value", nil, []float64{1})))
+	require.Error(t, err)
+}
+
+func TestGeoJSONOutput_delayedWrite(t *testing.T) {
+	writer := &testGeoJSONWriter{bodies: map[string][]byte{}}
+	out := NewGeoJSONOutput(writer, GeoJSONOutputConfig{UID: "positions", WriteIntervalMilliseconds: 50})
+	for i := 0; i < 3; i++ {
+		_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/fleet/positions"}, data.NewFrame("test",
+			data.NewField("lat", nil, []float64{float64(i)}),
+			data.NewField("lon", nil, []float64{float64(i)}),
+		))
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		writer.mu.Lock()
+		defer writer.mu.Unlock()
+		return writer.writes == 2
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, writer.collection(t, "positions").Features, 3)
+}
@@ -0,0 +1,337 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	protocol "github.com/influxdata/line-protocol"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+const (
+	influxFlushInterval = 10 * time.Second
+	// influxMaxBufferedPoints limits a number of points kept when endpoint is not
+	// available, oldest points are dropped first.
+	influxMaxBufferedPoints = 10000
+)
+
+var influxDroppedPoints = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: metrics.ExporterName,
+	Subsystem: "live_pipeline",
+	Name:      "influx_output_dropped_points_total",
+	Help:      "A counter for points dropped by InfluxDB outputs because of a full buffer",
+})
+
+func init() {
+	prometheus.MustRegister(influxDroppedPoints)
+}
+
+// InfluxFrameOutput writes frames as Influx line protocol to InfluxDB. InfluxDB 2.x
+// write API is used when bucket is configured, InfluxDB 1.x write API otherwise.
+type InfluxFrameOutput struct {
+	mu sync.Mutex
+
+	// Endpoint is an InfluxDB URL.
+	Endpoint string
+	// BasicAuth is an optional basic auth params, used with InfluxDB 1.x.
+	BasicAuth *BasicAuth
+	// Token is an optional API token sent in Authorization header, takes precedence
+	// over BasicAuth.
+	Token string
+
+	config     InfluxOutputConfig
+	tagFields  map[string]struct{}
+	httpClient *http.Client
+	buffer     []byte
+	now        func() time.Time
+	closeOnce  sync.Once
+	done       chan struct{}
+}
+
+func NewInfluxFrameOutput(endpoint string, basicAuth *BasicAuth, token string, config InfluxOutputConfig) (*InfluxFrameOutput, error) {
+	if config.Bucket == "" && config.Database == "" {
+		return nil, errors.New("bucket or database is required")
+	}
+	if config.Bucket != "" && config.Org == "" {
+		return nil, errors.New("org is required for bucket")
+	}
+	out := &InfluxFrameOutput{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		BasicAuth:  basicAuth,
+		Token:      token,
+		config:     config,
+		tagFields:  make(map[string]struct{}, len(config.TagFields)),
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		now:        time.Now,
+		done:       make(chan struct{}),
+	}
+	for _, name := range config.TagFields {
+		out.tagFields[name] = struct{}{}
+	}
+	if out.Endpoint != "" {
+		go out.flushPeriodically()
+	}
+	return out, nil
+}
+
+const FrameOutputTypeInflux = "influx"
+
+func (out *InfluxFrameOutput) Type() string {
+	return FrameOutputTypeInflux
+}
+
+func (out *InfluxFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip sending to InfluxDB: no url")
+		return nil, nil
+	}
+	lines, err := out.frameToLines(vars, frame)
+	if err != nil {
+		return nil, err
+	}
+	out.mu.Lock()
+	out.buffer = trimInfluxBuffer(append(out.buffer, lines...))
+	out.mu.Unlock()
+	return nil, nil
+}
+
+// trimInfluxBuffer drops oldest points of a buffer over influxMaxBufferedPoints.
+func trimInfluxBuffer(buffer []byte) []byte {
+	numDropped := bytes.Count(buffer, []byte("\n")) - influxMaxBufferedPoints
+	if numDropped <= 0 {
+		return buffer
+	}
+	logger.Warn("InfluxDB buffer is full, dropping points", "numDropped", numDropped)
+	influxDroppedPoints.Add(float64(numDropped))
+	for i := 0; i < numDropped; i++ {
+		buffer = buffer[bytes.IndexByte(buffer, '\n')+1:]
+	}
+	return buffer
+}
+
+// frameToLines encodes frame rows as points. Each row produces a point per distinct
+// set of field labels, labels and configured tag fields become tags. Time is taken
+// from the first time field.
+func (out *InfluxFrameOutput) frameToLines(vars Vars, frame *data.Frame) ([]byte, error) {
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	measurement := out.config.Measurement
+	if measurement == "" {
+		measurement = frame.Name
+	}
+	if measurement == "" {
+		measurement = vars.Path
+	}
+
+	timeIndex := -1
+	var (
+		tagFieldIndexes []int
+		groups          []*influxFieldGroup
+		groupIndex      = map[string]*influxFieldGroup{}
+	)
+	for i, f := range frame.Fields {
+		switch {
+		case f.Type().Time():
+			if timeIndex < 0 {
+				timeIndex = i
+			}
+			continue
+		case out.isTagField(f.Name):
+			tagFieldIndexes = append(tagFieldIndexes, i)
+			continue
+		}
+		key := f.Labels.String()
+		g, ok := groupIndex[key]
+		if !ok {
+			g = &influxFieldGroup{labels: f.Labels}
+			groupIndex[key] = g
+			groups = append(groups, g)
+		}
+		g.fields = append(g.fields, i)
+	}
+
+	var buf bytes.Buffer
+	encoder := protocol.NewEncoder(&buf)
+	encoder.SetFieldSortOrder(protocol.SortFields)
+	now := out.now()
+	for row := 0; row < numRows; row++ {
+		t := now
+		if timeIndex >= 0 {
+			if v, ok := frame.Fields[timeIndex].ConcreteAt(row); ok {
+				t = v.(time.Time)
+			}
+		}
+		for _, g := range groups {
+			tags := make(map[string]string, len(g.labels)+len(tagFieldIndexes))
+			for k, v := range g.labels {
+				tags[k] = v
+			}
+			for _, i := range tagFieldIndexes {
+				if v, ok := frame.Fields[i].ConcreteAt(row); ok {
+					tags[frame.Fields[i].Name] = fmt.Sprintf("%v", v)
+				}
+			}
+			fields := make(map[string]any, len(g.fields))
+			for _, i := range g.fields {
+				v, ok := frame.Fields[i].ConcreteAt(row)
+				if !ok {
+					continue
+				}
+				if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+					continue
+				}
+				fields[frame.Fields[i].Name] = v
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			m, err := protocol.New(measurement, tags, fields, t)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := encoder.Encode(m); err != nil {
+				return nil, fmt.Errorf("error encoding line protocol: %w", err)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+type influxFieldGroup struct {
+	labels data.Labels
+	fields []int
+}
+
+func (out *InfluxFrameOutput) isTagField(name string) bool {
+	_, ok := out.tagFields[name]
+	return ok
+}
+
+// Close stops periodic flushing, buffered points are sent once more in background.
+func (out *InfluxFrameOutput) Close() error {
+	out.closeOnce.Do(func() { close(out.done) })
+	return nil
+}
+
+func (out *InfluxFrameOutput) flushPeriodically() {
+	ticker := time.NewTicker(influxFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			out.flushBuffer()
+		case <-out.done:
+			out.flushBuffer()
+			return
+		}
+	}
+}
+
+func (out *InfluxFrameOutput) flushBuffer() {
+	out.mu.Lock()
+	if len(out.buffer) == 0 {
+		out.mu.Unlock()
+		return
+	}
+	tmpBuffer := make([]byte, len(out.buffer))
+	copy(tmpBuffer, out.buffer)
+	out.buffer = nil
+	out.mu.Unlock()
+
+	retry, err := out.flush(tmpBuffer)
+	if err != nil {
+		logger.Error("Error flush to InfluxDB", "error", err)
+	}
+	if retry {
+		out.mu.Lock()
+		out.buffer = trimInfluxBuffer(append(tmpBuffer, out.buffer...))
+		out.mu.Unlock()
+	}
+}
+
+// Pending returns a number of buffered points not sent yet.
+func (out *InfluxFrameOutput) Pending() int {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	return bytes.Count(out.buffer, []byte("\n"))
+}
+
+// Drain sends buffered points once.
+func (out *InfluxFrameOutput) Drain(ctx context.Context) (int, error) {
+	out.mu.Lock()
+	buffer := out.buffer
+	out.buffer = nil
+	out.mu.Unlock()
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+	err := runWithContext(ctx, func() error {
+		_, err := out.flush(buffer)
+		return err
+	})
+	if err != nil {
+		return bytes.Count(buffer, []byte("\n")), err
+	}
+	return 0, nil
+}
+
+func (out *InfluxFrameOutput) writeURL() string {
+	params := url.Values{}
+	params.Set("precision", "ns")
+	if out.config.Bucket != "" {
+		params.Set("org", out.config.Org)
+		params.Set("bucket", out.config.Bucket)
+		return out.Endpoint + "/api/v2/write?" + params.Encode()
+	}
+	params.Set("db", out.config.Database)
+	if out.config.RetentionPolicy != "" {
+		params.Set("rp", out.config.RetentionPolicy)
+	}
+	return out.Endpoint + "/write?" + params.Encode()
+}
+
+// flush sends lines with a single write request, retry is true when the request
+// failed and may succeed later.
+func (out *InfluxFrameOutput) flush(lines []byte) (retry bool, err error) {
+	logger.Debug("InfluxDB flush", "bodyLength", len(lines))
+	req, err := http.NewRequest(http.MethodPost, out.writeURL(), bytes.NewReader(lines))
+	if err != nil {
+		return false, fmt.Errorf("error constructing InfluxDB write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case out.Token != "":
+		req.Header.Set("Authorization", "Token "+out.Token)
+	case out.BasicAuth != nil:
+		req.SetBasicAuth(out.BasicAuth.User, out.BasicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("error sending to InfluxDB: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		logger.Error("Unexpected response code from InfluxDB endpoint", "code", resp.StatusCode, "body", string(body))
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return retry, errors.New("unexpected response code from InfluxDB endpoint")
+	}
+	logger.Debug("Successfully sent to InfluxDB", "elapsed", time.Since(started))
+	return false, nil
+}
@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfluxFrameOutput_frameToLines(t *testing.T) {
+	out, err := NewInfluxFrameOutput("", nil, "", InfluxOutputConfig{
+		Database:  "live",
+		TagFields: []string{"host"},
+	})
+	require.NoError(t, err)
+
+	ts := time.Unix(1, 0)
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("user", data.Labels{"core": "0"}, []*float64{floatPtr(1), nil}),
+		data.NewField("system", data.Labels{"core": "0"}, []float64{2, 3}),
+		data.NewField("user", data.Labels{"core": "1"}, []float64{4, 5}),
+		data.NewField("status", nil, []string{"ok", "ok"}),
+	)
+	lines, err := out.frameToLines(Vars{}, frame)
+	require.NoError(t, err)
+	require.Equal(t, `cpu,core=0,host=a system=2,user=1 1000000000
+cpu,core=1,host=a user=4 1000000000
+cpu,host=a status="ok" 1000000000
+cpu,core=0,host=b system=3 2000000000
+cpu,core=1,host=b user=5 2000000000
+cpu,host=b status="ok" 2000000000
+`, string(lines))
+}
+
+func TestInfluxFrameOutput_flush(t *testing.T) {
+	var (
+		request *http.Request
+		body    []byte
+		status  = http.StatusNoContent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	out, err := NewInfluxFrameOutput("", nil, "", InfluxOutputConfig{Org: "my-org", Bucket: "live"})
+	require.NoError(t, err)
+	out.Endpoint = server.URL
+	out.Token = "secret"
+
+	retry, err := out.flush([]byte("cpu value=1 1\n"))
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.Equal(t, "/api/v2/write", request.URL.Path)
+	require.Equal(t, "bucket=live&org=my-org&precision=ns", request.URL.RawQuery)
+	require.Equal(t, "Token secret", request.Header.Get("Authorization"))
+	require.Equal(t, "cpu value=1 1\n", string(body))
+
+	status = http.StatusBadRequest
+	retry, err = out.flush([]byte("invalid"))
+	require.Error(t, err)
+	require.False(t, retry)
+
+	status = http.StatusServiceUnavailable
+	retry, err = out.flush([]byte("cpu value=1 1\n"))
+	require.Error(t, err)
+	require.True(t, retry)
+}
+
+func TestTrimInfluxBuffer(t *testing.T) {
+	var buffer []byte
+	for i := 0; i < influxMaxBufferedPoints+2; i++ {
+		buffer = append(buffer, fmt.Sprintf("cpu value=%d\n", i)...)
+	}
+	dropped := testutil.ToFloat64(influxDroppedPoints)
+	buffer = trimInfluxBuffer(buffer)
+	require.Equal(t, influxMaxBufferedPoints, bytes.Count(buffer, []byte("\n")))
+	require.True(t, bytes.HasPrefix(buffer, []byte("cpu value=2\n")))
+	require.Equal(t, 2.0, testutil.ToFloat64(influxDroppedPoints)-dropped)
+	require.Equal(t, buffer, trimInfluxBuffer(buffer))
+}
+
+func TestNewInfluxFrameOutput_invalid(t *testing.T) {
+	_, err := NewInfluxFrameOutput("", nil, "", InfluxOutputConfig{})
+	require.Error(t, err)
+	_, err = NewInfluxFrameOutput("", nil, "", InfluxOutputConfig{Bucket: "live"})
+	require.Error(t, err)
+}
@@ -2,14 +2,34 @@ package pipeline
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
 )
 
+// Schema validation modes of managed stream outputs.
+const (
+	// SchemaValidationAdapt converts frames to the channel schema: missing fields are
+	// added with null values, extra fields are dropped and fields are reordered.
+	SchemaValidationAdapt = "adapt"
+	// SchemaValidationReject fails the output when a frame does not match the channel schema.
+	SchemaValidationReject = "reject"
+)
+
+// ErrSchemaMismatch is returned by managed stream outputs rejecting frames which
+// don't match a channel schema. Rejected frames get to the dead-letter queue.
+var ErrSchemaMismatch = errors.New("frame does not match managed stream schema")
+
 type ManagedStreamFrameOutput struct {
 	managedStream *managedstream.Runner
+	// SchemaValidation checks frames against the schema of the channel before
+	// publishing, one of SchemaValidationAdapt or SchemaValidationReject. Frames
+	// are published as is when empty.
+	SchemaValidation string
 }
 
 func NewManagedStreamFrameOutput(managedStream *managedstream.Runner) *ManagedStreamFrameOutput {
@@ -28,5 +48,92 @@ func (out *ManagedStreamFrameOutput) OutputFrame(ctx context.Context, vars Vars,
 		logger.Error("Error getting stream", "error", err)
 		return nil, err
 	}
+	if out.SchemaValidation != "" {
+		schema, ok, err := out.managedStream.GetSchema(ctx, vars.OrgID, vars.Channel)
+		if err != nil {
+			return nil, err
+		}
+		// The first frame of a channel sets its schema.
+		if ok {
+			frame, err = matchFrameSchema(frame, schema, out.SchemaValidation == SchemaValidationAdapt)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	return nil, stream.Push(ctx, vars.Path, frame)
 }
+
+// matchFrameSchema checks a frame has fields of a schema frame with the same names and
+// types in the same order. When adapt is set a frame with schema fields is returned
+// instead of an error, values of fields with different types are converted.
+func matchFrameSchema(frame *data.Frame, schema *data.Frame, adapt bool) (*data.Frame, error) {
+	if frameMatchesSchema(frame, schema) {
+		return frame, nil
+	}
+	if !adapt {
+		return nil, fmt.Errorf("%w: expected fields %s", ErrSchemaMismatch, schemaFieldsString(schema))
+	}
+	adapted := data.NewFrame(frame.Name)
+	adapted.Meta = frame.Meta
+	rows := frame.Rows()
+	for _, schemaField := range schema.Fields {
+		field := data.NewFieldFromFieldType(schemaField.Type(), rows)
+		field.Name = schemaField.Name
+		field.Labels = schemaField.Labels
+		idx := fieldIndex(frame, schemaField.Name)
+		if idx < 0 {
+			if !field.Nullable() {
+				return nil, fmt.Errorf("%w: missing non-nullable field %s", ErrSchemaMismatch, schemaField.Name)
+			}
+			adapted.Fields = append(adapted.Fields, field)
+			continue
+		}
+		field.Config = frame.Fields[idx].Config
+		for row := 0; row < rows; row++ {
+			v, ok := frame.Fields[idx].ConcreteAt(row)
+			if !ok {
+				if !field.Nullable() {
+					return nil, fmt.Errorf("%w: null value in non-nullable field %s", ErrSchemaMismatch, schemaField.Name)
+				}
+				continue
+			}
+			converted, err := convertToFieldType(v, field.Type())
+			if err != nil {
+				return nil, fmt.Errorf("%w: field %s: %s", ErrSchemaMismatch, schemaField.Name, err)
+			}
+			field.SetConcrete(row, converted)
+		}
+		adapted.Fields = append(adapted.Fields, field)
+	}
+	return adapted, nil
+}
+
+func frameMatchesSchema(frame *data.Frame, schema *data.Frame) bool {
+	if len(frame.Fields) != len(schema.Fields) {
+		return false
+	}
+	for i, f := range frame.Fields {
+		if f.Name != schema.Fields[i].Name || f.Type() != schema.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
+
+func schemaFieldsString(schema *data.Frame) string {
+	fields := make([]string, 0, len(schema.Fields))
+	for _, f := range schema.Fields {
+		fields = append(fields, f.Name+" ("+f.Type().ItemTypeString()+")")
+	}
+	return strings.Join(fields, ", ")
+}
+
+func validateManagedStreamOutputConfig(config ManagedStreamOutputConfig) error {
+	switch config.SchemaValidation {
+	case "", SchemaValidationAdapt, SchemaValidationReject:
+		return nil
+	default:
+		return fmt.Errorf("unknown schema validation mode: %s", config.SchemaValidation)
+	}
+}
@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+func TestManagedStreamFrameOutput_SchemaValidation(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	schemaFrame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts}),
+		data.NewField("value", nil, []*float64{floatPtr(1)}),
+		data.NewField("host", nil, []*string{stringPtr("a")}),
+	)
+
+	published := 0
+	runner := managedstream.NewRunner(func(_ int64, _ string, _ []byte) error {
+		published++
+		return nil
+	}, nil, managedstream.NewMemoryFrameCache())
+	vars := Vars{
+		OrgID:     1,
+		Channel:   "stream/test/schema",
+		Scope:     "stream",
+		Namespace: "test",
+		Path:      "schema",
+	}
+
+	out := NewManagedStreamFrameOutput(runner)
+	out.SchemaValidation = SchemaValidationReject
+	_, err := out.OutputFrame(context.Background(), vars, schemaFrame)
+	require.NoError(t, err)
+
+	extra := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts}),
+		data.NewField("value", nil, []*float64{floatPtr(2)}),
+		data.NewField("host", nil, []*string{stringPtr("b")}),
+		data.NewField("extra", nil, []bool{true}),
+	)
+	_, err = out.OutputFrame(context.Background(), vars, extra)
+	require.ErrorIs(t, err, ErrSchemaMismatch)
+	require.Equal(t, 1, published)
+
+	out.SchemaValidation = SchemaValidationAdapt
+	_, err = out.OutputFrame(context.Background(), vars, extra)
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+}
+
+func TestMatchFrameSchema(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	schema := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{}),
+		data.NewField("value", nil, []*float64{}),
+		data.NewField("host", nil, []*string{}),
+	)
+
+	t.Run("matching frame", func(t *testing.T) {
+		frame := data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{ts}),
+			data.NewField("value", nil, []*float64{floatPtr(1)}),
+			data.NewField("host", nil, []*string{stringPtr("a")}),
+		)
+		matched, err := matchFrameSchema(frame, schema, false)
+		require.NoError(t, err)
+		require.Same(t, frame, matched)
+	})
+
+	t.Run("adapt", func(t *testing.T) {
+		frame := data.NewFrame("test",
+			data.NewField("value", nil, []int64{5}),
+			data.NewField("time", nil, []time.Time{ts}),
+			data.NewField("extra", nil, []string{"x"}),
+		)
+		_, err := matchFrameSchema(frame, schema, false)
+		require.ErrorIs(t, err, ErrSchemaMismatch)
+
+		adapted, err := matchFrameSchema(frame, schema, true)
+		require.NoError(t, err)
+		require.Len(t, adapted.Fields, 3)
+		require.Equal(t, "time", adapted.Fields[0].Name)
+		require.Equal(t, ts, adapted.Fields[0].At(0))
+		require.Equal(t, data.FieldTypeNullableFloat64, adapted.Fields[1].Type())
+		require.Equal(t, 5.0, *adapted.Fields[1].At(0).(*float64))
+		require.Equal(t, "host", adapted.Fields[2].Name)
+		require.Nil(t, adapted.Fields[2].At(0))
+	})
+
+	t.Run("missing non-nullable field", func(t *testing.T) {
+		frame := data.NewFrame("test", data.NewField("value", nil, []*float64{floatPtr(1)}))
+		_, err := matchFrameSchema(frame, schema, true)
+		require.ErrorIs(t, err, ErrSchemaMismatch)
+	})
+}
@@ -2,14 +2,37 @@ package pipeline
 
 import (
 	"context"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
 )
 
+var multipleOutputBranchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.ExporterName,
+	Subsystem: "live_pipeline",
+	Name:      "multiple_output_branch_errors_total",
+	Help:      "A counter for errors of multiple output branches",
+}, []string{"branch", "output"})
+
+func init() {
+	prometheus.MustRegister(multipleOutputBranchErrors)
+}
+
 // MultipleFrameOutput can combine several FrameOutputter and
 // execute them sequentially.
 type MultipleFrameOutput struct {
 	Outputters []FrameOutputter
+	// ContinueOnError isolates branches, so a failing outputter does not stop the
+	// following ones. Failures are logged and counted per branch, and frames of
+	// successful branches are returned.
+	ContinueOnError bool
+
+	// branchErrors are numbers of errors by branch index.
+	branchErrors []atomic.Int64
 }
 
 const FrameOutputTypeMultiple = "multiple"
@@ -18,12 +41,19 @@ func (out *MultipleFrameOutput) Type() string {
 	return FrameOutputTypeMultiple
 }
 
-func (out MultipleFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+func (out *MultipleFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
 	var frames []*ChannelFrame
-	for _, out := range out.Outputters {
-		f, err := out.OutputFrame(ctx, vars, frame)
+	for i, o := range out.Outputters {
+		f, err := o.OutputFrame(ctx, vars, frame)
 		if err != nil {
-			logger.Error("Error outputting frame", "error", err)
+			logger.Error("Error outputting frame", "error", err, "channel", vars.Channel, "branch", i, "output", o.Type())
+			if i < len(out.branchErrors) {
+				out.branchErrors[i].Add(1)
+			}
+			multipleOutputBranchErrors.WithLabelValues(strconv.Itoa(i), o.Type()).Inc()
+			if out.ContinueOnError {
+				continue
+			}
 			return nil, err
 		}
 		frames = append(frames, f...)
@@ -31,6 +61,18 @@ func (out MultipleFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame
 	return frames, nil
 }
 
+// BranchErrors returns numbers of errors of each outputter since creation.
+func (out *MultipleFrameOutput) BranchErrors() []int64 {
+	counts := make([]int64, len(out.branchErrors))
+	for i := range out.branchErrors {
+		counts[i] = out.branchErrors[i].Load()
+	}
+	return counts
+}
+
 func NewMultipleFrameOutput(outputters ...FrameOutputter) *MultipleFrameOutput {
-	return &MultipleFrameOutput{Outputters: outputters}
+	return &MultipleFrameOutput{
+		Outputters:   outputters,
+		branchErrors: make([]atomic.Int64, len(outputters)),
+	}
 }
@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type channelFrameOutputter struct {
+	channel string
+}
+
+func (o *channelFrameOutputter) Type() string {
+	return "channelFrame"
+}
+
+func (o *channelFrameOutputter) OutputFrame(_ context.Context, _ Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	return []*ChannelFrame{{Channel: o.channel, Frame: frame}}, nil
+}
+
+func TestMultipleFrameOutput_OutputFrame(t *testing.T) {
+	failing := &testOutputter{err: errors.New("boom")}
+	last := &testOutputter{}
+	out := NewMultipleFrameOutput(failing, last)
+
+	frame := data.NewFrame("test")
+	_, err := out.OutputFrame(context.Background(), Vars{}, frame)
+	require.Error(t, err)
+	require.Nil(t, last.frame)
+	require.Equal(t, []int64{1, 0}, out.BranchErrors())
+}
+
+func TestMultipleFrameOutput_continueOnError(t *testing.T) {
+	failing := &testOutputter{err: errors.New("boom")}
+	last := &testOutputter{}
+	out := NewMultipleFrameOutput(failing, &channelFrameOutputter{channel: "stream/test/copy"}, last)
+	out.ContinueOnError = true
+
+	frame := data.NewFrame("test")
+	frames, err := out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Equal(t, frame, last.frame)
+
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 0, 0}, out.BranchErrors())
+}
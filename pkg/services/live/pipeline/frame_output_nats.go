@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// defaultNATSSubject is used when subject template is not set in config.
+	defaultNATSSubject = "grafana.live.{channel}"
+	natsAckTimeout     = 2 * time.Second
+	natsDialTimeout    = 5 * time.Second
+)
+
+// natsConns are connections shared by outputs of the same server and credentials.
+var natsConns sharedClients[*natsConn]
+
+// natsConn closes a NATS connection as io.Closer.
+type natsConn struct {
+	*nats.Conn
+}
+
+func (c *natsConn) Close() error {
+	c.Conn.Close()
+	return nil
+}
+
+// NATSFrameOutput publishes frames encoded to JSON to a NATS subject. With JetStream
+// enabled each publish waits for a stream acknowledgement.
+type NATSFrameOutput struct {
+	// Endpoint is a NATS server URL like nats://localhost:4222.
+	Endpoint string
+
+	config  NATSOutputConfig
+	subject *nameTemplate
+	connKey string
+	conn    *natsConn
+	js      nats.JetStreamContext
+
+	closeOnce sync.Once
+}
+
+func NewNATSFrameOutput(endpoint string, basicAuth *BasicAuth, token string, config NATSOutputConfig) (*NATSFrameOutput, error) {
+	if config.Subject == "" {
+		config.Subject = defaultNATSSubject
+	}
+	subject, err := parseNameTemplate(config.Subject)
+	if err != nil {
+		return nil, err
+	}
+	out := &NATSFrameOutput{
+		Endpoint: endpoint,
+		config:   config,
+		subject:  subject,
+	}
+	if endpoint == "" {
+		return out, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats URL: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("unsupported nats URL scheme: %s", u.Scheme)
+	}
+
+	opts := []nats.Option{
+		nats.Name("grafana-live"),
+		nats.Timeout(natsDialTimeout),
+		// Connections are kept while rules use them, publishes are buffered until
+		// the server is available.
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+	var user, password string
+	if basicAuth != nil {
+		user, password = basicAuth.User, basicAuth.Password
+		opts = append(opts, nats.UserInfo(user, password))
+	}
+	if token != "" {
+		opts = append(opts, nats.Token(token))
+	}
+	// The connection is acquired with the output, so it stays open when rules are
+	// rebuilt, as new outputs acquire it before replaced ones release it.
+	out.connKey = strings.Join([]string{endpoint, user, password, token}, "\x00")
+	out.conn, err = natsConns.acquire(out.connKey, func() (*natsConn, error) {
+		conn, err := nats.Connect(endpoint, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &natsConn{Conn: conn}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS: %w", err)
+	}
+	if config.JetStream {
+		out.js, err = out.conn.JetStream()
+		if err != nil {
+			_ = out.Close()
+			return nil, fmt.Errorf("error creating JetStream context: %w", err)
+		}
+	}
+	return out, nil
+}
+
+const FrameOutputTypeNATS = "nats"
+
+func (out *NATSFrameOutput) Type() string {
+	return FrameOutputTypeNATS
+}
+
+// Close releases the connection, it's closed when no other output uses it.
+func (out *NATSFrameOutput) Close() error {
+	if out.conn == nil {
+		return nil
+	}
+	out.closeOnce.Do(func() { natsConns.release(out.connKey) })
+	return nil
+}
+
+func (out *NATSFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip publishing to NATS: no url")
+		return nil, nil
+	}
+	subject, err := out.subjectName(vars, frame.Name)
+	if err != nil {
+		return nil, err
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.js == nil {
+		if err := out.conn.Publish(subject, frameJSON); err != nil {
+			return nil, fmt.Errorf("error publishing to NATS: %w", err)
+		}
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, natsAckTimeout)
+	defer cancel()
+	pubOpts := []nats.PubOpt{nats.Context(ctx)}
+	if out.config.Stream != "" {
+		pubOpts = append(pubOpts, nats.ExpectStream(out.config.Stream))
+	}
+	if _, err := out.js.Publish(subject, frameJSON, pubOpts...); err != nil {
+		return nil, fmt.Errorf("error publishing to JetStream: %w", err)
+	}
+	return nil, nil
+}
+
+// subjectName renders a subject, channel parts separated by slashes become subject
+// tokens.
+func (out *NATSFrameOutput) subjectName(vars Vars, frameName string) (string, error) {
+	subject := out.subject.render(vars, frameName, time.Now())
+	subject = strings.Map(func(r rune) rune {
+		switch r {
+		case '/':
+			return '.'
+		case ' ', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, subject)
+	if subject == "" || strings.HasPrefix(subject, ".") || strings.HasSuffix(subject, ".") || strings.Contains(subject, "..") {
+		return "", fmt.Errorf("invalid NATS subject: %q", subject)
+	}
+	return subject, nil
+}
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type natsPublished struct {
+	subject string
+	payload []byte
+}
+
+// startTestNATSServer accepts a single connection, acknowledges publishes with a
+// reply subject like JetStream does and sends received messages to a channel.
+func startTestNATSServer(t *testing.T, ack string) (string, <-chan natsPublished, <-chan map[string]any) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	published := make(chan natsPublished, 10)
+	connects := make(chan map[string]any, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		_, _ = fmt.Fprintf(conn, "INFO {\"max_payload\":1048576,\"headers\":true,\"proto\":1}\r\n")
+		// Reply subjects of publishes match the client inbox subscription.
+		var sid string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			op, args, _ := strings.Cut(line, " ")
+			switch op {
+			case "CONNECT":
+				var opts map[string]any
+				_ = json.Unmarshal([]byte(args), &opts)
+				connects <- opts
+			case "PING":
+				_, _ = fmt.Fprintf(conn, "PONG\r\n")
+			case "SUB":
+				parts := strings.Fields(args)
+				sid = parts[len(parts)-1]
+			case "PUB", "HPUB":
+				parts := strings.Fields(args)
+				size, _ := strconv.Atoi(parts[len(parts)-1])
+				headerSize := 0
+				if op == "HPUB" {
+					headerSize, _ = strconv.Atoi(parts[len(parts)-2])
+					parts = parts[:len(parts)-1]
+				}
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				published <- natsPublished{subject: parts[0], payload: payload[headerSize:size]}
+				if len(parts) == 3 {
+					_, _ = fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", parts[1], sid, len(ack), ack)
+				}
+			}
+		}
+	}()
+	return "nats://" + ln.Addr().String(), published, connects
+}
+
+func TestNATSFrameOutput_OutputFrame(t *testing.T) {
+	endpoint, published, connects := startTestNATSServer(t, "")
+	out, err := NewNATSFrameOutput(endpoint, &BasicAuth{User: "live", Password: "secret"}, "", NATSOutputConfig{})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+
+	opts := <-connects
+	require.Equal(t, "live", opts["user"])
+	require.Equal(t, "secret", opts["pass"])
+
+	select {
+	case msg := <-published:
+		require.Equal(t, "grafana.live.stream.test.cpu", msg.subject)
+		frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+		require.NoError(t, err)
+		require.JSONEq(t, string(frameJSON), string(msg.payload))
+	case <-time.After(time.Second):
+		t.Fatal("message not published")
+	}
+}
+
+func TestNATSFrameOutput_OutputFrame_jetStream(t *testing.T) {
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+
+	endpoint, _, _ := startTestNATSServer(t, `{"stream":"LIVE","seq":1}`)
+	out, err := NewNATSFrameOutput(endpoint, nil, "token", NATSOutputConfig{
+		Subject:   "telemetry.{path}",
+		JetStream: true,
+		Stream:    "LIVE",
+	})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+	_, err = out.OutputFrame(context.Background(), Vars{Path: "cpu"}, frame)
+	require.NoError(t, err)
+
+	endpoint, _, _ = startTestNATSServer(t, `{"error":{"code":503,"description":"no responders"}}`)
+	out, err = NewNATSFrameOutput(endpoint, nil, "", NATSOutputConfig{JetStream: true})
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.ErrorContains(t, err, "no responders")
+}
+
+func TestNATSFrameOutput_sharedConn(t *testing.T) {
+	// Test server accepts a single connection, so outputs must share it.
+	endpoint, published, _ := startTestNATSServer(t, "")
+	first, err := NewNATSFrameOutput(endpoint, nil, "", NATSOutputConfig{})
+	require.NoError(t, err)
+	second, err := NewNATSFrameOutput(endpoint, nil, "", NATSOutputConfig{})
+	require.NoError(t, err)
+	require.Same(t, first.conn, second.conn)
+
+	require.NoError(t, first.Close())
+	require.NoError(t, first.Close())
+	require.False(t, second.conn.IsClosed())
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = second.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("message not published")
+	}
+
+	require.NoError(t, second.Close())
+	require.True(t, second.conn.IsClosed())
+}
+
+func TestNATSFrameOutput_subjectName(t *testing.T) {
+	out, err := NewNATSFrameOutput("", nil, "", NATSOutputConfig{Subject: "live.{namespace}.{path}"})
+	require.NoError(t, err)
+	subject, err := out.subjectName(Vars{Namespace: "test", Path: "cpu/total"}, "")
+	require.NoError(t, err)
+	require.Equal(t, "live.test.cpu.total", subject)
+
+	_, err = out.subjectName(Vars{Namespace: "test"}, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,337 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/lib/pq"
+)
+
+const (
+	postgresFlushInterval    = 5 * time.Second
+	defaultPostgresBatchSize = 500
+	// postgresMaxBufferedRows limits a number of rows kept when database is not
+	// available, oldest rows are dropped first.
+	postgresMaxBufferedRows = 10000
+	// postgresMaxParams is a limit of bind parameters in a single statement.
+	postgresMaxParams = 65535
+)
+
+// sqlExecer is a part of sql.DB used by PostgresFrameOutput.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// postgresDBs are connection pools shared by outputs of the same database.
+var postgresDBs sharedClients[*sql.DB]
+
+// PostgresFrameOutput inserts frame rows into PostgreSQL (or TimescaleDB) tables.
+// Tables are not created automatically and must have columns for all mapped fields.
+type PostgresFrameOutput struct {
+	mu sync.Mutex
+
+	config  PostgresOutputConfig
+	table   *nameTemplate
+	dsn     string
+	db      sqlExecer
+	batches map[string]*postgresBatch
+	// numRows is a number of buffered rows in all batches.
+	numRows   int
+	flushCh   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+type postgresBatch struct {
+	table   string
+	columns []string
+	rows    [][]any
+}
+
+func NewPostgresFrameOutput(endpoint string, basicAuth *BasicAuth, config PostgresOutputConfig) (*PostgresFrameOutput, error) {
+	if config.Table == "" {
+		return nil, errors.New("table is required")
+	}
+	table, err := parseNameTemplate(config.Table)
+	if err != nil {
+		return nil, err
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultPostgresBatchSize
+	}
+	out := &PostgresFrameOutput{
+		config:  config,
+		table:   table,
+		batches: map[string]*postgresBatch{},
+		flushCh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if endpoint != "" {
+		dsn, err := postgresDSN(endpoint, basicAuth)
+		if err != nil {
+			return nil, err
+		}
+		db, err := postgresDBs.acquire(dsn, func() (*sql.DB, error) {
+			return sql.Open("postgres", dsn)
+		})
+		if err != nil {
+			return nil, err
+		}
+		out.dsn = dsn
+		out.db = db
+		go out.flushPeriodically()
+	}
+	return out, nil
+}
+
+// postgresDSN returns a connection URL with credentials from basic auth.
+func postgresDSN(endpoint string, basicAuth *BasicAuth) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid postgres URL: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return "", fmt.Errorf("unsupported postgres URL scheme: %s", u.Scheme)
+	}
+	if basicAuth != nil {
+		u.User = url.UserPassword(basicAuth.User, basicAuth.Password)
+	}
+	return u.String(), nil
+}
+
+const FrameOutputTypePostgres = "postgres"
+
+func (out *PostgresFrameOutput) Type() string {
+	return FrameOutputTypePostgres
+}
+
+func (out *PostgresFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.db == nil {
+		logger.Debug("Skip inserting to Postgres: no url")
+		return nil, nil
+	}
+	batch, err := out.frameToBatch(vars, frame, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(batch.rows) == 0 {
+		return nil, nil
+	}
+	out.mu.Lock()
+	out.add(batch)
+	full := out.numRows >= out.config.BatchSize
+	out.mu.Unlock()
+	if full {
+		select {
+		case out.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil, nil
+}
+
+// add appends rows to a buffered batch, must be called with mu held.
+func (out *PostgresFrameOutput) add(batch *postgresBatch) {
+	key := batch.table + "\x00" + strings.Join(batch.columns, "\x00")
+	existing, ok := out.batches[key]
+	if !ok {
+		existing = &postgresBatch{table: batch.table, columns: batch.columns}
+		out.batches[key] = existing
+	}
+	existing.rows = append(existing.rows, batch.rows...)
+	out.numRows += len(batch.rows)
+	if out.numRows > postgresMaxBufferedRows {
+		dropped := out.numRows - postgresMaxBufferedRows
+		logger.Warn("Postgres buffer is full, dropping rows", "numDropped", dropped)
+		if dropped > len(existing.rows) {
+			dropped = len(existing.rows)
+		}
+		existing.rows = existing.rows[dropped:]
+		out.numRows -= dropped
+	}
+}
+
+// frameToBatch maps frame fields to table columns. Without configured columns all
+// fields are inserted into columns with the same names.
+func (out *PostgresFrameOutput) frameToBatch(vars Vars, frame *data.Frame, now time.Time) (*postgresBatch, error) {
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	batch := &postgresBatch{table: out.table.render(vars, frame.Name, now)}
+
+	var fields []*data.Field
+	if len(out.config.Columns) == 0 {
+		for _, f := range frame.Fields {
+			batch.columns = append(batch.columns, f.Name)
+			fields = append(fields, f)
+		}
+	} else {
+		for _, c := range out.config.Columns {
+			i := fieldIndex(frame, c.Field)
+			if i < 0 {
+				return nil, fmt.Errorf("field %s not found", c.Field)
+			}
+			column := c.Column
+			if column == "" {
+				column = c.Field
+			}
+			batch.columns = append(batch.columns, column)
+			fields = append(fields, frame.Fields[i])
+		}
+	}
+
+	var labels []byte
+	if out.config.LabelsColumn != "" {
+		merged := data.Labels{}
+		for _, f := range fields {
+			for k, v := range f.Labels {
+				merged[k] = v
+			}
+		}
+		labels, err = json.Marshal(merged)
+		if err != nil {
+			return nil, err
+		}
+		batch.columns = append(batch.columns, out.config.LabelsColumn)
+	}
+
+	for row := 0; row < numRows; row++ {
+		values := make([]any, 0, len(batch.columns))
+		for _, f := range fields {
+			v, ok := f.ConcreteAt(row)
+			if !ok {
+				v = nil
+			}
+			values = append(values, v)
+		}
+		if labels != nil {
+			values = append(values, string(labels))
+		}
+		batch.rows = append(batch.rows, values)
+	}
+	return batch, nil
+}
+
+// Close stops periodic flushing, buffered rows are inserted once more in background
+// before the connection pool is released.
+func (out *PostgresFrameOutput) Close() error {
+	out.closeOnce.Do(func() { close(out.done) })
+	return nil
+}
+
+func (out *PostgresFrameOutput) flushPeriodically() {
+	ticker := time.NewTicker(postgresFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			out.flushBatches()
+		case <-out.flushCh:
+			out.flushBatches()
+		case <-out.done:
+			out.flushBatches()
+			postgresDBs.release(out.dsn)
+			return
+		}
+	}
+}
+
+func (out *PostgresFrameOutput) flushBatches() {
+	out.mu.Lock()
+	if out.numRows == 0 {
+		out.mu.Unlock()
+		return
+	}
+	batches := out.batches
+	out.batches = map[string]*postgresBatch{}
+	out.numRows = 0
+	out.mu.Unlock()
+
+	for _, batch := range batches {
+		err := out.insert(context.Background(), batch)
+		if err == nil {
+			continue
+		}
+		logger.Error("Error inserting to Postgres", "table", batch.table, "error", err)
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) {
+			// Connection problem, keep rows for the next attempt. Rows rejected by
+			// database would be rejected again, so they are dropped.
+			out.mu.Lock()
+			out.add(batch)
+			out.mu.Unlock()
+		}
+	}
+}
+
+// insert writes batch rows with multi-row INSERT statements.
+func (out *PostgresFrameOutput) insert(ctx context.Context, batch *postgresBatch) error {
+	if len(batch.columns) == 0 {
+		return nil
+	}
+	started := time.Now()
+	rowsPerStatement := out.config.BatchSize
+	if maxRows := postgresMaxParams / len(batch.columns); rowsPerStatement > maxRows {
+		rowsPerStatement = maxRows
+	}
+	for start := 0; start < len(batch.rows); start += rowsPerStatement {
+		end := start + rowsPerStatement
+		if end > len(batch.rows) {
+			end = len(batch.rows)
+		}
+		query, args := postgresInsertQuery(batch.table, batch.columns, batch.rows[start:end])
+		if _, err := out.db.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	logger.Debug("Successfully inserted to Postgres", "table", batch.table, "numRows", len(batch.rows), "elapsed", time.Since(started))
+	return nil
+}
+
+func postgresInsertQuery(table string, columns []string, rows [][]any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(quotePostgresTable(table))
+	sb.WriteString(" (")
+	for i, c := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(pq.QuoteIdentifier(c))
+	}
+	sb.WriteString(") VALUES ")
+	args := make([]any, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j, v := range row {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, v)
+			fmt.Fprintf(&sb, "$%d", len(args))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String(), args
+}
+
+// quotePostgresTable quotes a table name with optional schema.
+func quotePostgresTable(table string) string {
+	parts := strings.SplitN(table, ".", 2)
+	for i, p := range parts {
+		parts[i] = pq.QuoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
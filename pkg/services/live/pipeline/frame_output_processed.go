@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ProcessedOutput applies frame processors to a copy of a frame before passing it
+// to a child outputter, so other outputs of a rule (like managed stream for local
+// subscribers) get the frame unchanged. A frame dropped by a processor is not passed
+// to the child.
+type ProcessedOutput struct {
+	Processors []FrameProcessor
+	Outputter  FrameOutputter
+}
+
+func NewProcessedOutput(outputter FrameOutputter, processors ...FrameProcessor) *ProcessedOutput {
+	return &ProcessedOutput{Processors: processors, Outputter: outputter}
+}
+
+const FrameOutputTypeProcessed = "processed"
+
+func (out *ProcessedOutput) Type() string {
+	return FrameOutputTypeProcessed
+}
+
+func (out *ProcessedOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	// Processors may change a frame in place.
+	processed := copyFrame(frame)
+	for _, proc := range out.Processors {
+		var err error
+		processed, err = proc.ProcessFrame(ctx, vars, processed)
+		if err != nil {
+			return nil, err
+		}
+		if processed == nil {
+			return nil, nil
+		}
+	}
+	return out.Outputter.OutputFrame(ctx, vars, processed)
+}
+
+// copyFrame returns a deep copy of a frame.
+func copyFrame(frame *data.Frame) *data.Frame {
+	c := frame.EmptyCopy()
+	for i := 0; i < frame.Rows(); i++ {
+		c.AppendRow(frame.RowCopy(i)...)
+	}
+	return c
+}
@@ -0,0 +1,23 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessedOutput(t *testing.T) {
+	outputter := &generatorTestOutputter{frames: make(chan *data.Frame, 1)}
+	out := NewProcessedOutput(outputter, NewDropFieldsFrameProcessor(DropFieldsFrameProcessorConfig{FieldNames: []string{"secret"}}))
+	frame := data.NewFrame("test",
+		data.NewField("value", nil, []float64{1}),
+		data.NewField("secret", nil, []string{"x"}),
+	)
+	_, err := out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	processed := <-outputter.frames
+	require.Len(t, processed.Fields, 1)
+	require.Len(t, frame.Fields, 2)
+}
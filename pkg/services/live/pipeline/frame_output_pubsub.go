@@ -0,0 +1,224 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	defaultPubSubEndpoint = "https://pubsub.googleapis.com"
+	// defaultPubSubTopic is used when topic template is not set in config.
+	defaultPubSubTopic = "grafana-live"
+	pubSubScope        = "https://www.googleapis.com/auth/pubsub"
+	pubSubTimeout      = 10 * time.Second
+)
+
+// PubSubFrameOutput publishes frames encoded to JSON to a Google Cloud Pub/Sub topic with
+// Pub/Sub REST API. When ordering key field is set frame rows are grouped by a value of the
+// field and every group is published as a separate message with that ordering key.
+type PubSubFrameOutput struct {
+	// Endpoint is a Pub/Sub API URL, https://pubsub.googleapis.com by default.
+	Endpoint string
+
+	config            PubSubOutputConfig
+	topic             *nameTemplate
+	serviceAccountKey []byte
+	httpClient        *http.Client
+
+	mu      sync.Mutex
+	project string
+	tokens  oauth2.TokenSource
+}
+
+// NewPubSubFrameOutput creates PubSubFrameOutput. Service account key is a JSON key of
+// a service account, application default credentials are used when it's empty.
+func NewPubSubFrameOutput(endpoint string, serviceAccountKey string, config PubSubOutputConfig) (*PubSubFrameOutput, error) {
+	if config.Topic == "" {
+		config.Topic = defaultPubSubTopic
+	}
+	topic, err := parseNameTemplate(config.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		endpoint = defaultPubSubEndpoint
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid pub/sub endpoint: %w", err)
+	}
+	out := &PubSubFrameOutput{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		config:     config,
+		topic:      topic,
+		project:    config.Project,
+		httpClient: &http.Client{Timeout: pubSubTimeout},
+	}
+	if serviceAccountKey != "" {
+		out.serviceAccountKey = []byte(serviceAccountKey)
+		// Validate key early so that a rule with a broken key is not built.
+		if _, err := google.CredentialsFromJSON(context.Background(), out.serviceAccountKey, pubSubScope); err != nil {
+			return nil, fmt.Errorf("invalid service account key: %w", err)
+		}
+	}
+	return out, nil
+}
+
+const FrameOutputTypePubSub = "pubSub"
+
+func (out *PubSubFrameOutput) Type() string {
+	return FrameOutputTypePubSub
+}
+
+type pubSubMessage struct {
+	Data        string            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+type pubSubPublishRequest struct {
+	Messages []pubSubMessage `json:"messages"`
+}
+
+func (out *PubSubFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	tokens, project, err := out.getCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("error getting pub/sub credentials: %w", err)
+	}
+	if project == "" {
+		return nil, errors.New("pub/sub project is not set and can't be found in credentials")
+	}
+	topic := out.topic.render(vars, frame.Name, time.Now())
+
+	frames, keys, err := out.orderedFrames(frame)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, nil
+	}
+	attributes := map[string]string{
+		"orgId":   strconv.FormatInt(vars.OrgID, 10),
+		"channel": vars.Channel,
+	}
+	if vars.MessageID != "" {
+		attributes["messageId"] = vars.MessageID
+	}
+	request := pubSubPublishRequest{Messages: make([]pubSubMessage, 0, len(frames))}
+	for i, f := range frames {
+		frameJSON, err := data.FrameToJSON(f, data.IncludeAll)
+		if err != nil {
+			return nil, err
+		}
+		request.Messages = append(request.Messages, pubSubMessage{
+			Data:        base64.StdEncoding.EncodeToString(frameJSON),
+			Attributes:  attributes,
+			OrderingKey: keys[i],
+		})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	publishURL := fmt.Sprintf("%s/v1/projects/%s/topics/%s:publish", out.Endpoint, url.PathEscape(project), url.PathEscape(topic))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, publishURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tokens != nil {
+		token, err := tokens.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error getting pub/sub token: %w", err)
+		}
+		token.SetAuthHeader(req)
+	}
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error publishing to pub/sub: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected pub/sub response status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil, nil
+}
+
+// getCredentials lazily resolves a token source and a project, so that missing application
+// default credentials only fail publishing and not building a rule. A nil token source is
+// returned for a custom endpoint without credentials, like the Pub/Sub emulator.
+func (out *PubSubFrameOutput) getCredentials() (oauth2.TokenSource, string, error) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if out.tokens != nil {
+		return out.tokens, out.project, nil
+	}
+	if out.serviceAccountKey == nil && out.Endpoint != defaultPubSubEndpoint {
+		return nil, out.project, nil
+	}
+	var creds *google.Credentials
+	var err error
+	// Credentials outlive a single publish, so they must not be bound to its context.
+	if out.serviceAccountKey != nil {
+		creds, err = google.CredentialsFromJSON(context.Background(), out.serviceAccountKey, pubSubScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(context.Background(), pubSubScope)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	out.tokens = oauth2.ReuseTokenSource(nil, creds.TokenSource)
+	if out.project == "" {
+		out.project = creds.ProjectID
+	}
+	return out.tokens, out.project, nil
+}
+
+// orderedFrames splits frame by values of ordering key field preserving order of rows.
+// Rows with null value of the field are published without an ordering key.
+func (out *PubSubFrameOutput) orderedFrames(frame *data.Frame) ([]*data.Frame, []string, error) {
+	if out.config.OrderingKeyField == "" {
+		return []*data.Frame{frame}, []string{""}, nil
+	}
+	index := fieldIndex(frame, out.config.OrderingKeyField)
+	if index < 0 {
+		return nil, nil, fmt.Errorf("ordering key field not found: %s", out.config.OrderingKeyField)
+	}
+	var keys []string
+	groups := map[string]*data.Frame{}
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, nil, err
+	}
+	for row := 0; row < numRows; row++ {
+		key, _ := joinKey(frame.Fields[index], row)
+		g, ok := groups[key]
+		if !ok {
+			g = frame.EmptyCopy()
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.AppendRow(frame.RowCopy(row)...)
+	}
+	frames := make([]*data.Frame, 0, len(keys))
+	for _, key := range keys {
+		frames = append(frames, groups[key])
+	}
+	return frames, keys, nil
+}
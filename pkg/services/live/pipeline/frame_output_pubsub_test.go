@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func testPubSubServiceAccountKey(t *testing.T, tokenURL string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	keyJSON, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "test-project",
+		"private_key_id": "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "live@test-project.iam.gserviceaccount.com",
+		"token_uri":      tokenURL,
+	})
+	require.NoError(t, err)
+	return string(keyJSON)
+}
+
+func TestPubSubFrameOutput_OutputFrame(t *testing.T) {
+	var tokenRequests int32
+	published := make(chan pubSubPublishRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			atomic.AddInt32(&tokenRequests, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`))
+		case "/v1/projects/test-project/topics/live-test:publish":
+			require.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+			var req pubSubPublishRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			published <- req
+			_, _ = w.Write([]byte(`{"messageIds":["1"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	out, err := NewPubSubFrameOutput(server.URL, testPubSubServiceAccountKey(t, server.URL+"/token"), PubSubOutputConfig{
+		Topic:            "live-{namespace}",
+		OrderingKeyField: "device",
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test",
+		data.NewField("device", nil, []string{"a", "b", "a"}),
+		data.NewField("value", nil, []float64{1, 2, 3}),
+	)
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu", Scope: "stream", Namespace: "test", Path: "cpu"}
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+
+	req := <-published
+	require.Len(t, req.Messages, 2)
+	require.Equal(t, "a", req.Messages[0].OrderingKey)
+	require.Equal(t, "b", req.Messages[1].OrderingKey)
+	require.Equal(t, "stream/test/cpu", req.Messages[0].Attributes["channel"])
+
+	frameJSON, err := base64.StdEncoding.DecodeString(req.Messages[0].Data)
+	require.NoError(t, err)
+	decoded := &data.Frame{}
+	require.NoError(t, json.Unmarshal(frameJSON, decoded))
+	require.Equal(t, 2, decoded.Rows())
+	require.Equal(t, 3.0, decoded.Fields[1].At(1))
+
+	_, err = out.OutputFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	<-published
+	require.Equal(t, int32(1), atomic.LoadInt32(&tokenRequests))
+}
+
+func TestPubSubFrameOutput_Emulator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Empty(t, r.Header.Get("Authorization"))
+		require.Equal(t, "/v1/projects/local/topics/grafana-live:publish", r.URL.Path)
+		var req pubSubPublishRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Messages, 1)
+		require.Empty(t, req.Messages[0].OrderingKey)
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("topic not found"))
+	}))
+	defer server.Close()
+
+	out, err := NewPubSubFrameOutput(server.URL, "", PubSubOutputConfig{Project: "local"})
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, data.NewFrame("test", data.NewField("value", nil, []float64{1})))
+	require.ErrorContains(t, err, "topic not found")
+}
+
+func TestPubSubFrameOutput_Validation(t *testing.T) {
+	_, err := NewPubSubFrameOutput("", "{}", PubSubOutputConfig{})
+	require.ErrorContains(t, err, "invalid service account key")
+
+	out, err := NewPubSubFrameOutput("http://localhost:8085", "", PubSubOutputConfig{OrderingKeyField: "missing"})
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test"))
+	require.ErrorContains(t, err, "project is not set")
+
+	out, err = NewPubSubFrameOutput("http://localhost:8085", "", PubSubOutputConfig{Project: "local", OrderingKeyField: "missing"})
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test"))
+	require.ErrorContains(t, err, "ordering key field not found")
+}
@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/services/live/remotewrite"
+)
+
+const (
+	// defaultPushgatewayJob is used when job template is not set in config.
+	defaultPushgatewayJob = "grafana_live"
+	pushgatewayTimeout    = 5 * time.Second
+)
+
+// PushgatewayFrameOutput pushes numeric frame fields to a Prometheus Pushgateway as
+// gauges. Metric names and labels are the same as remote write output produces, the
+// last value of each series in a frame is pushed without a timestamp.
+type PushgatewayFrameOutput struct {
+	// Endpoint is a Pushgateway URL like http://localhost:9091.
+	Endpoint string
+	// BasicAuth is an optional basic auth params.
+	BasicAuth *BasicAuth
+
+	config     PushgatewayOutputConfig
+	job        *nameTemplate
+	grouping   []pushgatewayGroupingLabel
+	httpClient *http.Client
+}
+
+type pushgatewayGroupingLabel struct {
+	name  string
+	value *nameTemplate
+}
+
+func NewPushgatewayFrameOutput(endpoint string, basicAuth *BasicAuth, config PushgatewayOutputConfig) (*PushgatewayFrameOutput, error) {
+	if config.Job == "" {
+		config.Job = defaultPushgatewayJob
+	}
+	job, err := parseNameTemplate(config.Job)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(config.Grouping))
+	for name := range config.Grouping {
+		if name == "job" {
+			return nil, errors.New("job grouping label is set with job template")
+		}
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid grouping label name: %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	grouping := make([]pushgatewayGroupingLabel, 0, len(names))
+	for _, name := range names {
+		value, err := parseNameTemplate(config.Grouping[name])
+		if err != nil {
+			return nil, err
+		}
+		grouping = append(grouping, pushgatewayGroupingLabel{name: name, value: value})
+	}
+	return &PushgatewayFrameOutput{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		BasicAuth:  basicAuth,
+		config:     config,
+		job:        job,
+		grouping:   grouping,
+		httpClient: &http.Client{Timeout: pushgatewayTimeout},
+	}, nil
+}
+
+const FrameOutputTypePushgateway = "pushgateway"
+
+func (out *PushgatewayFrameOutput) Type() string {
+	return FrameOutputTypePushgateway
+}
+
+func (out *PushgatewayFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip pushing to Pushgateway: no url")
+		return nil, nil
+	}
+	now := time.Now()
+	groupingLabels := make(map[string]struct{}, len(out.grouping)+1)
+	groupingLabels["job"] = struct{}{}
+	path := pushgatewayPathSegment("job", out.job.render(vars, frame.Name, now))
+	for _, l := range out.grouping {
+		path += pushgatewayPathSegment(l.name, l.value.render(vars, frame.Name, now))
+		groupingLabels[l.name] = struct{}{}
+	}
+
+	body := encodePushgatewayMetrics(remotewrite.TimeSeriesFromFrames(frame), groupingLabels)
+	if len(body) == 0 {
+		return nil, nil
+	}
+	// PUT replaces all metrics of a group, POST only metrics with the same names.
+	method := http.MethodPost
+	if out.config.Replace {
+		method = http.MethodPut
+	}
+	req, err := http.NewRequestWithContext(ctx, method, out.Endpoint+"/metrics"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	if out.BasicAuth != nil {
+		req.SetBasicAuth(out.BasicAuth.User, out.BasicAuth.Password)
+	}
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error pushing to Pushgateway: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected Pushgateway response status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil, nil
+}
+
+// pushgatewayPathSegment encodes a grouping label for a Pushgateway URL path, values
+// which can't be a path segment are base64 encoded.
+func pushgatewayPathSegment(name, value string) string {
+	if value == "" {
+		return "/" + name + "@base64/="
+	}
+	if strings.Contains(value, "/") {
+		return "/" + name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return "/" + name + "/" + url.PathEscape(value)
+}
+
+// encodePushgatewayMetrics encodes the last sample of every time series as a gauge in
+// Prometheus text format. Labels which are part of the grouping key are dropped, as
+// Pushgateway sets them from the URL.
+func encodePushgatewayMetrics(timeSeries []prompb.TimeSeries, groupingLabels map[string]struct{}) []byte {
+	type metric struct {
+		labels string
+		value  float64
+	}
+	var names []string
+	metrics := map[string][]metric{}
+	seen := map[string]struct{}{}
+	for _, ts := range timeSeries {
+		if len(ts.Samples) == 0 {
+			continue
+		}
+		var name string
+		labels := make([]prompb.Label, 0, len(ts.Labels))
+		for _, l := range ts.Labels {
+			if l.Name == "__name__" {
+				name = l.Value
+				continue
+			}
+			if _, ok := groupingLabels[l.Name]; ok {
+				continue
+			}
+			labels = append(labels, l)
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+		var sb strings.Builder
+		for i, l := range labels {
+			if i == 0 {
+				sb.WriteByte('{')
+			} else {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(l.Name)
+			sb.WriteString(`="`)
+			sb.WriteString(escapePushgatewayLabelValue(l.Value))
+			sb.WriteByte('"')
+		}
+		if len(labels) > 0 {
+			sb.WriteByte('}')
+		}
+		key := name + sb.String()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if _, ok := metrics[name]; !ok {
+			names = append(names, name)
+		}
+		metrics[name] = append(metrics[name], metric{labels: sb.String(), value: ts.Samples[len(ts.Samples)-1].Value})
+	}
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		for _, m := range metrics[name] {
+			buf.WriteString(name)
+			buf.WriteString(m.labels)
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatFloat(m.value, 'g', -1, 64))
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+var pushgatewayLabelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapePushgatewayLabelValue(v string) string {
+	return pushgatewayLabelValueReplacer.Replace(v)
+}
@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/remotewrite"
+)
+
+func TestPushgatewayFrameOutput_OutputFrame(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		user   string
+		body   string
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		user, _, _ := r.BasicAuth()
+		requests <- request{method: r.Method, path: r.URL.EscapedPath(), user: user, body: string(body)}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	out, err := NewPushgatewayFrameOutput(server.URL, &BasicAuth{User: "admin", Password: "secret"}, PushgatewayOutputConfig{
+		Job:      "live_{namespace}",
+		Grouping: map[string]string{"channel": "{channel}", "instance": "a b"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{now, now.Add(time.Second)}),
+		data.NewField("usage", data.Labels{"host": "a", "channel": "dropped"}, []float64{0.5, 0.75}),
+		data.NewField("cores", nil, []int64{4, 4}),
+		data.NewField("state", nil, []string{"ok", "ok"}),
+	)
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu", Namespace: "test"}, frame)
+	require.NoError(t, err)
+
+	req := <-requests
+	require.Equal(t, http.MethodPost, req.method)
+	require.Equal(t, "/metrics/job/live_test/channel@base64/c3RyZWFtL3Rlc3QvY3B1/instance/a%20b", req.path)
+	require.Equal(t, "admin", req.user)
+	require.Equal(t, "# TYPE cpu_usage gauge\ncpu_usage{host=\"a\"} 0.75\n# TYPE cpu_cores gauge\ncpu_cores 4\n", req.body)
+}
+
+func TestPushgatewayFrameOutput_Replace(t *testing.T) {
+	methods := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods <- r.Method
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("pushed metrics are invalid"))
+	}))
+	defer server.Close()
+
+	out, err := NewPushgatewayFrameOutput(server.URL, nil, PushgatewayOutputConfig{Replace: true})
+	require.NoError(t, err)
+	frame := data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("usage", nil, []float64{1}),
+	)
+	_, err = out.OutputFrame(context.Background(), Vars{}, frame)
+	require.ErrorContains(t, err, "pushed metrics are invalid")
+	require.Equal(t, http.MethodPut, <-methods)
+}
+
+func TestNewPushgatewayFrameOutput_InvalidGrouping(t *testing.T) {
+	_, err := NewPushgatewayFrameOutput("http://localhost:9091", nil, PushgatewayOutputConfig{Grouping: map[string]string{"job": "x"}})
+	require.Error(t, err)
+	_, err = NewPushgatewayFrameOutput("http://localhost:9091", nil, PushgatewayOutputConfig{Grouping: map[string]string{"in-valid": "x"}})
+	require.ErrorContains(t, err, "invalid grouping label name")
+}
+
+func TestEncodePushgatewayMetrics_Escaping(t *testing.T) {
+	frame := data.NewFrame("m",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("v", data.Labels{"path": "C:\\dir \"x\""}, []float64{1}),
+	)
+	body := encodePushgatewayMetrics(remotewrite.TimeSeriesFromFrames(frame), map[string]struct{}{"job": {}})
+	require.Equal(t, "# TYPE m_v gauge\n"+`m_v{path="C:\\dir \"x\""} 1`+"\n", string(body))
+}
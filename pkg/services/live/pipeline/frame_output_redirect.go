@@ -3,23 +3,31 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
 // RedirectOutputConfig ...
 type RedirectOutputConfig struct {
+	// Channel template, supports {orgId}, {channel}, {scope}, {namespace}, {path} and
+	// {:name} placeholders, e.g. stream/sites/{:site} for stream/:site/:device rule.
 	Channel string `json:"channel"`
 }
 
 // RedirectFrameOutput passes processing control to the rule defined
 // for a configured channel.
 type RedirectFrameOutput struct {
-	config RedirectOutputConfig
+	config  RedirectOutputConfig
+	channel *nameTemplate
 }
 
-func NewRedirectFrameOutput(config RedirectOutputConfig) *RedirectFrameOutput {
-	return &RedirectFrameOutput{config: config}
+func NewRedirectFrameOutput(config RedirectOutputConfig) (*RedirectFrameOutput, error) {
+	channel, err := parseNameTemplate(config.Channel)
+	if err != nil {
+		return nil, err
+	}
+	return &RedirectFrameOutput{config: config, channel: channel}, nil
 }
 
 const FrameOutputTypeRedirect = "redirect"
@@ -29,11 +37,16 @@ func (out *RedirectFrameOutput) Type() string {
 }
 
 func (out *RedirectFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
-	if vars.Channel == out.config.Channel {
-		return nil, fmt.Errorf("redirect to the same channel: %s", out.config.Channel)
+	channel := out.targetChannel(vars, frame)
+	if vars.Channel == channel {
+		return nil, fmt.Errorf("redirect to the same channel: %s", channel)
 	}
 	return []*ChannelFrame{{
-		Channel: out.config.Channel,
+		Channel: channel,
 		Frame:   frame,
 	}}, nil
 }
+
+func (out *RedirectFrameOutput) targetChannel(vars Vars, frame *data.Frame) string {
+	return out.channel.render(vars, frame.Name, time.Now())
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedirectFrameOutput(t *testing.T, channel string) *RedirectFrameOutput {
+	t.Helper()
+	out, err := NewRedirectFrameOutput(RedirectOutputConfig{Channel: channel})
+	require.NoError(t, err)
+	return out
+}
+
+func TestRedirectFrameOutput_channelParams(t *testing.T) {
+	rule := &LiveChannelRule{Pattern: "stream/:site/:device"}
+	vars := Vars{
+		Channel: "stream/berlin/pump-1",
+		Params:  rule.ChannelParams("stream/berlin/pump-1"),
+	}
+	out := newTestRedirectFrameOutput(t, "stream/sites/{:site}")
+	frames, err := out.OutputFrame(context.Background(), vars, data.NewFrame("test"))
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	require.Equal(t, "stream/sites/berlin", frames[0].Channel)
+
+	_, err = NewRedirectFrameOutput(RedirectOutputConfig{Channel: "stream/{:}"})
+	require.Error(t, err)
+}
+
+func TestLiveChannelRule_ChannelParams(t *testing.T) {
+	rule := &LiveChannelRule{Pattern: "stream/:site/:device"}
+	require.Equal(t, map[string]string{"site": "berlin", "device": "pump-1"}, rule.ChannelParams("stream/berlin/pump-1"))
+
+	rule = &LiveChannelRule{Pattern: "stream/telegraf/*path"}
+	require.Equal(t, map[string]string{"path": "cpu/total"}, rule.ChannelParams("stream/telegraf/cpu/total"))
+
+	rule = &LiveChannelRule{Pattern: "stream/telegraf/cpu"}
+	require.Nil(t, rule.ChannelParams("stream/telegraf/cpu"))
+}
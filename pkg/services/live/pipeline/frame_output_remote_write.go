@@ -5,18 +5,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/prompb"
 
 	"github.com/grafana/grafana/pkg/services/live/remotewrite"
 )
 
-const flushInterval = 15 * time.Second
+const (
+	flushInterval = 15 * time.Second
+	// defaultMaxBufferSamples bounds samples kept in memory while remote write
+	// endpoint is unavailable, oldest samples are dropped first.
+	defaultMaxBufferSamples = 1000000
+)
 
+// RemoteWriteFrameOutput batches time series of all frames passed to it and sends
+// them to remote write endpoint when FlushInterval passes or MaxBatchSamples samples
+// are buffered, so the endpoint gets one request per batch instead of one per frame.
 type RemoteWriteFrameOutput struct {
 	mu sync.Mutex
 
@@ -26,30 +39,58 @@ type RemoteWriteFrameOutput struct {
 	// BasicAuth is an optional basic auth params.
 	BasicAuth *BasicAuth
 
+	// BearerToken is an optional token sent in Authorization header, it's used
+	// instead of BasicAuth when set.
+	BearerToken string
+
 	// SampleMilliseconds allow defining an interval to sample points inside a channel
 	// when outputting to remote write endpoint (on __name__ label basis). For example
 	// when having a 20Hz stream and SampleMilliseconds 1000 then only one point in a
 	// second will be sent to remote write endpoint. This reduces data resolution of course.
 	// If not set - then no down-sampling will be performed. If SampleMilliseconds is
-	// greater than flushInterval then each flush will include a point as we only keeping
+	// greater than FlushInterval then each flush will include a point as we only keeping
 	// track of timestamps in terms of each individual flush at the moment.
 	SampleMilliseconds int64
 
+	// RelabelConfigs are applied to time series labels before buffering, so sampling
+	// works with resulting series names.
+	RelabelConfigs []*relabel.Config
+
+	// labels are added to all time series before relabeling, see SetLabels.
+	labels []remoteWriteLabel
+
+	// FlushInterval is a max time samples are buffered for, 15s by default.
+	FlushInterval time.Duration
+	// MaxBatchSamples triggers a flush before FlushInterval passes when that many
+	// samples are buffered, flushes happen by time only when zero.
+	MaxBatchSamples int
+	// MaxBufferSamples is a max number of samples kept when flushes fail, 1000000
+	// by default.
+	MaxBufferSamples int
+
 	httpClient *http.Client
-	buffer     []prompb.TimeSeries
+	// wal persists time series instead of buffer when set, see useWAL.
+	wal    *remoteWriteWAL
+	buffer []prompb.TimeSeries
+	// numSamples in buffer.
+	numSamples int
+	flushCh    chan struct{}
+	startOnce  sync.Once
+	closeOnce  sync.Once
+	done       chan struct{}
+	// backend reports requests and queue depth per write config.
+	backend remoteWriteBackend
 }
 
 func NewRemoteWriteFrameOutput(endpoint string, basicAuth *BasicAuth, sampleMilliseconds int64) *RemoteWriteFrameOutput {
-	out := &RemoteWriteFrameOutput{
+	return &RemoteWriteFrameOutput{
 		Endpoint:           endpoint,
 		BasicAuth:          basicAuth,
 		SampleMilliseconds: sampleMilliseconds,
 		httpClient:         &http.Client{Timeout: 2 * time.Second},
+		flushCh:            make(chan struct{}, 1),
+		done:               make(chan struct{}),
 	}
-	if out.Endpoint != "" {
-		go out.flushPeriodically()
-	}
-	return out
 }
 
 const FrameOutputTypeRemoteWrite = "remoteWrite"
@@ -58,27 +99,172 @@ func (out *RemoteWriteFrameOutput) Type() string {
 	return FrameOutputTypeRemoteWrite
 }
 
+// useWAL makes output keep time series in a WAL in dir, shared by outputs of the same
+// backend. Flushing starts right away to send records left from a previous run.
+func (out *RemoteWriteFrameOutput) useWAL(dir string) error {
+	wal, err := openRemoteWriteWAL(dir)
+	if err != nil {
+		return err
+	}
+	out.wal = wal
+	if out.Endpoint != "" {
+		wal.addOutput(out)
+	}
+	return nil
+}
+
+// Close stops the flusher, buffered samples are sent once more in background without
+// retries. Outputs using a WAL stop sending its records, the WAL flusher is stopped
+// when no other outputs use the WAL.
+func (out *RemoteWriteFrameOutput) Close() error {
+	out.closeOnce.Do(func() {
+		close(out.done)
+		if out.wal != nil {
+			out.wal.removeOutput(out)
+		}
+	})
+	return nil
+}
+
+func (out *RemoteWriteFrameOutput) flushInterval() time.Duration {
+	if out.FlushInterval > 0 {
+		return out.FlushInterval
+	}
+	return flushInterval
+}
+
+// flushPeriodically is started with the first output, so batching settings can
+// be set after the output is created.
 func (out *RemoteWriteFrameOutput) flushPeriodically() {
-	for range time.NewTicker(flushInterval).C {
+	interval := out.flushInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		closed := false
+		select {
+		case <-ticker.C:
+		case <-out.flushCh:
+			ticker.Reset(interval)
+		case <-out.done:
+			closed = true
+		}
 		out.mu.Lock()
 		if len(out.buffer) == 0 {
 			out.mu.Unlock()
+			if closed {
+				return
+			}
 			continue
 		}
-		tmpBuffer := make([]prompb.TimeSeries, len(out.buffer))
-		copy(tmpBuffer, out.buffer)
+		tmpBuffer := out.buffer
 		out.buffer = nil
+		out.numSamples = 0
 		out.mu.Unlock()
 
 		err := out.flush(tmpBuffer)
+		if closed {
+			if err != nil {
+				logger.Error("Error flush to remote write on close", "error", err, "numSamples", countSamples(tmpBuffer))
+			}
+			out.backend.setQueued(0)
+			return
+		}
+		out.mu.Lock()
 		if err != nil {
 			logger.Error("Error flush to remote write", "error", err)
-			out.mu.Lock()
-			// TODO: drop in case of large buffer size? Make several attempts only?
+			out.backend.retry()
 			out.buffer = append(tmpBuffer, out.buffer...)
-			out.mu.Unlock()
+			out.numSamples += countSamples(tmpBuffer)
+			out.trimBuffer()
+		}
+		out.backend.setQueued(out.numSamples)
+		out.mu.Unlock()
+	}
+}
+
+// Pending returns a number of samples not sent yet.
+func (out *RemoteWriteFrameOutput) Pending() int {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	return out.numSamples
+}
+
+// Drain sends buffered samples once. Samples kept in WAL are synced to disk and
+// are sent after restart when the endpoint does not accept them, so they are not
+// reported as dropped.
+func (out *RemoteWriteFrameOutput) Drain(ctx context.Context) (int, error) {
+	if out.wal != nil {
+		if err := out.wal.sync(); err != nil {
+			return 0, err
+		}
+		return 0, runWithContext(ctx, func() error {
+			return out.wal.flush(out.flush, out.MaxBatchSamples)
+		})
+	}
+	out.mu.Lock()
+	buffer := out.buffer
+	out.buffer = nil
+	out.numSamples = 0
+	out.backend.setQueued(0)
+	out.mu.Unlock()
+	if len(buffer) == 0 {
+		return 0, nil
+	}
+	if err := runWithContext(ctx, func() error { return out.flush(buffer) }); err != nil {
+		return countSamples(buffer), err
+	}
+	return 0, nil
+}
+
+// trimBuffer drops oldest time series when buffer exceeds MaxBufferSamples, must be
+// called with mu held.
+func (out *RemoteWriteFrameOutput) trimBuffer() {
+	maxSamples := out.MaxBufferSamples
+	if maxSamples <= 0 {
+		maxSamples = defaultMaxBufferSamples
+	}
+	dropped := 0
+	for out.numSamples > maxSamples && len(out.buffer) > 0 {
+		out.numSamples -= len(out.buffer[0].Samples)
+		dropped += len(out.buffer[0].Samples)
+		out.buffer = out.buffer[1:]
+	}
+	if dropped > 0 {
+		logger.Warn("Remote write buffer is full, dropping samples", "url", out.Endpoint, "numSamples", dropped)
+	}
+}
+
+func countSamples(timeSeries []prompb.TimeSeries) int {
+	numSamples := 0
+	for _, ts := range timeSeries {
+		numSamples += len(ts.Samples)
+	}
+	return numSamples
+}
+
+// mergeTimeSeries merges samples of time series with the same labels, so labels of
+// a series are encoded once per batch.
+func mergeTimeSeries(timeSeries []prompb.TimeSeries) []prompb.TimeSeries {
+	index := make(map[string]int, len(timeSeries))
+	merged := make([]prompb.TimeSeries, 0, len(timeSeries))
+	var sb strings.Builder
+	for _, ts := range timeSeries {
+		sb.Reset()
+		for _, l := range ts.Labels {
+			sb.WriteString(l.Name)
+			sb.WriteByte(0xff)
+			sb.WriteString(l.Value)
+			sb.WriteByte(0xff)
+		}
+		key := sb.String()
+		if i, ok := index[key]; ok {
+			merged[i].Samples = append(merged[i].Samples, ts.Samples...)
+			continue
 		}
+		index[key] = len(merged)
+		merged = append(merged, prompb.TimeSeries{Labels: ts.Labels, Samples: append([]prompb.Sample(nil), ts.Samples...)})
 	}
+	return merged
 }
 
 func (out *RemoteWriteFrameOutput) sample(timeSeries []prompb.TimeSeries) []prompb.TimeSeries {
@@ -127,19 +313,13 @@ func (out *RemoteWriteFrameOutput) sample(timeSeries []prompb.TimeSeries) []prom
 }
 
 func (out *RemoteWriteFrameOutput) flush(timeSeries []prompb.TimeSeries) error {
-	numSamples := 0
-	for _, ts := range timeSeries {
-		numSamples += len(ts.Samples)
-	}
-	logger.Debug("Remote write flush", "numTimeSeries", len(timeSeries), "numSamples", numSamples)
+	logger.Debug("Remote write flush", "numTimeSeries", len(timeSeries), "numSamples", countSamples(timeSeries))
 
 	if out.SampleMilliseconds > 0 {
 		timeSeries = out.sample(timeSeries)
-		numSamples = 0
-		for _, ts := range timeSeries {
-			numSamples += len(ts.Samples)
-		}
-		logger.Debug("After down-sampling", "numTimeSeries", len(timeSeries), "numSamples", numSamples)
+		logger.Debug("After down-sampling", "numTimeSeries", len(timeSeries), "numSamples", countSamples(timeSeries))
+	} else {
+		timeSeries = mergeTimeSeries(timeSeries)
 	}
 	remoteWriteData, err := remotewrite.TimeSeriesToBytes(timeSeries)
 	if err != nil {
@@ -153,32 +333,133 @@ func (out *RemoteWriteFrameOutput) flush(timeSeries []prompb.TimeSeries) error {
 	req.Header.Set("Content-Type", "application/x-protobuf")
 	req.Header.Set("Content-Encoding", "snappy")
 	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
-	if out.BasicAuth != nil {
+	if out.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+out.BearerToken)
+	} else if out.BasicAuth != nil {
 		req.SetBasicAuth(out.BasicAuth.User, out.BasicAuth.Password)
 	}
 
 	started := time.Now()
 	resp, err := out.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error sending remote write request: %w", err)
+		err = fmt.Errorf("error sending remote write request: %w", err)
+		out.backend.failed(remoteWriteErrorNetwork, err)
+		return err
 	}
-	_ = resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusBadRequest && out.wal != nil {
+		// Samples sent before a restart, but not checkpointed, are rejected by
+		// backends as duplicate or out of order.
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		msg := strings.ToLower(string(body))
+		if strings.Contains(msg, "duplicate") || strings.Contains(msg, "out of order") || strings.Contains(msg, "out-of-order") {
+			logger.Debug("Remote write endpoint rejected already written samples", "url", out.Endpoint, "response", string(body))
+			return errRemoteWriteDuplicate
+		}
+	}
+	if resp.StatusCode/100 != 2 {
 		logger.Error("Unexpected response code from remote write endpoint", "code", resp.StatusCode)
+		class := remoteWriteErrorClient
+		if resp.StatusCode >= 500 {
+			class = remoteWriteErrorServer
+		}
+		out.backend.failed(class, fmt.Errorf("unexpected response code %d", resp.StatusCode))
 		return errors.New("unexpected response code from remote write endpoint")
 	}
 	logger.Debug("Successfully sent to remote write endpoint", "url", out.Endpoint, "elapsed", time.Since(started))
+	out.backend.sent(len(remoteWriteData), countSamples(timeSeries))
 	return nil
 }
 
-func (out *RemoteWriteFrameOutput) OutputFrame(_ context.Context, _ Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+type remoteWriteLabel struct {
+	name  string
+	value *nameTemplate
+}
+
+// SetLabels sets labels added to all time series. Values are templates supporting
+// {orgId}, {channel}, {scope}, {namespace}, {path}, {frame} and {:name} placeholders,
+// labels of frames with the same names are replaced.
+func (out *RemoteWriteFrameOutput) SetLabels(labels map[string]string) error {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if !model.LabelName(name).IsValid() || name == model.MetricNameLabel {
+			return fmt.Errorf("invalid label name: %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out.labels = make([]remoteWriteLabel, 0, len(names))
+	for _, name := range names {
+		value, err := parseNameTemplate(labels[name])
+		if err != nil {
+			return err
+		}
+		out.labels = append(out.labels, remoteWriteLabel{name: name, value: value})
+	}
+	return nil
+}
+
+// addLabels adds rendered labels to time series keeping labels sorted by name.
+func (out *RemoteWriteFrameOutput) addLabels(timeSeries []prompb.TimeSeries, vars Vars, frame *data.Frame) []prompb.TimeSeries {
+	if len(out.labels) == 0 {
+		return timeSeries
+	}
+	now := time.Now()
+	added := make(map[string]string, len(out.labels))
+	for _, l := range out.labels {
+		added[l.name] = l.value.render(vars, frame.Name, now)
+	}
+	for i, ts := range timeSeries {
+		labels := make([]prompb.Label, 0, len(ts.Labels)+len(added))
+		for _, l := range ts.Labels {
+			if _, ok := added[l.Name]; !ok {
+				labels = append(labels, l)
+			}
+		}
+		for _, l := range out.labels {
+			labels = append(labels, prompb.Label{Name: l.name, Value: added[l.name]})
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+		timeSeries[i].Labels = labels
+	}
+	return timeSeries
+}
+
+func (out *RemoteWriteFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
 	if out.Endpoint == "" {
 		logger.Debug("Skip sending to remote write: no url")
 		return nil, nil
 	}
-	ts := remotewrite.TimeSeriesFromFramesLabelsColumn(frame)
+	if out.wal == nil {
+		out.startOnce.Do(func() {
+			go out.flushPeriodically()
+		})
+	}
+	ts := out.addLabels(remotewrite.TimeSeriesFromFramesLabelsColumn(frame), vars, frame)
+	ts = relabelTimeSeries(ts, out.RelabelConfigs)
+	if out.wal != nil && len(ts) > 0 {
+		if err := out.wal.append(ts); err != nil {
+			return nil, err
+		}
+	}
 	out.mu.Lock()
-	out.buffer = append(out.buffer, ts...)
+	if out.wal == nil {
+		out.buffer = append(out.buffer, ts...)
+	}
+	out.numSamples += countSamples(ts)
+	out.trimBuffer()
+	out.backend.setQueued(out.numSamples)
+	full := out.MaxBatchSamples > 0 && out.numSamples >= out.MaxBatchSamples
 	out.mu.Unlock()
+	if full {
+		if out.wal != nil {
+			out.wal.requestFlush()
+		} else {
+			select {
+			case out.flushCh <- struct{}{}:
+			default:
+			}
+		}
+	}
 	return nil, nil
 }
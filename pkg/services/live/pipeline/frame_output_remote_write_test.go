@@ -1,9 +1,18 @@
 package pipeline
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/require"
 )
@@ -143,3 +152,225 @@ func TestRemoteWriteFrameOutput_sample_merge(t *testing.T) {
 	require.Equal(t, expectedSamples[sampledTimeSeries[0].Labels[0].Value], sampledTimeSeries[0].Samples)
 	require.Equal(t, expectedSamples[sampledTimeSeries[1].Labels[0].Value], sampledTimeSeries[1].Samples)
 }
+
+func TestRemoteWriteFrameOutput_batching(t *testing.T) {
+	var mu sync.Mutex
+	var requests []prompb.WriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		decoded, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		var req prompb.WriteRequest
+		require.NoError(t, proto.Unmarshal(decoded, &req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	out := NewRemoteWriteFrameOutput(server.URL, nil, 0)
+	out.FlushInterval = time.Hour
+	out.MaxBatchSamples = 3
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		frame := data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{now.Add(time.Duration(i) * time.Second)}),
+			data.NewField("value", nil, []float64{float64(i)}),
+		)
+		_, err := out.OutputFrame(context.Background(), Vars{}, frame)
+		require.NoError(t, err)
+	}
+
+	// Samples of 3 frames are sent in a single request with a single series.
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests[0].Timeseries, 1)
+	require.Len(t, requests[0].Timeseries[0].Samples, 3)
+}
+
+func TestRemoteWriteFrameOutput_bearerToken(t *testing.T) {
+	authorization := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization <- r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	out := NewRemoteWriteFrameOutput(server.URL, &BasicAuth{User: "admin", Password: "secret"}, 0)
+	out.BearerToken = "token"
+	out.FlushInterval = time.Hour
+	out.MaxBatchSamples = 1
+
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("value", nil, []float64{1}),
+	)
+	_, err := out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	select {
+	case header := <-authorization:
+		require.Equal(t, "Bearer token", header)
+	case <-time.After(5 * time.Second):
+		t.Fatal("remote write request not sent")
+	}
+}
+
+func TestRemoteWriteFrameOutput_labels(t *testing.T) {
+	out := NewRemoteWriteFrameOutput("", nil, 0)
+	require.NoError(t, out.SetLabels(map[string]string{"site": "{:site}", "host": "{namespace}"}))
+	require.Error(t, out.SetLabels(map[string]string{"__name__": "x"}))
+	require.Error(t, out.SetLabels(map[string]string{"bad-name": "x"}))
+
+	vars := Vars{Namespace: "telegraf", Params: map[string]string{"site": "berlin"}}
+	ts := out.addLabels([]prompb.TimeSeries{{Labels: []prompb.Label{
+		{Name: "__name__", Value: "cpu"},
+		{Name: "host", Value: "a"},
+		{Name: "zone", Value: "eu"},
+	}}}, vars, data.NewFrame("cpu"))
+	require.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "cpu"},
+		{Name: "host", Value: "telegraf"},
+		{Name: "site", Value: "berlin"},
+		{Name: "zone", Value: "eu"},
+	}, ts[0].Labels)
+}
+
+func TestRemoteWriteFrameOutput_trimBuffer(t *testing.T) {
+	out := NewRemoteWriteFrameOutput("", nil, 0)
+	out.MaxBufferSamples = 2
+	out.buffer = []prompb.TimeSeries{
+		{Samples: []prompb.Sample{{Value: 1}}},
+		{Samples: []prompb.Sample{{Value: 2}}},
+		{Samples: []prompb.Sample{{Value: 3}}},
+	}
+	out.numSamples = 3
+	out.trimBuffer()
+	require.Equal(t, 2, out.numSamples)
+	require.Equal(t, float64(2), out.buffer[0].Samples[0].Value)
+}
+
+func TestRemoteWriteFrameOutput_backendHealth(t *testing.T) {
+	codes := []int{http.StatusServiceUnavailable, http.StatusBadRequest, http.StatusOK}
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(codes[calls])
+		calls++
+	}))
+	defer server.Close()
+
+	out := NewRemoteWriteFrameOutput(server.URL, nil, 0)
+	out.backend = remoteWriteBackend{orgID: 101, uid: "test-backend"}
+	timeSeries := []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "test"}},
+		Samples: []prompb.Sample{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}},
+	}}
+
+	require.Error(t, out.flush(timeSeries))
+	backends := RemoteWriteBackends.List(101)
+	require.Len(t, backends, 1)
+	require.False(t, backends[0].Healthy)
+	require.Equal(t, int64(1), backends[0].ServerErrors)
+
+	require.Error(t, out.flush(timeSeries))
+	require.NoError(t, out.flush(timeSeries))
+	backends = RemoteWriteBackends.List(101)
+	require.Len(t, backends, 1)
+	require.True(t, backends[0].Healthy)
+	require.Equal(t, "test-backend", backends[0].UID)
+	require.Equal(t, int64(1), backends[0].ClientErrors)
+	require.Equal(t, int64(2), backends[0].SamplesSent)
+	require.Positive(t, backends[0].BytesSent)
+	require.Empty(t, RemoteWriteBackends.List(102))
+}
+
+// waitGoroutines waits for goroutines started by a test to exit, it polls in the
+// test goroutine as require.Eventually runs conditions in a new goroutine.
+func waitGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines are running, expected %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type remoteWriteWALBuilder struct {
+	dir      string
+	endpoint string
+}
+
+func (b *remoteWriteWALBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	out := NewRemoteWriteFrameOutput(b.endpoint, nil, 0)
+	if err := out.useWAL(b.dir); err != nil {
+		return nil, err
+	}
+	return []*LiveChannelRule{{
+		OrgId:           1,
+		Pattern:         "stream/test/wal",
+		FrameOutputters: []FrameOutputter{out},
+	}}, nil
+}
+
+func TestRemoteWriteFrameOutput_walRebuild(t *testing.T) {
+	dir := t.TempDir()
+	wal := reopenWAL(t, dir)
+	goroutines := runtime.NumGoroutine()
+
+	s := NewStaticSegmentedTree(&remoteWriteWALBuilder{dir: dir, endpoint: "http://localhost:9090/api/v1/write"})
+	_, ok, err := s.Get(1, "stream/test/wal")
+	require.NoError(t, err)
+	require.True(t, ok)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Refresh())
+	}
+
+	// Outputs of replaced rules are removed from the WAL, which has a single flusher.
+	wal.outputsMu.Lock()
+	require.Len(t, wal.outputs, 1)
+	wal.outputsMu.Unlock()
+	require.LessOrEqual(t, runtime.NumGoroutine(), goroutines+1)
+
+	require.NoError(t, s.Close())
+	wal.outputsMu.Lock()
+	require.Empty(t, wal.outputs)
+	require.Nil(t, wal.done)
+	wal.outputsMu.Unlock()
+	waitGoroutines(t, goroutines)
+}
+
+func TestRemoteWriteFrameOutput_Close(t *testing.T) {
+	requests := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+	}))
+	defer server.Close()
+	goroutines := runtime.NumGoroutine()
+
+	out := NewRemoteWriteFrameOutput(server.URL, nil, 0)
+	out.httpClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	out.FlushInterval = time.Hour
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("value", nil, []float64{1}),
+	)
+	_, err := out.OutputFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+
+	// Buffered samples are sent on close and the flusher stops.
+	require.NoError(t, out.Close())
+	require.NoError(t, out.Close())
+	select {
+	case <-requests:
+	case <-time.After(5 * time.Second):
+		t.Fatal("buffered samples were not sent on close")
+	}
+	waitGoroutines(t, goroutines)
+}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultRetryMaxAttempts    = 5
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 30 * time.Second
+	defaultRetryQueueSize      = 1000
+)
+
+// RetryOutput passes frames to a child outputter, frames it fails to output are
+// queued and retried in the background with exponential backoff. The queue is
+// bounded, an error is returned when a frame can't be queued so it's handled like
+// any other output failure (e.g. sent to a dead letter output). Frames returned by
+// a child on retries are not processed further.
+type RetryOutput struct {
+	Outputter FrameOutputter
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	after          func(time.Duration) <-chan time.Time
+
+	queue     chan retryItem
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+type retryItem struct {
+	vars  Vars
+	frame *data.Frame
+	// attempts made so far.
+	attempts int
+}
+
+func NewRetryOutput(outputter FrameOutputter, config RetryOutputConfig) (*RetryOutput, error) {
+	if config.MaxAttempts < 0 || config.QueueSize < 0 || config.InitialBackoffMilliseconds < 0 || config.MaxBackoffMilliseconds < 0 {
+		return nil, errors.New("retry settings can't be negative")
+	}
+	out := &RetryOutput{
+		Outputter:      outputter,
+		maxAttempts:    config.MaxAttempts,
+		initialBackoff: time.Duration(config.InitialBackoffMilliseconds) * time.Millisecond,
+		maxBackoff:     time.Duration(config.MaxBackoffMilliseconds) * time.Millisecond,
+		after:          time.After,
+		done:           make(chan struct{}),
+	}
+	if out.maxAttempts == 0 {
+		out.maxAttempts = defaultRetryMaxAttempts
+	}
+	if out.initialBackoff == 0 {
+		out.initialBackoff = defaultRetryInitialBackoff
+	}
+	if out.maxBackoff == 0 {
+		out.maxBackoff = defaultRetryMaxBackoff
+	}
+	if out.maxBackoff < out.initialBackoff {
+		return nil, fmt.Errorf("max backoff %s is less than initial backoff %s", out.maxBackoff, out.initialBackoff)
+	}
+	queueSize := config.QueueSize
+	if queueSize == 0 {
+		queueSize = defaultRetryQueueSize
+	}
+	out.queue = make(chan retryItem, queueSize)
+	return out, nil
+}
+
+const FrameOutputTypeRetry = "retry"
+
+func (out *RetryOutput) Type() string {
+	return FrameOutputTypeRetry
+}
+
+// Close stops retrying, frames waiting for retry are dropped.
+func (out *RetryOutput) Close() error {
+	out.closeOnce.Do(func() { close(out.done) })
+	return nil
+}
+
+func (out *RetryOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	channelFrames, err := out.Outputter.OutputFrame(ctx, vars, frame)
+	if err == nil {
+		return channelFrames, nil
+	}
+	if out.maxAttempts <= 1 {
+		return nil, err
+	}
+	out.startOnce.Do(func() {
+		go out.run()
+	})
+	select {
+	case out.queue <- retryItem{vars: vars, frame: frame, attempts: 1}:
+		logger.Debug("Frame output queued for retry", "channel", vars.Channel, "output", out.Outputter.Type(), "error", err)
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("retry queue is full: %w", err)
+	}
+}
+
+// run retries queued frames one by one, so the order of frames is kept and a
+// failing backend is not flooded with requests.
+func (out *RetryOutput) run() {
+	for {
+		select {
+		case item := <-out.queue:
+			out.retry(item)
+		case <-out.done:
+			if n := len(out.queue); n > 0 {
+				logger.Warn("Dropping frames queued for retry on close", "output", out.Outputter.Type(), "frames", n)
+			}
+			return
+		}
+	}
+}
+
+// retry outputs a frame until it succeeds, attempts are exhausted or the output is
+// closed.
+func (out *RetryOutput) retry(item retryItem) {
+	backoff := out.initialBackoff
+	for item.attempts < out.maxAttempts {
+		select {
+		case <-out.after(backoff):
+		case <-out.done:
+			return
+		}
+		item.attempts++
+		_, err := out.Outputter.OutputFrame(context.Background(), item.vars, item.frame)
+		if err == nil {
+			return
+		}
+		if item.attempts >= out.maxAttempts {
+			logger.Error("Dropping frame after retries", "channel", item.vars.Channel, "output", out.Outputter.Type(), "attempts", item.attempts, "error", err)
+			return
+		}
+		backoff *= 2
+		if backoff > out.maxBackoff {
+			backoff = out.maxBackoff
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyOutputter fails first failures calls.
+type flakyOutputter struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	frames   []*data.Frame
+}
+
+func (o *flakyOutputter) Type() string {
+	return "flaky"
+}
+
+func (o *flakyOutputter) OutputFrame(_ context.Context, _ Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls++
+	if o.calls <= o.failures {
+		return nil, errors.New("503 service unavailable")
+	}
+	o.frames = append(o.frames, frame)
+	return nil, nil
+}
+
+func (o *flakyOutputter) numCalls() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.calls
+}
+
+func TestRetryOutput_OutputFrame(t *testing.T) {
+	child := &flakyOutputter{failures: 2}
+	out, err := NewRetryOutput(child, RetryOutputConfig{InitialBackoffMilliseconds: 100, MaxBackoffMilliseconds: 150})
+	require.NoError(t, err)
+	var mu sync.Mutex
+	var backoffs []time.Duration
+	out.after = func(d time.Duration) <-chan time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		backoffs = append(backoffs, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+
+	frame := data.NewFrame("test")
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return child.numCalls() == 3 }, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	require.Equal(t, []time.Duration{100 * time.Millisecond, 150 * time.Millisecond}, backoffs)
+	mu.Unlock()
+	require.Equal(t, []*data.Frame{frame}, child.frames)
+}
+
+func TestRetryOutput_queueFull(t *testing.T) {
+	child := &flakyOutputter{failures: 100}
+	out, err := NewRetryOutput(child, RetryOutputConfig{QueueSize: 1, MaxAttempts: 2})
+	require.NoError(t, err)
+	block := make(chan time.Time)
+	out.after = func(time.Duration) <-chan time.Time { return block }
+	defer close(block)
+
+	// The first frame is taken by the retry loop, the second one fills the queue.
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(out.queue) == 0 }, time.Second, 5*time.Millisecond)
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test"))
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test"))
+	require.ErrorContains(t, err, "retry queue is full")
+}
+
+func TestRetryOutput_Close(t *testing.T) {
+	child := &flakyOutputter{failures: 100}
+	out, err := NewRetryOutput(child, RetryOutputConfig{MaxAttempts: 2})
+	require.NoError(t, err)
+	waiting := make(chan struct{})
+	out.after = func(time.Duration) <-chan time.Time {
+		close(waiting)
+		return nil
+	}
+
+	_, err = out.OutputFrame(context.Background(), Vars{}, data.NewFrame("test"))
+	require.NoError(t, err)
+	<-waiting
+
+	// A frame waiting for backoff is dropped on close without another attempt.
+	require.NoError(t, out.Close())
+	require.NoError(t, out.Close())
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, 1, child.numCalls())
+}
+
+func TestNewRetryOutput_invalid(t *testing.T) {
+	_, err := NewRetryOutput(&testOutputter{}, RetryOutputConfig{InitialBackoffMilliseconds: 1000, MaxBackoffMilliseconds: 100})
+	require.Error(t, err)
+	_, err = NewRetryOutput(&testOutputter{}, RetryOutputConfig{QueueSize: -1})
+	require.Error(t, err)
+}
@@ -0,0 +1,479 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	s3FormatNDJSON  = "ndjson"
+	s3FormatParquet = "parquet"
+
+	// defaultS3Key is used when key template is not set in config.
+	defaultS3Key            = "grafana-live/{orgId}/{channel}/{now{yyyy/MM/dd}}"
+	defaultS3Region         = "us-east-1"
+	defaultS3FlushInterval  = time.Minute
+	defaultS3MaxBufferRows  = 100000
+	s3UploadTimeout         = time.Minute
+	s3ParquetRowGroupLength = 64 * 1024
+)
+
+// s3Putter is a part of S3 API used by S3FrameOutput.
+type s3Putter interface {
+	PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error)
+}
+
+// S3FrameOutput buffers frames and periodically uploads them as NDJSON or Parquet
+// objects to S3 compatible storage. Each flush creates an object per rendered key
+// prefix (and per frame schema for Parquet) named as "<prefix>/<unix ms>-<uid>.<ext>".
+// Buffered frames are lost on restart, and re-queued when upload fails.
+type S3FrameOutput struct {
+	// Endpoint is an S3 compatible storage URL, like https://s3.eu-west-1.amazonaws.com
+	// or MinIO URL.
+	Endpoint string
+
+	config S3OutputConfig
+	key    *nameTemplate
+	client s3Putter
+	now    func() time.Time
+
+	mu         sync.Mutex
+	buffer     map[string][]*data.Frame
+	bufferRows int
+	flushCh    chan struct{}
+	closeOnce  sync.Once
+	done       chan struct{}
+}
+
+// NewS3FrameOutput creates S3FrameOutput, basic auth user and password are used as
+// access key ID and secret access key.
+func NewS3FrameOutput(endpoint string, basicAuth *BasicAuth, config S3OutputConfig) (*S3FrameOutput, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("bucket required")
+	}
+	switch config.Format {
+	case "":
+		config.Format = s3FormatNDJSON
+	case s3FormatNDJSON, s3FormatParquet:
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", config.Format)
+	}
+	if config.Key == "" {
+		config.Key = defaultS3Key
+	}
+	key, err := parseNameTemplate(config.Key)
+	if err != nil {
+		return nil, err
+	}
+	if config.Region == "" {
+		config.Region = defaultS3Region
+	}
+	out := &S3FrameOutput{
+		Endpoint: endpoint,
+		config:   config,
+		key:      key,
+		now:      time.Now,
+		buffer:   map[string][]*data.Frame{},
+		flushCh:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	if out.Endpoint == "" {
+		return out, nil
+	}
+	awsConfig := aws.NewConfig().
+		WithEndpoint(endpoint).
+		WithRegion(config.Region).
+		WithS3ForcePathStyle(config.ForcePathStyle)
+	if basicAuth != nil {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(basicAuth.User, basicAuth.Password, ""))
+	}
+	sess, err := awssession.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating S3 session: %w", err)
+	}
+	out.client = s3.New(sess)
+	go out.flushPeriodically()
+	return out, nil
+}
+
+const FrameOutputTypeS3 = "s3"
+
+func (out *S3FrameOutput) Type() string {
+	return FrameOutputTypeS3
+}
+
+// Close stops periodic flushing, buffered frames are uploaded once more in background.
+func (out *S3FrameOutput) Close() error {
+	out.closeOnce.Do(func() { close(out.done) })
+	return nil
+}
+
+func (out *S3FrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip uploading to S3: no url")
+		return nil, nil
+	}
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	if numRows == 0 {
+		return nil, nil
+	}
+	prefix := strings.Trim(out.key.render(vars, frame.Name, out.now()), "/")
+	out.mu.Lock()
+	out.buffer[prefix] = append(out.buffer[prefix], frame)
+	out.bufferRows += numRows
+	full := out.bufferRows >= out.maxBufferRows()
+	out.mu.Unlock()
+	if full {
+		select {
+		case out.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil, nil
+}
+
+func (out *S3FrameOutput) maxBufferRows() int {
+	if out.config.MaxBufferRows > 0 {
+		return out.config.MaxBufferRows
+	}
+	return defaultS3MaxBufferRows
+}
+
+func (out *S3FrameOutput) flushPeriodically() {
+	interval := defaultS3FlushInterval
+	if out.config.FlushIntervalMilliseconds > 0 {
+		interval = time.Duration(out.config.FlushIntervalMilliseconds) * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-out.flushCh:
+		case <-out.done:
+			out.flush()
+			return
+		}
+		out.flush()
+	}
+}
+
+// flush uploads buffered frames, frames of failed uploads are returned to buffer
+// unless buffer exceeds twice the max size.
+func (out *S3FrameOutput) flush() {
+	out.mu.Lock()
+	buffer := out.buffer
+	out.buffer = map[string][]*data.Frame{}
+	out.bufferRows = 0
+	out.mu.Unlock()
+
+	prefixes := make([]string, 0, len(buffer))
+	for prefix := range buffer {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		groups := [][]*data.Frame{buffer[prefix]}
+		if out.config.Format == s3FormatParquet {
+			groups = groupFramesBySchema(buffer[prefix])
+		}
+		for _, frames := range groups {
+			if err := out.upload(prefix, frames); err != nil {
+				logger.Error("Error uploading to S3", "bucket", out.config.Bucket, "prefix", prefix, "error", err)
+				out.requeue(prefix, frames)
+			}
+		}
+	}
+}
+
+func (out *S3FrameOutput) requeue(prefix string, frames []*data.Frame) {
+	numRows := 0
+	for _, f := range frames {
+		numRows += f.Rows()
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if out.bufferRows+numRows > 2*out.maxBufferRows() {
+		logger.Warn("S3 buffer is full, dropping frames", "prefix", prefix, "numDropped", len(frames))
+		return
+	}
+	out.buffer[prefix] = append(frames, out.buffer[prefix]...)
+	out.bufferRows += numRows
+}
+
+// upload puts frames into one object, frames must have the same schema for Parquet.
+func (out *S3FrameOutput) upload(prefix string, frames []*data.Frame) error {
+	var body []byte
+	var contentType string
+	var err error
+	switch out.config.Format {
+	case s3FormatParquet:
+		contentType = "application/vnd.apache.parquet"
+		body, err = framesToParquet(frames)
+	default:
+		contentType = "application/x-ndjson"
+		body, err = framesToNDJSON(frames)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3UploadTimeout)
+	defer cancel()
+	key := prefix + "/" + strconv.FormatInt(out.now().UnixMilli(), 10) + "-" + util.GenerateShortUID() + "." + out.config.Format
+	started := time.Now()
+	_, err = out.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(out.config.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return err
+	}
+	logger.Debug("Successfully uploaded to S3", "key", key, "size", len(body), "elapsed", time.Since(started))
+	return nil
+}
+
+// framesToNDJSON encodes each frame row as a JSON object with field values by field
+// name and labels of fields.
+func framesToNDJSON(frames []*data.Frame) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, frame := range frames {
+		labels := map[string]string{}
+		for _, f := range frame.Fields {
+			for k, v := range f.Labels {
+				labels[k] = v
+			}
+		}
+		for row := 0; row < frame.Rows(); row++ {
+			doc := make(map[string]any, len(frame.Fields)+1)
+			for _, f := range frame.Fields {
+				v, ok := f.ConcreteAt(row)
+				if !ok {
+					continue
+				}
+				switch val := v.(type) {
+				case time.Time:
+					v = val.Format(time.RFC3339Nano)
+				case float64:
+					if math.IsNaN(val) || math.IsInf(val, 0) {
+						continue
+					}
+				case float32:
+					if math.IsNaN(float64(val)) || math.IsInf(float64(val), 0) {
+						continue
+					}
+				}
+				doc[f.Name] = v
+			}
+			if _, ok := doc["labels"]; !ok && len(labels) > 0 {
+				doc["labels"] = labels
+			}
+			if err := enc.Encode(doc); err != nil {
+				return nil, fmt.Errorf("error encoding frame row: %w", err)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// parquetColumnName is a field name with labels, so fields with the same name and
+// different labels become separate columns.
+func parquetColumnName(f *data.Field) string {
+	if len(f.Labels) == 0 {
+		return f.Name
+	}
+	return f.Name + "{" + f.Labels.String() + "}"
+}
+
+// groupFramesBySchema groups frames with the same columns and types keeping order.
+func groupFramesBySchema(frames []*data.Frame) [][]*data.Frame {
+	var keys []string
+	groups := map[string][]*data.Frame{}
+	for _, frame := range frames {
+		var sb strings.Builder
+		for _, f := range frame.Fields {
+			sb.WriteString(parquetColumnName(f))
+			sb.WriteByte(0)
+			sb.WriteString(parquetType(f.Type()).String())
+			sb.WriteByte(0)
+		}
+		key := sb.String()
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], frame)
+	}
+	result := make([][]*data.Frame, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, groups[key])
+	}
+	return result
+}
+
+// parquetType returns an Arrow type stored in Parquet for a frame field type, all
+// integers are widened to 64 bits and times are stored with microsecond precision.
+func parquetType(ft data.FieldType) arrow.DataType {
+	switch ft.NonNullableType() {
+	case data.FieldTypeTime:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	case data.FieldTypeInt8, data.FieldTypeInt16, data.FieldTypeInt32, data.FieldTypeInt64:
+		return arrow.PrimitiveTypes.Int64
+	case data.FieldTypeUint8, data.FieldTypeUint16, data.FieldTypeUint32, data.FieldTypeUint64:
+		return arrow.PrimitiveTypes.Uint64
+	case data.FieldTypeFloat32, data.FieldTypeFloat64:
+		return arrow.PrimitiveTypes.Float64
+	case data.FieldTypeBool:
+		return arrow.FixedWidthTypes.Boolean
+	default:
+		return arrow.BinaryTypes.String
+	}
+}
+
+// framesToParquet encodes frames with the same schema into one Parquet file.
+func framesToParquet(frames []*data.Frame) ([]byte, error) {
+	first := frames[0]
+	fields := make([]arrow.Field, 0, len(first.Fields))
+	for _, f := range first.Fields {
+		fields = append(fields, arrow.Field{Name: parquetColumnName(f), Type: parquetType(f.Type()), Nullable: true})
+	}
+	schema := arrow.NewSchema(fields, nil)
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	for _, frame := range frames {
+		for i, f := range frame.Fields {
+			if err := appendParquetColumn(builder.Field(i), f); err != nil {
+				return nil, fmt.Errorf("error converting field %s: %w", f.Name, err)
+			}
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+
+	var buf bytes.Buffer
+	props := parquet.NewWriterProperties(
+		parquet.WithCompression(compress.Codecs.Snappy),
+		parquet.WithMaxRowGroupLength(s3ParquetRowGroupLength),
+	)
+	w, err := pqarrow.NewFileWriter(schema, &buf, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Write(record); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func appendParquetColumn(b array.Builder, f *data.Field) error {
+	for row := 0; row < f.Len(); row++ {
+		v, ok := f.ConcreteAt(row)
+		if !ok {
+			b.AppendNull()
+			continue
+		}
+		switch b := b.(type) {
+		case *array.TimestampBuilder:
+			b.Append(arrow.Timestamp(v.(time.Time).UnixMicro()))
+		case *array.Int64Builder:
+			n, err := toInt64(v)
+			if err != nil {
+				return err
+			}
+			b.Append(n)
+		case *array.Uint64Builder:
+			n, err := toUint64(v)
+			if err != nil {
+				return err
+			}
+			b.Append(n)
+		case *array.Float64Builder:
+			switch val := v.(type) {
+			case float64:
+				b.Append(val)
+			case float32:
+				b.Append(float64(val))
+			default:
+				return fmt.Errorf("unexpected value type %T", v)
+			}
+		case *array.BooleanBuilder:
+			b.Append(v.(bool))
+		case *array.StringBuilder:
+			switch val := v.(type) {
+			case string:
+				b.Append(val)
+			case json.RawMessage:
+				b.Append(string(val))
+			default:
+				b.Append(fmt.Sprintf("%v", val))
+			}
+		default:
+			return fmt.Errorf("unsupported column type %s", b.Type())
+		}
+	}
+	return nil
+}
+
+func toInt64(v any) (int64, error) {
+	switch val := v.(type) {
+	case int8:
+		return int64(val), nil
+	case int16:
+		return int64(val), nil
+	case int32:
+		return int64(val), nil
+	case int64:
+		return val, nil
+	default:
+		return 0, fmt.Errorf("unexpected value type %T", v)
+	}
+}
+
+func toUint64(v any) (uint64, error) {
+	switch val := v.(type) {
+	case uint8:
+		return uint64(val), nil
+	case uint16:
+		return uint64(val), nil
+	case uint32:
+		return uint64(val), nil
+	case uint64:
+		return val, nil
+	default:
+		return 0, fmt.Errorf("unexpected value type %T", v)
+	}
+}
@@ -0,0 +1,175 @@
+package pipeline
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet/file"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testS3Putter struct {
+	mu      sync.Mutex
+	err     error
+	objects map[string][]byte
+}
+
+func (p *testS3Putter) PutObjectWithContext(_ aws.Context, input *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	body, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	if p.objects == nil {
+		p.objects = map[string][]byte{}
+	}
+	p.objects[*input.Key] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func newTestS3FrameOutput(t *testing.T, config S3OutputConfig) (*S3FrameOutput, *testS3Putter) {
+	t.Helper()
+	out, err := NewS3FrameOutput("", nil, config)
+	require.NoError(t, err)
+	// Set endpoint after construction to not start periodic flushes.
+	out.Endpoint = "http://localhost:9000"
+	putter := &testS3Putter{}
+	out.client = putter
+	out.now = func() time.Time { return time.Date(2021, 9, 14, 10, 0, 0, 0, time.UTC) }
+	return out, putter
+}
+
+func TestS3FrameOutput_ndjson(t *testing.T) {
+	out, putter := newTestS3FrameOutput(t, S3OutputConfig{Bucket: "archive"})
+	ts := time.Date(2021, 9, 14, 9, 59, 0, 0, time.UTC)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("value", data.Labels{"host": "a"}, []*float64{floatPtr(1), nil}),
+	)
+	_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	out.flush()
+
+	require.Len(t, putter.objects, 1)
+	for key, body := range putter.objects {
+		require.True(t, strings.HasPrefix(key, "grafana-live/1/stream/test/cpu/2021/09/14/1631613600000-"), key)
+		require.True(t, strings.HasSuffix(key, ".ndjson"), key)
+		var lines []string
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		require.Len(t, lines, 2)
+		require.JSONEq(t, `{"time":"2021-09-14T09:59:00Z","value":1,"labels":{"host":"a"}}`, lines[0])
+		require.JSONEq(t, `{"time":"2021-09-14T09:59:01Z","labels":{"host":"a"}}`, lines[1])
+	}
+}
+
+func TestS3FrameOutput_parquet(t *testing.T) {
+	out, putter := newTestS3FrameOutput(t, S3OutputConfig{Bucket: "archive", Key: "{path}", Format: "parquet"})
+	ts := time.Date(2021, 9, 14, 9, 59, 0, 0, time.UTC)
+	vars := Vars{OrgID: 1, Channel: "stream/test/cpu", Path: "cpu"}
+	for i := 0; i < 2; i++ {
+		frame := data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{ts}),
+			data.NewField("value", nil, []int32{int32(i)}),
+			data.NewField("host", nil, []string{"a"}),
+		)
+		_, err := out.OutputFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+	}
+	// Frame with another schema goes to a separate object.
+	_, err := out.OutputFrame(context.Background(), vars, data.NewFrame("test", data.NewField("ok", nil, []bool{true})))
+	require.NoError(t, err)
+	out.flush()
+	require.Len(t, putter.objects, 2)
+
+	var numRows []int64
+	for _, body := range putter.objects {
+		reader, err := file.NewParquetReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		fileReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		require.NoError(t, err)
+		table, err := fileReader.ReadTable(context.Background())
+		require.NoError(t, err)
+		if table.NumCols() == 3 {
+			require.Equal(t, "time", table.Schema().Field(0).Name)
+			require.Equal(t, "int64", table.Schema().Field(1).Type.String())
+		}
+		numRows = append(numRows, table.NumRows())
+		table.Release()
+	}
+	require.ElementsMatch(t, []int64{2, 1}, numRows)
+}
+
+func TestS3FrameOutput_requeue(t *testing.T) {
+	out, putter := newTestS3FrameOutput(t, S3OutputConfig{Bucket: "archive", MaxBufferRows: 1})
+	putter.err = errors.New("boom")
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err := out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	out.flush()
+	require.Equal(t, 1, out.bufferRows)
+
+	// Buffer twice larger than max size is not grown by failed uploads.
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/mem"}, frame)
+	require.NoError(t, err)
+	out.flush()
+	require.LessOrEqual(t, out.bufferRows, 2)
+
+	putter.err = nil
+	out.flush()
+	require.Zero(t, out.bufferRows)
+	require.NotEmpty(t, putter.objects)
+	for _, body := range putter.objects {
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(bytes.Split(body, []byte("\n"))[0], &doc))
+		require.Equal(t, 1.0, doc["value"])
+	}
+}
+
+func TestS3FrameOutput_Close(t *testing.T) {
+	out, putter := newTestS3FrameOutput(t, S3OutputConfig{Bucket: "archive"})
+	go out.flushPeriodically()
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+
+	// Buffered frames are uploaded on close without waiting for the flush interval.
+	require.NoError(t, out.Close())
+	require.NoError(t, out.Close())
+	require.Eventually(t, func() bool {
+		putter.mu.Lock()
+		defer putter.mu.Unlock()
+		return len(putter.objects) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNewS3FrameOutput_invalid(t *testing.T) {
+	_, err := NewS3FrameOutput("", nil, S3OutputConfig{})
+	require.Error(t, err)
+	_, err = NewS3FrameOutput("", nil, S3OutputConfig{Bucket: "archive", Format: "csv"})
+	require.Error(t, err)
+	_, err = NewS3FrameOutput("", nil, S3OutputConfig{Bucket: "archive", Key: "{unknown}"})
+	require.Error(t, err)
+}
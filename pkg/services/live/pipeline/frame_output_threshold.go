@@ -11,6 +11,13 @@ import (
 type ThresholdOutputConfig struct {
 	FieldName string `json:"fieldName"`
 	Channel   string `json:"channel"`
+	// Hysteresis separates exit value of a threshold step from its enter value: a state
+	// is entered when value reaches step value and left only when value drops below step
+	// value minus Hysteresis.
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+	// ForMilliseconds is a minimum duration a new state must hold before state change is
+	// emitted, row time (or current time for frames without time) is used.
+	ForMilliseconds int64 `json:"forMilliseconds,omitempty"`
 }
 
 //go:generate mockgen -destination=frame_output_threshold_mock.go -package=pipeline github.com/grafana/grafana/pkg/services/live/pipeline FrameGetSetter
@@ -25,10 +32,11 @@ type FrameGetSetter interface {
 type ThresholdOutput struct {
 	frameStorage FrameGetSetter
 	config       ThresholdOutputConfig
+	now          func() time.Time
 }
 
 func NewThresholdOutput(frameStorage FrameGetSetter, config ThresholdOutputConfig) *ThresholdOutput {
-	return &ThresholdOutput{frameStorage: frameStorage, config: config}
+	return &ThresholdOutput{frameStorage: frameStorage, config: config, now: time.Now}
 }
 
 const FrameOutputTypeThreshold = "threshold"
@@ -41,10 +49,6 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 	if frame == nil {
 		return nil, nil
 	}
-	previousFrame, previousFrameOk, err := out.frameStorage.Get(vars.OrgID, out.config.Channel)
-	if err != nil {
-		return nil, err
-	}
 	fieldName := out.config.FieldName
 
 	currentFrameFieldIndex := -1
@@ -72,6 +76,15 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 		return nil, nil
 	}
 
+	if out.config.Hysteresis > 0 || out.config.ForMilliseconds > 0 {
+		return out.outputStateful(vars, frame, currentFrameFieldIndex)
+	}
+
+	previousFrame, previousFrameOk, err := out.frameStorage.Get(vars.OrgID, out.config.Channel)
+	if err != nil {
+		return nil, err
+	}
+
 	previousFrameFieldIndex := -1
 	if previousFrameOk {
 		for i, f := range previousFrame.Fields {
@@ -84,9 +97,9 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 	var previousState *string
 	if previousFrameOk && previousFrameFieldIndex >= 0 {
 		var previousThreshold data.Threshold
-		value, ok := previousFrame.Fields[previousFrameFieldIndex].At(previousFrame.Fields[0].Len() - 1).(*float64)
-		if !ok {
-			return nil, nil
+		value, err := thresholdValueAt(previousFrame.Fields[previousFrameFieldIndex], previousFrame.Fields[0].Len()-1)
+		if err != nil {
+			return nil, err
 		}
 		if value == nil {
 			// TODO: what should we do here?
@@ -114,10 +127,9 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 	f3.Name = "color"
 
 	for i := 0; i < frame.Fields[currentFrameFieldIndex].Len(); i++ {
-		// TODO: support other numeric types.
-		value, ok := frame.Fields[currentFrameFieldIndex].At(i).(*float64)
-		if !ok {
-			return nil, nil
+		value, err := thresholdValueAt(frame.Fields[currentFrameFieldIndex], i)
+		if err != nil {
+			return nil, err
 		}
 		if value == nil {
 			// TODO: what should we do here?
@@ -154,3 +166,180 @@ func (out *ThresholdOutput) OutputFrame(_ context.Context, vars Vars, frame *dat
 
 	return nil, out.frameStorage.Set(vars.OrgID, out.config.Channel, frame)
 }
+
+// thresholdValueAt returns a value of a numeric field converted to float64, nil for
+// null values.
+func thresholdValueAt(field *data.Field, i int) (*float64, error) {
+	if !field.Type().Numeric() {
+		return nil, fmt.Errorf("threshold field %q is not numeric: %s", field.Name, field.Type())
+	}
+	return field.NullableFloatAt(i)
+}
+
+// thresholdStateKey is appended to the output channel to keep threshold state in frame
+// storage, separately from frames published into the channel.
+const thresholdStateKey = "#threshold"
+
+// thresholdState is a current state of a field and a state pending for ForMilliseconds.
+type thresholdState struct {
+	known        bool
+	state        string
+	pending      *string
+	pendingSince time.Time
+}
+
+func (out *ThresholdOutput) loadState(orgID int64) (thresholdState, error) {
+	frame, ok, err := out.frameStorage.Get(orgID, out.config.Channel+thresholdStateKey)
+	if err != nil || !ok || frame.Rows() == 0 || len(frame.Fields) != 3 {
+		return thresholdState{}, err
+	}
+	st := thresholdState{known: true}
+	if v, ok := frame.Fields[0].ConcreteAt(0); ok {
+		st.state, _ = v.(string)
+	}
+	if v, ok := frame.Fields[1].ConcreteAt(0); ok {
+		if pending, ok := v.(string); ok {
+			st.pending = &pending
+		}
+	}
+	if v, ok := frame.Fields[2].ConcreteAt(0); ok {
+		st.pendingSince, _ = v.(time.Time)
+	}
+	return st, nil
+}
+
+func (out *ThresholdOutput) saveState(orgID int64, st thresholdState) error {
+	var pendingSince *time.Time
+	if st.pending != nil {
+		pendingSince = &st.pendingSince
+	}
+	frame := data.NewFrame("state",
+		data.NewField("state", nil, []string{st.state}),
+		data.NewField("pendingState", nil, []*string{st.pending}),
+		data.NewField("pendingSince", nil, []*time.Time{pendingSince}),
+	)
+	return out.frameStorage.Set(orgID, out.config.Channel+thresholdStateKey, frame)
+}
+
+// outputStateful emits state changes keeping the current and pending state in frame
+// storage, so noisy values around a threshold step do not flap the state.
+func (out *ThresholdOutput) outputStateful(vars Vars, frame *data.Frame, fieldIndex int) ([]*ChannelFrame, error) {
+	field := frame.Fields[fieldIndex]
+	steps := field.Config.Thresholds.Steps
+	forDuration := time.Duration(out.config.ForMilliseconds) * time.Millisecond
+
+	st, err := out.loadState(vars.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	fTime := data.NewFieldFromFieldType(data.FieldTypeTime, 0)
+	fTime.Name = "time"
+	f1 := data.NewFieldFromFieldType(data.FieldTypeFloat64, 0)
+	f1.Name = "value"
+	f2 := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	f2.Name = "state"
+	f3 := data.NewFieldFromFieldType(data.FieldTypeString, 0)
+	f3.Name = "color"
+
+	for i := 0; i < field.Len(); i++ {
+		value, err := thresholdValueAt(field, i)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		rowTime := frameRowTime(frame, i, out.now)
+
+		current, hasCurrent := -1, false
+		if st.known {
+			current, hasCurrent = thresholdStepIndexByState(steps, st.state)
+		}
+		var next data.Threshold
+		if index := thresholdStepIndex(steps, *value, current, hasCurrent, out.config.Hysteresis); index >= 0 {
+			next = steps[index]
+		}
+
+		if st.known && next.State == st.state {
+			st.pending = nil
+			continue
+		}
+		if st.known && forDuration > 0 {
+			if st.pending == nil || *st.pending != next.State {
+				pending := next.State
+				st.pending = &pending
+				st.pendingSince = rowTime
+			}
+			if rowTime.Sub(st.pendingSince) < forDuration {
+				continue
+			}
+		}
+
+		fTime.Append(rowTime)
+		f1.Append(*value)
+		f2.Append(next.State)
+		f3.Append(next.Color)
+		st = thresholdState{known: true, state: next.State}
+	}
+
+	if err := out.saveState(vars.OrgID, st); err != nil {
+		return nil, err
+	}
+	if fTime.Len() == 0 {
+		return nil, nil
+	}
+	return []*ChannelFrame{{
+		Channel: out.config.Channel,
+		Frame:   data.NewFrame("state", fTime, f1, f2, f3),
+	}}, nil
+}
+
+// frameRowTime is a value of the first time field in a row, now is used for frames
+// without time.
+func frameRowTime(frame *data.Frame, row int, now func() time.Time) time.Time {
+	for _, f := range frame.Fields {
+		if v, ok := f.ConcreteAt(row); ok {
+			if t, ok := v.(time.Time); ok {
+				return t
+			}
+		}
+	}
+	return now()
+}
+
+// thresholdStepIndexByState returns index of a step with the state, -1 is below all steps.
+func thresholdStepIndexByState(steps []data.Threshold, state string) (int, bool) {
+	if state == "" {
+		return -1, true
+	}
+	for i, step := range steps {
+		if step.State == state {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// thresholdStepIndex returns index of a step value belongs to, -1 when it's below all
+// steps. Moving up enters a step at its value, moving down from the current step
+// leaves it only below its value minus hysteresis.
+func thresholdStepIndex(steps []data.Threshold, value float64, current int, hasCurrent bool, hysteresis float64) int {
+	index := -1
+	for i, step := range steps {
+		if value >= float64(step.Value) {
+			index = i
+			continue
+		}
+		break
+	}
+	if !hasCurrent || index >= current {
+		return index
+	}
+	for i := current; i > index; i-- {
+		if value >= float64(steps[i].Value)-hysteresis {
+			return i
+		}
+	}
+	return index
+}
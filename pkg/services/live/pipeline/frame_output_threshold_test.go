@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -252,3 +253,118 @@ func TestThresholdOutput_WithPreviousFrame_MultipleRows(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, channelFrames, 1)
 }
+
+func thresholdTestFrame(start time.Time, values ...float64) *data.Frame {
+	times := make([]time.Time, len(values))
+	ptrs := make([]*float64, len(values))
+	for i := range values {
+		times[i] = start.Add(time.Duration(i) * time.Second)
+		ptrs[i] = &values[i]
+	}
+	f := data.NewField("test", nil, ptrs)
+	f.Config = &data.FieldConfig{
+		Thresholds: &data.ThresholdsConfig{
+			Mode: data.ThresholdsModeAbsolute,
+			Steps: []data.Threshold{
+				{Value: data.ConfFloat64(math.Inf(-1)), State: "normal", Color: "green"},
+				{Value: 80, State: "high", Color: "red"},
+			},
+		},
+	}
+	return data.NewFrame("test", data.NewField("time", nil, times), f)
+}
+
+func thresholdStates(channelFrames []*ChannelFrame) []string {
+	var states []string
+	for _, cf := range channelFrames {
+		for i := 0; i < cf.Frame.Rows(); i++ {
+			states = append(states, cf.Frame.Fields[2].At(i).(string))
+		}
+	}
+	return states
+}
+
+func TestThresholdOutput_Hysteresis(t *testing.T) {
+	outputter := NewThresholdOutput(NewFrameStorage(), ThresholdOutputConfig{
+		FieldName:  "test",
+		Channel:    "stream/test/hysteresis",
+		Hysteresis: 10,
+	})
+	start := time.Now()
+
+	channelFrames, err := outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(start, 85, 78, 71, 69, 79))
+	require.NoError(t, err)
+	require.Equal(t, []string{"high", "normal"}, thresholdStates(channelFrames))
+
+	// State is kept between frames.
+	channelFrames, err = outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(start.Add(time.Minute), 80, 75))
+	require.NoError(t, err)
+	require.Equal(t, []string{"high"}, thresholdStates(channelFrames))
+
+	channelFrames, err = outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(start.Add(2*time.Minute), 72))
+	require.NoError(t, err)
+	require.Empty(t, channelFrames)
+}
+
+func TestThresholdOutput_ForDuration(t *testing.T) {
+	outputter := NewThresholdOutput(NewFrameStorage(), ThresholdOutputConfig{
+		FieldName:       "test",
+		Channel:         "stream/test/for",
+		ForMilliseconds: 2000,
+	})
+	start := time.Now()
+
+	// Initial state is emitted at once, short spikes are ignored.
+	channelFrames, err := outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(start, 50, 90, 50, 90, 90))
+	require.NoError(t, err)
+	require.Equal(t, []string{"normal"}, thresholdStates(channelFrames))
+
+	// Pending state is kept between frames.
+	channelFrames, err = outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, thresholdTestFrame(start.Add(5*time.Second), 90))
+	require.NoError(t, err)
+	require.Len(t, channelFrames, 1)
+	require.Equal(t, []string{"high"}, thresholdStates(channelFrames))
+	require.Equal(t, start.Add(5*time.Second), channelFrames[0].Frame.Fields[0].At(0))
+	require.Equal(t, "red", channelFrames[0].Frame.Fields[3].At(0))
+}
+
+func TestThresholdOutput_numericTypes(t *testing.T) {
+	thresholds := &data.ThresholdsConfig{
+		Mode: data.ThresholdsModeAbsolute,
+		Steps: []data.Threshold{
+			{Value: data.ConfFloat64(math.Inf(-1)), State: "normal", Color: "green"},
+			{Value: 80, State: "high", Color: "red"},
+		},
+	}
+	for _, config := range []ThresholdOutputConfig{
+		{FieldName: "test", Channel: "stream/test/numeric"},
+		{FieldName: "test", Channel: "stream/test/numeric", Hysteresis: 10},
+	} {
+		outputter := NewThresholdOutput(NewFrameStorage(), config)
+		f := data.NewField("test", nil, []int64{50, 90})
+		f.Config = &data.FieldConfig{Thresholds: thresholds}
+		channelFrames, err := outputter.OutputFrame(context.Background(), Vars{OrgID: 1}, data.NewFrame("test", f))
+		require.NoError(t, err)
+		require.Equal(t, []string{"normal", "high"}, thresholdStates(channelFrames))
+		require.Equal(t, 90.0, channelFrames[0].Frame.Fields[1].At(1))
+
+		f = data.NewField("test", nil, []string{"90"})
+		f.Config = &data.FieldConfig{Thresholds: thresholds}
+		_, err = outputter.OutputFrame(context.Background(), Vars{OrgID: 2}, data.NewFrame("test", f))
+		require.ErrorContains(t, err, "not numeric")
+	}
+}
+
+func TestThresholdStepIndex(t *testing.T) {
+	steps := []data.Threshold{
+		{Value: 0, State: "normal"},
+		{Value: 50, State: "warning"},
+		{Value: 80, State: "critical"},
+	}
+	require.Equal(t, -1, thresholdStepIndex(steps, -1, 0, false, 5))
+	require.Equal(t, 2, thresholdStepIndex(steps, 85, 0, true, 5))
+	require.Equal(t, 2, thresholdStepIndex(steps, 76, 2, true, 5))
+	require.Equal(t, 1, thresholdStepIndex(steps, 74, 2, true, 5))
+	require.Equal(t, 1, thresholdStepIndex(steps, 46, 2, true, 5))
+	require.Equal(t, 0, thresholdStepIndex(steps, 44, 2, true, 5))
+}
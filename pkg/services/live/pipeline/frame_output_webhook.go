@@ -0,0 +1,201 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	defaultWebhookTimeout      = 5 * time.Second
+	defaultWebhookRetryBackoff = 500 * time.Millisecond
+	maxWebhookRetryBackoff     = 30 * time.Second
+	// webhookQueueSize limits a number of frames waiting for delivery, new frames
+	// are dropped when the queue is full.
+	webhookQueueSize = 1000
+)
+
+// WebhookFrameOutput sends frames encoded to JSON to an HTTP endpoint. Frames are
+// delivered in order by a background worker, so slow endpoints and retries do not
+// block processing.
+type WebhookFrameOutput struct {
+	// Endpoint to POST frames to.
+	Endpoint string
+	// BasicAuth is an optional basic auth params.
+	BasicAuth *BasicAuth
+	// Token is an optional bearer token, takes precedence over BasicAuth.
+	Token string
+
+	config     WebhookOutputConfig
+	httpClient *http.Client
+	backoff    time.Duration
+	queue      chan webhookPayload
+	closeOnce  sync.Once
+	done       chan struct{}
+}
+
+// webhookPayload is a body sent to a webhook.
+type webhookPayload struct {
+	OrgID     int64           `json:"orgId"`
+	Channel   string          `json:"channel"`
+	MessageID string          `json:"messageId,omitempty"`
+	Frame     json.RawMessage `json:"frame"`
+}
+
+func NewWebhookFrameOutput(endpoint string, basicAuth *BasicAuth, token string, config WebhookOutputConfig) (*WebhookFrameOutput, error) {
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("max retries must not be negative")
+	}
+	timeout := defaultWebhookTimeout
+	if config.TimeoutMilliseconds > 0 {
+		timeout = time.Duration(config.TimeoutMilliseconds) * time.Millisecond
+	}
+	backoff := defaultWebhookRetryBackoff
+	if config.RetryBackoffMilliseconds > 0 {
+		backoff = time.Duration(config.RetryBackoffMilliseconds) * time.Millisecond
+	}
+	out := &WebhookFrameOutput{
+		Endpoint:   endpoint,
+		BasicAuth:  basicAuth,
+		Token:      token,
+		config:     config,
+		httpClient: &http.Client{Timeout: timeout},
+		backoff:    backoff,
+		queue:      make(chan webhookPayload, webhookQueueSize),
+		done:       make(chan struct{}),
+	}
+	if out.Endpoint != "" {
+		go out.deliver()
+	}
+	return out, nil
+}
+
+const FrameOutputTypeWebhook = "webhook"
+
+func (out *WebhookFrameOutput) Type() string {
+	return FrameOutputTypeWebhook
+}
+
+// Close stops the delivery worker, queued frames are sent once more in background
+// without retries.
+func (out *WebhookFrameOutput) Close() error {
+	out.closeOnce.Do(func() { close(out.done) })
+	return nil
+}
+
+func (out *WebhookFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.Endpoint == "" {
+		logger.Debug("Skip sending to webhook: no url")
+		return nil, nil
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case out.queue <- webhookPayload{OrgID: vars.OrgID, Channel: vars.Channel, MessageID: vars.MessageID, Frame: frameJSON}:
+	default:
+		logger.Warn("Webhook queue is full, dropping frame", "channel", vars.Channel)
+	}
+	return nil, nil
+}
+
+func (out *WebhookFrameOutput) deliver() {
+	for {
+		select {
+		case payload := <-out.queue:
+			out.sendPayload(payload)
+		case <-out.done:
+			for {
+				select {
+				case payload := <-out.queue:
+					out.sendPayload(payload)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (out *WebhookFrameOutput) sendPayload(payload webhookPayload) {
+	if err := out.sendWithRetries(payload); err != nil {
+		logger.Error("Error sending to webhook", "channel", payload.Channel, "error", err)
+	}
+}
+
+// sendWithRetries sends a payload retrying failed requests with exponential backoff,
+// retries stop when the output is closed.
+func (out *WebhookFrameOutput) sendWithRetries(payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	backoff := out.backoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := out.send(body, payload.MessageID)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= out.config.MaxRetries {
+			return err
+		}
+		logger.Debug("Retrying webhook request", "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-out.done:
+			return err
+		}
+		backoff *= 2
+		if backoff > maxWebhookRetryBackoff {
+			backoff = maxWebhookRetryBackoff
+		}
+	}
+}
+
+// send makes a single request, retryable is true for network errors, 429 and 5xx
+// responses.
+func (out *WebhookFrameOutput) send(body []byte, messageID string) (retryable bool, err error) {
+	method := out.config.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, out.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("error constructing webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if messageID != "" {
+		req.Header.Set(MessageIDHeader, messageID)
+	}
+	for name, value := range out.config.Headers {
+		req.Header.Set(name, value)
+	}
+	switch {
+	case out.Token != "":
+		req.Header.Set("Authorization", "Bearer "+out.Token)
+	case out.BasicAuth != nil:
+		req.SetBasicAuth(out.BasicAuth.User, out.BasicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := out.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("error sending to webhook: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return retryable, fmt.Errorf("unexpected response code from webhook: %d", resp.StatusCode)
+	}
+	logger.Debug("Successfully sent to webhook", "elapsed", time.Since(started))
+	return false, nil
+}
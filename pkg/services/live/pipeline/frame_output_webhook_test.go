@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookFrameOutput_OutputFrame(t *testing.T) {
+	var attempts int32
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(server.URL, nil, "secret", WebhookOutputConfig{
+		Method:                   http.MethodPut,
+		Headers:                  map[string]string{"X-Source": "live"},
+		MaxRetries:               2,
+		RetryBackoffMilliseconds: 1,
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/cpu", MessageID: "msg-1"}, frame)
+	require.NoError(t, err)
+
+	select {
+	case r := <-received:
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "live", r.Header.Get("X-Source"))
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.Equal(t, "msg-1", r.Header.Get(MessageIDHeader))
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+	var payload webhookPayload
+	require.NoError(t, json.Unmarshal(<-bodies, &payload))
+	require.Equal(t, int64(1), payload.OrgID)
+	require.Equal(t, "stream/test/cpu", payload.Channel)
+	require.Equal(t, "msg-1", payload.MessageID)
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	require.NoError(t, err)
+	require.JSONEq(t, string(frameJSON), string(payload.Frame))
+	require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestWebhookFrameOutput_sendWithRetries(t *testing.T) {
+	var attempts int32
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput("", &BasicAuth{User: "u", Password: "p"}, "", WebhookOutputConfig{
+		MaxRetries:               2,
+		RetryBackoffMilliseconds: 1,
+	})
+	require.NoError(t, err)
+	out.Endpoint = server.URL
+
+	// Client errors are not retried.
+	require.Error(t, out.sendWithRetries(webhookPayload{Frame: json.RawMessage(`{}`)}))
+	require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+
+	status = http.StatusBadGateway
+	require.Error(t, out.sendWithRetries(webhookPayload{Frame: json.RawMessage(`{}`)}))
+	require.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestWebhookFrameOutput_Close(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts <- struct{}{}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	out, err := NewWebhookFrameOutput(server.URL, nil, "", WebhookOutputConfig{
+		MaxRetries:               10,
+		RetryBackoffMilliseconds: time.Hour.Milliseconds(),
+	})
+	require.NoError(t, err)
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{Channel: "stream/test/cpu"}, frame)
+	require.NoError(t, err)
+	select {
+	case <-attempts:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not called")
+	}
+
+	// Retries waiting for backoff stop on close.
+	require.NoError(t, out.Close())
+	require.NoError(t, out.Close())
+	require.Error(t, out.sendWithRetries(webhookPayload{Frame: json.RawMessage(`{}`)}))
+}
@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type EncryptFieldsFrameProcessorConfig struct {
+	// UID of a write config with a base64 encoded 16, 24 or 32 bytes AES key in
+	// "encryptionKey" secure setting.
+	UID        string   `json:"uid"`
+	FieldNames []string `json:"fieldNames"`
+}
+
+// EncryptFieldsFrameProcessor replaces values of configured fields with AES-GCM
+// encrypted strings: base64 of a random nonce followed by a ciphertext of a value
+// formatted as a string. Nulls are kept. Usually used inside a processed output, so
+// only frames sent to external systems are encrypted.
+type EncryptFieldsFrameProcessor struct {
+	aead   cipher.AEAD
+	fields map[string]struct{}
+}
+
+func NewEncryptFieldsFrameProcessor(key []byte, config EncryptFieldsFrameProcessorConfig) (*EncryptFieldsFrameProcessor, error) {
+	if len(config.FieldNames) == 0 {
+		return nil, errors.New("no fields to encrypt")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]struct{}, len(config.FieldNames))
+	for _, name := range config.FieldNames {
+		fields[name] = struct{}{}
+	}
+	return &EncryptFieldsFrameProcessor{aead: aead, fields: fields}, nil
+}
+
+const FrameProcessorTypeEncryptFields = "encryptFields"
+
+func (p *EncryptFieldsFrameProcessor) Type() string {
+	return FrameProcessorTypeEncryptFields
+}
+
+func (p *EncryptFieldsFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	fields := make([]*data.Field, len(frame.Fields))
+	for i, f := range frame.Fields {
+		if _, ok := p.fields[f.Name]; !ok {
+			fields[i] = f
+			continue
+		}
+		encrypted := data.NewFieldFromFieldType(data.FieldTypeNullableString, f.Len())
+		encrypted.Name = f.Name
+		encrypted.Labels = f.Labels
+		encrypted.Config = f.Config
+		for row := 0; row < f.Len(); row++ {
+			v, ok := f.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			s, err := p.encrypt(fmt.Sprint(v))
+			if err != nil {
+				return nil, fmt.Errorf("error encrypting %s: %w", f.Name, err)
+			}
+			encrypted.SetConcrete(row, s)
+		}
+		fields[i] = encrypted
+	}
+	out := data.NewFrame(frame.Name, fields...)
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	return out, nil
+}
+
+func (p *EncryptFieldsFrameProcessor) encrypt(value string) (string, error) {
+	nonce := make([]byte, p.aead.NonceSize(), p.aead.NonceSize()+len(value)+p.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(p.aead.Seal(nonce, nonce, []byte(value), nil)), nil
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptFieldsFrameProcessor(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	p, err := NewEncryptFieldsFrameProcessor(key, EncryptFieldsFrameProcessorConfig{FieldNames: []string{"email", "age"}})
+	require.NoError(t, err)
+
+	age := int64(42)
+	frame := data.NewFrame("users",
+		data.NewField("email", data.Labels{"team": "a"}, []string{"a@example.com"}),
+		data.NewField("age", nil, []*int64{&age}),
+		data.NewField("score", nil, []float64{1}),
+	)
+	frame.AppendRow("b@example.com", (*int64)(nil), 2.0)
+
+	encrypted, err := p.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeNullableString, encrypted.Fields[0].Type())
+	require.Equal(t, data.Labels{"team": "a"}, encrypted.Fields[0].Labels)
+	require.Equal(t, data.FieldTypeFloat64, encrypted.Fields[2].Type())
+	// Input frame is not changed.
+	require.Equal(t, "a@example.com", frame.Fields[0].At(0))
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	decrypt := func(field *data.Field, row int) string {
+		v, ok := field.ConcreteAt(row)
+		require.True(t, ok)
+		raw, err := base64.StdEncoding.DecodeString(v.(string))
+		require.NoError(t, err)
+		plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+		require.NoError(t, err)
+		return string(plain)
+	}
+	require.Equal(t, "a@example.com", decrypt(encrypted.Fields[0], 0))
+	require.Equal(t, "b@example.com", decrypt(encrypted.Fields[0], 1))
+	require.Equal(t, "42", decrypt(encrypted.Fields[1], 0))
+	_, ok := encrypted.Fields[1].ConcreteAt(1)
+	require.False(t, ok)
+
+	_, err = NewEncryptFieldsFrameProcessor([]byte("short"), EncryptFieldsFrameProcessorConfig{FieldNames: []string{"email"}})
+	require.ErrorContains(t, err, "invalid encryption key")
+}
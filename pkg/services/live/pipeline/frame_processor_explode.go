@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type ExplodeFrameProcessorConfig struct {
+	// FieldName is a name of string or JSON field which contains JSON array.
+	FieldName string `json:"fieldName"`
+}
+
+// ExplodeFrameProcessor explodes a field containing JSON array into multiple rows, values of
+// other fields are duplicated for each array element. Array of objects results into a field
+// per object key named as <fieldName>.<key>. Rows with empty or null array are kept with
+// null values in exploded fields.
+type ExplodeFrameProcessor struct {
+	config ExplodeFrameProcessorConfig
+}
+
+func NewExplodeFrameProcessor(config ExplodeFrameProcessorConfig) *ExplodeFrameProcessor {
+	return &ExplodeFrameProcessor{config: config}
+}
+
+const FrameProcessorTypeExplode = "explode"
+
+func (p *ExplodeFrameProcessor) Type() string {
+	return FrameProcessorTypeExplode
+}
+
+// explodedColumn keeps values of a single exploded field for all resulting rows.
+type explodedColumn struct {
+	name   string
+	values map[int]any
+}
+
+func (p *ExplodeFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	index := fieldIndex(frame, p.config.FieldName)
+	if index < 0 {
+		return frame, nil
+	}
+	arrayField := frame.Fields[index]
+
+	var (
+		sourceRows []int // Source row index for each resulting row.
+		columns    []*explodedColumn
+		columnsIdx = map[string]*explodedColumn{}
+	)
+	setValue := func(name string, row int, v any) {
+		c, ok := columnsIdx[name]
+		if !ok {
+			c = &explodedColumn{name: name, values: map[int]any{}}
+			columnsIdx[name] = c
+			columns = append(columns, c)
+		}
+		c.values[row] = v
+	}
+
+	for i := 0; i < arrayField.Len(); i++ {
+		elements, err := explodeArrayAt(arrayField, i)
+		if err != nil {
+			return nil, fmt.Errorf("error exploding field %s at row %d: %w", p.config.FieldName, i, err)
+		}
+		if len(elements) == 0 {
+			sourceRows = append(sourceRows, i)
+			continue
+		}
+		for _, element := range elements {
+			row := len(sourceRows)
+			sourceRows = append(sourceRows, i)
+			if obj, ok := element.(map[string]any); ok {
+				keys := make([]string, 0, len(obj))
+				for k := range obj {
+					keys = append(keys, k)
+				}
+				// Keep field order stable.
+				sort.Strings(keys)
+				for _, k := range keys {
+					setValue(p.config.FieldName+"."+k, row, obj[k])
+				}
+				continue
+			}
+			setValue(p.config.FieldName, row, element)
+		}
+	}
+
+	explodedFields := make([]*data.Field, 0, len(columns))
+	for _, c := range columns {
+		f, err := explodedField(c, len(sourceRows))
+		if err != nil {
+			return nil, fmt.Errorf("error building field %s: %w", c.name, err)
+		}
+		explodedFields = append(explodedFields, f)
+	}
+
+	fields := make([]*data.Field, 0, len(frame.Fields)+len(explodedFields))
+	for i, f := range frame.Fields {
+		if i == index {
+			fields = append(fields, explodedFields...)
+			continue
+		}
+		newField := data.NewFieldFromFieldType(f.Type(), len(sourceRows))
+		newField.Name = f.Name
+		newField.Labels = f.Labels
+		newField.Config = f.Config
+		for row, sourceRow := range sourceRows {
+			newField.Set(row, f.CopyAt(sourceRow))
+		}
+		fields = append(fields, newField)
+	}
+	frame.Fields = fields
+	return frame, nil
+}
+
+func explodeArrayAt(field *data.Field, i int) ([]any, error) {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return nil, nil
+	}
+	var raw []byte
+	switch val := v.(type) {
+	case string:
+		raw = []byte(val)
+	case json.RawMessage:
+		raw = val
+	default:
+		return nil, fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var elements []any
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
+
+func explodedField(c *explodedColumn, numRows int) (*data.Field, error) {
+	fieldType := data.FieldTypeUnknown
+	for _, v := range c.values {
+		if v == nil {
+			continue
+		}
+		ft, err := fieldTypeForValue(v)
+		if err != nil || (fieldType != data.FieldTypeUnknown && ft != fieldType) {
+			// Mixed or nested values, keep them as JSON strings.
+			fieldType = data.FieldTypeNullableString
+			break
+		}
+		fieldType = ft
+	}
+	if fieldType == data.FieldTypeUnknown {
+		fieldType = data.FieldTypeNullableString
+	}
+	f := data.NewFieldFromFieldType(fieldType, numRows)
+	f.Name = c.name
+	for row, v := range c.values {
+		if v == nil {
+			continue
+		}
+		if fieldType == data.FieldTypeNullableString {
+			if s, ok := v.(string); ok {
+				f.SetConcrete(row, s)
+				continue
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			f.SetConcrete(row, string(b))
+			continue
+		}
+		converted, err := convertToFieldType(v, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		f.SetConcrete(row, converted)
+	}
+	return f, nil
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplodeFrameProcessor_Objects(t *testing.T) {
+	processor := NewExplodeFrameProcessor(ExplodeFrameProcessorConfig{FieldName: "items"})
+
+	frame := data.NewFrame("test",
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("items", nil, []string{`[{"value":1},{"value":2,"tag":"x"}]`, `[]`}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, 3, frame.Fields[0].Len())
+	require.Equal(t, []any{"a", "a", "b"}, []any{frame.Fields[0].At(0), frame.Fields[0].At(1), frame.Fields[0].At(2)})
+
+	value := frame.Fields[fieldIndex(frame, "items.value")]
+	require.Equal(t, floatPtr(1), value.At(0))
+	require.Equal(t, floatPtr(2), value.At(1))
+	require.Nil(t, value.At(2))
+
+	tag := frame.Fields[fieldIndex(frame, "items.tag")]
+	require.Nil(t, tag.At(0))
+	v, ok := tag.ConcreteAt(1)
+	require.True(t, ok)
+	require.Equal(t, "x", v)
+}
+
+func TestExplodeFrameProcessor_Scalars(t *testing.T) {
+	processor := NewExplodeFrameProcessor(ExplodeFrameProcessorConfig{FieldName: "items"})
+
+	frame := data.NewFrame("test",
+		data.NewField("items", nil, []string{`[1, "two"]`}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 1)
+	require.Equal(t, data.FieldTypeNullableString, frame.Fields[0].Type())
+	v, _ := frame.Fields[0].ConcreteAt(0)
+	require.Equal(t, "1", v)
+}
+
+func TestExplodeFrameProcessor_InvalidJSON(t *testing.T) {
+	processor := NewExplodeFrameProcessor(ExplodeFrameProcessorConfig{FieldName: "items"})
+
+	frame := data.NewFrame("test",
+		data.NewField("items", nil, []string{`{`}),
+	)
+
+	_, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.Error(t, err)
+}
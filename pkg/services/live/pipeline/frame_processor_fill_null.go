@@ -0,0 +1,250 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// FillNullMode defines how null values are replaced.
+type FillNullMode string
+
+// Known FillNullMode types.
+const (
+	// FillNullModeConstant replaces nulls with a configured value.
+	FillNullModeConstant FillNullMode = "constant"
+	// FillNullModePrevious replaces nulls with the last known non-null value. The last
+	// value of previous frame kept in FrameStorage is used for leading nulls.
+	FillNullModePrevious FillNullMode = "previous"
+	// FillNullModeInterpolate replaces nulls with a linear interpolation between
+	// surrounding non-null values. Nulls without a value on both sides are left as is.
+	FillNullModeInterpolate FillNullMode = "interpolate"
+)
+
+type FillNullFieldConfig struct {
+	FieldName string       `json:"fieldName"`
+	Mode      FillNullMode `json:"mode"`
+	// Value is used in constant mode. Also used as a fallback for previous mode when
+	// there is no previous value known yet.
+	Value any `json:"value,omitempty"`
+}
+
+type FillNullFrameProcessorConfig struct {
+	Fields []FillNullFieldConfig `json:"fields"`
+}
+
+// FillNullFrameProcessor replaces null values or adds missing fields using configured defaults.
+type FillNullFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       FillNullFrameProcessorConfig
+}
+
+func NewFillNullFrameProcessor(frameStorage FrameGetSetter, config FillNullFrameProcessorConfig) *FillNullFrameProcessor {
+	return &FillNullFrameProcessor{frameStorage: frameStorage, config: config}
+}
+
+const FrameProcessorTypeFillNull = "fillNull"
+
+func (p *FillNullFrameProcessor) Type() string {
+	return FrameProcessorTypeFillNull
+}
+
+func (p *FillNullFrameProcessor) usesPrevious() bool {
+	for _, c := range p.config.Fields {
+		if c.Mode == FillNullModePrevious {
+			return true
+		}
+	}
+	return false
+}
+
+// fillNullStateKey is appended to a channel to keep a previous frame of a fill null
+// processor in frame storage.
+const fillNullStateKey = "#fillNull"
+
+func (p *FillNullFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	var previousFrame *data.Frame
+	if p.usesPrevious() {
+		f, ok, err := p.frameStorage.Get(vars.OrgID, vars.Channel+fillNullStateKey)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			previousFrame = f
+		}
+	}
+
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range p.config.Fields {
+		index := fieldIndex(frame, c.FieldName)
+		if index < 0 {
+			field, err := p.missingField(c, previousFrame, numRows)
+			if err != nil {
+				return nil, fmt.Errorf("error filling missing field %s: %w", c.FieldName, err)
+			}
+			if field != nil {
+				frame.Fields = append(frame.Fields, field)
+			}
+			continue
+		}
+		field := frame.Fields[index]
+		if !field.Nullable() {
+			continue
+		}
+		switch c.Mode {
+		case FillNullModeConstant:
+			err = fillNullConstant(field, c.Value)
+		case FillNullModePrevious:
+			err = fillNullPrevious(field, lastFieldValue(previousFrame, c.FieldName), c.Value)
+		case FillNullModeInterpolate:
+			err = fillNullInterpolate(frame, field)
+		default:
+			err = fmt.Errorf("unknown fill mode: %s", c.Mode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error filling field %s: %w", c.FieldName, err)
+		}
+	}
+
+	if p.usesPrevious() {
+		// Keep a copy, fields of the frame are modified by later stages.
+		if err := p.frameStorage.Set(vars.OrgID, vars.Channel+fillNullStateKey, copyFrame(frame)); err != nil {
+			return nil, err
+		}
+	}
+	return frame, nil
+}
+
+func (p *FillNullFrameProcessor) missingField(c FillNullFieldConfig, previousFrame *data.Frame, numRows int) (*data.Field, error) {
+	value := c.Value
+	fieldType := data.FieldTypeUnknown
+	if c.Mode == FillNullModePrevious && previousFrame != nil {
+		if index := fieldIndex(previousFrame, c.FieldName); index >= 0 {
+			fieldType = previousFrame.Fields[index].Type().NullableType()
+			if v := lastFieldValue(previousFrame, c.FieldName); v != nil {
+				value = v
+			}
+		}
+	}
+	if value == nil {
+		// Nothing to fill with, keep frame as is.
+		return nil, nil
+	}
+	if fieldType == data.FieldTypeUnknown {
+		var err error
+		fieldType, err = fieldTypeForValue(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	field := data.NewFieldFromFieldType(fieldType, numRows)
+	field.Name = c.FieldName
+	return field, fillNullConstant(field, value)
+}
+
+// lastFieldValue returns last non-null concrete value of a field in a frame.
+func lastFieldValue(frame *data.Frame, fieldName string) any {
+	if frame == nil {
+		return nil
+	}
+	index := fieldIndex(frame, fieldName)
+	if index < 0 {
+		return nil
+	}
+	field := frame.Fields[index]
+	for i := field.Len() - 1; i >= 0; i-- {
+		if v, ok := field.ConcreteAt(i); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func fillNullConstant(field *data.Field, value any) error {
+	if value == nil {
+		return nil
+	}
+	converted, err := convertToFieldType(value, field.Type())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < field.Len(); i++ {
+		if _, ok := field.ConcreteAt(i); !ok {
+			field.SetConcrete(i, converted)
+		}
+	}
+	return nil
+}
+
+func fillNullPrevious(field *data.Field, previous any, fallback any) error {
+	if previous == nil && fallback != nil {
+		converted, err := convertToFieldType(fallback, field.Type())
+		if err != nil {
+			return err
+		}
+		previous = converted
+	}
+	for i := 0; i < field.Len(); i++ {
+		if v, ok := field.ConcreteAt(i); ok {
+			previous = v
+			continue
+		}
+		if previous != nil {
+			field.SetConcrete(i, previous)
+		}
+	}
+	return nil
+}
+
+func fillNullInterpolate(frame *data.Frame, field *data.Field) error {
+	if !field.Type().Numeric() {
+		return fmt.Errorf("interpolation requires numeric field, got %s", field.Type())
+	}
+	// Use time field as X axis if exists, fallback to row index.
+	var timeField *data.Field
+	for _, f := range frame.Fields {
+		if f.Type().Time() {
+			timeField = f
+			break
+		}
+	}
+	x := func(i int) float64 {
+		if timeField != nil {
+			if v, err := timeField.FloatAt(i); err == nil && !math.IsNaN(v) {
+				return v
+			}
+		}
+		return float64(i)
+	}
+
+	prevIndex := -1
+	for i := 0; i < field.Len(); i++ {
+		if _, ok := field.ConcreteAt(i); !ok {
+			continue
+		}
+		if prevIndex >= 0 && i-prevIndex > 1 {
+			y0, _ := field.FloatAt(prevIndex)
+			y1, _ := field.FloatAt(i)
+			x0, x1 := x(prevIndex), x(i)
+			for j := prevIndex + 1; j < i; j++ {
+				y := y0
+				if x1 != x0 {
+					y = y0 + (y1-y0)*(x(j)-x0)/(x1-x0)
+				}
+				v, err := floatToNumericType(y, field.Type().NonNullableType())
+				if err != nil {
+					return err
+				}
+				field.SetConcrete(j, v)
+			}
+		}
+		prevIndex = i
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestFillNullFrameProcessor_Constant(t *testing.T) {
+	processor := NewFillNullFrameProcessor(nil, FillNullFrameProcessorConfig{
+		Fields: []FillNullFieldConfig{
+			{FieldName: "value", Mode: FillNullModeConstant, Value: 0.0},
+			{FieldName: "status", Mode: FillNullModeConstant, Value: "unknown"},
+		},
+	})
+
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0), time.Unix(2, 0)}),
+		data.NewField("value", nil, []*float64{nil, floatPtr(2)}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, floatPtr(0), frame.Fields[1].At(0))
+	require.Equal(t, floatPtr(2), frame.Fields[1].At(1))
+	require.Equal(t, "status", frame.Fields[2].Name)
+	v, ok := frame.Fields[2].ConcreteAt(1)
+	require.True(t, ok)
+	require.Equal(t, "unknown", v)
+}
+
+func TestFillNullFrameProcessor_Previous(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStorage := NewMockFrameGetSetter(mockCtrl)
+
+	mockStorage.EXPECT().Get(int64(1), "stream/test/fill#fillNull").DoAndReturn(func(orgID int64, channel string) (*data.Frame, bool, error) {
+		return data.NewFrame("test",
+			data.NewField("value", nil, []*float64{floatPtr(5), nil}),
+		), true, nil
+	}).Times(1)
+
+	mockStorage.EXPECT().Set(int64(1), "stream/test/fill#fillNull", gomock.Any()).Times(1)
+
+	processor := NewFillNullFrameProcessor(mockStorage, FillNullFrameProcessorConfig{
+		Fields: []FillNullFieldConfig{
+			{FieldName: "value", Mode: FillNullModePrevious},
+		},
+	})
+
+	frame := data.NewFrame("test",
+		data.NewField("value", nil, []*float64{nil, floatPtr(7), nil}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/fill"}, frame)
+	require.NoError(t, err)
+	require.Equal(t, floatPtr(5), frame.Fields[0].At(0))
+	require.Equal(t, floatPtr(7), frame.Fields[0].At(1))
+	require.Equal(t, floatPtr(7), frame.Fields[0].At(2))
+}
+
+func TestFillNullFrameProcessor_PreviousModifiedLater(t *testing.T) {
+	processor := NewFillNullFrameProcessor(NewFrameStorage(), FillNullFrameProcessorConfig{
+		Fields: []FillNullFieldConfig{
+			{FieldName: "value", Mode: FillNullModePrevious},
+		},
+	})
+	vars := Vars{OrgID: 1, Channel: "stream/test/fill"}
+
+	frame, err := processor.ProcessFrame(context.Background(), vars, data.NewFrame("test",
+		data.NewField("value", nil, []*float64{floatPtr(7)}),
+	))
+	require.NoError(t, err)
+	// A later stage modifies the frame in place.
+	frame.Fields[0].Set(0, floatPtr(100))
+	frame.Fields = append(frame.Fields, data.NewField("extra", nil, []float64{1}))
+
+	frame, err = processor.ProcessFrame(context.Background(), vars, data.NewFrame("test",
+		data.NewField("value", nil, []*float64{nil}),
+	))
+	require.NoError(t, err)
+	require.Equal(t, floatPtr(7), frame.Fields[0].At(0))
+}
+
+func TestFillNullFrameProcessor_Interpolate(t *testing.T) {
+	processor := NewFillNullFrameProcessor(nil, FillNullFrameProcessorConfig{
+		Fields: []FillNullFieldConfig{
+			{FieldName: "value", Mode: FillNullModeInterpolate},
+		},
+	})
+
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(0, 0), time.Unix(1, 0), time.Unix(4, 0), time.Unix(5, 0)}),
+		data.NewField("value", nil, []*float64{nil, floatPtr(0), nil, floatPtr(30)}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Nil(t, frame.Fields[1].At(0))
+	require.Equal(t, floatPtr(22.5), frame.Fields[1].At(2))
+}
+
+func TestFillNullFrameProcessor_InterpolateNonNumeric(t *testing.T) {
+	processor := NewFillNullFrameProcessor(nil, FillNullFrameProcessorConfig{
+		Fields: []FillNullFieldConfig{
+			{FieldName: "value", Mode: FillNullModeInterpolate},
+		},
+	})
+
+	s := "a"
+	frame := data.NewFrame("test",
+		data.NewField("value", nil, []*string{&s, nil}),
+	)
+
+	_, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.Error(t, err)
+}
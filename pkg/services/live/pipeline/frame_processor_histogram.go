@@ -0,0 +1,183 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type HistogramFrameProcessorConfig struct {
+	// FieldName is a numeric field to observe.
+	FieldName string `json:"fieldName"`
+	// Buckets are upper bounds of histogram buckets, +Inf bucket is always added.
+	Buckets []float64 `json:"buckets"`
+	// Accumulate keeps counting across frames of a channel like Prometheus histograms
+	// do. Otherwise, each frame is bucketed on its own.
+	Accumulate bool `json:"accumulate,omitempty"`
+}
+
+// HistogramFrameProcessor converts a numeric field into a single row frame with cumulative
+// histogram bucket fields named <fieldName>_bucket (with `le` label), <fieldName>_sum and
+// <fieldName>_count, so the result can be remote written as a Prometheus histogram.
+// Accumulated counters are kept in frame storage per channel, so they survive rule rebuilds.
+type HistogramFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       HistogramFrameProcessorConfig
+	buckets      []float64
+
+	mu sync.Mutex
+}
+
+type histogramState struct {
+	counts []uint64 // Not cumulative, the last one is +Inf.
+	sum    float64
+	count  uint64
+}
+
+func NewHistogramFrameProcessor(frameStorage FrameGetSetter, config HistogramFrameProcessorConfig) (*HistogramFrameProcessor, error) {
+	if len(config.Buckets) == 0 {
+		return nil, errors.New("at least one bucket required")
+	}
+	buckets := make([]float64, len(config.Buckets))
+	copy(buckets, config.Buckets)
+	sort.Float64s(buckets)
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] == buckets[i-1] {
+			return nil, fmt.Errorf("duplicate bucket: %v", buckets[i])
+		}
+	}
+	return &HistogramFrameProcessor{
+		frameStorage: frameStorage,
+		config:       config,
+		buckets:      buckets,
+	}, nil
+}
+
+const FrameProcessorTypeHistogram = "histogram"
+
+func (p *HistogramFrameProcessor) Type() string {
+	return FrameProcessorTypeHistogram
+}
+
+func (p *HistogramFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	index := fieldIndex(frame, p.config.FieldName)
+	if index < 0 {
+		return nil, fmt.Errorf("field %s not found", p.config.FieldName)
+	}
+	field := frame.Fields[index]
+	if !field.Type().Numeric() {
+		return nil, fmt.Errorf("field %s is not numeric", p.config.FieldName)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := &histogramState{counts: make([]uint64, len(p.buckets)+1)}
+	if p.config.Accumulate {
+		var err error
+		if state, err = p.loadState(vars.OrgID, vars.Channel); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < field.Len(); i++ {
+		v, err := field.NullableFloatAt(i)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil || math.IsNaN(*v) {
+			continue
+		}
+		state.counts[sort.SearchFloat64s(p.buckets, *v)]++
+		state.sum += *v
+		state.count++
+	}
+	if p.config.Accumulate {
+		if err := p.saveState(vars.OrgID, vars.Channel, state); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.frame(frame, field, state), nil
+}
+
+// histogramStateKey is appended to a channel to keep accumulated counters of a field
+// in frame storage, separately from frames published into the channel.
+const histogramStateKey = "#histogram:"
+
+// loadState returns accumulated counters of a channel, counters are reset when buckets
+// are changed.
+func (p *HistogramFrameProcessor) loadState(orgID int64, channel string) (*histogramState, error) {
+	state := &histogramState{counts: make([]uint64, len(p.buckets)+1)}
+	frame, ok, err := p.frameStorage.Get(orgID, channel+histogramStateKey+p.config.FieldName)
+	if err != nil || !ok {
+		return state, err
+	}
+	if len(frame.Fields) != 4 || frame.Rows() != len(state.counts) {
+		return state, nil
+	}
+	for i := range p.buckets {
+		if le, _ := frame.Fields[0].At(i).(float64); le != p.buckets[i] {
+			return state, nil
+		}
+	}
+	for i := range state.counts {
+		state.counts[i], _ = frame.Fields[1].At(i).(uint64)
+	}
+	state.sum, _ = frame.Fields[2].At(0).(float64)
+	state.count, _ = frame.Fields[3].At(0).(uint64)
+	return state, nil
+}
+
+func (p *HistogramFrameProcessor) saveState(orgID int64, channel string, state *histogramState) error {
+	les := append(append(make([]float64, 0, len(state.counts)), p.buckets...), math.Inf(1))
+	sums := make([]float64, len(state.counts))
+	counts := make([]uint64, len(state.counts))
+	for i := range state.counts {
+		sums[i] = state.sum
+		counts[i] = state.count
+	}
+	return p.frameStorage.Set(orgID, channel+histogramStateKey+p.config.FieldName, data.NewFrame("histogram",
+		data.NewField("le", nil, les),
+		data.NewField("bucket", nil, append([]uint64(nil), state.counts...)),
+		data.NewField("sum", nil, sums),
+		data.NewField("count", nil, counts),
+	))
+}
+
+func (p *HistogramFrameProcessor) frame(frame *data.Frame, field *data.Field, state *histogramState) *data.Frame {
+	ts := time.Now()
+	for _, f := range frame.Fields {
+		if f.Type() == data.FieldTypeTime && f.Len() > 0 {
+			ts = f.At(f.Len() - 1).(time.Time)
+			break
+		}
+	}
+
+	fields := []*data.Field{data.NewField("time", nil, []time.Time{ts})}
+	var cumulative uint64
+	for i, count := range state.counts {
+		cumulative += count
+		le := "+Inf"
+		if i < len(p.buckets) {
+			le = strconv.FormatFloat(p.buckets[i], 'f', -1, 64)
+		}
+		labels := data.Labels{"le": le}
+		for k, v := range field.Labels {
+			labels[k] = v
+		}
+		fields = append(fields, data.NewField(p.config.FieldName+"_bucket", labels, []float64{float64(cumulative)}))
+	}
+	fields = append(fields,
+		data.NewField(p.config.FieldName+"_sum", field.Labels.Copy(), []float64{state.sum}),
+		data.NewField(p.config.FieldName+"_count", field.Labels.Copy(), []float64{float64(state.count)}),
+	)
+	return data.NewFrame(frame.Name, fields...)
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramFrameProcessor(t *testing.T) {
+	processor, err := NewHistogramFrameProcessor(NewFrameStorage(), HistogramFrameProcessorConfig{
+		FieldName: "latency",
+		Buckets:   []float64{1, 0.5},
+	})
+	require.NoError(t, err)
+
+	ts := time.Unix(100, 0)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts.Add(-time.Second), ts}),
+		data.NewField("latency", data.Labels{"host": "a"}, []*float64{floatPtr(0.5), floatPtr(2)}),
+	)
+
+	frame, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 6)
+	require.Equal(t, ts, frame.Fields[0].At(0))
+
+	for i, expected := range []struct {
+		le    string
+		count float64
+	}{{"0.5", 1}, {"1", 1}, {"+Inf", 2}} {
+		field := frame.Fields[i+1]
+		require.Equal(t, "latency_bucket", field.Name)
+		require.Equal(t, data.Labels{"le": expected.le, "host": "a"}, field.Labels)
+		require.Equal(t, expected.count, field.At(0))
+	}
+	require.Equal(t, 2.5, frame.Fields[4].At(0))
+	require.Equal(t, 2.0, frame.Fields[5].At(0))
+}
+
+func TestHistogramFrameProcessor_Accumulate(t *testing.T) {
+	processor, err := NewHistogramFrameProcessor(NewFrameStorage(), HistogramFrameProcessorConfig{
+		FieldName:  "value",
+		Buckets:    []float64{10},
+		Accumulate: true,
+	})
+	require.NoError(t, err)
+
+	process := func(vars Vars, values ...float64) *data.Frame {
+		frame, err := processor.ProcessFrame(context.Background(), vars, data.NewFrame("test", data.NewField("value", nil, values)))
+		require.NoError(t, err)
+		return frame
+	}
+
+	process(Vars{OrgID: 1, Channel: "a"}, 1, 20)
+	frame := process(Vars{OrgID: 1, Channel: "a"}, 5)
+	require.Equal(t, 2.0, frame.Fields[1].At(0))
+	require.Equal(t, 3.0, frame.Fields[2].At(0))
+	require.Equal(t, 26.0, frame.Fields[3].At(0))
+
+	frame = process(Vars{OrgID: 1, Channel: "b"}, 5)
+	require.Equal(t, 1.0, frame.Fields[4].At(0))
+}
+
+func TestHistogramFrameProcessor_rebuild(t *testing.T) {
+	frameStorage := NewFrameStorage()
+	config := HistogramFrameProcessorConfig{FieldName: "value", Buckets: []float64{10}, Accumulate: true}
+	vars := Vars{OrgID: 1, Channel: "a"}
+	process := func(config HistogramFrameProcessorConfig, values ...float64) *data.Frame {
+		processor, err := NewHistogramFrameProcessor(frameStorage, config)
+		require.NoError(t, err)
+		frame, err := processor.ProcessFrame(context.Background(), vars, data.NewFrame("test", data.NewField("value", nil, values)))
+		require.NoError(t, err)
+		return frame
+	}
+
+	process(config, 1, 20)
+	// Rule is rebuilt, counters keep growing.
+	frame := process(config, 5)
+	require.Equal(t, 2.0, frame.Fields[1].At(0))
+	require.Equal(t, 3.0, frame.Fields[2].At(0))
+	require.Equal(t, 26.0, frame.Fields[3].At(0))
+	require.Equal(t, 3.0, frame.Fields[4].At(0))
+
+	// Counters are reset when buckets are changed.
+	config.Buckets = []float64{5}
+	frame = process(config, 5)
+	require.Equal(t, 1.0, frame.Fields[1].At(0))
+	require.Equal(t, 1.0, frame.Fields[4].At(0))
+}
+
+func TestHistogramFrameProcessor_Errors(t *testing.T) {
+	_, err := NewHistogramFrameProcessor(NewFrameStorage(), HistogramFrameProcessorConfig{FieldName: "value"})
+	require.Error(t, err)
+	_, err = NewHistogramFrameProcessor(NewFrameStorage(), HistogramFrameProcessorConfig{FieldName: "value", Buckets: []float64{1, 1}})
+	require.Error(t, err)
+
+	processor, err := NewHistogramFrameProcessor(NewFrameStorage(), HistogramFrameProcessorConfig{FieldName: "value", Buckets: []float64{1}})
+	require.NoError(t, err)
+	_, err = processor.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test", data.NewField("value", nil, []string{"x"})))
+	require.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+)
+
+type IdentityLabelsFrameProcessorConfig struct {
+	// Labels maps label names to value templates. Templates may contain {orgId},
+	// {orgName}, {login}, {identityType}, {identityId}, {teams} (comma separated team
+	// IDs) and {serviceAccount} (login of a service account, empty for other
+	// identities) placeholders.
+	Labels map[string]string `json:"labels"`
+	// KeepEmpty adds labels with empty values, by default labels with empty values
+	// are skipped.
+	KeepEmpty bool `json:"keepEmpty,omitempty"`
+}
+
+// IdentityLabelsFrameProcessor adds labels derived from the publishing identity to
+// non-time fields of a frame. Frames published without identity in context (for
+// example by backend plugins) get only the {orgId} value. Identity labels override
+// field labels with the same name, so publishers can not impersonate others.
+type IdentityLabelsFrameProcessor struct {
+	config IdentityLabelsFrameProcessorConfig
+}
+
+var identityLabelPlaceholderRegex = regexp.MustCompile(`\{(\w*)\}`)
+
+var identityLabelPlaceholders = map[string]func(vars Vars, u identity.Requester) string{
+	"orgId": func(vars Vars, _ identity.Requester) string {
+		return strconv.FormatInt(vars.OrgID, 10)
+	},
+	"orgName": func(_ Vars, u identity.Requester) string {
+		return u.GetOrgName()
+	},
+	"login": func(_ Vars, u identity.Requester) string {
+		return u.GetLogin()
+	},
+	"identityType": func(_ Vars, u identity.Requester) string {
+		namespace, _ := u.GetNamespacedID()
+		return namespace
+	},
+	"identityId": func(_ Vars, u identity.Requester) string {
+		_, id := u.GetNamespacedID()
+		return id
+	},
+	"teams": func(_ Vars, u identity.Requester) string {
+		teams := u.GetTeams()
+		ids := make([]string, 0, len(teams))
+		for _, id := range teams {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+		return strings.Join(ids, ",")
+	},
+	"serviceAccount": func(_ Vars, u identity.Requester) string {
+		if namespace, _ := u.GetNamespacedID(); namespace != identity.NamespaceServiceAccount {
+			return ""
+		}
+		return u.GetLogin()
+	},
+}
+
+func NewIdentityLabelsFrameProcessor(config IdentityLabelsFrameProcessorConfig) (*IdentityLabelsFrameProcessor, error) {
+	if len(config.Labels) == 0 {
+		return nil, fmt.Errorf("no labels configured")
+	}
+	for name, template := range config.Labels {
+		if name == "" {
+			return nil, fmt.Errorf("empty label name")
+		}
+		for _, m := range identityLabelPlaceholderRegex.FindAllStringSubmatch(template, -1) {
+			if _, ok := identityLabelPlaceholders[m[1]]; !ok {
+				return nil, fmt.Errorf("unknown placeholder %s in label %s", m[0], name)
+			}
+		}
+	}
+	return &IdentityLabelsFrameProcessor{config: config}, nil
+}
+
+const FrameProcessorTypeIdentityLabels = "identityLabels"
+
+func (p *IdentityLabelsFrameProcessor) Type() string {
+	return FrameProcessorTypeIdentityLabels
+}
+
+func (p *IdentityLabelsFrameProcessor) ProcessFrame(ctx context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	u, ok := livecontext.GetContextSignedUser(ctx)
+	if ok && (u == nil || u.IsNil()) {
+		ok = false
+	}
+
+	labels := make(data.Labels, len(p.config.Labels))
+	for name, template := range p.config.Labels {
+		value := identityLabelPlaceholderRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+			key := placeholder[1 : len(placeholder)-1]
+			if key != "orgId" && !ok {
+				return ""
+			}
+			return identityLabelPlaceholders[key](vars, u)
+		})
+		if value == "" && !p.config.KeepEmpty {
+			continue
+		}
+		labels[name] = value
+	}
+	if len(labels) == 0 {
+		return frame, nil
+	}
+
+	for _, f := range frame.Fields {
+		if f.Type().Time() {
+			continue
+		}
+		fieldLabels := make(data.Labels, len(f.Labels)+len(labels))
+		for k, v := range f.Labels {
+			fieldLabels[k] = v
+		}
+		for k, v := range labels {
+			fieldLabels[k] = v
+		}
+		f.Labels = fieldLabels
+	}
+	return frame, nil
+}
@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestIdentityLabelsFrameProcessor(t *testing.T) {
+	p, err := NewIdentityLabelsFrameProcessor(IdentityLabelsFrameProcessorConfig{
+		Labels: map[string]string{
+			"org":       "org-{orgId}",
+			"publisher": "{identityType}:{login}",
+			"teams":     "{teams}",
+			"sa":        "{serviceAccount}",
+		},
+	})
+	require.NoError(t, err)
+
+	newFrame := func() *data.Frame {
+		return data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+			data.NewField("value", data.Labels{"host": "a", "publisher": "spoofed"}, []float64{1}),
+		)
+	}
+
+	t.Run("service account", func(t *testing.T) {
+		ctx := livecontext.SetContextSignedUser(context.Background(), &user.SignedInUser{
+			UserID:           3,
+			OrgID:            2,
+			Login:            "sa-collector",
+			IsServiceAccount: true,
+			Teams:            []int64{5, 7},
+		})
+		frame, err := p.ProcessFrame(ctx, Vars{OrgID: 2}, newFrame())
+		require.NoError(t, err)
+		require.Nil(t, frame.Fields[0].Labels)
+		require.Equal(t, data.Labels{
+			"host":      "a",
+			"org":       "org-2",
+			"publisher": "service-account:sa-collector",
+			"teams":     "5,7",
+			"sa":        "sa-collector",
+		}, frame.Fields[1].Labels)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		ctx := livecontext.SetContextSignedUser(context.Background(), &user.SignedInUser{
+			UserID: 1,
+			OrgID:  1,
+			Login:  "admin",
+		})
+		frame, err := p.ProcessFrame(ctx, Vars{OrgID: 1}, newFrame())
+		require.NoError(t, err)
+		require.Equal(t, data.Labels{
+			"host":      "a",
+			"org":       "org-1",
+			"publisher": "user:admin",
+		}, frame.Fields[1].Labels)
+	})
+
+	t.Run("no identity", func(t *testing.T) {
+		frame, err := p.ProcessFrame(context.Background(), Vars{OrgID: 1}, newFrame())
+		require.NoError(t, err)
+		require.Equal(t, data.Labels{
+			"host":      "a",
+			"org":       "org-1",
+			"publisher": ":",
+		}, frame.Fields[1].Labels)
+	})
+}
+
+func TestNewIdentityLabelsFrameProcessor_invalid(t *testing.T) {
+	_, err := NewIdentityLabelsFrameProcessor(IdentityLabelsFrameProcessorConfig{})
+	require.Error(t, err)
+	_, err = NewIdentityLabelsFrameProcessor(IdentityLabelsFrameProcessorConfig{
+		Labels: map[string]string{"user": "{email}"},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,211 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
+)
+
+type JoinFrameProcessorConfig struct {
+	// Channel to join with. Last frame of that channel is taken from FrameStorage,
+	// the channel must have a join processor too, this is checked when rules are built.
+	Channel string `json:"channel"`
+	// KeyField is a field name both frames share. Values are compared using
+	// string representation so field types are not required to match.
+	KeyField string `json:"keyField"`
+	// Fields of joined frame to add. All fields except key field are added if empty.
+	Fields []string `json:"fields,omitempty"`
+	// Prefix is prepended to names of joined fields.
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// JoinFrameProcessor joins incoming frame with last frame of another channel on a
+// shared key field. This is a left join – all incoming rows are kept, joined fields
+// are null when there is no matching row. Incoming frame is saved to FrameStorage
+// so that other channels can join with it.
+type JoinFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       JoinFrameProcessorConfig
+}
+
+func NewJoinFrameProcessor(frameStorage FrameGetSetter, config JoinFrameProcessorConfig) *JoinFrameProcessor {
+	return &JoinFrameProcessor{frameStorage: frameStorage, config: config}
+}
+
+const FrameProcessorTypeJoin = "join"
+
+func (p *JoinFrameProcessor) Type() string {
+	return FrameProcessorTypeJoin
+}
+
+// joinStateKey is appended to a channel to keep a last frame of a channel for joins
+// in frame storage.
+const joinStateKey = "#join"
+
+func (p *JoinFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	otherFrame, ok, err := p.frameStorage.Get(vars.OrgID, p.config.Channel+joinStateKey)
+	if err != nil {
+		return nil, err
+	}
+	// Keep a copy without joined fields so that channels can join with each other,
+	// fields of the frame are modified by later stages.
+	if err := p.frameStorage.Set(vars.OrgID, vars.Channel+joinStateKey, copyFrame(frame)); err != nil {
+		return nil, err
+	}
+
+	keyIndex := fieldIndex(frame, p.config.KeyField)
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("key field %s not found in frame", p.config.KeyField)
+	}
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		otherKeyIndex = -1
+		otherRows     map[string]int
+	)
+	if ok {
+		otherKeyIndex = fieldIndex(otherFrame, p.config.KeyField)
+	}
+	if otherKeyIndex >= 0 {
+		otherRows = map[string]int{}
+		keyField := otherFrame.Fields[otherKeyIndex]
+		for i := 0; i < keyField.Len(); i++ {
+			if key, ok := joinKey(keyField, i); ok {
+				// Last row wins.
+				otherRows[key] = i
+			}
+		}
+	}
+
+	for _, name := range p.joinFieldNames(otherFrame, otherKeyIndex) {
+		joinedName := p.config.Prefix + name
+		if fieldIndex(frame, joinedName) >= 0 {
+			return nil, fmt.Errorf("field %s already exists in frame", joinedName)
+		}
+		var source *data.Field
+		if otherKeyIndex >= 0 {
+			if index := fieldIndex(otherFrame, name); index >= 0 {
+				source = otherFrame.Fields[index]
+			}
+		}
+		fieldType := data.FieldTypeNullableFloat64
+		if source != nil {
+			fieldType = source.Type().NullableType()
+		}
+		field := data.NewFieldFromFieldType(fieldType, numRows)
+		field.Name = joinedName
+		if source != nil {
+			field.Labels = source.Labels
+			field.Config = source.Config
+			for i := 0; i < numRows; i++ {
+				key, ok := joinKey(frame.Fields[keyIndex], i)
+				if !ok {
+					continue
+				}
+				row, ok := otherRows[key]
+				if !ok {
+					continue
+				}
+				if v, ok := source.ConcreteAt(row); ok {
+					field.SetConcrete(i, v)
+				}
+			}
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame, nil
+}
+
+// joinFieldNames returns names of fields to join. Configured fields are always added
+// to keep frame schema stable even if joined frame is not available yet.
+func (p *JoinFrameProcessor) joinFieldNames(otherFrame *data.Frame, otherKeyIndex int) []string {
+	if len(p.config.Fields) > 0 {
+		return p.config.Fields
+	}
+	if otherKeyIndex < 0 {
+		return nil
+	}
+	names := make([]string, 0, len(otherFrame.Fields)-1)
+	for i, f := range otherFrame.Fields {
+		if i == otherKeyIndex {
+			continue
+		}
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func joinKey(field *data.Field, i int) (string, bool) {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%v", v), true
+}
+
+// joinChannels returns channels joined by processors, nested ones included.
+func joinChannels(configs []FrameProcessorConfig) []string {
+	var channels []string
+	for _, c := range configs {
+		switch {
+		case c.Type == FrameProcessorTypeJoin && c.JoinProcessorConfig != nil:
+			channels = append(channels, c.JoinProcessorConfig.Channel)
+		case c.Type == FrameProcessorTypeMultiple && c.MultipleProcessorConfig != nil:
+			channels = append(channels, joinChannels(c.MultipleProcessorConfig.Processors)...)
+		}
+	}
+	return channels
+}
+
+func ruleJoinChannels(rule ChannelRule) []string {
+	configs := make([]FrameProcessorConfig, 0, len(rule.Settings.FrameProcessors))
+	for _, c := range rule.Settings.FrameProcessors {
+		if c != nil {
+			configs = append(configs, *c)
+		}
+	}
+	return joinChannels(configs)
+}
+
+// unjoinedChannel is a channel joined by a rule which is not matched by any rule with
+// a join processor, so its frames are never saved for joins.
+type unjoinedChannel struct {
+	ruleIndex int
+	channel   string
+}
+
+// unjoinedChannels checks join processors of rules of a single organization.
+func unjoinedChannels(rules []ChannelRule) []unjoinedChannel {
+	var joining []*tree.Node
+	for _, rule := range rules {
+		if len(ruleJoinChannels(rule)) == 0 {
+			continue
+		}
+		node := tree.New()
+		if addRoute(node, rule.Pattern) == "" {
+			joining = append(joining, node)
+		}
+	}
+	var unjoined []unjoinedChannel
+	for i, rule := range rules {
+		for _, channel := range ruleJoinChannels(rule) {
+			found := false
+			for _, node := range joining {
+				if node.GetValue("/"+channel, true).Handler != nil {
+					found = true
+					break
+				}
+			}
+			if !found {
+				unjoined = append(unjoined, unjoinedChannel{ruleIndex: i, channel: channel})
+			}
+		}
+	}
+	return unjoined
+}
@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinFrameProcessor(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStorage := NewMockFrameGetSetter(mockCtrl)
+
+	mockStorage.EXPECT().Get(int64(1), "stream/hosts/metadata#join").DoAndReturn(func(orgID int64, channel string) (*data.Frame, bool, error) {
+		return data.NewFrame("metadata",
+			data.NewField("host", nil, []string{"a", "b"}),
+			data.NewField("region", nil, []string{"eu", "us"}),
+		), true, nil
+	}).Times(1)
+
+	var stored *data.Frame
+	mockStorage.EXPECT().Set(int64(1), "stream/hosts/cpu#join", gomock.Any()).DoAndReturn(func(orgID int64, channel string, frame *data.Frame) error {
+		stored = frame
+		return nil
+	}).Times(1)
+
+	processor := NewJoinFrameProcessor(mockStorage, JoinFrameProcessorConfig{
+		Channel:  "stream/hosts/metadata",
+		KeyField: "host",
+		Prefix:   "meta_",
+	})
+
+	frame := data.NewFrame("cpu",
+		data.NewField("host", nil, []string{"b", "c"}),
+		data.NewField("value", nil, []float64{1, 2}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/hosts/cpu"}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, "meta_region", frame.Fields[2].Name)
+	v, ok := frame.Fields[2].ConcreteAt(0)
+	require.True(t, ok)
+	require.Equal(t, "us", v)
+	_, ok = frame.Fields[2].ConcreteAt(1)
+	require.False(t, ok)
+
+	// Stored frame has no joined fields and is not affected by later stages.
+	frame.Fields[1].Set(0, 10.0)
+	require.Len(t, stored.Fields, 2)
+	require.Equal(t, 1.0, stored.Fields[1].At(0))
+}
+
+func TestJoinFrameProcessor_NoJoinedFrame(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockStorage := NewMockFrameGetSetter(mockCtrl)
+
+	mockStorage.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, false, nil).Times(1)
+	mockStorage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+	processor := NewJoinFrameProcessor(mockStorage, JoinFrameProcessorConfig{
+		Channel:  "stream/hosts/metadata",
+		KeyField: "host",
+		Fields:   []string{"region"},
+	})
+
+	frame := data.NewFrame("cpu",
+		data.NewField("host", nil, []string{"a"}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 2)
+	require.Nil(t, frame.Fields[1].At(0))
+}
+
+type joinTestStorage struct {
+	Storage
+	rules []ChannelRule
+}
+
+func (s *joinTestStorage) ListChannelRules(_ context.Context, _ int64) ([]ChannelRule, error) {
+	return s.rules, nil
+}
+
+func (s *joinTestStorage) ListWriteConfigs(_ context.Context, _ int64) ([]WriteConfig, error) {
+	return nil, nil
+}
+
+func TestStorageRuleBuilder_JoinChannels(t *testing.T) {
+	join := func(channel string) []*FrameProcessorConfig {
+		return []*FrameProcessorConfig{{
+			Type:                FrameProcessorTypeJoin,
+			JoinProcessorConfig: &JoinFrameProcessorConfig{Channel: channel, KeyField: "host"},
+		}}
+	}
+	storage := &joinTestStorage{rules: []ChannelRule{
+		{Pattern: "stream/hosts/cpu", Settings: ChannelRuleSettings{FrameProcessors: join("stream/hosts/metadata")}},
+		// Frames of the joined channel are never saved for joins.
+		{Pattern: "stream/hosts/metadata"},
+	}}
+	builder := &StorageRuleBuilder{Storage: storage, FrameStorage: NewFrameStorage()}
+	_, err := builder.BuildRules(context.Background(), 1)
+	require.ErrorContains(t, err, "join channel stream/hosts/metadata is not matched by a rule with a join processor")
+
+	// Joined channel may be matched by a wildcard rule, nested processors are checked too.
+	storage.rules[1] = ChannelRule{Pattern: "stream/hosts/:kind", Settings: ChannelRuleSettings{
+		FrameProcessors: []*FrameProcessorConfig{{
+			Type: FrameProcessorTypeMultiple,
+			MultipleProcessorConfig: &MultipleFrameProcessorConfig{Processors: []FrameProcessorConfig{
+				*join("stream/hosts/cpu")[0],
+			}},
+		}},
+	}}
+	rules, err := builder.BuildRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	errs := ValidateRules([]ChannelRule{
+		{Pattern: "stream/hosts/cpu", Settings: ChannelRuleSettings{FrameProcessors: join("stream/hosts/metadata")}},
+		{OrgId: 2, Pattern: "stream/hosts/metadata", Settings: ChannelRuleSettings{FrameProcessors: join("stream/hosts/cpu")}},
+	}, nil)
+	// Rules of other organizations are not joined.
+	require.Len(t, errs, 2)
+	require.Equal(t, "rules[0].settings.frameProcessors", errs[0].Path)
+	require.Equal(t, "rules[1].settings.frameProcessors", errs[1].Path)
+}
@@ -0,0 +1,223 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ReshapeMode defines a direction of frame reshaping.
+type ReshapeMode string
+
+// Known ReshapeMode types.
+const (
+	// ReshapeModeToLong converts each value field into a row with key and value fields.
+	ReshapeModeToLong ReshapeMode = "toLong"
+	// ReshapeModeToWide converts rows with key and value fields into a field per key,
+	// rows with equal values of other fields are merged into one.
+	ReshapeModeToWide ReshapeMode = "toWide"
+)
+
+const (
+	defaultReshapeKeyField   = "key"
+	defaultReshapeValueField = "value"
+)
+
+type ReshapeFrameProcessorConfig struct {
+	Mode ReshapeMode `json:"mode"`
+	// KeyField is a name of a field with keys, "key" by default.
+	KeyField string `json:"keyField,omitempty"`
+	// ValueField is a name of a field with values, "value" by default.
+	ValueField string `json:"valueField,omitempty"`
+	// Fields to convert to rows in toLong mode, all numeric fields by default. Other
+	// fields are repeated for each produced row.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ReshapeFrameProcessor pivots frames between wide and long formats.
+type ReshapeFrameProcessor struct {
+	config ReshapeFrameProcessorConfig
+}
+
+func NewReshapeFrameProcessor(config ReshapeFrameProcessorConfig) *ReshapeFrameProcessor {
+	if config.KeyField == "" {
+		config.KeyField = defaultReshapeKeyField
+	}
+	if config.ValueField == "" {
+		config.ValueField = defaultReshapeValueField
+	}
+	return &ReshapeFrameProcessor{config: config}
+}
+
+const FrameProcessorTypeReshape = "reshape"
+
+func (p *ReshapeFrameProcessor) Type() string {
+	return FrameProcessorTypeReshape
+}
+
+func (p *ReshapeFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	switch p.config.Mode {
+	case ReshapeModeToLong:
+		return p.toLong(frame)
+	case ReshapeModeToWide:
+		return p.toWide(frame)
+	default:
+		return nil, fmt.Errorf("unknown reshape mode: %s", p.config.Mode)
+	}
+}
+
+func (p *ReshapeFrameProcessor) isValueField(f *data.Field) bool {
+	if len(p.config.Fields) == 0 {
+		return f.Type().Numeric()
+	}
+	for _, name := range p.config.Fields {
+		if name == f.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ReshapeFrameProcessor) toLong(frame *data.Frame) (*data.Frame, error) {
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	var keep, values []*data.Field
+	for _, f := range frame.Fields {
+		if p.isValueField(f) {
+			if !f.Type().Numeric() {
+				return nil, fmt.Errorf("field %s is not numeric", f.Name)
+			}
+			values = append(values, f)
+		} else {
+			keep = append(keep, f)
+		}
+	}
+
+	fields := make([]*data.Field, 0, len(keep)+2)
+	for _, f := range keep {
+		newField := data.NewFieldFromFieldType(f.Type(), 0)
+		newField.Name = f.Name
+		newField.Labels = f.Labels
+		newField.Config = f.Config
+		fields = append(fields, newField)
+	}
+	keyField := data.NewField(p.config.KeyField, nil, []string{})
+	valueField := data.NewField(p.config.ValueField, nil, []*float64{})
+	fields = append(fields, keyField, valueField)
+
+	for row := 0; row < numRows; row++ {
+		for _, f := range values {
+			for i, k := range keep {
+				fields[i].Append(k.CopyAt(row))
+			}
+			v, err := f.NullableFloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			keyField.Append(f.Name)
+			valueField.Append(v)
+		}
+	}
+	return data.NewFrame(frame.Name, fields...), nil
+}
+
+func (p *ReshapeFrameProcessor) toWide(frame *data.Frame) (*data.Frame, error) {
+	keyIndex := fieldIndex(frame, p.config.KeyField)
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("key field %s not found", p.config.KeyField)
+	}
+	valueIndex := fieldIndex(frame, p.config.ValueField)
+	if valueIndex < 0 {
+		return nil, fmt.Errorf("value field %s not found", p.config.ValueField)
+	}
+	keyField, valueField := frame.Fields[keyIndex], frame.Fields[valueIndex]
+	if !valueField.Type().Numeric() {
+		return nil, fmt.Errorf("field %s is not numeric", valueField.Name)
+	}
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	var keep []*data.Field
+	for i, f := range frame.Fields {
+		if i != keyIndex && i != valueIndex {
+			keep = append(keep, f)
+		}
+	}
+
+	// Output rows and key fields are ordered by first appearance.
+	var (
+		rowSources []int
+		rowIndex   = map[string]int{}
+		keys       []string
+		keyValues  = map[string][]*float64{}
+	)
+	for row := 0; row < numRows; row++ {
+		groupKey := rowGroupKey(keep, row)
+		outRow, ok := rowIndex[groupKey]
+		if !ok {
+			outRow = len(rowSources)
+			rowIndex[groupKey] = outRow
+			rowSources = append(rowSources, row)
+		}
+
+		k, ok := keyField.ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		key := fmt.Sprintf("%v", k)
+		if _, ok := keyValues[key]; !ok {
+			keys = append(keys, key)
+		}
+		v, err := valueField.NullableFloatAt(row)
+		if err != nil {
+			return nil, err
+		}
+		vals := keyValues[key]
+		for len(vals) <= outRow {
+			vals = append(vals, nil)
+		}
+		vals[outRow] = v
+		keyValues[key] = vals
+	}
+
+	fields := make([]*data.Field, 0, len(keep)+len(keys))
+	for _, f := range keep {
+		newField := data.NewFieldFromFieldType(f.Type(), len(rowSources))
+		newField.Name = f.Name
+		newField.Labels = f.Labels
+		newField.Config = f.Config
+		for i, sourceRow := range rowSources {
+			newField.Set(i, f.CopyAt(sourceRow))
+		}
+		fields = append(fields, newField)
+	}
+	for _, key := range keys {
+		vals := keyValues[key]
+		for len(vals) < len(rowSources) {
+			vals = append(vals, nil)
+		}
+		if fieldIndex(frame, key) >= 0 {
+			return nil, fmt.Errorf("field %s already exists", key)
+		}
+		fields = append(fields, data.NewField(key, valueField.Labels, vals))
+	}
+	return data.NewFrame(frame.Name, fields...), nil
+}
+
+func rowGroupKey(fields []*data.Field, row int) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if v, ok := f.ConcreteAt(row); ok {
+			parts[i] = fmt.Sprintf("%v", v)
+		} else {
+			parts[i] = "\x01"
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReshapeFrameProcessor_ToLong(t *testing.T) {
+	processor := NewReshapeFrameProcessor(ReshapeFrameProcessorConfig{Mode: ReshapeModeToLong, KeyField: "metric"})
+
+	ts := time.Unix(100, 0)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts, ts.Add(time.Second)}),
+		data.NewField("cpu", nil, []float64{1, 2}),
+		data.NewField("mem", nil, []*float64{floatPtr(3), nil}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, 4, frame.Fields[0].Len())
+	require.Equal(t, "metric", frame.Fields[1].Name)
+	require.Equal(t, "value", frame.Fields[2].Name)
+
+	require.Equal(t, []any{ts, ts, ts.Add(time.Second), ts.Add(time.Second)},
+		[]any{frame.Fields[0].At(0), frame.Fields[0].At(1), frame.Fields[0].At(2), frame.Fields[0].At(3)})
+	require.Equal(t, []any{"cpu", "mem", "cpu", "mem"},
+		[]any{frame.Fields[1].At(0), frame.Fields[1].At(1), frame.Fields[1].At(2), frame.Fields[1].At(3)})
+	require.Equal(t, floatPtr(3), frame.Fields[2].At(1))
+	require.Nil(t, frame.Fields[2].At(3))
+}
+
+func TestReshapeFrameProcessor_ToWide(t *testing.T) {
+	processor := NewReshapeFrameProcessor(ReshapeFrameProcessorConfig{Mode: ReshapeModeToWide})
+
+	ts := time.Unix(100, 0)
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{ts, ts, ts.Add(time.Second)}),
+		data.NewField("key", nil, []string{"cpu", "mem", "cpu"}),
+		data.NewField("value", nil, []float64{1, 3, 2}),
+	)
+
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, 2, frame.Fields[0].Len())
+	require.Equal(t, "cpu", frame.Fields[1].Name)
+	require.Equal(t, "mem", frame.Fields[2].Name)
+	require.Equal(t, floatPtr(1), frame.Fields[1].At(0))
+	require.Equal(t, floatPtr(2), frame.Fields[1].At(1))
+	require.Equal(t, floatPtr(3), frame.Fields[2].At(0))
+	require.Nil(t, frame.Fields[2].At(1))
+}
+
+func TestReshapeFrameProcessor_Errors(t *testing.T) {
+	frame := data.NewFrame("test",
+		data.NewField("key", nil, []string{"a"}),
+		data.NewField("value", nil, []string{"b"}),
+	)
+	processor := NewReshapeFrameProcessor(ReshapeFrameProcessorConfig{Mode: ReshapeModeToWide})
+	_, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.Error(t, err)
+
+	processor = NewReshapeFrameProcessor(ReshapeFrameProcessorConfig{Mode: ReshapeModeToLong, Fields: []string{"key"}})
+	_, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.Error(t, err)
+
+	processor = NewReshapeFrameProcessor(ReshapeFrameProcessorConfig{Mode: "unknown"})
+	_, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.Error(t, err)
+}
@@ -0,0 +1,238 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// ScriptLanguageCEL is a Common Expression Language, see https://github.com/google/cel-spec.
+const ScriptLanguageCEL = "cel"
+
+const (
+	defaultScriptTimeout   = 100 * time.Millisecond
+	defaultScriptCostLimit = 1000000
+)
+
+type ScriptFieldConfig struct {
+	// Name of a field to set, existing field with the same name is replaced.
+	Name string `json:"name"`
+	// Expression evaluated for each row, the result becomes a field value.
+	Expression string `json:"expression"`
+}
+
+// ScriptFrameProcessorConfig configures a scripted processor. Expressions have access
+// to `row` (map of field name to value, null for nulls), `index` (row index) and `vars`
+// (orgId, channel, scope, namespace, path).
+type ScriptFrameProcessorConfig struct {
+	// Language of expressions, only "cel" is supported at the moment.
+	Language string `json:"language,omitempty"`
+	// Filter is an optional boolean expression, rows evaluated to false are dropped.
+	Filter string `json:"filter,omitempty"`
+	// Fields to calculate.
+	Fields []ScriptFieldConfig `json:"fields,omitempty"`
+	// TimeoutMs limits the time spent on a single frame, 100ms by default.
+	TimeoutMs int64 `json:"timeoutMs,omitempty"`
+	// CostLimit limits cost of a single expression evaluation which bounds CPU and
+	// memory an expression can use, 1000000 by default.
+	CostLimit uint64 `json:"costLimit,omitempty"`
+}
+
+type scriptField struct {
+	name    string
+	program cel.Program
+}
+
+// ScriptFrameProcessor evaluates user-supplied expressions against frame rows.
+type ScriptFrameProcessor struct {
+	config  ScriptFrameProcessorConfig
+	filter  cel.Program
+	fields  []scriptField
+	timeout time.Duration
+}
+
+const FrameProcessorTypeScript = "script"
+
+func NewScriptFrameProcessor(config ScriptFrameProcessorConfig) (*ScriptFrameProcessor, error) {
+	if config.Language != "" && config.Language != ScriptLanguageCEL {
+		return nil, fmt.Errorf("unsupported script language: %s", config.Language)
+	}
+	if config.Filter == "" && len(config.Fields) == 0 {
+		return nil, errors.New("filter or fields required")
+	}
+	env, err := cel.NewEnv(
+		cel.Variable("row", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("vars", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("index", cel.IntType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	costLimit := config.CostLimit
+	if costLimit == 0 {
+		costLimit = defaultScriptCostLimit
+	}
+	compile := func(expr string) (cel.Program, error) {
+		ast, iss := env.Compile(expr)
+		if iss.Err() != nil {
+			return nil, iss.Err()
+		}
+		return env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(100))
+	}
+
+	p := &ScriptFrameProcessor{
+		config:  config,
+		timeout: defaultScriptTimeout,
+	}
+	if config.TimeoutMs > 0 {
+		p.timeout = time.Duration(config.TimeoutMs) * time.Millisecond
+	}
+	if config.Filter != "" {
+		if p.filter, err = compile(config.Filter); err != nil {
+			return nil, fmt.Errorf("error compiling filter: %w", err)
+		}
+	}
+	for _, f := range config.Fields {
+		program, err := compile(f.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling expression for field %s: %w", f.Name, err)
+		}
+		p.fields = append(p.fields, scriptField{name: f.Name, program: program})
+	}
+	return p, nil
+}
+
+func (p *ScriptFrameProcessor) Type() string {
+	return FrameProcessorTypeScript
+}
+
+func (p *ScriptFrameProcessor) ProcessFrame(ctx context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	scriptVars := map[string]any{
+		"orgId":     vars.OrgID,
+		"channel":   vars.Channel,
+		"scope":     vars.Scope,
+		"namespace": vars.Namespace,
+		"path":      vars.Path,
+	}
+
+	var keepRows []int
+	results := make([][]any, len(p.fields))
+	for i := 0; i < numRows; i++ {
+		row := make(map[string]any, len(frame.Fields))
+		for _, f := range frame.Fields {
+			v, _ := f.ConcreteAt(i)
+			row[f.Name] = v
+		}
+		activation := map[string]any{"row": row, "vars": scriptVars, "index": i}
+		if p.filter != nil {
+			out, err := evalScript(ctx, p.filter, activation)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating filter at row %d: %w", i, err)
+			}
+			keep, ok := out.(bool)
+			if !ok {
+				return nil, fmt.Errorf("filter must return bool, got %T", out)
+			}
+			if !keep {
+				continue
+			}
+		}
+		keepRows = append(keepRows, i)
+		for j, f := range p.fields {
+			out, err := evalScript(ctx, f.program, activation)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating field %s at row %d: %w", f.name, i, err)
+			}
+			results[j] = append(results[j], out)
+		}
+	}
+
+	if p.filter != nil && len(keepRows) != numRows {
+		for i, f := range frame.Fields {
+			newField := data.NewFieldFromFieldType(f.Type(), len(keepRows))
+			newField.Name = f.Name
+			newField.Labels = f.Labels
+			newField.Config = f.Config
+			for row, sourceRow := range keepRows {
+				newField.Set(row, f.CopyAt(sourceRow))
+			}
+			frame.Fields[i] = newField
+		}
+	}
+
+	for j, f := range p.fields {
+		field, err := scriptResultField(f.name, results[j], len(keepRows))
+		if err != nil {
+			return nil, fmt.Errorf("error setting field %s: %w", f.name, err)
+		}
+		if index := fieldIndex(frame, f.name); index >= 0 {
+			frame.Fields[index] = field
+		} else {
+			frame.Fields = append(frame.Fields, field)
+		}
+	}
+	return frame, nil
+}
+
+func evalScript(ctx context.Context, program cel.Program, activation map[string]any) (any, error) {
+	out, _, err := program.ContextEval(ctx, activation)
+	if err != nil {
+		return nil, err
+	}
+	return scriptValue(out), nil
+}
+
+func scriptValue(v ref.Val) any {
+	if v == nil || v == types.NullValue {
+		return nil
+	}
+	switch val := v.Value().(type) {
+	case uint64:
+		return float64(val)
+	case time.Duration:
+		return val.String()
+	default:
+		return val
+	}
+}
+
+func scriptResultField(name string, values []any, numRows int) (*data.Field, error) {
+	fieldType := data.FieldTypeNullableString
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		ft, err := fieldTypeForValue(v)
+		if err != nil {
+			return nil, err
+		}
+		fieldType = ft
+		break
+	}
+	field := data.NewFieldFromFieldType(fieldType, numRows)
+	field.Name = name
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		converted, err := convertToFieldType(v, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		field.SetConcrete(i, converted)
+	}
+	return field, nil
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptFrameProcessor(t *testing.T) {
+	processor, err := NewScriptFrameProcessor(ScriptFrameProcessorConfig{
+		Filter: `row.value != null && row.value > 1.0`,
+		Fields: []ScriptFieldConfig{
+			{Name: "double", Expression: `row.value * 2.0`},
+			{Name: "label", Expression: `vars.channel + "/" + row.host`},
+		},
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test",
+		data.NewField("host", nil, []string{"a", "b", "c"}),
+		data.NewField("value", nil, []*float64{floatPtr(1), nil, floatPtr(3)}),
+	)
+
+	frame, err = processor.ProcessFrame(context.Background(), Vars{Channel: "stream/test"}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, 1, frame.Fields[0].Len())
+	require.Equal(t, "c", frame.Fields[0].At(0))
+	v, _ := frame.Fields[2].ConcreteAt(0)
+	require.Equal(t, 6.0, v)
+	v, _ = frame.Fields[3].ConcreteAt(0)
+	require.Equal(t, "stream/test/c", v)
+}
+
+func TestScriptFrameProcessor_Errors(t *testing.T) {
+	_, err := NewScriptFrameProcessor(ScriptFrameProcessorConfig{Language: "starlark", Filter: "true"})
+	require.Error(t, err)
+
+	_, err = NewScriptFrameProcessor(ScriptFrameProcessorConfig{Filter: "row."})
+	require.Error(t, err)
+
+	processor, err := NewScriptFrameProcessor(ScriptFrameProcessorConfig{Filter: `"not bool"`})
+	require.NoError(t, err)
+	_, err = processor.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test",
+		data.NewField("value", nil, []float64{1}),
+	))
+	require.Error(t, err)
+}
+
+func TestScriptFrameProcessor_CostLimit(t *testing.T) {
+	processor, err := NewScriptFrameProcessor(ScriptFrameProcessorConfig{
+		Fields:    []ScriptFieldConfig{{Name: "big", Expression: `[1,2,3,4,5,6,7,8,9,10].map(x, [1,2,3,4,5,6,7,8,9,10].map(y, x * y)).size()`}},
+		CostLimit: 10,
+		TimeoutMs: int64(time.Second / time.Millisecond),
+	})
+	require.NoError(t, err)
+	_, err = processor.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test",
+		data.NewField("value", nil, []float64{1}),
+	))
+	require.Error(t, err)
+}
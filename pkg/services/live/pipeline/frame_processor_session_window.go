@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// maxSessionKeys limits a number of open sessions per channel, sessions with the
+// oldest events are closed when the limit is exceeded.
+const maxSessionKeys = 10000
+
+type SessionWindowFrameProcessorConfig struct {
+	// KeyField is an optional field to group events by, all events of a channel are
+	// in one session when not set.
+	KeyField string `json:"keyField,omitempty"`
+	// TimeField is a field with event time, the first time field by default.
+	TimeField string `json:"timeField,omitempty"`
+	// GapMilliseconds is a period of inactivity after which a session is closed.
+	GapMilliseconds int64 `json:"gapMilliseconds"`
+	// MaxDurationMilliseconds optionally closes sessions lasting longer.
+	MaxDurationMilliseconds int64 `json:"maxDurationMilliseconds,omitempty"`
+}
+
+// SessionWindowFrameProcessor groups events into gap based session windows and
+// outputs a frame with summaries of closed sessions: key, start, end, duration in
+// seconds and event count. Sessions are closed by event time: when an event of any
+// key arrives later than the gap after the last event of a session. Frames are
+// dropped while no session is closed. Open sessions are kept in frame storage, so
+// they survive rule rebuilds, but are not shared in HA setup.
+type SessionWindowFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       SessionWindowFrameProcessorConfig
+	gap          time.Duration
+	max          time.Duration
+
+	mu sync.Mutex
+}
+
+type sessionWindowState struct {
+	watermark time.Time
+	open      map[string]*session
+}
+
+type session struct {
+	key   string
+	start time.Time
+	last  time.Time
+	count int64
+}
+
+func NewSessionWindowFrameProcessor(frameStorage FrameGetSetter, config SessionWindowFrameProcessorConfig) (*SessionWindowFrameProcessor, error) {
+	if config.GapMilliseconds <= 0 {
+		return nil, errors.New("gap must be positive")
+	}
+	if config.MaxDurationMilliseconds < 0 {
+		return nil, errors.New("max duration must not be negative")
+	}
+	return &SessionWindowFrameProcessor{
+		frameStorage: frameStorage,
+		config:       config,
+		gap:          time.Duration(config.GapMilliseconds) * time.Millisecond,
+		max:          time.Duration(config.MaxDurationMilliseconds) * time.Millisecond,
+	}, nil
+}
+
+const FrameProcessorTypeSessionWindow = "sessionWindow"
+
+func (p *SessionWindowFrameProcessor) Type() string {
+	return FrameProcessorTypeSessionWindow
+}
+
+func (p *SessionWindowFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	timeIndex := -1
+	if p.config.TimeField != "" {
+		timeIndex = fieldIndex(frame, p.config.TimeField)
+		if timeIndex < 0 {
+			return nil, fmt.Errorf("time field %s not found", p.config.TimeField)
+		}
+		if !frame.Fields[timeIndex].Type().Time() {
+			return nil, fmt.Errorf("field %s is not a time field", p.config.TimeField)
+		}
+	} else {
+		for i, f := range frame.Fields {
+			if f.Type().Time() {
+				timeIndex = i
+				break
+			}
+		}
+		if timeIndex < 0 {
+			return nil, errors.New("no time field found")
+		}
+	}
+	var keyField *data.Field
+	if p.config.KeyField != "" {
+		i := fieldIndex(frame, p.config.KeyField)
+		if i < 0 {
+			return nil, fmt.Errorf("key field %s not found", p.config.KeyField)
+		}
+		keyField = frame.Fields[i]
+	}
+	numRows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state, err := p.loadState(vars.OrgID, vars.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	var closed []*session
+	for row := 0; row < numRows; row++ {
+		v, ok := frame.Fields[timeIndex].ConcreteAt(row)
+		if !ok {
+			continue
+		}
+		t := v.(time.Time)
+		key := ""
+		if keyField != nil {
+			k, ok := keyField.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			key = fmt.Sprintf("%v", k)
+		}
+
+		s := state.open[key]
+		if s != nil && t.Sub(s.last) > p.gap {
+			closed = append(closed, s)
+			s = nil
+		}
+		if s == nil {
+			s = &session{key: key, start: t, last: t}
+			state.open[key] = s
+		}
+		s.count++
+		if t.After(s.last) {
+			s.last = t
+		}
+		if t.Before(s.start) {
+			s.start = t
+		}
+		if p.max > 0 && s.last.Sub(s.start) >= p.max {
+			closed = append(closed, s)
+			delete(state.open, key)
+		}
+		if t.After(state.watermark) {
+			state.watermark = t
+		}
+	}
+
+	for key, s := range state.open {
+		if state.watermark.Sub(s.last) > p.gap {
+			closed = append(closed, s)
+			delete(state.open, key)
+		}
+	}
+	if len(state.open) > maxSessionKeys {
+		open := make([]*session, 0, len(state.open))
+		for _, s := range state.open {
+			open = append(open, s)
+		}
+		sort.Slice(open, func(i, j int) bool { return open[i].last.Before(open[j].last) })
+		for _, s := range open[:len(open)-maxSessionKeys] {
+			closed = append(closed, s)
+			delete(state.open, s.key)
+		}
+	}
+
+	if err := p.saveState(vars.OrgID, vars.Channel, state); err != nil {
+		return nil, err
+	}
+	if len(closed) == 0 {
+		return nil, nil
+	}
+	return p.summaryFrame(frame.Name, closed), nil
+}
+
+// sessionWindowStateKey is appended to a channel to keep open sessions in frame
+// storage, separately from frames published into the channel.
+const sessionWindowStateKey = "#sessionWindow"
+
+func (p *SessionWindowFrameProcessor) loadState(orgID int64, channel string) (*sessionWindowState, error) {
+	state := &sessionWindowState{open: map[string]*session{}}
+	frame, ok, err := p.frameStorage.Get(orgID, channel+sessionWindowStateKey)
+	if err != nil || !ok {
+		return state, err
+	}
+	if len(frame.Fields) != 5 {
+		return state, nil
+	}
+	for row := 0; row < frame.Rows(); row++ {
+		s := &session{}
+		s.key, _ = frame.Fields[0].At(row).(string)
+		s.start, _ = frame.Fields[1].At(row).(time.Time)
+		s.last, _ = frame.Fields[2].At(row).(time.Time)
+		s.count, _ = frame.Fields[3].At(row).(int64)
+		state.watermark, _ = frame.Fields[4].At(row).(time.Time)
+		state.open[s.key] = s
+	}
+	return state, nil
+}
+
+func (p *SessionWindowFrameProcessor) saveState(orgID int64, channel string, state *sessionWindowState) error {
+	keys := make([]string, 0, len(state.open))
+	for key := range state.open {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	starts := make([]time.Time, 0, len(keys))
+	lasts := make([]time.Time, 0, len(keys))
+	counts := make([]int64, 0, len(keys))
+	watermarks := make([]time.Time, 0, len(keys))
+	for _, key := range keys {
+		s := state.open[key]
+		starts = append(starts, s.start)
+		lasts = append(lasts, s.last)
+		counts = append(counts, s.count)
+		watermarks = append(watermarks, state.watermark)
+	}
+	return p.frameStorage.Set(orgID, channel+sessionWindowStateKey, data.NewFrame("sessionWindow",
+		data.NewField("key", nil, keys),
+		data.NewField("start", nil, starts),
+		data.NewField("last", nil, lasts),
+		data.NewField("count", nil, counts),
+		data.NewField("watermark", nil, watermarks),
+	))
+}
+
+func (p *SessionWindowFrameProcessor) summaryFrame(name string, sessions []*session) *data.Frame {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].last.Before(sessions[j].last)
+	})
+	startField := data.NewField("start", nil, make([]time.Time, len(sessions)))
+	endField := data.NewField("end", nil, make([]time.Time, len(sessions)))
+	durationField := data.NewField("duration", nil, make([]float64, len(sessions)))
+	durationField.Config = &data.FieldConfig{Unit: "s"}
+	countField := data.NewField("count", nil, make([]int64, len(sessions)))
+	fields := []*data.Field{startField, endField, durationField, countField}
+
+	var keyField *data.Field
+	if p.config.KeyField != "" {
+		keyField = data.NewField(p.config.KeyField, nil, make([]string, len(sessions)))
+		fields = append([]*data.Field{keyField}, fields...)
+	}
+	for i, s := range sessions {
+		if keyField != nil {
+			keyField.Set(i, s.key)
+		}
+		startField.Set(i, s.start)
+		endField.Set(i, s.last)
+		durationField.Set(i, s.last.Sub(s.start).Seconds())
+		countField.Set(i, s.count)
+	}
+	return data.NewFrame(name, fields...)
+}
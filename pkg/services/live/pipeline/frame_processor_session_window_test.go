@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionWindowFrameProcessor(t *testing.T) {
+	p, err := NewSessionWindowFrameProcessor(NewFrameStorage(), SessionWindowFrameProcessorConfig{
+		KeyField:        "user",
+		GapMilliseconds: 10000,
+	})
+	require.NoError(t, err)
+
+	base := time.Unix(1000, 0)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+	vars := Vars{OrgID: 1, Channel: "stream/test/activity"}
+	newFrame := func(times []time.Time, users []string) *data.Frame {
+		return data.NewFrame("activity",
+			data.NewField("time", nil, times),
+			data.NewField("user", nil, users),
+		)
+	}
+
+	// Sessions are open, nothing to output.
+	frame, err := p.ProcessFrame(context.Background(), vars, newFrame(
+		[]time.Time{at(0), at(2), at(5)},
+		[]string{"a", "b", "a"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	// Event of a after the gap closes the first session of a, b is closed by watermark.
+	frame, err = p.ProcessFrame(context.Background(), vars, newFrame(
+		[]time.Time{at(20)},
+		[]string{"a"},
+	))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	require.Equal(t, 2, frame.Rows())
+	require.Equal(t, []string{"user", "start", "end", "duration", "count"}, []string{
+		frame.Fields[0].Name, frame.Fields[1].Name, frame.Fields[2].Name, frame.Fields[3].Name, frame.Fields[4].Name,
+	})
+	// Ordered by session end.
+	require.Equal(t, "b", frame.Fields[0].At(0))
+	require.Equal(t, at(2), frame.Fields[1].At(0))
+	require.Equal(t, at(2), frame.Fields[2].At(0))
+	require.Equal(t, int64(1), frame.Fields[4].At(0))
+	require.Equal(t, "a", frame.Fields[0].At(1))
+	require.Equal(t, at(0), frame.Fields[1].At(1))
+	require.Equal(t, at(5), frame.Fields[2].At(1))
+	require.Equal(t, 5.0, frame.Fields[3].At(1))
+	require.Equal(t, int64(2), frame.Fields[4].At(1))
+
+	// State is kept per channel.
+	frame, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/other"}, newFrame(
+		[]time.Time{at(100)},
+		[]string{"a"},
+	))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+}
+
+func TestSessionWindowFrameProcessor_maxDuration(t *testing.T) {
+	p, err := NewSessionWindowFrameProcessor(NewFrameStorage(), SessionWindowFrameProcessorConfig{
+		GapMilliseconds:         10000,
+		MaxDurationMilliseconds: 5000,
+	})
+	require.NoError(t, err)
+	base := time.Unix(1000, 0)
+	frame, err := p.ProcessFrame(context.Background(), Vars{Channel: "stream/test/cycle"}, data.NewFrame("cycle",
+		data.NewField("time", nil, []time.Time{base, base.Add(3 * time.Second), base.Add(6 * time.Second), base.Add(7 * time.Second)}),
+	))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, int64(3), frame.Fields[3].At(0))
+}
+
+func TestSessionWindowFrameProcessor_rebuild(t *testing.T) {
+	frameStorage := NewFrameStorage()
+	config := SessionWindowFrameProcessorConfig{KeyField: "user", GapMilliseconds: 10000}
+	p, err := NewSessionWindowFrameProcessor(frameStorage, config)
+	require.NoError(t, err)
+
+	base := time.Unix(1000, 0)
+	vars := Vars{OrgID: 1, Channel: "stream/test/activity"}
+	newFrame := func(sec int, user string) *data.Frame {
+		return data.NewFrame("activity",
+			data.NewField("time", nil, []time.Time{base.Add(time.Duration(sec) * time.Second)}),
+			data.NewField("user", nil, []string{user}),
+		)
+	}
+	frame, err := p.ProcessFrame(context.Background(), vars, newFrame(0, "a"))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	// Rule is rebuilt in the middle of a session, the new processor continues it.
+	p, err = NewSessionWindowFrameProcessor(frameStorage, config)
+	require.NoError(t, err)
+	frame, err = p.ProcessFrame(context.Background(), vars, newFrame(5, "a"))
+	require.NoError(t, err)
+	require.Nil(t, frame)
+
+	p, err = NewSessionWindowFrameProcessor(frameStorage, config)
+	require.NoError(t, err)
+	frame, err = p.ProcessFrame(context.Background(), vars, newFrame(30, "b"))
+	require.NoError(t, err)
+	require.NotNil(t, frame)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, "a", frame.Fields[0].At(0))
+	require.Equal(t, base, frame.Fields[1].At(0))
+	require.Equal(t, base.Add(5*time.Second), frame.Fields[2].At(0))
+	require.Equal(t, int64(2), frame.Fields[4].At(0))
+}
+
+func TestNewSessionWindowFrameProcessor_invalid(t *testing.T) {
+	_, err := NewSessionWindowFrameProcessor(NewFrameStorage(), SessionWindowFrameProcessorConfig{})
+	require.Error(t, err)
+}
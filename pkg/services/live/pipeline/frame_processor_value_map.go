@@ -0,0 +1,165 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type ValueMapFrameProcessorConfig struct {
+	FieldNames []string `json:"fieldNames"`
+	// Mappings of values formatted as strings, like "3" or "E42", to display values.
+	Mappings map[string]string `json:"mappings,omitempty"`
+	// LookupTableUID is a UID of a jsonobj entity with an object of mappings, used for
+	// values missing in inline Mappings.
+	LookupTableUID string `json:"lookupTableUid,omitempty"`
+	// Default is a display value of unmapped values, they are kept formatted as
+	// strings when empty.
+	Default string `json:"default,omitempty"`
+}
+
+// LookupTableGetter returns mappings kept in the entity store and a version of the
+// entity, the version changes with every update of the table.
+type LookupTableGetter interface {
+	GetLookupTable(ctx context.Context, orgID int64, uid string) (table map[string]string, version string, err error)
+}
+
+// lookupTableCheckInterval is a period between checks of a lookup table entity version.
+const lookupTableCheckInterval = 10 * time.Second
+
+// ValueMapFrameProcessor replaces values of configured fields with display strings,
+// so status and error codes of devices are readable in panels and logs. Nulls are kept.
+type ValueMapFrameProcessor struct {
+	config ValueMapFrameProcessorConfig
+	fields map[string]struct{}
+	tables *lookupTableCache
+}
+
+func NewValueMapFrameProcessor(getter LookupTableGetter, config ValueMapFrameProcessorConfig) (*ValueMapFrameProcessor, error) {
+	if len(config.FieldNames) == 0 {
+		return nil, errors.New("no fields to map")
+	}
+	if len(config.Mappings) == 0 && config.LookupTableUID == "" {
+		return nil, errors.New("mappings or lookup table required")
+	}
+	p := &ValueMapFrameProcessor{config: config, fields: make(map[string]struct{}, len(config.FieldNames))}
+	for _, name := range config.FieldNames {
+		p.fields[name] = struct{}{}
+	}
+	if config.LookupTableUID != "" {
+		if getter == nil {
+			return nil, errors.New("lookup table store is not available")
+		}
+		p.tables = newLookupTableCache(getter, config.LookupTableUID)
+	}
+	return p, nil
+}
+
+const FrameProcessorTypeValueMap = "valueMap"
+
+func (p *ValueMapFrameProcessor) Type() string {
+	return FrameProcessorTypeValueMap
+}
+
+func (p *ValueMapFrameProcessor) ProcessFrame(ctx context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	var table map[string]string
+	if p.tables != nil {
+		var err error
+		if table, err = p.tables.get(ctx, vars.OrgID); err != nil {
+			return nil, fmt.Errorf("error getting lookup table: %w", err)
+		}
+	}
+	fields := make([]*data.Field, len(frame.Fields))
+	for i, f := range frame.Fields {
+		if _, ok := p.fields[f.Name]; !ok {
+			fields[i] = f
+			continue
+		}
+		mapped := data.NewFieldFromFieldType(data.FieldTypeNullableString, f.Len())
+		mapped.Name = f.Name
+		mapped.Labels = f.Labels
+		mapped.Config = f.Config
+		for row := 0; row < f.Len(); row++ {
+			v, ok := f.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			mapped.SetConcrete(row, p.mapValue(fmt.Sprint(v), table))
+		}
+		fields[i] = mapped
+	}
+	out := data.NewFrame(frame.Name, fields...)
+	out.RefID = frame.RefID
+	out.Meta = frame.Meta
+	return out, nil
+}
+
+func (p *ValueMapFrameProcessor) mapValue(value string, table map[string]string) string {
+	if display, ok := p.config.Mappings[value]; ok {
+		return display
+	}
+	if display, ok := table[value]; ok {
+		return display
+	}
+	if p.config.Default != "" {
+		return p.config.Default
+	}
+	return value
+}
+
+// lookupTableCache keeps a lookup table of a processor and reloads it when a version of
+// the table entity changes. Table is checked at most once per lookupTableCheckInterval.
+type lookupTableCache struct {
+	getter LookupTableGetter
+	uid    string
+	now    func() time.Time
+
+	mu        sync.Mutex
+	orgs      map[int64]*cachedLookupTable
+	lastCheck map[int64]time.Time
+}
+
+type cachedLookupTable struct {
+	version string
+	table   map[string]string
+}
+
+func newLookupTableCache(getter LookupTableGetter, uid string) *lookupTableCache {
+	return &lookupTableCache{
+		getter:    getter,
+		uid:       uid,
+		now:       time.Now,
+		orgs:      map[int64]*cachedLookupTable{},
+		lastCheck: map[int64]time.Time{},
+	}
+}
+
+func (c *lookupTableCache) get(ctx context.Context, orgID int64) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.orgs[orgID]
+	now := c.now()
+	if ok && now.Sub(c.lastCheck[orgID]) < lookupTableCheckInterval {
+		return cached.table, nil
+	}
+	table, version, err := c.getter.GetLookupTable(ctx, orgID, c.uid)
+	if err != nil {
+		if ok {
+			// Keep mapping with the last known table while the store is unavailable.
+			logger.Warn("Error getting lookup table, using cached version", "uid", c.uid, "orgId", orgID, "error", err)
+			c.lastCheck[orgID] = now
+			return cached.table, nil
+		}
+		return nil, err
+	}
+	c.lastCheck[orgID] = now
+	if !ok || cached.version != version {
+		logger.Debug("Loaded lookup table", "uid", c.uid, "orgId", orgID, "version", version)
+		c.orgs[orgID] = &cachedLookupTable{version: version, table: table}
+	}
+	return c.orgs[orgID].table, nil
+}
@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testLookupTableGetter struct {
+	table   map[string]string
+	version string
+	err     error
+	calls   int
+}
+
+func (g *testLookupTableGetter) GetLookupTable(_ context.Context, _ int64, _ string) (map[string]string, string, error) {
+	g.calls++
+	return g.table, g.version, g.err
+}
+
+func TestValueMapFrameProcessor(t *testing.T) {
+	getter := &testLookupTableGetter{table: map[string]string{"E42": "Overheat", "1": "From table"}, version: "1"}
+	p, err := NewValueMapFrameProcessor(getter, ValueMapFrameProcessorConfig{
+		FieldNames:     []string{"status", "error"},
+		Mappings:       map[string]string{"0": "Stopped", "1": "Running"},
+		LookupTableUID: "errors",
+	})
+	require.NoError(t, err)
+
+	stopped, running := int64(0), int64(1)
+	frame := data.NewFrame("test",
+		data.NewField("status", nil, []*int64{&stopped, &running, nil}),
+		data.NewField("error", nil, []string{"E42", "E1", ""}),
+		data.NewField("value", nil, []float64{1, 2, 3}),
+	)
+	out, err := p.ProcessFrame(context.Background(), Vars{OrgID: 1}, frame)
+	require.NoError(t, err)
+	require.Equal(t, []*string{stringPtr("Stopped"), stringPtr("Running"), nil}, stringFieldValues(out.Fields[0]))
+	require.Equal(t, []*string{stringPtr("Overheat"), stringPtr("E1"), stringPtr("")}, stringFieldValues(out.Fields[1]))
+	require.Equal(t, frame.Fields[2], out.Fields[2])
+
+	// Cached table is used while the store is unavailable.
+	getter.err = errors.New("unavailable")
+	p.tables.now = func() time.Time { return time.Now().Add(time.Minute) }
+	_, err = p.ProcessFrame(context.Background(), Vars{OrgID: 1}, frame)
+	require.NoError(t, err)
+	require.Equal(t, 2, getter.calls)
+
+	_, err = p.ProcessFrame(context.Background(), Vars{OrgID: 2}, frame)
+	require.Error(t, err)
+}
+
+func TestValueMapFrameProcessor_default(t *testing.T) {
+	p, err := NewValueMapFrameProcessor(nil, ValueMapFrameProcessorConfig{
+		FieldNames: []string{"status"},
+		Mappings:   map[string]string{"true": "On"},
+		Default:    "Unknown",
+	})
+	require.NoError(t, err)
+	out, err := p.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test", data.NewField("status", nil, []bool{true, false})))
+	require.NoError(t, err)
+	require.Equal(t, []*string{stringPtr("On"), stringPtr("Unknown")}, stringFieldValues(out.Fields[0]))
+
+	_, err = NewValueMapFrameProcessor(nil, ValueMapFrameProcessorConfig{FieldNames: []string{"status"}, LookupTableUID: "codes"})
+	require.Error(t, err)
+}
+
+func stringFieldValues(f *data.Field) []*string {
+	values := make([]*string, f.Len())
+	for i := range values {
+		values[i] = f.At(i).(*string)
+	}
+	return values
+}
@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// fieldIndex returns index of a field with the given name in a frame or -1 if not found.
+func fieldIndex(frame *data.Frame, name string) int {
+	for i, f := range frame.Fields {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// fieldTypeForValue returns a nullable field type suitable to keep a value
+// decoded from JSON configuration.
+func fieldTypeForValue(v any) (data.FieldType, error) {
+	switch v.(type) {
+	case float64, float32, int, int64, int32, uint64:
+		return data.FieldTypeNullableFloat64, nil
+	case string:
+		return data.FieldTypeNullableString, nil
+	case bool:
+		return data.FieldTypeNullableBool, nil
+	case time.Time:
+		return data.FieldTypeNullableTime, nil
+	default:
+		return data.FieldTypeUnknown, fmt.Errorf("unsupported value type: %T", v)
+	}
+}
+
+// convertToFieldType converts a (usually JSON-decoded) value to a concrete value of the
+// field type, so it can be used with data.Field SetConcrete and Append methods.
+//
+//nolint:gocyclo
+func convertToFieldType(v any, ft data.FieldType) (any, error) {
+	nonNullable := ft.NonNullableType()
+	switch nonNullable {
+	case data.FieldTypeString:
+		switch val := v.(type) {
+		case string:
+			return val, nil
+		case float64:
+			return strconv.FormatFloat(val, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(val), nil
+		default:
+			return fmt.Sprintf("%v", v), nil
+		}
+	case data.FieldTypeBool:
+		switch val := v.(type) {
+		case bool:
+			return val, nil
+		case string:
+			return strconv.ParseBool(val)
+		case float64:
+			return val != 0, nil
+		}
+	case data.FieldTypeTime:
+		switch val := v.(type) {
+		case time.Time:
+			return val, nil
+		case float64:
+			return time.UnixMilli(int64(val)), nil
+		case string:
+			return time.Parse(time.RFC3339Nano, val)
+		}
+	default:
+		if !nonNullable.Numeric() {
+			break
+		}
+		var f float64
+		switch val := v.(type) {
+		case float64:
+			f = val
+		case float32:
+			f = float64(val)
+		case int:
+			f = float64(val)
+		case int64:
+			f = float64(val)
+		case int32:
+			f = float64(val)
+		case uint64:
+			f = float64(val)
+		case bool:
+			if val {
+				f = 1
+			}
+		case string:
+			parsed, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, err
+			}
+			f = parsed
+		default:
+			return nil, fmt.Errorf("can't convert %T to %s", v, ft)
+		}
+		return floatToNumericType(f, nonNullable)
+	}
+	return nil, fmt.Errorf("can't convert %T to %s", v, ft)
+}
+
+func floatToNumericType(f float64, ft data.FieldType) (any, error) {
+	switch ft {
+	case data.FieldTypeFloat64:
+		return f, nil
+	case data.FieldTypeFloat32:
+		return float32(f), nil
+	case data.FieldTypeInt8:
+		return int8(f), nil
+	case data.FieldTypeInt16:
+		return int16(f), nil
+	case data.FieldTypeInt32:
+		return int32(f), nil
+	case data.FieldTypeInt64:
+		return int64(f), nil
+	case data.FieldTypeUint8:
+		return uint8(f), nil
+	case data.FieldTypeUint16:
+		return uint16(f), nil
+	case data.FieldTypeUint32:
+		return uint32(f), nil
+	case data.FieldTypeUint64:
+		return uint64(f), nil
+	default:
+		return nil, fmt.Errorf("not a numeric field type: %s", ft)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
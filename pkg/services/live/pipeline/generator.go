@@ -0,0 +1,297 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	GeneratorFieldTypeSine        = "sine"
+	GeneratorFieldTypeSquare      = "square"
+	GeneratorFieldTypeTriangle    = "triangle"
+	GeneratorFieldTypeSawtooth    = "sawtooth"
+	GeneratorFieldTypeRandom      = "random"
+	GeneratorFieldTypeRandomWalk  = "randomWalk"
+	GeneratorFieldTypeCounter     = "counter"
+	GeneratorFieldTypeCategorical = "categorical"
+
+	defaultGeneratorInterval = time.Second
+	defaultGeneratorPeriod   = time.Minute
+	// minGeneratorInterval protects the pipeline from misconfigured generators.
+	minGeneratorInterval = 10 * time.Millisecond
+)
+
+// Generator produces frames with synthetic data, so rules and dashboards can be
+// demoed and tested without real data sources.
+type Generator struct {
+	interval time.Duration
+	frame    string
+	fields   []*generatorField
+	rnd      *rand.Rand
+}
+
+type generatorField struct {
+	config GeneratorFieldConfig
+	period time.Duration
+	// value is a current value of random walk and counter fields.
+	value float64
+}
+
+// NewGenerator creates Generator from config.
+func NewGenerator(config GeneratorConfig) (*Generator, error) {
+	if len(config.Fields) == 0 {
+		return nil, errors.New("generator has no fields")
+	}
+	g := &Generator{
+		interval: time.Duration(config.IntervalMilliseconds) * time.Millisecond,
+		frame:    config.Frame,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if g.interval == 0 {
+		g.interval = defaultGeneratorInterval
+	}
+	if g.interval < minGeneratorInterval {
+		return nil, fmt.Errorf("generator interval can't be less than %s", minGeneratorInterval)
+	}
+	if g.frame == "" {
+		g.frame = "generator"
+	}
+	names := map[string]struct{}{"time": {}}
+	for _, fc := range config.Fields {
+		if fc.Name == "" {
+			return nil, errors.New("generator field name is required")
+		}
+		if _, ok := names[fc.Name]; ok {
+			return nil, fmt.Errorf("duplicate generator field: %s", fc.Name)
+		}
+		names[fc.Name] = struct{}{}
+
+		f := &generatorField{config: fc, period: time.Duration(fc.PeriodMilliseconds) * time.Millisecond}
+		if f.period <= 0 {
+			f.period = defaultGeneratorPeriod
+		}
+		switch fc.Type {
+		case GeneratorFieldTypeCategorical:
+			if len(fc.Values) == 0 {
+				return nil, fmt.Errorf("categorical field %s has no values", fc.Name)
+			}
+		case GeneratorFieldTypeSine, GeneratorFieldTypeSquare, GeneratorFieldTypeTriangle, GeneratorFieldTypeSawtooth,
+			GeneratorFieldTypeRandom, GeneratorFieldTypeRandomWalk, GeneratorFieldTypeCounter:
+			if f.config.Min == 0 && f.config.Max == 0 {
+				f.config.Max = 1
+			}
+			if f.config.Max <= f.config.Min {
+				return nil, fmt.Errorf("max of field %s must be greater than min", fc.Name)
+			}
+			if f.config.Step == 0 {
+				f.config.Step = (f.config.Max - f.config.Min) / 100
+			}
+			f.value = f.config.Min
+			if fc.Type == GeneratorFieldTypeRandomWalk {
+				f.value = f.config.Min + (f.config.Max-f.config.Min)/2
+			}
+		default:
+			return nil, fmt.Errorf("unknown generator field type: %s", fc.Type)
+		}
+		g.fields = append(g.fields, f)
+	}
+	return g, nil
+}
+
+// Frame returns a single row frame with values generated for a moment of time.
+func (g *Generator) Frame(now time.Time) *data.Frame {
+	fields := make([]*data.Field, 0, len(g.fields)+1)
+	fields = append(fields, data.NewField("time", nil, []time.Time{now}))
+	for _, f := range g.fields {
+		var field *data.Field
+		if f.config.Type == GeneratorFieldTypeCategorical {
+			field = data.NewField(f.config.Name, f.config.Labels, []string{f.config.Values[g.rnd.Intn(len(f.config.Values))]})
+		} else {
+			field = data.NewField(f.config.Name, f.config.Labels, []float64{g.value(f, now)})
+		}
+		fields = append(fields, field)
+	}
+	return data.NewFrame(g.frame, fields...)
+}
+
+func (g *Generator) value(f *generatorField, now time.Time) float64 {
+	lo, hi := f.config.Min, f.config.Max
+	// Waveform phase is aligned to wall clock, so values don't depend on start time.
+	phase := float64(now.UnixMilli()%f.period.Milliseconds()) / float64(f.period.Milliseconds())
+	switch f.config.Type {
+	case GeneratorFieldTypeSine:
+		return lo + (hi-lo)*(0.5+0.5*math.Sin(2*math.Pi*phase))
+	case GeneratorFieldTypeSquare:
+		if phase < 0.5 {
+			return hi
+		}
+		return lo
+	case GeneratorFieldTypeTriangle:
+		if phase < 0.5 {
+			return lo + (hi-lo)*phase*2
+		}
+		return hi - (hi-lo)*(phase-0.5)*2
+	case GeneratorFieldTypeSawtooth:
+		return lo + (hi-lo)*phase
+	case GeneratorFieldTypeRandom:
+		return lo + (hi-lo)*g.rnd.Float64()
+	case GeneratorFieldTypeRandomWalk:
+		f.value = math.Max(lo, math.Min(hi, f.value+f.config.Step*(2*g.rnd.Float64()-1)))
+		return f.value
+	case GeneratorFieldTypeCounter:
+		v := f.value
+		f.value += f.config.Step
+		if f.value > hi {
+			f.value = lo
+		}
+		return v
+	}
+	return 0
+}
+
+func (g *Generator) run(ctx context.Context, publish func(ctx context.Context, frame *data.Frame) error) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := publish(ctx, g.Frame(now)); err != nil {
+				logger.Error("Error publishing generated frame", "error", err)
+			}
+		}
+	}
+}
+
+// checkGeneratorChannel validates a channel generator of a rule publishes to.
+func checkGeneratorChannel(pattern string) error {
+	if strings.ContainsAny(pattern, ":*") {
+		return fmt.Errorf("generator can't be used with wildcard pattern %s", pattern)
+	}
+	return nil
+}
+
+// GeneratorRunner runs generators of channel rules of all organizations and feeds
+// generated frames to the pipeline like real traffic after the converter stage.
+type GeneratorRunner struct {
+	pipeline *Pipeline
+	storage  Storage
+	orgIDs   func(ctx context.Context) ([]int64, error)
+
+	mu      sync.Mutex
+	running map[generatorKey]*runningGenerator
+}
+
+type generatorKey struct {
+	orgID   int64
+	channel string
+}
+
+type runningGenerator struct {
+	// config is JSON of GeneratorConfig the generator was started with.
+	config string
+	cancel context.CancelFunc
+}
+
+// NewGeneratorRunner creates GeneratorRunner, orgIDs lists organizations to run
+// generators of.
+func NewGeneratorRunner(pipeline *Pipeline, storage Storage, orgIDs func(ctx context.Context) ([]int64, error)) *GeneratorRunner {
+	return &GeneratorRunner{
+		pipeline: pipeline,
+		storage:  storage,
+		orgIDs:   orgIDs,
+		running:  map[generatorKey]*runningGenerator{},
+	}
+}
+
+// Run starts generators and reloads them from rules periodically until context is done.
+func (r *GeneratorRunner) Run(ctx context.Context, reloadInterval time.Duration) error {
+	r.sync(ctx)
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.mu.Lock()
+			for key, g := range r.running {
+				g.cancel()
+				delete(r.running, key)
+			}
+			r.mu.Unlock()
+			return ctx.Err()
+		case <-ticker.C:
+			r.sync(ctx)
+		}
+	}
+}
+
+// sync starts generators of new or changed rules and stops removed ones.
+func (r *GeneratorRunner) sync(ctx context.Context) {
+	orgIDs, err := r.orgIDs(ctx)
+	if err != nil {
+		logger.Error("Error listing organizations for generators", "error", err)
+		return
+	}
+	desired := map[generatorKey]GeneratorConfig{}
+	// failedOrgs keep running generators when their rules can't be loaded.
+	failedOrgs := map[int64]struct{}{}
+	for _, orgID := range orgIDs {
+		rules, err := r.storage.ListChannelRules(ctx, orgID)
+		if err != nil {
+			logger.Error("Error listing channel rules for generators", "orgId", orgID, "error", err)
+			failedOrgs[orgID] = struct{}{}
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Settings.Generator != nil {
+				desired[generatorKey{orgID: orgID, channel: rule.Pattern}] = *rule.Settings.Generator
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, g := range r.running {
+		_, failed := failedOrgs[key.orgID]
+		if _, ok := desired[key]; !ok && !failed {
+			g.cancel()
+			delete(r.running, key)
+		}
+	}
+	for key, config := range desired {
+		configJSON, _ := json.Marshal(config)
+		if g, ok := r.running[key]; ok {
+			if g.config == string(configJSON) {
+				continue
+			}
+			g.cancel()
+			delete(r.running, key)
+		}
+		if err := checkGeneratorChannel(key.channel); err != nil {
+			logger.Error("Invalid generator", "orgId", key.orgID, "channel", key.channel, "error", err)
+			continue
+		}
+		generator, err := NewGenerator(config)
+		if err != nil {
+			logger.Error("Invalid generator", "orgId", key.orgID, "channel", key.channel, "error", err)
+			continue
+		}
+		genCtx, cancel := context.WithCancel(ctx)
+		r.running[key] = &runningGenerator{config: string(configJSON), cancel: cancel}
+		key := key
+		go generator.run(genCtx, func(ctx context.Context, frame *data.Frame) error {
+			_, err := r.pipeline.ProcessFrames(ctx, key.orgID, key.channel, []*data.Frame{frame})
+			return err
+		})
+	}
+}
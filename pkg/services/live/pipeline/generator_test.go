@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_Frame(t *testing.T) {
+	g, err := NewGenerator(GeneratorConfig{Fields: []GeneratorFieldConfig{
+		{Name: "sine", Type: GeneratorFieldTypeSine, Min: -1, Max: 1, PeriodMilliseconds: 4000},
+		{Name: "square", Type: GeneratorFieldTypeSquare, Max: 10, PeriodMilliseconds: 4000},
+		{Name: "counter", Type: GeneratorFieldTypeCounter, Max: 2, Step: 1},
+		{Name: "walk", Type: GeneratorFieldTypeRandomWalk, Min: 10, Max: 20},
+		{Name: "state", Type: GeneratorFieldTypeCategorical, Values: []string{"ok"}, Labels: data.Labels{"host": "a"}},
+	}})
+	require.NoError(t, err)
+
+	// A quarter of a period.
+	now := time.UnixMilli(1000)
+	frame := g.Frame(now)
+	require.Equal(t, "generator", frame.Name)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, now, frame.Fields[0].At(0))
+	require.InDelta(t, 1, frame.Fields[1].At(0), 1e-9)
+	require.Equal(t, float64(10), frame.Fields[2].At(0))
+	require.Equal(t, data.Labels{"host": "a"}, frame.Fields[5].Labels)
+	require.Equal(t, "ok", frame.Fields[5].At(0))
+
+	var counter []float64
+	for i := 0; i < 4; i++ {
+		frame = g.Frame(now)
+		counter = append(counter, frame.Fields[3].At(0).(float64))
+		walk := frame.Fields[4].At(0).(float64)
+		require.True(t, walk >= 10 && walk <= 20)
+	}
+	require.Equal(t, []float64{1, 2, 0, 1}, counter)
+}
+
+func TestNewGenerator_invalid(t *testing.T) {
+	for _, config := range []GeneratorConfig{
+		{},
+		{Fields: []GeneratorFieldConfig{{Name: "v", Type: "unknown"}}},
+		{Fields: []GeneratorFieldConfig{{Name: "v", Type: GeneratorFieldTypeSine, Min: 5, Max: 1}}},
+		{Fields: []GeneratorFieldConfig{{Name: "v", Type: GeneratorFieldTypeCategorical}}},
+		{Fields: []GeneratorFieldConfig{{Name: "time", Type: GeneratorFieldTypeRandom}}},
+		{IntervalMilliseconds: 1, Fields: []GeneratorFieldConfig{{Name: "v", Type: GeneratorFieldTypeRandom}}},
+	} {
+		_, err := NewGenerator(config)
+		require.Error(t, err)
+	}
+}
+
+type testRuleStorage struct {
+	Storage
+	mu    sync.Mutex
+	rules []ChannelRule
+}
+
+func (s *testRuleStorage) ListChannelRules(_ context.Context, _ int64) ([]ChannelRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rules, nil
+}
+
+func TestGeneratorRunner_sync(t *testing.T) {
+	outputter := &generatorTestOutputter{frames: make(chan *data.Frame, 10)}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/demo/sine": {
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	storage := &testRuleStorage{rules: []ChannelRule{{
+		Pattern: "stream/demo/sine",
+		Settings: ChannelRuleSettings{Generator: &GeneratorConfig{
+			IntervalMilliseconds: 10,
+			Fields:               []GeneratorFieldConfig{{Name: "value", Type: GeneratorFieldTypeSine}},
+		}},
+	}}}
+	r := NewGeneratorRunner(p, storage, func(context.Context) ([]int64, error) { return []int64{1}, nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r.sync(ctx)
+	require.Len(t, r.running, 1)
+	select {
+	case frame := <-outputter.frames:
+		require.Equal(t, "value", frame.Fields[1].Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no generated frame")
+	}
+
+	storage.mu.Lock()
+	storage.rules = nil
+	storage.mu.Unlock()
+	r.sync(ctx)
+	require.Empty(t, r.running)
+}
+
+type generatorTestOutputter struct {
+	frames chan *data.Frame
+}
+
+func (o *generatorTestOutputter) Type() string {
+	return "test"
+}
+
+func (o *generatorTestOutputter) OutputFrame(_ context.Context, _ Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	select {
+	case o.frames <- frame:
+	default:
+	}
+	return nil, nil
+}
@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// Heartbeat statuses of channels set in status frames.
+const (
+	HeartbeatStatusStale = "stale"
+	HeartbeatStatusOK    = "ok"
+)
+
+// Heartbeat of a rule sends status frames to FrameOutputters when a channel receives
+// no input for Timeout, and when inputs resume afterwards.
+type Heartbeat struct {
+	Timeout         time.Duration
+	FrameOutputters []FrameOutputter
+}
+
+// HeartbeatMonitor detects channels of rules with a heartbeat which stopped receiving
+// inputs, like devices which died silently. A channel is monitored since its first
+// input, so channels of wildcard patterns are monitored separately. Status frames have
+// time, lastSeen, status and up fields, where up is 1 when the channel receives inputs
+// and 0 when it is stale. Frames returned by heartbeat outputters are not processed
+// further.
+type HeartbeatMonitor struct {
+	ruleGetter ChannelRuleGetter
+	now        func() time.Time
+
+	mu       sync.Mutex
+	channels map[string]*heartbeatChannel
+}
+
+type heartbeatChannel struct {
+	orgID    int64
+	channel  string
+	lastSeen time.Time
+	stale    bool
+}
+
+func NewHeartbeatMonitor(ruleGetter ChannelRuleGetter) *HeartbeatMonitor {
+	return &HeartbeatMonitor{
+		ruleGetter: ruleGetter,
+		now:        time.Now,
+		channels:   map[string]*heartbeatChannel{},
+	}
+}
+
+// Run checks channels for staleness every interval until context is done.
+func (m *HeartbeatMonitor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+// seen records an input of a channel, recovery status is sent when the channel was stale.
+func (m *HeartbeatMonitor) seen(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string) {
+	if m == nil || rule.Heartbeat == nil {
+		return
+	}
+	now := m.now()
+	key := orgchannel.PrependOrgID(orgID, channelID)
+	m.mu.Lock()
+	c, ok := m.channels[key]
+	if !ok {
+		c = &heartbeatChannel{orgID: orgID, channel: channelID}
+		m.channels[key] = c
+	}
+	lastSeen, recovered := c.lastSeen, c.stale
+	c.lastSeen = now
+	c.stale = false
+	m.mu.Unlock()
+	if recovered {
+		m.output(ctx, rule, orgID, channelID, HeartbeatStatusOK, lastSeen, now)
+	}
+}
+
+// check sends stale status of channels which received no inputs for heartbeat timeout
+// of their rules. Channels of removed rules or rules without a heartbeat are forgotten.
+func (m *HeartbeatMonitor) check(ctx context.Context) {
+	type staleChannel struct {
+		rule    *LiveChannelRule
+		channel heartbeatChannel
+	}
+	var staleChannels []staleChannel
+	now := m.now()
+	m.mu.Lock()
+	for key, c := range m.channels {
+		rule, ok, err := m.ruleGetter.Get(c.orgID, c.channel)
+		if err != nil {
+			logger.Error("Error getting rule for heartbeat", "orgId", c.orgID, "channel", c.channel, "error", err)
+			continue
+		}
+		if !ok || rule.Heartbeat == nil {
+			delete(m.channels, key)
+			continue
+		}
+		if c.stale || now.Sub(c.lastSeen) < rule.Heartbeat.Timeout {
+			continue
+		}
+		c.stale = true
+		staleChannels = append(staleChannels, staleChannel{rule: rule, channel: *c})
+	}
+	m.mu.Unlock()
+	for _, s := range staleChannels {
+		m.output(ctx, s.rule, s.channel.orgID, s.channel.channel, HeartbeatStatusStale, s.channel.lastSeen, now)
+	}
+}
+
+func (m *HeartbeatMonitor) output(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, status string, lastSeen time.Time, now time.Time) {
+	ch, err := live.ParseChannel(channelID)
+	if err != nil {
+		logger.Error("Error parsing channel", "error", err, "channel", channelID)
+		return
+	}
+	vars := Vars{
+		OrgID:     orgID,
+		Channel:   channelID,
+		Scope:     ch.Scope,
+		Namespace: ch.Namespace,
+		Path:      ch.Path,
+		Params:    rule.ChannelParams(channelID),
+	}
+	up := 0.0
+	if status == HeartbeatStatusOK {
+		up = 1
+	}
+	frame := data.NewFrame("heartbeat",
+		data.NewField("time", nil, []time.Time{now}),
+		data.NewField("lastSeen", nil, []time.Time{lastSeen}),
+		data.NewField("status", nil, []string{status}),
+		data.NewField("up", data.Labels{"channel": channelID}, []*float64{&up}),
+	)
+	for _, out := range rule.Heartbeat.FrameOutputters {
+		if _, err := out.OutputFrame(ctx, vars, frame); err != nil {
+			logger.Error("Error outputting heartbeat", "orgId", orgID, "channel", channelID, "output", out.Type(), "error", err)
+		}
+	}
+}
+
+func validateHeartbeatConfig(config HeartbeatConfig) error {
+	if config.TimeoutMilliseconds <= 0 {
+		return errors.New("heartbeat timeout must be positive")
+	}
+	if len(config.Outputs) == 0 {
+		return errors.New("heartbeat requires at least one output")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatMonitor(t *testing.T) {
+	heartbeatOutputter := &testOutputter{}
+	ruleGetter := &testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/devices/1": {
+				Converter: &testConverter{"", data.NewFrame("test")},
+				Heartbeat: &Heartbeat{Timeout: time.Minute, FrameOutputters: []FrameOutputter{heartbeatOutputter}},
+			},
+		},
+	}
+	p, err := New(ruleGetter)
+	require.NoError(t, err)
+	monitor := NewHeartbeatMonitor(ruleGetter)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	monitor.now = func() time.Time { return now }
+	p.Heartbeats = monitor
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/devices/1", []byte(`{}`))
+	require.NoError(t, err)
+	lastSeen := now
+
+	now = now.Add(30 * time.Second)
+	monitor.check(context.Background())
+	require.Nil(t, heartbeatOutputter.frame)
+
+	now = now.Add(30 * time.Second)
+	monitor.check(context.Background())
+	require.NotNil(t, heartbeatOutputter.frame)
+	require.Equal(t, HeartbeatStatusStale, heartbeatOutputter.frame.Fields[2].At(0))
+	require.Equal(t, lastSeen, heartbeatOutputter.frame.Fields[1].At(0))
+	require.Equal(t, 0.0, *heartbeatOutputter.frame.Fields[3].At(0).(*float64))
+
+	// Stale status is sent once.
+	heartbeatOutputter.frame = nil
+	now = now.Add(time.Minute)
+	monitor.check(context.Background())
+	require.Nil(t, heartbeatOutputter.frame)
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/devices/1", []byte(`{}`))
+	require.NoError(t, err)
+	require.NotNil(t, heartbeatOutputter.frame)
+	require.Equal(t, HeartbeatStatusOK, heartbeatOutputter.frame.Fields[2].At(0))
+	require.Equal(t, 1.0, *heartbeatOutputter.frame.Fields[3].At(0).(*float64))
+
+	// Channels of removed rules are forgotten.
+	ruleGetter.mu.Lock()
+	delete(ruleGetter.rules, "stream/devices/1")
+	ruleGetter.mu.Unlock()
+	monitor.check(context.Background())
+	require.Empty(t, monitor.channels)
+}
@@ -0,0 +1,278 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// JSONSchemaGetter returns a JSON Schema document kept in the entity store and a version
+// of the entity, the version changes with every update of the schema.
+type JSONSchemaGetter interface {
+	GetJSONSchema(ctx context.Context, orgID int64, uid string) (schema []byte, version string, err error)
+}
+
+// schemaCheckInterval is a period between checks of a schema entity version.
+const schemaCheckInterval = 10 * time.Second
+
+// jsonSchemaProperty is an object property of a JSON Schema, nested object properties are
+// keyed by a path the same way JSON auto converter names fields, like "a.b".
+type jsonSchemaProperty struct {
+	fieldType  data.FieldType
+	defaultVal any
+	hasDefault bool
+	required   []string
+	properties map[string]*jsonSchemaProperty
+}
+
+// jsonSchemaDoc is the part of JSON Schema JSON auto converter uses: types, required
+// properties and defaults of object properties.
+type jsonSchemaDoc struct {
+	Type       any                       `json:"type"`
+	Format     string                    `json:"format"`
+	Default    any                       `json:"default"`
+	Required   []string                  `json:"required"`
+	Properties map[string]*jsonSchemaDoc `json:"properties"`
+}
+
+// jsonSchema is a compiled JSON Schema of documents a converter gets.
+type jsonSchema struct {
+	root *jsonSchemaProperty
+	// tips are field types by field name.
+	tips map[string]Field
+}
+
+func parseJSONSchema(body []byte) (*jsonSchema, error) {
+	var doc jsonSchemaDoc
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	if t := jsonSchemaType(doc.Type); t != "object" && t != "" {
+		return nil, fmt.Errorf("json schema must describe an object, got %s", t)
+	}
+	s := &jsonSchema{tips: map[string]Field{}}
+	root, err := s.compile("", &doc)
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+	return s, nil
+}
+
+func (s *jsonSchema) compile(path string, doc *jsonSchemaDoc) (*jsonSchemaProperty, error) {
+	p := &jsonSchemaProperty{defaultVal: doc.Default, hasDefault: doc.Default != nil}
+	schemaType := jsonSchemaType(doc.Type)
+	if schemaType == "" && doc.Properties != nil {
+		schemaType = "object"
+	}
+	switch schemaType {
+	case "object":
+		p.required = doc.Required
+		p.properties = make(map[string]*jsonSchemaProperty, len(doc.Properties))
+		for name, child := range doc.Properties {
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			compiled, err := s.compile(childPath, child)
+			if err != nil {
+				return nil, err
+			}
+			p.properties[name] = compiled
+		}
+		return p, nil
+	case "number":
+		p.fieldType = data.FieldTypeNullableFloat64
+	case "integer":
+		p.fieldType = data.FieldTypeNullableInt64
+	case "boolean":
+		p.fieldType = data.FieldTypeNullableBool
+	case "string":
+		p.fieldType = data.FieldTypeNullableString
+		if doc.Format == "date-time" {
+			p.fieldType = data.FieldTypeNullableTime
+		}
+	default:
+		// Arrays and properties without a type are converted with inference.
+		return p, nil
+	}
+	if p.hasDefault {
+		if _, err := convertToFieldType(p.defaultVal, p.fieldType); err != nil {
+			return nil, fmt.Errorf("invalid default of %s: %w", path, err)
+		}
+	}
+	s.tips[path] = Field{Name: path, Type: p.fieldType}
+	return p, nil
+}
+
+// jsonSchemaType returns a type of a schema, the first non-null type of a type list.
+func jsonSchemaType(t any) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// prepare sets defaults of missing properties and checks required properties of a document.
+func (s *jsonSchema) prepare(body []byte) ([]byte, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, errors.New("document must be an object")
+	}
+	if err := s.root.apply("", obj); err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+func (p *jsonSchemaProperty) apply(path string, obj map[string]any) error {
+	names := make([]string, 0, len(p.properties))
+	for name := range p.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		child := p.properties[name]
+		v, ok := obj[name]
+		if (!ok || v == nil) && child.hasDefault {
+			obj[name] = child.defaultVal
+			continue
+		}
+		if nested, isObj := v.(map[string]any); isObj && child.properties != nil {
+			if err := child.apply(joinSchemaPath(path, name), nested); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range p.required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("required property missing: %s", joinSchemaPath(path, name))
+		}
+	}
+	return nil
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// applyTypes converts fields of a frame to types of a schema, except fields with
+// configured tips.
+func (s *jsonSchema) applyTypes(frame *data.Frame, tips map[string]Field) error {
+	for i, f := range frame.Fields {
+		if _, ok := tips[f.Name]; ok {
+			continue
+		}
+		tip, ok := s.tips[f.Name]
+		if !ok || f.Type() == tip.Type {
+			continue
+		}
+		converted := data.NewFieldFromFieldType(tip.Type, f.Len())
+		converted.Name = f.Name
+		converted.Labels = f.Labels
+		converted.Config = f.Config
+		for row := 0; row < f.Len(); row++ {
+			v, ok := f.ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			cv, err := convertToFieldType(v, tip.Type)
+			if err != nil {
+				return fmt.Errorf("error converting %s: %w", f.Name, err)
+			}
+			converted.SetConcrete(row, cv)
+		}
+		frame.Fields[i] = converted
+	}
+	return nil
+}
+
+// schemaFieldTips merges field tips of a schema with configured ones, configured tips win.
+func schemaFieldTips(schema *jsonSchema, tips map[string]Field) map[string]Field {
+	merged := make(map[string]Field, len(schema.tips)+len(tips))
+	for name, tip := range schema.tips {
+		merged[name] = tip
+	}
+	for name, tip := range tips {
+		merged[name] = tip
+	}
+	return merged
+}
+
+// jsonSchemaCache keeps a compiled schema of a converter and recompiles it when a version
+// of the schema entity changes. Schema is checked at most once per schemaCheckInterval.
+type jsonSchemaCache struct {
+	getter JSONSchemaGetter
+	uid    string
+	now    func() time.Time
+
+	mu        sync.Mutex
+	orgs      map[int64]*cachedJSONSchema
+	lastCheck map[int64]time.Time
+}
+
+type cachedJSONSchema struct {
+	version string
+	schema  *jsonSchema
+}
+
+func newJSONSchemaCache(getter JSONSchemaGetter, uid string) *jsonSchemaCache {
+	return &jsonSchemaCache{
+		getter:    getter,
+		uid:       uid,
+		now:       time.Now,
+		orgs:      map[int64]*cachedJSONSchema{},
+		lastCheck: map[int64]time.Time{},
+	}
+}
+
+func (c *jsonSchemaCache) get(ctx context.Context, orgID int64) (*jsonSchema, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.orgs[orgID]
+	now := c.now()
+	if ok && now.Sub(c.lastCheck[orgID]) < schemaCheckInterval {
+		return cached.schema, nil
+	}
+	body, version, err := c.getter.GetJSONSchema(ctx, orgID, c.uid)
+	if err != nil {
+		if ok {
+			// Keep converting with the last known schema while the store is unavailable.
+			logger.Warn("Error getting json schema, using cached version", "uid", c.uid, "orgId", orgID, "error", err)
+			c.lastCheck[orgID] = now
+			return cached.schema, nil
+		}
+		return nil, err
+	}
+	c.lastCheck[orgID] = now
+	if ok && cached.version == version {
+		return cached.schema, nil
+	}
+	schema, err := parseJSONSchema(body)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %w", c.uid, err)
+	}
+	logger.Debug("Loaded json schema", "uid", c.uid, "orgId", orgID, "version", version)
+	c.orgs[orgID] = &cachedJSONSchema{version: version, schema: schema}
+	return schema, nil
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testJSONSchemaGetter struct {
+	schema  string
+	version string
+	err     error
+	calls   int
+}
+
+func (g *testJSONSchemaGetter) GetJSONSchema(_ context.Context, _ int64, _ string) ([]byte, string, error) {
+	g.calls++
+	return []byte(g.schema), g.version, g.err
+}
+
+const testSensorSchema = `{
+	"type": "object",
+	"required": ["id"],
+	"properties": {
+		"id": {"type": "string"},
+		"count": {"type": "integer"},
+		"value": {"type": ["number", "null"]},
+		"ok": {"type": "boolean", "default": true},
+		"ts": {"type": "string", "format": "date-time"},
+		"meta": {
+			"type": "object",
+			"properties": {
+				"unit": {"type": "string", "default": "C"}
+			}
+		}
+	}
+}`
+
+func TestAutoJsonConverter_Schema(t *testing.T) {
+	getter := &testJSONSchemaGetter{schema: testSensorSchema, version: "1"}
+	converter := NewAutoJsonConverter(AutoJsonConverterConfig{Schema: "sensor"})
+	require.NoError(t, converter.useJSONSchema(getter))
+	converter.nowTimeFunc = func() time.Time { return time.Unix(0, 0) }
+
+	frames, err := converter.Convert(context.Background(), Vars{OrgID: 1}, []byte(`{"id":"s1","count":3,"value":null,"ts":"2021-01-01T00:00:00Z","meta":{}}`))
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	frame := frames[0].Frame
+
+	field := func(name string) *data.Field {
+		i := fieldIndex(frame, name)
+		require.GreaterOrEqual(t, i, 0, name)
+		return frame.Fields[i]
+	}
+	require.Equal(t, data.FieldTypeNullableInt64, field("count").Type())
+	v, _ := field("count").ConcreteAt(0)
+	require.Equal(t, int64(3), v)
+	require.Equal(t, data.FieldTypeNullableFloat64, field("value").Type())
+	_, ok := field("value").ConcreteAt(0)
+	require.False(t, ok)
+	v, _ = field("ok").ConcreteAt(0)
+	require.Equal(t, true, v)
+	require.Equal(t, data.FieldTypeNullableTime, field("ts").Type())
+	v, _ = field("meta.unit").ConcreteAt(0)
+	require.Equal(t, "C", v)
+
+	_, err = converter.Convert(context.Background(), Vars{OrgID: 1}, []byte(`{"count":3}`))
+	require.ErrorContains(t, err, "required property missing: id")
+}
+
+func TestAutoJsonConverter_SchemaFieldTipsOverride(t *testing.T) {
+	getter := &testJSONSchemaGetter{schema: testSensorSchema, version: "1"}
+	converter := NewAutoJsonConverter(AutoJsonConverterConfig{
+		Schema:    "sensor",
+		FieldTips: map[string]Field{"count": {Name: "count", Type: data.FieldTypeNullableFloat64}},
+	})
+	require.NoError(t, converter.useJSONSchema(getter))
+
+	frames, err := converter.Convert(context.Background(), Vars{OrgID: 1}, []byte(`{"id":"s1","count":3}`))
+	require.NoError(t, err)
+	frame := frames[0].Frame
+	require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[fieldIndex(frame, "count")].Type())
+}
+
+func TestJSONSchemaCache_Reload(t *testing.T) {
+	getter := &testJSONSchemaGetter{schema: `{"properties":{"a":{"type":"integer"}}}`, version: "1"}
+	cache := newJSONSchemaCache(getter, "uid")
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+
+	s, err := cache.get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeNullableInt64, s.tips["a"].Type)
+
+	// Version is not checked again within check interval.
+	getter.schema = `{"properties":{"a":{"type":"string"}}}`
+	getter.version = "2"
+	s, err = cache.get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeNullableInt64, s.tips["a"].Type)
+	require.Equal(t, 1, getter.calls)
+
+	now = now.Add(schemaCheckInterval)
+	s, err = cache.get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeNullableString, s.tips["a"].Type)
+
+	// Last known schema is used while the store is unavailable.
+	getter.err = errors.New("unavailable")
+	now = now.Add(schemaCheckInterval)
+	s, err = cache.get(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeNullableString, s.tips["a"].Type)
+
+	_, err = cache.get(context.Background(), 2)
+	require.Error(t, err)
+}
+
+func TestParseJSONSchema_Invalid(t *testing.T) {
+	_, err := parseJSONSchema([]byte(`{"type":"array"}`))
+	require.Error(t, err)
+	_, err = parseJSONSchema([]byte(`{"properties":{"a":{"type":"integer","default":"x"}}}`))
+	require.ErrorContains(t, err, "invalid default of a")
+
+	err = NewAutoJsonConverter(AutoJsonConverterConfig{Schema: "x"}).useJSONSchema(nil)
+	require.ErrorContains(t, err, "json schema store is not available")
+}
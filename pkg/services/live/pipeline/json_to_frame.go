@@ -15,6 +15,7 @@ type doc struct {
 	fields     []*data.Field
 	fieldNames map[string]struct{}
 	fieldTips  map[string]Field
+	parser     *valueParser
 }
 
 func (d *doc) next() error {
@@ -64,6 +65,24 @@ func (d *doc) key() string {
 }
 
 func (d *doc) addString(v string) {
+	if tip, ok := d.fieldTips[d.key()]; ok && d.parser != nil {
+		switch {
+		case tip.Type.Numeric():
+			n, err := d.parser.parseNumber(v)
+			if err == nil {
+				d.addNumber(n)
+				return
+			}
+			logger.Warn("Error parsing number, keep as string", "key", d.key(), "error", err)
+		case tip.Type.Time():
+			t, err := d.parser.parseTime(v)
+			if err == nil {
+				d.addTime(t)
+				return
+			}
+			logger.Warn("Error parsing time, keep as string", "key", d.key(), "error", err)
+		}
+	}
 	f := data.NewFieldFromFieldType(data.FieldTypeNullableString, 1)
 	f.Name = d.key()
 	f.SetConcrete(0, v)
@@ -79,6 +98,14 @@ func (d *doc) addNumber(v float64) {
 	d.fieldNames[d.key()] = struct{}{}
 }
 
+func (d *doc) addTime(v time.Time) {
+	f := data.NewFieldFromFieldType(data.FieldTypeNullableTime, 1)
+	f.Name = d.key()
+	f.SetConcrete(0, v)
+	d.fields = append(d.fields, f)
+	d.fieldNames[d.key()] = struct{}{}
+}
+
 func (d *doc) addBool(v bool) {
 	f := data.NewFieldFromFieldType(data.FieldTypeNullableBool, 1)
 	f.Name = d.key()
@@ -99,29 +126,28 @@ func (d *doc) addNil() {
 	}
 }
 
-func jsonDocToFrame(name string, body []byte, fields map[string]Field, nowTimeFunc func() time.Time) (*data.Frame, error) {
-	d := doc{
-		iterator:   jsoniter.ParseBytes(jsoniter.ConfigDefault, body),
+func newDoc(fields map[string]Field, parser *valueParser, nowTimeFunc func() time.Time) *doc {
+	d := &doc{
 		path:       make([]string, 0),
 		fieldTips:  fields,
 		fieldNames: map[string]struct{}{},
+		parser:     parser,
 	}
-
 	f := data.NewFieldFromFieldType(data.FieldTypeTime, 1)
 	f.Name = "Time"
 	f.Set(0, nowTimeFunc())
 	d.fields = append(d.fields, f)
+	return d
+}
 
-	err := d.next()
-	if err != nil {
-		return nil, err
-	}
-
+// frame returns a frame with collected fields and null fields for tips which were
+// not found in a document.
+func (d *doc) frame(name string) (*data.Frame, error) {
 	if len(d.fields) < 2 {
 		return nil, fmt.Errorf("no fields found")
 	}
 
-	for name, tip := range fields {
+	for name, tip := range d.fieldTips {
 		if _, ok := d.fieldNames[name]; ok {
 			continue
 		}
@@ -134,3 +160,13 @@ func jsonDocToFrame(name string, body []byte, fields map[string]Field, nowTimeFu
 
 	return data.NewFrame(name, d.fields...), nil
 }
+
+func jsonDocToFrame(name string, body []byte, fields map[string]Field, parser *valueParser, nowTimeFunc func() time.Time) (*data.Frame, error) {
+	d := newDoc(fields, parser, nowTimeFunc)
+	d.iterator = jsoniter.ParseBytes(jsoniter.ConfigDefault, body)
+	err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	return d.frame(name)
+}
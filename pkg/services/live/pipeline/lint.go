@@ -0,0 +1,398 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
+)
+
+// LintCode identifies a kind of lint warning.
+type LintCode string
+
+// Known LintCode types.
+const (
+	// LintCodeShadowedRule is reported for rules which conflict with an earlier rule
+	// pattern, so the rule can never be matched.
+	LintCodeShadowedRule LintCode = "shadowedRule"
+	// LintCodeUnknownBackend is reported for outputs referencing write configs which
+	// do not exist.
+	LintCodeUnknownBackend LintCode = "unknownBackend"
+	// LintCodeUnreachableProcessing is reported for frame processors and outputs of a
+	// rule without converter when no other rule outputs frames to its channels.
+	LintCodeUnreachableProcessing LintCode = "unreachableProcessing"
+	// LintCodeDiscardedFrames is reported for frame processors without any frame output
+	// after them, so processing results are discarded.
+	LintCodeDiscardedFrames LintCode = "discardedFrames"
+	// LintCodeNeverTrueCondition is reported for conditions which can never be satisfied.
+	LintCodeNeverTrueCondition LintCode = "neverTrueCondition"
+)
+
+// LintWarning describes a possible problem in rules which are still valid.
+type LintWarning struct {
+	// RuleIndex is an index of the rule in the linted rules list.
+	RuleIndex int      `json:"ruleIndex"`
+	Pattern   string   `json:"pattern"`
+	Code      LintCode `json:"code"`
+	Message   string   `json:"message"`
+}
+
+// LintRules checks rules of a single organization beyond validity.
+func LintRules(rules []ChannelRule, writeConfigs []WriteConfig) []LintWarning {
+	warnings := []LintWarning{}
+	warn := func(index int, code LintCode, format string, args ...any) {
+		warnings = append(warnings, LintWarning{
+			RuleIndex: index,
+			Pattern:   rules[index].Pattern,
+			Code:      code,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Matching rules do not depend on order, but a rule conflicting with earlier rules
+	// can not be added to the tree.
+	t := tree.New()
+	for i, rule := range rules {
+		if reason := addRoute(t, rule.Pattern); reason != "" {
+			warn(i, LintCodeShadowedRule, "rule is shadowed by an earlier rule: %s", reason)
+		}
+	}
+
+	backends := make(map[string]struct{}, len(writeConfigs))
+	for _, c := range writeConfigs {
+		backends[c.UID] = struct{}{}
+	}
+
+	var frameTargets []string
+	for _, rule := range rules {
+		for _, out := range rule.Settings.FrameOutputters {
+			frameTargets = append(frameTargets, frameOutputChannels(out)...)
+		}
+	}
+
+	for i, rule := range rules {
+		settings := rule.Settings
+		for _, uid := range ruleBackendUIDs(settings) {
+			if _, ok := backends[uid]; !ok {
+				warn(i, LintCodeUnknownBackend, "output references unknown write config: %s", uid)
+			}
+		}
+
+		hasFrameProcessing := len(settings.FrameProcessors) > 0 || len(settings.FrameOutputters) > 0
+		if settings.Converter == nil && hasFrameProcessing && !patternMatchesAny(rule.Pattern, frameTargets) {
+			warn(i, LintCodeUnreachableProcessing, "frame processing is never executed: no converter and no frames redirected to the rule")
+		}
+		if len(settings.FrameProcessors) > 0 && len(settings.FrameOutputters) == 0 {
+			warn(i, LintCodeDiscardedFrames, "frame processors have no frame outputs after them, results are discarded")
+		}
+
+		for _, out := range settings.FrameOutputters {
+			for _, condition := range frameOutputConditions(out) {
+				if neverTrue(condition) {
+					warn(i, LintCodeNeverTrueCondition, "%s condition can never be true", condition.Type)
+				}
+			}
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].RuleIndex < warnings[j].RuleIndex
+	})
+	return warnings
+}
+
+// LintStorage checks rules of organizations kept in storage, write configs shared with
+// an organization are taken into account.
+func LintStorage(ctx context.Context, storage Storage, orgIDs []int64) (map[int64][]LintWarning, error) {
+	result := map[int64][]LintWarning{}
+	for _, orgID := range orgIDs {
+		rules, err := storage.ListChannelRules(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		writeConfigs, err := storage.ListWriteConfigs(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if lister, ok := storage.(SharedWriteConfigLister); ok {
+			shared, err := lister.ListSharedWriteConfigs(ctx, orgID)
+			if err != nil {
+				return nil, err
+			}
+			writeConfigs = append(writeConfigs, shared...)
+		}
+		if warnings := LintRules(rules, writeConfigs); len(warnings) > 0 {
+			result[orgID] = warnings
+		}
+	}
+	return result, nil
+}
+
+func addRoute(t *tree.Node, pattern string) (reason string) {
+	defer func() {
+		if r := recover(); r != nil {
+			reason = fmt.Sprintf("%v", r)
+		}
+	}()
+	t.AddRoute("/"+pattern, struct{}{})
+	return ""
+}
+
+func patternMatchesAny(pattern string, channels []string) bool {
+	t := tree.New()
+	if addRoute(t, pattern) != "" {
+		return false
+	}
+	for _, channel := range channels {
+		if strings.Contains(channel, "{") {
+			// Templated targets are only known at runtime.
+			return true
+		}
+		if t.GetValue("/"+channel, true).Handler != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleBackendUIDs(settings ChannelRuleSettings) []string {
+	var uids []string
+	for _, out := range settings.DataOutputters {
+		if out != nil && out.LokiOutputConfig != nil {
+			uids = append(uids, out.LokiOutputConfig.UID)
+		}
+	}
+	uids = append(uids, processorBackendUIDs(settings.FrameProcessors)...)
+	outputs := settings.FrameOutputters
+	if settings.DeadLetterOutputter != nil {
+		outputs = append(outputs[:len(outputs):len(outputs)], settings.DeadLetterOutputter)
+	}
+	if settings.Heartbeat != nil {
+		outputs = append(outputs[:len(outputs):len(outputs)], settings.Heartbeat.Outputs...)
+	}
+	for _, out := range outputs {
+		walkFrameOutputs(out, func(out *FrameOutputterConfig) {
+			if out.RemoteWriteOutputConfig != nil && out.RemoteWriteOutputConfig.Backend == nil {
+				uids = append(uids, out.RemoteWriteOutputConfig.UID)
+			}
+			if out.LokiOutputConfig != nil {
+				uids = append(uids, out.LokiOutputConfig.UID)
+			}
+			if out.ElasticsearchConfig != nil {
+				uids = append(uids, out.ElasticsearchConfig.UID)
+			}
+			if out.InfluxOutputConfig != nil {
+				uids = append(uids, out.InfluxOutputConfig.UID)
+			}
+			if out.PostgresOutputConfig != nil {
+				uids = append(uids, out.PostgresOutputConfig.UID)
+			}
+			if out.NATSOutputConfig != nil {
+				uids = append(uids, out.NATSOutputConfig.UID)
+			}
+			if out.AMQPOutputConfig != nil {
+				uids = append(uids, out.AMQPOutputConfig.UID)
+			}
+			if out.EventHubsOutputConfig != nil {
+				uids = append(uids, out.EventHubsOutputConfig.UID)
+			}
+			if out.PubSubOutputConfig != nil {
+				uids = append(uids, out.PubSubOutputConfig.UID)
+			}
+			if out.PushgatewayOutputConfig != nil {
+				uids = append(uids, out.PushgatewayOutputConfig.UID)
+			}
+			if out.FailureAlertOutputConfig != nil && out.FailureAlertOutputConfig.WebhookUID != "" {
+				uids = append(uids, out.FailureAlertOutputConfig.WebhookUID)
+			}
+			if out.ProcessedOutputConfig != nil {
+				uids = append(uids, processorBackendUIDs(out.ProcessedOutputConfig.Processors)...)
+			}
+			if out.WebhookOutputConfig != nil {
+				uids = append(uids, out.WebhookOutputConfig.UID)
+			}
+			if out.S3OutputConfig != nil {
+				uids = append(uids, out.S3OutputConfig.UID)
+			}
+			if out.ForwardOutputConfig != nil {
+				uids = append(uids, out.ForwardOutputConfig.UID)
+			}
+		})
+	}
+	return uids
+}
+
+// frameOutputChannels returns channels frames are sent to by an output.
+func frameOutputChannels(out *FrameOutputterConfig) []string {
+	var channels []string
+	walkFrameOutputs(out, func(out *FrameOutputterConfig) {
+		switch {
+		case out.RedirectOutputConfig != nil:
+			channels = append(channels, out.RedirectOutputConfig.Channel)
+		case out.ThresholdOutputConfig != nil:
+			channels = append(channels, out.ThresholdOutputConfig.Channel)
+		case out.ChangeLogOutputConfig != nil:
+			channels = append(channels, out.ChangeLogOutputConfig.Channel)
+		}
+	})
+	return channels
+}
+
+func processorBackendUIDs(processors []*FrameProcessorConfig) []string {
+	var uids []string
+	for _, proc := range processors {
+		if proc == nil {
+			continue
+		}
+		if proc.EncryptFieldsConfig != nil {
+			uids = append(uids, proc.EncryptFieldsConfig.UID)
+		}
+		if proc.MultipleProcessorConfig != nil {
+			for i := range proc.MultipleProcessorConfig.Processors {
+				uids = append(uids, processorBackendUIDs([]*FrameProcessorConfig{&proc.MultipleProcessorConfig.Processors[i]})...)
+			}
+		}
+	}
+	return uids
+}
+
+func frameOutputConditions(out *FrameOutputterConfig) []*FrameConditionCheckerConfig {
+	var conditions []*FrameConditionCheckerConfig
+	walkFrameOutputs(out, func(out *FrameOutputterConfig) {
+		if out.ConditionalOutputConfig != nil && out.ConditionalOutputConfig.Condition != nil {
+			conditions = append(conditions, out.ConditionalOutputConfig.Condition)
+		}
+	})
+	return conditions
+}
+
+func walkFrameOutputs(out *FrameOutputterConfig, fn func(out *FrameOutputterConfig)) {
+	if out == nil {
+		return
+	}
+	fn(out)
+	if out.MultipleOutputterConfig != nil {
+		for i := range out.MultipleOutputterConfig.Outputters {
+			walkFrameOutputs(&out.MultipleOutputterConfig.Outputters[i], fn)
+		}
+	}
+	if out.ConditionalOutputConfig != nil {
+		walkFrameOutputs(out.ConditionalOutputConfig.Outputter, fn)
+	}
+	if out.RetryOutputConfig != nil {
+		walkFrameOutputs(out.RetryOutputConfig.Outputter, fn)
+	}
+	if out.FailureAlertOutputConfig != nil {
+		walkFrameOutputs(out.FailureAlertOutputConfig.Outputter, fn)
+	}
+	if out.ProcessedOutputConfig != nil {
+		walkFrameOutputs(out.ProcessedOutputConfig.Outputter, fn)
+	}
+	if out.RolloutOutputConfig != nil {
+		walkFrameOutputs(out.RolloutOutputConfig.Outputter, fn)
+		walkFrameOutputs(out.RolloutOutputConfig.Fallback, fn)
+	}
+}
+
+// neverTrue reports whether a condition can't be satisfied by any frame.
+func neverTrue(c *FrameConditionCheckerConfig) bool {
+	if c.MultipleConditionCheckerConfig == nil {
+		return false
+	}
+	conditions := c.MultipleConditionCheckerConfig.Conditions
+	switch c.MultipleConditionCheckerConfig.ConditionType {
+	case ConditionAny:
+		for i := range conditions {
+			if !neverTrue(&conditions[i]) {
+				return false
+			}
+		}
+		return true
+	case ConditionAll:
+		ranges := map[string]*numberRange{}
+		for i := range conditions {
+			if neverTrue(&conditions[i]) {
+				return true
+			}
+			cfg := conditions[i].NumberCompareConditionConfig
+			if cfg == nil {
+				continue
+			}
+			r, ok := ranges[cfg.FieldName]
+			if !ok {
+				r = newNumberRange()
+				ranges[cfg.FieldName] = r
+			}
+			r.apply(cfg.Op, cfg.Value)
+		}
+		for _, r := range ranges {
+			if r.empty() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// numberRange is a set of numbers satisfying number compare conditions.
+type numberRange struct {
+	min, max         float64
+	minIncl, maxIncl bool
+	excluded         []float64
+}
+
+func newNumberRange() *numberRange {
+	return &numberRange{min: math.Inf(-1), max: math.Inf(1), minIncl: true, maxIncl: true}
+}
+
+func (r *numberRange) apply(op NumberCompareOp, v float64) {
+	switch op {
+	case NumberCompareOpGt:
+		r.setMin(v, false)
+	case NumberCompareOpGte:
+		r.setMin(v, true)
+	case NumberCompareOpLt:
+		r.setMax(v, false)
+	case NumberCompareOpLte:
+		r.setMax(v, true)
+	case NumberCompareOpEq:
+		r.setMin(v, true)
+		r.setMax(v, true)
+	case NumberCompareOpNe:
+		r.excluded = append(r.excluded, v)
+	}
+}
+
+func (r *numberRange) setMin(v float64, inclusive bool) {
+	if v > r.min || (v == r.min && !inclusive) {
+		r.min, r.minIncl = v, inclusive
+	}
+}
+
+func (r *numberRange) setMax(v float64, inclusive bool) {
+	if v < r.max || (v == r.max && !inclusive) {
+		r.max, r.maxIncl = v, inclusive
+	}
+}
+
+func (r *numberRange) empty() bool {
+	if r.min > r.max {
+		return true
+	}
+	if r.min == r.max {
+		if !r.minIncl || !r.maxIncl {
+			return true
+		}
+		for _, v := range r.excluded {
+			if v == r.min {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lintCodes(warnings []LintWarning) map[int][]LintCode {
+	codes := map[int][]LintCode{}
+	for _, w := range warnings {
+		codes[w.RuleIndex] = append(codes[w.RuleIndex], w.Code)
+	}
+	return codes
+}
+
+func TestLintRules(t *testing.T) {
+	rules := []ChannelRule{
+		{
+			Pattern: "stream/test/:path",
+			Settings: ChannelRuleSettings{
+				Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+				FrameOutputters: []*FrameOutputterConfig{
+					{Type: FrameOutputTypeRedirect, RedirectOutputConfig: &RedirectOutputConfig{Channel: "stream/redirected/one"}},
+					{Type: FrameOutputTypeRemoteWrite, RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: "unknown"}},
+				},
+			},
+		},
+		{
+			// Conflicts with the first rule wildcard.
+			Pattern: "stream/test/:other",
+		},
+		{
+			Pattern: "stream/redirected/:path",
+			Settings: ChannelRuleSettings{
+				FrameOutputters: []*FrameOutputterConfig{{Type: FrameOutputTypeRemoteWrite, RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: "known"}}},
+			},
+		},
+		{
+			Pattern: "stream/orphan",
+			Settings: ChannelRuleSettings{
+				FrameProcessors: []*FrameProcessorConfig{{Type: FrameProcessorTypeDropFields, DropFieldsProcessorConfig: &DropFieldsFrameProcessorConfig{}}},
+			},
+		},
+		{
+			Pattern: "stream/conditions",
+			Settings: ChannelRuleSettings{
+				Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+				FrameOutputters: []*FrameOutputterConfig{{
+					Type: FrameOutputTypeConditional,
+					ConditionalOutputConfig: &ConditionalOutputConfig{
+						Condition: &FrameConditionCheckerConfig{
+							Type: FrameConditionCheckerTypeMultiple,
+							MultipleConditionCheckerConfig: &MultipleFrameConditionCheckerConfig{
+								ConditionType: ConditionAll,
+								Conditions: []FrameConditionCheckerConfig{
+									{Type: FrameConditionCheckerTypeNumberCompare, NumberCompareConditionConfig: &NumberCompareFrameConditionConfig{FieldName: "value", Op: NumberCompareOpGt, Value: 10}},
+									{Type: FrameConditionCheckerTypeNumberCompare, NumberCompareConditionConfig: &NumberCompareFrameConditionConfig{FieldName: "value", Op: NumberCompareOpLte, Value: 10}},
+								},
+							},
+						},
+						Outputter: &FrameOutputterConfig{Type: FrameOutputTypeManagedStream},
+					},
+				}},
+			},
+		},
+	}
+
+	warnings := LintRules(rules, []WriteConfig{{UID: "known"}})
+	require.Equal(t, map[int][]LintCode{
+		0: {LintCodeUnknownBackend},
+		1: {LintCodeShadowedRule},
+		3: {LintCodeUnreachableProcessing, LintCodeDiscardedFrames},
+		4: {LintCodeNeverTrueCondition},
+	}, lintCodes(warnings))
+	require.Equal(t, "stream/test/:other", warnings[1].Pattern)
+}
+
+type lintTestStorage struct {
+	Storage
+	rules        map[int64][]ChannelRule
+	writeConfigs map[int64][]WriteConfig
+}
+
+func (s *lintTestStorage) ListChannelRules(_ context.Context, orgID int64) ([]ChannelRule, error) {
+	return s.rules[orgID], nil
+}
+
+func (s *lintTestStorage) ListWriteConfigs(_ context.Context, orgID int64) ([]WriteConfig, error) {
+	return s.writeConfigs[orgID], nil
+}
+
+func TestLintStorage(t *testing.T) {
+	output := func(uid string) ChannelRuleSettings {
+		return ChannelRuleSettings{
+			Converter: &ConverterConfig{Type: ConverterTypeJsonAuto},
+			FrameOutputters: []*FrameOutputterConfig{
+				{Type: FrameOutputTypeRemoteWrite, RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: uid}},
+			},
+		}
+	}
+	storage := &lintTestStorage{
+		rules: map[int64][]ChannelRule{
+			1: {{Pattern: "stream/test/cpu", Settings: output("known")}},
+			2: {{Pattern: "stream/test/cpu", Settings: output("known")}},
+		},
+		writeConfigs: map[int64][]WriteConfig{1: {{UID: "known"}}},
+	}
+	warnings, err := LintStorage(context.Background(), storage, []int64{1, 2, 3})
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	require.Equal(t, map[int][]LintCode{0: {LintCodeUnknownBackend}}, lintCodes(warnings[2]))
+}
+
+func TestNumberRange(t *testing.T) {
+	r := newNumberRange()
+	r.apply(NumberCompareOpGte, 5)
+	r.apply(NumberCompareOpLte, 5)
+	require.False(t, r.empty())
+	r.apply(NumberCompareOpNe, 5)
+	require.True(t, r.empty())
+
+	r = newNumberRange()
+	r.apply(NumberCompareOpEq, 1)
+	r.apply(NumberCompareOpLt, 2)
+	require.False(t, r.empty())
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// PriorityClass of a channel rule defines the order inputs are shed in when the
+// pipeline is overloaded.
+type PriorityClass string
+
+// Known priority classes, from the first to the last shed.
+const (
+	PriorityLow      PriorityClass = "low"
+	PriorityNormal   PriorityClass = "normal"
+	PriorityHigh     PriorityClass = "high"
+	PriorityCritical PriorityClass = "critical"
+)
+
+// ErrLoadShed is returned for inputs dropped because the pipeline is overloaded.
+var ErrLoadShed = errors.New("pipeline is overloaded")
+
+// priorityShedLoad is a share of max in-flight inputs after which inputs of a class
+// are shed. Critical inputs are shed only when the limit is reached.
+var priorityShedLoad = map[PriorityClass]float64{
+	PriorityLow:      0.5,
+	PriorityNormal:   0.75,
+	PriorityHigh:     0.9,
+	PriorityCritical: 1,
+}
+
+var (
+	shedInputs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "shed_inputs_total",
+		Help:      "A counter for pipeline inputs dropped by load shedding",
+	}, []string{"priority"})
+	shedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "shed_bytes_total",
+		Help:      "A counter for size of pipeline payloads dropped by load shedding",
+	}, []string{"priority"})
+	inFlightInputs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "in_flight_inputs",
+		Help:      "Number of pipeline inputs being processed",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(shedInputs, shedBytes, inFlightInputs)
+}
+
+// parsePriorityClass validates a priority class of a rule, empty means PriorityNormal.
+func parsePriorityClass(s string) (PriorityClass, error) {
+	if s == "" {
+		return PriorityNormal, nil
+	}
+	priority := PriorityClass(s)
+	if _, ok := priorityShedLoad[priority]; !ok {
+		return "", fmt.Errorf("unknown priority: %s", s)
+	}
+	return priority, nil
+}
+
+// LoadShedder limits a number of inputs processed concurrently. When the pipeline gets
+// loaded inputs of lower priority rules are dropped first, so important channels (like
+// alarms) keep flowing while debug telemetry is shed.
+type LoadShedder struct {
+	maxInFlight int
+
+	mu       sync.Mutex
+	inFlight int
+}
+
+// NewLoadShedder creates LoadShedder allowing up to maxInFlight concurrent inputs.
+func NewLoadShedder(maxInFlight int) *LoadShedder {
+	return &LoadShedder{maxInFlight: maxInFlight}
+}
+
+// acquire reserves a slot for an input of a priority class, release must be called
+// when the input is processed. It's a no-op for nil LoadShedder.
+func (s *LoadShedder) acquire(priority PriorityClass, size int) (release func(), ok bool) {
+	if s == nil {
+		return func() {}, true
+	}
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limit := int(float64(s.maxInFlight) * priorityShedLoad[priority])
+	if limit < 1 {
+		limit = 1
+	}
+	if s.inFlight >= limit {
+		shedInputs.WithLabelValues(string(priority)).Inc()
+		shedBytes.WithLabelValues(string(priority)).Add(float64(size))
+		return nil, false
+	}
+	s.inFlight++
+	inFlightInputs.Set(float64(s.inFlight))
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.inFlight--
+		inFlightInputs.Set(float64(s.inFlight))
+	}, true
+}
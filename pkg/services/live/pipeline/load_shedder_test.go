@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadShedder_priorities(t *testing.T) {
+	s := NewLoadShedder(4)
+
+	var releases []func()
+	acquire := func(priority PriorityClass) bool {
+		release, ok := s.acquire(priority, 10)
+		if ok {
+			releases = append(releases, release)
+		}
+		return ok
+	}
+
+	require.True(t, acquire(PriorityLow))
+	require.True(t, acquire(PriorityLow))
+	// Low priority inputs are shed at half of the limit.
+	require.False(t, acquire(PriorityLow))
+	require.True(t, acquire(PriorityNormal))
+	require.False(t, acquire(PriorityNormal))
+	require.False(t, acquire(PriorityHigh))
+	require.True(t, acquire(PriorityCritical))
+	require.False(t, acquire(PriorityCritical))
+
+	for _, release := range releases {
+		release()
+	}
+	require.True(t, acquire(PriorityLow))
+}
+
+func TestParsePriorityClass(t *testing.T) {
+	priority, err := parsePriorityClass("")
+	require.NoError(t, err)
+	require.Equal(t, PriorityNormal, priority)
+
+	priority, err = parsePriorityClass("critical")
+	require.NoError(t, err)
+	require.Equal(t, PriorityCritical, priority)
+
+	_, err = parsePriorityClass("urgent")
+	require.Error(t, err)
+}
+
+func TestPipeline_loadShedding(t *testing.T) {
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/debug": {
+				Priority:  PriorityLow,
+				Converter: &testConverter{"", data.NewFrame("test")},
+			},
+		},
+	})
+	require.NoError(t, err)
+	p.Shedder = NewLoadShedder(2)
+
+	// Occupy a slot, so low priority inputs are shed.
+	release, ok := p.Shedder.acquire(PriorityCritical, 0)
+	require.True(t, ok)
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/debug", []byte(`{}`))
+	require.ErrorIs(t, err, ErrLoadShed)
+
+	release()
+	ok, err = p.ProcessInput(context.Background(), 1, "stream/test/debug", []byte(`{}`))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
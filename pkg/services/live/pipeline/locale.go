@@ -0,0 +1,144 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocaleConfig defines how string values are parsed into numbers and timestamps by
+// converters. Used by jsonAuto converter for string values of fields with numeric or
+// time type in field tips.
+type LocaleConfig struct {
+	// Timezone is an IANA time zone name (like Europe/Berlin) used for timestamps
+	// without offset, UTC by default.
+	Timezone string `json:"timezone,omitempty"`
+	// Locale is a BCP 47 language tag (like de-DE) which defines a decimal separator
+	// and a date order, en-US by default.
+	Locale string `json:"locale,omitempty"`
+	// DecimalSeparator overrides a decimal separator of the locale, "." or ",".
+	DecimalSeparator string `json:"decimalSeparator,omitempty"`
+	// DayFirst overrides a date order of the locale, when true 02/01/2006 is 2 January.
+	DayFirst *bool `json:"dayFirst,omitempty"`
+}
+
+// Languages using comma as a decimal separator.
+var decimalCommaLanguages = map[string]struct{}{
+	"de": {}, "fr": {}, "es": {}, "it": {}, "pt": {}, "nl": {}, "ru": {}, "pl": {}, "cs": {},
+	"sk": {}, "sv": {}, "da": {}, "fi": {}, "nb": {}, "no": {}, "tr": {}, "uk": {}, "id": {},
+	"ro": {}, "hu": {}, "el": {}, "bg": {}, "hr": {}, "sl": {}, "lt": {}, "lv": {}, "et": {},
+}
+
+// Regions using month first dates, most of the world writes day first.
+var monthFirstRegions = map[string]struct{}{
+	"US": {}, "PH": {}, "CA": {}, "FM": {}, "MH": {}, "PW": {},
+}
+
+// Date layouts without time zone, tried in order after RFC 3339.
+var (
+	isoLayouts = []string{
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+	dayFirstLayouts = []string{
+		"02.01.2006 15:04:05",
+		"02.01.2006 15:04",
+		"02.01.2006",
+		"02/01/2006 15:04:05",
+		"02/01/2006 15:04",
+		"02/01/2006",
+		"02-01-2006 15:04:05",
+		"02-01-2006",
+	}
+	monthFirstLayouts = []string{
+		"01/02/2006 15:04:05",
+		"01/02/2006 15:04",
+		"01/02/2006 3:04:05 PM",
+		"01/02/2006 3:04 PM",
+		"01/02/2006",
+		"01-02-2006 15:04:05",
+		"01-02-2006",
+	}
+)
+
+// valueParser parses strings according to LocaleConfig.
+type valueParser struct {
+	location     *time.Location
+	decimalComma bool
+	dayFirst     bool
+}
+
+func newValueParser(config LocaleConfig) (*valueParser, error) {
+	p := &valueParser{location: time.UTC}
+	if config.Timezone != "" {
+		location, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+		p.location = location
+	}
+	if config.Locale != "" {
+		language, region, _ := strings.Cut(strings.ReplaceAll(config.Locale, "_", "-"), "-")
+		_, p.decimalComma = decimalCommaLanguages[strings.ToLower(language)]
+		// Plain "en" is treated as en-US, other languages mostly write day first.
+		p.dayFirst = !(strings.EqualFold(language, "en") && region == "")
+		if region != "" {
+			_, monthFirst := monthFirstRegions[strings.ToUpper(region)]
+			p.dayFirst = !monthFirst
+		}
+	}
+	switch config.DecimalSeparator {
+	case "":
+	case ".":
+		p.decimalComma = false
+	case ",":
+		p.decimalComma = true
+	default:
+		return nil, fmt.Errorf("unsupported decimal separator: %q", config.DecimalSeparator)
+	}
+	if config.DayFirst != nil {
+		p.dayFirst = *config.DayFirst
+	}
+	return p, nil
+}
+
+// parseNumber parses a number with optional group separators.
+func (p *valueParser) parseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "", "'", "").Replace(s)
+	if p.decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseTime parses RFC 3339 timestamps, Unix epoch milliseconds and common date
+// layouts in the configured date order and time zone.
+func (p *valueParser) parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	layouts := monthFirstLayouts
+	if p.dayFirst {
+		layouts = dayFirstLayouts
+	}
+	for _, group := range [][]string{isoLayouts, layouts} {
+		for _, layout := range group {
+			if t, err := time.ParseInLocation(layout, s, p.location); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time format: %q", s)
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueParser_Number(t *testing.T) {
+	p, err := newValueParser(LocaleConfig{Locale: "de-DE"})
+	require.NoError(t, err)
+	n, err := p.parseNumber("1.234,5")
+	require.NoError(t, err)
+	require.Equal(t, 1234.5, n)
+
+	p, err = newValueParser(LocaleConfig{})
+	require.NoError(t, err)
+	n, err = p.parseNumber("1,234.5")
+	require.NoError(t, err)
+	require.Equal(t, 1234.5, n)
+
+	p, err = newValueParser(LocaleConfig{Locale: "en-US", DecimalSeparator: ","})
+	require.NoError(t, err)
+	n, err = p.parseNumber("0,5")
+	require.NoError(t, err)
+	require.Equal(t, 0.5, n)
+
+	_, err = newValueParser(LocaleConfig{DecimalSeparator: ";"})
+	require.Error(t, err)
+}
+
+func TestValueParser_Time(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	p, err := newValueParser(LocaleConfig{Locale: "en-GB", Timezone: "Europe/Berlin"})
+	require.NoError(t, err)
+	ts, err := p.parseTime("02/01/2023 10:00")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 1, 2, 10, 0, 0, 0, berlin), ts)
+
+	ts, err = p.parseTime("2023-01-02T10:00:00Z")
+	require.NoError(t, err)
+	require.True(t, time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC).Equal(ts))
+
+	p, err = newValueParser(LocaleConfig{Locale: "en-US"})
+	require.NoError(t, err)
+	ts, err = p.parseTime("02/01/2023")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), ts)
+
+	_, err = p.parseTime("yesterday")
+	require.Error(t, err)
+
+	_, err = newValueParser(LocaleConfig{Timezone: "Mars/Olympus"})
+	require.Error(t, err)
+}
+
+func TestAutoJsonConverter_Locale(t *testing.T) {
+	converter, err := NewAutoJsonConverterWithLocale(AutoJsonConverterConfig{
+		FieldTips: map[string]Field{
+			"value": {Type: data.FieldTypeNullableFloat64},
+			"ts":    {Type: data.FieldTypeNullableTime},
+		},
+	}, LocaleConfig{Locale: "fr-FR"})
+	require.NoError(t, err)
+
+	channelFrames, err := converter.Convert(context.Background(), Vars{}, []byte(`{"value":"3,5","ts":"31/12/2022 23:00","name":"a"}`))
+	require.NoError(t, err)
+	require.Len(t, channelFrames, 1)
+	frame := channelFrames[0].Frame
+
+	require.Equal(t, floatPtr(3.5), frame.Fields[fieldIndex(frame, "value")].At(0))
+	ts := time.Date(2022, 12, 31, 23, 0, 0, 0, time.UTC)
+	require.Equal(t, &ts, frame.Fields[fieldIndex(frame, "ts")].At(0))
+	require.Equal(t, data.FieldTypeNullableString, frame.Fields[fieldIndex(frame, "name")].Type())
+}
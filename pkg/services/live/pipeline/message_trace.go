@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"container/list"
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// MessageIDHeader carries a message ID in push requests and responses and in requests
+// of outputs, so a message can be followed across Grafana instances and backends.
+const MessageIDHeader = "X-Grafana-Live-Message-Id"
+
+// Events of a message trace, stage events use the stage names of rule metrics.
+const (
+	MessageEventReceived   = "received"
+	MessageEventRejected   = "rejected"
+	MessageEventConvert    = ruleStageConvert
+	MessageEventProcess    = ruleStageProcess
+	MessageEventOutput     = ruleStageOutput
+	MessageEventDataOutput = ruleStageDataOutput
+	MessageEventDropped    = "dropped"
+	MessageEventDeadLetter = "deadLetter"
+)
+
+const (
+	defaultMessageTraceRetention   = 10 * time.Minute
+	defaultMessageTraceMaxMessages = 10000
+	// maxMessageTraceEvents limits events kept per message, e.g. for messages
+	// fanned out to many channels.
+	maxMessageTraceEvents       = 100
+	messageTraceCompactInterval = time.Minute
+)
+
+var messageIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+type messageIDKey struct{}
+
+// NewMessageID returns a new random message ID.
+func NewMessageID() string {
+	return util.GenerateShortUID()
+}
+
+// IsValidMessageID checks a message ID supplied by a client.
+func IsValidMessageID(id string) bool {
+	return messageIDRegexp.MatchString(id)
+}
+
+// WithMessageID returns a context carrying a message ID through the pipeline.
+func WithMessageID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, messageIDKey{}, id)
+}
+
+// MessageIDFromContext returns a message ID of a context, empty when not set.
+func MessageIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(messageIDKey{}).(string)
+	return id
+}
+
+// ensureMessageID assigns a new message ID to a context without one.
+func ensureMessageID(ctx context.Context) context.Context {
+	if MessageIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return WithMessageID(ctx, NewMessageID())
+}
+
+// MessageTrackerConfig sets retention of MessageTracker, zero values mean defaults.
+type MessageTrackerConfig struct {
+	// Retention is a period a message is kept for after it was received.
+	Retention time.Duration
+	// MaxMessages limits a number of kept messages, the oldest are evicted first.
+	MaxMessages int
+}
+
+// MessageEvent is something which happened to a message in a rule.
+type MessageEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	// Rule is a pattern of a rule, empty for events before rule matching.
+	Rule    string `json:"rule,omitempty"`
+	Channel string `json:"channel"`
+	// Type of a converter, processor or output of stage events.
+	Type  string `json:"type,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// MessageTrace is a fate of a message.
+type MessageTrace struct {
+	ID        string         `json:"id"`
+	OrgID     int64          `json:"orgId"`
+	FirstSeen time.Time      `json:"firstSeen"`
+	Events    []MessageEvent `json:"events"`
+	// Truncated is true when later events were not recorded.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type messageKey struct {
+	orgID int64
+	id    string
+}
+
+// MessageTracker keeps recent events of pipeline messages by message ID, so it's
+// possible to find out where a specific message went. Like the dead-letter queue it
+// is kept in memory and is not shared in HA setup.
+type MessageTracker struct {
+	config MessageTrackerConfig
+
+	mu sync.Mutex
+	// traces are ordered by first seen time.
+	traces *list.List
+	byKey  map[messageKey]*list.Element
+}
+
+func NewMessageTracker(config MessageTrackerConfig) *MessageTracker {
+	if config.Retention <= 0 {
+		config.Retention = defaultMessageTraceRetention
+	}
+	if config.MaxMessages <= 0 {
+		config.MaxMessages = defaultMessageTraceMaxMessages
+	}
+	return &MessageTracker{
+		config: config,
+		traces: list.New(),
+		byKey:  map[messageKey]*list.Element{},
+	}
+}
+
+// Run periodically removes expired messages until context is done.
+func (t *MessageTracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(messageTraceCompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.Compact(time.Now())
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// record adds an event of a message of a context, it's a no-op for nil MessageTracker
+// and contexts without message ID.
+func (t *MessageTracker) record(ctx context.Context, orgID int64, event MessageEvent) {
+	if t == nil {
+		return
+	}
+	id := MessageIDFromContext(ctx)
+	if id == "" {
+		return
+	}
+	now := time.Now()
+	event.Time = now
+	key := messageKey{orgID: orgID, id: id}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.byKey[key]
+	if !ok {
+		el = t.traces.PushBack(&MessageTrace{ID: id, OrgID: orgID, FirstSeen: now})
+		t.byKey[key] = el
+		t.evictLocked(now)
+	}
+	trace := el.Value.(*MessageTrace)
+	if len(trace.Events) >= maxMessageTraceEvents {
+		trace.Truncated = true
+		return
+	}
+	trace.Events = append(trace.Events, event)
+}
+
+// Get returns a trace of a message of an organization.
+func (t *MessageTracker) Get(orgID int64, id string) (MessageTrace, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.byKey[messageKey{orgID: orgID, id: id}]
+	if !ok {
+		return MessageTrace{}, false
+	}
+	trace := *el.Value.(*MessageTrace)
+	trace.Events = append([]MessageEvent(nil), trace.Events...)
+	return trace, true
+}
+
+// Compact removes messages older than configured retention.
+func (t *MessageTracker) Compact(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.evictLocked(now)
+}
+
+func (t *MessageTracker) evictLocked(now time.Time) {
+	for el := t.traces.Front(); el != nil; el = t.traces.Front() {
+		trace := el.Value.(*MessageTrace)
+		if now.Sub(trace.FirstSeen) <= t.config.Retention && t.traces.Len() <= t.config.MaxMessages {
+			break
+		}
+		t.traces.Remove(el)
+		delete(t.byKey, messageKey{orgID: trace.OrgID, id: trace.ID})
+	}
+}
+
+// recordMessage adds an event of a message to the message tracker of a pipeline.
+func (p *Pipeline) recordMessage(ctx context.Context, rule *LiveChannelRule, vars Vars, event string, eventType string, err error) {
+	if p.Messages == nil {
+		return
+	}
+	e := MessageEvent{Event: event, Channel: vars.Channel, Type: eventType}
+	if rule != nil {
+		e.Rule = rule.Pattern
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	p.Messages.record(ctx, vars.OrgID, e)
+}
@@ -0,0 +1,105 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func messageEvents(trace MessageTrace) []string {
+	events := make([]string, 0, len(trace.Events))
+	for _, e := range trace.Events {
+		events = append(events, e.Event+":"+e.Type)
+	}
+	return events
+}
+
+func TestPipeline_messageTrace(t *testing.T) {
+	outputter := &testOutputter{}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/ok": {
+				Pattern:         "stream/test/ok",
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameProcessors: []FrameProcessor{&testProcessor{}},
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+			"stream/test/drop": {
+				Pattern:         "stream/test/drop",
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameProcessors: []FrameProcessor{&dropProcessor{}},
+			},
+			"stream/test/error": {
+				Pattern:         "stream/test/error",
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameOutputters: []FrameOutputter{&testOutputter{err: errors.New("boom")}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	p.Messages = NewMessageTracker(MessageTrackerConfig{})
+
+	_, err = p.ProcessInput(WithMessageID(context.Background(), "ok"), 1, "stream/test/ok", []byte(`{}`))
+	require.NoError(t, err)
+	trace, ok := p.Messages.Get(1, "ok")
+	require.True(t, ok)
+	require.Equal(t, []string{"received:", "convert:test", "process:test", "output:test"}, messageEvents(trace))
+	require.Equal(t, "stream/test/ok", trace.Events[1].Rule)
+	_, ok = p.Messages.Get(2, "ok")
+	require.False(t, ok)
+
+	_, err = p.ProcessInput(WithMessageID(context.Background(), "drop"), 1, "stream/test/drop", []byte(`{}`))
+	require.NoError(t, err)
+	trace, _ = p.Messages.Get(1, "drop")
+	require.Equal(t, []string{"received:", "convert:test", "process:drop", "dropped:drop"}, messageEvents(trace))
+
+	_, err = p.ProcessInput(WithMessageID(context.Background(), "error"), 1, "stream/test/error", []byte(`{}`))
+	require.Error(t, err)
+	trace, _ = p.Messages.Get(1, "error")
+	require.Equal(t, []string{"received:", "convert:test", "output:test", "deadLetter:output"}, messageEvents(trace))
+	require.Equal(t, "boom", trace.Events[2].Error)
+
+	// Inputs without message ID get a new one.
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/ok", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, 4, p.Messages.traces.Len())
+}
+
+func TestMessageTracker_retention(t *testing.T) {
+	tracker := NewMessageTracker(MessageTrackerConfig{Retention: time.Minute, MaxMessages: 2})
+	for _, id := range []string{"a", "b", "c"} {
+		tracker.record(WithMessageID(context.Background(), id), 1, MessageEvent{Event: MessageEventReceived})
+	}
+	_, ok := tracker.Get(1, "a")
+	require.False(t, ok)
+	_, ok = tracker.Get(1, "c")
+	require.True(t, ok)
+
+	tracker.Compact(time.Now().Add(2 * time.Minute))
+	_, ok = tracker.Get(1, "c")
+	require.False(t, ok)
+
+	ctx := WithMessageID(context.Background(), "d")
+	for i := 0; i < maxMessageTraceEvents+1; i++ {
+		tracker.record(ctx, 1, MessageEvent{Event: MessageEventOutput})
+	}
+	trace, ok := tracker.Get(1, "d")
+	require.True(t, ok)
+	require.Len(t, trace.Events, maxMessageTraceEvents)
+	require.True(t, trace.Truncated)
+
+	// Events of contexts without message ID are not recorded.
+	tracker.record(context.Background(), 1, MessageEvent{Event: MessageEventReceived})
+	require.Equal(t, 1, tracker.traces.Len())
+}
+
+func TestIsValidMessageID(t *testing.T) {
+	require.True(t, IsValidMessageID(NewMessageID()))
+	require.True(t, IsValidMessageID("sensor-1:42"))
+	require.False(t, IsValidMessageID(""))
+	require.False(t, IsValidMessageID("with space"))
+}
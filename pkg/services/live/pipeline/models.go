@@ -3,6 +3,8 @@ package pipeline
 import (
 	"fmt"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/grafana/grafana/pkg/services/live/pipeline/pattern"
 	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
 )
@@ -57,15 +59,29 @@ func WriteConfigToDto(b WriteConfig) WriteConfigDto {
 	}
 	return WriteConfigDto{
 		UID:          b.UID,
+		Name:         b.Name,
 		Settings:     b.Settings,
 		SecureFields: secureFields,
+		Sharing:      b.Sharing,
 	}
 }
 
 type WriteConfigDto struct {
-	UID          string          `json:"uid"`
-	Settings     WriteSettings   `json:"settings"`
-	SecureFields map[string]bool `json:"secureFields"`
+	UID          string              `json:"uid"`
+	Name         string              `json:"name,omitempty"`
+	Settings     WriteSettings       `json:"settings"`
+	SecureFields map[string]bool     `json:"secureFields"`
+	Sharing      *WriteConfigSharing `json:"sharing,omitempty"`
+}
+
+// WriteConfigCatalogEntry describes a write config available to a user without
+// settings and secrets.
+type WriteConfigCatalogEntry struct {
+	OrgId int64  `json:"orgId"`
+	UID   string `json:"uid"`
+	Name  string `json:"name,omitempty"`
+	// Shared is set for write configs of other organizations shared with the user one.
+	Shared bool `json:"shared"`
 }
 
 type WriteConfigGetCmd struct {
@@ -73,16 +89,20 @@ type WriteConfigGetCmd struct {
 }
 
 type WriteConfigCreateCmd struct {
-	UID            string            `json:"uid"`
-	Settings       WriteSettings     `json:"settings"`
-	SecureSettings map[string]string `json:"secureSettings"`
+	UID            string              `json:"uid"`
+	Name           string              `json:"name,omitempty"`
+	Settings       WriteSettings       `json:"settings"`
+	SecureSettings map[string]string   `json:"secureSettings"`
+	Sharing        *WriteConfigSharing `json:"sharing,omitempty"`
 }
 
 // TODO: add version field later.
 type WriteConfigUpdateCmd struct {
-	UID            string            `json:"uid"`
-	Settings       WriteSettings     `json:"settings"`
-	SecureSettings map[string]string `json:"secureSettings"`
+	UID            string              `json:"uid"`
+	Name           string              `json:"name,omitempty"`
+	Settings       WriteSettings       `json:"settings"`
+	SecureSettings map[string]string   `json:"secureSettings"`
+	Sharing        *WriteConfigSharing `json:"sharing,omitempty"`
 }
 
 type WriteConfigDeleteCmd struct {
@@ -90,10 +110,48 @@ type WriteConfigDeleteCmd struct {
 }
 
 type WriteConfig struct {
-	OrgId          int64             `json:"-"`
-	UID            string            `json:"uid"`
+	// OrgId of the write config, write configs without organization belong to the
+	// main organization.
+	OrgId int64  `json:"orgId,omitempty"`
+	UID   string `json:"uid"`
+	// Name of a write config in an organization catalog, rules can reference write
+	// configs by name and organization.
+	Name           string            `json:"name,omitempty"`
 	Settings       WriteSettings     `json:"settings"`
 	SecureSettings map[string][]byte `json:"secureSettings,omitempty"`
+	// Sharing makes a write config available outside of organization admins.
+	Sharing *WriteConfigSharing `json:"sharing,omitempty"`
+
+	// shared is set for write configs of other organizations shared with the rule one.
+	shared bool
+}
+
+// WriteConfigSharing controls who can use a write config besides admins of its
+// organization.
+type WriteConfigSharing struct {
+	// OrgIDs of organizations which rules can reference the write config by name.
+	OrgIDs []int64 `json:"orgIds,omitempty"`
+	// TeamIDs of organization teams which members see the write config in the catalog,
+	// for delegated administration of team rules.
+	TeamIDs []int64 `json:"teamIds,omitempty"`
+}
+
+// SharedWithOrg returns true when rules of an organization can reference the write config.
+func (r WriteConfig) SharedWithOrg(orgID int64) bool {
+	return r.Sharing != nil && slices.Contains(r.Sharing.OrgIDs, orgID)
+}
+
+// SharedWithTeams returns true when the write config is shared with any of teams.
+func (r WriteConfig) SharedWithTeams(teamIDs []int64) bool {
+	if r.Sharing == nil {
+		return false
+	}
+	for _, id := range teamIDs {
+		if slices.Contains(r.Sharing.TeamIDs, id) {
+			return true
+		}
+	}
+	return false
 }
 
 func (r WriteConfig) Valid() (bool, string) {
@@ -109,11 +167,44 @@ func (r WriteConfig) Valid() (bool, string) {
 type BasicAuth struct {
 	// User is a user for remote write request.
 	User string `json:"user,omitempty"`
-	// Password is a plain text non-encrypted password.
-	// TODO: remove after integrating with the database.
+	// Password is a plain text non-encrypted password, it's only read from write
+	// configs saved before secure settings. Passwords sent in settings are moved to
+	// basicAuthPassword secure setting when write configs are saved.
 	Password string `json:"password,omitempty"`
 }
 
+// Names of write config secure settings used for authentication, secure settings are
+// encrypted with the secrets service.
+const (
+	SecureSettingBasicAuthPassword = "basicAuthPassword"
+	// SecureSettingBearerToken is sent in Authorization header of remote write requests.
+	SecureSettingBearerToken = "bearerToken"
+)
+
+// secureWriteSettings moves a plain text basic auth password from settings to secure
+// settings so it's never saved unencrypted, the password replaces one in secure
+// settings. Secure settings with empty values are removed, so API clients clear a
+// secure field by sending an empty value and keep it by omitting it: omitted fields
+// are taken from existing decrypted secure settings of an updated write config.
+func secureWriteSettings(settings WriteSettings, secureSettings map[string]string, existing map[string]string) (WriteSettings, map[string]string) {
+	secured := make(map[string]string, len(existing)+len(secureSettings)+1)
+	for k, v := range existing {
+		if _, ok := secureSettings[k]; !ok && v != "" {
+			secured[k] = v
+		}
+	}
+	for k, v := range secureSettings {
+		if v != "" {
+			secured[k] = v
+		}
+	}
+	if settings.BasicAuth != nil && settings.BasicAuth.Password != "" {
+		secured[SecureSettingBasicAuthPassword] = settings.BasicAuth.Password
+		settings.BasicAuth = &BasicAuth{User: settings.BasicAuth.User}
+	}
+	return settings, secured
+}
+
 type WriteSettings struct {
 	// Endpoint to send streaming frames to.
 	Endpoint string `json:"endpoint"`
@@ -129,6 +220,31 @@ type ChannelRules struct {
 	Rules []ChannelRule `json:"rules"`
 }
 
+// ruleOrgID returns an organization of a rule or write config, the main organization
+// when the organization is not set.
+func ruleOrgID(orgID int64) int64 {
+	if orgID == 0 {
+		return 1
+	}
+	return orgID
+}
+
+// checkAllRulesValid checks rules of every organization found in rules don't conflict.
+func checkAllRulesValid(rules []ChannelRule) (ok bool, reason string) {
+	checked := map[int64]struct{}{}
+	for _, rule := range rules {
+		orgID := ruleOrgID(rule.OrgId)
+		if _, ok := checked[orgID]; ok {
+			continue
+		}
+		checked[orgID] = struct{}{}
+		if ok, reason := checkRulesValid(orgID, rules); !ok {
+			return false, fmt.Sprintf("org %d: %s", orgID, reason)
+		}
+	}
+	return true, ""
+}
+
 func checkRulesValid(orgID int64, rules []ChannelRule) (ok bool, reason string) {
 	t := tree.New()
 	defer func() {
@@ -0,0 +1,35 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureWriteSettings(t *testing.T) {
+	settings := WriteSettings{Endpoint: "http://localhost", BasicAuth: &BasicAuth{User: "admin", Password: "plain"}}
+	secured, secureSettings := secureWriteSettings(settings, map[string]string{
+		SecureSettingBasicAuthPassword: "old",
+		SecureSettingBearerToken:       "",
+		"apiKey":                       "key",
+	}, nil)
+	require.Equal(t, &BasicAuth{User: "admin"}, secured.BasicAuth)
+	require.Equal(t, map[string]string{SecureSettingBasicAuthPassword: "plain", "apiKey": "key"}, secureSettings)
+	// Settings of a command are not changed in place.
+	require.Equal(t, "plain", settings.BasicAuth.Password)
+
+	secured, secureSettings = secureWriteSettings(WriteSettings{Endpoint: "http://localhost"}, nil, nil)
+	require.Nil(t, secured.BasicAuth)
+	require.Empty(t, secureSettings)
+
+	// Omitted secure settings of an updated write config are kept, empty ones are cleared.
+	_, secureSettings = secureWriteSettings(WriteSettings{Endpoint: "http://localhost"}, map[string]string{
+		SecureSettingBearerToken: "",
+		"apiKey":                 "new",
+	}, map[string]string{
+		SecureSettingBasicAuthPassword: "old",
+		SecureSettingBearerToken:       "token",
+		"apiKey":                       "key",
+	})
+	require.Equal(t, map[string]string{SecureSettingBasicAuthPassword: "old", "apiKey": "new"}, secureSettings)
+}
@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var nameTemplateRegex = regexp.MustCompile(`\{([^{}]*(?:\{[^{}]*\})?)\}`)
+
+// nameTemplate is a name of a destination (index, table, file) with placeholders for
+// channel parts and date math expressions, for example
+// "live-{scope}-{namespace}-{now/d{yyyy.MM}}".
+//
+// Supported placeholders are {orgId}, {channel}, {scope}, {namespace}, {path},
+// {frame} and {:name} for named parameters of a rule pattern, e.g. {:site} for
+// stream/:site/:device pattern. Unknown parameters render empty. Date math expressions follow Elasticsearch syntax: {now}, {now-1d},
+// {now/M}, {now/d{yyyy.MM.dd}} or {now/d{yyyy.MM.dd|Europe/Berlin}}. Date is
+// formatted as yyyy.MM.dd by default.
+type nameTemplate struct {
+	parts []nameTemplatePart
+}
+
+type nameTemplatePart struct {
+	literal string
+	name    string
+	param   string
+	date    *dateMath
+}
+
+func parseNameTemplate(s string) (*nameTemplate, error) {
+	t := &nameTemplate{}
+	last := 0
+	for _, m := range nameTemplateRegex.FindAllStringSubmatchIndex(s, -1) {
+		if m[0] > last {
+			t.parts = append(t.parts, nameTemplatePart{literal: s[last:m[0]]})
+		}
+		last = m[1]
+		expr := s[m[2]:m[3]]
+		switch expr {
+		case "orgId", "channel", "scope", "namespace", "path", "frame":
+			t.parts = append(t.parts, nameTemplatePart{name: expr})
+			continue
+		}
+		if param, ok := strings.CutPrefix(expr, ":"); ok {
+			if param == "" {
+				return nil, errors.New("empty template parameter name: {:}")
+			}
+			t.parts = append(t.parts, nameTemplatePart{param: param})
+			continue
+		}
+		if !strings.HasPrefix(expr, "now") {
+			return nil, fmt.Errorf("unknown template placeholder: {%s}", expr)
+		}
+		d, err := parseDateMath(expr)
+		if err != nil {
+			return nil, err
+		}
+		t.parts = append(t.parts, nameTemplatePart{date: d})
+	}
+	if last < len(s) {
+		t.parts = append(t.parts, nameTemplatePart{literal: s[last:]})
+	}
+	return t, nil
+}
+
+func (t *nameTemplate) render(vars Vars, frameName string, now time.Time) string {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		switch {
+		case p.date != nil:
+			sb.WriteString(p.date.eval(now))
+		case p.param != "":
+			sb.WriteString(vars.Params[p.param])
+		case p.name == "orgId":
+			sb.WriteString(strconv.FormatInt(vars.OrgID, 10))
+		case p.name == "channel":
+			sb.WriteString(vars.Channel)
+		case p.name == "scope":
+			sb.WriteString(vars.Scope)
+		case p.name == "namespace":
+			sb.WriteString(vars.Namespace)
+		case p.name == "path":
+			sb.WriteString(vars.Path)
+		case p.name == "frame":
+			sb.WriteString(frameName)
+		default:
+			sb.WriteString(p.literal)
+		}
+	}
+	return sb.String()
+}
+
+var dateMathRegex = regexp.MustCompile(`^now((?:[+-]\d+[yMwdhHms])*)(?:/([yMwdhHms]))?(?:\{([^{}|]*)(?:\|([^{}]*))?\})?$`)
+
+var dateMathOffsetRegex = regexp.MustCompile(`([+-])(\d+)([yMwdhHms])`)
+
+type dateMathOffset struct {
+	n    int
+	unit byte
+}
+
+type dateMath struct {
+	offsets  []dateMathOffset
+	round    byte
+	layout   string
+	location *time.Location
+}
+
+func parseDateMath(expr string) (*dateMath, error) {
+	m := dateMathRegex.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid date math expression: {%s}", expr)
+	}
+	d := &dateMath{location: time.UTC}
+	for _, o := range dateMathOffsetRegex.FindAllStringSubmatch(m[1], -1) {
+		n, err := strconv.Atoi(o[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date math expression: {%s}", expr)
+		}
+		if o[1] == "-" {
+			n = -n
+		}
+		d.offsets = append(d.offsets, dateMathOffset{n: n, unit: o[3][0]})
+	}
+	if m[2] != "" {
+		d.round = m[2][0]
+	}
+	format := m[3]
+	if format == "" {
+		format = "yyyy.MM.dd"
+	}
+	d.layout = javaDateFormatToLayout(format)
+	if m[4] != "" {
+		location, err := time.LoadLocation(m[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date math time zone: %w", err)
+		}
+		d.location = location
+	}
+	return d, nil
+}
+
+func (d *dateMath) eval(now time.Time) string {
+	t := now.In(d.location)
+	for _, o := range d.offsets {
+		switch o.unit {
+		case 'y':
+			t = t.AddDate(o.n, 0, 0)
+		case 'M':
+			t = t.AddDate(0, o.n, 0)
+		case 'w':
+			t = t.AddDate(0, 0, 7*o.n)
+		case 'd':
+			t = t.AddDate(0, 0, o.n)
+		case 'h', 'H':
+			t = t.Add(time.Duration(o.n) * time.Hour)
+		case 'm':
+			t = t.Add(time.Duration(o.n) * time.Minute)
+		case 's':
+			t = t.Add(time.Duration(o.n) * time.Second)
+		}
+	}
+	y, mon, day := t.Date()
+	switch d.round {
+	case 'y':
+		t = time.Date(y, time.January, 1, 0, 0, 0, 0, d.location)
+	case 'M':
+		t = time.Date(y, mon, 1, 0, 0, 0, 0, d.location)
+	case 'w':
+		// Weeks start on Monday as in Elasticsearch.
+		offset := (int(t.Weekday()) + 6) % 7
+		t = time.Date(y, mon, day-offset, 0, 0, 0, 0, d.location)
+	case 'd':
+		t = time.Date(y, mon, day, 0, 0, 0, 0, d.location)
+	case 'h', 'H':
+		t = time.Date(y, mon, day, t.Hour(), 0, 0, 0, d.location)
+	case 'm':
+		t = time.Date(y, mon, day, t.Hour(), t.Minute(), 0, 0, d.location)
+	case 's':
+		t = time.Date(y, mon, day, t.Hour(), t.Minute(), t.Second(), 0, d.location)
+	}
+	return t.Format(d.layout)
+}
+
+var javaDateFormatReplacer = strings.NewReplacer(
+	"yyyy", "2006",
+	"YYYY", "2006",
+	"yy", "06",
+	"YY", "06",
+	"MM", "01",
+	"dd", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// javaDateFormatToLayout converts common Java date format patterns used by
+// Elasticsearch into Go time layout.
+func javaDateFormatToLayout(format string) string {
+	return javaDateFormatReplacer.Replace(format)
+}
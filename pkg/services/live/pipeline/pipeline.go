@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/services/auth/identity"
 	"github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
 )
 
 const (
@@ -76,6 +78,11 @@ type Vars struct {
 	Scope     string
 	Namespace string
 	Path      string
+	// Params are values of named parameters of a rule pattern captured from Channel,
+	// e.g. site and device for stream/:site/:device pattern.
+	Params map[string]string
+	// MessageID identifies an input message, frames converted from it share the ID.
+	MessageID string
 }
 
 // DataOutputter can output incoming data before conversion to frames.
@@ -162,6 +169,46 @@ type LiveChannelRule struct {
 	// can optionally return a slice of ChannelFrame to pass the control to a rule defined
 	// by ChannelFrame.Channel.
 	FrameOutputters []FrameOutputter
+	// DeadLetterOutputter if set receives frames which failed conversion, processing or output
+	// of this rule instead of dropping them. Failed raw payloads are wrapped into a frame with
+	// a "data" string field. Fields of dead letter frames get "stage" and "error" labels.
+	// Frames returned by DeadLetterOutputter are not processed further.
+	DeadLetterOutputter FrameOutputter
+	// Priority defines the order inputs of rules are shed in when the pipeline is
+	// overloaded, PriorityNormal when empty.
+	Priority PriorityClass
+	// Order of the rule among rules matching the same channel, lower first.
+	Order int
+	// RateLimit if set drops inputs of the rule over a limit.
+	RateLimit *RateLimit
+	// Heartbeat if set reports channels of the rule which stopped receiving inputs.
+	Heartbeat *Heartbeat
+	// Rollout if set limits the rule to a percentage of matching channels.
+	Rollout *Rollout
+
+	// patternNode matches channels against Pattern, it's set by CacheSegmentedTree.
+	patternNode *tree.Node
+}
+
+// ChannelParams returns values of named parameters of the rule pattern captured from
+// a channel, e.g. {"site": "a", "device": "b"} for stream/:site/:device pattern and
+// stream/a/b channel.
+func (r *LiveChannelRule) ChannelParams(channel string) map[string]string {
+	node := r.patternNode
+	if node == nil {
+		node = tree.New()
+		node.AddRoute("/"+r.Pattern, r)
+	}
+	value := node.GetValue("/"+channel, true)
+	if value.Params == nil || len(*value.Params) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(*value.Params))
+	for _, p := range *value.Params {
+		// Catch-all parameters keep a leading slash.
+		params[p.Key] = strings.TrimPrefix(p.Value, "/")
+	}
+	return params
 }
 
 // Label ...
@@ -182,12 +229,38 @@ type ChannelRuleGetter interface {
 type Pipeline struct {
 	ruleGetter ChannelRuleGetter
 	tracer     trace.Tracer
+
+	// Stats counts processed inputs, frames and errors per rule when set.
+	Stats *RuleStats
+	// DeadLetters keeps failed payloads and frames of all rules when set.
+	DeadLetters *DeadLetterQueue
+	// Shedder drops inputs of low priority rules first when too many inputs are
+	// processed concurrently, inputs are never shed when not set.
+	Shedder *LoadShedder
+	// RuleMatch defines whether the first or all rules matching a channel process its
+	// inputs, RuleMatchFirst when empty.
+	RuleMatch RuleMatchMode
+	// RateLimiter counts inputs of rules with a rate limit, rate limits are not
+	// applied when not set. New sets a MemoryRateLimiter.
+	RateLimiter RateLimiter
+	// MaxDecompressedSize limits a size of compressed payloads after decompression,
+	// 16MiB by default.
+	MaxDecompressedSize int64
+	// Profiler labels stages of profiled rules when set.
+	Profiler *RuleProfiler
+	// Heartbeats tracks inputs of rules with a heartbeat when set.
+	Heartbeats *HeartbeatMonitor
+	// Messages keeps events of messages by message ID when set.
+	Messages *MessageTracker
+
+	inFlight inputTracker
 }
 
 // New creates new Pipeline.
 func New(ruleGetter ChannelRuleGetter) (*Pipeline, error) {
 	p := &Pipeline{
-		ruleGetter: ruleGetter,
+		ruleGetter:  ruleGetter,
+		RateLimiter: NewMemoryRateLimiter(),
 	}
 
 	if os.Getenv("GF_LIVE_PIPELINE_TRACE") != "" {
@@ -214,6 +287,48 @@ func (p *Pipeline) Get(orgID int64, channel string) (*LiveChannelRule, bool, err
 	return p.ruleGetter.Get(orgID, channel)
 }
 
+// acquire registers an in-flight input and reserves Shedder slot for it according
+// to a priority of its rule, ErrLoadShed is returned when the input must be dropped,
+// ErrRateLimited when it exceeds a rule rate limit and ErrPipelineShutdown when the
+// pipeline is shut down. Size of a raw payload is used for shed volume metrics only.
+func (p *Pipeline) acquire(ctx context.Context, orgID int64, channelID string, size int) (func(), error) {
+	if !p.inFlight.start() {
+		return nil, ErrPipelineShutdown
+	}
+	if err := p.checkRateLimit(ctx, orgID, channelID); err != nil {
+		p.inFlight.done()
+		return nil, err
+	}
+	release, err := p.acquireShedder(orgID, channelID, size)
+	if err != nil {
+		p.inFlight.done()
+		return nil, err
+	}
+	return func() {
+		release()
+		p.inFlight.done()
+	}, nil
+}
+
+func (p *Pipeline) acquireShedder(orgID int64, channelID string, size int) (func(), error) {
+	if p.Shedder == nil {
+		return func() {}, nil
+	}
+	priority := PriorityNormal
+	rule, ok, err := p.ruleGetter.Get(orgID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ok && rule.Priority != "" {
+		priority = rule.Priority
+	}
+	release, ok := p.Shedder.acquire(priority, size)
+	if !ok {
+		return nil, ErrLoadShed
+	}
+	return release, nil
+}
+
 func (p *Pipeline) ProcessInput(ctx context.Context, orgID int64, channelID string, body []byte) (bool, error) {
 	var span trace.Span
 	if p.tracer != nil {
@@ -225,6 +340,15 @@ func (p *Pipeline) ProcessInput(ctx context.Context, orgID int64, channelID stri
 		)
 		defer span.End()
 	}
+	ctx = ensureMessageID(ctx)
+	vars := Vars{OrgID: orgID, Channel: channelID}
+	release, err := p.acquire(ctx, orgID, channelID, len(body))
+	if err != nil {
+		p.recordMessage(ctx, nil, vars, MessageEventRejected, "", err)
+		return false, err
+	}
+	defer release()
+	p.recordMessage(ctx, nil, vars, MessageEventReceived, "", nil)
 	ok, err := p.processInput(ctx, orgID, channelID, body, nil)
 	if err != nil {
 		if p.tracer != nil && span != nil {
@@ -246,19 +370,37 @@ func (p *Pipeline) processInput(ctx context.Context, orgID int64, channelID stri
 		)
 		defer span.End()
 	}
-	rule, ok, err := p.ruleGetter.Get(orgID, channelID)
+	rules, err := p.MatchRules(orgID, channelID)
 	if err != nil {
 		return false, err
 	}
-	if !ok {
-		return false, nil
+	processed := false
+	for _, rule := range rules {
+		ruleVisitedChannels := visitedChannels
+		if len(rules) > 1 {
+			// Every rule follows its own chain of channels.
+			ruleVisitedChannels = make(map[string]struct{}, len(visitedChannels))
+			for ch := range visitedChannels {
+				ruleVisitedChannels[ch] = struct{}{}
+			}
+		}
+		ok, err := p.processRuleInput(ctx, rule, orgID, channelID, body, ruleVisitedChannels)
+		if err != nil {
+			return false, err
+		}
+		processed = processed || ok
 	}
+	return processed, nil
+}
+
+func (p *Pipeline) processRuleInput(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, body []byte, visitedChannels map[string]struct{}) (bool, error) {
+	p.Heartbeats.seen(ctx, rule, orgID, channelID)
 	if visitedChannels == nil {
 		visitedChannels = map[string]struct{}{}
 	}
 	if len(rule.DataOutputters) > 0 {
 		channelDataList := []*ChannelData{{Channel: channelID, Data: body}}
-		err = p.processChannelDataList(ctx, orgID, channelID, channelDataList, visitedChannels)
+		err := p.processChannelDataList(ctx, rule, orgID, channelID, channelDataList, visitedChannels)
 		if err != nil {
 			return false, err
 		}
@@ -266,12 +408,62 @@ func (p *Pipeline) processInput(ctx context.Context, orgID int64, channelID stri
 	if rule.Converter == nil {
 		return false, nil
 	}
+	p.Stats.update(rule, func(c *ruleCounters) { c.inputs++ })
+	ruleInputs.WithLabelValues(rule.Pattern).Inc()
 	channelFrames, err := p.DataToChannelFrames(ctx, *rule, orgID, channelID, body)
 	if err != nil {
+		p.Stats.update(rule, func(c *ruleCounters) { c.convertErrors++ })
+		p.deadLetterData(ctx, rule, orgID, channelID, err, body)
+		return false, err
+	}
+	err = p.processChannelFrames(ctx, rule, orgID, channelID, channelFrames, nil)
+	if err != nil {
+		return false, fmt.Errorf("error processing frame: %w", err)
+	}
+	return true, nil
+}
+
+// ProcessFrames passes already built frames to a channel rule skipping the converter
+// stage, so processors and frame outputters of the rule are applied directly.
+func (p *Pipeline) ProcessFrames(ctx context.Context, orgID int64, channelID string, frames []*data.Frame) (bool, error) {
+	var span trace.Span
+	if p.tracer != nil {
+		ctx, span = p.tracer.Start(ctx, "live.pipeline.process_frames")
+		span.SetAttributes(
+			attribute.Int64("orgId", orgID),
+			attribute.String("channel", channelID),
+			attribute.Int("numFrames", len(frames)),
+		)
+		defer span.End()
+	}
+	rule, ok, err := p.ruleGetter.Get(orgID, channelID)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, nil
+	}
+	ctx = ensureMessageID(ctx)
+	vars := Vars{OrgID: orgID, Channel: channelID}
+	release, err := p.acquire(ctx, orgID, channelID, 0)
+	if err != nil {
+		p.recordMessage(ctx, rule, vars, MessageEventRejected, "", err)
 		return false, err
 	}
-	err = p.processChannelFrames(ctx, orgID, channelID, channelFrames, nil)
+	defer release()
+	p.recordMessage(ctx, rule, vars, MessageEventReceived, "", nil)
+	p.Heartbeats.seen(ctx, rule, orgID, channelID)
+	channelFrames := make([]*ChannelFrame, 0, len(frames))
+	for _, frame := range frames {
+		channelFrames = append(channelFrames, &ChannelFrame{Channel: channelID, Frame: frame})
+	}
+	// Frames of a request share recursion tracking, they are processed in order and
+	// processing stops at the first failed frame.
+	err = p.processChannelFrames(ctx, nil, orgID, channelID, channelFrames, nil)
 	if err != nil {
+		if p.tracer != nil && span != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
 		return false, fmt.Errorf("error processing frame: %w", err)
 	}
 	return true, nil
@@ -300,11 +492,24 @@ func (p *Pipeline) DataToChannelFrames(ctx context.Context, rule LiveChannelRule
 		Scope:     channel.Scope,
 		Namespace: channel.Namespace,
 		Path:      channel.Path,
+		Params:    rule.ChannelParams(channelID),
+		MessageID: MessageIDFromContext(ctx),
+	}
+
+	body, err = decompressPayload(body, p.MaxDecompressedSize)
+	if err != nil {
+		logger.Error("Error decompressing data", "error", err, "channel", channelID)
+		return nil, err
 	}
 
-	frames, err := rule.Converter.Convert(ctx, vars, body)
+	var frames []*ChannelFrame
+	err = p.runStage(ctx, &rule, vars, ruleStageConvert, rule.Converter.Type(), func(ctx context.Context) error {
+		var err error
+		frames, err = rule.Converter.Convert(ctx, vars, body)
+		return err
+	})
 	if err != nil {
-		logger.Error("Error converting data", "error", err)
+		logger.Error("Error converting data", "error", err, "messageId", vars.MessageID)
 		return nil, err
 	}
 
@@ -313,7 +518,9 @@ func (p *Pipeline) DataToChannelFrames(ctx context.Context, rule LiveChannelRule
 
 var errChannelRecursion = errors.New("channel recursion")
 
-func (p *Pipeline) processChannelDataList(ctx context.Context, orgID int64, channelID string, channelDataList []*ChannelData, visitedChannels map[string]struct{}) error {
+// processChannelDataList passes data to data outputs of a rule of channelID, rules of
+// other channels are looked up.
+func (p *Pipeline) processChannelDataList(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, channelDataList []*ChannelData, visitedChannels map[string]struct{}) error {
 	for _, channelData := range channelDataList {
 		var nextChannel = channelID
 		if channelData.Channel != "" {
@@ -323,7 +530,11 @@ func (p *Pipeline) processChannelDataList(ctx context.Context, orgID int64, chan
 			return fmt.Errorf("%w: %s", errChannelRecursion, nextChannel)
 		}
 		visitedChannels[nextChannel] = struct{}{}
-		newChannelDataList, err := p.processData(ctx, orgID, nextChannel, channelData.Data)
+		var nextRule *LiveChannelRule
+		if nextChannel == channelID {
+			nextRule = rule
+		}
+		newChannelDataList, err := p.processData(ctx, nextRule, orgID, nextChannel, channelData.Data)
 		if err != nil {
 			return err
 		}
@@ -339,10 +550,11 @@ func (p *Pipeline) processChannelDataList(ctx context.Context, orgID int64, chan
 	return nil
 }
 
-func (p *Pipeline) processChannelFrames(ctx context.Context, orgID int64, channelID string, channelFrames []*ChannelFrame, visitedChannels map[string]struct{}) error {
-	if visitedChannels == nil {
-		visitedChannels = map[string]struct{}{}
-	}
+// processChannelFrames passes frames to a rule of channelID, or to rules matching
+// channelID when rule is nil. Frames of other channels go to rules matching them.
+// visitedChannels are channels a frame passed through, so a frame coming back to one
+// of them is a recursion while sibling frames may go to the same channel.
+func (p *Pipeline) processChannelFrames(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, channelFrames []*ChannelFrame, visitedChannels map[string]struct{}) error {
 	for _, channelFrame := range channelFrames {
 		var processorChannel = channelID
 		if channelFrame.Channel != "" {
@@ -351,13 +563,21 @@ func (p *Pipeline) processChannelFrames(ctx context.Context, orgID int64, channe
 		if _, ok := visitedChannels[processorChannel]; ok {
 			return fmt.Errorf("%w: %s", errChannelRecursion, processorChannel)
 		}
-		visitedChannels[processorChannel] = struct{}{}
-		frames, err := p.processFrame(ctx, orgID, processorChannel, channelFrame.Frame)
+		frameVisitedChannels := make(map[string]struct{}, len(visitedChannels)+1)
+		for ch := range visitedChannels {
+			frameVisitedChannels[ch] = struct{}{}
+		}
+		frameVisitedChannels[processorChannel] = struct{}{}
+		var processorRule *LiveChannelRule
+		if processorChannel == channelID {
+			processorRule = rule
+		}
+		frames, err := p.processFrame(ctx, processorRule, orgID, processorChannel, channelFrame.Frame)
 		if err != nil {
 			return err
 		}
 		if len(frames) > 0 {
-			err := p.processChannelFrames(ctx, orgID, processorChannel, frames, visitedChannels)
+			err := p.processChannelFrames(ctx, nil, orgID, processorChannel, frames, frameVisitedChannels)
 			if err != nil {
 				return err
 			}
@@ -366,7 +586,9 @@ func (p *Pipeline) processChannelFrames(ctx context.Context, orgID int64, channe
 	return nil
 }
 
-func (p *Pipeline) processFrame(ctx context.Context, orgID int64, channelID string, frame *data.Frame) ([]*ChannelFrame, error) {
+// processFrame passes a frame to a rule, or to all rules matching a channel when rule
+// is nil.
+func (p *Pipeline) processFrame(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, frame *data.Frame) ([]*ChannelFrame, error) {
 	var span trace.Span
 	if p.tracer != nil {
 		table, err := frame.StringTable(32, 32)
@@ -381,16 +603,35 @@ func (p *Pipeline) processFrame(ctx context.Context, orgID int64, channelID stri
 		)
 		defer span.End()
 	}
-	rule, ruleOk, err := p.ruleGetter.Get(orgID, channelID)
+	if rule != nil {
+		return p.processRuleFrame(ctx, rule, orgID, channelID, frame)
+	}
+	rules, err := p.MatchRules(orgID, channelID)
 	if err != nil {
 		logger.Error("Error getting rule", "error", err)
 		return nil, err
 	}
-	if !ruleOk {
+	if len(rules) == 0 {
 		logger.Debug("Rule not found", "channel", channelID)
-		return nil, err
+		return nil, nil
+	}
+	var resultingFrames []*ChannelFrame
+	for i, rule := range rules {
+		ruleFrame := frame
+		if i < len(rules)-1 {
+			// Frames are processed in place, each rule gets its own copy.
+			ruleFrame = copyFrame(frame)
+		}
+		frames, err := p.processRuleFrame(ctx, rule, orgID, channelID, ruleFrame)
+		if err != nil {
+			return nil, err
+		}
+		resultingFrames = append(resultingFrames, frames...)
 	}
+	return resultingFrames, nil
+}
 
+func (p *Pipeline) processRuleFrame(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, frame *data.Frame) ([]*ChannelFrame, error) {
 	ch, err := live.ParseChannel(channelID)
 	if err != nil {
 		logger.Error("Error parsing channel", "error", err, "channel", channelID)
@@ -403,16 +644,29 @@ func (p *Pipeline) processFrame(ctx context.Context, orgID int64, channelID stri
 		Scope:     ch.Scope,
 		Namespace: ch.Namespace,
 		Path:      ch.Path,
+		Params:    rule.ChannelParams(channelID),
+		MessageID: MessageIDFromContext(ctx),
 	}
 
+	ruleFramesIn.WithLabelValues(rule.Pattern).Inc()
 	if len(rule.FrameProcessors) > 0 {
 		for _, proc := range rule.FrameProcessors {
-			frame, err = p.execProcessor(ctx, proc, vars, frame)
+			var processed *data.Frame
+			err = p.runStage(ctx, rule, vars, ruleStageProcess, proc.Type(), func(ctx context.Context) error {
+				var err error
+				processed, err = p.execProcessor(ctx, proc, vars, frame)
+				return err
+			})
 			if err != nil {
-				logger.Error("Error processing frame", "error", err)
+				logger.Error("Error processing frame", "error", err, "messageId", vars.MessageID)
+				p.Stats.update(rule, func(c *ruleCounters) { c.processErrors++ })
+				p.deadLetterFrame(ctx, rule, vars, DeadLetterStageProcess, err, frame)
 				return nil, err
 			}
+			frame = processed
 			if frame == nil {
+				ruleFramesDropped.WithLabelValues(rule.Pattern, proc.Type()).Inc()
+				p.recordMessage(ctx, rule, vars, MessageEventDropped, proc.Type(), nil)
 				return nil, nil
 			}
 		}
@@ -421,19 +675,36 @@ func (p *Pipeline) processFrame(ctx context.Context, orgID int64, channelID stri
 	if len(rule.FrameOutputters) > 0 {
 		var resultingFrames []*ChannelFrame
 		for _, out := range rule.FrameOutputters {
-			frames, err := p.processFrameOutput(ctx, out, vars, frame)
+			var frames []*ChannelFrame
+			err = p.runStage(ctx, rule, vars, ruleStageOutput, out.Type(), func(ctx context.Context) error {
+				var err error
+				frames, err = p.processFrameOutput(ctx, out, vars, frame)
+				return err
+			})
 			if err != nil {
-				logger.Error("Error outputting frame", "error", err)
+				logger.Error("Error outputting frame", "error", err, "messageId", vars.MessageID)
+				p.Stats.update(rule, func(c *ruleCounters) { c.outputErrors++ })
+				p.deadLetterFrame(ctx, rule, vars, DeadLetterStageOutput, err, frame)
 				return nil, err
 			}
 			resultingFrames = append(resultingFrames, frames...)
 		}
+		p.countFrame(rule, frame)
 		return resultingFrames, nil
 	}
 
+	p.countFrame(rule, frame)
 	return nil, nil
 }
 
+func (p *Pipeline) countFrame(rule *LiveChannelRule, frame *data.Frame) {
+	ruleFramesOut.WithLabelValues(rule.Pattern).Inc()
+	p.Stats.update(rule, func(c *ruleCounters) {
+		c.frames++
+		c.rows += int64(frame.Rows())
+	})
+}
+
 func (p *Pipeline) execProcessor(ctx context.Context, proc FrameProcessor, vars Vars, frame *data.Frame) (*data.Frame, error) {
 	var span trace.Span
 	if p.tracer != nil {
@@ -473,7 +744,9 @@ func (p *Pipeline) processFrameOutput(ctx context.Context, out FrameOutputter, v
 	return out.OutputFrame(ctx, vars, frame)
 }
 
-func (p *Pipeline) processData(ctx context.Context, orgID int64, channelID string, data []byte) ([]*ChannelData, error) {
+// processData passes data to data outputs of a rule, the rule of a channel is looked
+// up when nil.
+func (p *Pipeline) processData(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, data []byte) ([]*ChannelData, error) {
 	var span trace.Span
 	if p.tracer != nil {
 		ctx, span = p.tracer.Start(ctx, "live.pipeline.process_data_"+channelID)
@@ -484,14 +757,18 @@ func (p *Pipeline) processData(ctx context.Context, orgID int64, channelID strin
 		)
 		defer span.End()
 	}
-	rule, ruleOk, err := p.ruleGetter.Get(orgID, channelID)
-	if err != nil {
-		logger.Error("Error getting rule", "error", err)
-		return nil, err
-	}
-	if !ruleOk {
-		logger.Debug("Rule not found", "channel", channelID)
-		return nil, err
+	if rule == nil {
+		var ok bool
+		var err error
+		rule, ok, err = p.ruleGetter.Get(orgID, channelID)
+		if err != nil {
+			logger.Error("Error getting rule", "error", err)
+			return nil, err
+		}
+		if !ok {
+			logger.Debug("Rule not found", "channel", channelID)
+			return nil, nil
+		}
 	}
 
 	ch, err := live.ParseChannel(channelID)
@@ -506,14 +783,21 @@ func (p *Pipeline) processData(ctx context.Context, orgID int64, channelID strin
 		Scope:     ch.Scope,
 		Namespace: ch.Namespace,
 		Path:      ch.Path,
+		Params:    rule.ChannelParams(channelID),
+		MessageID: MessageIDFromContext(ctx),
 	}
 
 	if len(rule.DataOutputters) > 0 {
 		var resultingChannelDataList []*ChannelData
 		for _, out := range rule.DataOutputters {
-			channelDataList, err := p.processDataOutput(ctx, out, vars, data)
+			var channelDataList []*ChannelData
+			err := p.runStage(ctx, rule, vars, ruleStageDataOutput, out.Type(), func(ctx context.Context) error {
+				var err error
+				channelDataList, err = p.processDataOutput(ctx, out, vars, data)
+				return err
+			})
 			if err != nil {
-				logger.Error("Error outputting frame", "error", err)
+				logger.Error("Error outputting data", "error", err, "messageId", vars.MessageID)
 				return nil, err
 			}
 			resultingChannelDataList = append(resultingChannelDataList, channelDataList...)
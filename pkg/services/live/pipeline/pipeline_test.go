@@ -123,17 +123,13 @@ func TestPipeline_Recursion(t *testing.T) {
 			"stream/test/xxx": {
 				Converter: &testConverter{"", data.NewFrame("test")},
 				FrameOutputters: []FrameOutputter{
-					NewRedirectFrameOutput(RedirectOutputConfig{
-						Channel: "stream/test/yyy",
-					}),
+					newTestRedirectFrameOutput(t, "stream/test/yyy"),
 				},
 			},
 			"stream/test/yyy": {
 				Converter: &testConverter{"", data.NewFrame("test")},
 				FrameOutputters: []FrameOutputter{
-					NewRedirectFrameOutput(RedirectOutputConfig{
-						Channel: "stream/test/xxx",
-					}),
+					newTestRedirectFrameOutput(t, "stream/test/xxx"),
 				},
 			},
 		},
@@ -142,3 +138,84 @@ func TestPipeline_Recursion(t *testing.T) {
 	_, err = p.ProcessInput(context.Background(), 1, "stream/test/xxx", []byte(`{}`))
 	require.ErrorIs(t, err, errChannelRecursion)
 }
+
+type testCountingOutputter struct {
+	mu     sync.Mutex
+	frames []*data.Frame
+	// failAt fails the frame with this name
+	failAt string
+}
+
+func (t *testCountingOutputter) Type() string {
+	return "test"
+}
+
+func (t *testCountingOutputter) OutputFrame(_ context.Context, _ Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if frame.Name == t.failAt {
+		return nil, errors.New("boom")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.frames = append(t.frames, frame)
+	return nil, nil
+}
+
+func TestPipeline_ProcessFrames(t *testing.T) {
+	outputter := &testCountingOutputter{}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/xxx": {
+				FrameOutputters: []FrameOutputter{newTestRedirectFrameOutput(t, "stream/test/yyy")},
+			},
+			"stream/test/yyy": {
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// Frames of a request may go through the same channels.
+	ok, err := p.ProcessFrames(context.Background(), 1, "stream/test/xxx", []*data.Frame{data.NewFrame("a"), data.NewFrame("b")})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, outputter.frames, 2)
+	require.Equal(t, "a", outputter.frames[0].Name)
+	require.Equal(t, "b", outputter.frames[1].Name)
+
+	ok, err = p.ProcessFrames(context.Background(), 1, "stream/test/unknown", []*data.Frame{data.NewFrame("a")})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestPipeline_ProcessFramesRecursion(t *testing.T) {
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/xxx": {
+				FrameOutputters: []FrameOutputter{newTestRedirectFrameOutput(t, "stream/test/yyy")},
+			},
+			"stream/test/yyy": {
+				FrameOutputters: []FrameOutputter{newTestRedirectFrameOutput(t, "stream/test/xxx")},
+			},
+		},
+	})
+	require.NoError(t, err)
+	_, err = p.ProcessFrames(context.Background(), 1, "stream/test/xxx", []*data.Frame{data.NewFrame("a"), data.NewFrame("b")})
+	require.ErrorIs(t, err, errChannelRecursion)
+}
+
+func TestPipeline_ProcessFramesPartialFailure(t *testing.T) {
+	outputter := &testCountingOutputter{failAt: "b"}
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/xxx": {
+				FrameOutputters: []FrameOutputter{outputter},
+			},
+		},
+	})
+	require.NoError(t, err)
+	_, err = p.ProcessFrames(context.Background(), 1, "stream/test/xxx", []*data.Frame{data.NewFrame("a"), data.NewFrame("b"), data.NewFrame("c")})
+	require.ErrorContains(t, err, "boom")
+	// Frames are processed in order until the failed one.
+	require.Len(t, outputter.frames, 1)
+	require.Equal(t, "a", outputter.frames[0].Name)
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// Labels set on stages of profiled rules. Captured profiles can be focused on a rule
+// with `go tool pprof -tagfocus=live_rule=<pattern>`.
+const (
+	profileLabelRule  = "live_rule"
+	profileLabelStage = "live_stage"
+)
+
+// MaxProfileDuration limits a time a rule is profiled for.
+const MaxProfileDuration = time.Minute
+
+// ErrProfilingInProgress is returned when a CPU profile is already being captured.
+var ErrProfilingInProgress = errors.New("profiling is already in progress")
+
+// RuleProfiler captures CPU profiles while stages of a profiled rule run with pprof
+// labels, so converters, processors and outputs of a rule can be told apart in a
+// flame graph. Only one profile is captured at a time since CPU profiling is process
+// wide. Rules are not labeled when they are not profiled, so there is no overhead.
+type RuleProfiler struct {
+	mu      sync.RWMutex
+	rules   map[string]struct{}
+	running bool
+}
+
+func NewRuleProfiler() *RuleProfiler {
+	return &RuleProfiler{rules: map[string]struct{}{}}
+}
+
+// Profile captures a CPU profile in pprof format to w for duration, rule stages of
+// pattern in an organization are labeled meanwhile.
+func (p *RuleProfiler) Profile(ctx context.Context, orgID int64, pattern string, duration time.Duration, w io.Writer) error {
+	if duration <= 0 || duration > MaxProfileDuration {
+		duration = MaxProfileDuration
+	}
+	key := orgchannel.PrependOrgID(orgID, pattern)
+	p.mu.Lock()
+	if p.running {
+		p.mu.Unlock()
+		return ErrProfilingInProgress
+	}
+	if err := pprof.StartCPUProfile(w); err != nil {
+		// Profile is captured by something else, like /debug/pprof.
+		p.mu.Unlock()
+		return ErrProfilingInProgress
+	}
+	p.running = true
+	p.rules[key] = struct{}{}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	delete(p.rules, key)
+	p.running = false
+	p.mu.Unlock()
+	pprof.StopCPUProfile()
+	return ctx.Err()
+}
+
+func (p *RuleProfiler) profiled(rule *LiveChannelRule) bool {
+	if p == nil || rule == nil {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.rules) == 0 {
+		return false
+	}
+	_, ok := p.rules[orgchannel.PrependOrgID(rule.OrgId, rule.Pattern)]
+	return ok
+}
+
+// profileStage runs fn with pprof labels of a rule stage, like "process:dropFields",
+// when the rule is profiled.
+func (p *RuleProfiler) profileStage(ctx context.Context, rule *LiveChannelRule, stage string, fn func(ctx context.Context)) {
+	if !p.profiled(rule) {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels(profileLabelRule, rule.Pattern, profileLabelStage, stage), fn)
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type labelRecordingProcessor struct {
+	stages chan string
+}
+
+func (p *labelRecordingProcessor) Type() string {
+	return "labelRecording"
+}
+
+func (p *labelRecordingProcessor) ProcessFrame(ctx context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	stage, _ := pprof.Label(ctx, profileLabelStage)
+	p.stages <- stage
+	return frame, nil
+}
+
+func TestRuleProfiler_Profile(t *testing.T) {
+	processor := &labelRecordingProcessor{stages: make(chan string, 1)}
+	rule := &LiveChannelRule{
+		OrgId:   1,
+		Pattern: "stream/test/profile",
+		Converter: &testConverter{
+			channel: "stream/test/profile",
+			frame:   data.NewFrame("test", data.NewField("value", nil, []float64{1})),
+		},
+		FrameProcessors: []FrameProcessor{processor},
+	}
+	p, err := New(&testRuleGetter{rules: map[string]*LiveChannelRule{"stream/test/profile": rule}})
+	require.NoError(t, err)
+	p.Profiler = NewRuleProfiler()
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/profile", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, "", <-processor.stages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	var buf bytes.Buffer
+	go func() {
+		done <- p.Profiler.Profile(ctx, 1, "stream/test/profile", time.Minute, &buf)
+	}()
+	require.Eventually(t, func() bool { return p.Profiler.profiled(rule) }, time.Second, 10*time.Millisecond)
+
+	err = p.Profiler.Profile(context.Background(), 1, "stream/test/other", time.Second, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrProfilingInProgress)
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/profile", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, "process:labelRecording", <-processor.stages)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.NotZero(t, buf.Len())
+	require.False(t, p.Profiler.profiled(rule))
+}
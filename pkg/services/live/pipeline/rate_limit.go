@@ -0,0 +1,166 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// ErrRateLimited is returned for inputs dropped because a rate limit of their rule is
+// exceeded.
+var ErrRateLimited = errors.New("pipeline rule rate limit exceeded")
+
+const defaultRateLimitInterval = time.Second
+
+var rateLimitedInputs = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.ExporterName,
+	Subsystem: "live_pipeline",
+	Name:      "rate_limited_inputs_total",
+	Help:      "A counter for pipeline inputs dropped by rule rate limits",
+}, []string{"pattern"})
+
+func init() {
+	prometheus.MustRegister(rateLimitedInputs)
+}
+
+// RateLimit of a rule allows up to Limit inputs per Interval.
+type RateLimit struct {
+	Limit    int
+	Interval time.Duration
+	// PerChannel limits each channel of the rule separately.
+	PerChannel bool
+}
+
+func newRateLimit(config RateLimitConfig) (*RateLimit, error) {
+	if config.Limit <= 0 {
+		return nil, errors.New("rate limit must be positive")
+	}
+	if config.IntervalMilliseconds < 0 {
+		return nil, errors.New("rate limit interval can't be negative")
+	}
+	interval := time.Duration(config.IntervalMilliseconds) * time.Millisecond
+	if interval == 0 {
+		interval = defaultRateLimitInterval
+	}
+	return &RateLimit{Limit: config.Limit, Interval: interval, PerChannel: config.PerChannel}, nil
+}
+
+// key of a rate limit counter of a rule input.
+func (l *RateLimit) key(rule *LiveChannelRule, channelID string) string {
+	if l.PerChannel {
+		return orgchannel.PrependOrgID(rule.OrgId, channelID)
+	}
+	return orgchannel.PrependOrgID(rule.OrgId, rule.Pattern)
+}
+
+// RateLimiter counts inputs of a key in fixed windows of an interval.
+type RateLimiter interface {
+	// Allow returns true when an input fits into a limit of the current window.
+	Allow(ctx context.Context, key string, limit int, interval time.Duration) (bool, error)
+}
+
+type rateLimitWindow struct {
+	end   time.Time
+	count int
+}
+
+// MemoryRateLimiter keeps rate limit counters in memory, so limits apply to every
+// Grafana instance separately.
+type MemoryRateLimiter struct {
+	mu          sync.Mutex
+	windows     map[string]*rateLimitWindow
+	lastCleanup time.Time
+	now         func() time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{windows: map[string]*rateLimitWindow{}, now: time.Now}
+}
+
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string, limit int, interval time.Duration) (bool, error) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastCleanup) >= time.Minute {
+		// Counters of inactive keys are removed periodically.
+		for k, w := range l.windows {
+			if !w.end.After(now) {
+				delete(l.windows, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+	w, ok := l.windows[key]
+	if !ok || !w.end.After(now) {
+		w = &rateLimitWindow{end: now.Truncate(interval).Add(interval)}
+		l.windows[key] = w
+	}
+	w.count++
+	return w.count <= limit, nil
+}
+
+// RedisRateLimiter keeps rate limit counters in Redis, so limits are shared by all
+// Grafana instances in HA mode. When Redis is not available inputs are limited by
+// a local fallback limiter.
+type RedisRateLimiter struct {
+	redisClient *redis.Client
+	fallback    *MemoryRateLimiter
+	now         func() time.Time
+}
+
+func NewRedisRateLimiter(redisClient *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		redisClient: redisClient,
+		fallback:    NewMemoryRateLimiter(),
+		now:         time.Now,
+	}
+}
+
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit int, interval time.Duration) (bool, error) {
+	window := l.now().UnixMilli() / interval.Milliseconds()
+	redisKey := "gf_live.pipeline_rate_limit." + key + "." + strconv.FormatInt(window, 10)
+
+	pipe := l.redisClient.TxPipeline()
+	defer func() { _ = pipe.Close() }()
+	incr := pipe.Incr(ctx, redisKey)
+	pipe.PExpire(ctx, redisKey, 2*interval)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warn("Error counting rate limit in Redis, using local limit", "key", key, "error", err)
+		return l.fallback.Allow(ctx, key, limit, interval)
+	}
+	return incr.Val() <= int64(limit), nil
+}
+
+// checkRateLimit returns ErrRateLimited when an input of a channel exceeds a rate
+// limit of its rule.
+func (p *Pipeline) checkRateLimit(ctx context.Context, orgID int64, channelID string) error {
+	if p.RateLimiter == nil {
+		return nil
+	}
+	rule, ok, err := p.ruleGetter.Get(orgID, channelID)
+	if err != nil {
+		return err
+	}
+	if !ok || rule.RateLimit == nil {
+		return nil
+	}
+	limit := rule.RateLimit
+	allowed, err := p.RateLimiter.Allow(ctx, limit.key(rule, channelID), limit.Limit, limit.Interval)
+	if err != nil {
+		return fmt.Errorf("error checking rate limit: %w", err)
+	}
+	if !allowed {
+		rateLimitedInputs.WithLabelValues(rule.Pattern).Inc()
+		return ErrRateLimited
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimit(t *testing.T) {
+	limit, err := newRateLimit(RateLimitConfig{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, time.Second, limit.Interval)
+
+	_, err = newRateLimit(RateLimitConfig{Limit: 0})
+	require.Error(t, err)
+	_, err = newRateLimit(RateLimitConfig{Limit: 1, IntervalMilliseconds: -1})
+	require.Error(t, err)
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewMemoryRateLimiter()
+	l.now = func() time.Time { return now }
+
+	allow := func(key string) bool {
+		ok, err := l.Allow(context.Background(), key, 2, time.Second)
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, allow("a"))
+	require.True(t, allow("a"))
+	require.False(t, allow("a"))
+	require.True(t, allow("b"))
+
+	now = now.Add(time.Second)
+	require.True(t, allow("a"))
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	now := time.Unix(1000, 0)
+	newLimiter := func() *RedisRateLimiter {
+		l := NewRedisRateLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		l.now = func() time.Time { return now }
+		return l
+	}
+	// Two instances share a limit.
+	l1, l2 := newLimiter(), newLimiter()
+	allow := func(l *RedisRateLimiter) bool {
+		ok, err := l.Allow(context.Background(), "1/stream/test/:id", 3, time.Second)
+		require.NoError(t, err)
+		return ok
+	}
+	require.True(t, allow(l1))
+	require.True(t, allow(l2))
+	require.True(t, allow(l1))
+	require.False(t, allow(l2))
+
+	now = now.Add(time.Second)
+	require.True(t, allow(l2))
+
+	// Local limit is used when Redis is not available.
+	mr.Close()
+	require.True(t, allow(l1))
+	require.True(t, allow(l1))
+	require.True(t, allow(l1))
+	require.False(t, allow(l1))
+}
+
+func TestPipeline_rateLimit(t *testing.T) {
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/debug": {
+				OrgId:     1,
+				Pattern:   "stream/test/debug",
+				RateLimit: &RateLimit{Limit: 1, Interval: time.Hour},
+				Converter: &testConverter{"", data.NewFrame("test")},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	ok, err := p.ProcessInput(context.Background(), 1, "stream/test/debug", []byte(`{}`))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/debug", []byte(`{}`))
+	require.ErrorIs(t, err, ErrRateLimited)
+}
@@ -1,5 +1,9 @@
 package pipeline
 
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
 type EntityInfo struct {
 	Type        string `json:"type"`
 	Description string `json:"description"`
@@ -35,19 +39,232 @@ var FrameOutputsRegistry = []EntityInfo{
 	{
 		Type:        FrameOutputTypeThreshold,
 		Description: "output field threshold boundaries cross into new channel",
+		Example: ThresholdOutputConfig{
+			FieldName:       "value",
+			Channel:         "stream/sensors/state",
+			Hysteresis:      5,
+			ForMilliseconds: 30000,
+		},
 	},
 	{
 		Type:        FrameOutputTypeChangeLog,
 		Description: "output field changes into new channel",
+		Example: ChangeLogOutputConfig{
+			Channel: "stream/sensors/changes",
+			Fields: []ChangeLogFieldConfig{
+				{Name: "status"},
+				{Name: "temperature", MinDelta: 0.5, IgnoreNull: true},
+			},
+		},
 	},
 	{
 		Type:        FrameOutputTypeRemoteWrite,
 		Description: "output to remote write endpoint",
+		Example: RemoteWriteOutputConfig{
+			RelabelConfigs: []RelabelConfig{
+				{SourceLabels: []string{"__name__"}, Regex: stringPtr("(.*)"), TargetLabel: "__name__", Replacement: stringPtr("live_$1")},
+				{Regex: stringPtr("internal_.*"), Action: "labeldrop"},
+			},
+			FlushIntervalMilliseconds: 5000,
+			MaxBatchSamples:           10000,
+		},
 	},
 	{
 		Type:        FrameOutputTypeLoki,
 		Description: "output frame as JSON to Loki",
 	},
+	{
+		Type:        FrameOutputTypeElasticsearch,
+		Description: "output frame rows as documents to Elasticsearch with bulk API",
+		Example: ElasticsearchOutputConfig{
+			Index: "live-{namespace}-{now/d{yyyy.MM.dd}}",
+		},
+	},
+	{
+		Type:        FrameOutputTypeInflux,
+		Description: "output frame as line protocol to InfluxDB",
+		Example: InfluxOutputConfig{
+			Org:    "my-org",
+			Bucket: "live",
+		},
+	},
+	{
+		Type:        FrameOutputTypePostgres,
+		Description: "insert frame rows into PostgreSQL table",
+		Example: PostgresOutputConfig{
+			Table:   "live_{path}",
+			Columns: []PostgresColumnConfig{{Field: "time"}, {Field: "value", Column: "cpu"}},
+		},
+	},
+	{
+		Type:        FrameOutputTypeNATS,
+		Description: "publish frame as JSON to NATS subject, optionally with JetStream ack",
+		Example: NATSOutputConfig{
+			Subject:   "telemetry.{namespace}.{path}",
+			JetStream: true,
+		},
+	},
+	{
+		Type:        FrameOutputTypeWebhook,
+		Description: "send frame as JSON to HTTP endpoint",
+		Example: WebhookOutputConfig{
+			Headers:    map[string]string{"X-Source": "grafana-live"},
+			MaxRetries: 3,
+		},
+	},
+	{
+		Type:        FrameOutputTypeS3,
+		Description: "archive frames as NDJSON or Parquet objects in S3 compatible storage",
+		Example: S3OutputConfig{
+			Bucket: "live-archive",
+			Key:    "{namespace}/{path}/{now/h{yyyy/MM/dd/HH}}",
+			Format: "parquet",
+		},
+	},
+	{
+		Type:        FrameOutputTypeFile,
+		Description: "append frames as JSON lines to local files with rotation",
+		Example: FileOutputConfig{
+			Path:                         "{orgId}/{namespace}/{path}",
+			MaxSizeBytes:                 16 * 1024 * 1024,
+			RotationIntervalMilliseconds: 3600000,
+			MaxFiles:                     24,
+		},
+	},
+	{
+		Type:        FrameOutputTypeDebug,
+		Description: "log frames and keep last ones in memory for rule debugging",
+		Example: DebugOutputConfig{
+			Level:   "info",
+			MaxRows: 5,
+		},
+	},
+	{
+		Type:        FrameOutputTypeAnnotation,
+		Description: "create Grafana annotations from frames",
+		Example: AnnotationOutputConfig{
+			DashboardUID: "live-dashboard",
+			Text:         "{{ .Fields.state }} on {{ .Labels.host }}",
+			Tags:         []string{"live", "{{ .Path }}"},
+		},
+	},
+	{
+		Type:        FrameOutputTypeAlertNotification,
+		Description: "send notifications through an alerting contact point",
+		Example: AlertNotificationOutputConfig{
+			ContactPoint: "ops-slack",
+			AlertName:    "{{ .Path }} threshold",
+			Summary:      "{{ .Labels.host }} is {{ .Fields.state }}",
+			Labels:       map[string]string{"severity": "{{ .Fields.state }}"},
+		},
+	},
+	{
+		Type:        FrameOutputTypeForward,
+		Description: "push frames to a Live channel of another Grafana instance",
+		Example: ForwardOutputConfig{
+			UID:     "central-grafana",
+			Channel: "stream/edge-1/{path}",
+		},
+	},
+	{
+		Type:        FrameOutputTypeRetry,
+		Description: "retry a failed output with exponential backoff",
+		Example: RetryOutputConfig{
+			Outputter: &FrameOutputterConfig{
+				Type:                FrameOutputTypeForward,
+				ForwardOutputConfig: &ForwardOutputConfig{UID: "central-grafana"},
+			},
+			MaxAttempts:                5,
+			InitialBackoffMilliseconds: 500,
+		},
+	},
+	{
+		Type:        FrameOutputTypeAMQP,
+		Description: "publish frame as JSON to AMQP exchange, optionally with publisher confirms",
+		Example: AMQPOutputConfig{
+			Exchange:   "telemetry",
+			RoutingKey: "live.{namespace}.{path}",
+			Confirm:    true,
+			Persistent: true,
+		},
+	},
+	{
+		Type:        FrameOutputTypeEventHubs,
+		Description: "send frame as JSON event to Azure Event Hub",
+		Example: EventHubsOutputConfig{
+			EventHub:     "telemetry",
+			PartitionKey: "{namespace}",
+		},
+	},
+	{
+		Type:        FrameOutputTypePubSub,
+		Description: "publish frame as JSON message to Google Cloud Pub/Sub topic",
+		Example: PubSubOutputConfig{
+			Project:          "my-project",
+			Topic:            "grafana-live-{namespace}",
+			OrderingKeyField: "device",
+		},
+	},
+	{
+		Type:        FrameOutputTypePushgateway,
+		Description: "push numeric fields as gauges to Prometheus Pushgateway",
+		Example: PushgatewayOutputConfig{
+			Job:      "live_{namespace}",
+			Grouping: map[string]string{"channel": "{channel}"},
+		},
+	},
+	{
+		Type:        FrameOutputTypeFailureAlert,
+		Description: "alert when output failure rate crosses a threshold or its circuit breaker opens",
+		Example: FailureAlertOutputConfig{
+			Outputter: &FrameOutputterConfig{
+				Type:                FrameOutputTypeForward,
+				ForwardOutputConfig: &ForwardOutputConfig{UID: "central-grafana"},
+			},
+			FailureRate:    0.2,
+			CircuitBreaker: &CircuitBreakerConfig{ConsecutiveFailures: 5},
+			ContactPoint:   "ops-slack",
+		},
+	},
+	{
+		Type:        FrameOutputTypeRollout,
+		Description: "send frames of a percentage of channels to a new output and others to a fallback output",
+		Example: RolloutOutputConfig{
+			RolloutConfig: RolloutConfig{Name: "loki-migration", Percentage: 10},
+			Outputter: &FrameOutputterConfig{
+				Type:             FrameOutputTypeLoki,
+				LokiOutputConfig: &LokiOutputConfig{UID: "loki"},
+			},
+			Fallback: &FrameOutputterConfig{
+				Type:                    FrameOutputTypeRemoteWrite,
+				RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: "prometheus"},
+			},
+		},
+	},
+	{
+		Type:        FrameOutputTypeProcessed,
+		Description: "apply processors to a copy of frame passed to an output, e.g. to encrypt fields sent to external systems",
+		Example: ProcessedOutputConfig{
+			Processors: []*FrameProcessorConfig{{
+				Type:                FrameProcessorTypeEncryptFields,
+				EncryptFieldsConfig: &EncryptFieldsFrameProcessorConfig{UID: "field-key", FieldNames: []string{"email"}},
+			}},
+			Outputter: &FrameOutputterConfig{
+				Type:                FrameOutputTypeWebhook,
+				WebhookOutputConfig: &WebhookOutputConfig{UID: "partner-webhook"},
+			},
+		},
+	},
+	{
+		Type:        FrameOutputTypeGeoJSON,
+		Description: "keep recent points as a rolling GeoJSON entity for Geomap layers",
+		Example: GeoJSONOutputConfig{
+			UID:                "fleet-positions",
+			KeyField:           "vehicle",
+			MaxPoints:          50,
+			MaxAgeMilliseconds: 900000,
+		},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -66,6 +283,22 @@ var ConvertersRegistry = []EntityInfo{
 		Type:        ConverterTypeJsonFrame,
 		Description: "JSON-encoded Grafana data frame",
 	},
+	{
+		Type:        ConverterTypeCBOR,
+		Description: "CBOR document from constrained devices, automatic or exact field mapping",
+		Example: CBORConverterConfig{
+			Mode: CBORConverterModeExact,
+			Fields: []Field{
+				{Name: "time", Type: data.FieldTypeTime},
+				{Name: "temperature", Type: data.FieldTypeNullableFloat64, Value: "sensor.temp"},
+			},
+		},
+	},
+	{
+		Type:        ConverterTypeNMEA,
+		Description: "NMEA 0183 GGA and RMC sentences into positions",
+		Example:     NMEAConverterConfig{SpeedUnit: NMEASpeedUnitKmh},
+	},
 }
 
 var FrameProcessorsRegistry = []EntityInfo{
@@ -79,6 +312,84 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeFillNull,
+		Description: "fill null or missing fields with constant, previous or interpolated values",
+		Example: FillNullFrameProcessorConfig{
+			Fields: []FillNullFieldConfig{{FieldName: "value", Mode: FillNullModePrevious}},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeJoin,
+		Description: "join frame with last frame of another channel on a shared key field",
+		Example: JoinFrameProcessorConfig{
+			Channel:  "stream/hosts/metadata",
+			KeyField: "host",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeExplode,
+		Description: "explode field with JSON array into multiple rows",
+		Example:     ExplodeFrameProcessorConfig{FieldName: "items"},
+	},
+	{
+		Type:        FrameProcessorTypeScript,
+		Description: "filter rows and calculate fields with CEL expressions",
+		Example: ScriptFrameProcessorConfig{
+			Language: ScriptLanguageCEL,
+			Filter:   `row.value != null`,
+			Fields:   []ScriptFieldConfig{{Name: "value_percent", Expression: `row.value * 100.0`}},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeHistogram,
+		Description: "convert numeric field into cumulative histogram buckets",
+		Example: HistogramFrameProcessorConfig{
+			FieldName:  "latency",
+			Buckets:    []float64{0.1, 0.5, 1, 5},
+			Accumulate: true,
+		},
+	},
+	{
+		Type:        FrameProcessorTypeReshape,
+		Description: "pivot frame between wide and long formats",
+		Example: ReshapeFrameProcessorConfig{
+			Mode:       ReshapeModeToLong,
+			KeyField:   "metric",
+			ValueField: "value",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeIdentityLabels,
+		Description: "add labels with publishing identity attributes to fields",
+		Example: IdentityLabelsFrameProcessorConfig{
+			Labels: map[string]string{"org": "{orgId}", "publisher": "{login}"},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeSessionWindow,
+		Description: "group events into gap based sessions and output closed session summaries",
+		Example: SessionWindowFrameProcessorConfig{
+			KeyField:        "user",
+			GapMilliseconds: 30 * 60 * 1000,
+		},
+	},
+	{
+		Type:        FrameProcessorTypeEncryptFields,
+		Description: "encrypt field values with AES-GCM key of a write config",
+		Example: EncryptFieldsFrameProcessorConfig{
+			UID:        "field-key",
+			FieldNames: []string{"email"},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeValueMap,
+		Description: "map status codes to human-readable values",
+		Example: ValueMapFrameProcessorConfig{
+			FieldNames: []string{"status"},
+			Mappings:   map[string]string{"0": "Stopped", "1": "Running", "2": "Fault"},
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// RelabelConfig is a Prometheus relabeling rule, see
+// https://prometheus.io/docs/prometheus/latest/configuration/configuration/#relabel_config.
+// Unset fields have Prometheus defaults.
+type RelabelConfig struct {
+	// SourceLabels to concatenate with Separator and match against Regex.
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+	// Separator is ";" by default.
+	Separator *string `json:"separator,omitempty"`
+	// Regex is an anchored regular expression, "(.*)" by default.
+	Regex *string `json:"regex,omitempty"`
+	// Modulus for hashmod action.
+	Modulus uint64 `json:"modulus,omitempty"`
+	// TargetLabel to write a result of replace, hashmod, lowercase and uppercase actions to.
+	TargetLabel string `json:"targetLabel,omitempty"`
+	// Replacement with regex capture group references, "$1" by default.
+	Replacement *string `json:"replacement,omitempty"`
+	// Action is one of replace, keep, drop, keepequal, dropequal, hashmod, labelmap,
+	// labeldrop, labelkeep, lowercase, uppercase. Default is replace.
+	Action string `json:"action,omitempty"`
+}
+
+// newRelabelConfigs validates relabeling rules and converts them to Prometheus
+// relabel configs.
+func newRelabelConfigs(configs []RelabelConfig) ([]*relabel.Config, error) {
+	result := make([]*relabel.Config, 0, len(configs))
+	for i, c := range configs {
+		rc, err := newRelabelConfig(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid relabel config %d: %w", i, err)
+		}
+		result = append(result, rc)
+	}
+	return result, nil
+}
+
+func newRelabelConfig(c RelabelConfig) (*relabel.Config, error) {
+	rc := relabel.DefaultRelabelConfig
+	if c.Action != "" {
+		rc.Action = relabel.Action(c.Action)
+	}
+	for _, l := range c.SourceLabels {
+		rc.SourceLabels = append(rc.SourceLabels, model.LabelName(l))
+	}
+	if c.Separator != nil {
+		rc.Separator = *c.Separator
+	}
+	if c.Regex != nil {
+		re, err := relabel.NewRegexp(*c.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		rc.Regex = re
+	}
+	if c.Replacement != nil {
+		rc.Replacement = *c.Replacement
+	}
+	rc.Modulus = c.Modulus
+	rc.TargetLabel = c.TargetLabel
+
+	switch rc.Action {
+	case relabel.Replace, relabel.Lowercase, relabel.Uppercase, relabel.KeepEqual, relabel.DropEqual:
+		if rc.TargetLabel == "" {
+			return nil, fmt.Errorf("%s action requires target label", rc.Action)
+		}
+	case relabel.HashMod:
+		if rc.TargetLabel == "" || rc.Modulus == 0 {
+			return nil, fmt.Errorf("%s action requires target label and modulus", rc.Action)
+		}
+	case relabel.Keep, relabel.Drop:
+		if len(rc.SourceLabels) == 0 {
+			return nil, fmt.Errorf("%s action requires source labels", rc.Action)
+		}
+	case relabel.LabelMap, relabel.LabelDrop, relabel.LabelKeep:
+	default:
+		return nil, fmt.Errorf("unknown relabel action: %s", rc.Action)
+	}
+	return &rc, nil
+}
+
+// relabelTimeSeries applies relabeling rules to time series labels, series dropped
+// by rules or left without labels are removed.
+func relabelTimeSeries(timeSeries []prompb.TimeSeries, configs []*relabel.Config) []prompb.TimeSeries {
+	if len(configs) == 0 {
+		return timeSeries
+	}
+	// In-place filtering, see https://github.com/golang/go/wiki/SliceTricks#filter-in-place.
+	n := 0
+	for _, ts := range timeSeries {
+		ls := make([]labels.Label, 0, len(ts.Labels))
+		for _, l := range ts.Labels {
+			ls = append(ls, labels.Label{Name: l.Name, Value: l.Value})
+		}
+		relabeled, keep := relabel.Process(labels.New(ls...), configs...)
+		if !keep || relabeled.IsEmpty() {
+			continue
+		}
+		ts.Labels = make([]prompb.Label, 0, relabeled.Len())
+		relabeled.Range(func(l labels.Label) {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+		})
+		timeSeries[n] = ts
+		n++
+	}
+	return timeSeries[:n]
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelabelTimeSeries(t *testing.T) {
+	configs, err := newRelabelConfigs([]RelabelConfig{
+		{SourceLabels: []string{"__name__"}, Regex: stringPtr("cpu_(.*)"), TargetLabel: "__name__", Replacement: stringPtr("node_cpu_$1")},
+		{SourceLabels: []string{"host"}, Regex: stringPtr("test-.*"), Action: "drop"},
+		{Regex: stringPtr("internal_.*"), Action: "labeldrop"},
+	})
+	require.NoError(t, err)
+
+	timeSeries := []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "cpu_user"},
+				{Name: "host", Value: "a"},
+				{Name: "internal_id", Value: "1"},
+			},
+			Samples: []prompb.Sample{{Timestamp: 1, Value: 1}},
+		},
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "cpu_user"},
+				{Name: "host", Value: "test-b"},
+			},
+			Samples: []prompb.Sample{{Timestamp: 1, Value: 2}},
+		},
+		{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "mem_free"},
+			},
+			Samples: []prompb.Sample{{Timestamp: 1, Value: 3}},
+		},
+	}
+	relabeled := relabelTimeSeries(timeSeries, configs)
+	require.Len(t, relabeled, 2)
+	require.Equal(t, []prompb.Label{
+		{Name: "__name__", Value: "node_cpu_user"},
+		{Name: "host", Value: "a"},
+	}, relabeled[0].Labels)
+	require.Equal(t, []prompb.Sample{{Timestamp: 1, Value: 1}}, relabeled[0].Samples)
+	require.Equal(t, []prompb.Label{{Name: "__name__", Value: "mem_free"}}, relabeled[1].Labels)
+}
+
+func TestNewRelabelConfigs_invalid(t *testing.T) {
+	for _, c := range []RelabelConfig{
+		{Action: "unknown"},
+		{Action: "replace"},
+		{Action: "keep"},
+		{Action: "hashmod", TargetLabel: "shard"},
+		{Regex: stringPtr("("), TargetLabel: "test"},
+	} {
+		_, err := newRelabelConfigs([]RelabelConfig{c})
+		require.Error(t, err, c)
+	}
+}
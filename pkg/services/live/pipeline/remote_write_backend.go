@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// Classes of remote write request failures.
+const (
+	remoteWriteErrorClient  = "4xx"
+	remoteWriteErrorServer  = "5xx"
+	remoteWriteErrorNetwork = "network"
+)
+
+var (
+	remoteWriteBytesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "remote_write_bytes_total",
+		Help:      "A counter for bytes of remote write requests accepted by backends",
+	}, []string{"backend"})
+	remoteWriteSamplesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "remote_write_samples_total",
+		Help:      "A counter for samples accepted by remote write backends",
+	}, []string{"backend"})
+	remoteWriteRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "remote_write_retries_total",
+		Help:      "A counter for failed remote write flushes which are retried later",
+	}, []string{"backend"})
+	remoteWriteErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "remote_write_errors_total",
+		Help:      "A counter for failed remote write requests by class: 4xx, 5xx or network",
+	}, []string{"backend", "class"})
+	remoteWriteQueueSamples = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "remote_write_queue_samples",
+		Help:      "Number of samples waiting to be sent to remote write backends",
+	}, []string{"backend"})
+)
+
+func init() {
+	prometheus.MustRegister(remoteWriteBytesSent, remoteWriteSamplesSent, remoteWriteRetries, remoteWriteErrors, remoteWriteQueueSamples)
+}
+
+// RemoteWriteBackendHealth is a state of a remote write backend since start.
+type RemoteWriteBackendHealth struct {
+	UID string `json:"uid"`
+	// Healthy is false when the last request to a backend failed.
+	Healthy       bool      `json:"healthy"`
+	LastSuccess   time.Time `json:"lastSuccess,omitempty"`
+	LastError     time.Time `json:"lastError,omitempty"`
+	LastErrorText string    `json:"lastErrorText,omitempty"`
+	BytesSent     int64     `json:"bytesSent"`
+	SamplesSent   int64     `json:"samplesSent"`
+	Retries       int64     `json:"retries"`
+	ClientErrors  int64     `json:"clientErrors"`
+	ServerErrors  int64     `json:"serverErrors"`
+	NetworkErrors int64     `json:"networkErrors"`
+	QueuedSamples int64     `json:"queuedSamples"`
+}
+
+type remoteWriteBackendKey struct {
+	orgID int64
+	uid   string
+}
+
+// remoteWriteBackends keeps health of remote write backends of all organizations.
+// Several outputs can write to the same backend, they share its state.
+type remoteWriteBackends struct {
+	mu       sync.Mutex
+	backends map[remoteWriteBackendKey]*RemoteWriteBackendHealth
+}
+
+// RemoteWriteBackends tracks remote write backends of outputs built from write configs.
+var RemoteWriteBackends = &remoteWriteBackends{backends: map[remoteWriteBackendKey]*RemoteWriteBackendHealth{}}
+
+// List returns health of remote write backends of an organization ordered by UID.
+func (b *remoteWriteBackends) List(orgID int64) []RemoteWriteBackendHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := make([]RemoteWriteBackendHealth, 0)
+	for key, h := range b.backends {
+		if key.orgID == orgID {
+			result = append(result, *h)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UID < result[j].UID })
+	return result
+}
+
+func (b *remoteWriteBackends) update(orgID int64, uid string, fn func(h *RemoteWriteBackendHealth)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := remoteWriteBackendKey{orgID: orgID, uid: uid}
+	h, ok := b.backends[key]
+	if !ok {
+		h = &RemoteWriteBackendHealth{UID: uid, Healthy: true}
+		b.backends[key] = h
+	}
+	fn(h)
+}
+
+// remoteWriteBackend reports activity of a remote write output to metrics and
+// backend health. Zero value does nothing, so outputs without a write config
+// (e.g. in tests) are not tracked.
+type remoteWriteBackend struct {
+	orgID int64
+	uid   string
+	// queued is a number of samples of an output reported to the queue gauge.
+	queued int
+}
+
+func (b *remoteWriteBackend) tracked() bool {
+	return b.uid != ""
+}
+
+func (b *remoteWriteBackend) sent(bytes int, samples int) {
+	if !b.tracked() {
+		return
+	}
+	remoteWriteBytesSent.WithLabelValues(b.uid).Add(float64(bytes))
+	remoteWriteSamplesSent.WithLabelValues(b.uid).Add(float64(samples))
+	RemoteWriteBackends.update(b.orgID, b.uid, func(h *RemoteWriteBackendHealth) {
+		h.Healthy = true
+		h.LastSuccess = time.Now()
+		h.BytesSent += int64(bytes)
+		h.SamplesSent += int64(samples)
+	})
+}
+
+func (b *remoteWriteBackend) failed(class string, err error) {
+	if !b.tracked() {
+		return
+	}
+	remoteWriteErrors.WithLabelValues(b.uid, class).Inc()
+	RemoteWriteBackends.update(b.orgID, b.uid, func(h *RemoteWriteBackendHealth) {
+		h.Healthy = false
+		h.LastError = time.Now()
+		h.LastErrorText = err.Error()
+		switch class {
+		case remoteWriteErrorClient:
+			h.ClientErrors++
+		case remoteWriteErrorServer:
+			h.ServerErrors++
+		default:
+			h.NetworkErrors++
+		}
+	})
+}
+
+func (b *remoteWriteBackend) retry() {
+	if !b.tracked() {
+		return
+	}
+	remoteWriteRetries.WithLabelValues(b.uid).Inc()
+	RemoteWriteBackends.update(b.orgID, b.uid, func(h *RemoteWriteBackendHealth) {
+		h.Retries++
+	})
+}
+
+// setQueued reports a number of samples buffered by an output, must be called with
+// output mutex held.
+func (b *remoteWriteBackend) setQueued(samples int) {
+	if !b.tracked() || samples == b.queued {
+		return
+	}
+	delta := samples - b.queued
+	b.queued = samples
+	remoteWriteQueueSamples.WithLabelValues(b.uid).Add(float64(delta))
+	RemoteWriteBackends.update(b.orgID, b.uid, func(h *RemoteWriteBackendHealth) {
+		h.QueuedSamples += int64(delta)
+	})
+}
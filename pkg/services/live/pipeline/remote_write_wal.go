@@ -0,0 +1,456 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+const (
+	walSegmentMaxBytes  = 16 * 1024 * 1024
+	walCheckpointFile   = "checkpoint"
+	walRecordHeaderSize = 8
+	walSegmentNameLen   = 8
+)
+
+// errRemoteWriteDuplicate is returned by remote write flush when an endpoint rejects
+// samples as duplicate or out of order, i.e. they were already written before.
+var errRemoteWriteDuplicate = errors.New("remote write endpoint rejected duplicate samples")
+
+// walPosition is a position of a record in WAL segments.
+type walPosition struct {
+	Segment int   `json:"segment"`
+	Offset  int64 `json:"offset"`
+}
+
+func (p walPosition) before(other walPosition) bool {
+	return p.Segment < other.Segment || (p.Segment == other.Segment && p.Offset < other.Offset)
+}
+
+// remoteWriteWAL is a write-ahead log of time series of a remote write backend. Series
+// are appended as records to segment files, and a checkpoint is advanced only after a
+// batch is acknowledged by the backend. Unacknowledged records are sent again after
+// restart, backends rejecting duplicate samples make the delivery exactly-once.
+//
+// Record format: 4 bytes of payload length, 4 bytes of payload CRC32 (both big endian)
+// and a protobuf encoded prompb.WriteRequest payload.
+type remoteWriteWAL struct {
+	dir string
+
+	// mu guards the active segment.
+	mu      sync.Mutex
+	segment *os.File
+	end     walPosition
+
+	// flushMu serializes reading records and checkpointing, so a batch is never sent
+	// twice by concurrent flushes.
+	flushMu    sync.Mutex
+	checkpoint walPosition
+
+	// outputsMu guards outputs using the WAL. A single flusher runs while there are
+	// outputs, the last added output sends records, so rule rebuilds don't start
+	// more flushers.
+	outputsMu sync.Mutex
+	outputs   []*RemoteWriteFrameOutput
+	flushCh   chan struct{}
+	done      chan struct{}
+}
+
+var (
+	walsMu sync.Mutex
+	// wals are shared by outputs of the same backend, as outputs are recreated on
+	// each rule reload.
+	wals = map[string]*remoteWriteWAL{}
+)
+
+// openRemoteWriteWAL opens a WAL in a directory, creating it when needed.
+func openRemoteWriteWAL(dir string) (*remoteWriteWAL, error) {
+	walsMu.Lock()
+	defer walsMu.Unlock()
+	if w, ok := wals[dir]; ok {
+		return w, nil
+	}
+	if err := os.MkdirAll(dir, fileDirPerm); err != nil {
+		return nil, fmt.Errorf("error creating WAL directory: %w", err)
+	}
+	w := &remoteWriteWAL{dir: dir, flushCh: make(chan struct{}, 1)}
+	if err := w.readCheckpoint(); err != nil {
+		return nil, err
+	}
+	segments, err := w.segments()
+	if err != nil {
+		return nil, err
+	}
+	last := w.checkpoint.Segment
+	if len(segments) > 0 && segments[len(segments)-1] > last {
+		last = segments[len(segments)-1]
+	}
+	if err := w.repairSegment(last); err != nil {
+		return nil, err
+	}
+	if err := w.openSegment(last); err != nil {
+		return nil, err
+	}
+	wals[dir] = w
+	return w, nil
+}
+
+// addOutput makes an output send WAL records, the flusher is started with the first output.
+func (w *remoteWriteWAL) addOutput(out *RemoteWriteFrameOutput) {
+	w.outputsMu.Lock()
+	defer w.outputsMu.Unlock()
+	w.outputs = append(w.outputs, out)
+	if w.done == nil {
+		w.done = make(chan struct{})
+		go w.flushPeriodically(w.done)
+	}
+}
+
+// removeOutput stops the flusher when the last output is removed.
+func (w *remoteWriteWAL) removeOutput(out *RemoteWriteFrameOutput) {
+	w.outputsMu.Lock()
+	defer w.outputsMu.Unlock()
+	for i, o := range w.outputs {
+		if o == out {
+			w.outputs = append(w.outputs[:i], w.outputs[i+1:]...)
+			break
+		}
+	}
+	if len(w.outputs) == 0 && w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+}
+
+// sender returns the last added output and resets queued samples of all outputs, as
+// all records are sent by the next flush.
+func (w *remoteWriteWAL) sender() *RemoteWriteFrameOutput {
+	w.outputsMu.Lock()
+	defer w.outputsMu.Unlock()
+	for _, out := range w.outputs {
+		out.mu.Lock()
+		out.numSamples = 0
+		out.backend.setQueued(0)
+		out.mu.Unlock()
+	}
+	if len(w.outputs) == 0 {
+		return nil
+	}
+	return w.outputs[len(w.outputs)-1]
+}
+
+// requestFlush flushes records before the flush interval passes.
+func (w *remoteWriteWAL) requestFlush() {
+	select {
+	case w.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// flushPeriodically sends records after the checkpoint with settings of the last added
+// output until done is closed.
+func (w *remoteWriteWAL) flushPeriodically(done chan struct{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		case <-w.flushCh:
+		}
+		out := w.sender()
+		if out == nil {
+			continue
+		}
+		ticker.Reset(out.flushInterval())
+		if err := w.sync(); err != nil {
+			logger.Error("Error syncing remote write WAL", "error", err)
+		}
+		if err := w.flush(out.flush, out.MaxBatchSamples); err != nil {
+			logger.Error("Error flush to remote write", "error", err)
+			out.backend.retry()
+		}
+	}
+}
+
+func (w *remoteWriteWAL) segmentPath(segment int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%0*d", walSegmentNameLen, segment))
+}
+
+// segments returns numbers of existing segment files in ascending order.
+func (w *remoteWriteWAL) segments() ([]int, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading WAL directory: %w", err)
+	}
+	var segments []int
+	for _, e := range entries {
+		if e.IsDir() || len(e.Name()) != walSegmentNameLen {
+			continue
+		}
+		n, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		segments = append(segments, n)
+	}
+	sort.Ints(segments)
+	return segments, nil
+}
+
+// repairSegment truncates a torn record written by a crash at the end of the last
+// segment, so new records are appended after the last valid one.
+func (w *remoteWriteWAL) repairSegment(segment int) error {
+	info, err := os.Stat(w.segmentPath(segment))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading WAL segment: %w", err)
+	}
+	end, err := w.readSegment(walPosition{Segment: segment}, info.Size(), func([]prompb.TimeSeries) bool { return true })
+	if err != nil {
+		return err
+	}
+	if end.Offset < info.Size() {
+		logger.Warn("Truncating torn WAL segment", "dir", w.dir, "segment", segment, "size", info.Size(), "validSize", end.Offset)
+		if err := os.Truncate(w.segmentPath(segment), end.Offset); err != nil {
+			return fmt.Errorf("error truncating WAL segment: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *remoteWriteWAL) openSegment(segment int) error {
+	// #nosec G304 -- segment path is built from a configured directory.
+	f, err := os.OpenFile(w.segmentPath(segment), fileOpenFlags, fileWritePerm)
+	if err != nil {
+		return fmt.Errorf("error opening WAL segment: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error opening WAL segment: %w", err)
+	}
+	w.segment = f
+	w.end = walPosition{Segment: segment, Offset: info.Size()}
+	return nil
+}
+
+// append writes time series as a single record.
+func (w *remoteWriteWAL) append(timeSeries []prompb.TimeSeries) error {
+	payload, err := proto.Marshal(&prompb.WriteRequest{Timeseries: timeSeries})
+	if err != nil {
+		return fmt.Errorf("error encoding WAL record: %w", err)
+	}
+	record := make([]byte, walRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[walRecordHeaderSize:], payload)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.end.Offset > 0 && w.end.Offset+int64(len(record)) > walSegmentMaxBytes {
+		if err := w.segment.Sync(); err != nil {
+			return fmt.Errorf("error syncing WAL segment: %w", err)
+		}
+		if err := w.segment.Close(); err != nil {
+			return fmt.Errorf("error closing WAL segment: %w", err)
+		}
+		if err := w.openSegment(w.end.Segment + 1); err != nil {
+			return err
+		}
+	}
+	n, err := w.segment.Write(record)
+	w.end.Offset += int64(n)
+	if err != nil {
+		return fmt.Errorf("error writing WAL record: %w", err)
+	}
+	return nil
+}
+
+// sync flushes the active segment to disk.
+func (w *remoteWriteWAL) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.segment.Sync()
+}
+
+// read returns time series of records after a position, up to maxSamples samples
+// when it's positive, and a position after the last returned record. A torn record
+// at the end of a segment (e.g. after a crash) ends the segment.
+func (w *remoteWriteWAL) read(from walPosition, maxSamples int) ([]prompb.TimeSeries, walPosition, error) {
+	w.mu.Lock()
+	end := w.end
+	w.mu.Unlock()
+
+	var timeSeries []prompb.TimeSeries
+	numSamples := 0
+	pos := from
+	for pos.before(end) {
+		limit := int64(-1)
+		if pos.Segment == end.Segment {
+			limit = end.Offset
+		}
+		next, err := w.readSegment(pos, limit, func(ts []prompb.TimeSeries) bool {
+			timeSeries = append(timeSeries, ts...)
+			numSamples += countSamples(ts)
+			return maxSamples <= 0 || numSamples < maxSamples
+		})
+		if err != nil {
+			return nil, from, err
+		}
+		if next == pos || (maxSamples > 0 && numSamples >= maxSamples) {
+			pos = next
+			break
+		}
+		pos = next
+	}
+	return timeSeries, pos, nil
+}
+
+// readSegment reads records of a segment from a position until limit offset (end of
+// file when negative) or until fn returns false. The returned position points to the
+// next segment when the segment was read to its end.
+func (w *remoteWriteWAL) readSegment(from walPosition, limit int64, fn func([]prompb.TimeSeries) bool) (walPosition, error) {
+	// #nosec G304 -- segment path is built from a configured directory.
+	f, err := os.Open(w.segmentPath(from.Segment))
+	if errors.Is(err, os.ErrNotExist) {
+		return walPosition{Segment: from.Segment + 1}, nil
+	}
+	if err != nil {
+		return from, fmt.Errorf("error opening WAL segment: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Seek(from.Offset, io.SeekStart); err != nil {
+		return from, fmt.Errorf("error reading WAL segment: %w", err)
+	}
+
+	pos := from
+	header := make([]byte, walRecordHeaderSize)
+	for limit < 0 || pos.Offset < limit {
+		if _, err := io.ReadFull(f, header); err != nil {
+			break
+		}
+		size := binary.BigEndian.Uint32(header[0:4])
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(f, payload); err != nil {
+			break
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			logger.Warn("Skipping corrupted WAL segment tail", "dir", w.dir, "segment", pos.Segment, "offset", pos.Offset)
+			break
+		}
+		var req prompb.WriteRequest
+		if err := proto.Unmarshal(payload, &req); err != nil {
+			return pos, fmt.Errorf("error decoding WAL record: %w", err)
+		}
+		pos.Offset += int64(walRecordHeaderSize) + int64(size)
+		if !fn(req.Timeseries) {
+			return pos, nil
+		}
+	}
+	if limit >= 0 {
+		// Active segment, more records can be appended.
+		return pos, nil
+	}
+	return walPosition{Segment: from.Segment + 1}, nil
+}
+
+// flush sends records after the checkpoint in batches of maxSamples, advancing the
+// checkpoint after each acknowledged batch.
+func (w *remoteWriteWAL) flush(send func([]prompb.TimeSeries) error, maxSamples int) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	for {
+		timeSeries, next, err := w.read(w.checkpoint, maxSamples)
+		if err != nil {
+			return err
+		}
+		if len(timeSeries) > 0 {
+			if err := send(timeSeries); err != nil && !errors.Is(err, errRemoteWriteDuplicate) {
+				return err
+			}
+		}
+		if next == w.checkpoint {
+			return nil
+		}
+		if err := w.saveCheckpoint(next); err != nil {
+			return err
+		}
+		if len(timeSeries) == 0 {
+			return nil
+		}
+	}
+}
+
+func (w *remoteWriteWAL) readCheckpoint() error {
+	body, err := os.ReadFile(filepath.Join(w.dir, walCheckpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading WAL checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(body, &w.checkpoint); err != nil {
+		return fmt.Errorf("error decoding WAL checkpoint: %w", err)
+	}
+	return nil
+}
+
+// saveCheckpoint atomically replaces the checkpoint file and removes segments which
+// were sent completely.
+func (w *remoteWriteWAL) saveCheckpoint(pos walPosition) error {
+	body, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(w.dir, walCheckpointFile)
+	tmp := path + ".tmp"
+	// #nosec G304 -- checkpoint path is built from a configured directory.
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileWritePerm)
+	if err != nil {
+		return fmt.Errorf("error writing WAL checkpoint: %w", err)
+	}
+	_, err = f.Write(body)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing WAL checkpoint: %w", err)
+	}
+	w.checkpoint = pos
+
+	segments, err := w.segments()
+	if err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		if segment >= pos.Segment {
+			break
+		}
+		if err := os.Remove(w.segmentPath(segment)); err != nil {
+			logger.Warn("Error removing WAL segment", "dir", w.dir, "segment", segment, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+)
+
+func walTestSeries(value float64) []prompb.TimeSeries {
+	return []prompb.TimeSeries{{
+		Labels:  []prompb.Label{{Name: "__name__", Value: "test"}},
+		Samples: []prompb.Sample{{Value: value, Timestamp: int64(value)}},
+	}}
+}
+
+func walValues(timeSeries []prompb.TimeSeries) []float64 {
+	var values []float64
+	for _, ts := range timeSeries {
+		for _, s := range ts.Samples {
+			values = append(values, s.Value)
+		}
+	}
+	return values
+}
+
+func reopenWAL(t *testing.T, dir string) *remoteWriteWAL {
+	t.Helper()
+	walsMu.Lock()
+	if w, ok := wals[dir]; ok {
+		_ = w.segment.Close()
+		delete(wals, dir)
+	}
+	walsMu.Unlock()
+	w, err := openRemoteWriteWAL(dir)
+	require.NoError(t, err)
+	return w
+}
+
+func TestRemoteWriteWAL_checkpoint(t *testing.T) {
+	dir := t.TempDir()
+	w := reopenWAL(t, dir)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, w.append(walTestSeries(float64(i))))
+	}
+
+	// Failed batch does not advance checkpoint.
+	var sent []float64
+	err := w.flush(func(ts []prompb.TimeSeries) error { return errors.New("503") }, 2)
+	require.Error(t, err)
+
+	require.NoError(t, w.flush(func(ts []prompb.TimeSeries) error {
+		sent = append(sent, walValues(ts)...)
+		return nil
+	}, 2))
+	require.Equal(t, []float64{1, 2, 3}, sent)
+
+	// After restart only new records are sent.
+	w = reopenWAL(t, dir)
+	require.NoError(t, w.append(walTestSeries(4)))
+	sent = nil
+	require.NoError(t, w.flush(func(ts []prompb.TimeSeries) error {
+		sent = append(sent, walValues(ts)...)
+		return errRemoteWriteDuplicate
+	}, 0))
+	require.Equal(t, []float64{4}, sent)
+	require.Equal(t, w.end, w.checkpoint)
+}
+
+func TestRemoteWriteWAL_unacknowledgedAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	w := reopenWAL(t, dir)
+	require.NoError(t, w.append(walTestSeries(1)))
+	require.NoError(t, w.append(walTestSeries(2)))
+
+	// Torn record written during a crash is truncated.
+	_, err := w.segment.Write([]byte{0, 0, 1})
+	require.NoError(t, err)
+
+	w = reopenWAL(t, dir)
+	require.NoError(t, w.append(walTestSeries(3)))
+	var sent []float64
+	require.NoError(t, w.flush(func(ts []prompb.TimeSeries) error {
+		sent = append(sent, walValues(ts)...)
+		return nil
+	}, 0))
+	require.Equal(t, []float64{1, 2, 3}, sent)
+}
+
+func TestRemoteWriteWAL_segments(t *testing.T) {
+	dir := t.TempDir()
+	w := reopenWAL(t, dir)
+	require.NoError(t, w.append(walTestSeries(1)))
+	// Force rotation of the active segment.
+	w.end.Offset = walSegmentMaxBytes
+	require.NoError(t, w.append(walTestSeries(2)))
+	require.Equal(t, 1, w.end.Segment)
+
+	var sent []float64
+	require.NoError(t, w.flush(func(ts []prompb.TimeSeries) error {
+		sent = append(sent, walValues(ts)...)
+		return nil
+	}, 0))
+	require.Equal(t, []float64{1, 2}, sent)
+
+	// Sent segment is removed.
+	_, err := os.Stat(w.segmentPath(0))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,130 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+const (
+	rolloutPathNew = "new"
+	rolloutPathOld = "old"
+)
+
+var (
+	rolloutChannelLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rollout_rule_lookups_total",
+		Help:      "A counter for rule lookups of rolled out rules by path, old path means the rule was skipped",
+	}, []string{"rollout", "path"})
+	rolloutFrames = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rollout_output_frames_total",
+		Help:      "A counter for frames of rollout outputs by path",
+	}, []string{"rollout", "path"})
+	rolloutErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rollout_output_errors_total",
+		Help:      "A counter for errors of rollout outputs by path",
+	}, []string{"rollout", "path"})
+	rolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rollout_output_duration_seconds",
+		Help:      "A histogram of rollout output durations by path",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+	}, []string{"rollout", "path"})
+)
+
+func init() {
+	prometheus.MustRegister(rolloutChannelLookups, rolloutFrames, rolloutErrors, rolloutDuration)
+}
+
+// Rollout selects a stable percentage of channels. A channel stays selected when the
+// percentage grows, so a rollout can be widened without moving channels back.
+type Rollout struct {
+	Name string
+	// threshold is a number of hash buckets out of 10000 in the rollout.
+	threshold uint64
+}
+
+func NewRollout(config RolloutConfig, defaultName string) (*Rollout, error) {
+	if config.Percentage < 0 || config.Percentage > 100 {
+		return nil, fmt.Errorf("rollout percentage must be between 0 and 100: %v", config.Percentage)
+	}
+	name := config.Name
+	if name == "" {
+		name = defaultName
+	}
+	return &Rollout{Name: name, threshold: uint64(config.Percentage * 100)}, nil
+}
+
+// Includes returns true when a channel of an organization is in the rollout.
+func (r *Rollout) Includes(orgID int64, channel string) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(r.Name + "\x00" + strconv.FormatInt(orgID, 10) + "\x00" + channel))
+	return h.Sum64()%10000 < r.threshold
+}
+
+// lookup returns true when a channel is in the rollout and counts rule lookups.
+func (r *Rollout) lookup(orgID int64, channel string) bool {
+	included := r.Includes(orgID, channel)
+	rolloutChannelLookups.WithLabelValues(r.Name, rolloutPath(included)).Inc()
+	return included
+}
+
+func rolloutPath(included bool) string {
+	if included {
+		return rolloutPathNew
+	}
+	return rolloutPathOld
+}
+
+// RolloutOutput sends frames of channels in a rollout to Outputter and frames of other
+// channels to Fallback, frames, errors and durations of both paths are counted so
+// they can be compared during the rollout.
+type RolloutOutput struct {
+	Rollout   *Rollout
+	Outputter FrameOutputter
+	Fallback  FrameOutputter
+}
+
+func NewRolloutOutput(rollout *Rollout, outputter FrameOutputter, fallback FrameOutputter) *RolloutOutput {
+	return &RolloutOutput{Rollout: rollout, Outputter: outputter, Fallback: fallback}
+}
+
+const FrameOutputTypeRollout = "rollout"
+
+func (out *RolloutOutput) Type() string {
+	return FrameOutputTypeRollout
+}
+
+func (out *RolloutOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	included := out.Rollout.Includes(vars.OrgID, vars.Channel)
+	next := out.Fallback
+	if included {
+		next = out.Outputter
+	}
+	if next == nil {
+		return nil, nil
+	}
+	path := rolloutPath(included)
+	started := time.Now()
+	frames, err := next.OutputFrame(ctx, vars, frame)
+	rolloutDuration.WithLabelValues(out.Rollout.Name, path).Observe(time.Since(started).Seconds())
+	rolloutFrames.WithLabelValues(out.Rollout.Name, path).Inc()
+	if err != nil {
+		rolloutErrors.WithLabelValues(out.Rollout.Name, path).Inc()
+	}
+	return frames, err
+}
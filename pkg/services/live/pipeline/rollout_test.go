@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRollout(t *testing.T) {
+	r, err := NewRollout(RolloutConfig{Percentage: 10}, "stream/telegraf/:metric")
+	require.NoError(t, err)
+	require.Equal(t, "stream/telegraf/:metric", r.Name)
+
+	_, err = NewRollout(RolloutConfig{Percentage: 101}, "")
+	require.Error(t, err)
+	_, err = NewRollout(RolloutConfig{Percentage: -1}, "")
+	require.Error(t, err)
+}
+
+func TestRollout_Includes(t *testing.T) {
+	none, err := NewRollout(RolloutConfig{Name: "test", Percentage: 0}, "")
+	require.NoError(t, err)
+	half, err := NewRollout(RolloutConfig{Name: "test", Percentage: 50}, "")
+	require.NoError(t, err)
+	more, err := NewRollout(RolloutConfig{Name: "test", Percentage: 80}, "")
+	require.NoError(t, err)
+	all, err := NewRollout(RolloutConfig{Name: "test", Percentage: 100}, "")
+	require.NoError(t, err)
+
+	included := 0
+	for i := 0; i < 1000; i++ {
+		channel := fmt.Sprintf("stream/test/%d", i)
+		require.False(t, none.Includes(1, channel))
+		require.True(t, all.Includes(1, channel))
+		require.Equal(t, half.Includes(1, channel), half.Includes(1, channel))
+		if half.Includes(1, channel) {
+			included++
+			// Channels stay in a rollout when its percentage grows.
+			require.True(t, more.Includes(1, channel))
+		}
+	}
+	require.InDelta(t, 500, included, 60)
+}
+
+func TestRolloutOutput(t *testing.T) {
+	rollout, err := NewRollout(RolloutConfig{Name: "test", Percentage: 50}, "")
+	require.NoError(t, err)
+	newOut := &generatorTestOutputter{frames: make(chan *data.Frame, 1)}
+	oldOut := &generatorTestOutputter{frames: make(chan *data.Frame, 1)}
+	out := NewRolloutOutput(rollout, newOut, oldOut)
+
+	var in, notIn string
+	for i := 0; in == "" || notIn == ""; i++ {
+		channel := fmt.Sprintf("stream/test/%d", i)
+		if rollout.Includes(1, channel) {
+			in = channel
+		} else {
+			notIn = channel
+		}
+	}
+
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: in}, frame)
+	require.NoError(t, err)
+	require.Len(t, newOut.frames, 1)
+	require.Len(t, oldOut.frames, 0)
+	<-newOut.frames
+
+	_, err = out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: notIn}, frame)
+	require.NoError(t, err)
+	require.Len(t, newOut.frames, 0)
+	require.Len(t, oldOut.frames, 1)
+
+	// Frames of channels outside the rollout are dropped without fallback.
+	out = NewRolloutOutput(rollout, newOut, nil)
+	frames, err := out.OutputFrame(context.Background(), Vars{OrgID: 1, Channel: notIn}, frame)
+	require.NoError(t, err)
+	require.Nil(t, frames)
+	require.Len(t, newOut.frames, 0)
+}
+
+type rolloutTestBuilder struct {
+	percentage float64
+}
+
+func (b *rolloutTestBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	rollout, err := NewRollout(RolloutConfig{Percentage: b.percentage}, "stream/test/:id")
+	if err != nil {
+		return nil, err
+	}
+	return []*LiveChannelRule{{OrgId: 1, Pattern: "stream/test/:id", Rollout: rollout}}, nil
+}
+
+func TestCacheSegmentedTree_GetRollout(t *testing.T) {
+	s := NewCacheSegmentedTree(&rolloutTestBuilder{percentage: 0})
+	_, ok, err := s.Get(1, "stream/test/1")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	s = NewCacheSegmentedTree(&rolloutTestBuilder{percentage: 100})
+	rule, ok, err := s.Get(1, "stream/test/1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "stream/test/:id", rule.Pattern)
+}
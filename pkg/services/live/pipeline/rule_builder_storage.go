@@ -2,10 +2,18 @@ package pipeline
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/centrifugal/centrifuge"
 
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/secrets"
 )
@@ -17,6 +25,23 @@ type StorageRuleBuilder struct {
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
+	// FileOutputDir is a directory file outputs write into.
+	FileOutputDir string
+	// RemoteWriteWALDir is a directory remote write WALs are kept in.
+	RemoteWriteWALDir string
+	// DebugFrames keeps frames captured by debug outputs.
+	DebugFrames *DebugFrameBuffer
+	// AnnotationsRepo and DashboardService are used by annotation outputs.
+	AnnotationsRepo  annotations.Repository
+	DashboardService dashboards.DashboardService
+	// ContactPoints is used by alert notification outputs, nil when alerting is disabled.
+	ContactPoints ContactPointNotifier
+	// JSONSchemas is used by JSON auto converters referencing a schema entity.
+	JSONSchemas JSONSchemaGetter
+	// LookupTables is used by value map processors referencing a lookup table entity.
+	LookupTables LookupTableGetter
+	// GeoJSON is used by geojson outputs.
+	GeoJSON GeoJSONWriter
 }
 
 func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscriber, error) {
@@ -48,7 +73,7 @@ func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscr
 	}
 }
 
-func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converter, error) {
+func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig, locale *LocaleConfig) (Converter, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -58,7 +83,20 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 		if config.AutoJsonConverterConfig == nil {
 			config.AutoJsonConverterConfig = &AutoJsonConverterConfig{}
 		}
-		return NewAutoJsonConverter(*config.AutoJsonConverterConfig), nil
+		converter := NewAutoJsonConverter(*config.AutoJsonConverterConfig)
+		if locale != nil {
+			var err error
+			converter, err = NewAutoJsonConverterWithLocale(*config.AutoJsonConverterConfig, *locale)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if config.AutoJsonConverterConfig.Schema != "" {
+			if err := converter.useJSONSchema(f.JSONSchemas); err != nil {
+				return nil, err
+			}
+		}
+		return converter, nil
 	case ConverterTypeJsonFrame:
 		if config.JsonFrameConverterConfig == nil {
 			config.JsonFrameConverterConfig = &JsonFrameConverterConfig{}
@@ -69,12 +107,30 @@ func (f *StorageRuleBuilder) extractConverter(config *ConverterConfig) (Converte
 			return nil, missingConfiguration
 		}
 		return NewAutoInfluxConverter(*config.AutoInfluxConverterConfig), nil
+	case ConverterTypeCBOR:
+		if config.CBORConverterConfig == nil {
+			config.CBORConverterConfig = &CBORConverterConfig{}
+		}
+		converter, err := NewCBORConverter(*config.CBORConverterConfig, locale)
+		if err != nil {
+			return nil, err
+		}
+		return converter, nil
+	case ConverterTypeNMEA:
+		if config.NMEAConverterConfig == nil {
+			config.NMEAConverterConfig = &NMEAConverterConfig{}
+		}
+		converter, err := NewNMEAConverter(*config.NMEAConverterConfig)
+		if err != nil {
+			return nil, err
+		}
+		return converter, nil
 	default:
 		return nil, fmt.Errorf("unknown converter type: %s", config.Type)
 	}
 }
 
-func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig) (FrameProcessor, error) {
+func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig, writeConfigs []WriteConfig) (FrameProcessor, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -90,6 +146,88 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			return nil, missingConfiguration
 		}
 		return NewKeepFieldsFrameProcessor(*config.KeepFieldsProcessorConfig), nil
+	case FrameProcessorTypeFillNull:
+		if config.FillNullProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewFillNullFrameProcessor(f.FrameStorage, *config.FillNullProcessorConfig), nil
+	case FrameProcessorTypeJoin:
+		if config.JoinProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewJoinFrameProcessor(f.FrameStorage, *config.JoinProcessorConfig), nil
+	case FrameProcessorTypeExplode:
+		if config.ExplodeProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewExplodeFrameProcessor(*config.ExplodeProcessorConfig), nil
+	case FrameProcessorTypeScript:
+		if config.ScriptProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		processor, err := NewScriptFrameProcessor(*config.ScriptProcessorConfig)
+		if err != nil {
+			return nil, err
+		}
+		return processor, nil
+	case FrameProcessorTypeHistogram:
+		if config.HistogramProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		processor, err := NewHistogramFrameProcessor(f.FrameStorage, *config.HistogramProcessorConfig)
+		if err != nil {
+			return nil, err
+		}
+		return processor, nil
+	case FrameProcessorTypeReshape:
+		if config.ReshapeProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewReshapeFrameProcessor(*config.ReshapeProcessorConfig), nil
+	case FrameProcessorTypeIdentityLabels:
+		if config.IdentityLabelsConfig == nil {
+			return nil, missingConfiguration
+		}
+		processor, err := NewIdentityLabelsFrameProcessor(*config.IdentityLabelsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return processor, nil
+	case FrameProcessorTypeSessionWindow:
+		if config.SessionWindowConfig == nil {
+			return nil, missingConfiguration
+		}
+		processor, err := NewSessionWindowFrameProcessor(f.FrameStorage, *config.SessionWindowConfig)
+		if err != nil {
+			return nil, err
+		}
+		return processor, nil
+	case FrameProcessorTypeValueMap:
+		if config.ValueMapConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewValueMapFrameProcessor(f.LookupTables, *config.ValueMapConfig)
+	case FrameProcessorTypeEncryptFields:
+		if config.EncryptFieldsConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.EncryptFieldsConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.EncryptFieldsConfig.UID)
+		}
+		encodedKey, err := f.decryptSecureSetting(writeConfig, "encryptionKey")
+		if err != nil {
+			return nil, err
+		}
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		processor, err := NewEncryptFieldsFrameProcessor(key, *config.EncryptFieldsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return processor, nil
 	case FrameProcessorTypeMultiple:
 		if config.MultipleProcessorConfig == nil {
 			return nil, missingConfiguration
@@ -97,7 +235,7 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 		var processors []FrameProcessor
 		for _, outConf := range config.MultipleProcessorConfig.Processors {
 			out := outConf
-			proc, err := f.extractFrameProcessor(&out)
+			proc, err := f.extractFrameProcessor(&out, writeConfigs)
 			if err != nil {
 				return nil, err
 			}
@@ -121,11 +259,60 @@ func (f *StorageRuleBuilder) extractFrameConditionChecker(config *FrameCondition
 		}
 		c := *config.NumberCompareConditionConfig
 		return NewFrameNumberCompareCondition(c.FieldName, c.Op, c.Value), nil
+	case FrameConditionCheckerTypeRegexMatch:
+		if config.RegexMatchConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		c := *config.RegexMatchConditionConfig
+		return NewFrameRegexMatchCondition(c.FieldName, c.Pattern)
+	case FrameConditionCheckerTypeFieldPresent:
+		if config.FieldPresentConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewFrameFieldPresentCondition(config.FieldPresentConditionConfig.FieldName), nil
+	case FrameConditionCheckerTypeIsNull:
+		if config.IsNullConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		c := *config.IsNullConditionConfig
+		return NewFrameIsNullCondition(c.FieldName, c.NotNull), nil
+	case FrameConditionCheckerTypeSchedule:
+		if config.ScheduleConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewFrameScheduleCondition(*config.ScheduleConditionConfig)
+	case FrameConditionCheckerTypeLabelCompare:
+		if config.LabelCompareConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		c := *config.LabelCompareConditionConfig
+		return NewFrameLabelCompareCondition(c.FieldName, c.Label, c.Op, c.Value)
+	case FrameConditionCheckerTypeValueChanged:
+		if config.ValueChangedConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewFrameValueChangedCondition(f.FrameStorage, config.ValueChangedConditionConfig.FieldName), nil
+	case FrameConditionCheckerTypeExpression:
+		if config.ExpressionConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewFrameExpressionCondition(*config.ExpressionConditionConfig)
+	case FrameConditionCheckerTypeFrameSize:
+		if config.FrameSizeConditionConfig == nil {
+			return nil, missingConfiguration
+		}
+		c := *config.FrameSizeConditionConfig
+		return NewFrameSizeCondition(c.Measure, c.Op, c.Value)
 	case FrameConditionCheckerTypeMultiple:
 		var conditions []FrameConditionChecker
 		if config.MultipleConditionCheckerConfig == nil {
 			return nil, missingConfiguration
 		}
+		switch config.MultipleConditionCheckerConfig.ConditionType {
+		case ConditionAll, ConditionAny, ConditionNone, "":
+		default:
+			return nil, fmt.Errorf("unknown condition type: %s", config.MultipleConditionCheckerConfig.ConditionType)
+		}
 		for _, outConf := range config.MultipleConditionCheckerConfig.Conditions {
 			out := outConf
 			cond, err := f.extractFrameConditionChecker(&out)
@@ -144,19 +331,13 @@ func (f *StorageRuleBuilder) constructBasicAuth(writeConfig WriteConfig) (*Basic
 	if writeConfig.Settings.BasicAuth == nil {
 		return nil, nil
 	}
-	var password string
-	hasSecurePassword := len(writeConfig.SecureSettings["basicAuthPassword"]) > 0
-	if hasSecurePassword {
-		passwordBytes, err := f.SecretsService.Decrypt(context.Background(), writeConfig.SecureSettings["basicAuthPassword"])
-		if err != nil {
-			return nil, fmt.Errorf("basicAuthPassword can't be decrypted: %w", err)
-		}
-		password = string(passwordBytes)
-	} else {
-		// Use plain text password (should be removed upon database integration).
-		if writeConfig.Settings.BasicAuth != nil {
-			password = writeConfig.Settings.BasicAuth.Password
-		}
+	password, err := f.decryptSecureSetting(writeConfig, SecureSettingBasicAuthPassword)
+	if err != nil {
+		return nil, err
+	}
+	if password == "" {
+		// Plain text password of write configs saved before secure settings.
+		password = writeConfig.Settings.BasicAuth.Password
 	}
 	return &BasicAuth{
 		User:     writeConfig.Settings.BasicAuth.User,
@@ -164,6 +345,20 @@ func (f *StorageRuleBuilder) constructBasicAuth(writeConfig WriteConfig) (*Basic
 	}, nil
 }
 
+// decryptSecureSetting returns a decrypted secure setting of write config, empty
+// string when the setting is not set.
+func (f *StorageRuleBuilder) decryptSecureSetting(writeConfig WriteConfig, key string) (string, error) {
+	encrypted := writeConfig.SecureSettings[key]
+	if len(encrypted) == 0 {
+		return "", nil
+	}
+	decrypted, err := f.SecretsService.Decrypt(context.Background(), encrypted)
+	if err != nil {
+		return "", fmt.Errorf("%s can't be decrypted: %w", key, err)
+	}
+	return string(decrypted), nil
+}
+
 func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig, writeConfigs []WriteConfig) (FrameOutputter, error) {
 	if config == nil {
 		return nil, nil
@@ -174,7 +369,7 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		if config.RedirectOutputConfig == nil {
 			return nil, missingConfiguration
 		}
-		return NewRedirectFrameOutput(*config.RedirectOutputConfig), nil
+		return NewRedirectFrameOutput(*config.RedirectOutputConfig)
 	case FrameOutputTypeMultiple:
 		if config.MultipleOutputterConfig == nil {
 			return nil, missingConfiguration
@@ -188,9 +383,18 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			}
 			outputters = append(outputters, outputter)
 		}
-		return NewMultipleFrameOutput(outputters...), nil
+		output := NewMultipleFrameOutput(outputters...)
+		output.ContinueOnError = config.MultipleOutputterConfig.ContinueOnError
+		return output, nil
 	case FrameOutputTypeManagedStream:
-		return NewManagedStreamFrameOutput(f.ManagedStream), nil
+		out := NewManagedStreamFrameOutput(f.ManagedStream)
+		if config.ManagedStreamConfig != nil {
+			if err := validateManagedStreamOutputConfig(*config.ManagedStreamConfig); err != nil {
+				return nil, err
+			}
+			out.SchemaValidation = config.ManagedStreamConfig.SchemaValidation
+		}
+		return out, nil
 	case FrameOutputTypeLocalSubscribers:
 		return NewLocalSubscribersFrameOutput(f.Node), nil
 	case FrameOutputTypeConditional:
@@ -206,28 +410,93 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, err
 		}
 		return NewConditionalOutput(condition, outputter), nil
+	case FrameOutputTypeRollout:
+		if config.RolloutOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		if config.RolloutOutputConfig.Name == "" {
+			return nil, errors.New("rollout name is required")
+		}
+		rollout, err := NewRollout(config.RolloutOutputConfig.RolloutConfig, "")
+		if err != nil {
+			return nil, err
+		}
+		outputter, err := f.extractFrameOutputter(config.RolloutOutputConfig.Outputter, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		var fallback FrameOutputter
+		if config.RolloutOutputConfig.Fallback != nil {
+			fallback, err = f.extractFrameOutputter(config.RolloutOutputConfig.Fallback, writeConfigs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return NewRolloutOutput(rollout, outputter, fallback), nil
 	case FrameOutputTypeThreshold:
 		if config.ThresholdOutputConfig == nil {
 			return nil, missingConfiguration
 		}
+		if config.ThresholdOutputConfig.Hysteresis < 0 || config.ThresholdOutputConfig.ForMilliseconds < 0 {
+			return nil, errors.New("threshold hysteresis and for duration can't be negative")
+		}
 		return NewThresholdOutput(f.FrameStorage, *config.ThresholdOutputConfig), nil
 	case FrameOutputTypeRemoteWrite:
 		if config.RemoteWriteOutputConfig == nil {
 			return nil, missingConfiguration
 		}
-		writeConfig, ok := f.getWriteConfig(config.RemoteWriteOutputConfig.UID, writeConfigs)
-		if !ok {
-			return nil, fmt.Errorf("unknown write config uid: %s", config.RemoteWriteOutputConfig.UID)
+		var writeConfig WriteConfig
+		if ref := config.RemoteWriteOutputConfig.Backend; ref != nil {
+			var ok bool
+			writeConfig, ok = resolveWriteConfigRef(*ref, writeConfigs)
+			if !ok {
+				return nil, fmt.Errorf("unknown write config name: %s (org %d)", ref.Name, ref.OrgID)
+			}
+		} else {
+			var ok bool
+			writeConfig, ok = f.getWriteConfig(config.RemoteWriteOutputConfig.UID, writeConfigs)
+			if !ok {
+				return nil, fmt.Errorf("unknown write config uid: %s", config.RemoteWriteOutputConfig.UID)
+			}
 		}
 		basicAuth, err := f.constructBasicAuth(writeConfig)
 		if err != nil {
 			return nil, fmt.Errorf("error getting password: %w", err)
 		}
-		return NewRemoteWriteFrameOutput(
+		relabelConfigs, err := newRelabelConfigs(config.RemoteWriteOutputConfig.RelabelConfigs)
+		if err != nil {
+			return nil, err
+		}
+		out := NewRemoteWriteFrameOutput(
 			writeConfig.Settings.Endpoint,
 			basicAuth,
 			config.RemoteWriteOutputConfig.SampleMilliseconds,
-		), nil
+		)
+		out.BearerToken, err = f.decryptSecureSetting(writeConfig, SecureSettingBearerToken)
+		if err != nil {
+			return nil, err
+		}
+		out.RelabelConfigs = relabelConfigs
+		if err := out.SetLabels(config.RemoteWriteOutputConfig.Labels); err != nil {
+			return nil, err
+		}
+		out.FlushInterval = time.Duration(config.RemoteWriteOutputConfig.FlushIntervalMilliseconds) * time.Millisecond
+		out.MaxBatchSamples = config.RemoteWriteOutputConfig.MaxBatchSamples
+		out.MaxBufferSamples = config.RemoteWriteOutputConfig.MaxBufferSamples
+		out.backend = remoteWriteBackend{orgID: writeConfig.OrgId, uid: writeConfig.UID}
+		if config.RemoteWriteOutputConfig.WAL {
+			if f.RemoteWriteWALDir == "" {
+				return nil, errors.New("remote write WAL directory is not configured")
+			}
+			if writeConfig.UID == "." || writeConfig.UID == ".." || strings.ContainsAny(writeConfig.UID, `/\`) {
+				return nil, fmt.Errorf("write config uid can't be used as WAL directory: %s", writeConfig.UID)
+			}
+			dir := filepath.Join(f.RemoteWriteWALDir, strconv.FormatInt(writeConfig.OrgId, 10), writeConfig.UID)
+			if err := out.useWAL(dir); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
 	case FrameOutputTypeLoki:
 		if config.LokiOutputConfig == nil {
 			return nil, missingConfiguration
@@ -248,7 +517,384 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 		if config.ChangeLogOutputConfig == nil {
 			return nil, missingConfiguration
 		}
+		if err := validateChangeLogConfig(*config.ChangeLogOutputConfig); err != nil {
+			return nil, err
+		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeElasticsearch:
+		if config.ElasticsearchConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.ElasticsearchConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.ElasticsearchConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		apiKey, err := f.decryptSecureSetting(writeConfig, "apiKey")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewElasticsearchFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			apiKey,
+			config.ElasticsearchConfig.Index,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeInflux:
+		if config.InfluxOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.InfluxOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.InfluxOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		token, err := f.decryptSecureSetting(writeConfig, "token")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewInfluxFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			token,
+			*config.InfluxOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypePostgres:
+		if config.PostgresOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.PostgresOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.PostgresOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		output, err := NewPostgresFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			*config.PostgresOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeNATS:
+		if config.NATSOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.NATSOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.NATSOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		token, err := f.decryptSecureSetting(writeConfig, "token")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewNATSFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			token,
+			*config.NATSOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeWebhook:
+		if config.WebhookOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.WebhookOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.WebhookOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		token, err := f.decryptSecureSetting(writeConfig, "token")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewWebhookFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			token,
+			*config.WebhookOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeS3:
+		if config.S3OutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.S3OutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.S3OutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		output, err := NewS3FrameOutput(writeConfig.Settings.Endpoint, basicAuth, *config.S3OutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeFile:
+		if config.FileOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		output, err := NewFileFrameOutput(f.FileOutputDir, *config.FileOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeDebug:
+		if config.DebugOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		output, err := NewDebugFrameOutput(f.DebugFrames, *config.DebugOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeAnnotation:
+		if config.AnnotationOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		var dashboardService dashboardGetter
+		if f.DashboardService != nil {
+			dashboardService = f.DashboardService
+		}
+		output, err := NewAnnotationFrameOutput(f.AnnotationsRepo, dashboardService, *config.AnnotationOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeAlertNotification:
+		if config.AlertNotificationConfig == nil {
+			return nil, missingConfiguration
+		}
+		output, err := NewAlertNotificationFrameOutput(f.ContactPoints, *config.AlertNotificationConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeForward:
+		if config.ForwardOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.ForwardOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.ForwardOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		token, err := f.decryptSecureSetting(writeConfig, "token")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewForwardFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			token,
+			*config.ForwardOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeRetry:
+		if config.RetryOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		outputter, err := f.extractFrameOutputter(config.RetryOutputConfig.Outputter, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		if outputter == nil {
+			return nil, missingConfiguration
+		}
+		output, err := NewRetryOutput(outputter, *config.RetryOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeAMQP:
+		if config.AMQPOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.AMQPOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.AMQPOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		output, err := NewAMQPFrameOutput(
+			writeConfig.Settings.Endpoint,
+			basicAuth,
+			*config.AMQPOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeEventHubs:
+		if config.EventHubsOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.EventHubsOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.EventHubsOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		connectionString, err := f.decryptSecureSetting(writeConfig, "connectionString")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewEventHubsFrameOutput(
+			writeConfig.Settings.Endpoint,
+			connectionString,
+			basicAuth,
+			*config.EventHubsOutputConfig,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypePubSub:
+		if config.PubSubOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.PubSubOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.PubSubOutputConfig.UID)
+		}
+		serviceAccountKey, err := f.decryptSecureSetting(writeConfig, "serviceAccountKey")
+		if err != nil {
+			return nil, err
+		}
+		output, err := NewPubSubFrameOutput(writeConfig.Settings.Endpoint, serviceAccountKey, *config.PubSubOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypePushgateway:
+		if config.PushgatewayOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, ok := f.getWriteConfig(config.PushgatewayOutputConfig.UID, writeConfigs)
+		if !ok {
+			return nil, fmt.Errorf("unknown write config uid: %s", config.PushgatewayOutputConfig.UID)
+		}
+		basicAuth, err := f.constructBasicAuth(writeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("error getting password: %w", err)
+		}
+		output, err := NewPushgatewayFrameOutput(writeConfig.Settings.Endpoint, basicAuth, *config.PushgatewayOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
+	case FrameOutputTypeProcessed:
+		if config.ProcessedOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		outputter, err := f.extractFrameOutputter(config.ProcessedOutputConfig.Outputter, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		if outputter == nil {
+			return nil, missingConfiguration
+		}
+		var processors []FrameProcessor
+		for _, procConfig := range config.ProcessedOutputConfig.Processors {
+			proc, err := f.extractFrameProcessor(procConfig, writeConfigs)
+			if err != nil {
+				return nil, err
+			}
+			if proc != nil {
+				processors = append(processors, proc)
+			}
+		}
+		return NewProcessedOutput(outputter, processors...), nil
+	case FrameOutputTypeGeoJSON:
+		if config.GeoJSONOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		if f.GeoJSON == nil {
+			return nil, errors.New("geojson store is not available")
+		}
+		if err := validateGeoJSONOutputConfig(*config.GeoJSONOutputConfig); err != nil {
+			return nil, err
+		}
+		return NewGeoJSONOutput(f.GeoJSON, *config.GeoJSONOutputConfig), nil
+	case FrameOutputTypeFailureAlert:
+		if config.FailureAlertOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		outputter, err := f.extractFrameOutputter(config.FailureAlertOutputConfig.Outputter, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		if outputter == nil {
+			return nil, missingConfiguration
+		}
+		var (
+			endpoint  string
+			basicAuth *BasicAuth
+			token     string
+		)
+		if uid := config.FailureAlertOutputConfig.WebhookUID; uid != "" {
+			writeConfig, ok := f.getWriteConfig(uid, writeConfigs)
+			if !ok {
+				return nil, fmt.Errorf("unknown write config uid: %s", uid)
+			}
+			basicAuth, err = f.constructBasicAuth(writeConfig)
+			if err != nil {
+				return nil, fmt.Errorf("error getting password: %w", err)
+			}
+			token, err = f.decryptSecureSetting(writeConfig, "token")
+			if err != nil {
+				return nil, err
+			}
+			endpoint = writeConfig.Settings.Endpoint
+		}
+		output, err := NewFailureAlertOutput(f.FrameStorage, outputter, endpoint, basicAuth, token, f.ContactPoints, *config.FailureAlertOutputConfig)
+		if err != nil {
+			return nil, err
+		}
+		return output, nil
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -292,23 +938,74 @@ func (f *StorageRuleBuilder) extractDataOutputter(config *DataOutputterConfig, w
 
 func (f *StorageRuleBuilder) getWriteConfig(uid string, writeConfigs []WriteConfig) (WriteConfig, bool) {
 	for _, rwb := range writeConfigs {
-		if rwb.UID == uid {
+		// Shared write configs of other organizations are only referenced by name.
+		if rwb.UID == uid && !rwb.shared {
 			return rwb, true
 		}
 	}
 	return WriteConfig{}, false
 }
 
+// resolveWriteConfigRef finds a write config by name in the rule organization, or in
+// the referenced organization when it shares the config with the rule one.
+func resolveWriteConfigRef(ref WriteConfigRef, writeConfigs []WriteConfig) (WriteConfig, bool) {
+	for _, c := range writeConfigs {
+		if c.Name != ref.Name {
+			continue
+		}
+		if c.shared {
+			if c.OrgId == ref.OrgID {
+				return c, true
+			}
+			continue
+		}
+		// Write configs without organization belong to the main organization.
+		if ref.OrgID == 0 || ruleOrgID(c.OrgId) == ref.OrgID {
+			return c, true
+		}
+	}
+	return WriteConfig{}, false
+}
+
+func (f *StorageRuleBuilder) extractHeartbeat(config HeartbeatConfig, writeConfigs []WriteConfig) (*Heartbeat, error) {
+	if err := validateHeartbeatConfig(config); err != nil {
+		return nil, err
+	}
+	heartbeat := &Heartbeat{Timeout: time.Duration(config.TimeoutMilliseconds) * time.Millisecond}
+	for _, outConfig := range config.Outputs {
+		out, err := f.extractFrameOutputter(outConfig, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		heartbeat.FrameOutputters = append(heartbeat.FrameOutputters, out)
+	}
+	return heartbeat, nil
+}
+
 func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*LiveChannelRule, error) {
 	channelRules, err := f.Storage.ListChannelRules(ctx, orgID)
 	if err != nil {
 		return nil, err
 	}
+	if unjoined := unjoinedChannels(channelRules); len(unjoined) > 0 {
+		return nil, fmt.Errorf("error building processor for %s: join channel %s is not matched by a rule with a join processor",
+			channelRules[unjoined[0].ruleIndex].Pattern, unjoined[0].channel)
+	}
 
 	writeConfigs, err := f.Storage.ListWriteConfigs(ctx, orgID)
 	if err != nil {
 		return nil, err
 	}
+	if lister, ok := f.Storage.(SharedWriteConfigLister); ok {
+		shared, err := lister.ListSharedWriteConfigs(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range shared {
+			c.shared = true
+			writeConfigs = append(writeConfigs, c)
+		}
+	}
 
 	rules := make([]*LiveChannelRule, 0, len(channelRules))
 
@@ -328,14 +1025,46 @@ func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*Li
 
 		var err error
 
-		rule.Converter, err = f.extractConverter(ruleConfig.Settings.Converter)
+		rule.Priority, err = parsePriorityClass(ruleConfig.Settings.Priority)
+		if err != nil {
+			return nil, fmt.Errorf("error building rule %s: %w", rule.Pattern, err)
+		}
+
+		rule.Order = ruleConfig.Settings.Order
+
+		if ruleConfig.Settings.RateLimit != nil {
+			rule.RateLimit, err = newRateLimit(*ruleConfig.Settings.RateLimit)
+			if err != nil {
+				return nil, fmt.Errorf("error building rate limit for %s: %w", rule.Pattern, err)
+			}
+		}
+
+		if ruleConfig.Settings.Generator != nil {
+			if err := checkGeneratorChannel(rule.Pattern); err != nil {
+				return nil, err
+			}
+			if _, err := NewGenerator(*ruleConfig.Settings.Generator); err != nil {
+				return nil, fmt.Errorf("error building rule %s: %w", rule.Pattern, err)
+			}
+		}
+
+		if ruleConfig.Settings.Snapshot != nil {
+			if err := checkSnapshotChannel(rule.Pattern); err != nil {
+				return nil, err
+			}
+			if _, err := parseSnapshotSchedule(*ruleConfig.Settings.Snapshot); err != nil {
+				return nil, fmt.Errorf("error building rule %s: %w", rule.Pattern, err)
+			}
+		}
+
+		rule.Converter, err = f.extractConverter(ruleConfig.Settings.Converter, ruleConfig.Settings.Locale)
 		if err != nil {
 			return nil, fmt.Errorf("error building converter for %s: %w", rule.Pattern, err)
 		}
 
 		var processors []FrameProcessor
 		for _, procConfig := range ruleConfig.Settings.FrameProcessors {
-			proc, err := f.extractFrameProcessor(procConfig)
+			proc, err := f.extractFrameProcessor(procConfig, writeConfigs)
 			if err != nil {
 				return nil, fmt.Errorf("error building processor for %s: %w", rule.Pattern, err)
 			}
@@ -363,6 +1092,27 @@ func (f *StorageRuleBuilder) BuildRules(ctx context.Context, orgID int64) ([]*Li
 		}
 		rule.FrameOutputters = outputters
 
+		if ruleConfig.Settings.DeadLetterOutputter != nil {
+			rule.DeadLetterOutputter, err = f.extractFrameOutputter(ruleConfig.Settings.DeadLetterOutputter, writeConfigs)
+			if err != nil {
+				return nil, fmt.Errorf("error building dead letter outputter for %s: %w", rule.Pattern, err)
+			}
+		}
+
+		if ruleConfig.Settings.Heartbeat != nil {
+			rule.Heartbeat, err = f.extractHeartbeat(*ruleConfig.Settings.Heartbeat, writeConfigs)
+			if err != nil {
+				return nil, fmt.Errorf("error building heartbeat for %s: %w", rule.Pattern, err)
+			}
+		}
+
+		if ruleConfig.Settings.Rollout != nil {
+			rule.Rollout, err = NewRollout(*ruleConfig.Settings.Rollout, rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error building rollout for %s: %w", rule.Pattern, err)
+			}
+		}
+
 		var subscribers []Subscriber
 		for _, subConfig := range ruleConfig.Settings.Subscribers {
 			sub, err := f.extractSubscriber(subConfig)
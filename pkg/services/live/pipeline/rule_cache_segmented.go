@@ -2,7 +2,9 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,18 +13,60 @@ import (
 
 // CacheSegmentedTree provides a fast access to channel rule configuration.
 type CacheSegmentedTree struct {
-	radixMu     sync.RWMutex
-	radix       map[int64]*tree.Node
+	radixMu sync.RWMutex
+	radix   map[int64]*tree.Node
+	rules   map[int64][]*LiveChannelRule
+	// matchers match a channel against each rule pattern separately, they are used
+	// to find all rules matching a channel.
+	matchers map[int64][]ruleMatcher
+	// ordered is true for organizations with rules which can't be resolved by the
+	// tree alone, i.e. rules with an explicit order or a rollout.
+	ordered     map[int64]bool
 	ruleBuilder RuleBuilder
+	// retired are outputs of replaced rules which still had buffered data.
+	retired []Drainer
+
+	closeOnce sync.Once
+	done      chan struct{}
 }
 
 func NewCacheSegmentedTree(storage RuleBuilder) *CacheSegmentedTree {
-	s := &CacheSegmentedTree{
+	s := NewStaticSegmentedTree(storage)
+	go s.updatePeriodically()
+	return s
+}
+
+// NewStaticSegmentedTree returns a tree which builds rules of an organization once and
+// does not update them, it's used to handle single requests. Close must be called when
+// rules are not used anymore to close their outputs.
+func NewStaticSegmentedTree(storage RuleBuilder) *CacheSegmentedTree {
+	return &CacheSegmentedTree{
 		radix:       map[int64]*tree.Node{},
+		rules:       map[int64][]*LiveChannelRule{},
+		matchers:    map[int64][]ruleMatcher{},
+		ordered:     map[int64]bool{},
 		ruleBuilder: storage,
+		done:        make(chan struct{}),
 	}
-	go s.updatePeriodically()
-	return s
+}
+
+// Close stops periodic updates and closes outputs of cached rules.
+func (s *CacheSegmentedTree) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.radixMu.Lock()
+		defer s.radixMu.Unlock()
+		for _, d := range s.retired {
+			if out, ok := d.(FrameOutputter); ok {
+				closeOutput(out)
+			}
+		}
+		s.retired = nil
+		for _, rules := range s.rules {
+			walkRuleOutputs(rules, closeOutput)
+		}
+	})
+	return nil
 }
 
 func (s *CacheSegmentedTree) updatePeriodically() {
@@ -39,10 +83,32 @@ func (s *CacheSegmentedTree) updatePeriodically() {
 				logger.Error("Error filling orgId", "error", err, "orgId", orgID)
 			}
 		}
-		time.Sleep(20 * time.Second)
+		select {
+		case <-time.After(20 * time.Second):
+		case <-s.done:
+			return
+		}
 	}
 }
 
+// Refresh rebuilds rules of all cached organizations at once instead of waiting for
+// the periodic update. Rules of an organization are replaced atomically.
+func (s *CacheSegmentedTree) Refresh() error {
+	s.radixMu.RLock()
+	orgIDs := make([]int64, 0, len(s.radix))
+	for orgID := range s.radix {
+		orgIDs = append(orgIDs, orgID)
+	}
+	s.radixMu.RUnlock()
+	var errs []error
+	for _, orgID := range orgIDs {
+		if err := s.fillOrg(orgID); err != nil {
+			errs = append(errs, fmt.Errorf("org %d: %w", orgID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -52,22 +118,68 @@ func (s *CacheSegmentedTree) fillOrg(orgID int64) error {
 	}
 	s.radixMu.Lock()
 	defer s.radixMu.Unlock()
+	s.retire(s.rules[orgID])
 	s.radix[orgID] = tree.New()
+	matchers := make([]ruleMatcher, 0, len(channels))
+	ordered := false
 	for _, ch := range channels {
 		s.radix[orgID].AddRoute("/"+ch.Pattern, ch)
+		node := tree.New()
+		node.AddRoute("/"+ch.Pattern, ch)
+		matchers = append(matchers, ruleMatcher{rule: ch, node: node})
+		ch.patternNode = node
+		if ch.Order != 0 || ch.Rollout != nil {
+			ordered = true
+		}
 	}
+	s.rules[orgID] = channels
+	s.matchers[orgID] = matchers
+	s.ordered[orgID] = ordered
 	return nil
 }
 
-func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule, bool, error) {
-	s.radixMu.RLock()
-	_, ok := s.radix[orgID]
-	s.radixMu.RUnlock()
-	if !ok {
-		err := s.fillOrg(orgID)
-		if err != nil {
-			return nil, false, fmt.Errorf("error filling org: %w", err)
+type ruleMatcher struct {
+	rule *LiveChannelRule
+	node *tree.Node
+}
+
+// retire closes outputs of replaced rules, as rules are rebuilt periodically. Outputs
+// with buffered data are kept until they send it, so it's not lost on shutdown, and
+// closed afterwards. Must be called with radixMu held.
+func (s *CacheSegmentedTree) retire(rules []*LiveChannelRule) {
+	retired := s.retired[:0]
+	for _, d := range s.retired {
+		if d.Pending() > 0 {
+			retired = append(retired, d)
+		} else if out, ok := d.(FrameOutputter); ok {
+			closeOutput(out)
+		}
+	}
+	walkRuleOutputs(rules, func(out FrameOutputter) {
+		if d, ok := out.(Drainer); ok && d.Pending() > 0 {
+			retired = append(retired, d)
+			return
 		}
+		closeOutput(out)
+	})
+	s.retired = retired
+}
+
+// Drainers returns outputs with buffered data of current and replaced rules.
+func (s *CacheSegmentedTree) Drainers() []Drainer {
+	s.radixMu.RLock()
+	defer s.radixMu.RUnlock()
+	drainers := append([]Drainer(nil), s.retired...)
+	for _, rules := range s.rules {
+		drainers = append(drainers, ruleDrainers(rules)...)
+	}
+	return drainers
+}
+
+// Get returns the first rule matching a channel, see Match.
+func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule, bool, error) {
+	if err := s.ensureOrg(orgID); err != nil {
+		return nil, false, err
 	}
 	s.radixMu.RLock()
 	defer s.radixMu.RUnlock()
@@ -75,9 +187,71 @@ func (s *CacheSegmentedTree) Get(orgID int64, channel string) (*LiveChannelRule,
 	if !ok {
 		return nil, false, nil
 	}
+	if s.ordered[orgID] {
+		rules := s.match(orgID, channel)
+		if len(rules) == 0 {
+			return nil, false, nil
+		}
+		return rules[0], true, nil
+	}
 	nodeValue := t.GetValue("/"+channel, true)
 	if nodeValue.Handler == nil {
 		return nil, false, nil
 	}
 	return nodeValue.Handler.(*LiveChannelRule), true, nil
 }
+
+// Match returns all rules matching a channel in execution order: rules with a lower
+// order first, the most specific pattern first among rules of the same order. Rules
+// which don't include the channel in their rollout are skipped, channels outside
+// rollouts of all matching rules keep default Live behaviour.
+func (s *CacheSegmentedTree) Match(orgID int64, channel string) ([]*LiveChannelRule, error) {
+	if err := s.ensureOrg(orgID); err != nil {
+		return nil, err
+	}
+	s.radixMu.RLock()
+	defer s.radixMu.RUnlock()
+	return s.match(orgID, channel), nil
+}
+
+func (s *CacheSegmentedTree) ensureOrg(orgID int64) error {
+	s.radixMu.RLock()
+	_, ok := s.radix[orgID]
+	s.radixMu.RUnlock()
+	if !ok {
+		err := s.fillOrg(orgID)
+		if err != nil {
+			return fmt.Errorf("error filling org: %w", err)
+		}
+	}
+	return nil
+}
+
+// match must be called with radixMu held.
+func (s *CacheSegmentedTree) match(orgID int64, channel string) []*LiveChannelRule {
+	t, ok := s.radix[orgID]
+	if !ok {
+		return nil
+	}
+	var best *LiveChannelRule
+	if nodeValue := t.GetValue("/"+channel, true); nodeValue.Handler != nil {
+		best = nodeValue.Handler.(*LiveChannelRule)
+	}
+	var rules []*LiveChannelRule
+	for _, m := range s.matchers[orgID] {
+		if m.rule != best && m.node.GetValue("/"+channel, true).Handler == nil {
+			continue
+		}
+		if m.rule.Rollout != nil && !m.rule.Rollout.lookup(orgID, channel) {
+			continue
+		}
+		rules = append(rules, m.rule)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Order != rules[j].Order {
+			return rules[i].Order < rules[j].Order
+		}
+		return rules[i] == best && rules[j] != best
+	})
+	return rules
+}
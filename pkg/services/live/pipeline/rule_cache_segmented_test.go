@@ -2,6 +2,8 @@ package pipeline
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -53,6 +55,71 @@ func TestStorage_Get(t *testing.T) {
 	require.Equal(t, "stream/boom:er", rule.Pattern)
 }
 
+type closingOutput struct {
+	testOutputter
+	closed atomic.Bool
+}
+
+func (o *closingOutput) Close() error {
+	o.closed.Store(true)
+	return nil
+}
+
+type closingOutputBuilder struct {
+	mu      sync.Mutex
+	outputs []*closingOutput
+}
+
+func (b *closingOutputBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := &closingOutput{}
+	b.outputs = append(b.outputs, out)
+	return []*LiveChannelRule{
+		{
+			OrgId:           1,
+			Pattern:         "stream/test/close",
+			FrameOutputters: []FrameOutputter{NewMultipleFrameOutput(out)},
+		},
+	}, nil
+}
+
+func TestStorage_RefreshClosesReplacedOutputs(t *testing.T) {
+	builder := &closingOutputBuilder{}
+	s := NewCacheSegmentedTree(builder)
+	_, ok, err := s.Get(1, "stream/test/close")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, s.Refresh())
+
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	require.GreaterOrEqual(t, len(builder.outputs), 2)
+	for i, out := range builder.outputs {
+		// Only outputs of current rules are open.
+		require.Equal(t, i < len(builder.outputs)-1, out.closed.Load())
+	}
+}
+
+func TestStaticSegmentedTree_Close(t *testing.T) {
+	builder := &closingOutputBuilder{}
+	s := NewStaticSegmentedTree(builder)
+	_, ok, err := s.Get(1, "stream/test/close")
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = s.Get(1, "stream/test/close")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	// Rules are built once and outputs are closed with the tree.
+	require.Len(t, builder.outputs, 1)
+	require.True(t, builder.outputs[0].closed.Load())
+}
+
 func BenchmarkRuleGet(b *testing.B) {
 	s := NewCacheSegmentedTree(&testBuilder{})
 	for i := 0; i < b.N; i++ {
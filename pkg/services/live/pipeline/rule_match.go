@@ -0,0 +1,43 @@
+package pipeline
+
+import "fmt"
+
+// RuleMatchMode defines which of rules matching a channel process its inputs.
+type RuleMatchMode string
+
+const (
+	// RuleMatchFirst processes inputs with the first matching rule only.
+	RuleMatchFirst RuleMatchMode = "first"
+	// RuleMatchAll processes inputs with all matching rules in order. Subscribers,
+	// authorization and other channel settings still come from the first rule.
+	RuleMatchAll RuleMatchMode = "all"
+)
+
+// ParseRuleMatchMode validates a rule match mode, empty means RuleMatchFirst.
+func ParseRuleMatchMode(s string) (RuleMatchMode, error) {
+	switch RuleMatchMode(s) {
+	case "", RuleMatchFirst:
+		return RuleMatchFirst, nil
+	case RuleMatchAll:
+		return RuleMatchAll, nil
+	}
+	return "", fmt.Errorf("unknown rule match mode: %s", s)
+}
+
+// ChannelRulesMatcher is implemented by rule getters which can return all rules
+// matching a channel.
+type ChannelRulesMatcher interface {
+	Match(orgID int64, channel string) ([]*LiveChannelRule, error)
+}
+
+// MatchRules returns rules processing inputs of a channel according to RuleMatch.
+func (p *Pipeline) MatchRules(orgID int64, channel string) ([]*LiveChannelRule, error) {
+	if matcher, ok := p.ruleGetter.(ChannelRulesMatcher); ok && p.RuleMatch == RuleMatchAll {
+		return matcher.Match(orgID, channel)
+	}
+	rule, ok, err := p.ruleGetter.Get(orgID, channel)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []*LiveChannelRule{rule}, nil
+}
@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type testRulesBuilder struct {
+	rules []*LiveChannelRule
+}
+
+func (b *testRulesBuilder) BuildRules(_ context.Context, _ int64) ([]*LiveChannelRule, error) {
+	return b.rules, nil
+}
+
+func matchedPatterns(t *testing.T, s *CacheSegmentedTree, channel string) []string {
+	t.Helper()
+	rules, err := s.Match(1, channel)
+	require.NoError(t, err)
+	var patterns []string
+	for _, rule := range rules {
+		patterns = append(patterns, rule.Pattern)
+	}
+	return patterns
+}
+
+func TestCacheSegmentedTree_Match(t *testing.T) {
+	s := NewCacheSegmentedTree(&testRulesBuilder{rules: []*LiveChannelRule{
+		{OrgId: 1, Pattern: "stream/telegraf/:metric"},
+		{OrgId: 1, Pattern: "stream/telegraf/cpu"},
+		{OrgId: 1, Pattern: "stream/:namespace/cpu"},
+	}})
+	// The most specific pattern goes first without explicit order.
+	require.Equal(t, []string{"stream/telegraf/cpu", "stream/telegraf/:metric", "stream/:namespace/cpu"}, matchedPatterns(t, s, "stream/telegraf/cpu"))
+	require.Equal(t, []string{"stream/telegraf/:metric"}, matchedPatterns(t, s, "stream/telegraf/mem"))
+	require.Empty(t, matchedPatterns(t, s, "plugin/test/cpu"))
+
+	rule, ok, err := s.Get(1, "stream/telegraf/cpu")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "stream/telegraf/cpu", rule.Pattern)
+}
+
+func TestCacheSegmentedTree_MatchOrder(t *testing.T) {
+	s := NewCacheSegmentedTree(&testRulesBuilder{rules: []*LiveChannelRule{
+		{OrgId: 1, Pattern: "stream/telegraf/cpu", Order: 10},
+		{OrgId: 1, Pattern: "stream/telegraf/:metric"},
+		{OrgId: 1, Pattern: "stream/:namespace/cpu", Order: -1},
+	}})
+	require.Equal(t, []string{"stream/:namespace/cpu", "stream/telegraf/:metric", "stream/telegraf/cpu"}, matchedPatterns(t, s, "stream/telegraf/cpu"))
+
+	rule, ok, err := s.Get(1, "stream/telegraf/cpu")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "stream/:namespace/cpu", rule.Pattern)
+}
+
+func TestParseRuleMatchMode(t *testing.T) {
+	mode, err := ParseRuleMatchMode("")
+	require.NoError(t, err)
+	require.Equal(t, RuleMatchFirst, mode)
+
+	mode, err = ParseRuleMatchMode("all")
+	require.NoError(t, err)
+	require.Equal(t, RuleMatchAll, mode)
+
+	_, err = ParseRuleMatchMode("any")
+	require.Error(t, err)
+}
+
+func TestPipeline_ruleMatch(t *testing.T) {
+	specific := &generatorTestOutputter{frames: make(chan *data.Frame, 2)}
+	wildcard := &generatorTestOutputter{frames: make(chan *data.Frame, 2)}
+	s := NewCacheSegmentedTree(&testRulesBuilder{rules: []*LiveChannelRule{
+		{
+			OrgId:           1,
+			Pattern:         "stream/test/cpu",
+			Converter:       &testConverter{"", data.NewFrame("specific")},
+			FrameOutputters: []FrameOutputter{specific},
+		},
+		{
+			OrgId:           1,
+			Pattern:         "stream/test/:metric",
+			Converter:       &testConverter{"", data.NewFrame("wildcard")},
+			FrameOutputters: []FrameOutputter{wildcard},
+		},
+	}})
+	p, err := New(s)
+	require.NoError(t, err)
+
+	ok, err := p.ProcessInput(context.Background(), 1, "stream/test/cpu", []byte(`{}`))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, specific.frames, 1)
+	require.Len(t, wildcard.frames, 0)
+	<-specific.frames
+
+	p.RuleMatch = RuleMatchAll
+	ok, err = p.ProcessInput(context.Background(), 1, "stream/test/cpu", []byte(`{}`))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "specific", (<-specific.frames).Name)
+	require.Equal(t, "wildcard", (<-wildcard.frames).Name)
+}
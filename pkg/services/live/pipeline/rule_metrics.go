@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// Stages of a rule in stage metrics, the type label is the converter, processor or
+// output type.
+const (
+	ruleStageConvert    = "convert"
+	ruleStageProcess    = "process"
+	ruleStageOutput     = "output"
+	ruleStageDataOutput = "dataOutput"
+)
+
+var (
+	ruleInputs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rule_inputs_total",
+		Help:      "A counter for inputs passed to the converter of a rule",
+	}, []string{"pattern"})
+	ruleFramesIn = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rule_frames_in_total",
+		Help:      "A counter for frames entering processors of a rule",
+	}, []string{"pattern"})
+	ruleFramesOut = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rule_frames_out_total",
+		Help:      "A counter for frames passing processors of a rule, these are passed to its outputs",
+	}, []string{"pattern"})
+	ruleFramesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rule_frames_dropped_total",
+		Help:      "A counter for frames dropped by processors of a rule",
+	}, []string{"pattern", "type"})
+	ruleStageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rule_stage_errors_total",
+		Help:      "A counter for errors of rule stages",
+	}, []string{"pattern", "stage", "type"})
+	ruleStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.ExporterName,
+		Subsystem: "live_pipeline",
+		Name:      "rule_stage_duration_seconds",
+		Help:      "A histogram of rule stage durations",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+	}, []string{"pattern", "stage", "type"})
+)
+
+func init() {
+	prometheus.MustRegister(ruleInputs, ruleFramesIn, ruleFramesOut, ruleFramesDropped, ruleStageErrors, ruleStageDuration)
+}
+
+// runStage runs a stage of a rule, labeled when the rule is profiled, observes its
+// duration and error and records it in the message trace.
+func (p *Pipeline) runStage(ctx context.Context, rule *LiveChannelRule, vars Vars, stage string, stageType string, fn func(ctx context.Context) error) error {
+	var err error
+	start := time.Now()
+	p.Profiler.profileStage(ctx, rule, stage+":"+stageType, func(ctx context.Context) {
+		err = fn(ctx)
+	})
+	ruleStageDuration.WithLabelValues(rule.Pattern, stage, stageType).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ruleStageErrors.WithLabelValues(rule.Pattern, stage, stageType).Inc()
+	}
+	p.recordMessage(ctx, rule, vars, stage, stageType, err)
+	return err
+}
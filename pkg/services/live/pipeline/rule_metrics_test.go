@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+type dropProcessor struct{}
+
+func (d *dropProcessor) Type() string {
+	return "drop"
+}
+
+func (d *dropProcessor) ProcessFrame(_ context.Context, _ Vars, _ *data.Frame) (*data.Frame, error) {
+	return nil, nil
+}
+
+func TestPipeline_ruleMetrics(t *testing.T) {
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/metrics/out": {
+				Pattern:         "stream/metrics/out",
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameProcessors: []FrameProcessor{&testProcessor{}},
+				FrameOutputters: []FrameOutputter{&testOutputter{}},
+			},
+			"stream/metrics/drop": {
+				Pattern:         "stream/metrics/drop",
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameProcessors: []FrameProcessor{&dropProcessor{}},
+				FrameOutputters: []FrameOutputter{&testOutputter{}},
+			},
+			"stream/metrics/error": {
+				Pattern:         "stream/metrics/error",
+				Converter:       &testConverter{"", data.NewFrame("test")},
+				FrameOutputters: []FrameOutputter{&testOutputter{err: errors.New("boom")}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err = p.ProcessInput(ctx, 1, "stream/metrics/out", []byte(`{}`))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2.0, testutil.ToFloat64(ruleInputs.WithLabelValues("stream/metrics/out")))
+	require.Equal(t, 2.0, testutil.ToFloat64(ruleFramesIn.WithLabelValues("stream/metrics/out")))
+	require.Equal(t, 2.0, testutil.ToFloat64(ruleFramesOut.WithLabelValues("stream/metrics/out")))
+	require.Equal(t, 0.0, testutil.ToFloat64(ruleStageErrors.WithLabelValues("stream/metrics/out", ruleStageOutput, "test")))
+
+	_, err = p.ProcessInput(ctx, 1, "stream/metrics/drop", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(ruleFramesIn.WithLabelValues("stream/metrics/drop")))
+	require.Equal(t, 0.0, testutil.ToFloat64(ruleFramesOut.WithLabelValues("stream/metrics/drop")))
+	require.Equal(t, 1.0, testutil.ToFloat64(ruleFramesDropped.WithLabelValues("stream/metrics/drop", "drop")))
+
+	_, err = p.ProcessInput(ctx, 1, "stream/metrics/error", []byte(`{}`))
+	require.Error(t, err)
+	require.Equal(t, 1.0, testutil.ToFloat64(ruleStageErrors.WithLabelValues("stream/metrics/error", ruleStageOutput, "test")))
+	require.Equal(t, 0.0, testutil.ToFloat64(ruleStageErrors.WithLabelValues("stream/metrics/error", ruleStageConvert, "test")))
+
+	// Durations are observed for every stage, errors included.
+	for _, stage := range []string{ruleStageConvert, ruleStageProcess, ruleStageOutput} {
+		require.Equal(t, uint64(2), stageDurationCount(t, "stream/metrics/out", stage))
+	}
+	require.Equal(t, uint64(1), stageDurationCount(t, "stream/metrics/error", ruleStageOutput))
+}
+
+func stageDurationCount(t *testing.T, pattern string, stage string) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	require.NoError(t, ruleStageDuration.WithLabelValues(pattern, stage, "test").(prometheus.Histogram).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
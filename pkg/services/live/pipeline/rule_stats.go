@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// RuleStatsNamespace and RuleStatsPath form grafana/pipeline/metrics managed
+	// stream channel rule stats are published to.
+	RuleStatsNamespace = "pipeline"
+	RuleStatsPath      = "metrics"
+)
+
+// RuleStats counts pipeline activity per channel rule, so ingestion health can
+// be observed from Grafana itself. Counters are reset on each Flush.
+type RuleStats struct {
+	mu       sync.Mutex
+	counters map[ruleStatsKey]*ruleCounters
+}
+
+type ruleStatsKey struct {
+	orgID   int64
+	pattern string
+}
+
+type ruleCounters struct {
+	inputs        int64
+	frames        int64
+	rows          int64
+	convertErrors int64
+	processErrors int64
+	outputErrors  int64
+}
+
+// NewRuleStats creates RuleStats.
+func NewRuleStats() *RuleStats {
+	return &RuleStats{counters: map[ruleStatsKey]*ruleCounters{}}
+}
+
+// update changes counters of a rule, it's a no-op for nil RuleStats.
+func (s *RuleStats) update(rule *LiveChannelRule, fn func(c *ruleCounters)) {
+	if s == nil || rule == nil {
+		return
+	}
+	key := ruleStatsKey{orgID: rule.OrgId, pattern: rule.Pattern}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[key]
+	if !ok {
+		c = &ruleCounters{}
+		s.counters[key] = c
+	}
+	fn(c)
+}
+
+// Flush returns a frame per organization with a row of counters per rule accumulated
+// since the previous flush, rules are sorted by pattern.
+func (s *RuleStats) Flush(now time.Time) map[int64]*data.Frame {
+	s.mu.Lock()
+	counters := s.counters
+	s.counters = map[ruleStatsKey]*ruleCounters{}
+	s.mu.Unlock()
+
+	keys := make([]ruleStatsKey, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].pattern < keys[j].pattern
+	})
+
+	frames := map[int64]*data.Frame{}
+	for _, k := range keys {
+		frame, ok := frames[k.orgID]
+		if !ok {
+			frame = data.NewFrame(RuleStatsPath,
+				data.NewField("time", nil, []time.Time{}),
+				data.NewField("pattern", nil, []string{}),
+				data.NewField("inputs", nil, []int64{}),
+				data.NewField("frames", nil, []int64{}),
+				data.NewField("rows", nil, []int64{}),
+				data.NewField("convertErrors", nil, []int64{}),
+				data.NewField("processErrors", nil, []int64{}),
+				data.NewField("outputErrors", nil, []int64{}),
+			)
+			frames[k.orgID] = frame
+		}
+		c := counters[k]
+		frame.AppendRow(now, k.pattern, c.inputs, c.frames, c.rows, c.convertErrors, c.processErrors, c.outputErrors)
+	}
+	return frames
+}
+
+// Run periodically flushes counters and publishes them until context is done.
+func (s *RuleStats) Run(ctx context.Context, interval time.Duration, publish func(ctx context.Context, orgID int64, frame *data.Frame) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			for orgID, frame := range s.Flush(now) {
+				if err := publish(ctx, orgID, frame); err != nil {
+					logger.Error("Error publishing pipeline rule stats", "orgId", orgID, "error", err)
+				}
+			}
+		}
+	}
+}
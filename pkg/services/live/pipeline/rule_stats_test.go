@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_Stats(t *testing.T) {
+	frame := data.NewFrame("test", data.NewField("value", nil, []float64{1, 2}))
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/ok": {
+				OrgId:           1,
+				Pattern:         "stream/test/ok",
+				Converter:       &testConverter{"", frame},
+				FrameOutputters: []FrameOutputter{&testOutputter{}},
+			},
+			"stream/test/fail": {
+				OrgId:           1,
+				Pattern:         "stream/test/fail",
+				Converter:       &testConverter{"", frame},
+				FrameOutputters: []FrameOutputter{&testOutputter{err: errors.New("boom")}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	p.Stats = NewRuleStats()
+
+	for i := 0; i < 2; i++ {
+		_, err = p.ProcessInput(context.Background(), 1, "stream/test/ok", []byte(`{}`))
+		require.NoError(t, err)
+	}
+	_, err = p.ProcessInput(context.Background(), 1, "stream/test/fail", []byte(`{}`))
+	require.Error(t, err)
+
+	now := time.Now()
+	frames := p.Stats.Flush(now)
+	require.Len(t, frames, 1)
+	statsFrame := frames[1]
+	require.Equal(t, 2, statsFrame.Rows())
+	require.Equal(t, []any{now, "stream/test/fail", int64(1), int64(0), int64(0), int64(0), int64(0), int64(1)}, statsFrame.RowCopy(0))
+	require.Equal(t, []any{now, "stream/test/ok", int64(2), int64(2), int64(4), int64(0), int64(0), int64(0)}, statsFrame.RowCopy(1))
+
+	// Counters are reset after flush.
+	require.Empty(t, p.Stats.Flush(now))
+}
@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+)
+
+// SampleGetter returns a sample payload kept in the entity store.
+type SampleGetter interface {
+	GetSample(ctx context.Context, orgID int64, uid string) ([]byte, error)
+}
+
+// SampleResult is an outcome of running a sample payload through its rule.
+type SampleResult struct {
+	Pattern string `json:"pattern"`
+	Sample  string `json:"sample"`
+	Channel string `json:"channel"`
+	Passed  bool   `json:"passed"`
+	// Frames is a number of frames after processing.
+	Frames int    `json:"frames"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RunRuleSamples runs sample payloads of rules through a pipeline built from the same
+// rules and reports results in rule order. Frame outputs are not executed, so samples
+// don't write anywhere.
+func RunRuleSamples(ctx context.Context, p *Pipeline, orgID int64, rules []ChannelRule, samples SampleGetter) []SampleResult {
+	var results []SampleResult
+	for _, rule := range rules {
+		for _, sample := range rule.Settings.Samples {
+			if sample == nil {
+				continue
+			}
+			channel := sample.Channel
+			if channel == "" {
+				channel = rule.Pattern
+			}
+			result := SampleResult{Pattern: rule.Pattern, Sample: sample.Name, Channel: channel}
+			frames, err := runRuleSample(ctx, p, orgID, rule.Pattern, channel, sample.UID, samples)
+			result.Frames = frames
+			switch {
+			case sample.ExpectError:
+				result.Passed = err != nil
+				if err == nil {
+					result.Error = "expected error, sample was processed"
+				}
+			case err != nil:
+				result.Error = err.Error()
+			case sample.ExpectFrames != nil && *sample.ExpectFrames != frames:
+				result.Error = fmt.Sprintf("expected %d frames, got %d", *sample.ExpectFrames, frames)
+			default:
+				result.Passed = true
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+var errSampleRuleMismatch = errors.New("sample channel is handled by another rule")
+
+func runRuleSample(ctx context.Context, p *Pipeline, orgID int64, pattern string, channel string, uid string, samples SampleGetter) (int, error) {
+	body, err := samples.GetSample(ctx, orgID, uid)
+	if err != nil {
+		return 0, fmt.Errorf("can't read sample: %w", err)
+	}
+	rule, ok, err := p.Get(orgID, channel)
+	if err != nil {
+		return 0, err
+	}
+	if !ok || rule.Pattern != pattern {
+		return 0, errSampleRuleMismatch
+	}
+	frames, err := p.RunSample(ctx, rule, orgID, channel, body)
+	return len(frames), err
+}
+
+// RunSample converts a payload with a rule converter and runs frames for the rule
+// channel through rule frame processors. Frames redirected to other channels by the
+// converter are returned as is, outputs are not executed.
+func (p *Pipeline) RunSample(ctx context.Context, rule *LiveChannelRule, orgID int64, channelID string, body []byte) ([]*ChannelFrame, error) {
+	if rule.Converter == nil {
+		return nil, errors.New("rule has no converter")
+	}
+	channelFrames, err := p.DataToChannelFrames(ctx, *rule, orgID, channelID, body)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := live.ParseChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	vars := Vars{
+		OrgID:     orgID,
+		Channel:   channelID,
+		Scope:     ch.Scope,
+		Namespace: ch.Namespace,
+		Path:      ch.Path,
+		Params:    rule.ChannelParams(channelID),
+	}
+	var result []*ChannelFrame
+	for _, channelFrame := range channelFrames {
+		if channelFrame.Channel != "" && channelFrame.Channel != channelID {
+			result = append(result, channelFrame)
+			continue
+		}
+		frame, err := p.runRuleProcessors(ctx, rule, vars, channelFrame.Frame)
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			result = append(result, &ChannelFrame{Channel: channelFrame.Channel, Frame: frame})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testSampleGetter map[string]string
+
+func (g testSampleGetter) GetSample(_ context.Context, _ int64, uid string) ([]byte, error) {
+	body, ok := g[uid]
+	if !ok {
+		return nil, fmt.Errorf("sample not found: %s", uid)
+	}
+	return []byte(body), nil
+}
+
+func TestRunRuleSamples(t *testing.T) {
+	p, err := New(&testRuleGetter{
+		rules: map[string]*LiveChannelRule{
+			"stream/test/json": {
+				Pattern:         "stream/test/json",
+				Converter:       NewAutoJsonConverter(AutoJsonConverterConfig{}),
+				FrameProcessors: []FrameProcessor{NewDropFieldsFrameProcessor(DropFieldsFrameProcessorConfig{FieldNames: []string{"debug"}})},
+			},
+			"stream/test/other": {Pattern: "stream/test/*"},
+		},
+	})
+	require.NoError(t, err)
+
+	two := 2
+	rules := []ChannelRule{{
+		Pattern: "stream/test/json",
+		Settings: ChannelRuleSettings{
+			Samples: []*RuleSampleConfig{
+				{Name: "valid", UID: "valid"},
+				{Name: "invalid", UID: "invalid", ExpectError: true},
+				{Name: "wrong frames", UID: "valid", ExpectFrames: &two},
+				{Name: "broken", UID: "invalid"},
+				{Name: "missing", UID: "missing"},
+				{Name: "other rule", UID: "valid", Channel: "stream/test/other"},
+			},
+		},
+	}}
+	samples := testSampleGetter{
+		"valid":   `{"value": 1, "debug": "x"}`,
+		"invalid": `not json`,
+	}
+
+	results := RunRuleSamples(context.Background(), p, 1, rules, samples)
+	require.Len(t, results, 6)
+	require.True(t, results[0].Passed, results[0].Error)
+	require.Equal(t, 1, results[0].Frames)
+	require.True(t, results[1].Passed, results[1].Error)
+	require.False(t, results[2].Passed)
+	require.Equal(t, "expected 2 frames, got 1", results[2].Error)
+	require.False(t, results[3].Passed)
+	require.False(t, results[4].Passed)
+	require.Contains(t, results[4].Error, "sample not found")
+	require.False(t, results[5].Passed)
+	require.Equal(t, errSampleRuleMismatch.Error(), results[5].Error)
+}
+
+func TestPipeline_RunSample(t *testing.T) {
+	p, err := New(&testRuleGetter{})
+	require.NoError(t, err)
+	rule := &LiveChannelRule{
+		Converter:       NewAutoJsonConverter(AutoJsonConverterConfig{}),
+		FrameProcessors: []FrameProcessor{NewDropFieldsFrameProcessor(DropFieldsFrameProcessorConfig{FieldNames: []string{"debug"}})},
+	}
+	frames, err := p.RunSample(context.Background(), rule, 1, "stream/test/json", []byte(`{"value": 1, "debug": "x"}`))
+	require.NoError(t, err)
+	require.Len(t, frames, 1)
+	for _, f := range frames[0].Frame.Fields {
+		require.NotEqual(t, "debug", f.Name)
+	}
+
+	_, err = p.RunSample(context.Background(), &LiveChannelRule{}, 1, "stream/test/json", nil)
+	require.Error(t, err)
+}
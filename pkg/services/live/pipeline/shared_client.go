@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"io"
+	"sync"
+)
+
+// sharedClients shares clients of outputs by key, e.g. connection pools of the same
+// database, as outputs are recreated on each rule rebuild. A client is closed when the
+// last output using it is closed.
+type sharedClients[T io.Closer] struct {
+	mu      sync.Mutex
+	clients map[string]*sharedClient[T]
+}
+
+type sharedClient[T io.Closer] struct {
+	client T
+	refs   int
+}
+
+// acquire returns a client for a key, open is called when there is no client yet.
+// Each acquire must be followed by release once the client is not used.
+func (s *sharedClients[T]) acquire(key string, open func() (T, error)) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.clients[key]; ok {
+		c.refs++
+		return c.client, nil
+	}
+	client, err := open()
+	if err != nil {
+		return client, err
+	}
+	if s.clients == nil {
+		s.clients = map[string]*sharedClient[T]{}
+	}
+	s.clients[key] = &sharedClient[T]{client: client, refs: 1}
+	return client, nil
+}
+
+// release closes a client of a key when it's not used by other outputs.
+func (s *sharedClients[T]) release(key string) {
+	s.mu.Lock()
+	c, ok := s.clients[key]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	c.refs--
+	if c.refs > 0 {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.clients, key)
+	s.mu.Unlock()
+	if err := c.client.Close(); err != nil {
+		logger.Error("Error closing live pipeline output client", "error", err)
+	}
+}
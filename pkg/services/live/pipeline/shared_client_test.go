@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	closed bool
+}
+
+func (c *testClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSharedClients(t *testing.T) {
+	var clients sharedClients[*testClient]
+	numOpened := 0
+	open := func() (*testClient, error) {
+		numOpened++
+		return &testClient{}, nil
+	}
+
+	c1, err := clients.acquire("a", open)
+	require.NoError(t, err)
+	c2, err := clients.acquire("a", open)
+	require.NoError(t, err)
+	require.Same(t, c1, c2)
+	require.Equal(t, 1, numOpened)
+
+	clients.release("a")
+	require.False(t, c1.closed)
+	clients.release("a")
+	require.True(t, c1.closed)
+
+	c3, err := clients.acquire("a", open)
+	require.NoError(t, err)
+	require.NotSame(t, c1, c3)
+	require.Equal(t, 2, numOpened)
+	clients.release("a")
+}
@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrPipelineShutdown is returned for inputs received after the pipeline was shut down.
+var ErrPipelineShutdown = errors.New("live pipeline is shut down")
+
+// Drainer is implemented by frame outputs sending buffered data in background.
+type Drainer interface {
+	// Pending returns a number of buffered items not sent yet.
+	Pending() int
+	// Drain sends buffered items and returns a number of items which were not sent.
+	Drain(ctx context.Context) (int, error)
+}
+
+// DrainerLister is implemented by rule getters which know frame outputs of rules,
+// including outputs of replaced rules which still have buffered data.
+type DrainerLister interface {
+	Drainers() []Drainer
+}
+
+// ShutdownReport describes work which was lost when the pipeline was shut down.
+type ShutdownReport struct {
+	// InFlight is a number of inputs still processed when the timeout passed.
+	InFlight int `json:"inFlight"`
+	// Dropped is a number of buffered items outputs could not send by output type.
+	Dropped map[string]int `json:"dropped,omitempty"`
+}
+
+// Lost returns true when any work was lost on shutdown.
+func (r ShutdownReport) Lost() bool {
+	return r.InFlight > 0 || len(r.Dropped) > 0
+}
+
+// Shutdown stops accepting inputs, waits for in-flight inputs to be processed and
+// drains buffered data of outputs until ctx is done. In-flight inputs are waited for
+// half of the time at most, so outputs are drained even when inputs are stuck. Inputs
+// and items which were not processed or sent in time are reported.
+func (p *Pipeline) Shutdown(ctx context.Context) ShutdownReport {
+	p.inFlight.close()
+	report := ShutdownReport{Dropped: map[string]int{}}
+	waitCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(ctx, time.Now().Add(time.Until(deadline)/2))
+		defer cancel()
+	}
+	report.InFlight = p.inFlight.wait(waitCtx)
+
+	if lister, ok := p.ruleGetter.(DrainerLister); ok {
+		for _, d := range lister.Drainers() {
+			if d.Pending() == 0 {
+				continue
+			}
+			dropped, err := d.Drain(ctx)
+			if err != nil {
+				logger.Error("Error draining live pipeline output", "error", err)
+			}
+			if dropped > 0 {
+				outputType := "unknown"
+				if out, ok := d.(FrameOutputter); ok {
+					outputType = out.Type()
+				}
+				report.Dropped[outputType] += dropped
+			}
+		}
+	}
+	if len(report.Dropped) == 0 {
+		report.Dropped = nil
+	}
+	return report
+}
+
+// inputTracker counts inputs being processed, no inputs are accepted once closed.
+type inputTracker struct {
+	mu     sync.Mutex
+	count  int
+	closed bool
+}
+
+func (c *inputTracker) start() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.count++
+	return true
+}
+
+func (c *inputTracker) done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count--
+}
+
+func (c *inputTracker) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+// wait returns when no inputs are in-flight or ctx is done, a number of inputs
+// still in-flight is returned.
+func (c *inputTracker) wait(ctx context.Context) int {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		c.mu.Lock()
+		count := c.count
+		c.mu.Unlock()
+		if count == 0 {
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			return count
+		case <-ticker.C:
+		}
+	}
+}
+
+// runWithContext returns when fn returns or ctx is done, fn keeps running in background then.
+func runWithContext(ctx context.Context, fn func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ruleDrainers returns outputs of rules with buffered data, including nested outputs.
+func ruleDrainers(rules []*LiveChannelRule) []Drainer {
+	var drainers []Drainer
+	walkRuleOutputs(rules, func(out FrameOutputter) {
+		if d, ok := out.(Drainer); ok {
+			drainers = append(drainers, d)
+		}
+	})
+	return drainers
+}
+
+// closeOutput stops background work of an output of a replaced rule. Outputs running
+// goroutines or holding connections implement io.Closer, Close must not block as it's
+// called while rules are swapped.
+func closeOutput(out FrameOutputter) {
+	c, ok := out.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := c.Close(); err != nil {
+		logger.Error("Error closing live pipeline output", "type", out.Type(), "error", err)
+	}
+}
+
+// walkRuleOutputs calls fn for every output of rules, outputs wrapping other outputs
+// are passed to fn before the wrapped ones.
+func walkRuleOutputs(rules []*LiveChannelRule, fn func(out FrameOutputter)) {
+	var walk func(out FrameOutputter)
+	walk = func(out FrameOutputter) {
+		if out == nil {
+			return
+		}
+		fn(out)
+		switch o := out.(type) {
+		case *MultipleFrameOutput:
+			for _, nested := range o.Outputters {
+				walk(nested)
+			}
+		case *ConditionalOutput:
+			walk(o.Outputter)
+		case *RetryOutput:
+			walk(o.Outputter)
+		case *ProcessedOutput:
+			walk(o.Outputter)
+		case *FailureAlertOutput:
+			walk(o.Outputter)
+		case *RolloutOutput:
+			walk(o.Outputter)
+			walk(o.Fallback)
+		}
+	}
+	for _, rule := range rules {
+		for _, out := range rule.FrameOutputters {
+			walk(out)
+		}
+		walk(rule.DeadLetterOutputter)
+		if rule.Heartbeat != nil {
+			for _, out := range rule.Heartbeat.FrameOutputters {
+				walk(out)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+type blockingOutput struct {
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (o *blockingOutput) Type() string { return "blocking" }
+
+func (o *blockingOutput) OutputFrame(_ context.Context, _ Vars, _ *data.Frame) ([]*ChannelFrame, error) {
+	close(o.started)
+	<-o.unblock
+	return nil, nil
+}
+
+type drainerRuleGetter struct {
+	testRuleGetter
+	drainers []Drainer
+}
+
+func (g *drainerRuleGetter) Drainers() []Drainer {
+	return g.drainers
+}
+
+func TestPipeline_Shutdown(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+	sent := NewRemoteWriteFrameOutput(server.URL, nil, 0)
+	sent.FlushInterval = time.Hour
+	failing := NewRemoteWriteFrameOutput("http://127.0.0.1:0", nil, 0)
+	failing.FlushInterval = time.Hour
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("value", nil, []float64{1}),
+	)
+	for _, out := range []*RemoteWriteFrameOutput{sent, failing} {
+		_, err := out.OutputFrame(context.Background(), Vars{}, frame)
+		require.NoError(t, err)
+	}
+
+	blocking := &blockingOutput{started: make(chan struct{}), unblock: make(chan struct{})}
+	defer close(blocking.unblock)
+	getter := &drainerRuleGetter{
+		testRuleGetter: testRuleGetter{rules: map[string]*LiveChannelRule{
+			"stream/test/blocking": {FrameOutputters: []FrameOutputter{blocking}},
+		}},
+		drainers: []Drainer{sent, failing},
+	}
+	p, err := New(getter)
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = p.ProcessFrames(context.Background(), 1, "stream/test/blocking", []*data.Frame{frame})
+	}()
+	<-blocking.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report := p.Shutdown(ctx)
+	require.True(t, report.Lost())
+	require.Equal(t, 1, report.InFlight)
+	require.Equal(t, map[string]int{FrameOutputTypeRemoteWrite: 1}, report.Dropped)
+	require.Equal(t, int64(1), requests.Load())
+	require.Zero(t, sent.Pending())
+
+	_, err = p.ProcessFrames(context.Background(), 1, "stream/test/blocking", []*data.Frame{frame})
+	require.ErrorIs(t, err, ErrPipelineShutdown)
+}
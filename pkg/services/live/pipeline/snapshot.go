@@ -0,0 +1,185 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// FrameGetter returns the last frame of a channel as JSON.
+type FrameGetter interface {
+	GetFrame(ctx context.Context, orgID int64, channel string) (json.RawMessage, bool, error)
+}
+
+// SnapshotWriter saves a frame snapshot as a new version of a data frame entity.
+type SnapshotWriter interface {
+	WriteSnapshot(ctx context.Context, orgID int64, uid string, channel string, frame json.RawMessage) error
+}
+
+// snapshotUID returns an entity UID of channel snapshots.
+func snapshotUID(channel string, config SnapshotConfig) string {
+	if config.UID != "" {
+		return config.UID
+	}
+	return strings.ReplaceAll(channel, "/", "-")
+}
+
+// checkSnapshotChannel validates a channel snapshots of a rule are taken of.
+func checkSnapshotChannel(pattern string) error {
+	if strings.ContainsAny(pattern, ":*") {
+		return fmt.Errorf("snapshot can't be used with wildcard pattern %s", pattern)
+	}
+	return nil
+}
+
+func parseSnapshotSchedule(config SnapshotConfig) (cron.Schedule, error) {
+	if config.Schedule == "" {
+		return nil, errors.New("snapshot schedule is required")
+	}
+	schedule, err := cron.ParseStandard(config.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// SnapshotRunner periodically saves the last frame of channels with a snapshot
+// configured in rules of all organizations, so live-only channels get a low
+// frequency history in the entity store.
+type SnapshotRunner struct {
+	storage Storage
+	frames  FrameGetter
+	writer  SnapshotWriter
+	orgIDs  func(ctx context.Context) ([]int64, error)
+
+	mu      sync.Mutex
+	running map[generatorKey]*runningSnapshot
+}
+
+type runningSnapshot struct {
+	config SnapshotConfig
+	cancel context.CancelFunc
+}
+
+// NewSnapshotRunner creates SnapshotRunner, orgIDs lists organizations to take
+// snapshots of.
+func NewSnapshotRunner(storage Storage, frames FrameGetter, writer SnapshotWriter, orgIDs func(ctx context.Context) ([]int64, error)) *SnapshotRunner {
+	return &SnapshotRunner{
+		storage: storage,
+		frames:  frames,
+		writer:  writer,
+		orgIDs:  orgIDs,
+		running: map[generatorKey]*runningSnapshot{},
+	}
+}
+
+// Run schedules snapshots and reloads them from rules periodically until context is done.
+func (r *SnapshotRunner) Run(ctx context.Context, reloadInterval time.Duration) error {
+	r.sync(ctx)
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			r.mu.Lock()
+			for key, s := range r.running {
+				s.cancel()
+				delete(r.running, key)
+			}
+			r.mu.Unlock()
+			return ctx.Err()
+		case <-ticker.C:
+			r.sync(ctx)
+		}
+	}
+}
+
+// sync schedules snapshots of new or changed rules and stops removed ones.
+func (r *SnapshotRunner) sync(ctx context.Context) {
+	orgIDs, err := r.orgIDs(ctx)
+	if err != nil {
+		logger.Error("Error listing organizations for snapshots", "error", err)
+		return
+	}
+	desired := map[generatorKey]SnapshotConfig{}
+	// failedOrgs keep scheduled snapshots when their rules can't be loaded.
+	failedOrgs := map[int64]struct{}{}
+	for _, orgID := range orgIDs {
+		rules, err := r.storage.ListChannelRules(ctx, orgID)
+		if err != nil {
+			logger.Error("Error listing channel rules for snapshots", "orgId", orgID, "error", err)
+			failedOrgs[orgID] = struct{}{}
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Settings.Snapshot != nil {
+				desired[generatorKey{orgID: orgID, channel: rule.Pattern}] = *rule.Settings.Snapshot
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, s := range r.running {
+		_, failed := failedOrgs[key.orgID]
+		if _, ok := desired[key]; !ok && !failed {
+			s.cancel()
+			delete(r.running, key)
+		}
+	}
+	for key, config := range desired {
+		if s, ok := r.running[key]; ok {
+			if s.config == config {
+				continue
+			}
+			s.cancel()
+			delete(r.running, key)
+		}
+		if err := checkSnapshotChannel(key.channel); err != nil {
+			logger.Error("Invalid snapshot", "orgId", key.orgID, "channel", key.channel, "error", err)
+			continue
+		}
+		schedule, err := parseSnapshotSchedule(config)
+		if err != nil {
+			logger.Error("Invalid snapshot", "orgId", key.orgID, "channel", key.channel, "error", err)
+			continue
+		}
+		snapshotCtx, cancel := context.WithCancel(ctx)
+		r.running[key] = &runningSnapshot{config: config, cancel: cancel}
+		go r.run(snapshotCtx, key, schedule, snapshotUID(key.channel, config))
+	}
+}
+
+func (r *SnapshotRunner) run(ctx context.Context, key generatorKey, schedule cron.Schedule, uid string) {
+	for {
+		timer := time.NewTimer(time.Until(schedule.Next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := r.snapshot(ctx, key, uid); err != nil {
+				logger.Error("Error taking channel snapshot", "orgId", key.orgID, "channel", key.channel, "uid", uid, "error", err)
+			}
+		}
+	}
+}
+
+// snapshot writes the last frame of a channel, channels without frames are skipped.
+func (r *SnapshotRunner) snapshot(ctx context.Context, key generatorKey, uid string) error {
+	frame, ok, err := r.frames.GetFrame(ctx, key.orgID, key.channel)
+	if err != nil {
+		return fmt.Errorf("error getting frame: %w", err)
+	}
+	if !ok {
+		logger.Debug("Skip channel snapshot: no frame", "orgId", key.orgID, "channel", key.channel)
+		return nil
+	}
+	return r.writer.WriteSnapshot(ctx, key.orgID, uid, key.channel, frame)
+}
@@ -0,0 +1,74 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testFrameGetter struct {
+	frames map[string]json.RawMessage
+}
+
+func (g *testFrameGetter) GetFrame(_ context.Context, _ int64, channel string) (json.RawMessage, bool, error) {
+	frame, ok := g.frames[channel]
+	return frame, ok, nil
+}
+
+type testSnapshot struct {
+	orgID   int64
+	uid     string
+	channel string
+	frame   string
+}
+
+type testSnapshotWriter struct {
+	snapshots []testSnapshot
+}
+
+func (w *testSnapshotWriter) WriteSnapshot(_ context.Context, orgID int64, uid string, channel string, frame json.RawMessage) error {
+	w.snapshots = append(w.snapshots, testSnapshot{orgID: orgID, uid: uid, channel: channel, frame: string(frame)})
+	return nil
+}
+
+func TestSnapshotRunner_sync(t *testing.T) {
+	storage := &testRuleStorage{rules: []ChannelRule{
+		{Pattern: "stream/demo/cpu", Settings: ChannelRuleSettings{Snapshot: &SnapshotConfig{Schedule: "@hourly"}}},
+		{Pattern: "stream/demo/:path", Settings: ChannelRuleSettings{Snapshot: &SnapshotConfig{Schedule: "@hourly"}}},
+		{Pattern: "stream/demo/mem", Settings: ChannelRuleSettings{Snapshot: &SnapshotConfig{Schedule: "every hour"}}},
+	}}
+	r := NewSnapshotRunner(storage, &testFrameGetter{}, &testSnapshotWriter{}, func(context.Context) ([]int64, error) { return []int64{1}, nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r.sync(ctx)
+	require.Len(t, r.running, 1)
+	require.Contains(t, r.running, generatorKey{orgID: 1, channel: "stream/demo/cpu"})
+
+	storage.mu.Lock()
+	storage.rules = nil
+	storage.mu.Unlock()
+	r.sync(ctx)
+	require.Empty(t, r.running)
+}
+
+func TestSnapshotRunner_snapshot(t *testing.T) {
+	frames := &testFrameGetter{frames: map[string]json.RawMessage{"stream/demo/cpu": json.RawMessage(`{"schema":{}}`)}}
+	writer := &testSnapshotWriter{}
+	r := NewSnapshotRunner(&testRuleStorage{}, frames, writer, nil)
+
+	require.NoError(t, r.snapshot(context.Background(), generatorKey{orgID: 2, channel: "stream/demo/cpu"}, snapshotUID("stream/demo/cpu", SnapshotConfig{})))
+	require.NoError(t, r.snapshot(context.Background(), generatorKey{orgID: 2, channel: "stream/demo/mem"}, "mem"))
+	require.Equal(t, []testSnapshot{{orgID: 2, uid: "stream-demo-cpu", channel: "stream/demo/cpu", frame: `{"schema":{}}`}}, writer.snapshots)
+}
+
+func TestParseSnapshotSchedule(t *testing.T) {
+	_, err := parseSnapshotSchedule(SnapshotConfig{Schedule: "*/15 * * * *"})
+	require.NoError(t, err)
+	_, err = parseSnapshotSchedule(SnapshotConfig{})
+	require.ErrorContains(t, err, "snapshot schedule is required")
+	_, err = parseSnapshotSchedule(SnapshotConfig{Schedule: "* *"})
+	require.ErrorContains(t, err, "invalid snapshot schedule")
+}
@@ -1,6 +1,15 @@
 package pipeline
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrChannelRuleNotFound = errors.New("rule not found")
+	ErrWriteConfigNotFound = errors.New("write config not found")
+)
 
 // Storage describes all methods to manage Live pipeline persistent data.
 type Storage interface {
@@ -14,3 +23,23 @@ type Storage interface {
 	UpdateChannelRule(_ context.Context, orgID int64, cmd ChannelRuleUpdateCmd) (ChannelRule, error)
 	DeleteChannelRule(_ context.Context, orgID int64, cmd ChannelRuleDeleteCmd) error
 }
+
+// SharedWriteConfigLister is implemented by storages supporting write configs shared
+// between organizations.
+type SharedWriteConfigLister interface {
+	// ListSharedWriteConfigs returns write configs of other organizations shared with orgID.
+	ListSharedWriteConfigs(_ context.Context, orgID int64) ([]WriteConfig, error)
+}
+
+// checkWriteConfigName checks a write config name is unique in an organization.
+func checkWriteConfigName(writeConfig WriteConfig, existing []WriteConfig) error {
+	if writeConfig.Name == "" {
+		return nil
+	}
+	for _, c := range existing {
+		if c.UID != writeConfig.UID && c.Name == writeConfig.Name {
+			return fmt.Errorf("write config name already exists in org: %s", writeConfig.Name)
+		}
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/util"
@@ -16,6 +18,16 @@ import (
 type FileStorage struct {
 	DataPath       string
 	SecretsService secrets.Service
+	// RulesFile is a path of channel rules file, live-channel-rules.json in
+	// pipeline directory of DataPath by default. It can be a directory of JSON and
+	// YAML fragments, rules can't be changed over API then.
+	RulesFile string
+
+	// rules are kept in memory once loaded with Reload, file is not read on
+	// every call then.
+	rulesMu      sync.RWMutex
+	rules        *ChannelRules
+	rulesModTime time.Time
 }
 
 func (f *FileStorage) ListWriteConfigs(_ context.Context, orgID int64) ([]WriteConfig, error) {
@@ -32,6 +44,20 @@ func (f *FileStorage) ListWriteConfigs(_ context.Context, orgID int64) ([]WriteC
 	return orgConfigs, nil
 }
 
+func (f *FileStorage) ListSharedWriteConfigs(_ context.Context, orgID int64) ([]WriteConfig, error) {
+	writeConfigs, err := f.readWriteConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("can't read write configs: %w", err)
+	}
+	var shared []WriteConfig
+	for _, b := range writeConfigs.Configs {
+		if b.OrgId != orgID && b.SharedWithOrg(orgID) {
+			shared = append(shared, b)
+		}
+	}
+	return shared, nil
+}
+
 func (f *FileStorage) GetWriteConfig(_ context.Context, orgID int64, cmd WriteConfigGetCmd) (WriteConfig, bool, error) {
 	writeConfigs, err := f.readWriteConfigs()
 	if err != nil {
@@ -54,6 +80,7 @@ func (f *FileStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd Wr
 		cmd.UID = util.GenerateShortUID()
 	}
 
+	cmd.Settings, cmd.SecureSettings = secureWriteSettings(cmd.Settings, cmd.SecureSettings, nil)
 	secureSettings, err := f.SecretsService.EncryptJsonData(ctx, cmd.SecureSettings, secrets.WithoutScope())
 	if err != nil {
 		return WriteConfig{}, fmt.Errorf("error encrypting data: %w", err)
@@ -62,8 +89,10 @@ func (f *FileStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd Wr
 	backend := WriteConfig{
 		OrgId:          orgID,
 		UID:            cmd.UID,
+		Name:           cmd.Name,
 		Settings:       cmd.Settings,
 		SecureSettings: secureSettings,
+		Sharing:        cmd.Sharing,
 	}
 
 	ok, reason := backend.Valid()
@@ -75,6 +104,13 @@ func (f *FileStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd Wr
 			return WriteConfig{}, fmt.Errorf("backend already exists in org: %s", backend.UID)
 		}
 	}
+	orgConfigs, err := f.ListWriteConfigs(ctx, orgID)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	if err := checkWriteConfigName(backend, orgConfigs); err != nil {
+		return WriteConfig{}, err
+	}
 	writeConfigs.Configs = append(writeConfigs.Configs, backend)
 	err = f.saveWriteConfigs(orgID, writeConfigs)
 	return backend, err
@@ -86,6 +122,23 @@ func (f *FileStorage) UpdateWriteConfig(ctx context.Context, orgID int64, cmd Wr
 		return WriteConfig{}, fmt.Errorf("can't read write configs: %w", err)
 	}
 
+	index := -1
+
+	for i, existingBackend := range writeConfigs.Configs {
+		if uidMatch(orgID, cmd.UID, existingBackend) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return f.CreateWriteConfig(ctx, orgID, WriteConfigCreateCmd(cmd))
+	}
+
+	existingSecureSettings, err := f.SecretsService.DecryptJsonData(ctx, writeConfigs.Configs[index].SecureSettings)
+	if err != nil {
+		return WriteConfig{}, fmt.Errorf("error decrypting data: %w", err)
+	}
+	cmd.Settings, cmd.SecureSettings = secureWriteSettings(cmd.Settings, cmd.SecureSettings, existingSecureSettings)
 	secureSettings, err := f.SecretsService.EncryptJsonData(ctx, cmd.SecureSettings, secrets.WithoutScope())
 	if err != nil {
 		return WriteConfig{}, fmt.Errorf("error encrypting data: %w", err)
@@ -94,28 +147,24 @@ func (f *FileStorage) UpdateWriteConfig(ctx context.Context, orgID int64, cmd Wr
 	backend := WriteConfig{
 		OrgId:          orgID,
 		UID:            cmd.UID,
+		Name:           cmd.Name,
 		Settings:       cmd.Settings,
 		SecureSettings: secureSettings,
+		Sharing:        cmd.Sharing,
 	}
 
 	ok, reason := backend.Valid()
 	if !ok {
 		return WriteConfig{}, fmt.Errorf("invalid channel rule: %s", reason)
 	}
-
-	index := -1
-
-	for i, existingBackend := range writeConfigs.Configs {
-		if uidMatch(orgID, backend.UID, existingBackend) {
-			index = i
-			break
-		}
+	orgConfigs, err := f.ListWriteConfigs(ctx, orgID)
+	if err != nil {
+		return WriteConfig{}, err
 	}
-	if index > -1 {
-		writeConfigs.Configs[index] = backend
-	} else {
-		return f.CreateWriteConfig(ctx, orgID, WriteConfigCreateCmd(cmd))
+	if err := checkWriteConfigName(backend, orgConfigs); err != nil {
+		return WriteConfig{}, err
 	}
+	writeConfigs.Configs[index] = backend
 
 	err = f.saveWriteConfigs(orgID, writeConfigs)
 	return backend, err
@@ -138,7 +187,7 @@ func (f *FileStorage) DeleteWriteConfig(_ context.Context, orgID int64, cmd Writ
 	if index > -1 {
 		writeConfigs.Configs = removeWriteConfigByIndex(writeConfigs.Configs, index)
 	} else {
-		return fmt.Errorf("write config not found")
+		return ErrWriteConfigNotFound
 	}
 
 	return f.saveWriteConfigs(orgID, writeConfigs)
@@ -232,11 +281,26 @@ func removeChannelRuleByIndex(s []ChannelRule, index int) []ChannelRule {
 }
 
 func (f *FileStorage) ruleFilePath() string {
+	if f.RulesFile != "" {
+		return f.RulesFile
+	}
 	return filepath.Join(f.DataPath, "pipeline", "live-channel-rules.json")
 }
 
 func (f *FileStorage) readRules() (ChannelRules, error) {
+	f.rulesMu.RLock()
+	defer f.rulesMu.RUnlock()
+	if f.rules != nil {
+		return ChannelRules{Rules: append([]ChannelRule(nil), f.rules.Rules...)}, nil
+	}
+	return f.readRulesFile()
+}
+
+func (f *FileStorage) readRulesFile() (ChannelRules, error) {
 	ruleFile := f.ruleFilePath()
+	if info, err := os.Stat(ruleFile); err == nil && info.IsDir() {
+		return readRulesDir(ruleFile)
+	}
 	// Safe to ignore gosec warning G304.
 	// nolint:gosec
 	ruleBytes, err := os.ReadFile(ruleFile)
@@ -252,6 +316,9 @@ func (f *FileStorage) readRules() (ChannelRules, error) {
 }
 
 func (f *FileStorage) saveChannelRules(orgID int64, rules ChannelRules) error {
+	if info, err := os.Stat(f.ruleFilePath()); err == nil && info.IsDir() {
+		return errRulesDirReadOnly
+	}
 	ok, reason := checkRulesValid(orgID, rules.Rules)
 	if !ok {
 		return errors.New(reason)
@@ -270,6 +337,14 @@ func (f *FileStorage) saveChannelRules(orgID int64, rules ChannelRules) error {
 	if err != nil {
 		return fmt.Errorf("can't save rules to file: %w", err)
 	}
+	f.rulesMu.Lock()
+	defer f.rulesMu.Unlock()
+	if f.rules != nil {
+		f.rules = &rules
+		if info, err := file.Stat(); err == nil {
+			f.rulesModTime = info.ModTime()
+		}
+	}
 	return nil
 }
 
@@ -290,7 +365,7 @@ func (f *FileStorage) DeleteChannelRule(_ context.Context, orgID int64, cmd Chan
 	if index > -1 {
 		channelRules.Rules = removeChannelRuleByIndex(channelRules.Rules, index)
 	} else {
-		return fmt.Errorf("rule not found")
+		return ErrChannelRuleNotFound
 	}
 
 	return f.saveChannelRules(orgID, channelRules)
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+var errRulesDirReadOnly = errors.New("channel rules are loaded from a directory and can't be changed over API")
+
+// readRulesDir merges channel rules of JSON and YAML fragments in a directory, so teams
+// can own their rule files. Fragments are read in name order, a pattern of an
+// organization can only be defined in one fragment.
+func readRulesDir(dir string) (ChannelRules, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ChannelRules{}, fmt.Errorf("can't read pipeline rules directory: %w", err)
+	}
+	var merged ChannelRules
+	patternFiles := map[string]string{}
+	for _, entry := range entries {
+		if !isRulesFragment(entry) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		// Safe to ignore gosec warning G304.
+		// nolint:gosec
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return ChannelRules{}, fmt.Errorf("can't read pipeline rules: %s: %w", path, err)
+		}
+		rules, err := decodeRulesFragment(entry.Name(), body)
+		if err != nil {
+			return ChannelRules{}, fmt.Errorf("can't unmarshal %s data: %w", path, err)
+		}
+		for _, rule := range rules.Rules {
+			key := orgchannel.PrependOrgID(ruleOrgID(rule.OrgId), rule.Pattern)
+			if other, ok := patternFiles[key]; ok {
+				return ChannelRules{}, fmt.Errorf("pattern %s is defined in both %s and %s", rule.Pattern, other, entry.Name())
+			}
+			patternFiles[key] = entry.Name()
+			merged.Rules = append(merged.Rules, rule)
+		}
+	}
+	return merged, nil
+}
+
+func isRulesFragment(entry os.DirEntry) bool {
+	if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+		return false
+	}
+	switch filepath.Ext(entry.Name()) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// decodeRulesFragment decodes a JSON or YAML rules document, YAML uses the same keys as JSON.
+func decodeRulesFragment(name string, body []byte) (ChannelRules, error) {
+	if ext := filepath.Ext(name); ext == ".yaml" || ext == ".yml" {
+		var doc any
+		if err := yaml.Unmarshal(body, &doc); err != nil {
+			return ChannelRules{}, err
+		}
+		var err error
+		body, err = json.Marshal(doc)
+		if err != nil {
+			return ChannelRules{}, err
+		}
+	}
+	var rules ChannelRules
+	err := json.Unmarshal(body, &rules)
+	return rules, err
+}
+
+// rulesModTime returns modification time of a rules file, or the latest one of a rules
+// directory and its fragments. Directory time changes when fragments are added or removed.
+func rulesModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	modTime := info.ModTime()
+	if !info.IsDir() {
+		return modTime, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, entry := range entries {
+		if !isRulesFragment(entry) {
+			continue
+		}
+		entryInfo, err := entry.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if entryInfo.ModTime().After(modTime) {
+			modTime = entryInfo.ModTime()
+		}
+	}
+	return modTime, nil
+}
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStorage_RulesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"rules": [{"pattern": "stream/team-a/metrics"}]}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yaml"), []byte(`
+rules:
+  - pattern: stream/team-b/metrics
+    settings:
+      priority: high
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte(`not rules`), 0600))
+
+	s := &FileStorage{RulesFile: dir}
+	require.NoError(t, s.Reload())
+	rules, err := s.ListChannelRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, "stream/team-a/metrics", rules[0].Pattern)
+	require.Equal(t, "stream/team-b/metrics", rules[1].Pattern)
+	require.Equal(t, "high", rules[1].Settings.Priority)
+
+	_, err = s.CreateChannelRule(context.Background(), 1, ChannelRuleCreateCmd{Pattern: "stream/test/a"})
+	require.ErrorIs(t, err, errRulesDirReadOnly)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.yml"), []byte(`{"rules": [{"pattern": "stream/team-a/metrics"}]}`), 0600))
+	err = s.Reload()
+	require.ErrorContains(t, err, "pattern stream/team-a/metrics is defined in both a.json and c.yml")
+	rules, err = s.ListChannelRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+}
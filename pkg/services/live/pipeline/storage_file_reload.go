@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Reload reads and validates channel rules file or directory and keeps rules in memory, so they
+// are not read from disk on every call. Previously loaded rules are kept when the
+// file is not valid.
+func (f *FileStorage) Reload() error {
+	modTime, err := rulesModTime(f.ruleFilePath())
+	if err != nil {
+		return fmt.Errorf("can't read pipeline rules: %w", err)
+	}
+	rules, err := f.readRulesFile()
+	if err != nil {
+		return err
+	}
+	// Rules without organization belong to the main organization.
+	for _, rule := range rules.Rules {
+		if ok, reason := rule.Valid(); !ok {
+			return fmt.Errorf("invalid channel rule %s: %s", rule.Pattern, reason)
+		}
+	}
+	if ok, reason := checkAllRulesValid(rules.Rules); !ok {
+		return errors.New(reason)
+	}
+	f.rulesMu.Lock()
+	defer f.rulesMu.Unlock()
+	f.rules = &rules
+	f.rulesModTime = modTime
+	return nil
+}
+
+// Watch reloads channel rules when modification time of the file changes, file is
+// checked every interval. onReload is called after rules are reloaded.
+func (f *FileStorage) Watch(ctx context.Context, interval time.Duration, onReload func()) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// Invalid file is reported once until it's modified again.
+	var failedModTime time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			modTime, err := rulesModTime(f.ruleFilePath())
+			if err != nil {
+				logger.Error("Error checking pipeline rules file", "error", err)
+				continue
+			}
+			f.rulesMu.RLock()
+			changed := f.rules == nil || !modTime.Equal(f.rulesModTime)
+			f.rulesMu.RUnlock()
+			if !changed || modTime.Equal(failedModTime) {
+				continue
+			}
+			if err := f.Reload(); err != nil {
+				logger.Error("Error reloading pipeline rules file, keeping previous rules", "error", err)
+				failedModTime = modTime
+				continue
+			}
+			logger.Info("Pipeline rules file reloaded", "path", f.ruleFilePath())
+			if onReload != nil {
+				onReload()
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+)
+
+func TestFileStorage_Reload(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`{"rules": [{"pattern": "stream/test/a"}]}`), 0600))
+
+	s := &FileStorage{RulesFile: rulesFile}
+	require.NoError(t, s.Reload())
+
+	// Rules are kept in memory, file is not read on every call.
+	require.NoError(t, os.Remove(rulesFile))
+	rules, err := s.ListChannelRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, "stream/test/a", rules[0].Pattern)
+
+	// Invalid file keeps previous rules.
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`{"rules": [{"pattern": "stream/test/a"}, {"pattern": "stream/test/a"}]}`), 0600))
+	require.Error(t, s.Reload())
+	rules, err = s.ListChannelRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+}
+
+func TestFileStorage_Watch(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`{"rules": [{"pattern": "stream/test/a"}]}`), 0600))
+	s := &FileStorage{RulesFile: rulesFile}
+	require.NoError(t, s.Reload())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	go func() {
+		_ = s.Watch(ctx, 10*time.Millisecond, func() { reloaded <- struct{}{} })
+	}()
+
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`{"rules": [{"pattern": "stream/test/a"}, {"pattern": "stream/test/b"}]}`), 0600))
+	// Make sure modification time changes on file systems with coarse timestamps.
+	require.NoError(t, os.Chtimes(rulesFile, time.Now(), time.Now().Add(time.Minute)))
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("rules were not reloaded")
+	}
+	rules, err := s.ListChannelRules(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+}
+
+func TestFileStorage_orgIsolation(t *testing.T) {
+	dataPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataPath, "pipeline"), 0750))
+	rulesFile := filepath.Join(dataPath, "pipeline", "live-channel-rules.json")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`{"rules": [{"pattern": "stream/test/a"}]}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "pipeline", "write-configs.json"), []byte(`{"writeConfigs": []}`), 0600))
+
+	ctx := context.Background()
+	s := &FileStorage{DataPath: dataPath, SecretsService: fakes.NewFakeSecretsService()}
+	_, err := s.CreateChannelRule(ctx, 2, ChannelRuleCreateCmd{Pattern: "stream/test/a"})
+	require.NoError(t, err)
+	_, err = s.CreateWriteConfig(ctx, 2, WriteConfigCreateCmd{UID: "prom", Settings: WriteSettings{Endpoint: "http://localhost"}})
+	require.NoError(t, err)
+
+	// Organization is kept in files, so rules and write configs of one organization
+	// don't become rules of the main organization after reload.
+	require.NoError(t, s.Reload())
+	rules, err := s.ListChannelRules(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	rules, err = s.ListChannelRules(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, int64(2), rules[0].OrgId)
+
+	writeConfigs, err := s.ListWriteConfigs(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, writeConfigs)
+	writeConfigs, err = s.ListWriteConfigs(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, writeConfigs, 1)
+}
+
+func TestFileStorage_UpdateWriteConfigKeepsSecureSettings(t *testing.T) {
+	dataPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataPath, "pipeline"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, "pipeline", "write-configs.json"), []byte(`{"writeConfigs": []}`), 0600))
+
+	ctx := context.Background()
+	s := &FileStorage{DataPath: dataPath, SecretsService: fakes.NewFakeSecretsService()}
+	_, err := s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{
+		UID:            "prom",
+		Settings:       WriteSettings{Endpoint: "http://localhost"},
+		SecureSettings: map[string]string{SecureSettingBasicAuthPassword: "secret", SecureSettingBearerToken: "token"},
+	})
+	require.NoError(t, err)
+
+	_, err = s.UpdateWriteConfig(ctx, 1, WriteConfigUpdateCmd{
+		UID:            "prom",
+		Settings:       WriteSettings{Endpoint: "http://prometheus"},
+		SecureSettings: map[string]string{SecureSettingBearerToken: ""},
+	})
+	require.NoError(t, err)
+
+	writeConfig, ok, err := s.GetWriteConfig(ctx, 1, WriteConfigGetCmd{UID: "prom"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "http://prometheus", writeConfig.Settings.Endpoint)
+	require.Equal(t, map[string][]byte{SecureSettingBasicAuthPassword: []byte("secret")}, writeConfig.SecureSettings)
+}
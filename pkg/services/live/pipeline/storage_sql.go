@@ -0,0 +1,376 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// SQLStorage keeps channel rules and write configs of organizations in the Grafana
+// database, so rules can be managed over HTTP API without editing files on disk.
+type SQLStorage struct {
+	SQLStore       db.DB
+	SecretsService secrets.Service
+}
+
+type liveChannelRule struct {
+	Id       int64
+	OrgId    int64
+	Pattern  string
+	Settings string
+
+	Created time.Time
+	Updated time.Time
+}
+
+func (r *liveChannelRule) TableName() string {
+	return "live_channel_rule"
+}
+
+type liveWriteConfig struct {
+	Id             int64
+	OrgId          int64
+	Uid            string
+	Name           string
+	Settings       string
+	SecureSettings string
+	Sharing        string
+
+	Created time.Time
+	Updated time.Time
+}
+
+func (c *liveWriteConfig) TableName() string {
+	return "live_write_config"
+}
+
+func (s *SQLStorage) ListWriteConfigs(ctx context.Context, orgID int64) ([]WriteConfig, error) {
+	var rows []liveWriteConfig
+	err := s.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ?", orgID).Asc("uid").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeConfigs := make([]WriteConfig, 0, len(rows))
+	for _, row := range rows {
+		writeConfig, err := row.toWriteConfig()
+		if err != nil {
+			return nil, err
+		}
+		writeConfigs = append(writeConfigs, writeConfig)
+	}
+	return writeConfigs, nil
+}
+
+func (s *SQLStorage) GetWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigGetCmd) (WriteConfig, bool, error) {
+	row := liveWriteConfig{OrgId: orgID, Uid: cmd.UID}
+	var found bool
+	err := s.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		found, err = sess.Get(&row)
+		return err
+	})
+	if err != nil || !found {
+		return WriteConfig{}, false, err
+	}
+	writeConfig, err := row.toWriteConfig()
+	return writeConfig, true, err
+}
+
+func (s *SQLStorage) CreateWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigCreateCmd) (WriteConfig, error) {
+	if cmd.UID == "" {
+		cmd.UID = util.GenerateShortUID()
+	}
+	writeConfig, row, err := s.newWriteConfigRow(ctx, orgID, WriteConfigUpdateCmd(cmd), nil)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Exist(&liveWriteConfig{OrgId: orgID, Uid: cmd.UID})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("backend already exists in org: %s", cmd.UID)
+		}
+		if err := checkSQLWriteConfigName(sess, writeConfig); err != nil {
+			return err
+		}
+		_, err = sess.Insert(row)
+		return err
+	})
+	return writeConfig, err
+}
+
+func (s *SQLStorage) UpdateWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigUpdateCmd) (WriteConfig, error) {
+	existing, found, err := s.GetWriteConfig(ctx, orgID, WriteConfigGetCmd{UID: cmd.UID})
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	if !found {
+		return s.CreateWriteConfig(ctx, orgID, WriteConfigCreateCmd(cmd))
+	}
+	// Secure settings omitted in the command are kept, the row is replaced with all columns.
+	existingSecureSettings, err := s.SecretsService.DecryptJsonData(ctx, existing.SecureSettings)
+	if err != nil {
+		return WriteConfig{}, fmt.Errorf("error decrypting data: %w", err)
+	}
+	writeConfig, row, err := s.newWriteConfigRow(ctx, orgID, cmd, existingSecureSettings)
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	var created bool
+	err = s.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		existing := liveWriteConfig{OrgId: orgID, Uid: cmd.UID}
+		found, err := sess.Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !found {
+			created = true
+			return nil
+		}
+		if err := checkSQLWriteConfigName(sess, writeConfig); err != nil {
+			return err
+		}
+		row.Created = existing.Created
+		_, err = sess.ID(existing.Id).AllCols().Update(row)
+		return err
+	})
+	if err != nil {
+		return WriteConfig{}, err
+	}
+	if created {
+		return s.CreateWriteConfig(ctx, orgID, WriteConfigCreateCmd(cmd))
+	}
+	return writeConfig, nil
+}
+
+func (s *SQLStorage) DeleteWriteConfig(ctx context.Context, orgID int64, cmd WriteConfigDeleteCmd) error {
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Delete(&liveWriteConfig{OrgId: orgID, Uid: cmd.UID})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrWriteConfigNotFound
+		}
+		return nil
+	})
+}
+
+func (s *SQLStorage) ListSharedWriteConfigs(ctx context.Context, orgID int64) ([]WriteConfig, error) {
+	var rows []liveWriteConfig
+	err := s.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id <> ? AND sharing <> ''", orgID).Asc("org_id", "uid").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var writeConfigs []WriteConfig
+	for _, row := range rows {
+		writeConfig, err := row.toWriteConfig()
+		if err != nil {
+			return nil, err
+		}
+		if writeConfig.SharedWithOrg(orgID) {
+			writeConfigs = append(writeConfigs, writeConfig)
+		}
+	}
+	return writeConfigs, nil
+}
+
+func checkSQLWriteConfigName(sess *db.Session, writeConfig WriteConfig) error {
+	if writeConfig.Name == "" {
+		return nil
+	}
+	var rows []liveWriteConfig
+	if err := sess.Where("org_id = ? AND name = ?", writeConfig.OrgId, writeConfig.Name).Find(&rows); err != nil {
+		return err
+	}
+	existing := make([]WriteConfig, 0, len(rows))
+	for _, row := range rows {
+		existing = append(existing, WriteConfig{UID: row.Uid, Name: row.Name})
+	}
+	return checkWriteConfigName(writeConfig, existing)
+}
+
+func (s *SQLStorage) newWriteConfigRow(ctx context.Context, orgID int64, cmd WriteConfigUpdateCmd, existingSecureSettings map[string]string) (WriteConfig, *liveWriteConfig, error) {
+	cmd.Settings, cmd.SecureSettings = secureWriteSettings(cmd.Settings, cmd.SecureSettings, existingSecureSettings)
+	encrypted, err := s.SecretsService.EncryptJsonData(ctx, cmd.SecureSettings, secrets.WithoutScope())
+	if err != nil {
+		return WriteConfig{}, nil, fmt.Errorf("error encrypting data: %w", err)
+	}
+	writeConfig := WriteConfig{
+		OrgId:          orgID,
+		UID:            cmd.UID,
+		Name:           cmd.Name,
+		Settings:       cmd.Settings,
+		SecureSettings: encrypted,
+		Sharing:        cmd.Sharing,
+	}
+	ok, reason := writeConfig.Valid()
+	if !ok {
+		return WriteConfig{}, nil, fmt.Errorf("invalid write config: %s", reason)
+	}
+	settingsJSON, err := json.Marshal(writeConfig.Settings)
+	if err != nil {
+		return WriteConfig{}, nil, err
+	}
+	secureSettingsJSON, err := json.Marshal(writeConfig.SecureSettings)
+	if err != nil {
+		return WriteConfig{}, nil, err
+	}
+	var sharingJSON []byte
+	if writeConfig.Sharing != nil {
+		sharingJSON, err = json.Marshal(writeConfig.Sharing)
+		if err != nil {
+			return WriteConfig{}, nil, err
+		}
+	}
+	now := time.Now()
+	return writeConfig, &liveWriteConfig{
+		OrgId:          orgID,
+		Uid:            cmd.UID,
+		Name:           cmd.Name,
+		Settings:       string(settingsJSON),
+		SecureSettings: string(secureSettingsJSON),
+		Sharing:        string(sharingJSON),
+		Created:        now,
+		Updated:        now,
+	}, nil
+}
+
+func (c *liveWriteConfig) toWriteConfig() (WriteConfig, error) {
+	writeConfig := WriteConfig{OrgId: c.OrgId, UID: c.Uid, Name: c.Name}
+	if err := json.Unmarshal([]byte(c.Settings), &writeConfig.Settings); err != nil {
+		return WriteConfig{}, fmt.Errorf("can't unmarshal write config %s settings: %w", c.Uid, err)
+	}
+	if c.SecureSettings != "" {
+		if err := json.Unmarshal([]byte(c.SecureSettings), &writeConfig.SecureSettings); err != nil {
+			return WriteConfig{}, fmt.Errorf("can't unmarshal write config %s secure settings: %w", c.Uid, err)
+		}
+	}
+	if c.Sharing != "" {
+		if err := json.Unmarshal([]byte(c.Sharing), &writeConfig.Sharing); err != nil {
+			return WriteConfig{}, fmt.Errorf("can't unmarshal write config %s sharing: %w", c.Uid, err)
+		}
+	}
+	return writeConfig, nil
+}
+
+func (s *SQLStorage) ListChannelRules(ctx context.Context, orgID int64) ([]ChannelRule, error) {
+	var rules []ChannelRule
+	err := s.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		rules, err = listChannelRules(sess, orgID)
+		return err
+	})
+	return rules, err
+}
+
+func listChannelRules(sess *db.Session, orgID int64) ([]ChannelRule, error) {
+	var rows []liveChannelRule
+	if err := sess.Where("org_id = ?", orgID).Asc("pattern").Find(&rows); err != nil {
+		return nil, err
+	}
+	rules := make([]ChannelRule, 0, len(rows))
+	for _, row := range rows {
+		rule := ChannelRule{OrgId: row.OrgId, Pattern: row.Pattern}
+		if err := json.Unmarshal([]byte(row.Settings), &rule.Settings); err != nil {
+			return nil, fmt.Errorf("can't unmarshal channel rule %s settings: %w", row.Pattern, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (s *SQLStorage) CreateChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleCreateCmd) (ChannelRule, error) {
+	rule := ChannelRule{
+		OrgId:    orgID,
+		Pattern:  cmd.Pattern,
+		Settings: cmd.Settings,
+	}
+	err := s.saveChannelRule(ctx, rule, false)
+	return rule, err
+}
+
+func (s *SQLStorage) UpdateChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleUpdateCmd) (ChannelRule, error) {
+	rule := ChannelRule{
+		OrgId:    orgID,
+		Pattern:  cmd.Pattern,
+		Settings: cmd.Settings,
+	}
+	err := s.saveChannelRule(ctx, rule, true)
+	return rule, err
+}
+
+// saveChannelRule inserts a rule, or updates an existing rule with the same pattern
+// when update is set. Patterns of all rules of an organization are checked to not
+// conflict with each other.
+func (s *SQLStorage) saveChannelRule(ctx context.Context, rule ChannelRule, update bool) error {
+	ok, reason := rule.Valid()
+	if !ok {
+		return fmt.Errorf("invalid channel rule: %s", reason)
+	}
+	settings, err := json.Marshal(rule.Settings)
+	if err != nil {
+		return err
+	}
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		rules, err := listChannelRules(sess, rule.OrgId)
+		if err != nil {
+			return err
+		}
+		exists := false
+		for i, existingRule := range rules {
+			if existingRule.Pattern == rule.Pattern {
+				if !update {
+					return fmt.Errorf("pattern already exists in org: %s", rule.Pattern)
+				}
+				rules[i] = rule
+				exists = true
+			}
+		}
+		if !exists {
+			rules = append(rules, rule)
+		}
+		if ok, reason := checkRulesValid(rule.OrgId, rules); !ok {
+			return errors.New(reason)
+		}
+		now := time.Now()
+		row := &liveChannelRule{
+			OrgId:    rule.OrgId,
+			Pattern:  rule.Pattern,
+			Settings: string(settings),
+			Updated:  now,
+		}
+		if exists {
+			_, err = sess.Where("org_id = ? AND pattern = ?", rule.OrgId, rule.Pattern).Cols("settings", "updated").Update(row)
+			return err
+		}
+		row.Created = now
+		_, err = sess.Insert(row)
+		return err
+	})
+}
+
+func (s *SQLStorage) DeleteChannelRule(ctx context.Context, orgID int64, cmd ChannelRuleDeleteCmd) error {
+	return s.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Delete(&liveChannelRule{OrgId: orgID, Pattern: cmd.Pattern})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrChannelRuleNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+)
+
+func TestIntegrationSQLStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	s := &SQLStorage{SQLStore: db.InitTestDB(t), SecretsService: fakes.NewFakeSecretsService()}
+	ctx := context.Background()
+
+	t.Run("channel rules", func(t *testing.T) {
+		_, err := s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/test/a"})
+		require.NoError(t, err)
+		_, err = s.CreateChannelRule(ctx, 1, ChannelRuleCreateCmd{Pattern: "stream/test/a"})
+		require.ErrorContains(t, err, "pattern already exists")
+		_, err = s.CreateChannelRule(ctx, 2, ChannelRuleCreateCmd{Pattern: "stream/test/a"})
+		require.NoError(t, err)
+
+		_, err = s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{
+			Pattern:  "stream/test/a",
+			Settings: ChannelRuleSettings{Priority: "high"},
+		})
+		require.NoError(t, err)
+		_, err = s.UpdateChannelRule(ctx, 1, ChannelRuleUpdateCmd{Pattern: "stream/test/b"})
+		require.NoError(t, err)
+
+		rules, err := s.ListChannelRules(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		require.Equal(t, "stream/test/a", rules[0].Pattern)
+		require.Equal(t, "high", rules[0].Settings.Priority)
+		require.Equal(t, "stream/test/b", rules[1].Pattern)
+
+		require.NoError(t, s.DeleteChannelRule(ctx, 1, ChannelRuleDeleteCmd{Pattern: "stream/test/a"}))
+		require.ErrorIs(t, s.DeleteChannelRule(ctx, 1, ChannelRuleDeleteCmd{Pattern: "stream/test/a"}), ErrChannelRuleNotFound)
+
+		rules, err = s.ListChannelRules(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+	})
+
+	t.Run("write configs", func(t *testing.T) {
+		_, err := s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{
+			UID:            "prom",
+			Settings:       WriteSettings{Endpoint: "http://localhost:9090/api/v1/write"},
+			SecureSettings: map[string]string{"basicAuthPassword": "secret"},
+		})
+		require.NoError(t, err)
+
+		writeConfig, ok, err := s.GetWriteConfig(ctx, 1, WriteConfigGetCmd{UID: "prom"})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, []byte("secret"), writeConfig.SecureSettings["basicAuthPassword"])
+
+		_, err = s.UpdateWriteConfig(ctx, 1, WriteConfigUpdateCmd{
+			UID:      "prom",
+			Settings: WriteSettings{Endpoint: "http://prometheus:9090/api/v1/write"},
+		})
+		require.NoError(t, err)
+		writeConfigs, err := s.ListWriteConfigs(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, writeConfigs, 1)
+		require.Equal(t, "http://prometheus:9090/api/v1/write", writeConfigs[0].Settings.Endpoint)
+		// Secure settings omitted in the update are kept.
+		require.Equal(t, []byte("secret"), writeConfigs[0].SecureSettings["basicAuthPassword"])
+
+		// Plain text passwords are saved as secure settings.
+		_, err = s.UpdateWriteConfig(ctx, 1, WriteConfigUpdateCmd{
+			UID:      "prom",
+			Settings: WriteSettings{Endpoint: "http://prometheus:9090/api/v1/write", BasicAuth: &BasicAuth{User: "admin", Password: "plain"}},
+		})
+		require.NoError(t, err)
+		writeConfig, _, err = s.GetWriteConfig(ctx, 1, WriteConfigGetCmd{UID: "prom"})
+		require.NoError(t, err)
+		require.Equal(t, &BasicAuth{User: "admin"}, writeConfig.Settings.BasicAuth)
+		require.Equal(t, []byte("plain"), writeConfig.SecureSettings[SecureSettingBasicAuthPassword])
+
+		_, ok, err = s.GetWriteConfig(ctx, 2, WriteConfigGetCmd{UID: "prom"})
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		require.NoError(t, s.DeleteWriteConfig(ctx, 1, WriteConfigDeleteCmd{UID: "prom"}))
+		require.ErrorIs(t, s.DeleteWriteConfig(ctx, 1, WriteConfigDeleteCmd{UID: "prom"}), ErrWriteConfigNotFound)
+	})
+
+	t.Run("shared write configs", func(t *testing.T) {
+		_, err := s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{
+			UID:      "mimir",
+			Name:     "central",
+			Settings: WriteSettings{Endpoint: "http://mimir:9009/api/v1/push"},
+			Sharing:  &WriteConfigSharing{OrgIDs: []int64{2}},
+		})
+		require.NoError(t, err)
+		_, err = s.CreateWriteConfig(ctx, 1, WriteConfigCreateCmd{UID: "other", Name: "central", Settings: WriteSettings{Endpoint: "http://other"}})
+		require.ErrorContains(t, err, "name already exists")
+		_, err = s.CreateWriteConfig(ctx, 2, WriteConfigCreateCmd{UID: "local", Name: "central", Settings: WriteSettings{Endpoint: "http://local"}})
+		require.NoError(t, err)
+
+		shared, err := s.ListSharedWriteConfigs(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, shared, 1)
+		require.Equal(t, "mimir", shared[0].UID)
+		shared, err = s.ListSharedWriteConfigs(ctx, 3)
+		require.NoError(t, err)
+		require.Empty(t, shared)
+
+		_, err = s.CreateChannelRule(ctx, 2, ChannelRuleCreateCmd{
+			Pattern: "stream/test/shared",
+			Settings: ChannelRuleSettings{
+				FrameOutputters: []*FrameOutputterConfig{{
+					Type:                    FrameOutputTypeRemoteWrite,
+					RemoteWriteOutputConfig: &RemoteWriteOutputConfig{Backend: &WriteConfigRef{Name: "central", OrgID: 1}},
+				}},
+			},
+		})
+		require.NoError(t, err)
+		builder := &StorageRuleBuilder{Storage: s, SecretsService: fakes.NewFakeSecretsService()}
+		rules, err := builder.BuildRules(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+
+		writeConfigs, err := s.ListWriteConfigs(ctx, 2)
+		require.NoError(t, err)
+		writeConfigs = append(writeConfigs, WriteConfig{OrgId: 1, UID: "mimir", Name: "central", shared: true})
+		wc, ok := resolveWriteConfigRef(WriteConfigRef{Name: "central", OrgID: 1}, writeConfigs)
+		require.True(t, ok)
+		require.Equal(t, "mimir", wc.UID)
+		wc, ok = resolveWriteConfigRef(WriteConfigRef{Name: "central"}, writeConfigs)
+		require.True(t, ok)
+		require.Equal(t, "local", wc.UID)
+		_, ok = resolveWriteConfigRef(WriteConfigRef{Name: "central", OrgID: 3}, writeConfigs)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,439 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline/pattern"
+	"github.com/grafana/grafana/pkg/services/live/pipeline/tree"
+)
+
+// ValidationError is a problem in channel rules, Path is a JSON path to the invalid
+// value in a rules list like rules[0].settings.frameOutputs[1].remoteWrite.uid.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Known types of rule entities, values tell whether a configuration object named
+// after the type is required. Kept in sync with StorageRuleBuilder.
+var (
+	validationSubscriberTypes = map[string]bool{
+		SubscriberTypeBuiltin:       false,
+		SubscriberTypeManagedStream: false,
+		SubscriberTypeMultiple:      true,
+	}
+	validationConverterTypes = map[string]bool{
+		ConverterTypeJsonAuto:   false,
+		ConverterTypeJsonFrame:  false,
+		ConverterTypeInfluxAuto: true,
+		ConverterTypeCBOR:       false,
+		ConverterTypeNMEA:       false,
+	}
+	validationFrameProcessorTypes = map[string]bool{
+		FrameProcessorTypeDropFields:     true,
+		FrameProcessorTypeKeepFields:     true,
+		FrameProcessorTypeFillNull:       true,
+		FrameProcessorTypeJoin:           true,
+		FrameProcessorTypeExplode:        true,
+		FrameProcessorTypeScript:         true,
+		FrameProcessorTypeHistogram:      true,
+		FrameProcessorTypeReshape:        true,
+		FrameProcessorTypeIdentityLabels: true,
+		FrameProcessorTypeSessionWindow:  true,
+		FrameProcessorTypeValueMap:       true,
+		FrameProcessorTypeEncryptFields:  true,
+		FrameProcessorTypeMultiple:       true,
+	}
+	validationConditionTypes = map[string]bool{
+		FrameConditionCheckerTypeNumberCompare: true,
+		FrameConditionCheckerTypeRegexMatch:    true,
+		FrameConditionCheckerTypeFieldPresent:  true,
+		FrameConditionCheckerTypeIsNull:        true,
+		FrameConditionCheckerTypeSchedule:      true,
+		FrameConditionCheckerTypeLabelCompare:  true,
+		FrameConditionCheckerTypeValueChanged:  true,
+		FrameConditionCheckerTypeExpression:    true,
+		FrameConditionCheckerTypeFrameSize:     true,
+		FrameConditionCheckerTypeMultiple:      true,
+	}
+	validationFrameOutputTypes = map[string]bool{
+		FrameOutputTypeManagedStream:     false,
+		FrameOutputTypeLocalSubscribers:  false,
+		FrameOutputTypeRedirect:          true,
+		FrameOutputTypeMultiple:          true,
+		FrameOutputTypeConditional:       true,
+		FrameOutputTypeThreshold:         true,
+		FrameOutputTypeRemoteWrite:       true,
+		FrameOutputTypeLoki:              true,
+		FrameOutputTypeChangeLog:         true,
+		FrameOutputTypeElasticsearch:     true,
+		FrameOutputTypeInflux:            true,
+		FrameOutputTypePostgres:          true,
+		FrameOutputTypeNATS:              true,
+		FrameOutputTypeWebhook:           true,
+		FrameOutputTypeS3:                true,
+		FrameOutputTypeFile:              true,
+		FrameOutputTypeDebug:             true,
+		FrameOutputTypeAnnotation:        true,
+		FrameOutputTypeAlertNotification: true,
+		FrameOutputTypeForward:           true,
+		FrameOutputTypeRetry:             true,
+		FrameOutputTypeAMQP:              true,
+		FrameOutputTypeEventHubs:         true,
+		FrameOutputTypePubSub:            true,
+		FrameOutputTypePushgateway:       true,
+		FrameOutputTypeProcessed:         true,
+		FrameOutputTypeGeoJSON:           true,
+		FrameOutputTypeFailureAlert:      true,
+		FrameOutputTypeRollout:           true,
+	}
+	validationDataOutputTypes = map[string]bool{
+		DataOutputTypeBuiltin:          false,
+		DataOutputTypeLocalSubscribers: false,
+		DataOutputTypeRedirect:         true,
+		DataOutputTypeLoki:             true,
+	}
+)
+
+// ValidateRules checks rules strictly and reports all problems found instead of the
+// first one: unknown types, missing configurations, invalid and conflicting patterns,
+// invalid regular expressions and references to write configs which do not exist.
+// Rules of all organizations can be validated at once, write configs are matched by
+// organization like in FileStorage.
+func ValidateRules(rules []ChannelRule, writeConfigs []WriteConfig) []ValidationError {
+	v := &ruleValidator{
+		errors: []ValidationError{},
+	}
+	trees := map[int64]*tree.Node{}
+	for i, rule := range rules {
+		orgID := rule.OrgId
+		if orgID == 0 {
+			orgID = 1
+		}
+		v.backends = map[string]struct{}{}
+		for _, c := range writeConfigs {
+			if c.OrgId == orgID || (orgID == 1 && c.OrgId == 0) {
+				v.backends[c.UID] = struct{}{}
+			}
+		}
+
+		path := fmt.Sprintf("rules[%d]", i)
+		if ok, reason := pattern.Valid(rule.Pattern); !ok {
+			v.add(path+".pattern", "invalid pattern: %s", reason)
+		} else {
+			t, ok := trees[orgID]
+			if !ok {
+				t = tree.New()
+				trees[orgID] = t
+			}
+			if reason := addRoute(t, rule.Pattern); reason != "" {
+				v.add(path+".pattern", "pattern conflicts with an earlier rule: %s", reason)
+			}
+		}
+		v.settings(path+".settings", rule.Settings)
+	}
+	v.joins(rules)
+	return v.errors
+}
+
+// ValidateRulesFile validates rules of a rules file or directory of rule files. Write
+// configs are read from a write-configs.json file when writeConfigsFile is set, all
+// backend references are reported as unknown otherwise.
+func ValidateRulesFile(rulesFile string, writeConfigsFile string) ([]ValidationError, error) {
+	storage := &FileStorage{RulesFile: rulesFile}
+	channelRules, err := storage.readRulesFile()
+	if err != nil {
+		return nil, err
+	}
+	var writeConfigs WriteConfigs
+	if writeConfigsFile != "" {
+		// Safe to ignore gosec warning G304.
+		// nolint:gosec
+		data, err := os.ReadFile(writeConfigsFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %s file: %w", writeConfigsFile, err)
+		}
+		if err := json.Unmarshal(data, &writeConfigs); err != nil {
+			return nil, fmt.Errorf("can't unmarshal %s data: %w", writeConfigsFile, err)
+		}
+	}
+	return ValidateRules(channelRules.Rules, writeConfigs.Configs), nil
+}
+
+type ruleValidator struct {
+	// backends are UIDs of write configs of a validated rule organization.
+	backends map[string]struct{}
+	errors   []ValidationError
+}
+
+func (v *ruleValidator) add(path string, format string, args ...any) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *ruleValidator) settings(path string, s ChannelRuleSettings) {
+	for i, sub := range s.Subscribers {
+		v.subscriber(fmt.Sprintf("%s.subscribers[%d]", path, i), sub)
+	}
+	if s.Converter != nil {
+		v.typed(path+".converter", "converter", s.Converter.Type, validationConverterTypes, s.Converter)
+	}
+	for i, out := range s.DataOutputters {
+		v.dataOutput(fmt.Sprintf("%s.dataOutputs[%d]", path, i), out)
+	}
+	for i, proc := range s.FrameProcessors {
+		v.frameProcessor(fmt.Sprintf("%s.frameProcessors[%d]", path, i), proc)
+	}
+	for i, out := range s.FrameOutputters {
+		v.frameOutput(fmt.Sprintf("%s.frameOutputs[%d]", path, i), out)
+	}
+	if s.DeadLetterOutputter != nil {
+		v.frameOutput(path+".deadLetterOutput", s.DeadLetterOutputter)
+	}
+	if s.Heartbeat != nil {
+		for i, out := range s.Heartbeat.Outputs {
+			v.frameOutput(fmt.Sprintf("%s.heartbeat.outputs[%d]", path, i), out)
+		}
+	}
+	if s.RateLimit != nil {
+		if _, err := newRateLimit(*s.RateLimit); err != nil {
+			v.add(path+".rateLimit", "%s", err)
+		}
+	}
+	if s.Rollout != nil {
+		if _, err := NewRollout(*s.Rollout, ""); err != nil {
+			v.add(path+".rollout.percentage", "%s", err)
+		}
+	}
+}
+
+// joins checks joined channels are matched by a rule with a join processor in the
+// organization of the joining rule.
+func (v *ruleValidator) joins(rules []ChannelRule) {
+	var orgIDs []int64
+	orgRules := map[int64][]ChannelRule{}
+	orgIndexes := map[int64][]int{}
+	for i, rule := range rules {
+		orgID := rule.OrgId
+		if orgID == 0 {
+			orgID = 1
+		}
+		if _, ok := orgRules[orgID]; !ok {
+			orgIDs = append(orgIDs, orgID)
+		}
+		orgRules[orgID] = append(orgRules[orgID], rule)
+		orgIndexes[orgID] = append(orgIndexes[orgID], i)
+	}
+	var unjoined []unjoinedChannel
+	for _, orgID := range orgIDs {
+		for _, u := range unjoinedChannels(orgRules[orgID]) {
+			u.ruleIndex = orgIndexes[orgID][u.ruleIndex]
+			unjoined = append(unjoined, u)
+		}
+	}
+	sort.SliceStable(unjoined, func(i, j int) bool {
+		return unjoined[i].ruleIndex < unjoined[j].ruleIndex
+	})
+	for _, u := range unjoined {
+		v.add(fmt.Sprintf("rules[%d].settings.frameProcessors", u.ruleIndex),
+			"join channel %s is not matched by a rule with a join processor", u.channel)
+	}
+}
+
+// typed checks a type of an entity is known and its configuration is set when required.
+// Returns false when the entity configuration can't be checked further.
+func (v *ruleValidator) typed(path string, kind string, entityType string, types map[string]bool, config any) bool {
+	if entityType == "" {
+		v.add(path+".type", "%s type is required", kind)
+		return false
+	}
+	required, ok := types[entityType]
+	if !ok {
+		v.add(path+".type", "unknown %s type: %s", kind, entityType)
+		return false
+	}
+	if required && !typedConfigSet(config, entityType) {
+		v.add(path+"."+entityType, "missing configuration for %s", entityType)
+		return false
+	}
+	return true
+}
+
+// typedConfigSet returns true when a field of a config struct with JSON name equal to
+// an entity type is set.
+func typedConfigSet(config any, entityType string) bool {
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if name == entityType {
+			return !value.Field(i).IsNil()
+		}
+	}
+	return false
+}
+
+func (v *ruleValidator) backend(path string, uid string) {
+	if uid == "" {
+		v.add(path, "write config uid is required")
+		return
+	}
+	if _, ok := v.backends[uid]; !ok {
+		v.add(path, "unknown write config: %s", uid)
+	}
+}
+
+func (v *ruleValidator) subscriber(path string, c *SubscriberConfig) {
+	if c == nil || !v.typed(path, "subscriber", c.Type, validationSubscriberTypes, c) {
+		return
+	}
+	if c.MultipleSubscriberConfig != nil {
+		for i := range c.MultipleSubscriberConfig.Subscribers {
+			v.subscriber(fmt.Sprintf("%s.multiple.subscribers[%d]", path, i), &c.MultipleSubscriberConfig.Subscribers[i])
+		}
+	}
+}
+
+func (v *ruleValidator) dataOutput(path string, c *DataOutputterConfig) {
+	if c == nil || !v.typed(path, "data output", c.Type, validationDataOutputTypes, c) {
+		return
+	}
+	if c.Type == DataOutputTypeLoki {
+		v.backend(path+".loki.uid", c.LokiOutputConfig.UID)
+	}
+}
+
+func (v *ruleValidator) frameProcessor(path string, c *FrameProcessorConfig) {
+	if c == nil || !v.typed(path, "processor", c.Type, validationFrameProcessorTypes, c) {
+		return
+	}
+	switch c.Type {
+	case FrameProcessorTypeMultiple:
+		for i := range c.MultipleProcessorConfig.Processors {
+			v.frameProcessor(fmt.Sprintf("%s.multiple.processors[%d]", path, i), &c.MultipleProcessorConfig.Processors[i])
+		}
+	case FrameProcessorTypeEncryptFields:
+		v.backend(path+".encryptFields.uid", c.EncryptFieldsConfig.UID)
+	}
+}
+
+func (v *ruleValidator) condition(path string, c *FrameConditionCheckerConfig) {
+	if c == nil {
+		v.add(path, "condition is required")
+		return
+	}
+	if !v.typed(path, "condition", c.Type, validationConditionTypes, c) {
+		return
+	}
+	switch c.Type {
+	case FrameConditionCheckerTypeMultiple:
+		for i := range c.MultipleConditionCheckerConfig.Conditions {
+			v.condition(fmt.Sprintf("%s.multiple.conditions[%d]", path, i), &c.MultipleConditionCheckerConfig.Conditions[i])
+		}
+	case FrameConditionCheckerTypeRegexMatch:
+		if _, err := NewFrameRegexMatchCondition(c.RegexMatchConditionConfig.FieldName, c.RegexMatchConditionConfig.Pattern); err != nil {
+			v.add(path+".regexMatch.pattern", "%s", err)
+		}
+	case FrameConditionCheckerTypeLabelCompare:
+		cfg := c.LabelCompareConditionConfig
+		if _, err := NewFrameLabelCompareCondition(cfg.FieldName, cfg.Label, cfg.Op, cfg.Value); err != nil {
+			v.add(path+".labelCompare", "%s", err)
+		}
+	}
+}
+
+func (v *ruleValidator) frameOutput(path string, c *FrameOutputterConfig) {
+	if c == nil {
+		v.add(path, "output is required")
+		return
+	}
+	if !v.typed(path, "output", c.Type, validationFrameOutputTypes, c) {
+		return
+	}
+	switch c.Type {
+	case FrameOutputTypeMultiple:
+		for i := range c.MultipleOutputterConfig.Outputters {
+			v.frameOutput(fmt.Sprintf("%s.multiple.outputs[%d]", path, i), &c.MultipleOutputterConfig.Outputters[i])
+		}
+	case FrameOutputTypeConditional:
+		v.condition(path+".conditional.condition", c.ConditionalOutputConfig.Condition)
+		v.frameOutput(path+".conditional.output", c.ConditionalOutputConfig.Outputter)
+	case FrameOutputTypeRetry:
+		v.frameOutput(path+".retry.output", c.RetryOutputConfig.Outputter)
+	case FrameOutputTypeFailureAlert:
+		v.frameOutput(path+".failureAlert.output", c.FailureAlertOutputConfig.Outputter)
+		if c.FailureAlertOutputConfig.WebhookUID != "" {
+			v.backend(path+".failureAlert.webhookUid", c.FailureAlertOutputConfig.WebhookUID)
+		}
+	case FrameOutputTypeRollout:
+		if c.RolloutOutputConfig.Name == "" {
+			v.add(path+".rollout.name", "rollout name is required")
+		}
+		if _, err := NewRollout(c.RolloutOutputConfig.RolloutConfig, ""); err != nil {
+			v.add(path+".rollout.percentage", "%s", err)
+		}
+		v.frameOutput(path+".rollout.output", c.RolloutOutputConfig.Outputter)
+		if c.RolloutOutputConfig.Fallback != nil {
+			v.frameOutput(path+".rollout.fallback", c.RolloutOutputConfig.Fallback)
+		}
+	case FrameOutputTypeProcessed:
+		for i, proc := range c.ProcessedOutputConfig.Processors {
+			v.frameProcessor(fmt.Sprintf("%s.processed.processors[%d]", path, i), proc)
+		}
+		v.frameOutput(path+".processed.output", c.ProcessedOutputConfig.Outputter)
+	case FrameOutputTypeRemoteWrite:
+		if c.RemoteWriteOutputConfig.Backend == nil {
+			v.backend(path+".remoteWrite.uid", c.RemoteWriteOutputConfig.UID)
+		}
+		if err := (&RemoteWriteFrameOutput{}).SetLabels(c.RemoteWriteOutputConfig.Labels); err != nil {
+			v.add(path+".remoteWrite.labels", "%s", err)
+		}
+		for i, rc := range c.RemoteWriteOutputConfig.RelabelConfigs {
+			if _, err := newRelabelConfig(rc); err != nil {
+				v.add(fmt.Sprintf("%s.remoteWrite.relabelConfigs[%d]", path, i), "%s", err)
+			}
+		}
+	default:
+		if uid, ok := frameOutputBackendUID(c); ok {
+			v.backend(path+"."+c.Type+".uid", uid)
+		}
+	}
+}
+
+// frameOutputBackendUID returns a write config UID referenced by an output.
+func frameOutputBackendUID(c *FrameOutputterConfig) (string, bool) {
+	switch c.Type {
+	case FrameOutputTypeLoki:
+		return c.LokiOutputConfig.UID, true
+	case FrameOutputTypeElasticsearch:
+		return c.ElasticsearchConfig.UID, true
+	case FrameOutputTypeInflux:
+		return c.InfluxOutputConfig.UID, true
+	case FrameOutputTypePostgres:
+		return c.PostgresOutputConfig.UID, true
+	case FrameOutputTypeNATS:
+		return c.NATSOutputConfig.UID, true
+	case FrameOutputTypeWebhook:
+		return c.WebhookOutputConfig.UID, true
+	case FrameOutputTypeS3:
+		return c.S3OutputConfig.UID, true
+	case FrameOutputTypeForward:
+		return c.ForwardOutputConfig.UID, true
+	case FrameOutputTypeAMQP:
+		return c.AMQPOutputConfig.UID, true
+	case FrameOutputTypeEventHubs:
+		return c.EventHubsOutputConfig.UID, true
+	case FrameOutputTypePubSub:
+		return c.PubSubOutputConfig.UID, true
+	case FrameOutputTypePushgateway:
+		return c.PushgatewayOutputConfig.UID, true
+	}
+	return "", false
+}
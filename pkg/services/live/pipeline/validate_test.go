@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRules(t *testing.T) {
+	rules := []ChannelRule{
+		{
+			Pattern: "stream/test/:path",
+			Settings: ChannelRuleSettings{
+				Converter: &ConverterConfig{Type: ConverterTypeInfluxAuto},
+				FrameProcessors: []*FrameProcessorConfig{
+					{Type: "unknown"},
+				},
+				FrameOutputters: []*FrameOutputterConfig{
+					{Type: FrameOutputTypeRemoteWrite, RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: "known"}},
+					{Type: FrameOutputTypeRemoteWrite, RemoteWriteOutputConfig: &RemoteWriteOutputConfig{UID: "dangling"}},
+				},
+			},
+		},
+		{
+			// Conflicts with the first rule wildcard.
+			Pattern: "stream/test/:other",
+		},
+		{
+			Pattern: "/stream/invalid",
+		},
+		{
+			OrgId:   2,
+			Pattern: "stream/test/:path",
+			Settings: ChannelRuleSettings{
+				FrameOutputters: []*FrameOutputterConfig{{
+					Type: FrameOutputTypeConditional,
+					ConditionalOutputConfig: &ConditionalOutputConfig{
+						Condition: &FrameConditionCheckerConfig{
+							Type: FrameConditionCheckerTypeMultiple,
+							MultipleConditionCheckerConfig: &MultipleFrameConditionCheckerConfig{
+								ConditionType: ConditionAll,
+								Conditions: []FrameConditionCheckerConfig{
+									{Type: FrameConditionCheckerTypeRegexMatch, RegexMatchConditionConfig: &RegexMatchFrameConditionConfig{FieldName: "value", Pattern: "("}},
+								},
+							},
+						},
+						Outputter: &FrameOutputterConfig{Type: FrameOutputTypeMultiple, MultipleOutputterConfig: &MultipleOutputterConfig{
+							Outputters: []FrameOutputterConfig{
+								{Type: FrameOutputTypeManagedStream},
+								// Write configs of other organizations are not visible.
+								{Type: FrameOutputTypeLoki, LokiOutputConfig: &LokiOutputConfig{UID: "known"}},
+							},
+						}},
+					},
+				}},
+			},
+		},
+	}
+
+	errs := ValidateRules(rules, []WriteConfig{{UID: "known"}})
+	paths := make([]string, 0, len(errs))
+	for _, err := range errs {
+		paths = append(paths, err.Path)
+	}
+	require.Equal(t, []string{
+		"rules[0].settings.converter.influxAuto",
+		"rules[0].settings.frameProcessors[0].type",
+		"rules[0].settings.frameOutputs[1].remoteWrite.uid",
+		"rules[1].pattern",
+		"rules[2].pattern",
+		"rules[3].settings.frameOutputs[0].conditional.condition.multiple.conditions[0].regexMatch.pattern",
+		"rules[3].settings.frameOutputs[0].conditional.output.multiple.outputs[1].loki.uid",
+	}, paths)
+	require.Equal(t, "rules[0].settings.frameProcessors[0].type: unknown processor type: unknown", errs[1].Error())
+	require.Equal(t, "unknown write config: dangling", errs[2].Message)
+}
+
+func TestValidateRulesFile(t *testing.T) {
+	dir := t.TempDir()
+	rulesFile := filepath.Join(dir, "live-channel-rules.json")
+	writeConfigsFile := filepath.Join(dir, "write-configs.json")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`{"rules": [{"pattern": "stream/test", "settings": {"frameOutputs": [{"type": "remoteWrite", "remoteWrite": {"uid": "known"}}]}}]}`), 0600))
+	require.NoError(t, os.WriteFile(writeConfigsFile, []byte(`{"writeConfigs": [{"uid": "known"}]}`), 0600))
+
+	errs, err := ValidateRulesFile(rulesFile, writeConfigsFile)
+	require.NoError(t, err)
+	require.Empty(t, errs)
+
+	errs, err = ValidateRulesFile(rulesFile, "")
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	require.Equal(t, "rules[0].settings.frameOutputs[0].remoteWrite.uid", errs[0].Path)
+
+	_, err = ValidateRulesFile(filepath.Join(dir, "missing.json"), "")
+	require.Error(t, err)
+}
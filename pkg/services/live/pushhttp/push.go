@@ -1,17 +1,22 @@
 package pushhttp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/live/convert"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
 	"github.com/grafana/grafana/pkg/services/live/pushurl"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
@@ -113,11 +118,20 @@ func (g *Gateway) HandlePipelinePush(ctx *contextmodel.ReqContext) {
 		"bodyLength", len(body),
 	)
 
-	ruleFound, err := g.GrafanaLive.Pipeline.ProcessInput(ctx.Req.Context(), ctx.OrgID, channelID, body)
+	messageCtx, ok := withMessageID(ctx)
+	if !ok {
+		return
+	}
+	pipelineCtx := livecontext.SetContextSignedUser(messageCtx, ctx.SignedInUser)
+	ruleFound, err := g.GrafanaLive.Pipeline.ProcessInput(pipelineCtx, ctx.OrgID, channelID, body)
 	if err != nil {
-		logger.Error("Pipeline input processing error", "error", err, "body", string(body))
+		logger.Error("Pipeline input processing error", "error", err, "messageId", pipeline.MessageIDFromContext(messageCtx), "body", string(body))
 		if errors.Is(err, liveDto.ErrInvalidChannelID) {
 			ctx.Resp.WriteHeader(http.StatusBadRequest)
+		} else if errors.Is(err, pipeline.ErrRateLimited) {
+			ctx.Resp.WriteHeader(http.StatusTooManyRequests)
+		} else if errors.Is(err, pipeline.ErrLoadShed) || errors.Is(err, pipeline.ErrPipelineShutdown) {
+			ctx.Resp.WriteHeader(http.StatusServiceUnavailable)
 		} else {
 			ctx.Resp.WriteHeader(http.StatusInternalServerError)
 		}
@@ -131,3 +145,92 @@ func (g *Gateway) HandlePipelinePush(ctx *contextmodel.ReqContext) {
 
 	ctx.Resp.WriteHeader(http.StatusOK)
 }
+
+// HandlePipelinePushFrames accepts data frames in Grafana JSON frame format (a single
+// frame or an array of frames) and passes them to a channel rule after the converter
+// stage, so backend services which already have frames skip conversion.
+func (g *Gateway) HandlePipelinePushFrames(ctx *contextmodel.ReqContext) {
+	channelID := web.Params(ctx.Req)["*"]
+
+	if g.GrafanaLive.Pipeline == nil {
+		ctx.Resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(ctx.Req.Body)
+	if err != nil {
+		logger.Error("Error reading body", "error", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	logger.Debug("Live channel frames push request",
+		"protocol", "http",
+		"channel", channelID,
+		"bodyLength", len(body),
+	)
+
+	frames, err := unmarshalFrames(body)
+	if err != nil {
+		logger.Error("Error decoding frames", "error", err, "channel", channelID)
+		ctx.Resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	messageCtx, ok := withMessageID(ctx)
+	if !ok {
+		return
+	}
+	pipelineCtx := livecontext.SetContextSignedUser(messageCtx, ctx.SignedInUser)
+	ruleFound, err := g.GrafanaLive.Pipeline.ProcessFrames(pipelineCtx, ctx.SignedInUser.GetOrgID(), channelID, frames)
+	if err != nil {
+		logger.Error("Pipeline frames processing error", "error", err, "messageId", pipeline.MessageIDFromContext(messageCtx), "channel", channelID)
+		if errors.Is(err, liveDto.ErrInvalidChannelID) {
+			ctx.Resp.WriteHeader(http.StatusBadRequest)
+		} else if errors.Is(err, pipeline.ErrRateLimited) {
+			ctx.Resp.WriteHeader(http.StatusTooManyRequests)
+		} else if errors.Is(err, pipeline.ErrLoadShed) || errors.Is(err, pipeline.ErrPipelineShutdown) {
+			ctx.Resp.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	if !ruleFound {
+		logger.Error("No rule for a channel", "channel", channelID)
+		ctx.Resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	ctx.Resp.WriteHeader(http.StatusOK)
+}
+
+// withMessageID returns a request context with a message ID from the message ID header,
+// or a new one, and sets it in the response header. It responds with 400 to invalid IDs.
+func withMessageID(ctx *contextmodel.ReqContext) (context.Context, bool) {
+	messageID := ctx.Req.Header.Get(pipeline.MessageIDHeader)
+	if messageID == "" {
+		messageID = pipeline.NewMessageID()
+	} else if !pipeline.IsValidMessageID(messageID) {
+		logger.Error("Invalid message ID", "messageId", messageID)
+		ctx.Resp.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+	ctx.Resp.Header().Set(pipeline.MessageIDHeader, messageID)
+	return pipeline.WithMessageID(ctx.Req.Context(), messageID), true
+}
+
+func unmarshalFrames(body []byte) ([]*data.Frame, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var frames []*data.Frame
+		if err := json.Unmarshal(body, &frames); err != nil {
+			return nil, err
+		}
+		return frames, nil
+	}
+	var frame data.Frame
+	if err := json.Unmarshal(body, &frame); err != nil {
+		return nil, err
+	}
+	return []*data.Frame{&frame}, nil
+}
@@ -0,0 +1,121 @@
+package pushhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type testRuleGetter struct {
+	rules map[string]*pipeline.LiveChannelRule
+}
+
+func (g *testRuleGetter) Get(_ int64, channel string) (*pipeline.LiveChannelRule, bool, error) {
+	rule, ok := g.rules[channel]
+	return rule, ok, nil
+}
+
+// testOutput records frames with users they were pushed by, a frame named failAt fails.
+type testOutput struct {
+	mu     sync.Mutex
+	frames []string
+	users  []string
+	failAt string
+}
+
+func (o *testOutput) Type() string {
+	return "test"
+}
+
+func (o *testOutput) OutputFrame(ctx context.Context, _ pipeline.Vars, frame *data.Frame) ([]*pipeline.ChannelFrame, error) {
+	if frame.Name == o.failAt {
+		return nil, errors.New("boom")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.frames = append(o.frames, frame.Name)
+	var userID string
+	if u, ok := livecontext.GetContextSignedUser(ctx); ok {
+		_, userID = u.GetNamespacedID()
+	}
+	o.users = append(o.users, userID)
+	return nil, nil
+}
+
+func newTestGateway(t *testing.T, out *testOutput) *Gateway {
+	t.Helper()
+	p, err := pipeline.New(&testRuleGetter{rules: map[string]*pipeline.LiveChannelRule{
+		"stream/test/frames": {
+			Pattern:         "stream/test/frames",
+			FrameOutputters: []pipeline.FrameOutputter{out},
+		},
+	}})
+	require.NoError(t, err)
+	return &Gateway{GrafanaLive: &live.GrafanaLive{Pipeline: p}}
+}
+
+func pushFrames(g *Gateway, channel string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/live/pipeline/push-frames/"+channel, strings.NewReader(body))
+	req = web.SetURLParams(req, map[string]string{"*": channel})
+	g.HandlePipelinePushFrames(&contextmodel.ReqContext{
+		Context: &web.Context{
+			Req:  req,
+			Resp: web.NewResponseWriter(http.MethodPost, recorder),
+		},
+		SignedInUser: &user.SignedInUser{OrgID: 1, UserID: 7},
+	})
+	return recorder
+}
+
+func TestHandlePipelinePushFrames(t *testing.T) {
+	out := &testOutput{}
+	g := newTestGateway(t, out)
+
+	rsp := pushFrames(g, "stream/test/frames", `[{"schema":{"name":"a","fields":[]}},{"schema":{"name":"b","fields":[]}}]`)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.NotEmpty(t, rsp.Header().Get(pipeline.MessageIDHeader))
+	require.Equal(t, []string{"a", "b"}, out.frames)
+	// Outputs see the user who pushed frames.
+	require.Equal(t, []string{"7", "7"}, out.users)
+
+	rsp = pushFrames(g, "stream/test/frames", `{"schema":{"name":"c","fields":[]}}`)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	require.Equal(t, []string{"a", "b", "c"}, out.frames)
+}
+
+func TestHandlePipelinePushFrames_badRequest(t *testing.T) {
+	out := &testOutput{}
+	g := newTestGateway(t, out)
+
+	rsp := pushFrames(g, "stream/test/frames", `[{"schema":`)
+	require.Equal(t, http.StatusBadRequest, rsp.Code)
+	require.Empty(t, out.frames)
+
+	rsp = pushFrames(g, "stream/test/unknown", `{"schema":{"name":"a","fields":[]}}`)
+	require.Equal(t, http.StatusNotFound, rsp.Code)
+}
+
+func TestHandlePipelinePushFrames_partialFailure(t *testing.T) {
+	out := &testOutput{failAt: "b"}
+	g := newTestGateway(t, out)
+
+	rsp := pushFrames(g, "stream/test/frames", `[{"schema":{"name":"a","fields":[]}},{"schema":{"name":"b","fields":[]}},{"schema":{"name":"c","fields":[]}}]`)
+	require.Equal(t, http.StatusInternalServerError, rsp.Code)
+	// Frames before the failed one are processed, the rest is not.
+	require.Equal(t, []string{"a"}, out.frames)
+}
@@ -0,0 +1,33 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+// entitySampleGetter reads sample payloads of pipeline rules from jsonobj entities.
+type entitySampleGetter struct {
+	store entity.EntityStoreServer
+}
+
+var _ pipeline.SampleGetter = &entitySampleGetter{}
+
+func (g *entitySampleGetter) GetSample(ctx context.Context, orgID int64, uid string) ([]byte, error) {
+	rsp, err := readJSONObjEntity(ctx, g.store, orgID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.GRN == nil {
+		return nil, fmt.Errorf("sample entity not found: %s", uid)
+	}
+	// Payloads which are not JSON, like Influx line protocol, are kept as JSON strings.
+	var raw string
+	if err := json.Unmarshal(rsp.Body, &raw); err == nil {
+		return []byte(raw), nil
+	}
+	return rsp.Body, nil
+}
@@ -0,0 +1,45 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// entitySnapshotWriter saves channel snapshots as versions of data frame entities.
+type entitySnapshotWriter struct {
+	store entity.EntityStoreServer
+}
+
+var _ pipeline.SnapshotWriter = &entitySnapshotWriter{}
+
+func (w *entitySnapshotWriter) WriteSnapshot(ctx context.Context, orgID int64, uid string, channel string, frame json.RawMessage) error {
+	// Snapshots are taken outside of user requests, write them as an org admin.
+	ctx = appcontext.WithUser(ctx, &user.SignedInUser{
+		OrgID:   orgID,
+		OrgRole: org.RoleAdmin,
+	})
+	rsp, err := w.store.Write(ctx, &entity.WriteEntityRequest{
+		GRN: &grn.GRN{
+			TenantID:           orgID,
+			ResourceKind:       entity.StandardKindDataFrame,
+			ResourceIdentifier: uid,
+		},
+		Body:    frame,
+		Comment: "Snapshot of " + channel,
+	})
+	if err != nil {
+		return err
+	}
+	if rsp.Error != nil {
+		return errors.New(rsp.Error.Message)
+	}
+	return nil
+}
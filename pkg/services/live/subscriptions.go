@@ -0,0 +1,204 @@
+package live
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/gobwas/glob"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// connectionRegistry keeps track of connection start times since Centrifuge
+// does not expose this information on a Client.
+type connectionRegistry struct {
+	mu          sync.RWMutex
+	connectedAt map[string]time.Time
+}
+
+func newConnectionRegistry() *connectionRegistry {
+	return &connectionRegistry{
+		connectedAt: map[string]time.Time{},
+	}
+}
+
+func (r *connectionRegistry) add(clientID string, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connectedAt[clientID] = t
+}
+
+func (r *connectionRegistry) remove(clientID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.connectedAt, clientID)
+}
+
+func (r *connectionRegistry) get(clientID string) (time.Time, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.connectedAt[clientID]
+	return t, ok
+}
+
+// ChannelSubscriber describes a single connection subscribed to a channel.
+type ChannelSubscriber struct {
+	Channel     string    `json:"channel"`
+	ClientID    string    `json:"clientId"`
+	Namespace   string    `json:"namespace"`
+	UserID      string    `json:"userId"`
+	Login       string    `json:"login,omitempty"`
+	Transport   string    `json:"transport"`
+	Protocol    string    `json:"protocol"`
+	ConnectedAt time.Time `json:"connectedAt,omitempty"`
+	// AgeSeconds is a connection age in seconds.
+	AgeSeconds int64 `json:"ageSeconds"`
+}
+
+type channelSubscribersResponse struct {
+	Subscribers []ChannelSubscriber `json:"subscribers"`
+}
+
+// channelFilter matches org-local channel names against an exact channel
+// name or a glob pattern. Empty filter matches all channels.
+type channelFilter struct {
+	channel string
+	pattern glob.Glob
+}
+
+func newChannelFilter(channel string, pattern string) (*channelFilter, error) {
+	f := &channelFilter{channel: channel}
+	if pattern != "" {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, err
+		}
+		f.pattern = g
+	}
+	return f, nil
+}
+
+func (f *channelFilter) match(channel string) bool {
+	if f.channel != "" && f.channel != channel {
+		return false
+	}
+	if f.pattern != nil && !f.pattern.Match(channel) {
+		return false
+	}
+	return true
+}
+
+// channelSubscribers returns subscribers of org channels matching the filter on the current node.
+func (g *GrafanaLive) channelSubscribers(orgID int64, filter *channelFilter) []ChannelSubscriber {
+	now := time.Now()
+	var subscribers []ChannelSubscriber
+	for _, client := range g.node.Hub().Connections() {
+		for _, orgChannel := range client.Channels() {
+			channelOrgID, channel, err := orgchannel.StripOrgID(orgChannel)
+			if err != nil || channelOrgID != orgID || !filter.match(channel) {
+				continue
+			}
+			subscriber := ChannelSubscriber{
+				Channel:   channel,
+				ClientID:  client.ID(),
+				UserID:    client.UserID(),
+				Transport: client.Transport().Name(),
+				Protocol:  string(client.Transport().Protocol()),
+			}
+			if user, ok := livecontext.GetContextSignedUser(client.Context()); ok {
+				subscriber.Namespace, subscriber.UserID = user.GetNamespacedID()
+				subscriber.Login = user.GetLogin()
+			}
+			if connectedAt, ok := g.connections.get(client.ID()); ok {
+				subscriber.ConnectedAt = connectedAt
+				subscriber.AgeSeconds = int64(now.Sub(connectedAt).Seconds())
+			}
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	sort.Slice(subscribers, func(i, j int) bool {
+		if subscribers[i].Channel != subscribers[j].Channel {
+			return subscribers[i].Channel < subscribers[j].Channel
+		}
+		return subscribers[i].ClientID < subscribers[j].ClientID
+	})
+	return subscribers
+}
+
+// HandleSubscriptionsListHTTP returns active subscribers of channels on this node. Subscribers
+// can be filtered by exact channel name (?channel=) or glob pattern (?pattern=).
+func (g *GrafanaLive) HandleSubscriptionsListHTTP(c *contextmodel.ReqContext) response.Response {
+	query := c.Req.URL.Query()
+	filter, err := newChannelFilter(query.Get("channel"), query.Get("pattern"))
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid channel pattern", err)
+	}
+	return response.JSON(http.StatusOK, channelSubscribersResponse{
+		Subscribers: g.channelSubscribers(c.SignedInUser.GetOrgID(), filter),
+	})
+}
+
+// SubscriptionDisconnectCmd describes connections to forcefully remove from channels.
+type SubscriptionDisconnectCmd struct {
+	// ClientID limits the action to a single connection.
+	ClientID string `json:"clientId"`
+	// Channel limits the action to subscribers of a single channel.
+	Channel string `json:"channel"`
+	// Pattern limits the action to subscribers of channels matching a glob pattern.
+	Pattern string `json:"pattern"`
+	// Unsubscribe only removes matched subscriptions instead of closing the
+	// whole connection.
+	Unsubscribe bool `json:"unsubscribe"`
+}
+
+// HandleSubscriptionsDisconnectHTTP forcefully disconnects (or unsubscribes) subscribers
+// matching the command. Disconnected clients are not allowed to reconnect automatically.
+func (g *GrafanaLive) HandleSubscriptionsDisconnectHTTP(c *contextmodel.ReqContext) response.Response {
+	var cmd SubscriptionDisconnectCmd
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.ClientID == "" && cmd.Channel == "" && cmd.Pattern == "" {
+		return response.Error(http.StatusBadRequest, "clientId, channel or pattern required", errors.New("empty disconnect command"))
+	}
+	filter, err := newChannelFilter(cmd.Channel, cmd.Pattern)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Invalid channel pattern", err)
+	}
+
+	orgID := c.SignedInUser.GetOrgID()
+	subscribers := g.channelSubscribers(orgID, filter)
+	connections := g.node.Hub().Connections()
+
+	disconnected := map[string]struct{}{}
+	affected := make([]ChannelSubscriber, 0, len(subscribers))
+	for _, s := range subscribers {
+		if cmd.ClientID != "" && cmd.ClientID != s.ClientID {
+			continue
+		}
+		client, ok := connections[s.ClientID]
+		if !ok {
+			continue
+		}
+		if cmd.Unsubscribe {
+			client.Unsubscribe(orgchannel.PrependOrgID(orgID, s.Channel))
+		} else if _, ok := disconnected[s.ClientID]; !ok {
+			client.Disconnect(centrifuge.DisconnectForceNoReconnect)
+			disconnected[s.ClientID] = struct{}{}
+		}
+		affected = append(affected, s)
+	}
+	logger.Info("Live subscribers removed by admin", "user", c.SignedInUser.GetLogin(), "count", len(affected), "unsubscribe", cmd.Unsubscribe)
+	return response.JSON(http.StatusOK, util.DynMap{
+		"affected": affected,
+	})
+}
@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/common/model"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// NotifyContactPoint sends a single alert with the given labels and annotations to a contact point
+// of an organization. Notification policies, grouping and silences are not applied.
+func (moa *MultiOrgAlertmanager) NotifyContactPoint(ctx context.Context, orgID int64, contactPoint string, labels map[string]string, annotations map[string]string) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+
+	query := models.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID}
+	amConfig, err := moa.configStore.GetLatestAlertmanagerConfiguration(ctx, &query)
+	if err != nil {
+		return fmt.Errorf("failed to get latest configuration: %w", err)
+	}
+	cfg, err := Load([]byte(amConfig.AlertmanagerConfiguration))
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal alertmanager configuration: %w", err)
+	}
+
+	var receiver *apimodels.PostableApiReceiver
+	for _, r := range cfg.AlertmanagerConfig.Receivers {
+		if r.Name == contactPoint {
+			receiver = r
+			break
+		}
+	}
+	if receiver == nil {
+		return fmt.Errorf("contact point %q not found", contactPoint)
+	}
+
+	alert := &apimodels.TestReceiversConfigAlertParams{
+		Labels:      make(model.LabelSet, len(labels)),
+		Annotations: make(model.LabelSet, len(annotations)),
+	}
+	for k, v := range labels {
+		alert.Labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	for k, v := range annotations {
+		alert.Annotations[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	result, err := am.TestReceivers(ctx, apimodels.TestReceiversConfigBodyParams{
+		Alert:     alert,
+		Receivers: []*apimodels.PostableApiReceiver{receiver},
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range result.Receivers {
+		for _, c := range r.Configs {
+			if c.Error != nil {
+				return fmt.Errorf("failed to notify %s integration %s: %w", contactPoint, c.Name, c.Error)
+			}
+		}
+	}
+	return nil
+}
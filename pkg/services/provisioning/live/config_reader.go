@@ -0,0 +1,141 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+)
+
+type configReader struct {
+	log        log.Logger
+	orgService org.Service
+}
+
+func (cr *configReader) readConfig(ctx context.Context, path string) ([]*liveAsConfig, error) {
+	var configs []*liveAsConfig
+	cr.log.Debug("Looking for live provisioning files", "path", path)
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Failed to read live provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing live provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseLiveConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating live pipeline configs")
+	if err := cr.validateLiveConfigs(ctx, configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func (cr *configReader) parseLiveConfig(path string, file fs.DirEntry) (*liveAsConfig, error) {
+	filename, err := filepath.Abs(filepath.Join(path, file.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiVersion *configVersion
+	err = yaml.Unmarshal(yamlFile, &apiVersion)
+	if err != nil {
+		return nil, err
+	}
+	if apiVersion == nil {
+		apiVersion = &configVersion{APIVersion: 1}
+	}
+	if apiVersion.APIVersion > 1 {
+		return nil, fmt.Errorf("unsupported live provisioning config version %d in %s", apiVersion.APIVersion, filename)
+	}
+
+	var cfg *liveAsConfigV1
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToLiveFromConfig(), nil
+}
+
+// validateLiveConfigs checks required fields, defaults organization IDs to the main
+// organization and makes sure rule settings are valid before anything is provisioned.
+func (cr *configReader) validateLiveConfigs(ctx context.Context, configs []*liveAsConfig) error {
+	for _, cfg := range configs {
+		for index, rule := range cfg.ChannelRules {
+			if rule.Pattern == "" {
+				return fmt.Errorf("channel rule item %d in configuration doesn't contain required field pattern", index+1)
+			}
+			rule.OrgID = defaultOrgID(rule.OrgID)
+			if err := utils.CheckOrgExists(ctx, cr.orgService, rule.OrgID); err != nil {
+				return fmt.Errorf("failed to provision %q channel rule: %w", rule.Pattern, err)
+			}
+			settings, err := rule.channelRuleSettings()
+			if err != nil {
+				return fmt.Errorf("invalid settings of %q channel rule: %w", rule.Pattern, err)
+			}
+			channelRule := pipeline.ChannelRule{OrgId: rule.OrgID, Pattern: rule.Pattern, Settings: settings}
+			if ok, reason := channelRule.Valid(); !ok {
+				return fmt.Errorf("invalid %q channel rule: %s", rule.Pattern, reason)
+			}
+		}
+
+		for index, wc := range cfg.WriteConfigs {
+			if wc.UID == "" {
+				return fmt.Errorf("write config item %d in configuration doesn't contain required field uid", index+1)
+			}
+			if wc.Endpoint == "" {
+				return fmt.Errorf("write config item %d in configuration doesn't contain required field endpoint", index+1)
+			}
+			wc.OrgID = defaultOrgID(wc.OrgID)
+			if err := utils.CheckOrgExists(ctx, cr.orgService, wc.OrgID); err != nil {
+				return fmt.Errorf("failed to provision %q write config: %w", wc.UID, err)
+			}
+		}
+
+		for _, rule := range cfg.DeleteChannelRules {
+			rule.OrgID = defaultOrgID(rule.OrgID)
+		}
+		for _, wc := range cfg.DeleteWriteConfigs {
+			wc.OrgID = defaultOrgID(wc.OrgID)
+		}
+	}
+
+	return nil
+}
+
+func defaultOrgID(orgID int64) int64 {
+	if orgID < 1 {
+		return 1
+	}
+	return orgID
+}
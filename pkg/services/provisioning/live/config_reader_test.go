@@ -0,0 +1,84 @@
+package live
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+)
+
+const (
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+	missingPattern    = "./testdata/test-configs/missing-pattern"
+	correctProperties = "./testdata/test-configs/correct-properties"
+)
+
+func TestConfigReader(t *testing.T) {
+	orgFake := &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: 1}}
+
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger"), orgService: orgFake}
+		_, err := reader.readConfig(context.Background(), brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger"), orgService: orgFake}
+		cfg, err := reader.readConfig(context.Background(), emptyFolder)
+		require.NoError(t, err)
+		require.Len(t, cfg, 0)
+	})
+
+	t.Run("Channel rule without pattern should return error", func(t *testing.T) {
+		reader := &configReader{log: log.New("test logger"), orgService: orgFake}
+		_, err := reader.readConfig(context.Background(), missingPattern)
+		require.Error(t, err)
+		require.Equal(t, "channel rule item 1 in configuration doesn't contain required field pattern", err.Error())
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		t.Setenv("REMOTE_WRITE_URL", "http://prometheus:9090/api/v1/write")
+		t.Setenv("REMOTE_WRITE_PASSWORD", "secret")
+		t.Setenv("CHANNEL_PATH", "metrics")
+
+		reader := &configReader{log: log.New("test logger"), orgService: orgFake}
+		cfg, err := reader.readConfig(context.Background(), correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		require.Len(t, cfg[0].DeleteChannelRules, 1)
+		require.Equal(t, &deleteChannelRuleConfig{OrgID: 1, Pattern: "stream/old/metrics"}, cfg[0].DeleteChannelRules[0])
+
+		require.Len(t, cfg[0].WriteConfigs, 1)
+		wc := cfg[0].WriteConfigs[0]
+		require.Equal(t, int64(2), wc.OrgID)
+		require.Equal(t, "prometheus", wc.UID)
+		require.Equal(t, "Prometheus", wc.Name)
+		require.Equal(t, pipeline.WriteSettings{
+			Endpoint:  "http://prometheus:9090/api/v1/write",
+			BasicAuth: &pipeline.BasicAuth{User: "grafana"},
+		}, wc.writeSettings())
+		require.Equal(t, map[string]string{"basicAuthPassword": "secret"}, wc.SecureSettings)
+		require.Equal(t, &pipeline.WriteConfigSharing{OrgIDs: []int64{3}}, wc.Sharing)
+
+		require.Len(t, cfg[0].ChannelRules, 2)
+		rule := cfg[0].ChannelRules[0]
+		require.Equal(t, int64(2), rule.OrgID)
+		require.Equal(t, "stream/telegraf/cpu", rule.Pattern)
+		settings, err := rule.channelRuleSettings()
+		require.NoError(t, err)
+		require.Equal(t, pipeline.ConverterTypeInfluxAuto, settings.Converter.Type)
+		require.Len(t, settings.FrameOutputters, 1)
+		require.Equal(t, "prometheus", settings.FrameOutputters[0].RemoteWriteOutputConfig.UID)
+		require.Equal(t, int64(1000), settings.FrameOutputters[0].RemoteWriteOutputConfig.SampleMilliseconds)
+
+		require.Equal(t, int64(1), cfg[0].ChannelRules[1].OrgID)
+		require.Equal(t, "stream/test/metrics", cfg[0].ChannelRules[1].Pattern)
+	})
+}
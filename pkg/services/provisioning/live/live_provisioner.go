@@ -0,0 +1,95 @@
+package live
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+// Provision scans a directory for provisioning config files
+// and provisions the live pipeline channel rules and write configs in those files.
+func Provision(ctx context.Context, configDirectory string, storage pipeline.Storage, orgService org.Service) error {
+	logger := log.New("provisioning.live")
+	lp := LiveProvisioner{
+		log:       logger,
+		cfgReader: &configReader{log: logger, orgService: orgService},
+		storage:   storage,
+	}
+	return lp.applyChanges(ctx, configDirectory)
+}
+
+// LiveProvisioner is responsible for provisioning live pipeline channel rules
+// and write configs based on configuration read by the `configReader`.
+type LiveProvisioner struct {
+	log       log.Logger
+	cfgReader *configReader
+	storage   pipeline.Storage
+}
+
+func (lp *LiveProvisioner) apply(ctx context.Context, cfg *liveAsConfig) error {
+	for _, wc := range cfg.DeleteWriteConfigs {
+		lp.log.Info("Deleting live write config from configuration", "uid", wc.UID, "orgId", wc.OrgID)
+		err := lp.storage.DeleteWriteConfig(ctx, wc.OrgID, pipeline.WriteConfigDeleteCmd{UID: wc.UID})
+		if err != nil && !errors.Is(err, pipeline.ErrWriteConfigNotFound) {
+			return err
+		}
+	}
+
+	// Write configs go first so that provisioned rules can reference them.
+	for _, wc := range cfg.WriteConfigs {
+		lp.log.Info("Updating live write config from configuration", "uid", wc.UID, "orgId", wc.OrgID)
+		_, err := lp.storage.UpdateWriteConfig(ctx, wc.OrgID, pipeline.WriteConfigUpdateCmd{
+			UID:            wc.UID,
+			Name:           wc.Name,
+			Settings:       wc.writeSettings(),
+			SecureSettings: wc.SecureSettings,
+			Sharing:        wc.Sharing,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range cfg.DeleteChannelRules {
+		lp.log.Info("Deleting live channel rule from configuration", "pattern", rule.Pattern, "orgId", rule.OrgID)
+		err := lp.storage.DeleteChannelRule(ctx, rule.OrgID, pipeline.ChannelRuleDeleteCmd{Pattern: rule.Pattern})
+		if err != nil && !errors.Is(err, pipeline.ErrChannelRuleNotFound) {
+			return err
+		}
+	}
+
+	for _, rule := range cfg.ChannelRules {
+		settings, err := rule.channelRuleSettings()
+		if err != nil {
+			return err
+		}
+		lp.log.Info("Updating live channel rule from configuration", "pattern", rule.Pattern, "orgId", rule.OrgID)
+		_, err = lp.storage.UpdateChannelRule(ctx, rule.OrgID, pipeline.ChannelRuleUpdateCmd{
+			Pattern:  rule.Pattern,
+			Settings: settings,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (lp *LiveProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := lp.cfgReader.readConfig(ctx, configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := lp.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package live
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// configVersion is used to figure out which API version a config uses.
+type configVersion struct {
+	APIVersion int64 `json:"apiVersion" yaml:"apiVersion"`
+}
+
+// liveAsConfig is a normalized data object for live pipeline config data. Any config
+// version should be mappable to this type.
+type liveAsConfig struct {
+	ChannelRules       []*channelRuleFromConfig
+	DeleteChannelRules []*deleteChannelRuleConfig
+	WriteConfigs       []*writeConfigFromConfig
+	DeleteWriteConfigs []*deleteWriteConfigConfig
+}
+
+type channelRuleFromConfig struct {
+	OrgID    int64
+	Pattern  string
+	Settings map[string]any
+}
+
+type deleteChannelRuleConfig struct {
+	OrgID   int64
+	Pattern string
+}
+
+type writeConfigFromConfig struct {
+	OrgID          int64
+	UID            string
+	Name           string
+	Endpoint       string
+	BasicAuthUser  string
+	SecureSettings map[string]string
+	Sharing        *pipeline.WriteConfigSharing
+}
+
+type deleteWriteConfigConfig struct {
+	OrgID int64
+	UID   string
+}
+
+type liveAsConfigV1 struct {
+	configVersion
+
+	ChannelRules       []*channelRuleFromConfigV1   `json:"channelRules" yaml:"channelRules"`
+	DeleteChannelRules []*deleteChannelRuleConfigV1 `json:"deleteChannelRules" yaml:"deleteChannelRules"`
+	WriteConfigs       []*writeConfigFromConfigV1   `json:"writeConfigs" yaml:"writeConfigs"`
+	DeleteWriteConfigs []*deleteWriteConfigConfigV1 `json:"deleteWriteConfigs" yaml:"deleteWriteConfigs"`
+}
+
+type channelRuleFromConfigV1 struct {
+	OrgID    values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Pattern  values.StringValue `json:"pattern" yaml:"pattern"`
+	Settings values.JSONValue   `json:"settings" yaml:"settings"`
+}
+
+type deleteChannelRuleConfigV1 struct {
+	OrgID   values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Pattern values.StringValue `json:"pattern" yaml:"pattern"`
+}
+
+type writeConfigFromConfigV1 struct {
+	OrgID          values.Int64Value     `json:"orgId" yaml:"orgId"`
+	UID            values.StringValue    `json:"uid" yaml:"uid"`
+	Name           values.StringValue    `json:"name" yaml:"name"`
+	Endpoint       values.StringValue    `json:"endpoint" yaml:"endpoint"`
+	BasicAuthUser  values.StringValue    `json:"basicAuthUser" yaml:"basicAuthUser"`
+	SecureSettings values.StringMapValue `json:"secureSettings" yaml:"secureSettings"`
+	Sharing        *sharingFromConfigV1  `json:"sharing" yaml:"sharing"`
+}
+
+type sharingFromConfigV1 struct {
+	OrgIDs  []int64 `json:"orgIds" yaml:"orgIds"`
+	TeamIDs []int64 `json:"teamIds" yaml:"teamIds"`
+}
+
+type deleteWriteConfigConfigV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	UID   values.StringValue `json:"uid" yaml:"uid"`
+}
+
+// mapToLiveFromConfig maps config syntax to a normalized liveAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *liveAsConfigV1) mapToLiveFromConfig() *liveAsConfig {
+	r := &liveAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, rule := range cfg.ChannelRules {
+		if rule == nil {
+			continue
+		}
+		r.ChannelRules = append(r.ChannelRules, &channelRuleFromConfig{
+			OrgID:    rule.OrgID.Value(),
+			Pattern:  rule.Pattern.Value(),
+			Settings: rule.Settings.Value(),
+		})
+	}
+
+	for _, rule := range cfg.DeleteChannelRules {
+		if rule == nil {
+			continue
+		}
+		r.DeleteChannelRules = append(r.DeleteChannelRules, &deleteChannelRuleConfig{
+			OrgID:   rule.OrgID.Value(),
+			Pattern: rule.Pattern.Value(),
+		})
+	}
+
+	for _, wc := range cfg.WriteConfigs {
+		if wc == nil {
+			continue
+		}
+		writeConfig := &writeConfigFromConfig{
+			OrgID:          wc.OrgID.Value(),
+			UID:            wc.UID.Value(),
+			Name:           wc.Name.Value(),
+			Endpoint:       wc.Endpoint.Value(),
+			BasicAuthUser:  wc.BasicAuthUser.Value(),
+			SecureSettings: wc.SecureSettings.Value(),
+		}
+		if wc.Sharing != nil {
+			writeConfig.Sharing = &pipeline.WriteConfigSharing{
+				OrgIDs:  wc.Sharing.OrgIDs,
+				TeamIDs: wc.Sharing.TeamIDs,
+			}
+		}
+		r.WriteConfigs = append(r.WriteConfigs, writeConfig)
+	}
+
+	for _, wc := range cfg.DeleteWriteConfigs {
+		if wc == nil {
+			continue
+		}
+		r.DeleteWriteConfigs = append(r.DeleteWriteConfigs, &deleteWriteConfigConfig{
+			OrgID: wc.OrgID.Value(),
+			UID:   wc.UID.Value(),
+		})
+	}
+
+	return r
+}
+
+// channelRuleSettings decodes interpolated rule settings into pipeline rule settings.
+func (rule *channelRuleFromConfig) channelRuleSettings() (pipeline.ChannelRuleSettings, error) {
+	var settings pipeline.ChannelRuleSettings
+	if rule.Settings == nil {
+		return settings, nil
+	}
+	data, err := json.Marshal(rule.Settings)
+	if err != nil {
+		return settings, err
+	}
+	err = json.Unmarshal(data, &settings)
+	return settings, err
+}
+
+func (wc *writeConfigFromConfig) writeSettings() pipeline.WriteSettings {
+	settings := pipeline.WriteSettings{Endpoint: wc.Endpoint}
+	if wc.BasicAuthUser != "" {
+		settings.BasicAuth = &pipeline.BasicAuth{User: wc.BasicAuthUser}
+	}
+	return settings
+}
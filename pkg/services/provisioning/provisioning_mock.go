@@ -9,6 +9,7 @@ type Calls struct {
 	ProvisionNotifications              []any
 	ProvisionDashboards                 []any
 	ProvisionAlerting                   []any
+	ProvisionLive                       []any
 	GetDashboardProvisionerResolvedPath []any
 	GetAllowUIUpdatesFromConfig         []any
 	Run                                 []any
@@ -77,6 +78,11 @@ func (mock *ProvisioningServiceMock) ProvisionAlerting(ctx context.Context) erro
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionLive(ctx context.Context) error {
+	mock.Calls.ProvisionLive = append(mock.Calls.ProvisionLive, nil)
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) GetDashboardProvisionerResolvedPath(name string) string {
 	mock.Calls.GetDashboardProvisionerResolvedPath = append(mock.Calls.GetDashboardProvisionerResolvedPath, name)
 	if mock.GetDashboardProvisionerResolvedPathFunc != nil {
@@ -28,6 +28,8 @@ const (
 	documentFieldName        = "name"
 	documentFieldName_sort   = "name_sort"
 	documentFieldName_ngram  = "name_ngram"
+	documentFieldText        = "text" // name and description analyzed according to language
+	documentFieldLanguage    = "language"
 	documentFieldLocation    = "location" // parent path
 	documentFieldPanelType   = "panel_type"
 	documentFieldTransformer = "transformer"
@@ -144,7 +146,7 @@ func getFolderDashboardDoc(dash dashboard) *bluge.Document {
 		dash.summary.Description = ""
 	}
 
-	return newSearchDocument(uid, dash.summary.Name, dash.summary.Description, url).
+	return newSearchDocument(uid, dash.summary.Name, dash.summary.Description, url, summaryLanguage(dash.summary)).
 		AddField(bluge.NewKeywordField(documentFieldKind, string(entityKindFolder)).Aggregatable().StoreValue()).
 		AddField(bluge.NewDateTimeField(DocumentFieldCreatedAt, dash.created).Sortable().StoreValue()).
 		AddField(bluge.NewDateTimeField(DocumentFieldUpdatedAt, dash.updated).Sortable().StoreValue())
@@ -154,7 +156,7 @@ func getNonFolderDashboardDoc(dash dashboard, location string) *bluge.Document {
 	url := fmt.Sprintf("/d/%s/%s", dash.uid, dash.slug)
 
 	// Dashboard document
-	doc := newSearchDocument(dash.uid, dash.summary.Name, dash.summary.Description, url).
+	doc := newSearchDocument(dash.uid, dash.summary.Name, dash.summary.Description, url, summaryLanguage(dash.summary)).
 		AddField(bluge.NewKeywordField(documentFieldKind, string(entityKindDashboard)).Aggregatable().StoreValue()).
 		AddField(bluge.NewKeywordField(documentFieldLocation, location).Aggregatable().StoreValue()).
 		AddField(bluge.NewDateTimeField(DocumentFieldCreatedAt, dash.created).Sortable().StoreValue()).
@@ -190,6 +192,8 @@ func getNonFolderDashboardDoc(dash dashboard, location string) *bluge.Document {
 
 func getDashboardPanelDocs(dash dashboard, location string) []*bluge.Document {
 	dashURL := fmt.Sprintf("/d/%s/%s", dash.uid, slugify.Slugify(dash.summary.Name))
+	// Panels share the language of a dashboard.
+	lang := summaryLanguage(dash.summary)
 
 	var docs []*bluge.Document
 	for _, panel := range dash.summary.Nested {
@@ -203,7 +207,7 @@ func getDashboardPanelDocs(dash dashboard, location string) []*bluge.Document {
 		}
 
 		url := fmt.Sprintf("%s?viewPanel=%d", dashURL, panelId)
-		doc := newSearchDocument(panel.UID, panel.Name, panel.Description, url).
+		doc := newSearchDocument(panel.UID, panel.Name, panel.Description, url, lang).
 			AddField(bluge.NewKeywordField(documentFieldLocation, location).Aggregatable().StoreValue()).
 			AddField(bluge.NewKeywordField(documentFieldKind, string(entityKindPanel)).Aggregatable().StoreValue()) // likely want independent index for this
 
@@ -239,7 +243,7 @@ func getDashboardPanelDocs(dash dashboard, location string) []*bluge.Document {
 }
 
 // Names need to be indexed a few ways to support key features
-func newSearchDocument(uid string, name string, descr string, url string) *bluge.Document {
+func newSearchDocument(uid string, name string, descr string, url string, lang string) *bluge.Document {
 	doc := bluge.NewDocument(uid)
 
 	if name != "" {
@@ -252,6 +256,12 @@ func newSearchDocument(uid string, name string, descr string, url string) *bluge
 			doc.AddField(bluge.NewKeywordField(documentFieldName_sort, sortStr).Sortable())
 		}
 	}
+	if text := strings.TrimSpace(name + "\n" + descr); text != "" {
+		doc.AddField(bluge.NewTextField(documentFieldText, text).WithAnalyzer(textAnalyzerForLanguage(lang)))
+	}
+	if lang != "" {
+		doc.AddField(bluge.NewKeywordField(documentFieldLanguage, lang).Aggregatable().StoreValue())
+	}
 	if url != "" {
 		doc.AddField(bluge.NewKeywordField(documentFieldURL, url).StoreValue())
 	}
@@ -460,6 +470,13 @@ func doSearchQuery(
 				SetAnalyzer(ngramQueryAnalyzer).SetBoost(1))
 		}
 
+		if lang := queryLanguage(q); lang != "" {
+			bq.AddShould(bluge.NewMatchQuery(q.Query).
+				SetField(documentFieldText).
+				SetOperator(bluge.MatchQueryOperatorAnd). // all terms must match
+				SetAnalyzer(textAnalyzerForLanguage(lang)).SetBoost(1))
+		}
+
 		fullQuery.AddMust(bq)
 	}
 
@@ -0,0 +1,70 @@
+package searchV2
+
+import (
+	"github.com/blugelabs/bluge/analysis"
+	"github.com/blugelabs/bluge/analysis/analyzer"
+	"github.com/blugelabs/bluge/analysis/lang/ar"
+	"github.com/blugelabs/bluge/analysis/lang/cjk"
+	"github.com/blugelabs/bluge/analysis/lang/de"
+	"github.com/blugelabs/bluge/analysis/lang/en"
+	"github.com/blugelabs/bluge/analysis/lang/es"
+	"github.com/blugelabs/bluge/analysis/lang/fr"
+	"github.com/blugelabs/bluge/analysis/lang/hi"
+	"github.com/blugelabs/bluge/analysis/lang/it"
+	"github.com/blugelabs/bluge/analysis/lang/nl"
+	"github.com/blugelabs/bluge/analysis/lang/pt"
+	"github.com/blugelabs/bluge/analysis/lang/ru"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+var standardTextAnalyzer = analyzer.NewStandardAnalyzer()
+
+// textAnalyzers holds language specific analyzers (stemming, stop words, CJK bigrams)
+// keyed by ISO 639-1 code. Languages without an entry use standardTextAnalyzer.
+var textAnalyzers = func() map[string]*analysis.Analyzer {
+	cjkAnalyzer := cjk.Analyzer()
+	return map[string]*analysis.Analyzer{
+		"en": en.NewAnalyzer(),
+		"de": de.Analyzer(),
+		"fr": fr.Analyzer(),
+		"es": es.Analyzer(),
+		"it": it.Analyzer(),
+		"pt": pt.Analyzer(),
+		"nl": nl.Analyzer(),
+		"ru": ru.Analyzer(),
+		"ar": ar.Analyzer(),
+		"hi": hi.Analyzer(),
+		"zh": cjkAnalyzer,
+		"ja": cjkAnalyzer,
+		"ko": cjkAnalyzer,
+	}
+}()
+
+func textAnalyzerForLanguage(lang string) *analysis.Analyzer {
+	if a, ok := textAnalyzers[lang]; ok {
+		return a
+	}
+	return standardTextAnalyzer
+}
+
+// queryLanguage returns a language to analyze query text with. Language specific
+// matching is only used when requested explicitly or when the query is detected to be
+// written in a language other than English, which is covered by name and ngram fields.
+func queryLanguage(q DashboardQuery) string {
+	if q.Language != "" {
+		return q.Language
+	}
+	if lang := entity.DetectLanguage(q.Query); lang != "en" {
+		return lang
+	}
+	return ""
+}
+
+func summaryLanguage(summary *entity.EntitySummary) string {
+	if summary == nil {
+		return ""
+	}
+	lang, _ := summary.Fields[entity.SummaryFieldLanguage].(string)
+	return lang
+}
@@ -31,6 +31,7 @@ type DashboardQuery struct {
 	HasPreview         string       `json:"hasPreview,omitempty"` // the light|dark theme
 	Limit              int          `json:"limit,omitempty"`      // explicit page size
 	From               int          `json:"from,omitempty"`       // for paging
+	Language           string       `json:"language,omitempty"`   // query language (ISO 639-1), detected when empty
 }
 
 type IsSearchReadyResponse struct {
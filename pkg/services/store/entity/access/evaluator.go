@@ -0,0 +1,137 @@
+package access
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/models/roletype"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+)
+
+// Explain evaluates access of the identity to every verb. For each verb the first layer
+// (entity > folder > kind) having grants for the verb decides, when no layer has grants
+// the organization role is used.
+func Explain(grn string, user identity.Requester, grants Grants) *Explanation {
+	ns, id := user.GetNamespacedID()
+	rsp := &Explanation{
+		GRN:       grn,
+		Identity:  ns + ":" + id,
+		OrgRole:   string(user.GetOrgRole()),
+		Decisions: make([]Decision, 0, len(Verbs)),
+	}
+	for _, verb := range Verbs {
+		rsp.Decisions = append(rsp.Decisions, evaluate(verb, user, grants))
+	}
+	return rsp
+}
+
+// Allowed returns true when the identity can perform the verb.
+func Allowed(verb Verb, user identity.Requester, grants Grants) bool {
+	return evaluate(verb, user, grants).Allowed
+}
+
+func evaluate(verb Verb, user identity.Requester, grants Grants) Decision {
+	var entityGrants []Grant
+	source := SourceEntity
+	for _, g := range grants.Entity {
+		if g.Verb == verb {
+			entityGrants = append(entityGrants, g.Grant)
+			if g.Source == SourceKind {
+				source = SourceKind
+			}
+		}
+	}
+	if len(entityGrants) > 0 {
+		d := decide(verb, source, user, entityGrants)
+		if source == SourceKind {
+			d.Reason += " (inherited from kind defaults)"
+		}
+		return d
+	}
+
+	for _, folder := range grants.Folders {
+		if folderGrants := grantsForVerb(verb, folder.Grants); len(folderGrants) > 0 {
+			d := decide(verb, SourceFolder, user, folderGrants)
+			d.Folder = folder.UID
+			d.Reason += fmt.Sprintf(" in folder %q", folder.UID)
+			return d
+		}
+	}
+
+	if kindGrants := grantsForVerb(verb, grants.Kind); len(kindGrants) > 0 {
+		return decide(verb, SourceKind, user, kindGrants)
+	}
+
+	role := user.GetOrgRole()
+	return Decision{
+		Verb:    verb,
+		Allowed: roleAllows(role, verb),
+		Source:  SourceRole,
+		Reason:  fmt.Sprintf("no grants for %s, organization role %s applies", verb, role),
+	}
+}
+
+func decide(verb Verb, source Source, user identity.Requester, grants []Grant) Decision {
+	for _, g := range grants {
+		if subjectMatches(g.Subject, user) {
+			grant := g
+			return Decision{
+				Verb:    verb,
+				Allowed: true,
+				Source:  source,
+				Grant:   &grant,
+				Reason:  fmt.Sprintf("%s grant for %s", source, g.Subject),
+			}
+		}
+	}
+	return Decision{
+		Verb:    verb,
+		Allowed: false,
+		Source:  source,
+		Reason:  fmt.Sprintf("no matching %s grant", source),
+	}
+}
+
+func grantsForVerb(verb Verb, grants []Grant) []Grant {
+	var res []Grant
+	for _, g := range grants {
+		if g.Verb == verb {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+func subjectMatches(subject string, user identity.Requester) bool {
+	switch {
+	case subject == SubjectEveryone:
+		return true
+	case strings.HasPrefix(subject, SubjectRolePrefix):
+		return user.HasRole(roletype.RoleType(strings.TrimPrefix(subject, SubjectRolePrefix)))
+	case strings.HasPrefix(subject, SubjectUserPrefix):
+		ns, id := user.GetNamespacedID()
+		return ns == identity.NamespaceUser && id == strings.TrimPrefix(subject, SubjectUserPrefix)
+	case strings.HasPrefix(subject, SubjectTeamPrefix):
+		teamID, err := strconv.ParseInt(strings.TrimPrefix(subject, SubjectTeamPrefix), 10, 64)
+		if err != nil {
+			return false
+		}
+		for _, t := range user.GetTeams() {
+			if t == teamID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func roleAllows(role roletype.RoleType, verb Verb) bool {
+	switch verb {
+	case VerbRead:
+		return role.Includes(roletype.RoleViewer)
+	case VerbWrite, VerbDelete:
+		return role.Includes(roletype.RoleEditor)
+	}
+	return false
+}
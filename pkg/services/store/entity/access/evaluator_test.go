@@ -0,0 +1,73 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestExplain(t *testing.T) {
+	viewer := &user.SignedInUser{UserID: 2, OrgID: 1, OrgRole: org.RoleViewer, Teams: []int64{5}}
+	editor := &user.SignedInUser{UserID: 3, OrgID: 1, OrgRole: org.RoleEditor}
+
+	t.Run("org role is used without grants", func(t *testing.T) {
+		rsp := Explain("grn", viewer, Grants{})
+		require.Equal(t, "user:2", rsp.Identity)
+		require.Equal(t, "Viewer", rsp.OrgRole)
+		require.Len(t, rsp.Decisions, 3)
+		require.True(t, rsp.Decisions[0].Allowed)
+		require.False(t, rsp.Decisions[1].Allowed)
+		require.Equal(t, SourceRole, rsp.Decisions[1].Source)
+	})
+
+	t.Run("kind defaults take precedence over role", func(t *testing.T) {
+		grants := Grants{Kind: []Grant{{Subject: "role:Admin", Verb: VerbRead}}}
+		d := Explain("grn", editor, grants).Decisions[0]
+		require.False(t, d.Allowed)
+		require.Equal(t, SourceKind, d.Source)
+	})
+
+	t.Run("closest folder takes precedence over kind", func(t *testing.T) {
+		grants := Grants{
+			Folders: []FolderGrants{
+				{UID: "child", Grants: []Grant{{Subject: "team:5", Verb: VerbWrite}}},
+				{UID: "root", Grants: []Grant{{Subject: "role:Admin", Verb: VerbWrite}}},
+			},
+			Kind: []Grant{{Subject: "role:Admin", Verb: VerbWrite}},
+		}
+		d := Explain("grn", viewer, grants).Decisions[1]
+		require.True(t, d.Allowed)
+		require.Equal(t, SourceFolder, d.Source)
+		require.Equal(t, "child", d.Folder)
+		require.Equal(t, &Grant{Subject: "team:5", Verb: VerbWrite}, d.Grant)
+	})
+
+	t.Run("entity grants take precedence over folder", func(t *testing.T) {
+		grants := Grants{
+			Entity:  []EntityGrant{{Grant: Grant{Subject: "user:3", Verb: VerbDelete}, Source: SourceEntity}},
+			Folders: []FolderGrants{{UID: "child", Grants: []Grant{{Subject: "*", Verb: VerbDelete}}}},
+		}
+		require.False(t, Allowed(VerbDelete, viewer, grants))
+		require.True(t, Allowed(VerbDelete, editor, grants))
+	})
+
+	t.Run("inherited kind grants are reported", func(t *testing.T) {
+		grants := Grants{
+			Entity: []EntityGrant{{Grant: Grant{Subject: "role:Viewer", Verb: VerbRead}, Source: SourceKind}},
+		}
+		d := Explain("grn", viewer, grants).Decisions[0]
+		require.True(t, d.Allowed)
+		require.Equal(t, SourceKind, d.Source)
+	})
+}
+
+func TestGrantValidate(t *testing.T) {
+	require.NoError(t, Grant{Subject: "role:Viewer", Verb: VerbRead}.Validate())
+	require.NoError(t, Grant{Subject: "*", Verb: VerbWrite}.Validate())
+	require.Error(t, Grant{Subject: "role:Owner", Verb: VerbRead}.Validate())
+	require.Error(t, Grant{Subject: "user:1", Verb: "admin"}.Validate())
+	require.Error(t, Grant{Subject: "group:1", Verb: VerbRead}.Validate())
+}
@@ -0,0 +1,116 @@
+package access
+
+//-----------------------------------------------------------------------------------------------------
+// NOTE: the entity access model is experimental, like the rest of the object store
+//-----------------------------------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verb is an action on an entity.
+type Verb string
+
+const (
+	VerbRead   Verb = "read"
+	VerbWrite  Verb = "write"
+	VerbDelete Verb = "delete"
+)
+
+// Verbs lists all known verbs in the order they are explained.
+var Verbs = []Verb{VerbRead, VerbWrite, VerbDelete}
+
+// Source is a layer of the access model which decided an access.
+// Layers are evaluated in order: entity > folder > kind > role.
+type Source string
+
+const (
+	// SourceEntity are grants set explicitly on the entity.
+	SourceEntity Source = "entity"
+	// SourceFolder are grants of the closest parent folder with grants for a verb.
+	SourceFolder Source = "folder"
+	// SourceKind are default grants of the entity kind.
+	SourceKind Source = "kind"
+	// SourceRole is a fallback to the organization role.
+	SourceRole Source = "role"
+)
+
+// Subject prefixes. A subject is `*` (everyone), `role:<Viewer|Editor|Admin>`,
+// `user:<id>` or `team:<id>`.
+const (
+	SubjectEveryone   = "*"
+	SubjectRolePrefix = "role:"
+	SubjectUserPrefix = "user:"
+	SubjectTeamPrefix = "team:"
+)
+
+// Grant allows a subject to perform a verb.
+type Grant struct {
+	Subject string `json:"subject"`
+	Verb    Verb   `json:"verb"`
+}
+
+func (g Grant) Validate() error {
+	switch g.Verb {
+	case VerbRead, VerbWrite, VerbDelete:
+	default:
+		return fmt.Errorf("unknown verb: %q", g.Verb)
+	}
+	switch {
+	case g.Subject == SubjectEveryone:
+	case strings.HasPrefix(g.Subject, SubjectRolePrefix):
+		switch strings.TrimPrefix(g.Subject, SubjectRolePrefix) {
+		case "Viewer", "Editor", "Admin":
+		default:
+			return fmt.Errorf("unknown role in subject: %q", g.Subject)
+		}
+	case strings.HasPrefix(g.Subject, SubjectUserPrefix), strings.HasPrefix(g.Subject, SubjectTeamPrefix):
+	default:
+		return fmt.Errorf("invalid subject: %q", g.Subject)
+	}
+	return nil
+}
+
+// EntityGrant is a grant stored on an entity, Source is SourceKind when it was
+// inherited from kind defaults at creation time.
+type EntityGrant struct {
+	Grant
+	Source Source `json:"source"`
+}
+
+// Decision explains why a verb is allowed or denied.
+type Decision struct {
+	Verb    Verb   `json:"verb"`
+	Allowed bool   `json:"allowed"`
+	Source  Source `json:"source"`
+	// Folder UID when decided by folder grants.
+	Folder string `json:"folder,omitempty"`
+	// Grant that allowed the verb, empty when denied or decided by role.
+	Grant *Grant `json:"grant,omitempty"`
+	// Human readable explanation.
+	Reason string `json:"reason"`
+}
+
+// Explanation lists decisions for all verbs.
+type Explanation struct {
+	GRN      string `json:"grn"`
+	Identity string `json:"identity"`
+	// OrgRole of the identity used when no grants exist for a verb.
+	OrgRole   string     `json:"orgRole"`
+	Decisions []Decision `json:"decisions"`
+}
+
+// FolderGrants are grants of a single folder.
+type FolderGrants struct {
+	UID    string
+	Grants []Grant
+}
+
+// Grants are all grants affecting an entity, used to evaluate access.
+type Grants struct {
+	Entity []EntityGrant
+	// Folders are ordered from the closest parent folder to the root.
+	Folders []FolderGrants
+	Kind    []Grant
+}
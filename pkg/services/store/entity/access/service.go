@@ -0,0 +1,188 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+// Service manages entity access grants and kind defaults stored next to the entity tables.
+type Service struct {
+	sess *session.SessionDB
+}
+
+func ProvideService(db db.DB) *Service {
+	return &Service{
+		sess: db.GetSqlxSession(),
+	}
+}
+
+type grantRow struct {
+	Subject string `db:"subject"`
+	Verb    string `db:"verb"`
+	Source  string `db:"source"`
+}
+
+func (s *Service) GetKindDefaults(ctx context.Context, tenantID int64, kind string) ([]Grant, error) {
+	var rows []grantRow
+	err := s.sess.Select(ctx, &rows, "SELECT subject, verb, '' AS source FROM entity_kind_access WHERE tenant_id=? AND kind=? ORDER BY verb, subject", tenantID, kind)
+	if err != nil {
+		return nil, err
+	}
+	grants := make([]Grant, 0, len(rows))
+	for _, r := range rows {
+		grants = append(grants, Grant{Subject: r.Subject, Verb: Verb(r.Verb)})
+	}
+	return grants, nil
+}
+
+// SetKindDefaults replaces default grants of a kind. Defaults are copied to entities
+// created afterwards, existing entities only use them when they have no own grants.
+func (s *Service) SetKindDefaults(ctx context.Context, tenantID int64, kind string, grants []Grant) error {
+	for _, g := range grants {
+		if err := g.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_kind_access WHERE tenant_id=? AND kind=?", tenantID, kind); err != nil {
+			return err
+		}
+		for _, g := range dedupGrants(grants) {
+			_, err := tx.Exec(ctx, "INSERT INTO entity_kind_access (tenant_id, kind, subject, verb) VALUES (?, ?, ?, ?)",
+				tenantID, kind, g.Subject, g.Verb)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) GetEntityGrants(ctx context.Context, g *grn.GRN) ([]EntityGrant, error) {
+	var rows []grantRow
+	err := s.sess.Select(ctx, &rows, "SELECT subject, verb, source FROM entity_access WHERE grn=? ORDER BY verb, subject", g.ToGRNString())
+	if err != nil {
+		return nil, err
+	}
+	grants := make([]EntityGrant, 0, len(rows))
+	for _, r := range rows {
+		grants = append(grants, EntityGrant{
+			Grant:  Grant{Subject: r.Subject, Verb: Verb(r.Verb)},
+			Source: Source(r.Source),
+		})
+	}
+	return grants, nil
+}
+
+// SetEntityGrants replaces grants of an entity, including the ones inherited from kind defaults.
+func (s *Service) SetEntityGrants(ctx context.Context, g *grn.GRN, grants []Grant) error {
+	for _, grant := range grants {
+		if err := grant.Validate(); err != nil {
+			return err
+		}
+	}
+	oid := g.ToGRNString()
+	return s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_access WHERE grn=?", oid); err != nil {
+			return err
+		}
+		for _, grant := range dedupGrants(grants) {
+			_, err := tx.Exec(ctx, "INSERT INTO entity_access (grn, tenant_id, subject, verb, source) VALUES (?, ?, ?, ?, ?)",
+				oid, g.TenantID, grant.Subject, grant.Verb, SourceEntity)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetGrants collects grants of all layers affecting the entity.
+func (s *Service) GetGrants(ctx context.Context, g *grn.GRN) (Grants, error) {
+	var (
+		grants Grants
+		err    error
+	)
+	grants.Entity, err = s.GetEntityGrants(ctx, g)
+	if err != nil {
+		return grants, err
+	}
+	grants.Kind, err = s.GetKindDefaults(ctx, g.TenantID, g.ResourceKind)
+	if err != nil {
+		return grants, err
+	}
+
+	folders, err := s.parentFolders(ctx, g)
+	if err != nil {
+		return grants, err
+	}
+	for _, uid := range folders {
+		folderGRN := &grn.GRN{TenantID: g.TenantID, ResourceKind: entity.StandardKindFolder, ResourceIdentifier: uid}
+		folderGrants, err := s.GetEntityGrants(ctx, folderGRN)
+		if err != nil {
+			return grants, err
+		}
+		if len(folderGrants) == 0 {
+			continue
+		}
+		fg := FolderGrants{UID: uid}
+		for _, grant := range folderGrants {
+			fg.Grants = append(fg.Grants, grant.Grant)
+		}
+		grants.Folders = append(grants.Folders, fg)
+	}
+	return grants, nil
+}
+
+// parentFolders returns parent folder UIDs of an entity from the closest one to the root.
+func (s *Service) parentFolders(ctx context.Context, g *grn.GRN) ([]string, error) {
+	var folder string
+	err := s.sess.Get(ctx, &folder, "SELECT folder FROM entity WHERE grn=?", g.ToGRNString())
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && folder == "") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tree string
+	err = s.sess.Get(ctx, &tree, "SELECT tree FROM entity_folder WHERE tenant_id=? AND uid=?", g.TenantID, folder)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Folder tree is not built yet, use the direct parent only.
+		return []string{folder}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stack []struct {
+		UID string `json:"uid"`
+	}
+	if err := json.Unmarshal([]byte(tree), &stack); err != nil {
+		return nil, err
+	}
+	uids := make([]string, 0, len(stack))
+	for i := len(stack) - 1; i >= 0; i-- {
+		uids = append(uids, stack[i].UID)
+	}
+	return uids, nil
+}
+
+func dedupGrants(grants []Grant) []Grant {
+	seen := make(map[Grant]struct{}, len(grants))
+	res := make([]Grant, 0, len(grants))
+	for _, g := range grants {
+		if _, ok := seen[g]; ok {
+			continue
+		}
+		seen[g] = struct{}{}
+		res = append(res, g)
+	}
+	return res
+}
@@ -0,0 +1,104 @@
+package comments
+
+//-----------------------------------------------------------------------------------------------------
+// NOTE: entity comments are experimental, like the rest of the object store
+//-----------------------------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxBodyLength is a max length of a comment body in bytes.
+const MaxBodyLength = 16 * 1024
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrEmptyBody       = errors.New("comment body is empty")
+	ErrBodyTooLong     = errors.New("comment body is too long")
+	// ErrNestedReply is returned for replies to replies, threads are one level deep.
+	ErrNestedReply = errors.New("replies can only be added to a thread root comment")
+)
+
+// Comment is a message attached to an entity. Comments without a parent start a thread,
+// replies reference the thread root comment.
+type Comment struct {
+	ID       int64  `json:"id" db:"id"`
+	GRN      string `json:"grn" db:"grn"`
+	ParentID int64  `json:"parentId,omitempty" db:"parent_id"`
+	Body     string `json:"body" db:"body"`
+
+	// CreatedBy is an identity string of the author (see store.GetUserIDString)
+	CreatedBy   string `json:"createdBy" db:"created_by"`
+	CreatedByID int64  `json:"createdById" db:"created_by_id"`
+	CreatedAt   int64  `json:"createdAt" db:"created_at"`
+
+	// Only thread root comments are resolved
+	Resolved   bool   `json:"resolved,omitempty" db:"resolved"`
+	ResolvedBy string `json:"resolvedBy,omitempty" db:"resolved_by"`
+	ResolvedAt int64  `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
+
+// Thread is a root comment with its replies sorted by creation time.
+type Thread struct {
+	Comment
+	Replies []Comment `json:"replies"`
+}
+
+// AddCommentCmd adds a comment to an entity.
+type AddCommentCmd struct {
+	ParentID int64  `json:"parentId,omitempty"`
+	Body     string `json:"body"`
+}
+
+func (cmd AddCommentCmd) Validate() error {
+	if strings.TrimSpace(cmd.Body) == "" {
+		return ErrEmptyBody
+	}
+	if len(cmd.Body) > MaxBodyLength {
+		return ErrBodyTooLong
+	}
+	return nil
+}
+
+// EventType of a comment change.
+type EventType string
+
+const (
+	EventCommentAdded      EventType = "comment-added"
+	EventCommentDeleted    EventType = "comment-deleted"
+	EventCommentResolved   EventType = "comment-resolved"
+	EventCommentUnresolved EventType = "comment-unresolved"
+)
+
+// Event is published to listeners for every comment change, so comment activity can be
+// followed along with entity changes.
+type Event struct {
+	Type      EventType `json:"type"`
+	TenantID  int64     `json:"tenantId"`
+	GRN       string    `json:"grn"`
+	Comment   Comment   `json:"comment"`
+	Timestamp int64     `json:"timestamp"`
+}
+
+// buildThreads groups comments sorted by creation time into threads, replies of missing
+// roots are dropped.
+func buildThreads(comments []Comment) []Thread {
+	threads := make([]Thread, 0)
+	index := map[int64]int{}
+	for _, c := range comments {
+		if c.ParentID == 0 {
+			index[c.ID] = len(threads)
+			threads = append(threads, Thread{Comment: c, Replies: []Comment{}})
+		}
+	}
+	for _, c := range comments {
+		if c.ParentID == 0 {
+			continue
+		}
+		if i, ok := index[c.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, c)
+		}
+	}
+	return threads
+}
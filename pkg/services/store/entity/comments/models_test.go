@@ -0,0 +1,31 @@
+package comments
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildThreads(t *testing.T) {
+	threads := buildThreads([]Comment{
+		{ID: 1, Body: "first"},
+		{ID: 2, ParentID: 1, Body: "reply"},
+		{ID: 3, Body: "second"},
+		{ID: 4, ParentID: 1, Body: "another reply"},
+		{ID: 5, ParentID: 10, Body: "orphan"},
+	})
+	require.Len(t, threads, 2)
+	require.Equal(t, int64(1), threads[0].ID)
+	require.Equal(t, []int64{2, 4}, []int64{threads[0].Replies[0].ID, threads[0].Replies[1].ID})
+	require.Equal(t, int64(3), threads[1].ID)
+	require.Empty(t, threads[1].Replies)
+
+	require.NotNil(t, buildThreads(nil))
+}
+
+func TestAddCommentCmdValidate(t *testing.T) {
+	require.NoError(t, AddCommentCmd{Body: "looks good"}.Validate())
+	require.ErrorIs(t, AddCommentCmd{Body: "  \n"}.Validate(), ErrEmptyBody)
+	require.ErrorIs(t, AddCommentCmd{Body: strings.Repeat("a", MaxBodyLength+1)}.Validate(), ErrBodyTooLong)
+}
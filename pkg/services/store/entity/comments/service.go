@@ -0,0 +1,158 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+)
+
+const commentColumns = "id, grn, parent_id, body, created_by, created_by_id, created_at, resolved, resolved_by, resolved_at"
+
+// Service manages comment threads of entities stored next to the entity tables.
+type Service struct {
+	sess *session.SessionDB
+
+	listenersMu sync.RWMutex
+	listeners   map[int]func(Event)
+	nextID      int
+}
+
+func ProvideService(db db.DB) *Service {
+	return &Service{
+		sess:      db.GetSqlxSession(),
+		listeners: map[int]func(Event){},
+	}
+}
+
+// Subscribe registers a listener called for every comment change, the returned function
+// removes it. Listeners are called synchronously and must not block.
+func (s *Service) Subscribe(fn func(Event)) func() {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = fn
+	return func() {
+		s.listenersMu.Lock()
+		defer s.listenersMu.Unlock()
+		delete(s.listeners, id)
+	}
+}
+
+func (s *Service) publish(eventType EventType, tenantID int64, c Comment) {
+	e := Event{Type: eventType, TenantID: tenantID, GRN: c.GRN, Comment: c, Timestamp: time.Now().UnixMilli()}
+	s.listenersMu.RLock()
+	defer s.listenersMu.RUnlock()
+	for _, fn := range s.listeners {
+		fn(e)
+	}
+}
+
+// ListThreads returns comment threads of an entity, oldest first.
+func (s *Service) ListThreads(ctx context.Context, g *grn.GRN) ([]Thread, error) {
+	var rows []Comment
+	err := s.sess.Select(ctx, &rows, "SELECT "+commentColumns+" FROM entity_comment WHERE grn=? ORDER BY created_at, id", g.ToGRNString())
+	if err != nil {
+		return nil, err
+	}
+	return buildThreads(rows), nil
+}
+
+// GetComment returns a comment of an entity by ID.
+func (s *Service) GetComment(ctx context.Context, g *grn.GRN, id int64) (*Comment, error) {
+	var rows []Comment
+	err := s.sess.Select(ctx, &rows, "SELECT "+commentColumns+" FROM entity_comment WHERE grn=? AND id=?", g.ToGRNString(), id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrCommentNotFound
+	}
+	return &rows[0], nil
+}
+
+// AddComment adds a thread root comment or a reply to an existing thread.
+func (s *Service) AddComment(ctx context.Context, g *grn.GRN, author string, authorID int64, cmd AddCommentCmd) (*Comment, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	if cmd.ParentID != 0 {
+		parent, err := s.GetComment(ctx, g, cmd.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ParentID != 0 {
+			return nil, ErrNestedReply
+		}
+	}
+	c := &Comment{
+		GRN:         g.ToGRNString(),
+		ParentID:    cmd.ParentID,
+		Body:        cmd.Body,
+		CreatedBy:   author,
+		CreatedByID: authorID,
+		CreatedAt:   time.Now().UnixMilli(),
+	}
+	id, err := s.sess.ExecWithReturningId(ctx, "INSERT INTO entity_comment "+
+		"(grn, tenant_id, parent_id, body, created_by, created_by_id, created_at, resolved, resolved_by, resolved_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, '', 0)",
+		c.GRN, g.TenantID, c.ParentID, c.Body, c.CreatedBy, c.CreatedByID, c.CreatedAt, false)
+	if err != nil {
+		return nil, err
+	}
+	c.ID = id
+	s.publish(EventCommentAdded, g.TenantID, *c)
+	return c, nil
+}
+
+// DeleteComment deletes a comment, deleting a thread root deletes its replies.
+func (s *Service) DeleteComment(ctx context.Context, g *grn.GRN, id int64) error {
+	c, err := s.GetComment(ctx, g, id)
+	if err != nil {
+		return err
+	}
+	err = s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_comment WHERE grn=? AND parent_id=?", c.GRN, c.ID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM entity_comment WHERE grn=? AND id=?", c.GRN, c.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.publish(EventCommentDeleted, g.TenantID, *c)
+	return nil
+}
+
+// SetResolved marks a thread resolved or reopens it.
+func (s *Service) SetResolved(ctx context.Context, g *grn.GRN, id int64, resolved bool, by string) (*Comment, error) {
+	c, err := s.GetComment(ctx, g, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.ParentID != 0 {
+		return nil, errors.New("only thread root comments can be resolved")
+	}
+	c.Resolved = resolved
+	c.ResolvedBy, c.ResolvedAt = "", 0
+	if resolved {
+		c.ResolvedBy, c.ResolvedAt = by, time.Now().UnixMilli()
+	}
+	_, err = s.sess.Exec(ctx, "UPDATE entity_comment SET resolved=?, resolved_by=?, resolved_at=? WHERE grn=? AND id=?",
+		c.Resolved, c.ResolvedBy, c.ResolvedAt, c.GRN, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	eventType := EventCommentUnresolved
+	if resolved {
+		eventType = EventCommentResolved
+	}
+	s.publish(eventType, g.TenantID, *c)
+	return c, nil
+}
@@ -0,0 +1,39 @@
+package favorites
+
+//-----------------------------------------------------------------------------------------------------
+// NOTE: entity favorites are experimental, like the rest of the object store
+//-----------------------------------------------------------------------------------------------------
+
+const (
+	// MaxRecent is a number of recently viewed entities kept per user and tenant.
+	MaxRecent = 50
+	// DefaultRecentLimit is a number of recently viewed entities listed when limit is not set.
+	DefaultRecentLimit = 20
+)
+
+// Favorite is an entity starred by a user.
+type Favorite struct {
+	GRN       string `json:"grn" db:"grn"`
+	Kind      string `json:"kind" db:"kind"`
+	UID       string `json:"uid" db:"uid"`
+	CreatedAt int64  `json:"createdAt" db:"created_at"`
+}
+
+// RecentView is an entity recently viewed by a user.
+type RecentView struct {
+	GRN      string `json:"grn" db:"grn"`
+	Kind     string `json:"kind" db:"kind"`
+	UID      string `json:"uid" db:"uid"`
+	ViewedAt int64  `json:"viewedAt" db:"viewed_at"`
+}
+
+// RecentLimit returns a number of recently viewed entities to list for a requested limit.
+func RecentLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultRecentLimit
+	}
+	if limit > MaxRecent {
+		return MaxRecent
+	}
+	return limit
+}
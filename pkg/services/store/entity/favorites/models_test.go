@@ -0,0 +1,14 @@
+package favorites
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentLimit(t *testing.T) {
+	require.Equal(t, DefaultRecentLimit, RecentLimit(0))
+	require.Equal(t, DefaultRecentLimit, RecentLimit(-5))
+	require.Equal(t, 5, RecentLimit(5))
+	require.Equal(t, MaxRecent, RecentLimit(MaxRecent+1))
+}
@@ -0,0 +1,118 @@
+package favorites
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+)
+
+// Service keeps entities starred and recently viewed by users next to the entity tables.
+type Service struct {
+	sess *session.SessionDB
+}
+
+func ProvideService(db db.DB) *Service {
+	return &Service{sess: db.GetSqlxSession()}
+}
+
+// Star adds an entity to favorites of a user, starring it again is a no-op.
+func (s *Service) Star(ctx context.Context, userID int64, g *grn.GRN) error {
+	return s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_favorite WHERE user_id=? AND grn=?", userID, g.ToGRNString()); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO entity_favorite (grn, tenant_id, user_id, kind, uid, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			g.ToGRNString(), g.TenantID, userID, g.ResourceKind, g.ResourceIdentifier, time.Now().UnixMilli())
+		return err
+	})
+}
+
+// Unstar removes an entity from favorites of a user.
+func (s *Service) Unstar(ctx context.Context, userID int64, g *grn.GRN) error {
+	_, err := s.sess.Exec(ctx, "DELETE FROM entity_favorite WHERE user_id=? AND grn=?", userID, g.ToGRNString())
+	return err
+}
+
+// ListStarred returns favorites of a user in a tenant, newest first, optionally filtered by kind.
+func (s *Service) ListStarred(ctx context.Context, userID int64, tenantID int64, kinds []string) ([]Favorite, error) {
+	query := "SELECT grn, kind, uid, created_at FROM entity_favorite WHERE user_id=? AND tenant_id=?"
+	args := []any{userID, tenantID}
+	if len(kinds) > 0 {
+		query += " AND kind IN (?" + strings.Repeat(",?", len(kinds)-1) + ")"
+		for _, k := range kinds {
+			args = append(args, k)
+		}
+	}
+	rows := make([]Favorite, 0)
+	err := s.sess.Select(ctx, &rows, query+" ORDER BY created_at DESC", args...)
+	return rows, err
+}
+
+// StarredGRNs returns GRN strings of entities starred by a user in a tenant.
+func (s *Service) StarredGRNs(ctx context.Context, userID int64, tenantID int64) (map[string]bool, error) {
+	var rows []string
+	err := s.sess.Select(ctx, &rows, "SELECT grn FROM entity_favorite WHERE user_id=? AND tenant_id=?", userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return toSet(rows), nil
+}
+
+// RecordView marks an entity as viewed by a user now, only MaxRecent last views are kept.
+func (s *Service) RecordView(ctx context.Context, userID int64, g *grn.GRN) error {
+	return s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_recent WHERE user_id=? AND grn=?", userID, g.ToGRNString()); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO entity_recent (grn, tenant_id, user_id, kind, uid, viewed_at) VALUES (?, ?, ?, ?, ?, ?)",
+			g.ToGRNString(), g.TenantID, userID, g.ResourceKind, g.ResourceIdentifier, time.Now().UnixMilli())
+		if err != nil {
+			return err
+		}
+
+		// Trim views older than the last one kept
+		var oldest int64
+		err = tx.Get(ctx, &oldest, "SELECT viewed_at FROM entity_recent WHERE user_id=? AND tenant_id=? ORDER BY viewed_at DESC LIMIT 1 OFFSET ?",
+			userID, g.TenantID, MaxRecent-1)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM entity_recent WHERE user_id=? AND tenant_id=? AND viewed_at<?", userID, g.TenantID, oldest)
+		return err
+	})
+}
+
+// ListRecent returns entities recently viewed by a user in a tenant, newest first.
+func (s *Service) ListRecent(ctx context.Context, userID int64, tenantID int64, limit int) ([]RecentView, error) {
+	rows := make([]RecentView, 0)
+	err := s.sess.Select(ctx, &rows, "SELECT grn, kind, uid, viewed_at FROM entity_recent WHERE user_id=? AND tenant_id=? ORDER BY viewed_at DESC LIMIT ?",
+		userID, tenantID, RecentLimit(limit))
+	return rows, err
+}
+
+// RecentGRNs returns GRN strings of entities recently viewed by a user in a tenant.
+func (s *Service) RecentGRNs(ctx context.Context, userID int64, tenantID int64) (map[string]bool, error) {
+	var rows []string
+	err := s.sess.Select(ctx, &rows, "SELECT grn FROM entity_recent WHERE user_id=? AND tenant_id=?", userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return toSet(rows), nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,153 @@
+package httpentitystore
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// checkAccess returns an error response when the signed in user can't perform the verb
+// on an entity. It evaluates the same layers as the explain endpoint.
+func (s *httpEntityStore) checkAccess(c *contextmodel.ReqContext, g *grn.GRN, verb access.Verb) response.Response {
+	ok, err := s.allows(c, verb)(g)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading entity access", err)
+	}
+	if !ok {
+		return response.Error(http.StatusForbidden, fmt.Sprintf("%s access to entity denied", verb), nil)
+	}
+	return nil
+}
+
+// accessFunc tells whether an entity can be accessed, see httpEntityStore.allows
+type accessFunc func(g *grn.GRN) (bool, error)
+
+// allows returns a function checking whether the signed in user can perform the verb on entities
+func (s *httpEntityStore) allows(c *contextmodel.ReqContext, verb access.Verb) accessFunc {
+	return func(g *grn.GRN) (bool, error) {
+		grants, err := s.access.GetGrants(c.Req.Context(), g)
+		if err != nil {
+			return false, err
+		}
+		return access.Allowed(verb, c.SignedInUser, grants), nil
+	}
+}
+
+// readableResults drops search results the user can't read, it must run before bodies are
+// searched or summarized so nothing of a denied entity is returned
+func readableResults(results []*entity.EntitySearchResult, canRead accessFunc) ([]*entity.EntitySearchResult, error) {
+	filtered := results[:0]
+	for _, r := range results {
+		if r.GRN == nil {
+			continue
+		}
+		ok, err := canRead(r.GRN)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+type grantsBody struct {
+	Grants []access.Grant `json:"grants"`
+}
+
+func (s *httpEntityStore) doGetKindAccess(c *contextmodel.ReqContext) response.Response {
+	kind := web.Params(c.Req)[":kind"]
+	grants, err := s.access.GetKindDefaults(c.Req.Context(), c.OrgID, kind)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading kind access", err)
+	}
+	return response.JSON(http.StatusOK, grantsBody{Grants: grants})
+}
+
+func (s *httpEntityStore) doSetKindAccess(c *contextmodel.ReqContext) response.Response {
+	kind := web.Params(c.Req)[":kind"]
+	if _, err := s.kinds.GetInfo(kind); err != nil {
+		return response.Error(http.StatusBadRequest, "unknown kind", err)
+	}
+	body := grantsBody{}
+	if err := web.Bind(c.Req, &body); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := s.access.SetKindDefaults(c.Req.Context(), c.OrgID, kind, body.Grants); err != nil {
+		return response.Error(http.StatusBadRequest, "error saving kind access", err)
+	}
+	return response.JSON(http.StatusOK, body)
+}
+
+func (s *httpEntityStore) doGetEntityAccess(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	grants, err := s.access.GetEntityGrants(c.Req.Context(), grn)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading entity access", err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"grants": grants})
+}
+
+func (s *httpEntityStore) doSetEntityAccess(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	body := grantsBody{}
+	if err := web.Bind(c.Req, &body); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := s.access.SetEntityGrants(c.Req.Context(), grn, body.Grants); err != nil {
+		return response.Error(http.StatusBadRequest, "error saving entity access", err)
+	}
+	return response.JSON(http.StatusOK, body)
+}
+
+// doExplainAccess returns for each verb whether it is allowed and which grant decided it.
+// The signed in user is explained by default, other users of the organization with ?userId=
+// or ?login=. The route requires the org admin role, as changing grants does.
+func (s *httpEntityStore) doExplainAccess(c *contextmodel.ReqContext) response.Response {
+	grn, params, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	var requester identity.Requester = c.SignedInUser
+	if params["userId"] != "" || params["login"] != "" {
+		query := &user.GetSignedInUserQuery{OrgID: c.OrgID, Login: params["login"]}
+		if params["userId"] != "" {
+			query.UserID, err = strconv.ParseInt(params["userId"], 10, 64)
+			if err != nil {
+				return response.Error(http.StatusBadRequest, "invalid userId", err)
+			}
+		}
+		u, err := s.users.GetSignedInUser(c.Req.Context(), query)
+		if err != nil {
+			if errors.Is(err, user.ErrUserNotFound) {
+				return response.Error(http.StatusNotFound, "user not found", err)
+			}
+			return response.Error(http.StatusInternalServerError, "error reading user", err)
+		}
+		requester = u
+	}
+
+	grants, err := s.access.GetGrants(c.Req.Context(), grn)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading entity access", err)
+	}
+	return response.JSON(http.StatusOK, access.Explain(grn.ToGRNString(), requester, grants))
+}
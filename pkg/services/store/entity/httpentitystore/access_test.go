@@ -0,0 +1,47 @@
+package httpentitystore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/favorites"
+)
+
+func TestReadableResults(t *testing.T) {
+	result := func(uid string) *entity.EntitySearchResult {
+		return &entity.EntitySearchResult{GRN: &grn.GRN{TenantID: 1, ResourceKind: "dashboard", ResourceIdentifier: uid}, Body: []byte(uid)}
+	}
+	canRead := func(g *grn.GRN) (bool, error) { return g.ResourceIdentifier != "secret", nil }
+
+	results := []*entity.EntitySearchResult{result("a"), result("secret"), result("b"), {}}
+	filtered, err := readableResults(results, canRead)
+	require.NoError(t, err)
+	require.Len(t, filtered, 2)
+	require.Equal(t, "a", filtered[0].GRN.ResourceIdentifier)
+	require.Equal(t, "b", filtered[1].GRN.ResourceIdentifier)
+
+	// Bodies of denied entities are not searched for snippets
+	rsp := searchContent(filtered, "secret", 10, false, func(string) bool { return true })
+	require.Empty(t, rsp.Results)
+
+	_, err = readableResults([]*entity.EntitySearchResult{result("a")}, func(*grn.GRN) (bool, error) {
+		return false, errors.New("db down")
+	})
+	require.Error(t, err)
+}
+
+func TestReadableRows(t *testing.T) {
+	canRead := func(g *grn.GRN) (bool, error) { return g.ResourceIdentifier != "secret", nil }
+	rows := []favorites.Favorite{
+		{GRN: "grn:1:dashboard/a"},
+		{GRN: "grn:1:dashboard/secret"},
+		{GRN: "invalid"},
+	}
+	filtered, err := readableRows(rows, func(f favorites.Favorite) string { return f.GRN }, canRead)
+	require.NoError(t, err)
+	require.Equal(t, []favorites.Favorite{{GRN: "grn:1:dashboard/a"}}, filtered)
+}
@@ -0,0 +1,103 @@
+package httpentitystore
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/kind/zip"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type unpackedFile struct {
+	Path    string                      `json:"path"`
+	Kind    string                      `json:"kind,omitempty"`
+	Skipped bool                        `json:"skipped,omitempty"`
+	Result  *entity.WriteEntityResponse `json:"result,omitempty"`
+}
+
+// doUnpackBundle imports files of a ZIP bundle as individual entities in the folder of the bundle.
+// Files with unknown kinds are skipped. Unpacked entities reference the bundle in their origin.
+// Nothing is written unless the user can read the bundle and write all unpacked entities.
+func (s *httpEntityStore) doUnpackBundle(c *contextmodel.ReqContext) response.Response {
+	bundleGRN := &grn.GRN{
+		TenantID:           c.OrgID,
+		ResourceKind:       entity.StandardKindZip,
+		ResourceIdentifier: web.Params(c.Req)[":uid"],
+	}
+	if errRsp := s.checkAccess(c, bundleGRN, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	ctx := c.Req.Context()
+	bundle, err := s.store.Read(ctx, &entity.ReadEntityRequest{
+		GRN:      bundleGRN,
+		WithBody: true,
+	})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading bundle", err)
+	}
+	if bundle.GRN == nil {
+		return response.Error(http.StatusNotFound, "bundle not found", nil)
+	}
+
+	files, err := zip.ReadFiles(bundle.Body, zip.NewExtensionKindDetector(s.kinds.GetFromExtension), true)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "error reading bundle", err)
+	}
+
+	for _, f := range files {
+		if f.Kind == "" || f.Kind == entity.StandardKindZip {
+			continue
+		}
+		if errRsp := s.checkAccess(c, unpackedGRN(c.OrgID, bundleGRN, f), access.VerbWrite); errRsp != nil {
+			return errRsp
+		}
+	}
+
+	admin, isAdmin := s.store.(entity.EntityStoreAdminServer)
+	origin := &entity.EntityOriginInfo{
+		Source: zip.OriginSource,
+		Key:    bundleGRN.ToGRNString(),
+		Time:   time.Now().UnixMilli(),
+	}
+	rsp := make([]unpackedFile, 0, len(files))
+	for _, f := range files {
+		out := unpackedFile{Path: f.Path, Kind: f.Kind}
+		if f.Kind == "" || f.Kind == entity.StandardKindZip {
+			out.Skipped = true
+			rsp = append(rsp, out)
+			continue
+		}
+
+		req := &entity.WriteEntityRequest{
+			GRN:     unpackedGRN(c.OrgID, bundleGRN, f),
+			Body:    f.Body,
+			Folder:  bundle.Folder,
+			Comment: "unpacked from " + bundleGRN.ResourceIdentifier,
+		}
+		if isAdmin {
+			adminReq := entity.ToAdminWriteEntityRequest(req)
+			adminReq.Origin = origin
+			out.Result, err = admin.AdminWrite(ctx, adminReq)
+		} else {
+			out.Result, err = s.store.Write(ctx, req)
+		}
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "error writing "+f.Path, err)
+		}
+		rsp = append(rsp, out)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+func unpackedGRN(orgID int64, bundleGRN *grn.GRN, f zip.File) *grn.GRN {
+	return &grn.GRN{
+		TenantID:           orgID,
+		ResourceKind:       f.Kind,
+		ResourceIdentifier: zip.UnpackedUID(bundleGRN.ResourceIdentifier, f.Path),
+	}
+}
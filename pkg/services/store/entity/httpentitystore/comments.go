@@ -0,0 +1,129 @@
+package httpentitystore
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/comments"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type resolveCommentBody struct {
+	Resolved bool `json:"resolved"`
+}
+
+func commentID(c *contextmodel.ReqContext) (int64, error) {
+	return strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+}
+
+func commentError(err error) response.Response {
+	switch {
+	case errors.Is(err, comments.ErrCommentNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, comments.ErrEmptyBody), errors.Is(err, comments.ErrBodyTooLong), errors.Is(err, comments.ErrNestedReply):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, "error saving comment", err)
+}
+
+func (s *httpEntityStore) doListComments(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	threads, err := s.comments.ListThreads(c.Req.Context(), grn)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading comments", err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"threads": threads})
+}
+
+func (s *httpEntityStore) doAddComment(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	cmd := comments.AddCommentCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	rsp, err := s.store.Read(c.Req.Context(), &entity.ReadEntityRequest{GRN: grn})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error fetching entity", err)
+	}
+	if rsp == nil || rsp.GRN == nil {
+		return response.Error(http.StatusNotFound, "entity not found", nil)
+	}
+	comment, err := s.comments.AddComment(c.Req.Context(), grn, store.GetUserIDString(c.SignedInUser), c.UserID, cmd)
+	if err != nil {
+		return commentError(err)
+	}
+	return response.JSON(http.StatusOK, comment)
+}
+
+// doDeleteComment deletes a comment of the signed in user, org admins can delete any comment
+func (s *httpEntityStore) doDeleteComment(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	id, err := commentID(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "invalid comment id", err)
+	}
+	comment, err := s.comments.GetComment(c.Req.Context(), grn, id)
+	if err != nil {
+		return commentError(err)
+	}
+	if comment.CreatedByID != c.UserID && !c.SignedInUser.HasRole(org.RoleAdmin) {
+		return response.Error(http.StatusForbidden, "only the author or an org admin can delete a comment", nil)
+	}
+	if err := s.comments.DeleteComment(c.Req.Context(), grn, id); err != nil {
+		return commentError(err)
+	}
+	return response.Success("comment deleted")
+}
+
+func (s *httpEntityStore) doResolveComment(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	id, err := commentID(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "invalid comment id", err)
+	}
+	body := resolveCommentBody{Resolved: true}
+	if c.Req.ContentLength != 0 {
+		if err := web.Bind(c.Req, &body); err != nil {
+			return response.Error(http.StatusBadRequest, "bad request data", err)
+		}
+	}
+	comment, err := s.comments.SetResolved(c.Req.Context(), grn, id, body.Resolved, store.GetUserIDString(c.SignedInUser))
+	if err != nil {
+		if errors.Is(err, comments.ErrCommentNotFound) {
+			return commentError(err)
+		}
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.JSON(http.StatusOK, comment)
+}
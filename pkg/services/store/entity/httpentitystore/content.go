@@ -0,0 +1,212 @@
+package httpentitystore
+
+import (
+	"mime"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/usage"
+)
+
+const (
+	// contentSearchCandidates is the number of entities read from the store to search by content
+	contentSearchCandidates = 1000
+	// maxContentSearchBodySize is the largest body searched by content, bigger bodies only match
+	// by name and description
+	maxContentSearchBodySize = 1 << 20
+	// maxContentHighlights is the number of highlighted fragments returned per field
+	maxContentHighlights = 3
+	// contentHighlightContext is the number of bytes kept around matches in highlighted fragments
+	contentHighlightContext = 40
+)
+
+// contentSearchResponse is a search response with highlights of matched terms
+type contentSearchResponse struct {
+	Results []*entity.EntitySearchResult `json:"results"`
+	// Highlights of results keyed by GRN
+	Highlights map[string][]contentHighlight `json:"highlights,omitempty"`
+	// More results may match, the query was only run on the first candidates of the store
+	Truncated bool `json:"truncated,omitempty"`
+	// Usage summaries of results keyed by GRN, returned with ?usage=true
+	Usage map[string]usage.Summary `json:"usage,omitempty"`
+}
+
+// contentHighlight is a fragment of a name, description or body with matched terms
+type contentHighlight struct {
+	Field    string `json:"field"`
+	Fragment string `json:"fragment"`
+	// Byte offsets of matched terms in the fragment
+	Matches [][2]int `json:"matches"`
+}
+
+// isTextKind returns true when bodies of a kind are text that can be searched by content.
+// Non raw kinds are stored as JSON
+func isTextKind(info entity.EntityKindInfo) bool {
+	if !info.IsRaw {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(info.MimeType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "+yaml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml",
+		"application/jsonnet", "application/x-jsonnet", "application/sql", "application/graphql":
+		return true
+	}
+	return false
+}
+
+// textKindChecker returns a function to check if bodies of a kind are searchable text,
+// kinds which are not registered are stored as JSON objects
+func (s *httpEntityStore) textKindChecker() func(kind string) bool {
+	return func(kind string) bool {
+		info, err := s.kinds.GetInfo(kind)
+		if err != nil {
+			return true
+		}
+		return isTextKind(info)
+	}
+}
+
+// searchContent keeps results where all query terms are found in the name, description or
+// body of text kinds, ignoring case. Bodies are removed from results unless withBody is set
+func searchContent(results []*entity.EntitySearchResult, query string, limit int64, withBody bool, isText func(kind string) bool) *contentSearchResponse {
+	var terms []*regexp.Regexp
+	for _, term := range strings.Fields(query) {
+		terms = append(terms, regexp.MustCompile("(?i)"+regexp.QuoteMeta(term)))
+	}
+
+	rsp := &contentSearchResponse{
+		Results:    []*entity.EntitySearchResult{},
+		Highlights: make(map[string][]contentHighlight),
+	}
+	for _, r := range results {
+		if limit > 0 && int64(len(rsp.Results)) >= limit {
+			rsp.Truncated = true
+			break
+		}
+
+		fields := []struct {
+			name string
+			text string
+		}{
+			{name: "name", text: r.Name},
+			{name: "description", text: r.Description},
+		}
+		if r.GRN != nil && len(r.Body) <= maxContentSearchBodySize && isText(r.GRN.ResourceKind) && utf8.Valid(r.Body) {
+			fields = append(fields, struct {
+				name string
+				text string
+			}{name: "body", text: string(r.Body)})
+		}
+
+		matched := make([]bool, len(terms))
+		var highlights []contentHighlight
+		for _, field := range fields {
+			var matches [][]int
+			for i, term := range terms {
+				found := term.FindAllStringIndex(field.text, -1)
+				if len(found) > 0 {
+					matched[i] = true
+					matches = append(matches, found...)
+				}
+			}
+			highlights = append(highlights, highlightMatches(field.name, field.text, matches)...)
+		}
+		if len(terms) == 0 || !allTrue(matched) {
+			continue
+		}
+
+		if !withBody {
+			r.Body = nil
+		}
+		rsp.Results = append(rsp.Results, r)
+		if r.GRN != nil && len(highlights) > 0 {
+			rsp.Highlights[r.GRN.ToGRNString()] = highlights
+		}
+	}
+	if int64(len(results)) >= contentSearchCandidates {
+		rsp.Truncated = true
+	}
+	return rsp
+}
+
+// highlightMatches returns fragments of text around matches, matches close to each other
+// share a fragment
+func highlightMatches(field string, text string, matches [][]int) []contentHighlight {
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i][0] < matches[j][0]
+	})
+
+	var highlights []contentHighlight
+	var current *contentHighlight
+	start, end := 0, 0
+	for _, m := range matches {
+		if current != nil && m[0] < end {
+			if m[1] > end {
+				// overlapping matches of different terms, extend the fragment to the last one
+				end = runeBoundary(text, minInt(len(text), m[1]+contentHighlightContext))
+				current.Fragment = text[start:end]
+			}
+			current.Matches = append(current.Matches, [2]int{m[0] - start, m[1] - start})
+			continue
+		}
+		if len(highlights) == maxContentHighlights {
+			break
+		}
+		start = runeBoundary(text, maxInt(0, m[0]-contentHighlightContext))
+		end = runeBoundary(text, minInt(len(text), m[1]+contentHighlightContext))
+		highlights = append(highlights, contentHighlight{
+			Field:    field,
+			Fragment: text[start:end],
+			Matches:  [][2]int{{m[0] - start, m[1] - start}},
+		})
+		current = &highlights[len(highlights)-1]
+	}
+	return highlights
+}
+
+// runeBoundary moves an offset back to the start of a rune
+func runeBoundary(text string, offset int) int {
+	for offset > 0 && offset < len(text) && !utf8.RuneStart(text[offset]) {
+		offset--
+	}
+	return offset
+}
+
+func allTrue(values []bool) bool {
+	for _, v := range values {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
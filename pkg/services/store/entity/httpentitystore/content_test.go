@@ -0,0 +1,92 @@
+package httpentitystore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+func TestIsTextKind(t *testing.T) {
+	require.True(t, isTextKind(entity.EntityKindInfo{ID: "dashboard"}))
+	require.True(t, isTextKind(entity.EntityKindInfo{ID: "markdown", IsRaw: true, MimeType: "text/markdown; charset=utf-8"}))
+	require.True(t, isTextKind(entity.EntityKindInfo{ID: "jsonnet", IsRaw: true, MimeType: "application/x-jsonnet"}))
+	require.True(t, isTextKind(entity.EntityKindInfo{ID: "svg", IsRaw: true, MimeType: "image/svg+xml"}))
+	require.False(t, isTextKind(entity.EntityKindInfo{ID: "png", IsRaw: true, MimeType: "image/png"}))
+	require.False(t, isTextKind(entity.EntityKindInfo{ID: "dummy", IsRaw: true}))
+}
+
+func TestSearchContent(t *testing.T) {
+	result := func(kind, uid, name string, body string) *entity.EntitySearchResult {
+		return &entity.EntitySearchResult{
+			GRN:  &grn.GRN{TenantID: 1, ResourceKind: kind, ResourceIdentifier: uid},
+			Name: name,
+			Body: []byte(body),
+		}
+	}
+	isText := func(kind string) bool {
+		return kind != "png"
+	}
+	newResults := func() []*entity.EntitySearchResult {
+		return []*entity.EntitySearchResult{
+			result("dashboard", "a", "CPU usage", `{"panels":[{"targets":[{"expr":"rate(node_cpu_seconds_total[5m])"}]}]}`),
+			result("markdown", "b", "Runbook", "# Runbook\n\nCheck NODE_CPU_SECONDS_TOTAL when the alert fires."),
+			result("png", "c", "Screenshot", "node_cpu_seconds_total"),
+			result("markdown", "d", "Node cpu", "nothing to see"),
+		}
+	}
+
+	t.Run("matches bodies of text kinds ignoring case", func(t *testing.T) {
+		rsp := searchContent(newResults(), "node_cpu_seconds_total", 0, false, isText)
+		require.Len(t, rsp.Results, 2)
+		require.Equal(t, "a", rsp.Results[0].GRN.ResourceIdentifier)
+		require.Equal(t, "b", rsp.Results[1].GRN.ResourceIdentifier)
+		require.Nil(t, rsp.Results[0].Body)
+		require.False(t, rsp.Truncated)
+
+		highlights := rsp.Highlights[rsp.Results[1].GRN.ToGRNString()]
+		require.Len(t, highlights, 1)
+		require.Equal(t, "body", highlights[0].Field)
+		m := highlights[0].Matches[0]
+		require.Equal(t, "NODE_CPU_SECONDS_TOTAL", highlights[0].Fragment[m[0]:m[1]])
+	})
+
+	t.Run("all terms must match in any field", func(t *testing.T) {
+		rsp := searchContent(newResults(), "node cpu", 0, true, isText)
+		require.Len(t, rsp.Results, 3)
+		require.NotNil(t, rsp.Results[0].Body)
+
+		highlights := rsp.Highlights[rsp.Results[2].GRN.ToGRNString()]
+		require.Len(t, highlights, 1)
+		require.Equal(t, contentHighlight{Field: "name", Fragment: "Node cpu", Matches: [][2]int{{0, 4}, {5, 8}}}, highlights[0])
+	})
+
+	t.Run("results are limited", func(t *testing.T) {
+		rsp := searchContent(newResults(), "node", 1, false, isText)
+		require.Len(t, rsp.Results, 1)
+		require.True(t, rsp.Truncated)
+	})
+
+	t.Run("large bodies are not searched", func(t *testing.T) {
+		large := make([]byte, maxContentSearchBodySize+1)
+		for i := range large {
+			large[i] = 'x'
+		}
+		results := []*entity.EntitySearchResult{result("markdown", "e", "Large", string(large))}
+		rsp := searchContent(results, "xxx", 0, false, isText)
+		require.Len(t, rsp.Results, 0)
+	})
+}
+
+func TestHighlightMatches(t *testing.T) {
+	text := "a rather long text where the word cpu appears, and then much later after a long while cpu appears again"
+	highlights := highlightMatches("body", text, [][]int{{86, 89}, {34, 37}})
+	require.Len(t, highlights, 2)
+	for _, h := range highlights {
+		require.Len(t, h.Matches, 1)
+		require.Equal(t, "cpu", h.Fragment[h.Matches[0][0]:h.Matches[0][1]])
+		require.LessOrEqual(t, len(h.Fragment), 3+2*contentHighlightContext)
+	}
+}
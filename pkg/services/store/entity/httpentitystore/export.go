@@ -0,0 +1,247 @@
+package httpentitystore
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+)
+
+const (
+	// defaultExportLimit is the number of exported entities when the limit is not set
+	defaultExportLimit = 1000
+	maxExportLimit     = 10000
+)
+
+// exportTable is a listing of entities, values are strings, int64, float64 or bool
+type exportTable struct {
+	header []string
+	rows   [][]any
+}
+
+// doExport writes entities matching the search filters as CSV, or XLSX with ?format=xlsx.
+// Summary fields are added as columns with ?field=name. Results over the limit are not exported,
+// this is flagged with the X-Export-Truncated header
+func (s *httpEntityStore) doExport(c *contextmodel.ReqContext) response.Response {
+	vals := c.Req.URL.Query()
+	req, err := searchRequestFromQuery(vals)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	req.WithBody = false
+	req.WithFields = len(vals["field"]) > 0
+	if req.Limit <= 0 {
+		req.Limit = defaultExportLimit
+	}
+	if req.Limit > maxExportLimit {
+		req.Limit = maxExportLimit
+	}
+
+	format := vals.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return response.Error(http.StatusBadRequest, "unsupported format: "+format, nil)
+	}
+
+	rsp, err := s.store.Search(c.Req.Context(), req)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error searching entities", err)
+	}
+	if rsp.Results, err = readableResults(rsp.Results, s.allows(c, access.VerbRead)); err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading entity access", err)
+	}
+	table, err := newExportTable(rsp.Results, vals["field"])
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading summary fields", err)
+	}
+
+	var body []byte
+	contentType := "text/csv"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		body, err = table.xlsx()
+	} else {
+		body, err = table.csv()
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error writing export", err)
+	}
+
+	header := http.Header{
+		"Content-Type":        []string{contentType},
+		"Content-Disposition": []string{fmt.Sprintf(`attachment;filename="entities-%s.%s"`, time.Now().UTC().Format("20060102-150405"), format)},
+	}
+	if rsp.NextPageToken != "" {
+		header.Set("X-Export-Truncated", "true")
+	}
+	return response.CreateNormalResponse(header, body, http.StatusOK)
+}
+
+func newExportTable(results []*entity.EntitySearchResult, fields []string) (*exportTable, error) {
+	table := &exportTable{
+		header: append([]string{"uid", "name", "kind", "folder", "size", "updated", "updatedBy"}, fields...),
+		rows:   make([][]any, 0, len(results)),
+	}
+	for _, r := range results {
+		updated := ""
+		if r.UpdatedAt > 0 {
+			updated = time.UnixMilli(r.UpdatedAt).UTC().Format(time.RFC3339)
+		}
+		row := []any{r.GRN.ResourceIdentifier, r.Name, r.GRN.ResourceKind, r.Folder, r.Size, updated, r.UpdatedBy}
+
+		var summary map[string]any
+		if len(fields) > 0 && len(r.FieldsJson) > 0 {
+			if err := json.Unmarshal(r.FieldsJson, &summary); err != nil {
+				return nil, err
+			}
+		}
+		for _, f := range fields {
+			switch v := summary[f].(type) {
+			case nil:
+				row = append(row, "")
+			case string, float64, bool:
+				row = append(row, v)
+			default:
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				row = append(row, string(b))
+			}
+		}
+		table.rows = append(table.rows, row)
+	}
+	return table, nil
+}
+
+func (t *exportTable) csv() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	if err := w.Write(t.header); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(t.header))
+	for _, row := range t.rows {
+		for i, v := range row {
+			switch v := v.(type) {
+			case string:
+				record[i] = escapeCSVFormula(v)
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// escapeCSVFormula prevents spreadsheet applications from evaluating names and
+// summaries of entities as formulas
+func escapeCSVFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// xlsx writes the table as a single sheet workbook with inline strings
+func (t *exportTable) xlsx() ([]byte, error) {
+	sheet := &bytes.Buffer{}
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]any, len(t.header))
+	for i, h := range t.header {
+		header[i] = h
+	}
+	for r, row := range append([][]any{header}, t.rows...) {
+		fmt.Fprintf(sheet, `<row r="%d">`, r+1)
+		for c, v := range row {
+			ref := xlsxColumn(c) + strconv.Itoa(r+1)
+			switch v := v.(type) {
+			case int64:
+				fmt.Fprintf(sheet, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case float64:
+				fmt.Fprintf(sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v, 'f', -1, 64))
+			case bool:
+				b := 0
+				if v {
+					b = 1
+				}
+				fmt.Fprintf(sheet, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+			default:
+				fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+				if err := xml.EscapeText(sheet, []byte(fmt.Sprint(v))); err != nil {
+					return nil, err
+				}
+				sheet.WriteString(`</t></is></c>`)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := []struct {
+		name string
+		body []byte
+	}{
+		{"[Content_Types].xml", []byte(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`)},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`)},
+		{"xl/workbook.xml", []byte(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Entities" sheetId="1" r:id="rId1"/></sheets></workbook>`)},
+		{"xl/_rels/workbook.xml.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`)},
+		{"xl/worksheets/sheet1.xml", sheet.Bytes()},
+	}
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(f.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// xlsxColumn returns the letters of a zero based column index: A, B, ..., Z, AA, ...
+func xlsxColumn(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
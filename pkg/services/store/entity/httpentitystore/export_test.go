@@ -0,0 +1,66 @@
+package httpentitystore
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+func TestExportTable(t *testing.T) {
+	results := []*entity.EntitySearchResult{
+		{
+			GRN:        &grn.GRN{ResourceKind: "dashboard", ResourceIdentifier: "abc"},
+			Name:       "=HYPERLINK(\"x\")",
+			Folder:     "ops",
+			Size:       120,
+			UpdatedAt:  1631613600000,
+			UpdatedBy:  "user:1",
+			FieldsJson: []byte(`{"schemaVersion":36,"tags":["a","b"],"live":true}`),
+		},
+		{
+			GRN:  &grn.GRN{ResourceKind: "playlist", ResourceIdentifier: "def"},
+			Name: "Morning <review>",
+		},
+	}
+	table, err := newExportTable(results, []string{"schemaVersion", "tags", "live"})
+	require.NoError(t, err)
+
+	body, err := table.csv()
+	require.NoError(t, err)
+	require.Equal(t, "uid,name,kind,folder,size,updated,updatedBy,schemaVersion,tags,live\n"+
+		"abc,\"'=HYPERLINK(\"\"x\"\")\",dashboard,ops,120,2021-09-14T10:00:00Z,user:1,36,\"[\"\"a\"\",\"\"b\"\"]\",true\n"+
+		"def,Morning <review>,playlist,,0,,,,,\n", string(body))
+
+	body, err = table.xlsx()
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	names := make([]string, 0, len(zr.File))
+	var sheet []byte
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, err := f.Open()
+			require.NoError(t, err)
+			sheet, err = io.ReadAll(r)
+			require.NoError(t, err)
+		}
+	}
+	require.Contains(t, names, "[Content_Types].xml")
+	require.Contains(t, string(sheet), `<c r="E2"><v>120</v></c>`)
+	require.Contains(t, string(sheet), `<c r="J2" t="b"><v>1</v></c>`)
+	require.Contains(t, string(sheet), `Morning &lt;review&gt;`)
+}
+
+func TestXLSXColumn(t *testing.T) {
+	require.Equal(t, "A", xlsxColumn(0))
+	require.Equal(t, "Z", xlsxColumn(25))
+	require.Equal(t, "AA", xlsxColumn(26))
+	require.Equal(t, "BA", xlsxColumn(52))
+}
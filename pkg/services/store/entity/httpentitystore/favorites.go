@@ -0,0 +1,148 @@
+package httpentitystore
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/favorites"
+)
+
+func (s *httpEntityStore) doListStarred(c *contextmodel.ReqContext) response.Response {
+	rows, err := s.favorites.ListStarred(c.Req.Context(), c.UserID, c.OrgID, c.Req.URL.Query()["kind"])
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading favorites", err)
+	}
+	if rows, err = readableRows(rows, func(f favorites.Favorite) string { return f.GRN }, s.allows(c, access.VerbRead)); err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading entity access", err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"favorites": rows})
+}
+
+func (s *httpEntityStore) doStar(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	rsp, err := s.store.Read(c.Req.Context(), &entity.ReadEntityRequest{GRN: grn})
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error fetching entity", err)
+	}
+	if rsp == nil || rsp.GRN == nil {
+		return response.Error(http.StatusNotFound, "entity not found", nil)
+	}
+	if err := s.favorites.Star(c.Req.Context(), c.UserID, grn); err != nil {
+		return response.Error(http.StatusInternalServerError, "error saving favorite", err)
+	}
+	return response.Success("entity starred")
+}
+
+func (s *httpEntityStore) doUnstar(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if err := s.favorites.Unstar(c.Req.Context(), c.UserID, grn); err != nil {
+		return response.Error(http.StatusInternalServerError, "error removing favorite", err)
+	}
+	return response.Success("entity unstarred")
+}
+
+// doListRecent returns entities recently viewed by the signed in user, ?limit is capped at the number of kept views
+func (s *httpEntityStore) doListRecent(c *contextmodel.ReqContext) response.Response {
+	limit := 0
+	if v := c.Req.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return response.Error(http.StatusBadRequest, "bad limit", err)
+		}
+	}
+	rows, err := s.favorites.ListRecent(c.Req.Context(), c.UserID, c.OrgID, limit)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading recently viewed", err)
+	}
+	if rows, err = readableRows(rows, func(v favorites.RecentView) string { return v.GRN }, s.allows(c, access.VerbRead)); err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading entity access", err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"recent": rows})
+}
+
+// recordView tracks an entity read by the signed in user, failures do not fail the read
+func (s *httpEntityStore) recordView(c *contextmodel.ReqContext, g *grn.GRN) {
+	if c.UserID == 0 {
+		return
+	}
+	if err := s.favorites.RecordView(c.Req.Context(), c.UserID, g); err != nil {
+		s.log.Warn("error recording entity view", "grn", g.ToGRNString(), "error", err)
+	}
+}
+
+// personalFilters returns sets of entities starred or recently viewed by the signed in user
+// when ?starred or ?recent are set
+func (s *httpEntityStore) personalFilters(c *contextmodel.ReqContext) ([]map[string]bool, error) {
+	vals := c.Req.URL.Query()
+	var keep []map[string]bool
+	if asBoolean("starred", vals, false) {
+		grns, err := s.favorites.StarredGRNs(c.Req.Context(), c.UserID, c.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		keep = append(keep, grns)
+	}
+	if asBoolean("recent", vals, false) {
+		grns, err := s.favorites.RecentGRNs(c.Req.Context(), c.UserID, c.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		keep = append(keep, grns)
+	}
+	return keep, nil
+}
+
+// filterSearchResults keeps results with GRNs in all sets
+func filterSearchResults(results []*entity.EntitySearchResult, keep ...map[string]bool) []*entity.EntitySearchResult {
+	if len(keep) == 0 {
+		return results
+	}
+	filtered := make([]*entity.EntitySearchResult, 0, len(results))
+outer:
+	for _, r := range results {
+		if r.GRN == nil {
+			continue
+		}
+		key := r.GRN.ToGRNString()
+		for _, set := range keep {
+			if !set[key] {
+				continue outer
+			}
+		}
+		filtered = append(filtered, r)
+	}
+	return filtered
+}
+
+// readableRows drops rows of entities the user can't read anymore, rows with invalid GRNs are dropped too
+func readableRows[T any](rows []T, grnOf func(T) string, canRead accessFunc) ([]T, error) {
+	filtered := rows[:0]
+	for _, row := range rows {
+		g, err := grn.ParseStr(grnOf(row))
+		if err != nil {
+			continue
+		}
+		ok, err := canRead(g)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,30 @@
+package httpentitystore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+func TestFilterSearchResults(t *testing.T) {
+	result := func(uid string) *entity.EntitySearchResult {
+		return &entity.EntitySearchResult{GRN: &grn.GRN{TenantID: 1, ResourceKind: "dashboard", ResourceIdentifier: uid}}
+	}
+	key := func(uid string) string {
+		return result(uid).GRN.ToGRNString()
+	}
+	results := []*entity.EntitySearchResult{result("a"), result("b"), result("c"), {}}
+
+	require.Len(t, filterSearchResults(results), 4)
+
+	starred := map[string]bool{key("a"): true, key("c"): true}
+	filtered := filterSearchResults(results, starred)
+	require.Equal(t, []*entity.EntitySearchResult{results[0], results[2]}, filtered)
+
+	recent := map[string]bool{key("c"): true, key("b"): true}
+	filtered = filterSearchResults(results, starred, recent)
+	require.Equal(t, []*entity.EntitySearchResult{results[2]}, filtered)
+}
@@ -0,0 +1,35 @@
+package httpentitystore
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *httpEntityStore) doStartKindMigration(c *contextmodel.ReqContext) response.Response {
+	migrator, ok := s.store.(entity.KindMigrator)
+	if !ok {
+		return response.Error(http.StatusNotImplemented, "kind migrations are not supported by the store", nil)
+	}
+	kind := web.Params(c.Req)[":kind"]
+	status, err := migrator.StartKindMigration(c.Req.Context(), kind)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.JSON(http.StatusAccepted, status)
+}
+
+func (s *httpEntityStore) doGetKindMigration(c *contextmodel.ReqContext) response.Response {
+	migrator, ok := s.store.(entity.KindMigrator)
+	if !ok {
+		return response.Error(http.StatusNotImplemented, "kind migrations are not supported by the store", nil)
+	}
+	status := migrator.GetKindMigrationStatus(web.Params(c.Req)[":kind"])
+	if status == nil {
+		return response.Error(http.StatusNotFound, "kind migration not found", nil)
+	}
+	return response.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,116 @@
+package httpentitystore
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+// Entity representations selected with the Accept header
+const (
+	entityFormatJSON = "json"
+	entityFormatYAML = "yaml"
+	entityFormatRaw  = "raw"
+)
+
+type acceptedType struct {
+	mediaType string
+	q         float64
+}
+
+// negotiateEntityFormat picks an entity representation from the Accept header.
+// mime is the media type of raw bodies of the kind. An empty string is returned when none of
+// the accepted types is supported
+func negotiateEntityFormat(accept string, mime string, defaultFormat string) string {
+	if strings.TrimSpace(accept) == "" {
+		return defaultFormat
+	}
+
+	accepted := make([]acceptedType, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		t := acceptedType{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					t.q = q
+				}
+			}
+		}
+		if t.mediaType != "" && t.q > 0 {
+			accepted = append(accepted, t)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].q > accepted[j].q
+	})
+
+	for _, t := range accepted {
+		switch t.mediaType {
+		case "*/*", "application/*":
+			return defaultFormat
+		case "application/json":
+			return entityFormatJSON
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			return entityFormatYAML
+		case "application/octet-stream":
+			return entityFormatRaw
+		}
+		if mime != "" && t.mediaType == strings.ToLower(mime) {
+			return entityFormatRaw
+		}
+	}
+	return ""
+}
+
+// entityYAML renders the JSON representation of a value as YAML
+func entityYAML(v any) response.Response {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return response.Error(500, "error encoding entity", err)
+	}
+	return bodyYAML(b)
+}
+
+// bodyYAML renders a JSON body as YAML
+func bodyYAML(body []byte) response.Response {
+	var obj any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return response.Error(http.StatusNotAcceptable, "body is not JSON, it can not be rendered as YAML", err)
+	}
+	return response.YAML(200, obj)
+}
+
+// canonicalJSON renders a JSON body compacted, with sorted object keys
+func canonicalJSON(body []byte) response.Response {
+	var obj any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return response.Error(http.StatusNotAcceptable, "body is not JSON", err)
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return response.Error(500, "error encoding body", err)
+	}
+	return response.CreateNormalResponse(http.Header{"Content-Type": []string{"application/json"}}, b, 200)
+}
+
+// rawBody returns the body of an entity as it was saved
+func rawBody(rsp *entity.Entity, mime string) response.Response {
+	if mime == "" {
+		mime = "application/json"
+	}
+	return response.CreateNormalResponse(
+		http.Header{
+			"Content-Type": []string{mime},
+			"ETag":         []string{rsp.ETag},
+		},
+		rsp.Body,
+		200,
+	)
+}
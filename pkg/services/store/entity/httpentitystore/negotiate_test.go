@@ -0,0 +1,29 @@
+package httpentitystore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEntityFormat(t *testing.T) {
+	tests := []struct {
+		accept   string
+		mime     string
+		expected string
+	}{
+		{accept: "", expected: entityFormatJSON},
+		{accept: "*/*", expected: entityFormatJSON},
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", expected: entityFormatJSON},
+		{accept: "application/yaml", expected: entityFormatYAML},
+		{accept: "text/yaml; charset=utf-8", expected: entityFormatYAML},
+		{accept: "application/json;q=0.5, application/yaml", expected: entityFormatYAML},
+		{accept: "application/octet-stream", expected: entityFormatRaw},
+		{accept: "image/png", mime: "image/png", expected: entityFormatRaw},
+		{accept: "image/png", expected: ""},
+		{accept: "application/yaml;q=0", expected: ""},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, negotiateEntityFormat(tt.accept, tt.mime, entityFormatJSON), tt.accept)
+	}
+}
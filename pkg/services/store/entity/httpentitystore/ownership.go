@@ -0,0 +1,112 @@
+package httpentitystore
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/ownership"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// ownerMe is replaced by the signed in user identity in owner filters
+const ownerMe = "me"
+
+func ownershipError(err error) response.Response {
+	switch {
+	case errors.Is(err, ownership.ErrEntityNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, ownership.ErrEmptyOwner), errors.Is(err, ownership.ErrSameOwner):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, "error saving ownership", err)
+}
+
+func (s *httpEntityStore) doGetOwnership(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	rsp, err := s.ownership.Get(c.Req.Context(), grn)
+	if err != nil {
+		return ownershipError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+// doSetOwnership changes the owner and steward of an entity, only the current owner or an
+// org admin can do it
+func (s *httpEntityStore) doSetOwnership(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbWrite); errRsp != nil {
+		return errRsp
+	}
+	cmd := ownership.SetOwnershipCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	current, err := s.ownership.Get(c.Req.Context(), grn)
+	if err != nil {
+		return ownershipError(err)
+	}
+	identity := store.GetUserIDString(c.SignedInUser)
+	if current.Owner != identity && !c.SignedInUser.HasRole(org.RoleAdmin) {
+		return response.Error(http.StatusForbidden, "only the owner or an org admin can change ownership", nil)
+	}
+	rsp, err := s.ownership.Set(c.Req.Context(), grn, cmd, identity)
+	if err != nil {
+		return ownershipError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+func (s *httpEntityStore) doReassignOwnership(c *contextmodel.ReqContext) response.Response {
+	cmd := ownership.ReassignCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	rsp, err := s.ownership.Reassign(c.Req.Context(), c.OrgID, cmd, store.GetUserIDString(c.SignedInUser))
+	if err != nil {
+		return ownershipError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+// ownershipFilters returns sets of entities owned or stewarded by an identity when ?owner or
+// ?steward are set, "me" is the signed in user
+func (s *httpEntityStore) ownershipFilters(c *contextmodel.ReqContext) ([]map[string]bool, error) {
+	vals := c.Req.URL.Query()
+	identity := func(key string) string {
+		v := vals.Get(key)
+		if v == ownerMe {
+			return store.GetUserIDString(c.SignedInUser)
+		}
+		return v
+	}
+	var keep []map[string]bool
+	if owner := identity("owner"); owner != "" {
+		grns, err := s.ownership.OwnedGRNs(c.Req.Context(), c.OrgID, owner)
+		if err != nil {
+			return nil, err
+		}
+		keep = append(keep, grns)
+	}
+	if steward := identity("steward"); steward != "" {
+		grns, err := s.ownership.StewardedGRNs(c.Req.Context(), c.OrgID, steward)
+		if err != nil {
+			return nil, err
+		}
+		keep = append(keep, grns)
+	}
+	return keep, nil
+}
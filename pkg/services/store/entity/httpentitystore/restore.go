@@ -0,0 +1,200 @@
+package httpentitystore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Statuses of entities in a restore response
+const (
+	restoreStatusRestored  = "restored"
+	restoreStatusPending   = "pending" // would be restored, returned for dry runs
+	restoreStatusUnchanged = "unchanged"
+	restoreStatusSkipped   = "skipped"
+)
+
+type restoredEntity struct {
+	GRN string `json:"grn"`
+	// Version the entity is restored from
+	Version    string `json:"version,omitempty"`
+	NewVersion string `json:"newVersion,omitempty"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// doRestore writes the body of the version an entity had at the ?at time (RFC3339 or epoch
+// milliseconds) as a new version. Folders are restored with their whole subtree with ?recursive=true.
+// Entities created after that time are skipped, deleted entities have no history to restore from.
+// Nothing is written with ?dryRun=true, entities under retention need ?override=<reason>.
+// Entities of a folder the user can't write are skipped.
+func (s *httpEntityStore) doRestore(c *contextmodel.ReqContext) response.Response {
+	g, params, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	at, err := parseRestoreTime(params["at"])
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	dryRun := params["dryRun"] == "true"
+	if errRsp := s.checkAccess(c, g, access.VerbWrite); errRsp != nil {
+		return errRsp
+	}
+	ctx, errRsp := retentionContext(c)
+	if errRsp != nil {
+		return errRsp
+	}
+
+	entities := make([]restoredEntity, 0)
+	if g.ResourceKind == entity.StandardKindFolder && params["recursive"] == "true" {
+		entities, err = s.restoreFolder(ctx, g, at, dryRun, s.allows(c, access.VerbWrite), entities)
+	} else {
+		var r restoredEntity
+		r, err = s.restoreEntity(ctx, g, at, dryRun)
+		entities = append(entities, r)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error restoring entities", err)
+	}
+	return response.JSON(http.StatusOK, util.DynMap{
+		"at":       at.UTC().Format(time.RFC3339),
+		"dryRun":   dryRun,
+		"entities": entities,
+	})
+}
+
+func parseRestoreTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, fmt.Errorf("missing point in time, set ?at=")
+	}
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid point in time, expected RFC3339 or epoch milliseconds")
+	}
+	return t, nil
+}
+
+// restoreEntity restores a single entity to the last version saved before at
+func (s *httpEntityStore) restoreEntity(ctx context.Context, g *grn.GRN, at time.Time, dryRun bool) (restoredEntity, error) {
+	out := restoredEntity{GRN: g.ToGRNString()}
+	history, err := s.store.History(ctx, &entity.EntityHistoryRequest{GRN: g})
+	if err != nil {
+		return out, err
+	}
+	var current, target *entity.EntityVersionInfo
+	for _, v := range history.Versions {
+		if current == nil || v.UpdatedAt > current.UpdatedAt {
+			current = v
+		}
+		if v.UpdatedAt <= at.UnixMilli() && (target == nil || v.UpdatedAt > target.UpdatedAt) {
+			target = v
+		}
+	}
+	switch {
+	case current == nil:
+		out.Status = restoreStatusSkipped
+		out.Reason = "no history"
+		return out, nil
+	case target == nil:
+		out.Status = restoreStatusSkipped
+		out.Reason = "no version saved before the point in time"
+		return out, nil
+	case target.Version == current.Version:
+		out.Version = target.Version
+		out.Status = restoreStatusUnchanged
+		return out, nil
+	}
+	out.Version = target.Version
+	if dryRun {
+		out.Status = restoreStatusPending
+		return out, nil
+	}
+
+	old, err := s.store.Read(ctx, &entity.ReadEntityRequest{
+		GRN:      g,
+		Version:  target.Version,
+		WithBody: true,
+	})
+	if err != nil {
+		return out, err
+	}
+	if old.GRN == nil {
+		out.Status = restoreStatusSkipped
+		out.Reason = "version not found"
+		return out, nil
+	}
+	rsp, err := s.store.Write(ctx, &entity.WriteEntityRequest{
+		GRN:             g,
+		Body:            old.Body,
+		Comment:         fmt.Sprintf("restored version %s from %s", target.Version, time.UnixMilli(target.UpdatedAt).UTC().Format(time.RFC3339)),
+		PreviousVersion: current.Version,
+	})
+	if err != nil {
+		return out, err
+	}
+	if rsp.Error != nil {
+		out.Status = restoreStatusSkipped
+		out.Reason = rsp.Error.Message
+		return out, nil
+	}
+	out.Status = restoreStatusRestored
+	if rsp.Entity != nil {
+		out.NewVersion = rsp.Entity.Version
+	}
+	return out, nil
+}
+
+// restoreFolder restores a folder, entities inside it and nested folders, entities which
+// can't be written are skipped
+func (s *httpEntityStore) restoreFolder(ctx context.Context, folder *grn.GRN, at time.Time, dryRun bool, canWrite accessFunc, restored []restoredEntity) ([]restoredEntity, error) {
+	r, err := s.restoreEntity(ctx, folder, at, dryRun)
+	if err != nil {
+		return restored, err
+	}
+	restored = append(restored, r)
+
+	rsp, err := s.store.Search(ctx, &entity.EntitySearchRequest{
+		Folder: folder.ResourceIdentifier,
+		Limit:  maxExportLimit,
+	})
+	if err != nil {
+		return restored, err
+	}
+	for _, item := range rsp.Results {
+		ok, err := canWrite(item.GRN)
+		if err != nil {
+			return restored, err
+		}
+		if !ok {
+			restored = append(restored, restoredEntity{
+				GRN:    item.GRN.ToGRNString(),
+				Status: restoreStatusSkipped,
+				Reason: "write access denied",
+			})
+			continue
+		}
+		if item.GRN.ResourceKind == entity.StandardKindFolder {
+			restored, err = s.restoreFolder(ctx, item.GRN, at, dryRun, canWrite, restored)
+		} else {
+			r, err = s.restoreEntity(ctx, item.GRN, at, dryRun)
+			restored = append(restored, r)
+		}
+		if err != nil {
+			return restored, err
+		}
+	}
+	return restored, nil
+}
@@ -0,0 +1,136 @@
+package httpentitystore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+type restoreTestStore struct {
+	entity.UnimplementedEntityStoreServer
+	history map[string][]*entity.EntityVersionInfo
+	bodies  map[string][]byte // by version
+	folders map[string][]*grn.GRN
+	writes  []*entity.WriteEntityRequest
+}
+
+func (s *restoreTestStore) History(_ context.Context, r *entity.EntityHistoryRequest) (*entity.EntityHistoryResponse, error) {
+	return &entity.EntityHistoryResponse{GRN: r.GRN, Versions: s.history[r.GRN.ResourceIdentifier]}, nil
+}
+
+func (s *restoreTestStore) Read(_ context.Context, r *entity.ReadEntityRequest) (*entity.Entity, error) {
+	return &entity.Entity{GRN: r.GRN, Version: r.Version, Body: s.bodies[r.Version]}, nil
+}
+
+func (s *restoreTestStore) Write(_ context.Context, r *entity.WriteEntityRequest) (*entity.WriteEntityResponse, error) {
+	s.writes = append(s.writes, r)
+	return &entity.WriteEntityResponse{GRN: r.GRN, Entity: &entity.EntityVersionInfo{Version: "new"}, Status: entity.WriteEntityResponse_UPDATED}, nil
+}
+
+func (s *restoreTestStore) Search(_ context.Context, r *entity.EntitySearchRequest) (*entity.EntitySearchResponse, error) {
+	rsp := &entity.EntitySearchResponse{}
+	for _, g := range s.folders[r.Folder] {
+		rsp.Results = append(rsp.Results, &entity.EntitySearchResult{GRN: g})
+	}
+	return rsp, nil
+}
+
+func TestRestore(t *testing.T) {
+	store := &restoreTestStore{
+		history: map[string][]*entity.EntityVersionInfo{
+			"ops":  {{Version: "f1", UpdatedAt: 1000}},
+			"a":    {{Version: "a3", UpdatedAt: 3000}, {Version: "a2", UpdatedAt: 2000}, {Version: "a1", UpdatedAt: 1000}},
+			"b":    {{Version: "b1", UpdatedAt: 1000}},
+			"c":    {{Version: "c1", UpdatedAt: 4000}},
+			"team": {{Version: "t1", UpdatedAt: 1000}},
+		},
+		bodies: map[string][]byte{"a2": []byte(`{"title":"v2"}`)},
+		folders: map[string][]*grn.GRN{
+			"ops": {
+				{ResourceKind: "dashboard", ResourceIdentifier: "a"},
+				{ResourceKind: entity.StandardKindFolder, ResourceIdentifier: "team"},
+			},
+			"team": {
+				{ResourceKind: "dashboard", ResourceIdentifier: "b"},
+				{ResourceKind: "dashboard", ResourceIdentifier: "c"},
+			},
+		},
+	}
+	s := &httpEntityStore{store: store}
+	at := time.UnixMilli(2500)
+
+	folder := &grn.GRN{ResourceKind: entity.StandardKindFolder, ResourceIdentifier: "ops"}
+	allowAll := func(*grn.GRN) (bool, error) { return true, nil }
+	restored, err := s.restoreFolder(context.Background(), folder, at, true, allowAll, nil)
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, r := range restored {
+		statuses[r.GRN] = r.Status
+	}
+	require.Equal(t, map[string]string{
+		"grn:0:folder/ops":  restoreStatusUnchanged,
+		"grn:0:dashboard/a": restoreStatusPending,
+		"grn:0:folder/team": restoreStatusUnchanged,
+		"grn:0:dashboard/b": restoreStatusUnchanged,
+		"grn:0:dashboard/c": restoreStatusSkipped,
+	}, statuses)
+	require.Empty(t, store.writes)
+
+	r, err := s.restoreEntity(context.Background(), &grn.GRN{ResourceKind: "dashboard", ResourceIdentifier: "a"}, at, false)
+	require.NoError(t, err)
+	require.Equal(t, restoredEntity{GRN: "grn:0:dashboard/a", Version: "a2", NewVersion: "new", Status: restoreStatusRestored}, r)
+	require.Len(t, store.writes, 1)
+	require.Equal(t, `{"title":"v2"}`, string(store.writes[0].Body))
+	require.Equal(t, "a3", store.writes[0].PreviousVersion)
+}
+
+func TestRestore_writeDenied(t *testing.T) {
+	store := &restoreTestStore{
+		history: map[string][]*entity.EntityVersionInfo{
+			"ops": {{Version: "f1", UpdatedAt: 1000}},
+			"a":   {{Version: "a2", UpdatedAt: 2000}, {Version: "a1", UpdatedAt: 1000}},
+		},
+		folders: map[string][]*grn.GRN{
+			"ops": {
+				{ResourceKind: "dashboard", ResourceIdentifier: "a"},
+				{ResourceKind: entity.StandardKindFolder, ResourceIdentifier: "team"},
+			},
+			"team": {
+				{ResourceKind: "dashboard", ResourceIdentifier: "b"},
+			},
+		},
+	}
+	s := &httpEntityStore{store: store}
+	canWrite := func(g *grn.GRN) (bool, error) { return g.ResourceIdentifier != "team", nil }
+
+	folder := &grn.GRN{ResourceKind: entity.StandardKindFolder, ResourceIdentifier: "ops"}
+	restored, err := s.restoreFolder(context.Background(), folder, time.UnixMilli(1500), false, canWrite, nil)
+	require.NoError(t, err)
+	require.Equal(t, []restoredEntity{
+		{GRN: "grn:0:folder/ops", Version: "f1", Status: restoreStatusUnchanged},
+		{GRN: "grn:0:dashboard/a", Version: "a1", NewVersion: "new", Status: restoreStatusRestored},
+		{GRN: "grn:0:folder/team", Status: restoreStatusSkipped, Reason: "write access denied"},
+	}, restored)
+	// Entities of the denied folder are not restored
+	require.Len(t, store.writes, 1)
+}
+
+func TestParseRestoreTime(t *testing.T) {
+	at, err := parseRestoreTime("1631613600000")
+	require.NoError(t, err)
+	require.Equal(t, int64(1631613600000), at.UnixMilli())
+
+	at, err = parseRestoreTime("2021-09-14T10:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, int64(1631613600000), at.UnixMilli())
+
+	_, err = parseRestoreTime("yesterday")
+	require.Error(t, err)
+	_, err = parseRestoreTime("")
+	require.Error(t, err)
+}
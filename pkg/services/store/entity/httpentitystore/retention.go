@@ -0,0 +1,107 @@
+package httpentitystore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/retention"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func retentionError(err error) response.Response {
+	switch {
+	case errors.Is(err, retention.ErrLocked):
+		return response.Error(http.StatusLocked, err.Error(), err)
+	case errors.Is(err, retention.ErrOverrideRequired):
+		return response.Error(http.StatusForbidden, err.Error(), err)
+	case errors.Is(err, retention.ErrPolicyNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, retention.ErrInvalidDays):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, "error saving retention policy", err)
+}
+
+// retentionContext returns the request context, allowing changes to entities under retention
+// when an org admin sets ?override=<reason>
+func retentionContext(c *contextmodel.ReqContext) (context.Context, response.Response) {
+	ctx := c.Req.Context()
+	reason := c.Req.URL.Query().Get("override")
+	if reason == "" {
+		return ctx, nil
+	}
+	if !c.SignedInUser.HasRole(org.RoleAdmin) {
+		return nil, response.Error(http.StatusForbidden, "only org admins can override retention", nil)
+	}
+	return retention.WithOverride(ctx, reason, store.GetUserIDString(c.SignedInUser)), nil
+}
+
+func (s *httpEntityStore) doListRetentionPolicies(c *contextmodel.ReqContext) response.Response {
+	rsp, err := s.retention.List(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return retentionError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+// doSetRetentionPolicy creates or changes a policy, shortening retention needs ?override=<reason>
+func (s *httpEntityStore) doSetRetentionPolicy(c *contextmodel.ReqContext) response.Response {
+	cmd := retention.SetPolicyCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	ctx, errRsp := retentionContext(c)
+	if errRsp != nil {
+		return errRsp
+	}
+	rsp, err := s.retention.Set(ctx, c.OrgID, cmd, store.GetUserIDString(c.SignedInUser))
+	if err != nil {
+		return retentionError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+// doDeleteRetentionPolicy removes the policy of ?folder and ?kind, this needs ?override=<reason>
+func (s *httpEntityStore) doDeleteRetentionPolicy(c *contextmodel.ReqContext) response.Response {
+	ctx, errRsp := retentionContext(c)
+	if errRsp != nil {
+		return errRsp
+	}
+	vals := c.Req.URL.Query()
+	if err := s.retention.Delete(ctx, c.OrgID, vals.Get("folder"), vals.Get("kind")); err != nil {
+		return retentionError(err)
+	}
+	return response.Success("retention policy deleted")
+}
+
+func (s *httpEntityStore) doGetRetentionStatus(c *contextmodel.ReqContext) response.Response {
+	grn, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	rsp, err := s.retention.Status(c.Req.Context(), grn)
+	if err != nil {
+		return retentionError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
+
+// doGetRetentionAudit lists the latest ?limit overrides of the org
+func (s *httpEntityStore) doGetRetentionAudit(c *contextmodel.ReqContext) response.Response {
+	limit, _ := strconv.Atoi(c.Req.URL.Query().Get("limit"))
+	rsp, err := s.retention.Audit(c.Req.Context(), c.OrgID, limit)
+	if err != nil {
+		return retentionError(err)
+	}
+	return response.JSON(http.StatusOK, rsp)
+}
@@ -1,12 +1,14 @@
 package httpentitystore
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -15,7 +17,14 @@ import (
 	"github.com/grafana/grafana/pkg/middleware"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/comments"
+	"github.com/grafana/grafana/pkg/services/store/entity/favorites"
+	"github.com/grafana/grafana/pkg/services/store/entity/ownership"
+	"github.com/grafana/grafana/pkg/services/store/entity/retention"
+	"github.com/grafana/grafana/pkg/services/store/entity/usage"
 	"github.com/grafana/grafana/pkg/services/store/kind"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -26,16 +35,30 @@ type HTTPEntityStore interface {
 }
 
 type httpEntityStore struct {
-	store entity.EntityStoreServer
-	log   log.Logger
-	kinds kind.KindRegistry
+	store     entity.EntityStoreServer
+	log       log.Logger
+	kinds     kind.KindRegistry
+	access    *access.Service
+	users     user.Service
+	comments  *comments.Service
+	favorites *favorites.Service
+	ownership *ownership.Service
+	usage     *usage.Service
+	retention *retention.Service
 }
 
-func ProvideHTTPEntityStore(store entity.EntityStoreServer, kinds kind.KindRegistry) HTTPEntityStore {
+func ProvideHTTPEntityStore(store entity.EntityStoreServer, kinds kind.KindRegistry, access *access.Service, users user.Service, comments *comments.Service, favorites *favorites.Service, ownership *ownership.Service, usage *usage.Service, retention *retention.Service) HTTPEntityStore {
 	return &httpEntityStore{
-		store: store,
-		log:   log.New("http-entity-store"),
-		kinds: kinds,
+		store:     store,
+		log:       log.New("http-entity-store"),
+		kinds:     kinds,
+		access:    access,
+		users:     users,
+		comments:  comments,
+		favorites: favorites,
+		ownership: ownership,
+		usage:     usage,
+		retention: retention,
 	}
 }
 
@@ -50,11 +73,52 @@ func (s *httpEntityStore) RegisterHTTPRoutes(route routing.RouteRegister) {
 	route.Delete("/store/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doDeleteEntity))
 	route.Get("/raw/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doGetRawEntity))
 	route.Get("/history/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doGetHistory))
-	route.Get("/list/:uid", reqGrafanaAdmin, routing.Wrap(s.doListFolder)) // Simplified version of search -- path is prefix
+	route.Post("/restore/:kind/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.doRestore)) // point in time restore from history
+	route.Get("/list/:uid", reqGrafanaAdmin, routing.Wrap(s.doListFolder))               // Simplified version of search -- path is prefix
 	route.Get("/search", reqGrafanaAdmin, routing.Wrap(s.doSearch))
+	route.Get("/export", reqGrafanaAdmin, routing.Wrap(s.doExport)) // search results as CSV or XLSX
 
 	// File upload
 	route.Post("/upload", reqGrafanaAdmin, routing.Wrap(s.doUpload))
+	route.Post("/unpack/:uid", reqGrafanaAdmin, routing.Wrap(s.doUnpackBundle))
+
+	// Access grants, checked on entity read, write and delete
+	route.Get("/access/kind/:kind", middleware.ReqOrgAdmin, routing.Wrap(s.doGetKindAccess))
+	route.Put("/access/kind/:kind", middleware.ReqOrgAdmin, routing.Wrap(s.doSetKindAccess))
+	route.Get("/access/store/:kind/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.doGetEntityAccess))
+	route.Put("/access/store/:kind/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.doSetEntityAccess))
+	route.Get("/access/explain/:kind/:uid", middleware.ReqOrgAdmin, routing.Wrap(s.doExplainAccess))
+
+	// Comment threads
+	route.Get("/comments/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doListComments))
+	route.Post("/comments/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doAddComment))
+	route.Delete("/comments/:kind/:uid/:id", reqGrafanaAdmin, routing.Wrap(s.doDeleteComment))
+	route.Post("/comments/:kind/:uid/:id/resolve", reqGrafanaAdmin, routing.Wrap(s.doResolveComment))
+
+	// Favorites and recently viewed entities of the signed in user
+	route.Get("/favorites", reqGrafanaAdmin, routing.Wrap(s.doListStarred))
+	route.Post("/favorites/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doStar))
+	route.Delete("/favorites/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doUnstar))
+	route.Get("/recent", reqGrafanaAdmin, routing.Wrap(s.doListRecent))
+
+	// Owners and stewards of entities
+	route.Get("/owner/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doGetOwnership))
+	route.Post("/owner/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doSetOwnership))
+	route.Post("/owner/reassign", middleware.ReqOrgAdmin, routing.Wrap(s.doReassignOwnership))
+
+	// Sampled usage of entities, search also supports ?usage, ?unused=<days> and usage sort fields
+	route.Get("/usage/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doGetUsage))
+
+	// Write-once-read-many retention, writes and deletes of locked entities need ?override=<reason>
+	route.Get("/retention", reqGrafanaAdmin, routing.Wrap(s.doListRetentionPolicies))
+	route.Put("/retention", middleware.ReqOrgAdmin, routing.Wrap(s.doSetRetentionPolicy))
+	route.Delete("/retention", middleware.ReqOrgAdmin, routing.Wrap(s.doDeleteRetentionPolicy))
+	route.Get("/retention/audit", middleware.ReqOrgAdmin, routing.Wrap(s.doGetRetentionAudit))
+	route.Get("/retention/:kind/:uid", reqGrafanaAdmin, routing.Wrap(s.doGetRetentionStatus))
+
+	// Background migrations of stored bodies touch every tenant
+	route.Get("/migrate/:kind", middleware.ReqGrafanaAdmin, routing.Wrap(s.doGetKindMigration))
+	route.Post("/migrate/:kind", middleware.ReqGrafanaAdmin, routing.Wrap(s.doStartKindMigration))
 }
 
 // This function will extract UID+Kind from the requested path "*" in our router
@@ -76,16 +140,30 @@ func (s *httpEntityStore) getGRNFromRequest(c *contextmodel.ReqContext) (*grn.GR
 	}, params, nil
 }
 
+// doGetEntity returns an entity as JSON, YAML or raw body bytes depending on the Accept header
 func (s *httpEntityStore) doGetEntity(c *contextmodel.ReqContext) response.Response {
 	grn, params, err := s.getGRNFromRequest(c)
 	if err != nil {
 		return response.Error(400, err.Error(), err)
 	}
+	mime := ""
+	if info, err := s.kinds.GetInfo(grn.ResourceKind); err == nil {
+		mime = info.MimeType
+	}
+	format := negotiateEntityFormat(c.Req.Header.Get("Accept"), mime, entityFormatJSON)
+	if format == "" {
+		return response.Error(http.StatusNotAcceptable, "unsupported accept header", nil)
+	}
+	c.Resp.Header().Set("Vary", "Accept")
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+
 	rsp, err := s.store.Read(c.Req.Context(), &entity.ReadEntityRequest{
 		GRN:         grn,
-		Version:     params["version"],           // ?version = XYZ
-		WithBody:    params["body"] != "false",   // default to true
-		WithSummary: params["summary"] == "true", // default to false
+		Version:     params["version"],                                      // ?version = XYZ
+		WithBody:    params["body"] != "false" || format == entityFormatRaw, // default to true
+		WithSummary: params["summary"] == "true",                            // default to false
 	})
 	if err != nil {
 		return response.Error(500, "error fetching entity", err)
@@ -93,6 +171,10 @@ func (s *httpEntityStore) doGetEntity(c *contextmodel.ReqContext) response.Respo
 	if rsp == nil {
 		return response.Error(404, "not found", nil)
 	}
+	if rsp.GRN != nil {
+		s.recordView(c, grn)
+		s.recordUsage(c, grn, usage.AccessView)
+	}
 
 	// Configure etag support
 	currentEtag := rsp.ETag
@@ -108,14 +190,25 @@ func (s *httpEntityStore) doGetEntity(c *contextmodel.ReqContext) response.Respo
 	}
 
 	c.Resp.Header().Set("ETag", currentEtag)
+	switch format {
+	case entityFormatRaw:
+		return rawBody(rsp, mime)
+	case entityFormatYAML:
+		return entityYAML(rsp)
+	}
 	return response.JSON(200, rsp)
 }
 
+// doGetRawEntity returns the entity body as it was saved, JSON bodies can also be requested as
+// canonical JSON or YAML with the Accept header
 func (s *httpEntityStore) doGetRawEntity(c *contextmodel.ReqContext) response.Response {
 	grn, params, err := s.getGRNFromRequest(c)
 	if err != nil {
 		return response.Error(400, err.Error(), err)
 	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
 	rsp, err := s.store.Read(c.Req.Context(), &entity.ReadEntityRequest{
 		GRN:         grn,
 		Version:     params["version"], // ?version = XYZ
@@ -130,7 +223,15 @@ func (s *httpEntityStore) doGetRawEntity(c *contextmodel.ReqContext) response.Re
 		return response.Error(400, "Unsupported kind", err)
 	}
 
+	format := negotiateEntityFormat(c.Req.Header.Get("Accept"), info.MimeType, entityFormatRaw)
+	if format == "" {
+		return response.Error(http.StatusNotAcceptable, "unsupported accept header", nil)
+	}
+	c.Resp.Header().Set("Vary", "Accept")
+
 	if rsp != nil && rsp.Body != nil {
+		s.recordUsage(c, grn, usage.AccessQuery)
+
 		// Configure etag support
 		currentEtag := rsp.ETag
 		previousEtag := c.Req.Header.Get("If-None-Match")
@@ -143,18 +244,15 @@ func (s *httpEntityStore) doGetRawEntity(c *contextmodel.ReqContext) response.Re
 				http.StatusNotModified, // 304
 			)
 		}
-		mime := info.MimeType
-		if mime == "" {
-			mime = "application/json"
+		switch format {
+		case entityFormatJSON:
+			c.Resp.Header().Set("ETag", currentEtag)
+			return canonicalJSON(rsp.Body)
+		case entityFormatYAML:
+			c.Resp.Header().Set("ETag", currentEtag)
+			return bodyYAML(rsp.Body)
 		}
-		return response.CreateNormalResponse(
-			http.Header{
-				"Content-Type": []string{mime},
-				"ETag":         []string{currentEtag},
-			},
-			rsp.Body,
-			200,
-		)
+		return rawBody(rsp, info.MimeType)
 	}
 	return response.JSON(400, rsp) // ???
 }
@@ -174,13 +272,23 @@ func (s *httpEntityStore) doWriteEntity(c *contextmodel.ReqContext) response.Res
 		return response.Error(400, "error reading body", err)
 	}
 
-	rsp, err := s.store.Write(c.Req.Context(), &entity.WriteEntityRequest{
+	if errRsp := s.checkAccess(c, grn, access.VerbWrite); errRsp != nil {
+		return errRsp
+	}
+	ctx, errRsp := retentionContext(c)
+	if errRsp != nil {
+		return errRsp
+	}
+	rsp, err := s.store.Write(ctx, &entity.WriteEntityRequest{
 		GRN:             grn,
 		Body:            b,
 		Folder:          params["folder"],
 		Comment:         params["comment"],
 		PreviousVersion: params["previousVersion"],
 	})
+	if errors.Is(err, retention.ErrLocked) {
+		return retentionError(err)
+	}
 	if err != nil {
 		return response.Error(500, "?", err)
 	}
@@ -192,10 +300,20 @@ func (s *httpEntityStore) doDeleteEntity(c *contextmodel.ReqContext) response.Re
 	if err != nil {
 		return response.Error(400, err.Error(), err)
 	}
-	rsp, err := s.store.Delete(c.Req.Context(), &entity.DeleteEntityRequest{
+	if errRsp := s.checkAccess(c, grn, access.VerbDelete); errRsp != nil {
+		return errRsp
+	}
+	ctx, errRsp := retentionContext(c)
+	if errRsp != nil {
+		return errRsp
+	}
+	rsp, err := s.store.Delete(ctx, &entity.DeleteEntityRequest{
 		GRN:             grn,
 		PreviousVersion: params["previousVersion"],
 	})
+	if errors.Is(err, retention.ErrLocked) {
+		return retentionError(err)
+	}
 	if err != nil {
 		return response.Error(500, "?", err)
 	}
@@ -207,6 +325,9 @@ func (s *httpEntityStore) doGetHistory(c *contextmodel.ReqContext) response.Resp
 	if err != nil {
 		return response.Error(400, err.Error(), err)
 	}
+	if errRsp := s.checkAccess(c, grn, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
 	limit := int64(20) // params
 	rsp, err := s.store.History(c.Req.Context(), &entity.EntityHistoryRequest{
 		GRN:           grn,
@@ -269,6 +390,9 @@ func (s *httpEntityStore) doUpload(c *contextmodel.ReqContext) response.Response
 				ResourceKind:       kind.ID,
 				TenantID:           c.OrgID,
 			}
+			if errRsp := s.checkAccess(c, grn, access.VerbWrite); errRsp != nil {
+				return errRsp
+			}
 
 			if !overwriteExistingFile {
 				result, err := s.store.Read(ctx, &entity.ReadEntityRequest{
@@ -308,7 +432,68 @@ func (s *httpEntityStore) doListFolder(c *contextmodel.ReqContext) response.Resp
 
 func (s *httpEntityStore) doSearch(c *contextmodel.ReqContext) response.Response {
 	vals := c.Req.URL.Query()
+	req, err := searchRequestFromQuery(vals)
+	if err != nil {
+		return response.Error(400, err.Error(), err)
+	}
+
+	usageOpts, err := usageSearchFromQuery(vals, req)
+	if err != nil {
+		return response.Error(400, err.Error(), err)
+	}
 
+	// With ?content=true the query is also searched in bodies of text kinds
+	content := asBoolean("content", vals, false) && strings.TrimSpace(req.Query) != ""
+	withBody, limit := req.WithBody, req.Limit
+	if content {
+		req.WithBody = true
+	}
+	if content || usageOpts.needsCandidates() {
+		req.Limit = contentSearchCandidates
+	}
+
+	rsp, err := s.store.Search(c.Req.Context(), req)
+	if err != nil {
+		return response.Error(500, "?", err)
+	}
+	keep, err := s.personalFilters(c)
+	if err != nil {
+		return response.Error(500, "error reading favorites", err)
+	}
+	owned, err := s.ownershipFilters(c)
+	if err != nil {
+		return response.Error(500, "error reading ownership", err)
+	}
+	rsp.Results = filterSearchResults(rsp.Results, append(keep, owned...)...)
+	if rsp.Results, err = readableResults(rsp.Results, s.allows(c, access.VerbRead)); err != nil {
+		return response.Error(500, "error reading entity access", err)
+	}
+
+	var summaries map[string]usage.Summary
+	if usageOpts.enabled() {
+		if summaries, err = s.usage.Summaries(c.Req.Context(), c.OrgID); err != nil {
+			return response.Error(500, "error reading usage", err)
+		}
+		rsp.Results = usageOpts.apply(rsp.Results, summaries, time.Now())
+	}
+	if content {
+		contentRsp := searchContent(rsp.Results, req.Query, limit, withBody, s.textKindChecker())
+		if usageOpts.withUsage {
+			contentRsp.Usage = resultUsage(contentRsp.Results, summaries)
+		}
+		return response.JSON(200, contentRsp)
+	}
+	if usageOpts.needsCandidates() && limit > 0 && int64(len(rsp.Results)) > limit {
+		rsp.Results = rsp.Results[:limit]
+	}
+	if usageOpts.withUsage {
+		return response.JSON(200, usageSearchResponse{EntitySearchResponse: rsp, Usage: resultUsage(rsp.Results, summaries)})
+	}
+	return response.JSON(200, rsp)
+}
+
+// searchRequestFromQuery reads search filters from URL parameters, labels are passed as ?label=key=value
+func searchRequestFromQuery(vals url.Values) (*entity.EntitySearchRequest, error) {
 	req := &entity.EntitySearchRequest{
 		WithBody:   asBoolean("body", vals, false),
 		WithLabels: asBoolean("labels", vals, true),
@@ -321,16 +506,21 @@ func (s *httpEntityStore) doSearch(c *contextmodel.ReqContext) response.Response
 	if vals.Has("limit") {
 		limit, err := strconv.ParseInt(vals.Get("limit"), 10, 64)
 		if err != nil {
-			return response.Error(400, "bad limit", err)
+			return nil, fmt.Errorf("bad limit")
 		}
 		req.Limit = limit
 	}
-
-	rsp, err := s.store.Search(c.Req.Context(), req)
-	if err != nil {
-		return response.Error(500, "?", err)
+	for _, label := range vals["label"] {
+		k, v, ok := strings.Cut(label, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("bad label: %s", label)
+		}
+		if req.Labels == nil {
+			req.Labels = make(map[string]string)
+		}
+		req.Labels[k] = v
 	}
-	return response.JSON(200, rsp)
+	return req, nil
 }
 
 func asBoolean(key string, vals url.Values, defaultValue bool) bool {
@@ -0,0 +1,137 @@
+package httpentitystore
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/usage"
+)
+
+// usageSearch is how search results are filtered, sorted and annotated by usage
+type usageSearch struct {
+	// with ?usage=true summaries of results are returned
+	withUsage bool
+	// sortField is a usage sort field from ?sort, removed from the store request
+	sortField string
+	sortDesc  bool
+	// with ?unused=<days> only entities without sampled accesses in the last days are kept
+	unusedDays int
+}
+
+// usageSearchFromQuery reads usage options of a search, usage sort fields are removed from the
+// request since the store can't sort by them
+func usageSearchFromQuery(vals url.Values, req *entity.EntitySearchRequest) (*usageSearch, error) {
+	opts := &usageSearch{withUsage: asBoolean("usage", vals, false)}
+	var storeSort []string
+	for _, s := range req.Sort {
+		field, desc, ok := usage.ParseSort(s)
+		if !ok {
+			storeSort = append(storeSort, s)
+			continue
+		}
+		if opts.sortField != "" {
+			return nil, fmt.Errorf("only one usage sort is supported")
+		}
+		opts.sortField, opts.sortDesc = field, desc
+	}
+	req.Sort = storeSort
+	if v := vals.Get("unused"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 || days > usage.RetentionDays {
+			return nil, fmt.Errorf("unused must be a number of days between 1 and %d", usage.RetentionDays)
+		}
+		opts.unusedDays = days
+	}
+	return opts, nil
+}
+
+// needsCandidates is true when results are filtered or sorted by usage, all candidates are
+// read from the store then
+func (o *usageSearch) needsCandidates() bool {
+	return o.sortField != "" || o.unusedDays > 0
+}
+
+func (o *usageSearch) enabled() bool {
+	return o.withUsage || o.needsCandidates()
+}
+
+// apply filters and sorts results by usage summaries, entities without summaries have no usage
+func (o *usageSearch) apply(results []*entity.EntitySearchResult, summaries map[string]usage.Summary, now time.Time) []*entity.EntitySearchResult {
+	summary := func(r *entity.EntitySearchResult) usage.Summary {
+		if r.GRN == nil {
+			return usage.Summary{}
+		}
+		return summaries[r.GRN.ToGRNString()]
+	}
+	if o.unusedDays > 0 {
+		cutoff := now.AddDate(0, 0, -o.unusedDays).UnixMilli()
+		unused := make([]*entity.EntitySearchResult, 0, len(results))
+		for _, r := range results {
+			if summary(r).LastAccessAt < cutoff {
+				unused = append(unused, r)
+			}
+		}
+		results = unused
+	}
+	if o.sortField != "" {
+		sort.SliceStable(results, func(i, j int) bool {
+			if o.sortDesc {
+				return usage.Less(summary(results[j]), summary(results[i]), o.sortField)
+			}
+			return usage.Less(summary(results[i]), summary(results[j]), o.sortField)
+		})
+	}
+	return results
+}
+
+// usageSearchResponse is a search response with usage summaries keyed by GRN
+type usageSearchResponse struct {
+	*entity.EntitySearchResponse
+	Usage map[string]usage.Summary `json:"usage,omitempty"`
+}
+
+// resultUsage returns summaries of results with sampled accesses
+func resultUsage(results []*entity.EntitySearchResult, summaries map[string]usage.Summary) map[string]usage.Summary {
+	found := make(map[string]usage.Summary)
+	for _, r := range results {
+		if r.GRN == nil {
+			continue
+		}
+		if summary, ok := summaries[r.GRN.ToGRNString()]; ok {
+			found[summary.GRN] = summary
+		}
+	}
+	return found
+}
+
+// doGetUsage returns estimated views, queries and the last access of an entity
+func (s *httpEntityStore) doGetUsage(c *contextmodel.ReqContext) response.Response {
+	g, _, err := s.getGRNFromRequest(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errRsp := s.checkAccess(c, g, access.VerbRead); errRsp != nil {
+		return errRsp
+	}
+	summary, err := s.usage.Get(c.Req.Context(), g)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "error reading usage", err)
+	}
+	return response.JSON(http.StatusOK, summary)
+}
+
+// recordUsage counts an access to an entity, failures do not fail the read
+func (s *httpEntityStore) recordUsage(c *contextmodel.ReqContext, g *grn.GRN, access usage.Access) {
+	if err := s.usage.Record(c.Req.Context(), g, access); err != nil {
+		s.log.Warn("error recording entity usage", "grn", g.ToGRNString(), "error", err)
+	}
+}
@@ -0,0 +1,57 @@
+package httpentitystore
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/usage"
+)
+
+func TestUsageSearchFromQuery(t *testing.T) {
+	req := &entity.EntitySearchRequest{Sort: []string{"name", "-views"}}
+	opts, err := usageSearchFromQuery(url.Values{"usage": {"true"}, "unused": {"30"}}, req)
+	require.NoError(t, err)
+	require.Equal(t, &usageSearch{withUsage: true, sortField: usage.SortViews, sortDesc: true, unusedDays: 30}, opts)
+	require.Equal(t, []string{"name"}, req.Sort)
+	require.True(t, opts.needsCandidates())
+
+	_, err = usageSearchFromQuery(url.Values{}, &entity.EntitySearchRequest{Sort: []string{"views", "queries"}})
+	require.Error(t, err)
+	_, err = usageSearchFromQuery(url.Values{"unused": {"1000"}}, &entity.EntitySearchRequest{})
+	require.Error(t, err)
+
+	opts, err = usageSearchFromQuery(url.Values{}, &entity.EntitySearchRequest{})
+	require.NoError(t, err)
+	require.False(t, opts.enabled())
+}
+
+func TestUsageSearchApply(t *testing.T) {
+	now := time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+	result := func(uid string) *entity.EntitySearchResult {
+		return &entity.EntitySearchResult{GRN: &grn.GRN{TenantID: 1, ResourceKind: "geojson", ResourceIdentifier: uid}}
+	}
+	key := func(uid string) string {
+		return result(uid).GRN.ToGRNString()
+	}
+	summaries := map[string]usage.Summary{
+		key("a"): {GRN: key("a"), Views: 30, LastAccessAt: now.Add(-time.Hour).UnixMilli()},
+		key("b"): {GRN: key("b"), Views: 10, LastAccessAt: now.AddDate(0, 0, -40).UnixMilli()},
+	}
+	results := []*entity.EntitySearchResult{result("a"), result("b"), result("c")}
+
+	sorted := (&usageSearch{sortField: usage.SortViews, sortDesc: true}).apply(append([]*entity.EntitySearchResult{}, results...), summaries, now)
+	require.Equal(t, []*entity.EntitySearchResult{results[0], results[1], results[2]}, sorted)
+
+	sorted = (&usageSearch{sortField: usage.SortViews}).apply(append([]*entity.EntitySearchResult{}, results...), summaries, now)
+	require.Equal(t, []*entity.EntitySearchResult{results[2], results[1], results[0]}, sorted)
+
+	unused := (&usageSearch{unusedDays: 30}).apply(results, summaries, now)
+	require.Equal(t, []*entity.EntitySearchResult{results[1], results[2]}, unused)
+
+	require.Equal(t, map[string]usage.Summary{key("b"): summaries[key("b")]}, resultUsage(unused, summaries))
+}
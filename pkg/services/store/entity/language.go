@@ -0,0 +1,113 @@
+package entity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SummaryFieldLanguage is a summary field holding the detected ISO 639-1 language code
+// of text-bearing entities. It is only set when the language could be detected.
+const SummaryFieldLanguage = "language"
+
+// Minimal number of stop words required to detect a language written in latin script.
+const minLatinStopWords = 2
+
+// Stop words used to distinguish languages written in latin script. The lists are
+// intentionally short and only contain frequent words unique enough for a language.
+var latinStopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "for", "with", "is", "are", "this", "that", "from", "by", "on"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "für", "von", "den", "ein", "eine", "auf"},
+	"fr": {"le", "la", "les", "et", "des", "est", "pour", "une", "dans", "du", "avec", "sur", "pas"},
+	"es": {"el", "los", "las", "y", "es", "para", "una", "del", "con", "por", "en", "que", "como"},
+	"it": {"il", "gli", "che", "per", "una", "della", "con", "sono", "non", "nel", "di", "del"},
+	"pt": {"os", "as", "não", "uma", "para", "com", "dos", "das", "em", "que", "do", "da"},
+	"nl": {"de", "het", "een", "en", "van", "voor", "niet", "met", "zijn", "op", "dat"},
+}
+
+// DetectLanguage guesses the language of the texts and returns ISO 639-1 code.
+// Non-latin scripts are detected by their unicode ranges, latin languages by
+// counting stop words. Empty string is returned when the language is unknown.
+func DetectLanguage(texts ...string) string {
+	var (
+		total  int
+		counts = map[*unicode.RangeTable]int{}
+		words  []string
+	)
+	scripts := []*unicode.RangeTable{
+		unicode.Hiragana, unicode.Katakana, unicode.Han, unicode.Hangul,
+		unicode.Cyrillic, unicode.Arabic, unicode.Greek, unicode.Devanagari,
+		unicode.Latin,
+	}
+	for _, text := range texts {
+		for _, r := range text {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			total++
+			for _, script := range scripts {
+				if unicode.Is(script, r) {
+					counts[script]++
+					break
+				}
+			}
+		}
+		words = append(words, strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})...)
+	}
+	if total == 0 {
+		return ""
+	}
+
+	// Kana is mixed with Han in Japanese texts.
+	if counts[unicode.Hiragana]+counts[unicode.Katakana] > 0 {
+		return "ja"
+	}
+	for _, s := range []struct {
+		script *unicode.RangeTable
+		lang   string
+	}{
+		{unicode.Han, "zh"},
+		{unicode.Hangul, "ko"},
+		{unicode.Cyrillic, "ru"},
+		{unicode.Arabic, "ar"},
+		{unicode.Greek, "el"},
+		{unicode.Devanagari, "hi"},
+	} {
+		// Technical names in latin are common in any language, so a third is enough.
+		if counts[s.script]*3 >= total {
+			return s.lang
+		}
+	}
+	if counts[unicode.Latin]*2 < total {
+		return ""
+	}
+	return detectLatinLanguage(words)
+}
+
+func detectLatinLanguage(words []string) string {
+	scores := map[string]int{}
+	for lang, stopWords := range latinStopWords {
+		for _, w := range words {
+			for _, sw := range stopWords {
+				if w == sw {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, bestScore, tie := "", 0, false
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = lang, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie || bestScore < minLatinStopWords {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		texts []string
+		want  string
+	}{
+		{[]string{""}, ""},
+		{[]string{"CPU"}, ""},
+		{[]string{"Overview of the cluster", "Memory and CPU usage of the nodes"}, "en"},
+		{[]string{"Übersicht der Server", "Die Auslastung ist nicht kritisch"}, "de"},
+		{[]string{"Vue d'ensemble des serveurs", "La charge est pour les hôtes"}, "fr"},
+		{[]string{"サーバー監視", "CPU"}, "ja"},
+		{[]string{"服务器监控"}, "zh"},
+		{[]string{"서버 모니터링"}, "ko"},
+		{[]string{"Мониторинг серверов", "CPU"}, "ru"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, DetectLanguage(tt.texts...), tt.texts)
+	}
+}
@@ -180,6 +180,164 @@ func initEntityTables(mg *migrator.Migrator) {
 		},
 	})
 
+	// Access grants of an entity. Kind defaults are copied here when an entity is created
+	tables = append(tables, migrator.Table{
+		Name: "entity_access",
+		Columns: []*migrator.Column{
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "subject", Type: migrator.DB_NVarchar, Length: 190, Nullable: false}, // role:Viewer, user:1, team:2, *
+			{Name: "verb", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "source", Type: migrator.DB_NVarchar, Length: 40, Nullable: false}, // entity | kind
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"grn", "subject", "verb"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"tenant_id"}, Type: migrator.IndexType},
+		},
+	})
+
+	// Default access grants per kind
+	tables = append(tables, migrator.Table{
+		Name: "entity_kind_access",
+		Columns: []*migrator.Column{
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "subject", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "verb", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"tenant_id", "kind", "subject", "verb"}, Type: migrator.UniqueIndex},
+		},
+	})
+
+	// Comment threads of entities, replies reference the thread root with parent_id
+	tables = append(tables, migrator.Table{
+		Name: "entity_comment",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "parent_id", Type: migrator.DB_BigInt, Nullable: false}, // 0 for thread root
+			{Name: "body", Type: migrator.DB_Text, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created_by_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "resolved", Type: migrator.DB_Bool, Nullable: false},
+			{Name: "resolved_by", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "resolved_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"grn", "created_at"}},
+			{Cols: []string{"tenant_id"}},
+		},
+	})
+
+	// Entities starred by users
+	tables = append(tables, migrator.Table{
+		Name: "entity_favorite",
+		Columns: []*migrator.Column{
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"user_id", "grn"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"user_id", "tenant_id", "created_at"}, Type: migrator.IndexType},
+			{Cols: []string{"grn"}, Type: migrator.IndexType},
+		},
+	})
+
+	// Entities recently viewed by users, trimmed to the last views per user
+	tables = append(tables, migrator.Table{
+		Name: "entity_recent",
+		Columns: []*migrator.Column{
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "user_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "viewed_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"user_id", "grn"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"user_id", "tenant_id", "viewed_at"}, Type: migrator.IndexType},
+			{Cols: []string{"grn"}, Type: migrator.IndexType},
+		},
+	})
+
+	// Explicit owners and stewards of entities, the creator owns entities without a row
+	tables = append(tables, migrator.Table{
+		Name: "entity_owner",
+		Columns: []*migrator.Column{
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false, IsPrimaryKey: true},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "owner", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "steward", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "updated_by", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "updated_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"tenant_id", "owner"}, Type: migrator.IndexType},
+			{Cols: []string{"tenant_id", "steward"}, Type: migrator.IndexType},
+		},
+	})
+
+	// Sampled usage of entities aggregated by day
+	tables = append(tables, migrator.Table{
+		Name: "entity_usage",
+		Columns: []*migrator.Column{
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "day", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "views", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "queries", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "last_access_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"grn", "day"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"tenant_id", "day"}, Type: migrator.IndexType},
+		},
+	})
+
+	// Write-once-read-many retention of entities by folder and kind, empty matches all
+	tables = append(tables, migrator.Table{
+		Name: "entity_retention_policy",
+		Columns: []*migrator.Column{
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "folder", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "retention_days", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "updated_by", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "updated_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"tenant_id", "folder", "kind"}, Type: migrator.UniqueIndex},
+		},
+	})
+
+	// Admin overrides of retention locks, kept when entities are deleted
+	tables = append(tables, migrator.Table{
+		Name: "entity_retention_audit",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, Nullable: false, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "tenant_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "grn", Type: migrator.DB_NVarchar, Length: grnLength, Nullable: false}, // empty for policy changes
+			{Name: "action", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "reason", Type: migrator.DB_Text, Nullable: false},
+			{Name: "actor", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"tenant_id", "created_at"}, Type: migrator.IndexType},
+		},
+	})
+
 	// Initialize all tables
 	for t := range tables {
 		mg.AddMigration("drop table "+tables[t].Name, migrator.NewDropTableMigration(tables[t].Name))
@@ -189,6 +347,13 @@ func initEntityTables(mg *migrator.Migrator) {
 		}
 	}
 
+	// Schema version of the stored body, empty for kinds without versions
+	for _, name := range []string{"entity", "entity_history"} {
+		mg.AddMigration("add kind_version column to "+name, migrator.NewAddColumnMigration(migrator.Table{Name: name}, &migrator.Column{
+			Name: "kind_version", Type: migrator.DB_NVarchar, Length: 64, Nullable: false, Default: "''",
+		}))
+	}
+
 	mg.AddMigration("set path collation on entity table", migrator.NewRawSQLMigration("").
 		// MySQL `utf8mb4_unicode_ci` collation is set in `mysql_dialect.go`
 		// SQLite uses a `BINARY` collation by default
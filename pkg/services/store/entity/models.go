@@ -32,6 +32,9 @@ const (
 	// StandardKindGeoJSON represents spatial data
 	StandardKindGeoJSON = "geojson"
 
+	// StandardKindZip ZIP bundle with mixed assets
+	StandardKindZip = "zip"
+
 	// StandardKindDataFrame data frame
 	StandardKindDataFrame = "frame"
 
@@ -84,6 +87,13 @@ type EntityKindInfo struct {
 
 	// The correct mime-type to return for raw objects
 	MimeType string `json:"mimeType,omitempty"`
+
+	// API group of the kind (for e.g. dashboard.grafana.app)
+	Group string `json:"group,omitempty"`
+
+	// Schema version bodies are stored in, bodies written or read in other
+	// versions are converted with the kind version converter
+	Version string `json:"version,omitempty"`
 }
 
 // EntitySummary represents common data derived from a raw object bytes.
@@ -142,3 +152,30 @@ type EntityExternalReference struct {
 // EntitySummaryBuilder will read an object, validate it, and return a summary, sanitized payload, or an error
 // This should not include values that depend on system state, only the raw object
 type EntitySummaryBuilder = func(ctx context.Context, uid string, body []byte) (*EntitySummary, []byte, error)
+
+// EntityVersionConverter converts an object body between schema versions of a kind.
+// Bodies stored before the kind was versioned have an empty fromVersion
+type EntityVersionConverter = func(ctx context.Context, uid string, body []byte, fromVersion string, toVersion string) ([]byte, error)
+
+// EntityMigration upgrades an object body from one version of a kind to the next one
+type EntityMigration = func(ctx context.Context, uid string, body []byte) ([]byte, error)
+
+// KindMigrationStatus is the progress of a background migration of stored bodies to the current kind version
+type KindMigrationStatus struct {
+	Kind       string `json:"kind"`
+	Version    string `json:"version"`
+	Running    bool   `json:"running"`
+	Total      int64  `json:"total"`
+	Migrated   int64  `json:"migrated"`
+	Failed     int64  `json:"failed"`
+	LastError  string `json:"lastError,omitempty"`
+	StartedAt  int64  `json:"startedAt"`
+	FinishedAt int64  `json:"finishedAt,omitempty"`
+}
+
+// KindMigrator rewrites stored bodies of a kind in its current version, so old versions
+// do not need to be converted on every read
+type KindMigrator interface {
+	StartKindMigration(ctx context.Context, kind string) (*KindMigrationStatus, error)
+	GetKindMigrationStatus(kind string) *KindMigrationStatus
+}
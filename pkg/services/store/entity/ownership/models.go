@@ -0,0 +1,69 @@
+package ownership
+
+//-----------------------------------------------------------------------------------------------------
+// NOTE: entity ownership is experimental, like the rest of the object store
+//-----------------------------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrEntityNotFound = errors.New("entity not found")
+	ErrEmptyOwner     = errors.New("owner is required")
+	ErrSameOwner      = errors.New("from and to owners are the same")
+)
+
+// Ownership is an owner accountable for an entity and an optional steward taking care
+// of it. Identities are strings like store.GetUserIDString returns, entities without
+// explicit ownership are owned by their creator.
+type Ownership struct {
+	GRN       string `json:"grn" db:"grn"`
+	Owner     string `json:"owner" db:"owner"`
+	Steward   string `json:"steward,omitempty" db:"steward"`
+	CreatedBy string `json:"createdBy" db:"created_by"`
+	UpdatedBy string `json:"updatedBy,omitempty" db:"updated_by"`
+	UpdatedAt int64  `json:"updatedAt,omitempty" db:"updated_at"`
+}
+
+// SetOwnershipCmd changes the owner and steward of an entity.
+type SetOwnershipCmd struct {
+	Owner   string `json:"owner"`
+	Steward string `json:"steward,omitempty"`
+}
+
+func (cmd *SetOwnershipCmd) Validate() error {
+	cmd.Owner = strings.TrimSpace(cmd.Owner)
+	cmd.Steward = strings.TrimSpace(cmd.Steward)
+	if cmd.Owner == "" {
+		return ErrEmptyOwner
+	}
+	return nil
+}
+
+// ReassignCmd moves ownership and stewardship of all entities of a tenant, optionally
+// of some kinds only, from one identity to another, e.g. when someone leaves.
+type ReassignCmd struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+func (cmd *ReassignCmd) Validate() error {
+	cmd.From = strings.TrimSpace(cmd.From)
+	cmd.To = strings.TrimSpace(cmd.To)
+	if cmd.From == "" || cmd.To == "" {
+		return ErrEmptyOwner
+	}
+	if cmd.From == cmd.To {
+		return ErrSameOwner
+	}
+	return nil
+}
+
+// ReassignResult counts entities changed by a reassignment.
+type ReassignResult struct {
+	Owned     int64 `json:"owned"`
+	Stewarded int64 `json:"stewarded"`
+}
@@ -0,0 +1,38 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOwnershipCmd_Validate(t *testing.T) {
+	cmd := SetOwnershipCmd{Owner: " user:1:admin ", Steward: " team:2 "}
+	require.NoError(t, cmd.Validate())
+	require.Equal(t, "user:1:admin", cmd.Owner)
+	require.Equal(t, "team:2", cmd.Steward)
+
+	cmd = SetOwnershipCmd{Steward: "team:2"}
+	require.ErrorIs(t, cmd.Validate(), ErrEmptyOwner)
+}
+
+func TestReassignCmd_Validate(t *testing.T) {
+	cmd := ReassignCmd{From: "user:1:admin", To: "user:2:editor"}
+	require.NoError(t, cmd.Validate())
+
+	cmd = ReassignCmd{From: "user:1:admin"}
+	require.ErrorIs(t, cmd.Validate(), ErrEmptyOwner)
+
+	cmd = ReassignCmd{From: "user:1:admin", To: " user:1:admin"}
+	require.ErrorIs(t, cmd.Validate(), ErrSameOwner)
+}
+
+func TestKindsCondition(t *testing.T) {
+	filter, args := kindsCondition("kind", nil)
+	require.Empty(t, filter)
+	require.Empty(t, args)
+
+	filter, args = kindsCondition("kind", []string{"dashboard", "folder"})
+	require.Equal(t, " AND kind IN (?,?)", filter)
+	require.Equal(t, []any{"dashboard", "folder"}, args)
+}
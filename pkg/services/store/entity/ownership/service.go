@@ -0,0 +1,143 @@
+package ownership
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+)
+
+// ownershipSelect reads the effective ownership of entities, the creator owns entities
+// without an entity_owner row
+const ownershipSelect = "SELECT e.grn, COALESCE(o.owner, e.created_by) AS owner, COALESCE(o.steward, '') AS steward, " +
+	"e.created_by, COALESCE(o.updated_by, '') AS updated_by, COALESCE(o.updated_at, 0) AS updated_at " +
+	"FROM entity e LEFT JOIN entity_owner o ON o.grn=e.grn "
+
+// Service keeps owners and stewards of entities next to the entity tables.
+type Service struct {
+	sess *session.SessionDB
+}
+
+func ProvideService(db db.DB) *Service {
+	return &Service{sess: db.GetSqlxSession()}
+}
+
+// Get returns ownership of an entity.
+func (s *Service) Get(ctx context.Context, g *grn.GRN) (*Ownership, error) {
+	var rows []Ownership
+	if err := s.sess.Select(ctx, &rows, ownershipSelect+"WHERE e.grn=?", g.ToGRNString()); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrEntityNotFound
+	}
+	return &rows[0], nil
+}
+
+// Set changes ownership of an entity.
+func (s *Service) Set(ctx context.Context, g *grn.GRN, cmd SetOwnershipCmd, by string) (*Ownership, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	current, err := s.Get(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	err = s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_owner WHERE grn=?", current.GRN); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO entity_owner (grn, tenant_id, kind, owner, steward, updated_by, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			current.GRN, g.TenantID, g.ResourceKind, cmd.Owner, cmd.Steward, by, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	current.Owner, current.Steward, current.UpdatedBy, current.UpdatedAt = cmd.Owner, cmd.Steward, by, now
+	return current, nil
+}
+
+// Reassign moves ownership and stewardship of entities of a tenant in bulk.
+func (s *Service) Reassign(ctx context.Context, tenantID int64, cmd ReassignCmd, by string) (*ReassignResult, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	kindFilter, kindArgs := kindsCondition("kind", cmd.Kinds)
+	now := time.Now().UnixMilli()
+	result := &ReassignResult{}
+	err := s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		// Entities owned by their creator get an explicit owner first
+		args := append([]any{tenantID, cmd.From}, kindArgs...)
+		_, err := tx.Exec(ctx, "INSERT INTO entity_owner (grn, tenant_id, kind, owner, steward, updated_by, updated_at) "+
+			"SELECT grn, tenant_id, kind, created_by, '', '', 0 FROM entity "+
+			"WHERE tenant_id=? AND created_by=?"+kindFilter+" AND grn NOT IN (SELECT grn FROM entity_owner)", args...)
+		if err != nil {
+			return err
+		}
+
+		args = append([]any{cmd.To, by, now, tenantID, cmd.From}, kindArgs...)
+		res, err := tx.Exec(ctx, "UPDATE entity_owner SET owner=?, updated_by=?, updated_at=? WHERE tenant_id=? AND owner=?"+kindFilter, args...)
+		if err != nil {
+			return err
+		}
+		if result.Owned, err = res.RowsAffected(); err != nil {
+			return err
+		}
+
+		res, err = tx.Exec(ctx, "UPDATE entity_owner SET steward=?, updated_by=?, updated_at=? WHERE tenant_id=? AND steward=?"+kindFilter, args...)
+		if err != nil {
+			return err
+		}
+		result.Stewarded, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OwnedGRNs returns GRN strings of entities of a tenant owned by an identity.
+func (s *Service) OwnedGRNs(ctx context.Context, tenantID int64, owner string) (map[string]bool, error) {
+	var rows []string
+	err := s.sess.Select(ctx, &rows, "SELECT e.grn FROM entity e LEFT JOIN entity_owner o ON o.grn=e.grn "+
+		"WHERE e.tenant_id=? AND COALESCE(o.owner, e.created_by)=?", tenantID, owner)
+	if err != nil {
+		return nil, err
+	}
+	return toSet(rows), nil
+}
+
+// StewardedGRNs returns GRN strings of entities of a tenant stewarded by an identity.
+func (s *Service) StewardedGRNs(ctx context.Context, tenantID int64, steward string) (map[string]bool, error) {
+	var rows []string
+	err := s.sess.Select(ctx, &rows, "SELECT grn FROM entity_owner WHERE tenant_id=? AND steward=?", tenantID, steward)
+	if err != nil {
+		return nil, err
+	}
+	return toSet(rows), nil
+}
+
+func kindsCondition(column string, kinds []string) (string, []any) {
+	if len(kinds) == 0 {
+		return "", nil
+	}
+	args := make([]any, 0, len(kinds))
+	for _, k := range kinds {
+		args = append(args, k)
+	}
+	return " AND " + column + " IN (?" + strings.Repeat(",?", len(kinds)-1) + ")", args
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,40 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPolicyCmd_Validate(t *testing.T) {
+	cmd := SetPolicyCmd{Folder: " reports ", Kind: " dashboard ", RetentionDays: 30}
+	require.NoError(t, cmd.Validate())
+	require.Equal(t, "reports", cmd.Folder)
+	require.Equal(t, "dashboard", cmd.Kind)
+
+	cmd = SetPolicyCmd{Folder: "reports"}
+	require.ErrorIs(t, cmd.Validate(), ErrInvalidDays)
+}
+
+func TestLockedUntil(t *testing.T) {
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.True(t, time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC).Equal(LockedUntil(created.UnixMilli(), 30)))
+}
+
+func TestOverride(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, OverrideFrom(ctx))
+	require.Nil(t, OverrideFrom(WithOverride(ctx, " ", "user:1:admin")))
+
+	o := OverrideFrom(WithOverride(ctx, " legal hold lifted ", "user:1:admin"))
+	require.NotNil(t, o)
+	require.Equal(t, "legal hold lifted", o.Reason)
+	require.Equal(t, "user:1:admin", o.Actor)
+}
+
+func TestPolicyName(t *testing.T) {
+	require.Equal(t, "folder=* kind=*", policyName("", ""))
+	require.Equal(t, "folder=reports kind=report", policyName("reports", "report"))
+}
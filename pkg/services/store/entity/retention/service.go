@@ -0,0 +1,186 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+)
+
+// querier is implemented by both sessions and transactions
+type querier interface {
+	Get(ctx context.Context, dest any, query string, args ...any) error
+	Exec(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Service keeps retention policies and the audit log of overrides next to the entity tables.
+type Service struct {
+	sess *session.SessionDB
+}
+
+func ProvideService(db db.DB) *Service {
+	return &Service{sess: db.GetSqlxSession()}
+}
+
+// List returns retention policies of a tenant.
+func (s *Service) List(ctx context.Context, tenantID int64) ([]Policy, error) {
+	rows := []Policy{}
+	err := s.sess.Select(ctx, &rows, "SELECT tenant_id, folder, kind, retention_days, updated_by, updated_at "+
+		"FROM entity_retention_policy WHERE tenant_id=? ORDER BY folder, kind", tenantID)
+	return rows, err
+}
+
+// Set creates or changes a retention policy, shortening an existing one needs an override
+// in the context.
+func (s *Service) Set(ctx context.Context, tenantID int64, cmd SetPolicyCmd, by string) (*Policy, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+	policy := &Policy{
+		TenantID:      tenantID,
+		Folder:        cmd.Folder,
+		Kind:          cmd.Kind,
+		RetentionDays: cmd.RetentionDays,
+		UpdatedBy:     by,
+		UpdatedAt:     time.Now().UnixMilli(),
+	}
+	err := s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		current, err := getPolicy(ctx, tx, tenantID, cmd.Folder, cmd.Kind)
+		if err != nil && !errors.Is(err, ErrPolicyNotFound) {
+			return err
+		}
+		if current != nil && current.RetentionDays > cmd.RetentionDays {
+			if err := audit(ctx, tx, tenantID, "", ActionSetPolicy, policyName(cmd.Folder, cmd.Kind)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, "DELETE FROM entity_retention_policy WHERE tenant_id=? AND folder=? AND kind=?",
+			tenantID, cmd.Folder, cmd.Kind); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO entity_retention_policy (tenant_id, folder, kind, retention_days, updated_by, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+			policy.TenantID, policy.Folder, policy.Kind, policy.RetentionDays, policy.UpdatedBy, policy.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Delete removes a retention policy, this always needs an override in the context.
+func (s *Service) Delete(ctx context.Context, tenantID int64, folder string, kind string) error {
+	return s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if _, err := getPolicy(ctx, tx, tenantID, folder, kind); err != nil {
+			return err
+		}
+		if err := audit(ctx, tx, tenantID, "", ActionDeletePolicy, policyName(folder, kind)); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM entity_retention_policy WHERE tenant_id=? AND folder=? AND kind=?", tenantID, folder, kind)
+		return err
+	})
+}
+
+// Status returns the retention lock of an entity.
+func (s *Service) Status(ctx context.Context, g *grn.GRN) (*Lock, error) {
+	until, err := lockedUntil(ctx, s.sess, g)
+	if err != nil {
+		return nil, err
+	}
+	lock := &Lock{GRN: g.ToGRNString()}
+	if until.After(time.Now()) {
+		lock.Locked = true
+		lock.LockedUntil = until.UnixMilli()
+	}
+	return lock, nil
+}
+
+// Audit returns the latest overrides of a tenant.
+func (s *Service) Audit(ctx context.Context, tenantID int64, limit int) ([]AuditEntry, error) {
+	if limit < 1 {
+		limit = 100
+	}
+	rows := []AuditEntry{}
+	err := s.sess.Select(ctx, &rows, "SELECT grn, action, reason, actor, created_at FROM entity_retention_audit "+
+		"WHERE tenant_id=? ORDER BY created_at DESC, id DESC LIMIT ?", tenantID, limit)
+	return rows, err
+}
+
+// Check fails with ErrLocked when an existing entity is under retention, unless the context
+// carries an override which is then audited in the same transaction. The storage layer calls
+// it before changing or deleting an entity.
+func Check(ctx context.Context, tx *session.SessionTx, g *grn.GRN, action string) error {
+	until, err := lockedUntil(ctx, tx, g)
+	if err != nil || !until.After(time.Now()) {
+		return err
+	}
+	if OverrideFrom(ctx) == nil {
+		return fmt.Errorf("%w until %s", ErrLocked, until.UTC().Format(time.RFC3339))
+	}
+	return audit(ctx, tx, g.TenantID, g.ToGRNString(), action, "")
+}
+
+// lockedUntil returns the end of retention of an entity, zero when it does not exist or
+// no policy matches
+func lockedUntil(ctx context.Context, q querier, g *grn.GRN) (time.Time, error) {
+	info := struct {
+		Folder    string `db:"folder"`
+		CreatedAt int64  `db:"created_at"`
+	}{}
+	err := q.Get(ctx, &info, "SELECT folder, created_at FROM entity WHERE grn=?", g.ToGRNString())
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	var days int64
+	err = q.Get(ctx, &days, "SELECT COALESCE(MAX(retention_days), 0) FROM entity_retention_policy "+
+		"WHERE tenant_id=? AND (folder='' OR folder=?) AND (kind='' OR kind=?)", g.TenantID, info.Folder, g.ResourceKind)
+	if err != nil || days < 1 {
+		return time.Time{}, err
+	}
+	return LockedUntil(info.CreatedAt, days), nil
+}
+
+func getPolicy(ctx context.Context, q querier, tenantID int64, folder string, kind string) (*Policy, error) {
+	policy := &Policy{}
+	err := q.Get(ctx, policy, "SELECT tenant_id, folder, kind, retention_days, updated_by, updated_at "+
+		"FROM entity_retention_policy WHERE tenant_id=? AND folder=? AND kind=?", tenantID, folder, kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPolicyNotFound
+	}
+	return policy, err
+}
+
+// audit records an override of the context, failing when there is none
+func audit(ctx context.Context, q querier, tenantID int64, grn string, action string, detail string) error {
+	o := OverrideFrom(ctx)
+	if o == nil {
+		return ErrOverrideRequired
+	}
+	reason := o.Reason
+	if detail != "" {
+		reason = detail + ": " + reason
+	}
+	_, err := q.Exec(ctx, "INSERT INTO entity_retention_audit (tenant_id, grn, action, reason, actor, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		tenantID, grn, action, reason, o.Actor, time.Now().UnixMilli())
+	return err
+}
+
+// policyName describes a policy in audit entries
+func policyName(folder string, kind string) string {
+	if folder == "" {
+		folder = "*"
+	}
+	if kind == "" {
+		kind = "*"
+	}
+	return fmt.Sprintf("folder=%s kind=%s", folder, kind)
+}
@@ -0,0 +1,165 @@
+package sqlstash
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+var _ entity.KindMigrator = &sqlEntityServer{}
+
+// kindMigrationBatchSize is a number of entities read and rewritten at once
+const kindMigrationBatchSize = 100
+
+type kindMigrations struct {
+	mutex  sync.Mutex
+	status map[string]*entity.KindMigrationStatus
+}
+
+// StartKindMigration rewrites stored bodies of a kind (in every tenant) in its current version.
+// Entities are converted with registered migrations in the background, the summary is kept
+// as it describes the same object. Entities that fail to convert are skipped and counted
+func (s *sqlEntityServer) StartKindMigration(ctx context.Context, kind string) (*entity.KindMigrationStatus, error) {
+	info, err := s.kinds.GetInfo(kind)
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind %q", kind)
+	}
+	if info.Version == "" {
+		return nil, fmt.Errorf("kind %q is not versioned", kind)
+	}
+
+	s.migrations.mutex.Lock()
+	defer s.migrations.mutex.Unlock()
+	if s.migrations.status == nil {
+		s.migrations.status = make(map[string]*entity.KindMigrationStatus)
+	}
+	if current, ok := s.migrations.status[kind]; ok && current.Running {
+		return nil, fmt.Errorf("migration of kind %q is already running", kind)
+	}
+
+	status := &entity.KindMigrationStatus{
+		Kind:      kind,
+		Version:   info.Version,
+		Running:   true,
+		StartedAt: time.Now().UnixMilli(),
+	}
+	rows, err := s.sess.Query(ctx, "SELECT COUNT(*) FROM entity WHERE kind=? AND kind_version<>? AND body IS NOT NULL", kind, info.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	if rows.Next() {
+		if err := rows.Scan(&status.Total); err != nil {
+			return nil, err
+		}
+	}
+	s.migrations.status[kind] = status
+
+	go s.runKindMigration(context.Background(), status)
+	cpy := *status
+	return &cpy, nil
+}
+
+// GetKindMigrationStatus returns the progress of the last migration of a kind, or nil if it never ran
+func (s *sqlEntityServer) GetKindMigrationStatus(kind string) *entity.KindMigrationStatus {
+	s.migrations.mutex.Lock()
+	defer s.migrations.mutex.Unlock()
+	status, ok := s.migrations.status[kind]
+	if !ok {
+		return nil
+	}
+	cpy := *status
+	return &cpy
+}
+
+type kindMigrationRow struct {
+	grn     string
+	uid     string
+	body    []byte
+	version string
+}
+
+func (s *sqlEntityServer) runKindMigration(ctx context.Context, status *entity.KindMigrationStatus) {
+	logger := s.log.New("kind", status.Kind, "version", status.Version)
+	logger.Info("Starting kind migration", "total", status.Total)
+
+	// entities are paged by GRN, so the ones failing to convert are not read again
+	after := ""
+	for {
+		batch, err := s.readKindMigrationBatch(ctx, status.Kind, status.Version, after)
+		if err != nil {
+			s.updateKindMigration(status, func() {
+				status.LastError = err.Error()
+			})
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = batch[len(batch)-1].grn
+
+		for _, row := range batch {
+			err := s.migrateEntity(ctx, status.Kind, status.Version, row)
+			s.updateKindMigration(status, func() {
+				if err != nil {
+					status.Failed++
+					status.LastError = err.Error()
+				} else {
+					status.Migrated++
+				}
+			})
+			if err != nil {
+				logger.Warn("Error migrating entity", "grn", row.grn, "error", err)
+			}
+		}
+	}
+
+	var migrated, failed int64
+	s.updateKindMigration(status, func() {
+		status.Running = false
+		status.FinishedAt = time.Now().UnixMilli()
+		migrated, failed = status.Migrated, status.Failed
+	})
+	logger.Info("Finished kind migration", "migrated", migrated, "failed", failed)
+}
+
+func (s *sqlEntityServer) updateKindMigration(status *entity.KindMigrationStatus, update func()) {
+	s.migrations.mutex.Lock()
+	defer s.migrations.mutex.Unlock()
+	update()
+}
+
+func (s *sqlEntityServer) readKindMigrationBatch(ctx context.Context, kind string, version string, after string) ([]kindMigrationRow, error) {
+	rows, err := s.sess.Query(ctx, "SELECT grn, uid, body, kind_version FROM entity "+
+		"WHERE kind=? AND kind_version<>? AND body IS NOT NULL AND grn>? "+
+		"ORDER BY grn ASC LIMIT ?", kind, version, after, kindMigrationBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	batch := make([]kindMigrationRow, 0, kindMigrationBatchSize)
+	for rows.Next() {
+		row := kindMigrationRow{}
+		if err := rows.Scan(&row.grn, &row.uid, &row.body, &row.version); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
+
+// migrateEntity rewrites the current body of an entity, history keeps the versions it was written in.
+// The update is skipped when the entity changed since it was read
+func (s *sqlEntityServer) migrateEntity(ctx context.Context, kind string, version string, row kindMigrationRow) error {
+	body, err := s.kinds.ConvertVersion(ctx, kind, row.uid, row.body, row.version, version)
+	if err != nil {
+		return err
+	}
+	_, err = s.sess.Exec(ctx, "UPDATE entity SET body=?, size=?, etag=?, kind_version=? WHERE grn=? AND kind_version=?",
+		body, len(body), createContentsHash(body), version, row.grn, row.version)
+	return err
+}
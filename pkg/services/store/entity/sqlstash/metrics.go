@@ -0,0 +1,176 @@
+package sqlstash
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+const (
+	opRead      = "read"
+	opBatchRead = "batch_read"
+	opWrite     = "write"
+	opDelete    = "delete"
+	opHistory   = "history"
+	opSearch    = "search"
+)
+
+const (
+	// kindMixed is used for operations touching multiple kinds.
+	kindMixed = "mixed"
+	// kindOther is used for kinds not known to the registry, keeps label cardinality bounded.
+	kindOther = "other"
+)
+
+const (
+	errorTypeCanceled = "canceled"
+	errorTypeTimeout  = "timeout"
+	errorTypeConflict = "conflict"
+	errorTypeOther    = "other"
+)
+
+var (
+	opsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "entity_store",
+			Name:      "operations_total",
+			Help:      "A counter for entity store operations",
+		},
+		[]string{"operation", "kind"},
+	)
+	opErrorsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "entity_store",
+			Name:      "operation_errors_total",
+			Help:      "A counter for failed entity store operations",
+		},
+		[]string{"operation", "kind", "error_type"},
+	)
+	opDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "entity_store",
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of entity store operations",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"operation", "kind"},
+	)
+	bodySize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "entity_store",
+			Name:      "body_size_bytes",
+			Help:      "Size of entity bodies read or written",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 8),
+		},
+		[]string{"operation", "kind"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		opsCounter,
+		opErrorsCounter,
+		opDuration,
+		bodySize,
+	)
+}
+
+type opObserver struct {
+	s     *sqlEntityServer
+	ctx   context.Context
+	op    string
+	kind  string
+	grns  []*grn.GRN
+	start time.Time
+}
+
+// observe starts measuring an operation, call done with the size of bodies and the operation
+// error when the operation completes.
+func (s *sqlEntityServer) observe(ctx context.Context, op string, kind string, grns ...*grn.GRN) *opObserver {
+	return &opObserver{
+		s:     s,
+		ctx:   ctx,
+		op:    op,
+		kind:  s.kindLabel(kind),
+		grns:  grns,
+		start: time.Now(),
+	}
+}
+
+func (o *opObserver) done(size int, err error) {
+	elapsed := time.Since(o.start)
+	opsCounter.WithLabelValues(o.op, o.kind).Inc()
+	opDuration.WithLabelValues(o.op, o.kind).Observe(elapsed.Seconds())
+	if size > 0 {
+		bodySize.WithLabelValues(o.op, o.kind).Observe(float64(size))
+	}
+	if err != nil {
+		opErrorsCounter.WithLabelValues(o.op, o.kind, errorType(err)).Inc()
+	}
+
+	if o.s.slowThreshold > 0 && elapsed >= o.s.slowThreshold {
+		logger := o.s.log.FromContext(o.ctx)
+		args := []any{"operation", o.op, "kind", o.kind, "duration", elapsed, "size", size}
+		if len(o.grns) > 0 {
+			ids := make([]string, 0, len(o.grns))
+			for _, g := range o.grns {
+				if g != nil {
+					ids = append(ids, g.ToGRNString())
+				}
+			}
+			args = append(args, "grn", strings.Join(ids, ","))
+		}
+		if err != nil {
+			args = append(args, "error", err)
+		}
+		logger.Warn("Slow entity store operation", args...)
+	}
+}
+
+func (s *sqlEntityServer) kindLabel(kind string) string {
+	if kind == "" || kind == kindMixed {
+		return kind
+	}
+	if _, err := s.kinds.GetInfo(kind); err != nil {
+		return kindOther
+	}
+	return kind
+}
+
+// kindOf returns a kind of GRNs or kindMixed if they are of different kinds.
+func kindOf(grns ...*grn.GRN) string {
+	kind := ""
+	for _, g := range grns {
+		if g == nil {
+			continue
+		}
+		if kind != "" && kind != g.ResourceKind {
+			return kindMixed
+		}
+		kind = g.ResourceKind
+	}
+	return kind
+}
+
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errorTypeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorTypeTimeout
+	case strings.Contains(err.Error(), "optimistic lock"):
+		return errorTypeConflict
+	default:
+		return errorTypeOther
+	}
+}
@@ -0,0 +1,91 @@
+package sqlstash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/kind"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// testLogger keeps logs of loggers returned from FromContext.
+type testLogger struct {
+	*logtest.Fake
+}
+
+func (l testLogger) FromContext(_ context.Context) log.Logger {
+	return l
+}
+
+func histogramCount(t *testing.T, h *prometheus.HistogramVec, op string, kind string) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	require.NoError(t, h.WithLabelValues(op, kind).(prometheus.Histogram).Write(m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestObserve(t *testing.T) {
+	logger := testLogger{&logtest.Fake{}}
+	s := &sqlEntityServer{log: logger, kinds: kind.NewKindRegistry(), slowThreshold: time.Hour}
+	g := &grn.GRN{TenantID: 1, ResourceKind: entity.StandardKindDashboard, ResourceIdentifier: "a"}
+
+	ops := testutil.ToFloat64(opsCounter.WithLabelValues(opRead, entity.StandardKindDashboard))
+	durations := histogramCount(t, opDuration, opRead, entity.StandardKindDashboard)
+	sizes := histogramCount(t, bodySize, opRead, entity.StandardKindDashboard)
+	s.observe(context.Background(), opRead, g.ResourceKind, g).done(100, nil)
+	require.Equal(t, ops+1, testutil.ToFloat64(opsCounter.WithLabelValues(opRead, entity.StandardKindDashboard)))
+	require.Equal(t, durations+1, histogramCount(t, opDuration, opRead, entity.StandardKindDashboard))
+	require.Equal(t, sizes+1, histogramCount(t, bodySize, opRead, entity.StandardKindDashboard))
+	require.Zero(t, logger.WarnLogs.Calls)
+
+	// Unknown kinds share a label, errors are counted by type.
+	errs := testutil.ToFloat64(opErrorsCounter.WithLabelValues(opWrite, kindOther, errorTypeTimeout))
+	s.observe(context.Background(), opWrite, "unknown").done(0, context.DeadlineExceeded)
+	require.Equal(t, errs+1, testutil.ToFloat64(opErrorsCounter.WithLabelValues(opWrite, kindOther, errorTypeTimeout)))
+}
+
+func TestObserve_slowOperation(t *testing.T) {
+	logger := testLogger{&logtest.Fake{}}
+	s := &sqlEntityServer{log: logger, kinds: kind.NewKindRegistry(), slowThreshold: time.Millisecond}
+	g := &grn.GRN{TenantID: 1, ResourceKind: entity.StandardKindDashboard, ResourceIdentifier: "a"}
+
+	o := s.observe(context.Background(), opWrite, g.ResourceKind, g)
+	o.start = o.start.Add(-time.Second)
+	o.done(10, errors.New("boom"))
+	require.Equal(t, 1, logger.WarnLogs.Calls)
+	require.Equal(t, "Slow entity store operation", logger.WarnLogs.Message)
+	require.Contains(t, logger.WarnLogs.Ctx, "grn:1:dashboard/a")
+	require.Contains(t, logger.WarnLogs.Ctx, opWrite)
+
+	// Operations under the threshold are not logged, zero threshold disables logging.
+	s.observe(context.Background(), opWrite, g.ResourceKind, g).done(10, nil)
+	s.slowThreshold = 0
+	o = s.observe(context.Background(), opWrite, g.ResourceKind, g)
+	o.start = o.start.Add(-time.Second)
+	o.done(10, nil)
+	require.Equal(t, 1, logger.WarnLogs.Calls)
+}
+
+func TestSlowThresholdFromConfig(t *testing.T) {
+	require.Equal(t, defaultSlowThreshold, slowThresholdFromConfig(nil))
+
+	cfg := setting.NewCfg()
+	cfg.Raw = ini.Empty()
+	require.Equal(t, defaultSlowThreshold, slowThresholdFromConfig(cfg))
+
+	_, err := cfg.Raw.Section("entity_api").NewKey("slow_operation_threshold", "250ms")
+	require.NoError(t, err)
+	require.Equal(t, 250*time.Millisecond, slowThresholdFromConfig(cfg))
+}
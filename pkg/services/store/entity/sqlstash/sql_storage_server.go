@@ -18,6 +18,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/session"
 	"github.com/grafana/grafana/pkg/services/store"
 	"github.com/grafana/grafana/pkg/services/store/entity"
+	"github.com/grafana/grafana/pkg/services/store/entity/access"
+	"github.com/grafana/grafana/pkg/services/store/entity/retention"
 	"github.com/grafana/grafana/pkg/services/store/kind"
 	"github.com/grafana/grafana/pkg/services/store/resolver"
 	"github.com/grafana/grafana/pkg/setting"
@@ -29,26 +31,44 @@ var _ entity.EntityStoreAdminServer = &sqlEntityServer{}
 
 func ProvideSQLEntityServer(db db.DB, cfg *setting.Cfg, grpcServerProvider grpcserver.Provider, kinds kind.KindRegistry, resolver resolver.EntityReferenceResolver) entity.EntityStoreServer {
 	entityServer := &sqlEntityServer{
-		sess:     db.GetSqlxSession(),
-		log:      log.New("sql-entity-server"),
-		kinds:    kinds,
-		resolver: resolver,
+		sess:          db.GetSqlxSession(),
+		log:           log.New("sql-entity-server"),
+		kinds:         kinds,
+		resolver:      resolver,
+		slowThreshold: slowThresholdFromConfig(cfg),
 	}
 	entity.RegisterEntityStoreServer(grpcServerProvider.GetServer(), entityServer)
 	return entityServer
 }
 
+// defaultSlowThreshold is a duration after which store operations are logged as slow.
+const defaultSlowThreshold = time.Second
+
+// slowThresholdFromConfig reads entity_api.slow_operation_threshold, zero disables logging.
+func slowThresholdFromConfig(cfg *setting.Cfg) time.Duration {
+	if cfg == nil || cfg.Raw == nil {
+		return defaultSlowThreshold
+	}
+	return cfg.Raw.Section("entity_api").Key("slow_operation_threshold").MustDuration(defaultSlowThreshold)
+}
+
 type sqlEntityServer struct {
 	log      log.Logger
 	sess     *session.SessionDB
 	kinds    kind.KindRegistry
 	resolver resolver.EntityReferenceResolver
+
+	// slowThreshold of zero disables slow operation logging
+	slowThreshold time.Duration
+
+	// background migrations of stored bodies to current kind versions
+	migrations kindMigrations
 }
 
 func getReadSelect(r *entity.ReadEntityRequest) string {
 	fields := []string{
 		"tenant_id", "kind", "uid", "folder", // GRN + folder
-		"version", "size", "etag", "errors", // errors are always returned
+		"version", "kind_version", "size", "etag", "errors", // errors are always returned
 		"created_at", "created_by",
 		"updated_at", "updated_by",
 		"origin", "origin_key", "origin_ts"}
@@ -62,16 +82,18 @@ func getReadSelect(r *entity.ReadEntityRequest) string {
 	return "SELECT " + strings.Join(fields, ",") + " FROM entity WHERE "
 }
 
-func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql.Rows, r *entity.ReadEntityRequest) (*entity.Entity, error) {
+// rowToReadEntityResponse reads an entity row and the kind version its body is stored in
+func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql.Rows, r *entity.ReadEntityRequest) (*entity.Entity, string, error) {
 	raw := &entity.Entity{
 		GRN:    &grn.GRN{},
 		Origin: &entity.EntityOriginInfo{},
 	}
 
+	storedVersion := ""
 	summaryjson := &summarySupport{}
 	args := []any{
 		&raw.GRN.TenantID, &raw.GRN.ResourceKind, &raw.GRN.ResourceIdentifier, &raw.Folder,
-		&raw.Version, &raw.Size, &raw.ETag, &summaryjson.errors,
+		&raw.Version, &storedVersion, &raw.Size, &raw.ETag, &summaryjson.errors,
 		&raw.CreatedAt, &raw.CreatedBy,
 		&raw.UpdatedAt, &raw.UpdatedBy,
 		&raw.Origin.Source, &raw.Origin.Key, &raw.Origin.Time,
@@ -85,7 +107,7 @@ func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql
 
 	err := rows.Scan(args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	if raw.Origin.Source == "" {
@@ -95,16 +117,38 @@ func (s *sqlEntityServer) rowToReadEntityResponse(ctx context.Context, rows *sql
 	if r.WithSummary || summaryjson.errors != nil {
 		summary, err := summaryjson.toEntitySummary()
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		js, err := json.Marshal(summary)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		raw.SummaryJson = js
 	}
-	return raw, nil
+	return raw, storedVersion, nil
+}
+
+// convertVersion converts the body of a read entity from the version it was stored in to the
+// requested version, or to the current version of the kind when no version is requested.
+// The GRN of the entity is updated with the group and version of the returned body
+func (s *sqlEntityServer) convertVersion(ctx context.Context, raw *entity.Entity, storedVersion string, version string) error {
+	info, _ := s.kinds.GetInfo(raw.GRN.ResourceKind)
+	if version == "" {
+		version = info.Version
+	}
+	raw.GRN.ResourceGroup = info.Group
+	raw.GRN.ResourceVersion = version
+
+	if len(raw.Body) == 0 {
+		return nil
+	}
+	body, err := s.kinds.ConvertVersion(ctx, raw.GRN.ResourceKind, raw.GRN.ResourceIdentifier, raw.Body, storedVersion, version)
+	if err != nil {
+		return err
+	}
+	raw.Body = body
+	return nil
 }
 
 func (s *sqlEntityServer) validateGRN(ctx context.Context, grn *grn.GRN) (*grn.GRN, error) {
@@ -133,10 +177,21 @@ func (s *sqlEntityServer) validateGRN(ctx context.Context, grn *grn.GRN) (*grn.G
 	if strings.ContainsAny(grn.ResourceIdentifier, "/#$@?") {
 		return nil, fmt.Errorf("invalid character in GRN")
 	}
-	return grn, nil
+	if grn.ResourceGroup != "" {
+		info, err := s.kinds.GetInfo(grn.ResourceKind)
+		if err != nil || info.Group != grn.ResourceGroup {
+			return nil, fmt.Errorf("GRN group %q does not match kind %q", grn.ResourceGroup, grn.ResourceKind)
+		}
+	}
+
+	// Objects are stored by their unversioned GRN, the version only selects the body shape
+	return grn.Unversioned(), nil
 }
 
-func (s *sqlEntityServer) Read(ctx context.Context, r *entity.ReadEntityRequest) (*entity.Entity, error) {
+func (s *sqlEntityServer) Read(ctx context.Context, r *entity.ReadEntityRequest) (rsp *entity.Entity, err error) {
+	o := s.observe(ctx, opRead, kindOf(r.GRN), r.GRN)
+	defer func() { o.done(len(rsp.GetBody()), err) }()
+
 	if r.Version != "" {
 		return s.readFromHistory(ctx, r)
 	}
@@ -158,7 +213,14 @@ func (s *sqlEntityServer) Read(ctx context.Context, r *entity.ReadEntityRequest)
 		return &entity.Entity{}, nil
 	}
 
-	return s.rowToReadEntityResponse(ctx, rows, r)
+	raw, storedVersion, err := s.rowToReadEntityResponse(ctx, rows, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.convertVersion(ctx, raw, storedVersion, r.GRN.ResourceVersion); err != nil {
+		return nil, err
+	}
+	return raw, nil
 }
 
 func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEntityRequest) (*entity.Entity, error) {
@@ -169,7 +231,7 @@ func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEnt
 	oid := grn.ToGRNString()
 
 	fields := []string{
-		"body", "size", "etag",
+		"body", "kind_version", "size", "etag",
 		"updated_at", "updated_by",
 	}
 
@@ -187,9 +249,10 @@ func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEnt
 	}
 
 	raw := &entity.Entity{
-		GRN: r.GRN,
+		GRN: grn,
 	}
-	err = rows.Scan(&raw.Body, &raw.Size, &raw.ETag, &raw.UpdatedAt, &raw.UpdatedBy)
+	storedVersion := ""
+	err = rows.Scan(&raw.Body, &storedVersion, &raw.Size, &raw.ETag, &raw.UpdatedAt, &raw.UpdatedBy)
 	if err != nil {
 		return nil, err
 	}
@@ -218,10 +281,26 @@ func (s *sqlEntityServer) readFromHistory(ctx context.Context, r *entity.ReadEnt
 		raw.Body = nil
 	}
 
+	if err := s.convertVersion(ctx, raw, storedVersion, r.GRN.ResourceVersion); err != nil {
+		return nil, err
+	}
 	return raw, err
 }
 
-func (s *sqlEntityServer) BatchRead(ctx context.Context, b *entity.BatchReadEntityRequest) (*entity.BatchReadEntityResponse, error) {
+func (s *sqlEntityServer) BatchRead(ctx context.Context, b *entity.BatchReadEntityRequest) (rsp *entity.BatchReadEntityResponse, err error) {
+	grns := make([]*grn.GRN, 0, len(b.Batch))
+	for _, r := range b.Batch {
+		grns = append(grns, r.GRN)
+	}
+	o := s.observe(ctx, opBatchRead, kindOf(grns...), grns...)
+	defer func() {
+		size := 0
+		for _, r := range rsp.GetResults() {
+			size += len(r.Body)
+		}
+		o.done(size, err)
+	}()
+
 	if len(b.Batch) < 1 {
 		return nil, fmt.Errorf("missing querires")
 	}
@@ -229,6 +308,7 @@ func (s *sqlEntityServer) BatchRead(ctx context.Context, b *entity.BatchReadEnti
 	first := b.Batch[0]
 	args := []any{}
 	constraints := []string{}
+	versions := make(map[string]string, len(b.Batch))
 
 	for _, r := range b.Batch {
 		if r.WithBody != first.WithBody || r.WithSummary != first.WithSummary {
@@ -242,6 +322,7 @@ func (s *sqlEntityServer) BatchRead(ctx context.Context, b *entity.BatchReadEnti
 
 		where := "grn=?"
 		args = append(args, grn.ToGRNString())
+		versions[grn.ToGRNString()] = r.GRN.ResourceVersion
 		if r.Version != "" {
 			return nil, fmt.Errorf("version not supported for batch read (yet?)")
 		}
@@ -257,12 +338,15 @@ func (s *sqlEntityServer) BatchRead(ctx context.Context, b *entity.BatchReadEnti
 	defer func() { _ = rows.Close() }()
 
 	// TODO? make sure the results are in order?
-	rsp := &entity.BatchReadEntityResponse{}
+	rsp = &entity.BatchReadEntityResponse{}
 	for rows.Next() {
-		r, err := s.rowToReadEntityResponse(ctx, rows, req)
+		r, storedVersion, err := s.rowToReadEntityResponse(ctx, rows, req)
 		if err != nil {
 			return nil, err
 		}
+		if err := s.convertVersion(ctx, r, storedVersion, versions[r.GRN.ToGRNString()]); err != nil {
+			return nil, err
+		}
 		rsp.Results = append(rsp.Results, r)
 	}
 	return rsp, nil
@@ -273,7 +357,10 @@ func (s *sqlEntityServer) Write(ctx context.Context, r *entity.WriteEntityReques
 }
 
 //nolint:gocyclo
-func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEntityRequest) (*entity.WriteEntityResponse, error) {
+func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEntityRequest) (rsp *entity.WriteEntityResponse, err error) {
+	o := s.observe(ctx, opWrite, kindOf(r.GRN), r.GRN)
+	defer func() { o.done(len(r.Body), err) }()
+
 	grn, err := s.validateGRN(ctx, r.GRN)
 	if err != nil {
 		return nil, err
@@ -299,14 +386,27 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 		updatedAt = timestamp
 	}
 
-	summary, body, err := s.prepare(ctx, r)
+	// Bodies are stored in the current version of the kind
+	info, _ := s.kinds.GetInfo(grn.ResourceKind)
+	body := r.Body
+	if r.GRN.ResourceVersion != "" {
+		body, err = s.kinds.ConvertVersion(ctx, grn.ResourceKind, grn.ResourceIdentifier, body, r.GRN.ResourceVersion, info.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	summary, body, err := s.prepare(ctx, r, body)
 	if err != nil {
 		return nil, err
 	}
 
 	etag := createContentsHash(body)
-	rsp := &entity.WriteEntityResponse{
-		GRN:    grn,
+	written := grn.Unversioned()
+	written.ResourceGroup = info.Group
+	written.ResourceVersion = info.Version
+	rsp = &entity.WriteEntityResponse{
+		GRN:    written,
 		Status: entity.WriteEntityResponse_CREATED, // Will be changed if not true
 	}
 	origin := r.Origin
@@ -325,6 +425,9 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 					return err
 				}
 			}
+			if err := retention.Check(ctx, tx, grn, retention.ActionWrite); err != nil {
+				return err
+			}
 			_, err = doDelete(ctx, tx, grn)
 			if err != nil {
 				return err
@@ -344,6 +447,13 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 			return nil
 		}
 
+		// Entities under retention are write-once
+		if versionInfo.Version != "" && !r.ClearHistory {
+			if err := retention.Check(ctx, tx, grn, retention.ActionWrite); err != nil {
+				return err
+			}
+		}
+
 		// Optimistic locking
 		if r.PreviousVersion != "" {
 			if r.PreviousVersion != versionInfo.Version {
@@ -389,11 +499,11 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 		versionInfo.UpdatedBy = updatedBy
 		_, err = tx.Exec(ctx, `INSERT INTO entity_history (`+
 			"grn, version, message, "+
-			"size, body, etag, "+
+			"size, body, etag, kind_version, "+
 			"updated_at, updated_by) "+
-			"VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
 			oid, versionInfo.Version, versionInfo.Comment,
-			versionInfo.Size, body, versionInfo.ETag,
+			versionInfo.Size, body, versionInfo.ETag, info.Version,
 			updatedAt, versionInfo.UpdatedBy,
 		)
 		if err != nil {
@@ -405,13 +515,13 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 		if isUpdate {
 			rsp.Status = entity.WriteEntityResponse_UPDATED
 			_, err = tx.Exec(ctx, "UPDATE entity SET "+
-				"body=?, size=?, etag=?, version=?, "+
+				"body=?, size=?, etag=?, version=?, kind_version=?, "+
 				"updated_at=?, updated_by=?,"+
 				"name=?, description=?,"+
 				"labels=?, fields=?, errors=?, "+
 				"origin=?, origin_key=?, origin_ts=? "+
 				"WHERE grn=?",
-				body, versionInfo.Size, etag, versionInfo.Version,
+				body, versionInfo.Size, etag, versionInfo.Version, info.Version,
 				updatedAt, versionInfo.UpdatedBy,
 				summary.model.Name, summary.model.Description,
 				summary.labels, summary.fields, summary.errors,
@@ -428,24 +538,32 @@ func (s *sqlEntityServer) AdminWrite(ctx context.Context, r *entity.AdminWriteEn
 
 			_, err = tx.Exec(ctx, "INSERT INTO entity ("+
 				"grn, tenant_id, kind, uid, folder, "+
-				"size, body, etag, version, "+
+				"size, body, etag, version, kind_version, "+
 				"updated_at, updated_by, created_at, created_by, "+
 				"name, description, slug, "+
 				"labels, fields, errors, "+
 				"origin, origin_key, origin_ts) "+
 				"VALUES (?, ?, ?, ?, ?, "+
-				" ?, ?, ?, ?, "+
+				" ?, ?, ?, ?, ?, "+
 				" ?, ?, ?, ?, "+
 				" ?, ?, ?, "+
 				" ?, ?, ?, "+
 				" ?, ?, ?)",
 				oid, grn.TenantID, grn.ResourceKind, grn.ResourceIdentifier, r.Folder,
-				versionInfo.Size, body, etag, versionInfo.Version,
+				versionInfo.Size, body, etag, versionInfo.Version, info.Version,
 				updatedAt, createdBy, createdAt, createdBy,
 				summary.model.Name, summary.model.Description, summary.model.Slug,
 				summary.labels, summary.fields, summary.errors,
 				origin.Source, origin.Key, origin.Time,
 			)
+			if err == nil {
+				// New entities inherit default grants of the kind
+				_, err = tx.Exec(ctx, "INSERT INTO entity_access (grn, tenant_id, subject, verb, source) "+
+					"SELECT ?, tenant_id, subject, verb, ? FROM entity_kind_access "+
+					"WHERE tenant_id=? AND kind=? AND NOT EXISTS (SELECT 1 FROM entity_access WHERE grn=?)",
+					oid, access.SourceKind, grn.TenantID, grn.ResourceKind, oid,
+				)
+			}
 		}
 		if err == nil && entity.StandardKindFolder == r.GRN.ResourceKind {
 			err = updateFolderTree(ctx, tx, grn.TenantID)
@@ -597,13 +715,13 @@ func (s *sqlEntityServer) writeSearchInfo(
 	return nil
 }
 
-func (s *sqlEntityServer) prepare(ctx context.Context, r *entity.AdminWriteEntityRequest) (*summarySupport, []byte, error) {
+func (s *sqlEntityServer) prepare(ctx context.Context, r *entity.AdminWriteEntityRequest, body []byte) (*summarySupport, []byte, error) {
 	builder := s.kinds.GetSummaryBuilder(r.GRN.ResourceKind)
 	if builder == nil {
 		return nil, nil, fmt.Errorf("unsupported kind")
 	}
 
-	summary, body, err := builder(ctx, r.GRN.ResourceIdentifier, r.Body)
+	summary, body, err := builder(ctx, r.GRN.ResourceIdentifier, body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -625,16 +743,35 @@ func (s *sqlEntityServer) prepare(ctx context.Context, r *entity.AdminWriteEntit
 	return summaryjson, body, nil
 }
 
-func (s *sqlEntityServer) Delete(ctx context.Context, r *entity.DeleteEntityRequest) (*entity.DeleteEntityResponse, error) {
+func (s *sqlEntityServer) Delete(ctx context.Context, r *entity.DeleteEntityRequest) (rsp *entity.DeleteEntityResponse, err error) {
+	o := s.observe(ctx, opDelete, kindOf(r.GRN), r.GRN)
+	defer func() { o.done(0, err) }()
+
 	grn2, err := s.validateGRN(ctx, r.GRN)
 	if err != nil {
 		return nil, err
 	}
 
-	rsp := &entity.DeleteEntityResponse{}
+	rsp = &entity.DeleteEntityResponse{}
 	err = s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		if err := retention.Check(ctx, tx, grn2, retention.ActionDelete); err != nil {
+			return err
+		}
 		rsp.OK, err = doDelete(ctx, tx, grn2)
-		return err
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM entity_access WHERE grn=?", grn2.ToGRNString())
+		if err != nil {
+			return err
+		}
+		for _, table := range []string{"entity_comment", "entity_favorite", "entity_recent", "entity_owner", "entity_usage"} {
+			_, err = tx.Exec(ctx, "DELETE FROM "+table+" WHERE grn=?", grn2.ToGRNString())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 	return rsp, err
 }
@@ -674,7 +811,10 @@ func doDelete(ctx context.Context, tx *session.SessionTx, grn2 *grn.GRN) (bool,
 	return rows > 0, err
 }
 
-func (s *sqlEntityServer) History(ctx context.Context, r *entity.EntityHistoryRequest) (*entity.EntityHistoryResponse, error) {
+func (s *sqlEntityServer) History(ctx context.Context, r *entity.EntityHistoryRequest) (rsp *entity.EntityHistoryResponse, err error) {
+	o := s.observe(ctx, opHistory, kindOf(r.GRN), r.GRN)
+	defer func() { o.done(0, err) }()
+
 	grn2, err := s.validateGRN(ctx, r.GRN)
 	if err != nil {
 		return nil, err
@@ -699,7 +839,7 @@ func (s *sqlEntityServer) History(ctx context.Context, r *entity.EntityHistoryRe
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
-	rsp := &entity.EntityHistoryResponse{
+	rsp = &entity.EntityHistoryResponse{
 		GRN: r.GRN,
 	}
 	for rows.Next() {
@@ -713,7 +853,20 @@ func (s *sqlEntityServer) History(ctx context.Context, r *entity.EntityHistoryRe
 	return rsp, err
 }
 
-func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequest) (*entity.EntitySearchResponse, error) {
+func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequest) (rsp *entity.EntitySearchResponse, err error) {
+	searchKind := kindMixed
+	if len(r.Kind) == 1 {
+		searchKind = r.Kind[0]
+	}
+	o := s.observe(ctx, opSearch, searchKind)
+	defer func() {
+		size := 0
+		for _, r := range rsp.GetResults() {
+			size += len(r.Body)
+		}
+		o.done(size, err)
+	}()
+
 	user, err := appcontext.User(ctx)
 	if err != nil {
 		return nil, err
@@ -785,7 +938,7 @@ func (s *sqlEntityServer) Search(ctx context.Context, r *entity.EntitySearchRequ
 	}
 	defer func() { _ = rows.Close() }()
 	oid := ""
-	rsp := &entity.EntitySearchResponse{}
+	rsp = &entity.EntitySearchResponse{}
 	for rows.Next() {
 		result := &entity.EntitySearchResult{
 			GRN: &grn.GRN{},
@@ -0,0 +1,89 @@
+package usage
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+//-----------------------------------------------------------------------------------------------------
+// NOTE: entity usage insights are experimental, like the rest of the object store
+//-----------------------------------------------------------------------------------------------------
+
+const (
+	// DefaultSampleRate is a fraction of accesses written to the database, counts of sampled
+	// accesses are scaled up so they estimate all accesses.
+	DefaultSampleRate = 0.1
+	// RetentionDays is a number of days of usage kept and summarized.
+	RetentionDays = 90
+)
+
+// Access is a type of entity access counted by usage insights.
+type Access string
+
+const (
+	// AccessView is an entity read with its metadata, usually by a user opening it.
+	AccessView Access = "view"
+	// AccessQuery is a raw body read, e.g. a geojson layer or an image loaded by a panel.
+	AccessQuery Access = "query"
+)
+
+// Sort fields of search results by usage, prefixed with - for descending order.
+const (
+	SortViews      = "views"
+	SortQueries    = "queries"
+	SortLastAccess = "lastAccess"
+)
+
+// Summary is the usage of an entity over the last RetentionDays. Counts are estimated from
+// sampled accesses and LastAccessAt is the last sampled access, so rarely used entities may
+// show no usage.
+type Summary struct {
+	GRN          string `json:"grn" db:"grn"`
+	Kind         string `json:"kind" db:"kind"`
+	UID          string `json:"uid" db:"uid"`
+	Views        int64  `json:"views" db:"views"`
+	Queries      int64  `json:"queries" db:"queries"`
+	LastAccessAt int64  `json:"lastAccessAt" db:"last_access_at"`
+}
+
+// Day returns a start of the UTC day of a time in milliseconds, usage is aggregated by day.
+func Day(t time.Time) int64 {
+	return t.UTC().Truncate(24 * time.Hour).UnixMilli()
+}
+
+// Cutoff returns a start of the oldest day of usage summarized at a time.
+func Cutoff(now time.Time) int64 {
+	return Day(now.AddDate(0, 0, -(RetentionDays - 1)))
+}
+
+// Weight returns a count added for every sampled access.
+func Weight(sampleRate float64) int64 {
+	if sampleRate <= 0 || sampleRate >= 1 {
+		return 1
+	}
+	return int64(math.Round(1 / sampleRate))
+}
+
+// ParseSort returns a usage sort field and whether it is descending, ok is false for
+// fields which are not usage fields.
+func ParseSort(sort string) (field string, desc bool, ok bool) {
+	field = strings.TrimPrefix(sort, "-")
+	switch field {
+	case SortViews, SortQueries, SortLastAccess:
+		return field, field != sort, true
+	}
+	return "", false, false
+}
+
+// Less compares summaries by a usage sort field, unknown fields are compared by views.
+func Less(a, b Summary, field string) bool {
+	switch field {
+	case SortQueries:
+		return a.Queries < b.Queries
+	case SortLastAccess:
+		return a.LastAccessAt < b.LastAccessAt
+	default:
+		return a.Views < b.Views
+	}
+}
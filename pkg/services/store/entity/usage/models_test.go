@@ -0,0 +1,49 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDay(t *testing.T) {
+	day := time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, day.UnixMilli(), Day(day.Add(23*time.Hour)))
+	require.Equal(t, day.UnixMilli(), Day(time.Date(2023, 5, 10, 3, 0, 0, 0, time.FixedZone("ahead", 2*3600))))
+	require.Equal(t, day.AddDate(0, 0, -(RetentionDays-1)).UnixMilli(), Cutoff(day.Add(time.Hour)))
+}
+
+func TestWeight(t *testing.T) {
+	require.Equal(t, int64(10), Weight(0.1))
+	require.Equal(t, int64(3), Weight(0.3))
+	require.Equal(t, int64(1), Weight(1))
+	require.Equal(t, int64(1), Weight(0))
+}
+
+func TestParseSort(t *testing.T) {
+	field, desc, ok := ParseSort("-views")
+	require.True(t, ok)
+	require.True(t, desc)
+	require.Equal(t, SortViews, field)
+
+	field, desc, ok = ParseSort(SortLastAccess)
+	require.True(t, ok)
+	require.False(t, desc)
+	require.Equal(t, SortLastAccess, field)
+
+	_, _, ok = ParseSort("name")
+	require.False(t, ok)
+}
+
+func TestLess(t *testing.T) {
+	a := Summary{Views: 1, Queries: 5, LastAccessAt: 10}
+	b := Summary{Views: 2, Queries: 3, LastAccessAt: 20}
+	require.True(t, Less(a, b, SortViews))
+	require.False(t, Less(a, b, SortQueries))
+	require.True(t, Less(a, b, SortLastAccess))
+
+	// Unknown fields don't panic and are compared by views.
+	require.True(t, Less(a, b, "name"))
+	require.False(t, Less(b, a, ""))
+}
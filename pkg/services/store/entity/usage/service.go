@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/grn"
+	"github.com/grafana/grafana/pkg/services/sqlstore/session"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const summarySelect = "SELECT grn, kind, uid, SUM(views) AS views, SUM(queries) AS queries, MAX(last_access_at) AS last_access_at " +
+	"FROM entity_usage "
+
+// Service keeps sampled daily usage counts of entities next to the entity tables.
+type Service struct {
+	sess       *session.SessionDB
+	sampleRate float64
+	random     func() float64
+	now        func() time.Time
+}
+
+func ProvideService(db db.DB, cfg *setting.Cfg) *Service {
+	s := &Service{
+		sess:       db.GetSqlxSession(),
+		sampleRate: DefaultSampleRate,
+		random:     rand.Float64,
+		now:        time.Now,
+	}
+	if cfg != nil && cfg.Raw != nil {
+		s.sampleRate = cfg.Raw.Section("entity_api").Key("usage_sample_rate").MustFloat64(DefaultSampleRate)
+	}
+	return s
+}
+
+// Record counts an access to an entity when it is sampled. Days older than RetentionDays
+// are removed when the first access of a day is written.
+func (s *Service) Record(ctx context.Context, g *grn.GRN, access Access) error {
+	if s.random() >= s.sampleRate {
+		return nil
+	}
+	var views, queries int64
+	switch access {
+	case AccessView:
+		views = Weight(s.sampleRate)
+	case AccessQuery:
+		queries = Weight(s.sampleRate)
+	}
+	now := s.now()
+	key := g.ToGRNString()
+	return s.sess.WithTransaction(ctx, func(tx *session.SessionTx) error {
+		res, err := tx.Exec(ctx, "UPDATE entity_usage SET views=views+?, queries=queries+?, last_access_at=? WHERE grn=? AND day=?",
+			views, queries, now.UnixMilli(), key, Day(now))
+		if err != nil {
+			return err
+		}
+		if updated, err := res.RowsAffected(); err != nil || updated > 0 {
+			return err
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO entity_usage (grn, tenant_id, kind, uid, day, views, queries, last_access_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			key, g.TenantID, g.ResourceKind, g.ResourceIdentifier, Day(now), views, queries, now.UnixMilli())
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM entity_usage WHERE grn=? AND day<?", key, Cutoff(now))
+		return err
+	})
+}
+
+// Get returns usage of an entity, entities without sampled accesses have empty usage.
+func (s *Service) Get(ctx context.Context, g *grn.GRN) (Summary, error) {
+	var rows []Summary
+	err := s.sess.Select(ctx, &rows, summarySelect+"WHERE grn=? AND day>=? GROUP BY grn, kind, uid", g.ToGRNString(), Cutoff(s.now()))
+	if err != nil {
+		return Summary{}, err
+	}
+	if len(rows) == 0 {
+		return Summary{GRN: g.ToGRNString(), Kind: g.ResourceKind, UID: g.ResourceIdentifier}, nil
+	}
+	return rows[0], nil
+}
+
+// Summaries returns usage of all entities of a tenant with sampled accesses keyed by GRN.
+func (s *Service) Summaries(ctx context.Context, tenantID int64) (map[string]Summary, error) {
+	var rows []Summary
+	err := s.sess.Select(ctx, &rows, summarySelect+"WHERE tenant_id=? AND day>=? GROUP BY grn, kind, uid", tenantID, Cutoff(s.now()))
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[string]Summary, len(rows))
+	for _, row := range rows {
+		summaries[row.GRN] = row
+	}
+	return summaries, nil
+}
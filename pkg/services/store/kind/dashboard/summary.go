@@ -66,6 +66,14 @@ func NewStaticDashboardSummaryBuilder(lookup DatasourceLookup, sanitize bool) en
 		}
 		summary.Fields["schemaVersion"] = dash.SchemaVersion
 
+		texts := []string{dash.Title, dash.Description}
+		for _, panel := range dash.Panels {
+			texts = append(texts, panel.Title, panel.Description)
+		}
+		if lang := entity.DetectLanguage(texts...); lang != "" {
+			summary.Fields[entity.SummaryFieldLanguage] = lang
+		}
+
 		for _, panel := range dash.Panels {
 			panelRefs := NewReferenceAccumulator()
 			p := &entity.EntitySummary{
@@ -38,6 +38,9 @@ func GetEntitySummaryBuilder() entity.EntitySummaryBuilder {
 			Description: obj.Description,
 			UID:         uid,
 		}
+		if lang := entity.DetectLanguage(obj.Name, obj.Description); lang != "" {
+			summary.Fields = map[string]any{entity.SummaryFieldLanguage: lang}
+		}
 
 		out, err := json.MarshalIndent(obj, "", "  ")
 		return summary, out, err
@@ -40,6 +40,9 @@ func summaryBuilder(ctx context.Context, uid string, body []byte) (*entity.Entit
 		Name:        obj.Name,
 		Description: fmt.Sprintf("%d items, refreshed every %s", len(obj.Items), obj.Interval),
 	}
+	if lang := entity.DetectLanguage(obj.Name); lang != "" {
+		summary.Fields = map[string]any{entity.SummaryFieldLanguage: lang}
+	}
 
 	for _, item := range obj.Items {
 		switch item.Type {
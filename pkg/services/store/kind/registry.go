@@ -1,6 +1,7 @@
 package kind
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
@@ -17,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/store/kind/preferences"
 	"github.com/grafana/grafana/pkg/services/store/kind/snapshot"
 	"github.com/grafana/grafana/pkg/services/store/kind/svg"
+	"github.com/grafana/grafana/pkg/services/store/kind/zip"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -26,6 +28,9 @@ type KindRegistry interface {
 	GetInfo(kind string) (entity.EntityKindInfo, error)
 	GetFromExtension(suffix string) (entity.EntityKindInfo, error)
 	GetKinds() []entity.EntityKindInfo
+	RegisterVersionConverter(kind string, converter entity.EntityVersionConverter) error
+	RegisterMigration(kind string, fromVersion string, toVersion string, migration entity.EntityMigration) error
+	ConvertVersion(ctx context.Context, kind string, uid string, body []byte, fromVersion string, toVersion string) ([]byte, error)
 }
 
 func NewKindRegistry() KindRegistry {
@@ -73,6 +78,9 @@ func NewKindRegistry() KindRegistry {
 		kinds: kinds,
 	}
 	reg.updateInfoArray()
+
+	// ZIP bundles detect kinds of contained files from the registry
+	_ = reg.Register(zip.GetEntityKindInfo(), zip.GetEntitySummaryBuilder(zip.NewExtensionKindDetector(reg.GetFromExtension)))
 	return reg
 }
 
@@ -91,8 +99,17 @@ func ProvideService(cfg *setting.Cfg, renderer rendering.Service) KindRegistry {
 }
 
 type kindValues struct {
-	info    entity.EntityKindInfo
-	builder entity.EntitySummaryBuilder
+	info      entity.EntityKindInfo
+	builder   entity.EntitySummaryBuilder
+	converter entity.EntityVersionConverter
+
+	// migration steps by the version they upgrade from
+	migrations map[string]migrationStep
+}
+
+type migrationStep struct {
+	toVersion string
+	migration entity.EntityMigration
 }
 
 type registry struct {
@@ -185,3 +202,98 @@ func (r *registry) GetKinds() []entity.EntityKindInfo {
 
 	return r.info // returns a copy of the array
 }
+
+// RegisterVersionConverter sets a hook converting bodies of a registered kind between schema versions
+func (r *registry) RegisterVersionConverter(kind string, converter entity.EntityVersionConverter) error {
+	if converter == nil {
+		return fmt.Errorf("invalid converter")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	v, ok := r.kinds[kind]
+	if !ok {
+		return fmt.Errorf("not found")
+	}
+	v.converter = converter
+	return nil
+}
+
+// RegisterMigration adds a step upgrading bodies of a registered kind from one version to the next one.
+// Steps are chained, so bodies stored in any older version are upgraded to the current one
+func (r *registry) RegisterMigration(kind string, fromVersion string, toVersion string, migration entity.EntityMigration) error {
+	if migration == nil || toVersion == "" || fromVersion == toVersion {
+		return fmt.Errorf("invalid migration")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	v, ok := r.kinds[kind]
+	if !ok {
+		return fmt.Errorf("not found")
+	}
+	if _, ok := v.migrations[fromVersion]; ok {
+		return fmt.Errorf("migration from version %q already exists", fromVersion)
+	}
+	if v.migrations == nil {
+		v.migrations = make(map[string]migrationStep)
+	}
+	v.migrations[fromVersion] = migrationStep{toVersion: toVersion, migration: migration}
+	return nil
+}
+
+// ConvertVersion returns a body in toVersion, the body is returned unchanged when versions are equal.
+// Registered migrations are applied when they lead to toVersion, otherwise the converter is used
+func (r *registry) ConvertVersion(ctx context.Context, kind string, uid string, body []byte, fromVersion string, toVersion string) ([]byte, error) {
+	if fromVersion == toVersion {
+		return body, nil
+	}
+
+	r.mutex.RLock()
+	v, ok := r.kinds[kind]
+	var steps []migrationStep
+	var converter entity.EntityVersionConverter
+	if ok {
+		steps = v.migrationPath(fromVersion, toVersion)
+		converter = v.converter
+	}
+	r.mutex.RUnlock()
+
+	if steps != nil {
+		var err error
+		for _, step := range steps {
+			body, err = step.migration(ctx, uid, body)
+			if err != nil {
+				return nil, fmt.Errorf("error migrating %s/%s to version %q: %w", kind, uid, step.toVersion, err)
+			}
+		}
+		return body, nil
+	}
+
+	if converter == nil {
+		return nil, fmt.Errorf("kind %q can not be converted from version %q to %q", kind, fromVersion, toVersion)
+	}
+	return converter(ctx, uid, body, fromVersion, toVersion)
+}
+
+// migrationPath returns chained migration steps from one version to another one, or nil when
+// the registered steps do not lead there
+func (v *kindValues) migrationPath(fromVersion string, toVersion string) []migrationStep {
+	var steps []migrationStep
+	version := fromVersion
+	// every step is used at most once, so cycles stop the walk
+	for len(steps) < len(v.migrations) {
+		step, ok := v.migrations[version]
+		if !ok {
+			return nil
+		}
+		steps = append(steps, step)
+		if step.toVersion == toVersion {
+			return steps
+		}
+		version = step.toVersion
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package kind
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -29,6 +30,7 @@ func TestKindRegistry(t *testing.T) {
 		"preferences",
 		"snapshot",
 		"test",
+		"zip",
 	}, ids)
 
 	// Check playlist exists
@@ -49,3 +51,70 @@ func TestKindRegistry(t *testing.T) {
 	require.Equal(t, "PNG", info.Name)
 	require.True(t, info.IsRaw)
 }
+
+func TestKindRegistry_ConvertVersion(t *testing.T) {
+	registry := NewKindRegistry()
+	info := dummy.GetEntityKindInfo("test")
+	info.Group = "test.grafana.app"
+	info.Version = "v2"
+	err := registry.Register(info, dummy.GetEntitySummaryBuilder("test"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// Same version does not need a converter
+	body, err := registry.ConvertVersion(ctx, "test", "a", []byte("v1"), "v1", "v1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v1"), body)
+
+	_, err = registry.ConvertVersion(ctx, "test", "a", []byte("v1"), "v1", "v2")
+	require.Error(t, err)
+
+	err = registry.RegisterVersionConverter("unknown", func(ctx context.Context, uid string, body []byte, fromVersion string, toVersion string) ([]byte, error) {
+		return body, nil
+	})
+	require.Error(t, err)
+
+	err = registry.RegisterVersionConverter("test", func(ctx context.Context, uid string, body []byte, fromVersion string, toVersion string) ([]byte, error) {
+		return []byte(toVersion), nil
+	})
+	require.NoError(t, err)
+
+	body, err = registry.ConvertVersion(ctx, "test", "a", []byte("v1"), "v1", "v2")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), body)
+}
+
+func TestKindRegistry_Migrations(t *testing.T) {
+	registry := NewKindRegistry()
+	info := dummy.GetEntityKindInfo("test")
+	info.Version = "v3"
+	err := registry.Register(info, dummy.GetEntitySummaryBuilder("test"))
+	require.NoError(t, err)
+
+	appendVersion := func(version string) entity.EntityMigration {
+		return func(ctx context.Context, uid string, body []byte) ([]byte, error) {
+			return append(body, []byte(","+version)...), nil
+		}
+	}
+	require.NoError(t, registry.RegisterMigration("test", "", "v1", appendVersion("v1")))
+	require.NoError(t, registry.RegisterMigration("test", "v1", "v2", appendVersion("v2")))
+	require.NoError(t, registry.RegisterMigration("test", "v2", "v3", appendVersion("v3")))
+	require.Error(t, registry.RegisterMigration("test", "v1", "v3", appendVersion("v3")))
+	require.Error(t, registry.RegisterMigration("unknown", "v1", "v2", appendVersion("v2")))
+
+	ctx := context.Background()
+
+	// Bodies stored before the kind was versioned run every step
+	body, err := registry.ConvertVersion(ctx, "test", "a", []byte("v0"), "", "v3")
+	require.NoError(t, err)
+	require.Equal(t, "v0,v1,v2,v3", string(body))
+
+	body, err = registry.ConvertVersion(ctx, "test", "a", []byte("v2"), "v2", "v3")
+	require.NoError(t, err)
+	require.Equal(t, "v2,v3", string(body))
+
+	// Downgrades need a converter
+	_, err = registry.ConvertVersion(ctx, "test", "a", []byte("v3"), "v3", "v1")
+	require.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/store"
+	"github.com/grafana/grafana/pkg/services/store/entity"
+)
+
+const (
+	// MaxFiles limits the number of files in a bundle
+	MaxFiles = 1000
+	// MaxUnpackedSize limits the total uncompressed size of a bundle
+	MaxUnpackedSize = 50 * 1024 * 1024 // 50MB
+)
+
+func GetEntityKindInfo() entity.EntityKindInfo {
+	return entity.EntityKindInfo{
+		ID:            entity.StandardKindZip,
+		Name:          "ZIP",
+		Description:   "ZIP bundle with mixed assets",
+		IsRaw:         true,
+		FileExtension: "zip",
+		MimeType:      "application/zip",
+	}
+}
+
+// OriginSource is the origin source of entities unpacked from a bundle, the origin key is the bundle GRN
+const OriginSource = "zip"
+
+// KindDetector returns a kind for a file path, or an empty string when the kind is not known
+type KindDetector = func(filePath string) string
+
+// NewExtensionKindDetector detects kinds from file extensions using a kind registry lookup
+func NewExtensionKindDetector(fromExtension func(suffix string) (entity.EntityKindInfo, error)) KindDetector {
+	return func(filePath string) string {
+		ext := strings.ToLower(path.Ext(filePath))
+		if ext == "" {
+			return ""
+		}
+		// Extensions are registered both with and without the dot
+		info, err := fromExtension(ext[1:])
+		if err != nil {
+			info, err = fromExtension(ext)
+		}
+		if err != nil {
+			return ""
+		}
+		return info.ID
+	}
+}
+
+// File is a single file in a bundle
+type File struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Kind string `json:"kind,omitempty"`
+	Body []byte `json:"-"`
+}
+
+// GetEntitySummaryBuilder lists files in the bundle with sizes and detected kinds
+func GetEntitySummaryBuilder(detect KindDetector) entity.EntitySummaryBuilder {
+	return func(ctx context.Context, uid string, body []byte) (*entity.EntitySummary, []byte, error) {
+		files, err := ReadFiles(body, detect, false)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		size := int64(0)
+		kinds := make(map[string]int64)
+		for _, f := range files {
+			size += f.Size
+			if f.Kind != "" {
+				kinds[f.Kind]++
+			}
+		}
+
+		summary := &entity.EntitySummary{
+			Kind: entity.StandardKindZip,
+			Name: store.GuessNameFromUID(uid),
+			UID:  uid,
+			Fields: map[string]any{
+				"count": int64(len(files)),
+				"size":  size,
+				"files": files,
+				"kinds": kinds,
+			},
+		}
+		return summary, body, nil
+	}
+}
+
+// ReadFiles reads all files of a bundle ordered by path, directories are skipped
+func ReadFiles(body []byte, detect KindDetector, withBody bool) ([]File, error) {
+	reader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(reader.File))
+	total := int64(0)
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if len(files) >= MaxFiles {
+			return nil, fmt.Errorf("too many files in bundle (>%d)", MaxFiles)
+		}
+		if strings.HasPrefix(f.Name, "/") || strings.Contains(f.Name, "..") {
+			return nil, fmt.Errorf("invalid file name in bundle: %s", f.Name)
+		}
+
+		file := File{Path: f.Name}
+		if detect != nil {
+			file.Kind = detect(f.Name)
+		}
+
+		// The declared size can not be trusted, so count what is actually read
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		var w io.Writer = io.Discard
+		var buf bytes.Buffer
+		if withBody {
+			w = &buf
+		}
+		n, err := io.Copy(w, io.LimitReader(rc, MaxUnpackedSize-total+1))
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		total += n
+		if total > MaxUnpackedSize {
+			return nil, fmt.Errorf("bundle is too large when unpacked (>%d bytes)", MaxUnpackedSize)
+		}
+		file.Size = n
+		if withBody {
+			file.Body = buf.Bytes()
+		}
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// UnpackedUID returns the UID of an entity unpacked from a bundle
+func UnpackedUID(bundleUID string, filePath string) string {
+	name := strings.TrimSuffix(filePath, path.Ext(filePath))
+	return bundleUID + "-" + strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '#', '$', '@', '?':
+			return '-'
+		}
+		return r
+	}, name)
+}
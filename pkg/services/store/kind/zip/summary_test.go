@@ -0,0 +1,83 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createBundle(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	for name, body := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestZipSummary(t *testing.T) {
+	body := createBundle(t, map[string]string{
+		"dash.json":          `{"title":"hello"}`,
+		"maps/world.geojson": `{"type":"FeatureCollection"}`,
+		"README.md":          "hello",
+	})
+	detect := func(filePath string) string {
+		switch path.Ext(filePath) {
+		case ".json":
+			return "dashboard"
+		case ".geojson":
+			return "geojson"
+		}
+		return ""
+	}
+
+	_, _, err := GetEntitySummaryBuilder(detect)(context.Background(), "hello", []byte("not a zip"))
+	require.Error(t, err)
+
+	summary, out, err := GetEntitySummaryBuilder(detect)(context.Background(), "hello", body)
+	require.NoError(t, err)
+	require.Equal(t, body, out)
+
+	asjson, err := json.Marshal(summary)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"uid": "hello",
+		"kind": "zip",
+		"name": "hello",
+		"fields": {
+			"count": 3,
+			"size": 50,
+			"kinds": {"dashboard": 1, "geojson": 1},
+			"files": [
+				{"path": "README.md", "size": 5},
+				{"path": "dash.json", "size": 17, "kind": "dashboard"},
+				{"path": "maps/world.geojson", "size": 28, "kind": "geojson"}
+			]
+		}
+	}`, string(asjson))
+}
+
+func TestReadFiles(t *testing.T) {
+	body := createBundle(t, map[string]string{"a/b.txt": "hello"})
+	files, err := ReadFiles(body, nil, true)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, []byte("hello"), files[0].Body)
+
+	_, err = ReadFiles(createBundle(t, map[string]string{"../x.txt": "x"}), nil, false)
+	require.Error(t, err)
+}
+
+func TestUnpackedUID(t *testing.T) {
+	require.Equal(t, "bundle-maps-world", UnpackedUID("bundle", "maps/world.geojson"))
+}
@@ -482,6 +482,45 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveManagedStreamHistoryEnabled keeps history of managed stream frames in memory,
+	// Redis in HA mode and the entity store.
+	LiveManagedStreamHistoryEnabled      bool
+	LiveManagedStreamHistoryMemoryFrames int
+	LiveManagedStreamHistoryRedisMaxAge  time.Duration
+	LiveManagedStreamHistoryChunkFrames  int
+	// LiveManagedStreamIdleCleanupAfter removes managed stream channels without frames
+	// for the duration, 0 keeps channels forever.
+	LiveManagedStreamIdleCleanupAfter    time.Duration
+	LiveManagedStreamIdleCleanupInterval time.Duration
+	// LivePipelineEnabled enables processing of Live data by channel rules.
+	LivePipelineEnabled bool
+	// LiveChannelRulesFile is a path of a file with channel rules and write configs,
+	// rules are loaded from the database when it's empty.
+	LiveChannelRulesFile string
+	// LivePipelineRuleMatch is a mode of matching channel rules, first or all.
+	LivePipelineRuleMatch            string
+	LivePipelineDebugBufferSize      int
+	LivePipelineDeadLetterMaxEntries int
+	LivePipelineDeadLetterMaxBytes   int
+	LivePipelineDeadLetterMaxAge     time.Duration
+	// LivePipelineMessageTraceEnabled records pipeline events of messages with IDs.
+	LivePipelineMessageTraceEnabled     bool
+	LivePipelineMessageTraceRetention   time.Duration
+	LivePipelineMessageTraceMaxMessages int
+	// LivePipelineMetricsStreamEnabled publishes channel rule stats into a Live channel.
+	LivePipelineMetricsStreamEnabled  bool
+	LivePipelineMetricsStreamInterval time.Duration
+	// LivePipelineMaxInFlight limits a number of concurrently processed pipeline inputs,
+	// 0 means unlimited.
+	LivePipelineMaxInFlight int
+	// LivePipelineMaxDecompressedSize limits a size of decompressed pipeline inputs,
+	// 0 means a default limit.
+	LivePipelineMaxDecompressedSize int64
+	LivePipelineGeneratorsEnabled   bool
+	LivePipelineSnapshotsEnabled    bool
+	LivePipelineShutdownTimeout     time.Duration
+	// LivePipelineFileOutputDir is a directory pipeline file outputs write into.
+	LivePipelineFileOutputDir string
 
 	// GitHub OAuth
 	GitHubAuthEnabled     bool
@@ -2000,5 +2039,31 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return err
 	}
 	cfg.LiveAllowedOrigins = originPatterns
+
+	cfg.LiveManagedStreamHistoryEnabled = section.Key("managed_stream_history_enabled").MustBool(false)
+	cfg.LiveManagedStreamHistoryMemoryFrames = section.Key("managed_stream_history_memory_frames").MustInt(100)
+	cfg.LiveManagedStreamHistoryRedisMaxAge = section.Key("managed_stream_history_redis_max_age").MustDuration(time.Hour)
+	cfg.LiveManagedStreamHistoryChunkFrames = section.Key("managed_stream_history_chunk_frames").MustInt(1000)
+	cfg.LiveManagedStreamIdleCleanupAfter = section.Key("managed_stream_idle_cleanup_after").MustDuration(0)
+	cfg.LiveManagedStreamIdleCleanupInterval = section.Key("managed_stream_idle_cleanup_interval").MustDuration(time.Hour)
+
+	cfg.LivePipelineEnabled = section.Key("pipeline_enabled").MustBool(false)
+	cfg.LiveChannelRulesFile = section.Key("channel_rules_file").MustString("")
+	cfg.LivePipelineRuleMatch = section.Key("pipeline_rule_match").MustString("")
+	cfg.LivePipelineDebugBufferSize = section.Key("pipeline_debug_buffer_size").MustInt(0)
+	cfg.LivePipelineDeadLetterMaxEntries = section.Key("pipeline_dead_letter_max_entries").MustInt(0)
+	cfg.LivePipelineDeadLetterMaxBytes = section.Key("pipeline_dead_letter_max_bytes").MustInt(0)
+	cfg.LivePipelineDeadLetterMaxAge = section.Key("pipeline_dead_letter_max_age").MustDuration(0)
+	cfg.LivePipelineMessageTraceEnabled = section.Key("pipeline_message_trace_enabled").MustBool(false)
+	cfg.LivePipelineMessageTraceRetention = section.Key("pipeline_message_trace_retention").MustDuration(0)
+	cfg.LivePipelineMessageTraceMaxMessages = section.Key("pipeline_message_trace_max_messages").MustInt(0)
+	cfg.LivePipelineMetricsStreamEnabled = section.Key("pipeline_metrics_stream_enabled").MustBool(false)
+	cfg.LivePipelineMetricsStreamInterval = section.Key("pipeline_metrics_stream_interval").MustDuration(10 * time.Second)
+	cfg.LivePipelineMaxInFlight = section.Key("pipeline_max_in_flight").MustInt(0)
+	cfg.LivePipelineMaxDecompressedSize = section.Key("pipeline_max_decompressed_size").MustInt64(0)
+	cfg.LivePipelineGeneratorsEnabled = section.Key("pipeline_generators_enabled").MustBool(false)
+	cfg.LivePipelineSnapshotsEnabled = section.Key("pipeline_snapshots_enabled").MustBool(false)
+	cfg.LivePipelineShutdownTimeout = section.Key("pipeline_shutdown_timeout").MustDuration(10 * time.Second)
+	cfg.LivePipelineFileOutputDir = section.Key("pipeline_file_output_dir").MustString(filepath.Join(cfg.DataPath, "live", "files"))
 	return nil
 }